@@ -524,6 +524,13 @@ func (in *YurtAppSetStatus) DeepCopyInto(out *YurtAppSetStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.PoolReadyReplicas != nil {
+		in, out := &in.PoolReadyReplicas, &out.PoolReadyReplicas
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new YurtAppSetStatus.
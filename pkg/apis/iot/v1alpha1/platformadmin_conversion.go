@@ -17,7 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
@@ -25,19 +28,31 @@ import (
 	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
 )
 
+// annotationV1alpha2Spec keeps the spec of v1alpha2 which can not be represented by v1alpha1,
+// so reading a v1alpha2 object as v1alpha1 and writing it back does not lose anything.
+const annotationV1alpha2Spec = "iot.openyurt.io/v1alpha2-spec"
+
 func (src *PlatformAdmin) ConvertTo(dstRaw conversion.Hub) error {
 	// Transform metadata
 	dst := dstRaw.(*v1alpha2.PlatformAdmin)
-	dst.ObjectMeta = src.ObjectMeta
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
 	dst.TypeMeta = src.TypeMeta
 	dst.TypeMeta.APIVersion = "iot.openyurt.io/v1alpha2"
 
 	// Transform spec
+	dst.Spec = v1alpha2.PlatformAdminSpec{}
+	if data, ok := src.Annotations[annotationV1alpha2Spec]; ok {
+		if err := strictUnmarshal(data, &dst.Spec); err != nil {
+			return fmt.Errorf("invalid annotation %s, %w", annotationV1alpha2Spec, err)
+		}
+		delete(dst.Annotations, annotationV1alpha2Spec)
+	} else {
+		dst.Spec.Security = false
+		dst.Spec.Platform = v1alpha2.PlatformAdminPlatformEdgeX
+	}
 	dst.Spec.Version = src.Spec.Version
-	dst.Spec.Security = false
 	dst.Spec.ImageRegistry = src.Spec.ImageRegistry
 	dst.Spec.PoolName = src.Spec.PoolName
-	dst.Spec.Platform = v1alpha2.PlatformAdminPlatformEdgeX
 
 	// Transform status
 	dst.Status.Ready = src.Status.Ready
@@ -46,13 +61,20 @@ func (src *PlatformAdmin) ConvertTo(dstRaw conversion.Hub) error {
 	dst.Status.UnreadyComponentNum = src.Status.DeploymentReplicas - src.Status.DeploymentReadyReplicas
 	dst.Status.Conditions = transToV2Condition(src.Status.Conditions)
 
+	// The fields of v1alpha1 are authoritative, the stale annotations are removed
+	delete(dst.Annotations, AnnotationAdditionalDeployments)
+	delete(dst.Annotations, AnnotationAdditionalServices)
+
 	// Transform additionaldeployment
 	if len(src.Spec.AdditionalDeployment) > 0 {
 		additionalDeployment, err := json.Marshal(src.Spec.AdditionalDeployment)
 		if err != nil {
 			return err
 		}
-		dst.ObjectMeta.Annotations["AdditionalDeployments"] = string(additionalDeployment)
+		if dst.ObjectMeta.Annotations == nil {
+			dst.ObjectMeta.Annotations = make(map[string]string)
+		}
+		dst.ObjectMeta.Annotations[AnnotationAdditionalDeployments] = string(additionalDeployment)
 	}
 
 	// Transform additionalservice
@@ -61,10 +83,14 @@ func (src *PlatformAdmin) ConvertTo(dstRaw conversion.Hub) error {
 		if err != nil {
 			return err
 		}
-		dst.ObjectMeta.Annotations["AdditionalServices"] = string(additionalService)
+		if dst.ObjectMeta.Annotations == nil {
+			dst.ObjectMeta.Annotations = make(map[string]string)
+		}
+		dst.ObjectMeta.Annotations[AnnotationAdditionalServices] = string(additionalService)
+	}
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
 	}
-
-	//TODO: Components
 
 	return nil
 }
@@ -72,11 +98,12 @@ func (src *PlatformAdmin) ConvertTo(dstRaw conversion.Hub) error {
 func (dst *PlatformAdmin) ConvertFrom(srcRaw conversion.Hub) error {
 	// Transform metadata
 	src := srcRaw.(*v1alpha2.PlatformAdmin)
-	dst.ObjectMeta = src.ObjectMeta
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
 	dst.TypeMeta = src.TypeMeta
 	dst.TypeMeta.APIVersion = "iot.openyurt.io/v1alpha1"
 
 	// Transform spec
+	dst.Spec = PlatformAdminSpec{}
 	dst.Spec.Version = src.Spec.Version
 	dst.Spec.ImageRegistry = src.Spec.ImageRegistry
 	dst.Spec.PoolName = src.Spec.PoolName
@@ -92,28 +119,80 @@ func (dst *PlatformAdmin) ConvertFrom(srcRaw conversion.Hub) error {
 	dst.Status.Conditions = transToV1Condition(src.Status.Conditions)
 
 	// Transform additionaldeployment
-	if _, ok := src.ObjectMeta.Annotations["AdditionalDeployments"]; ok {
-		var additionalDeployments []DeploymentTemplateSpec = make([]DeploymentTemplateSpec, 0)
-		err := json.Unmarshal([]byte(src.ObjectMeta.Annotations["AdditionalDeployments"]), &additionalDeployments)
+	if data, ok := src.ObjectMeta.Annotations[AnnotationAdditionalDeployments]; ok {
+		additionalDeployments, err := DecodeAdditionalDeployments(data)
 		if err != nil {
 			return err
 		}
 		dst.Spec.AdditionalDeployment = additionalDeployments
+		delete(dst.Annotations, AnnotationAdditionalDeployments)
 	}
 
 	// Transform additionalservice
-	if _, ok := src.ObjectMeta.Annotations["AdditionalServices"]; ok {
-		var additionalServices []ServiceTemplateSpec = make([]ServiceTemplateSpec, 0)
-		err := json.Unmarshal([]byte(src.ObjectMeta.Annotations["AdditionalServices"]), &additionalServices)
+	if data, ok := src.ObjectMeta.Annotations[AnnotationAdditionalServices]; ok {
+		additionalServices, err := DecodeAdditionalServices(data)
 		if err != nil {
 			return err
 		}
 		dst.Spec.AdditionalService = additionalServices
+		delete(dst.Annotations, AnnotationAdditionalServices)
+	}
+
+	// Keep the spec which is only supported by v1alpha2
+	if !reflect.DeepEqual(src.Spec, v1alpha2.PlatformAdminSpec{
+		Version:       src.Spec.Version,
+		ImageRegistry: src.Spec.ImageRegistry,
+		PoolName:      src.Spec.PoolName,
+		Platform:      v1alpha2.PlatformAdminPlatformEdgeX,
+	}) {
+		data, err := json.Marshal(src.Spec)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = make(map[string]string)
+		}
+		dst.Annotations[annotationV1alpha2Spec] = string(data)
+	}
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
 	}
 
 	return nil
 }
 
+// DecodeAdditionalDeployments decodes the additional deployments from the annotation of v1alpha2,
+// unknown fields are rejected, so malformed payloads are found at conversion time.
+func DecodeAdditionalDeployments(data string) ([]DeploymentTemplateSpec, error) {
+	additionalDeployments := make([]DeploymentTemplateSpec, 0)
+	if err := strictUnmarshal(data, &additionalDeployments); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s, %w", AnnotationAdditionalDeployments, err)
+	}
+	return additionalDeployments, nil
+}
+
+// DecodeAdditionalServices decodes the additional services from the annotation of v1alpha2,
+// unknown fields are rejected, so malformed payloads are found at conversion time.
+func DecodeAdditionalServices(data string) ([]ServiceTemplateSpec, error) {
+	additionalServices := make([]ServiceTemplateSpec, 0)
+	if err := strictUnmarshal(data, &additionalServices); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s, %w", AnnotationAdditionalServices, err)
+	}
+	return additionalServices, nil
+}
+
+func strictUnmarshal(data string, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after the json value")
+	}
+	return nil
+}
+
 func transToV1Condition(c2 []v1alpha2.PlatformAdminCondition) (c1 []PlatformAdminCondition) {
 	for _, ic := range c2 {
 		c1 = append(c1, PlatformAdminCondition{
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/rand"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+const fuzzIterations = 200
+
+// newFuzzer generates objects which survive the json round trip, the types with custom json
+// encoding are filled with valid values, and slices and maps are either nil or non-empty.
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.New().NilChance(0.5).NumElements(1, 2).RandSource(rand.NewSource(seed)).Funcs(
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1000), resource.DecimalSI)
+		},
+		func(i *intstr.IntOrString, c fuzz.Continue) {
+			if c.RandBool() {
+				*i = intstr.FromInt(c.Intn(1000))
+			} else {
+				*i = intstr.FromString(c.RandString())
+			}
+		},
+		func(t *metav1.Time, c fuzz.Continue) {
+			*t = metav1.Unix(c.Int63n(1000000000), 0)
+		},
+		func(f *metav1.ManagedFieldsEntry, c fuzz.Continue) {
+			c.Fuzz(&f.Manager)
+		},
+	)
+}
+
+func TestPlatformAdminRoundTripFromV1alpha1(t *testing.T) {
+	for i := 0; i < fuzzIterations; i++ {
+		f := newFuzzer(int64(i))
+		src := &PlatformAdmin{}
+		f.Fuzz(&src.ObjectMeta)
+		f.Fuzz(&src.Spec)
+		// v1alpha2 always serves components with ClusterIP services
+		src.Spec.ServiceType = corev1.ServiceTypeClusterIP
+
+		hub := &v1alpha2.PlatformAdmin{}
+		if err := src.ConvertTo(hub); err != nil {
+			t.Fatalf("failed to convert to v1alpha2, %v", err)
+		}
+		dst := &PlatformAdmin{}
+		if err := dst.ConvertFrom(hub); err != nil {
+			t.Fatalf("failed to convert from v1alpha2, %v", err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(src.Spec, dst.Spec) {
+			t.Errorf("expect spec %+v after round trip, but got %+v", src.Spec, dst.Spec)
+		}
+		if !apiequality.Semantic.DeepEqual(src.ObjectMeta, dst.ObjectMeta) {
+			t.Errorf("expect metadata %+v after round trip, but got %+v", src.ObjectMeta, dst.ObjectMeta)
+		}
+	}
+}
+
+func TestPlatformAdminRoundTripFromV1alpha2(t *testing.T) {
+	for i := 0; i < fuzzIterations; i++ {
+		f := newFuzzer(int64(i))
+		src := &v1alpha2.PlatformAdmin{}
+		f.Fuzz(&src.Spec)
+
+		spoke := &PlatformAdmin{}
+		if err := spoke.ConvertFrom(src); err != nil {
+			t.Fatalf("failed to convert from v1alpha2, %v", err)
+		}
+		dst := &v1alpha2.PlatformAdmin{}
+		if err := spoke.ConvertTo(dst); err != nil {
+			t.Fatalf("failed to convert to v1alpha2, %v", err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(src.Spec, dst.Spec) {
+			t.Errorf("expect spec %+v after round trip, but got %+v", src.Spec, dst.Spec)
+		}
+		if len(dst.Annotations) != 0 {
+			t.Errorf("expect no annotations after round trip, but got %v", dst.Annotations)
+		}
+	}
+}
+
+func TestPlatformAdminConversionRejectsMalformedAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{
+			name:        "invalid json",
+			annotations: map[string]string{AnnotationAdditionalDeployments: `[{"metadata":`},
+		},
+		{
+			name:        "unknown field",
+			annotations: map[string]string{AnnotationAdditionalServices: `[{"metadata":{"name":"svc"},"specs":{}}]`},
+		},
+		{
+			name:        "trailing data",
+			annotations: map[string]string{AnnotationAdditionalServices: `[] []`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &v1alpha2.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex", Annotations: tt.annotations},
+			}
+			if err := (&PlatformAdmin{}).ConvertFrom(src); err == nil {
+				t.Errorf("expect conversion error for annotations %v, but got nil", tt.annotations)
+			}
+		})
+	}
+}
@@ -27,6 +27,11 @@ const (
 	EdgexFinalizer = "edgex.edgexfoundry.org"
 
 	LabelEdgeXGenerate = "www.edgexfoundry.org/generate"
+
+	// AnnotationAdditionalDeployments and AnnotationAdditionalServices carry the additional deployments and
+	// services of v1alpha1 in the annotations of v1alpha2, since v1alpha2 has no such fields.
+	AnnotationAdditionalDeployments = "AdditionalDeployments"
+	AnnotationAdditionalServices    = "AdditionalServices"
 )
 
 // PlatformAdminConditionType indicates valid conditions type of a iot platform.
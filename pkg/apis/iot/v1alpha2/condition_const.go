@@ -17,16 +17,87 @@ limitations under the License.
 package v1alpha2
 
 const (
+	// ReadyCondition summarizes the conditions which PlatformAdmin is ready with, the reason is the one
+	// of the first condition which is not true. It has the same shape as metav1.Condition.
+	ReadyCondition PlatformAdminConditionType = "Ready"
+
+	ReadyReason = "Ready"
+
+	ReadyUnknownReason = "Reconciling"
 	// ConfigmapAvailableCondition documents the status of the PlatformAdmin configmap.
 	ConfigmapAvailableCondition PlatformAdminConditionType = "ConfigmapAvailable"
 
 	ConfigmapProvisioningReason = "ConfigmapProvisioning"
 
 	ConfigmapProvisioningFailedReason = "ConfigmapProvisioningFailed"
+	// ExternallyManagedReason documents that the configmaps are managed by the users instead of the controller.
+	ExternallyManagedReason = "ExternallyManaged"
+	// SecretAvailableCondition documents the status of the PlatformAdmin secrets in security mode.
+	SecretAvailableCondition PlatformAdminConditionType = "SecretAvailable"
+
+	SecretProvisioningReason = "SecretProvisioning"
+
+	SecretProvisioningFailedReason = "SecretProvisioningFailed"
 	// ComponentAvailableCondition documents the status of the PlatformAdmin component.
 	ComponentAvailableCondition PlatformAdminConditionType = "ComponentAvailable"
 
 	ComponentProvisioningReason = "ComponentProvisioning"
 
 	ComponentProvisioningFailedReason = "ComponentProvisioningFailed"
+
+	DeploymentNotReadyReason = "DeploymentNotReady"
+
+	EndpointsNotReadyReason = "EndpointsNotReady"
+
+	ComponentRejectedReason = "ComponentRejected"
+
+	DependencyMissingReason = "DependencyMissing"
+
+	ComponentSpecInvalidReason = "ComponentSpecInvalid"
+
+	AdditionalComponentsInvalidReason = "AdditionalComponentsInvalid"
+	// ComponentConflictCondition documents the existing objects which are not generated by PlatformAdmin and can not be adopted.
+	ComponentConflictCondition PlatformAdminConditionType = "ComponentConflict"
+
+	ComponentConflictReason = "ComponentConflict"
+	// RBACDeniedReason, QuotaExceededReason and NamespaceTerminatingReason document the objects of PlatformAdmin
+	// which are denied by the RBAC rules of yurt-manager, a resource quota or the terminating namespace.
+	RBACDeniedReason = "RBACDenied"
+
+	QuotaExceededReason = "QuotaExceeded"
+
+	NamespaceTerminatingReason = "NamespaceTerminating"
+	// PausedCondition documents that the objects of PlatformAdmin are not managed by the controller.
+	PausedCondition PlatformAdminConditionType = "Paused"
+
+	PausedReason = "Paused"
+	// UnknownComponentCondition documents the components referenced by the spec of PlatformAdmin which do not exist.
+	UnknownComponentCondition PlatformAdminConditionType = "UnknownComponent"
+
+	UnknownComponentReason = "UnknownComponent"
+	// ArchUnsupportedCondition documents the components whose supported architectures match none of the nodes in the
+	// pool of PlatformAdmin, they are not deployed since their pods can not run there.
+	ArchUnsupportedCondition PlatformAdminConditionType = "ArchUnsupported"
+
+	ArchUnsupportedReason = "ArchUnsupported"
+	// SecurityMigrationCondition documents the migration of components after the security mode of PlatformAdmin
+	// is toggled, it is true while the components exclusive to the previous mode are kept.
+	SecurityMigrationCondition PlatformAdminConditionType = "SecurityMigration"
+
+	SecurityMigrationProvisioningReason = "ProvisioningNewComponents"
+
+	SecurityMigrationReleasingReason = "ReleasingOldComponents"
+
+	SecurityMigrationCompletedReason = "Completed"
+	// PoolConflictCondition documents that the pool of PlatformAdmin is claimed by another PlatformAdmin
+	// generating components into the same namespace, the components are not managed until it is resolved.
+	PoolConflictCondition PlatformAdminConditionType = "PoolConflict"
+
+	PoolConflictReason = "PoolConflict"
+	// TerminatingCondition documents the drain of the pool after PlatformAdmin is deleted with a deletion grace period.
+	TerminatingCondition PlatformAdminConditionType = "Terminating"
+
+	DrainingReason = "Draining"
+
+	DrainTimeoutReason = "DrainTimeout"
 )
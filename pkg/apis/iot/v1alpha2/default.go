@@ -21,4 +21,7 @@ func SetDefaultsPlatformAdmin(obj *PlatformAdmin) {
 	if obj.Annotations == nil {
 		obj.Annotations = make(map[string]string)
 	}
+	if obj.Spec.DeletionPolicy == "" {
+		obj.Spec.DeletionPolicy = DeletionPolicyDelete
+	}
 }
@@ -17,15 +17,65 @@ limitations under the License.
 package v1alpha2
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	// name of finalizer
 	PlatformAdminFinalizer = "iot.openyurt.io"
+	// ConfigMapFinalizer protects the generated configmaps shared by PlatformAdmins, it is only removed when
+	// the last PlatformAdmin desiring the configmap is gone
+	ConfigMapFinalizer = "iot.openyurt.io/configmap-protection"
 
 	LabelPlatformAdminGenerate = "iot.openyurt.io/generate"
+
+	// LabelPlatformAdmin tracks the objects generated into a workload namespace other than the one of PlatformAdmin,
+	// since the owner references can not cross namespaces. The value is <namespace>.<name> of PlatformAdmin.
+	LabelPlatformAdmin = "iot.openyurt.io/platformadmin"
+
+	// LabelPlatformAdminName, LabelPlatformAdminNamespace and LabelComponent identify the PlatformAdmin and the
+	// component which the object is generated for. The name is only labeled while the object has one PlatformAdmin
+	// as owner, the objects shared by several PlatformAdmins are told by their owner references.
+	LabelPlatformAdminName      = "iot.openyurt.io/platformadmin-name"
+	LabelPlatformAdminNamespace = "iot.openyurt.io/platformadmin-namespace"
+	LabelComponent              = "iot.openyurt.io/component"
+
+	// AnnotationTemplateHash records the hash of the component template which the workload is generated from
+	AnnotationTemplateHash = "iot.openyurt.io/template-hash"
+
+	// AnnotationDryRun makes the controller only preview the objects of PlatformAdmin instead of creating them
+	AnnotationDryRun = "iot.openyurt.io/dry-run"
+
+	// PropagatePrefix is the prefix of PlatformAdmin labels and annotations which are copied to the generated objects
+	// with the prefix stripped, e.g. label "iot.openyurt.io/propagate.team=edge" is copied as "team=edge".
+	PropagatePrefix = "iot.openyurt.io/propagate."
+	// AnnotationPropagatedLabels and AnnotationPropagatedAnnotations record the keys which are propagated to
+	// the generated object, so they can be cleaned up after being removed from PlatformAdmin.
+	AnnotationPropagatedLabels      = "iot.openyurt.io/propagated-labels"
+	AnnotationPropagatedAnnotations = "iot.openyurt.io/propagated-annotations"
+
+	// AnnotationAdoptable allows the controller to adopt an existing object which is not generated by PlatformAdmin
+	AnnotationAdoptable = "iot.openyurt.io/adoptable"
+	// AnnotationAdoptedKeys records the keys of an adopted configmap which existed before the adoption, their values
+	// are kept instead of being overwritten by the template.
+	AnnotationAdoptedKeys = "iot.openyurt.io/adopted-keys"
+
+	// AnnotationPaused stops the controller from managing the objects of PlatformAdmin, the same as spec.paused
+	AnnotationPaused = "iot.openyurt.io/paused"
+
+	// AnnotationUnmanaged on a generated YurtAppSet stops the controller from patching its template and topology,
+	// e.g. while the image of the component is hotfixed manually. The pool is still removed on deletion.
+	AnnotationUnmanaged = "iot.openyurt.io/unmanaged"
+
+	// AnnotationProtectResources protects the generated configmaps, services and yurtappsets of PlatformAdmin,
+	// the updates and deletions of them are rejected unless they come from yurt-manager.
+	AnnotationProtectResources = "iot.openyurt.io/protect-resources"
+	// LabelProtected marks the generated objects which are protected by the webhook
+	LabelProtected = "iot.openyurt.io/protected"
 )
 
 // PlatformAdmin platform supported by openyurt
@@ -33,6 +83,73 @@ const (
 	PlatformAdminPlatformEdgeX = "edgex"
 )
 
+// Service topology of components supported by PlatformAdmin
+const (
+	ServiceTopologyNodePool = "nodepool"
+	ServiceTopologyZone     = "zone"
+	ServiceTopologyNone     = "none"
+)
+
+// Schemes of the internal communication between components supported by PlatformAdmin
+const (
+	PlatformAdminSchemeHTTP  = "http"
+	PlatformAdminSchemeHTTPS = "https"
+)
+
+// Deletion policies of the objects released by PlatformAdmin
+const (
+	// DeletionPolicyDelete deletes the released objects which are not owned by others
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyOrphan leaves the released objects in place, and stops managing them
+	DeletionPolicyOrphan = "Orphan"
+)
+
+// Workload types of components supported by PlatformAdmin
+const (
+	WorkloadTypeDeployment = "Deployment"
+	WorkloadTypeDaemonSet  = "DaemonSet"
+)
+
+// Message bus supported by PlatformAdmin
+const (
+	PlatformAdminMessageBusRedis = "redis"
+	PlatformAdminMessageBusMQTT  = "mqtt"
+)
+
+// PreviewComponent is the summary of an object which would be generated for PlatformAdmin
+type PreviewComponent struct {
+	// Name of the object
+	Name string `json:"name"`
+
+	// Kind of the object, e.g. YurtAppSet, Service or ConfigMap
+	Kind string `json:"kind"`
+
+	// Images of the containers, only set for workloads
+	// +optional
+	Images []string `json:"images,omitempty"`
+
+	// Ports of the service, only set for services
+	// +optional
+	Ports []int32 `json:"ports,omitempty"`
+}
+
+// ResourceRef refers to an object created or adopted by PlatformAdmin
+type ResourceRef struct {
+	// Kind of the object, e.g. YurtAppSet, Service or ConfigMap
+	Kind string `json:"kind"`
+
+	// Name of the object
+	Name string `json:"name"`
+
+	// Namespace of the object
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// UID of the object
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+}
+
 // PlatformAdminConditionType indicates valid conditions type of a PlatformAdmin.
 type PlatformAdminConditionType string
 type PlatformAdminConditionSeverity string
@@ -43,16 +160,81 @@ type Component struct {
 
 	// +optional
 	Image string `json:"image,omitempty"`
+
+	// ServiceTopology is the topology of the service of component, the service is only reachable from
+	// the same nodepool by default, and none makes it reachable from other pools and the cloud.
+	// +kubebuilder:validation:Enum=nodepool;zone;none
+	// +optional
+	ServiceTopology string `json:"serviceTopology,omitempty"`
+
+	// WorkloadType is the workload of component, the component is deployed with a YurtAppSet by default,
+	// and DaemonSet deploys it with a YurtAppDaemon to every nodepool sharing it. The node selector
+	// requirements and tolerations of PlatformAdmin only apply to YurtAppSet.
+	// +kubebuilder:validation:Enum=Deployment;DaemonSet
+	// +optional
+	WorkloadType string `json:"workloadType,omitempty"`
+
+	// LivenessProbe, ReadinessProbe and StartupProbe override the probes of the first container of component.
+	// The probe of template is kept if it is not set, and an empty probe removes the probe of template.
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// UpdateStrategy overrides the strategy of the deployment template of component, e.g. Recreate for the device
+	// services which can not run two instances at the same time. The strategy of template is kept if it is not set.
+	// +optional
+	UpdateStrategy *appsv1.DeploymentStrategy `json:"updateStrategy,omitempty"`
+
+	// Autoscaling scales the deployment of component in the pool by a HorizontalPodAutoscaler,
+	// e.g. core-data during device storms. It is ignored for the DaemonSet components.
+	// +optional
+	Autoscaling *ComponentAutoscaling `json:"autoscaling,omitempty"`
+
+	// Replicas is the number of replicas of component in the pool, and defaults to 1. Zero scales the component
+	// down while the pool is kept. It is ignored for the autoscaled and the DaemonSet components.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// ComponentAutoscaling is the HorizontalPodAutoscaler of the deployment of component in the pool.
+type ComponentAutoscaling struct {
+	// MinReplicas is the lower limit of the replicas, and defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit of the replicas, it can not be less than MinReplicas.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the target average CPU utilization over the pods of component,
+	// represented as a percentage of the requested CPU. The default policy of autoscaler is used if it is not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
 }
 
 // PlatformAdminSpec defines the desired state of PlatformAdmin
 type PlatformAdminSpec struct {
 	Version string `json:"version,omitempty"`
 
+	// ImageRegistry replaces the registry of the images of all components, the repositories and the tags are kept.
+	// The components are rolled with the new images when it is changed.
+	// +optional
 	ImageRegistry string `json:"imageRegistry,omitempty"`
 
 	PoolName string `json:"poolName,omitempty"`
 
+	// Pools are the other nodepools which the components are deployed into along with PoolName, so one
+	// PlatformAdmin serves several nodepools with the same components. The pools can be added after creation,
+	// but not removed.
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
 	// +optional
 	Platform string `json:"platform,omitempty"`
 
@@ -61,6 +243,115 @@ type PlatformAdminSpec struct {
 
 	// +optional
 	Security bool `json:"security,omitempty"`
+
+	// MessageBus is the message bus used by the components, redis or mqtt.
+	// The message bus of component templates is used if it is empty.
+	// +optional
+	MessageBus string `json:"messageBus,omitempty"`
+
+	// Scheme is the scheme of the internal communication between components, http or https.
+	// The https overrides of component templates are applied for https, and the templates are used as they are if it is empty.
+	// +kubebuilder:validation:Enum=http;https
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+
+	// ConfigMapOverrides is keyed by the name of configmap, its data is merged on top of the template data.
+	// A key set to an empty string is deleted from the template data.
+	// +optional
+	ConfigMapOverrides map[string]map[string]string `json:"configMapOverrides,omitempty"`
+
+	// NodeSelectorRequirements are appended to the node selector term of the pool, so the components are
+	// further constrained to the nodes inside the pool, e.g. nodes with specific hardware.
+	// +optional
+	NodeSelectorRequirements []corev1.NodeSelectorRequirement `json:"nodeSelectorRequirements,omitempty"`
+
+	// Tolerations are set to the pool of components.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// ImagePullSecrets are appended to the pod template of every component, so the images can be pulled from
+	// private registries. The secrets already listed by the template are not duplicated.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// PodDisruptionBudget makes the controller create a PodDisruptionBudget for each component,
+	// so the pods of components are not evicted at the same time during node drains.
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// NetworkPolicy makes the controller create a NetworkPolicy for each component, which only allows
+	// the ingress from the pods of PlatformAdmin components in the same namespace to the service ports.
+	// +optional
+	NetworkPolicy bool `json:"networkPolicy,omitempty"`
+
+	// Paused stops the controller from creating or updating the objects of PlatformAdmin,
+	// so the generated objects can be edited manually while debugging. Deletion is still handled.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// ComponentEnv is keyed by the name of component, the env vars are set to every container of the component
+	// and override the env vars of the template with the same name.
+	// +optional
+	ComponentEnv map[string][]corev1.EnvVar `json:"componentEnv,omitempty"`
+
+	// DisabledComponents are the names of components which are not deployed by the controller, e.g. the bundled
+	// redis when users bring their own. The pool is removed from the workloads of components disabled after deployment.
+	// +optional
+	DisabledComponents []string `json:"disabledComponents,omitempty"`
+
+	// WorkloadNamespace is the namespace which the components are deployed into, it defaults to the namespace of
+	// PlatformAdmin. The objects in another namespace are tracked by label instead of owner reference, and are
+	// deleted explicitly when PlatformAdmin is deleted. A workload namespace is not supposed to be shared by several
+	// PlatformAdmins. It can not be changed after creation.
+	// +optional
+	WorkloadNamespace string `json:"workloadNamespace,omitempty"`
+
+	// DeletionPolicy decides what happens to the objects released by PlatformAdmin, when a component is removed or
+	// PlatformAdmin is deleted. Delete deletes them, and Orphan only removes the owner references and the generate
+	// label, so the objects(e.g. redis with a persistent volume) survive and are not managed anymore.
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// AdditionalComponentsRef refers to a ConfigMap in the namespace of PlatformAdmin, each key of which holds a YAML
+	// or JSON document of an additional component, i.e. a DeploymentTemplateSpec or a ServiceTemplateSpec with kind
+	// Deployment or Service. They are merged with the additional components of the annotations.
+	// +optional
+	AdditionalComponentsRef *corev1.LocalObjectReference `json:"additionalComponentsRef,omitempty"`
+
+	// DeletionGracePeriodSeconds enables the drain of the pool when PlatformAdmin is deleted. The replicas of the pool
+	// are scaled to 0 first, so the device services can publish the last readings and deregister, and the pool is
+	// removed once no replica is ready or the grace period expires. The pool is removed immediately if it is not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DeletionGracePeriodSeconds *int64 `json:"deletionGracePeriodSeconds,omitempty"`
+
+	// Monitoring configures how the metrics of components are scraped. The services of components exposing metrics
+	// are always annotated with the prometheus scrape hints.
+	// +optional
+	Monitoring *PlatformAdminMonitoring `json:"monitoring,omitempty"`
+
+	// SkipConfigmapManagement leaves the configmaps of components to the users, e.g. when they are provisioned by
+	// GitOps. The controller neither creates nor updates them, and never deletes them. Once it is turned off again,
+	// the existing configmaps not generated by PlatformAdmin are adopted with their data kept, and only the missing
+	// keys of the template are added.
+	// +optional
+	SkipConfigmapManagement bool `json:"skipConfigmapManagement,omitempty"`
+}
+
+// PlatformAdminMonitoring defines the monitoring of components
+type PlatformAdminMonitoring struct {
+	// ServiceMonitor makes the controller create a ServiceMonitor for each component exposing metrics, it is ignored
+	// if the CRDs of monitoring.coreos.com are not installed.
+	// +optional
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+}
+
+// PodDisruptionBudgetSpec defines the PodDisruptionBudget of components
+type PodDisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of pods of each component that must be available after an eviction
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
 }
 
 // PlatformAdminStatus defines the observed state of PlatformAdmin
@@ -77,11 +368,78 @@ type PlatformAdminStatus struct {
 	// +optional
 	UnreadyComponentNum int32 `json:"unreadyComponentNum,omitempty"`
 
+	// Components is the readiness of each desired component, sorted by name
+	// +optional
+	Components []ComponentStatus `json:"components,omitempty"`
+
+	// CurrentVersion is the version which all components are ready at
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// CurrentSecurity is the security mode which the components are deployed with. A migration is in progress
+	// while it differs from spec.security, the components of the previous mode are kept until the new ones are ready.
+	// +optional
+	CurrentSecurity *bool `json:"currentSecurity,omitempty"`
+
+	// CurrentPools are the nodepools which the components are deployed into. The pools removed from the spec are
+	// released from the workloads before they are dropped from it.
+	// +optional
+	CurrentPools []string `json:"currentPools,omitempty"`
+
+	// ObservedGeneration is the generation of the spec which all components are ready with. The rollout of
+	// the latest spec is complete only if Ready is true and ObservedGeneration equals metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// PreviewComponents lists the objects which would be generated, it is only set in dry-run mode
+	// +optional
+	PreviewComponents []PreviewComponent `json:"previewComponents,omitempty"`
+
+	// ManagedResources lists the objects created or adopted by PlatformAdmin, sorted by kind, namespace and name.
+	// The list is capped, the number of objects left out is recorded in OmittedManagedResources.
+	// +optional
+	ManagedResources []ResourceRef `json:"managedResources,omitempty"`
+
+	// OmittedManagedResources is the number of managed objects which are not listed in ManagedResources
+	// +optional
+	OmittedManagedResources int32 `json:"omittedManagedResources,omitempty"`
+
+	// UnmanagedComponents are the components whose YurtAppSets are annotated as unmanaged, sorted by name.
+	// Their readiness is still reported from the live status of the YurtAppSets.
+	// +optional
+	UnmanagedComponents []string `json:"unmanagedComponents,omitempty"`
+
+	// PendingChanges summarizes the differences between the live components and configmaps and the desired ones,
+	// one change per line. It is set while the desired state is not converged and cleared once PlatformAdmin is ready.
+	// The list is capped, the number of changes left out is recorded in OmittedPendingChanges.
+	// +optional
+	PendingChanges []string `json:"pendingChanges,omitempty"`
+
+	// OmittedPendingChanges is the number of changes which are not listed in PendingChanges
+	// +optional
+	OmittedPendingChanges int32 `json:"omittedPendingChanges,omitempty"`
+
 	// Current PlatformAdmin state
 	// +optional
 	Conditions []PlatformAdminCondition `json:"conditions,omitempty"`
 }
 
+// ComponentStatus is the readiness of a component of PlatformAdmin
+type ComponentStatus struct {
+	Name string `json:"name"`
+
+	// Ready is true if the service of component is reconciled and the workload of component in the pool is ready
+	Ready bool `json:"ready"`
+
+	// Reason is why the component is not ready, e.g. DeploymentNotReady
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message tells the details of the unready component, e.g. the condition of its workload
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // PlatformAdminCondition describes current state of a PlatformAdmin.
 type PlatformAdminCondition struct {
 	// Type of in place set condition.
@@ -108,6 +466,8 @@ type PlatformAdminCondition struct {
 // +kubebuilder:printcolumn:name="READY",type="boolean",JSONPath=".status.ready",description="The platformadmin ready status"
 // +kubebuilder:printcolumn:name="ReadyComponentNum",type="integer",JSONPath=".status.readyComponentNum",description="The Ready Component."
 // +kubebuilder:printcolumn:name="UnreadyComponentNum",type="integer",JSONPath=".status.unreadyComponentNum",description="The Unready Component."
+// +kubebuilder:printcolumn:name="REASON",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,description="The reason why the platformadmin is not ready."
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:storageversion
 
 // PlatformAdmin is the Schema for the samples API
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+const (
+	additionalComponentKindDeployment = "Deployment"
+	additionalComponentKindService    = "Service"
+)
+
+// additionalComponentDocument is the document of an additional component in the referenced configmap,
+// its kind tells whether the spec is the one of DeploymentTemplateSpec or of ServiceTemplateSpec.
+type additionalComponentDocument struct {
+	Kind              string `json:"kind"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              json.RawMessage `json:"spec"`
+}
+
+// additionalComponentsError reports the referenced configmap of additional components which can not be used,
+// the key is empty if the configmap itself is the problem(e.g. it does not exist).
+type additionalComponentsError struct {
+	configMap string
+	key       string
+	err       error
+}
+
+func (e *additionalComponentsError) Error() string {
+	if e.key == "" {
+		return fmt.Sprintf("additional components configmap %s is invalid, %v", e.configMap, e.err)
+	}
+	return fmt.Sprintf("key %s of additional components configmap %s is invalid, %v", e.key, e.configMap, e.err)
+}
+
+func (e *additionalComponentsError) Unwrap() error {
+	return e.err
+}
+
+// configMapToTemplates decodes the additional deployments and services of the configmap referenced by
+// PlatformAdmin.Spec.AdditionalComponentsRef. The keys are decoded in order, so the first invalid key is reported.
+func configMapToTemplates(configmap *corev1.ConfigMap) ([]iotv1alpha1.DeploymentTemplateSpec, []iotv1alpha1.ServiceTemplateSpec, error) {
+	keys := make([]string, 0, len(configmap.Data))
+	for key := range configmap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var deployments []iotv1alpha1.DeploymentTemplateSpec
+	var services []iotv1alpha1.ServiceTemplateSpec
+	for _, key := range keys {
+		invalid := func(err error) error {
+			return &additionalComponentsError{configMap: configmap.Name, key: key, err: err}
+		}
+		// JSON is a subset of YAML, so both of them are converted to JSON and decoded strictly
+		data, err := yaml.YAMLToJSON([]byte(configmap.Data[key]))
+		if err != nil {
+			return nil, nil, invalid(err)
+		}
+		document := additionalComponentDocument{}
+		if err := strictDecode(data, &document); err != nil {
+			return nil, nil, invalid(err)
+		}
+		if document.Name == "" {
+			return nil, nil, invalid(fmt.Errorf("metadata.name is required"))
+		}
+		switch document.Kind {
+		case additionalComponentKindDeployment:
+			deployment := iotv1alpha1.DeploymentTemplateSpec{ObjectMeta: document.ObjectMeta}
+			if err := strictDecode(document.Spec, &deployment.Spec); err != nil {
+				return nil, nil, invalid(err)
+			}
+			deployments = append(deployments, deployment)
+		case additionalComponentKindService:
+			service := iotv1alpha1.ServiceTemplateSpec{ObjectMeta: document.ObjectMeta}
+			if err := strictDecode(document.Spec, &service.Spec); err != nil {
+				return nil, nil, invalid(err)
+			}
+			services = append(services, service)
+		default:
+			return nil, nil, invalid(fmt.Errorf("unsupported kind %q, only %s and %s are supported", document.Kind,
+				additionalComponentKindDeployment, additionalComponentKindService))
+		}
+	}
+	return deployments, services, nil
+}
+
+// strictDecode decodes the JSON data and rejects the unknown fields, so the typos are not ignored silently.
+func strictDecode(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("spec is required")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// additionalComponentsConfigMap returns the configmap referenced by PlatformAdmin.Spec.AdditionalComponentsRef,
+// it is nil if PlatformAdmin does not refer to one. A missing configmap is reported as additionalComponentsError,
+// the PlatformAdmin is reconciled again once it is created.
+func (r *ReconcilePlatformAdmin) additionalComponentsConfigMap(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin) (*corev1.ConfigMap, error) {
+	ref := platformAdmin.Spec.AdditionalComponentsRef
+	if ref == nil || ref.Name == "" {
+		return nil, nil
+	}
+	configmap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: platformAdmin.Namespace, Name: ref.Name}, configmap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &additionalComponentsError{configMap: ref.Name, err: err}
+		}
+		return nil, err
+	}
+	return configmap, nil
+}
+
+// mapAdditionalComponentsToPlatformAdmins enqueues the PlatformAdmins referring to the configmap of additional components,
+// so the edits of the configmap are propagated to the components.
+func (r *ReconcilePlatformAdmin) mapAdditionalComponentsToPlatformAdmins(obj client.Object) []reconcile.Request {
+	platformAdmins := &iotv1alpha2.PlatformAdminList{}
+	if err := r.List(context.TODO(), platformAdmins, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{util.IndexerPathForAdditionalComponentsRef: obj.GetName()}); err != nil {
+		klog.ErrorS(err, "List PlatformAdmins by additional components configmap error", "controller", ControllerName, "configmap", klog.KObj(obj))
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, platformAdmin := range platformAdmins.Items {
+		// The index is not honored by every client(e.g. the fake client of tests), so the reference is checked again
+		ref := platformAdmin.Spec.AdditionalComponentsRef
+		if ref == nil || ref.Name != obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdmin.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+const (
+	testDeploymentYAML = `kind: Deployment
+metadata:
+  name: device-virtual
+spec:
+  selector:
+    matchLabels:
+      app: device-virtual
+  template:
+    metadata:
+      labels:
+        app: device-virtual
+    spec:
+      containers:
+      - name: device-virtual
+        image: edgexfoundry/device-virtual:2.3.0
+`
+	testServiceJSON = `{"kind": "Service", "metadata": {"name": "device-virtual"}, "spec": {"ports": [{"name": "http", "port": 59900}]}}`
+)
+
+func newTestAdditionalComponentsConfigMap(namespace, name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       data,
+	}
+}
+
+func TestConfigMapToTemplates(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              map[string]string
+		expectDeployments []string
+		expectServices    []string
+		expectInvalidKey  string
+	}{
+		{
+			name:              "yaml deployment and json service",
+			data:              map[string]string{"deployment.yaml": testDeploymentYAML, "service.json": testServiceJSON},
+			expectDeployments: []string{"device-virtual"},
+			expectServices:    []string{"device-virtual"},
+		},
+		{
+			name:             "unknown field",
+			data:             map[string]string{"deployment.yaml": testDeploymentYAML, "service.json": `{"kind": "Service", "metadata": {"name": "foo"}, "spec": {"portz": []}}`},
+			expectInvalidKey: "service.json",
+		},
+		{
+			name:             "unsupported kind",
+			data:             map[string]string{"statefulset.yaml": "kind: StatefulSet\nmetadata:\n  name: foo\nspec: {}\n"},
+			expectInvalidKey: "statefulset.yaml",
+		},
+		{
+			name:             "name is missing",
+			data:             map[string]string{"service.yaml": "kind: Service\nspec: {}\n"},
+			expectInvalidKey: "service.yaml",
+		},
+		{
+			name:             "malformed yaml",
+			data:             map[string]string{"a.yaml": "kind: [Service", "b.yaml": "kind: {"},
+			expectInvalidKey: "a.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployments, services, err := configMapToTemplates(newTestAdditionalComponentsConfigMap("default", "additional", tt.data))
+			if tt.expectInvalidKey != "" {
+				invalid := (*additionalComponentsError)(nil)
+				if !errors.As(err, &invalid) || invalid.key != tt.expectInvalidKey {
+					t.Fatalf("expect invalid key %s, but got %v", tt.expectInvalidKey, err)
+				}
+				if !strings.Contains(err.Error(), tt.expectInvalidKey) {
+					t.Errorf("expect error names key %s, but got %v", tt.expectInvalidKey, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			var deploymentNames, serviceNames []string
+			for _, deployment := range deployments {
+				deploymentNames = append(deploymentNames, deployment.Name)
+			}
+			for _, service := range services {
+				serviceNames = append(serviceNames, service.Name)
+			}
+			if !reflect.DeepEqual(deploymentNames, tt.expectDeployments) {
+				t.Errorf("expect deployments %v, but got %v", tt.expectDeployments, deploymentNames)
+			}
+			if !reflect.DeepEqual(serviceNames, tt.expectServices) {
+				t.Errorf("expect services %v, but got %v", tt.expectServices, serviceNames)
+			}
+		})
+	}
+}
+
+func TestAdditionalComponents(t *testing.T) {
+	configmap := newTestAdditionalComponentsConfigMap("default", "additional", map[string]string{
+		"deployment.yaml": testDeploymentYAML,
+		"service.json":    testServiceJSON,
+	})
+
+	// the deployment and service of the configmap are paired, and merged with the annotation components
+	components, err := additionalComponents(newAdditionalComponentAnnotations("app-service"), configmap)
+	if err != nil {
+		t.Fatalf("expect no error, but got %v", err)
+	}
+	var names []string
+	for _, component := range components {
+		names = append(names, component.Name)
+		if component.Deployment == nil || component.Service == nil {
+			t.Errorf("expect component %s has both deployment and service", component.Name)
+		}
+	}
+	if expect := []string{"app-service", "device-virtual"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect components %v, but got %v", expect, names)
+	}
+
+	// the same component can not be defined in both of them
+	if _, err := additionalComponents(newAdditionalComponentAnnotations("device-virtual"), configmap); err == nil {
+		t.Errorf("expect duplicate components are rejected, but got nil")
+	}
+}
+
+func TestAdditionalComponentsRef(t *testing.T) {
+	configmap := newTestAdditionalComponentsConfigMap("default", "additional", map[string]string{
+		"deployment.yaml": testDeploymentYAML,
+		"service.json":    testServiceJSON,
+	})
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.AdditionalComponentsRef = &corev1.LocalObjectReference{Name: configmap.Name}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, configmap)
+	getPlatformAdmin := func() *iotv1alpha2.PlatformAdmin {
+		t.Helper()
+		latest := &iotv1alpha2.PlatformAdmin{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		return latest
+	}
+	updateConfigMap := func(key, value string) {
+		t.Helper()
+		latest := &corev1.ConfigMap{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(configmap), latest); err != nil {
+			t.Fatalf("failed to get configmap, %v", err)
+		}
+		latest.Data[key] = value
+		if err := r.Update(context.TODO(), latest); err != nil {
+			t.Fatalf("failed to update configmap, %v", err)
+		}
+		// the edit of configmap enqueues the PlatformAdmin referring to it
+		requests := r.mapAdditionalComponentsToPlatformAdmins(latest)
+		expect := []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(pa)}}
+		if !reflect.DeepEqual(requests, expect) {
+			t.Fatalf("expect requests %v, but got %v", expect, requests)
+		}
+		reconcilePlatformAdmin(t, r, pa)
+	}
+
+	// the components of the configmap are deployed
+	reconcilePlatformAdmin(t, r, pa)
+	yas := getYurtAppSet(t, r, pa.Namespace, "device-virtual")
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != "edgexfoundry/device-virtual:2.3.0" {
+		t.Errorf("expect image edgexfoundry/device-virtual:2.3.0, but got %s", image)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "device-virtual"}, &corev1.Service{}); err != nil {
+		t.Errorf("expect service device-virtual is created, but got %v", err)
+	}
+
+	// the live update of configmap is propagated
+	updateConfigMap("deployment.yaml", strings.Replace(testDeploymentYAML, "2.3.0", "3.0.0", 1))
+	yas = getYurtAppSet(t, r, pa.Namespace, "device-virtual")
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != "edgexfoundry/device-virtual:3.0.0" {
+		t.Errorf("expect image edgexfoundry/device-virtual:3.0.0, but got %s", image)
+	}
+
+	// the bad key is reported by condition
+	updateConfigMap("service.json", `{"kind": "Service"`)
+	condition := util.GetPlatformAdminCondition(getPlatformAdmin().Status, iotv1alpha2.ComponentAvailableCondition)
+	if condition == nil || condition.Reason != iotv1alpha2.AdditionalComponentsInvalidReason || !strings.Contains(condition.Message, "service.json") {
+		t.Errorf("expect condition %s naming key service.json, but got %v", iotv1alpha2.AdditionalComponentsInvalidReason, condition)
+	}
+
+	// the missing configmap is reported by condition too
+	if err := r.Delete(context.TODO(), configmap); err != nil {
+		t.Fatalf("failed to delete configmap, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	condition = util.GetPlatformAdminCondition(getPlatformAdmin().Status, iotv1alpha2.ComponentAvailableCondition)
+	if condition == nil || condition.Reason != iotv1alpha2.AdditionalComponentsInvalidReason {
+		t.Errorf("expect condition %s for the missing configmap, but got %v", iotv1alpha2.AdditionalComponentsInvalidReason, condition)
+	}
+}
+
+func TestMapAdditionalComponentsToPlatformAdmins(t *testing.T) {
+	referring := newTestPlatformAdmin("default", "referring", "hangzhou")
+	referring.Spec.AdditionalComponentsRef = &corev1.LocalObjectReference{Name: "additional"}
+	other := newTestPlatformAdmin("default", "other", "beijing")
+	other.Spec.AdditionalComponentsRef = &corev1.LocalObjectReference{Name: "another"}
+	anotherNamespace := newTestPlatformAdmin("edge", "referring", "hangzhou")
+	anotherNamespace.Spec.AdditionalComponentsRef = &corev1.LocalObjectReference{Name: "additional"}
+	r := newTestReconciler(newTestConfiguration(), referring, other, anotherNamespace, newTestPlatformAdmin("default", "plain", "shanghai"))
+
+	requests := r.mapAdditionalComponentsToPlatformAdmins(newTestAdditionalComponentsConfigMap("default", "additional", nil))
+	expect := []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(referring)}}
+	if !reflect.DeepEqual(requests, expect) {
+		t.Errorf("expect requests %v, but got %v", expect, requests)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// FieldManager is the field manager which PlatformAdmin controller applies the generated objects with.
+const FieldManager = "platformadmin-controller"
+
+// applyOwned applies the object with the fields owned by PlatformAdmin controller only, so the fields set by other
+// managers on the existing object, e.g. the annotations of a service mesh injector, survive the apply. The owner
+// references of the other PlatformAdmins sharing the object are read from the existing object and applied too,
+// since the owner references applied by the same manager are replaced as a whole. The mutate sets the owned fields
+// on the object, the existing object is nil if it is not found. The object is filled with the applied object from
+// the apiserver on success.
+func (r *ReconcilePlatformAdmin) applyOwned(ctx context.Context, obj client.Object, mutate func(existing client.Object) error) (controllerutil.OperationResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme())
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, err
+		}
+		existing = nil
+	}
+	if existing != nil {
+		obj.SetOwnerReferences(platformAdminOwnerReferences(existing))
+	}
+	if err := mutate(existing); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+
+	// The apply is sent with the kind, which is not kept by the typed object once it is decoded
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetResourceVersion("")
+	if err := r.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	switch {
+	case existing == nil:
+		return controllerutil.OperationResultCreated, nil
+	case existing.GetResourceVersion() != obj.GetResourceVersion():
+		return controllerutil.OperationResultUpdated, nil
+	}
+	return controllerutil.OperationResultNone, nil
+}
+
+// platformAdminOwnerReferences returns the owner references of object to the PlatformAdmins.
+func platformAdminOwnerReferences(obj client.Object) []metav1.OwnerReference {
+	var owners []metav1.OwnerReference
+	for _, owner := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err == nil && gv.Group == controllerKind.Group && owner.Kind == controllerKind.Kind {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// applyClient emulates the server-side apply which the fake client does not support. The applied object is created
+// if it is not found, or is merged into the existing object. The keys of labels, annotations and data applied
+// before but not any longer are removed, as the apiserver does for the fields owned by the field manager alone.
+type applyClient struct {
+	client.Client
+	// applied records the applied keys of the maps by object
+	applied map[string]map[string]sets.String
+}
+
+func newApplyClient(c client.Client) *applyClient {
+	return &applyClient{Client: c, applied: make(map[string]map[string]sets.String)}
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	metadata := fields["metadata"].(map[string]interface{})
+	key := obj.GetObjectKind().GroupVersionKind().Kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	if c.applied[key] == nil {
+		c.applied[key] = make(map[string]sets.String)
+	}
+	removeUnapplied(c.applied[key], "labels", metadata)
+	removeUnapplied(c.applied[key], "annotations", metadata)
+	removeUnapplied(c.applied[key], "data", fields)
+
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return c.Client.Create(ctx, obj)
+	}
+	delete(metadata, "creationTimestamp")
+	delete(fields, "status")
+
+	// The apply without changes does not update the object, like the apiserver
+	data, err = json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]interface{})
+	if err := json.Unmarshal(data, &current); err != nil {
+		return err
+	}
+	merged := make(map[string]interface{})
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return err
+	}
+	mergeFields(merged, fields)
+	if reflect.DeepEqual(current, merged) {
+		return json.Unmarshal(data, obj)
+	}
+
+	if data, err = json.Marshal(fields); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+// mergeFields merges the fields into target with the semantics of JSON merge patch.
+func mergeFields(target, fields map[string]interface{}) {
+	for k, v := range fields {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		patch, ok := v.(map[string]interface{})
+		if !ok {
+			target[k] = v
+			continue
+		}
+		sub, ok := target[k].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			target[k] = sub
+		}
+		mergeFields(sub, patch)
+	}
+}
+
+// removeUnapplied sets the keys applied before but not any longer to null in the map of fields, and records the
+// applied keys.
+func removeUnapplied(applied map[string]sets.String, name string, fields map[string]interface{}) {
+	current, _ := fields[name].(map[string]interface{})
+	keys := sets.NewString()
+	for k := range current {
+		keys.Insert(k)
+	}
+	for _, k := range applied[name].Difference(keys).UnsortedList() {
+		if current == nil {
+			current = make(map[string]interface{})
+			fields[name] = current
+		}
+		current[k] = nil
+	}
+	applied[name] = keys
+}
+
+func TestApplyOwned(t *testing.T) {
+	foreign := map[string]string{"sidecar.istio.io/status": "injected"}
+	cases := []struct {
+		name   string
+		get    func(r *ReconcilePlatformAdmin) (client.Object, error)
+		modify func(obj client.Object)
+		check  func(t *testing.T, obj client.Object)
+	}{
+		{
+			name: "configmap",
+			get: func(r *ReconcilePlatformAdmin) (client.Object, error) {
+				cm := &corev1.ConfigMap{}
+				return cm, r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "common-variable-levski"}, cm)
+			},
+			modify: func(obj client.Object) {
+				cm := obj.(*corev1.ConfigMap)
+				cm.Data["EDGEX_SECURITY_SECRET_STORE"] = "true"
+				cm.Data["FOREIGN"] = "kept"
+			},
+			check: func(t *testing.T, obj client.Object) {
+				cm := obj.(*corev1.ConfigMap)
+				if cm.Data["EDGEX_SECURITY_SECRET_STORE"] != "false" {
+					t.Errorf("expect the data of template is enforced, but got %v", cm.Data)
+				}
+				if cm.Data["FOREIGN"] != "kept" {
+					t.Errorf("expect the data of others is kept, but got %v", cm.Data)
+				}
+			},
+		},
+		{
+			name: "service",
+			get: func(r *ReconcilePlatformAdmin) (client.Object, error) {
+				svc := &corev1.Service{}
+				return svc, r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: testComponent}, svc)
+			},
+			modify: func(obj client.Object) {
+				svc := obj.(*corev1.Service)
+				svc.Spec.Selector = map[string]string{"app": "other"}
+			},
+			check: func(t *testing.T, obj client.Object) {
+				svc := obj.(*corev1.Service)
+				if svc.Spec.Selector["app"] != testComponent {
+					t.Errorf("expect the selector of template is enforced, but got %v", svc.Spec.Selector)
+				}
+				if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != 59882 {
+					t.Errorf("expect the ports of template are enforced, but got %v", svc.Spec.Ports)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)))
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			if err := r.Create(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to create PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			// others annotate the object and change the fields owned by PlatformAdmin controller
+			obj, err := tc.get(r)
+			if err != nil {
+				t.Fatalf("failed to get object, %v", err)
+			}
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			for k, v := range foreign {
+				annotations[k] = v
+			}
+			obj.SetAnnotations(annotations)
+			obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate] = "other"
+			tc.modify(obj)
+			if err := r.Update(context.TODO(), obj); err != nil {
+				t.Fatalf("failed to update object, %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				reconcilePlatformAdmin(t, r, pa)
+				obj, err = tc.get(r)
+				if err != nil {
+					t.Fatalf("failed to get object, %v", err)
+				}
+				for k, v := range foreign {
+					if obj.GetAnnotations()[k] != v {
+						t.Errorf("expect annotation %s=%s of others is kept after apply %d, but got %v", k, v, i+1, obj.GetAnnotations())
+					}
+				}
+				if obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate] == "other" {
+					t.Errorf("expect the generate label is enforced after apply %d, but got %v", i+1, obj.GetLabels())
+				}
+				if !isOwnedBy(obj, pa) {
+					t.Errorf("expect object is owned by PlatformAdmin, but got %v", obj.GetOwnerReferences())
+				}
+				tc.check(t, obj)
+			}
+		})
+	}
+}
+
+func TestApplyOwnedKeepsOtherOwners(t *testing.T) {
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)))
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	if err := r.Create(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to create PlatformAdmin, %v", err)
+	}
+	other := &metav1.OwnerReference{APIVersion: controllerKind.GroupVersion().String(), Kind: controllerKind.Kind, Name: "edgex-shanghai", UID: "other"}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "common-variable-levski"}}
+	result, err := r.applyOwned(context.TODO(), cm, func(client.Object) error {
+		cm.OwnerReferences = append(cm.OwnerReferences, *other)
+		return nil
+	})
+	if err != nil || result != controllerutil.OperationResultCreated {
+		t.Fatalf("expect configmap is created, but got %v, %v", result, err)
+	}
+
+	cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "common-variable-levski"}}
+	if _, err := r.applyOwned(context.TODO(), cm, func(client.Object) error {
+		return r.setOwner(pa, cm)
+	}); err != nil {
+		t.Fatalf("failed to apply configmap, %v", err)
+	}
+	if platformAdminOwners(cm) != 2 {
+		t.Errorf("expect the owner of other PlatformAdmin is kept, but got %v", cm.OwnerReferences)
+	}
+}
+
+func TestReconcileKeepsCustomLabels(t *testing.T) {
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	template := &conf.NoSectyConfigMaps[testVersion][0]
+	template.Labels = map[string]string{"app.kubernetes.io/part-of": "edgex"}
+	template.Annotations = map[string]string{"edgex.io/template": "common"}
+	r := newTestReconciler(conf)
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	if err := r.Create(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to create PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	objs := []client.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: template.Name}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: testComponent}},
+	}
+	// the users label the generated objects, e.g. for the selectors of network policies
+	for _, obj := range objs {
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Fatalf("failed to get object, %v", err)
+		}
+		obj.GetLabels()["team"] = "iot"
+		if err := r.Update(context.TODO(), obj); err != nil {
+			t.Fatalf("failed to update object, %v", err)
+		}
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	for _, obj := range objs {
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Fatalf("failed to get object, %v", err)
+		}
+		if obj.GetLabels()["team"] != "iot" {
+			t.Errorf("expect the label of users is kept on %s, but got %v", obj.GetName(), obj.GetLabels())
+		}
+		if obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate] == "" {
+			t.Errorf("expect the generate label is set on %s, but got %v", obj.GetName(), obj.GetLabels())
+		}
+	}
+	cm := objs[0]
+	if cm.GetLabels()["app.kubernetes.io/part-of"] != "edgex" {
+		t.Errorf("expect the label of template is applied, but got %v", cm.GetLabels())
+	}
+	if cm.GetAnnotations()["edgex.io/template"] != "common" {
+		t.Errorf("expect the annotation of template is applied, but got %v", cm.GetAnnotations())
+	}
+}
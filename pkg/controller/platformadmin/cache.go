@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// generatedSelector selects the objects generated by PlatformAdmins, whatever kind of object the label tells.
+func generatedSelector() labels.Selector {
+	requirement, _ := labels.NewRequirement(iotv1alpha2.LabelPlatformAdminGenerate, selection.Exists, nil)
+	return labels.NewSelector().Add(*requirement)
+}
+
+// generatedPredicate drops the events of objects which are not generated by PlatformAdmins, so the configmaps
+// and services of others are not mapped to PlatformAdmins.
+func generatedPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]
+		return ok
+	})
+}
+
+// generatedCacheOptions returns the options of the cache which only lists and watches the configmaps and services
+// generated by PlatformAdmins, so the cache does not grow with the configmaps and services of others.
+func generatedCacheOptions(scheme *runtime.Scheme, mapper meta.RESTMapper) cache.Options {
+	selector := generatedSelector()
+	return cache.Options{
+		Scheme: scheme,
+		Mapper: mapper,
+		SelectorsByObject: cache.SelectorsByObject{
+			&corev1.ConfigMap{}: {Label: selector},
+			&corev1.Service{}:   {Label: selector},
+		},
+	}
+}
+
+// frameworkCacheOptions returns the options of the cache which only watches the framework configmap.
+func frameworkCacheOptions(scheme *runtime.Scheme, mapper meta.RESTMapper, namespace string) cache.Options {
+	return cache.Options{
+		Scheme:    scheme,
+		Mapper:    mapper,
+		Namespace: namespace,
+		SelectorsByObject: cache.SelectorsByObject{
+			&corev1.ConfigMap{}: {Field: fields.OneTermEqualSelector("metadata.name", config.FrameworkConfigMapName)},
+		},
+	}
+}
+
+// setupLabelScopedCaches replaces the cluster-wide informers of configmaps and services by the label-scoped ones.
+// The caches are started by the manager, the reads of configmaps and services go through the generated cache
+// and fall back to the apiserver for the objects not generated by PlatformAdmins.
+func (r *ReconcilePlatformAdmin) setupLabelScopedCaches(mgr manager.Manager) error {
+	generatedCache, err := cache.New(mgr.GetConfig(), generatedCacheOptions(mgr.GetScheme(), mgr.GetRESTMapper()))
+	if err != nil {
+		return err
+	}
+	frameworkCache, err := cache.New(mgr.GetConfig(), frameworkCacheOptions(mgr.GetScheme(), mgr.GetRESTMapper(), r.frameworkNamespace))
+	if err != nil {
+		return err
+	}
+	for _, c := range []cache.Cache{generatedCache, frameworkCache} {
+		if err := mgr.Add(c); err != nil {
+			return err
+		}
+	}
+	r.generatedCache = generatedCache
+	r.frameworkCache = frameworkCache
+	r.Client = &labelScopedClient{Client: r.Client, cache: generatedCache, apiReader: mgr.GetAPIReader()}
+	return nil
+}
+
+// setupPlatformAdminCache sets up the cache of PlatformAdmins for the controller added after the manager is started,
+// since the informers of manager can not be indexed anymore. The cache is indexed and synced before it is returned,
+// and the reads of PlatformAdmins go through it.
+func (r *ReconcilePlatformAdmin) setupPlatformAdminCache(ctx context.Context, mgr manager.Manager) error {
+	platformAdminCache, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return err
+	}
+	if err := util.RegisterFieldIndexers(platformAdminCache); err != nil {
+		return err
+	}
+	go func() {
+		if err := platformAdminCache.Start(ctx); err != nil {
+			klog.ErrorS(err, "Failed to start the cache of PlatformAdmins", "controller", ControllerName)
+		}
+	}()
+	if !platformAdminCache.WaitForCacheSync(ctx) {
+		return fmt.Errorf("failed to sync the cache of PlatformAdmins")
+	}
+	r.platformAdminCache = platformAdminCache
+	r.Client = &platformAdminCachedClient{Client: r.Client, cache: platformAdminCache}
+	return nil
+}
+
+// platformAdminSource returns the source of PlatformAdmins, which are watched by the cache of PlatformAdmins if
+// the controller is added after the manager is started.
+func (r *ReconcilePlatformAdmin) platformAdminSource() source.Source {
+	if r.platformAdminCache == nil {
+		return &source.Kind{Type: &iotv1alpha2.PlatformAdmin{}}
+	}
+	return source.NewKindWithCache(&iotv1alpha2.PlatformAdmin{}, r.platformAdminCache)
+}
+
+// generatedSource returns the source of the generated configmaps or services, they are watched by the generated
+// cache if the label-scoped caches are set up.
+func (r *ReconcilePlatformAdmin) generatedSource(obj client.Object) source.Source {
+	if r.generatedCache == nil {
+		return &source.Kind{Type: obj}
+	}
+	return source.NewKindWithCache(obj, r.generatedCache)
+}
+
+// frameworkSource returns the source of the framework configmap.
+func (r *ReconcilePlatformAdmin) frameworkSource() source.Source {
+	if r.frameworkCache == nil {
+		return &source.Kind{Type: &corev1.ConfigMap{}}
+	}
+	return source.NewKindWithCache(&corev1.ConfigMap{}, r.frameworkCache)
+}
+
+// labelScopedClient reads the configmaps and services from the label-scoped cache. The lists of controller always
+// select the generated objects, while the configmaps not generated by PlatformAdmins(e.g. the configmap of
+// additional components) are missed by the cache, so they are read from the apiserver instead.
+type labelScopedClient struct {
+	client.Client
+	cache     client.Reader
+	apiReader client.Reader
+}
+
+// isLabelScoped tells whether the kind of object is cached by the label-scoped cache.
+func isLabelScoped(obj interface{}) bool {
+	switch obj.(type) {
+	case *corev1.ConfigMap, *corev1.ConfigMapList, *corev1.Service, *corev1.ServiceList:
+		return true
+	}
+	return false
+}
+
+func (c *labelScopedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if !isLabelScoped(obj) {
+		return c.Client.Get(ctx, key, obj)
+	}
+	err := c.cache.Get(ctx, key, obj)
+	if apierrors.IsNotFound(err) {
+		return c.apiReader.Get(ctx, key, obj)
+	}
+	return err
+}
+
+func (c *labelScopedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if !isLabelScoped(list) {
+		return c.Client.List(ctx, list, opts...)
+	}
+	return c.cache.List(ctx, list, opts...)
+}
+
+// platformAdminCachedClient reads the PlatformAdmins from the cache of PlatformAdmins.
+type platformAdminCachedClient struct {
+	client.Client
+	cache client.Reader
+}
+
+func (c *platformAdminCachedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*iotv1alpha2.PlatformAdmin); ok {
+		return c.cache.Get(ctx, key, obj)
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *platformAdminCachedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*iotv1alpha2.PlatformAdminList); ok {
+		return c.cache.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
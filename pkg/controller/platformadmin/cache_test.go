@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+func TestGeneratedPredicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		expect bool
+	}{
+		{name: "generated configmap", labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelConfigmap}, expect: true},
+		{name: "generated with empty value", labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: ""}, expect: true},
+		{name: "other labels", labels: map[string]string{"app": "edgex"}},
+		{name: "no labels"},
+	}
+	p := generatedPredicate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm", Labels: tt.labels}}
+			if got := p.Create(event.CreateEvent{Object: obj}); got != tt.expect {
+				t.Errorf("expect create %v, but got %v", tt.expect, got)
+			}
+			if got := p.Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}); got != tt.expect {
+				t.Errorf("expect update %v, but got %v", tt.expect, got)
+			}
+			if got := p.Delete(event.DeleteEvent{Object: obj}); got != tt.expect {
+				t.Errorf("expect delete %v, but got %v", tt.expect, got)
+			}
+			if got := p.Generic(event.GenericEvent{Object: obj}); got != tt.expect {
+				t.Errorf("expect generic %v, but got %v", tt.expect, got)
+			}
+			if got := generatedSelector().Matches(labels.Set(tt.labels)); got != tt.expect {
+				t.Errorf("expect selector matches %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestGeneratedCacheOptions(t *testing.T) {
+	opts := generatedCacheOptions(newTestScheme(), nil)
+	if len(opts.SelectorsByObject) != 2 {
+		t.Fatalf("expect selectors of configmaps and services, but got %v", opts.SelectorsByObject)
+	}
+	generated := labels.Set{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}
+	for obj, selector := range opts.SelectorsByObject {
+		switch obj.(type) {
+		case *corev1.ConfigMap, *corev1.Service:
+		default:
+			t.Errorf("expect only configmaps and services are scoped, but got %T", obj)
+		}
+		if selector.Label == nil || !selector.Label.Matches(generated) || selector.Label.Matches(labels.Set{}) {
+			t.Errorf("expect label selector of generated objects for %T, but got %v", obj, selector.Label)
+		}
+	}
+
+	opts = frameworkCacheOptions(newTestScheme(), nil, "kube-system")
+	if opts.Namespace != "kube-system" {
+		t.Errorf("expect framework cache in kube-system, but got %q", opts.Namespace)
+	}
+	for obj, selector := range opts.SelectorsByObject {
+		if _, ok := obj.(*corev1.ConfigMap); !ok {
+			t.Errorf("expect only configmaps are scoped, but got %T", obj)
+		}
+		if selector.Field == nil || !selector.Field.Matches(fields.Set{"metadata.name": config.FrameworkConfigMapName}) || selector.Field.Matches(fields.Set{"metadata.name": "others"}) {
+			t.Errorf("expect field selector of framework configmap, but got %v", selector.Field)
+		}
+	}
+}
+
+func TestLabelScopedClient(t *testing.T) {
+	generated := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "common-variables", Labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelConfigmap},
+	}}
+	additional := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "additional"}}
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+
+	scheme := newTestScheme()
+	// the cache only holds the generated objects, while the apiserver holds all of them
+	c := &labelScopedClient{
+		Client:    fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(pa).Build(),
+		cache:     fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(generated).Build(),
+		apiReader: fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(generated.DeepCopy(), additional).Build(),
+	}
+
+	for _, name := range []string{generated.Name, additional.Name} {
+		if err := c.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, &corev1.ConfigMap{}); err != nil {
+			t.Errorf("expect configmap %s is got, but got %v", name, err)
+		}
+	}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(other), &corev1.ConfigMap{}); err == nil {
+		t.Errorf("expect configmap %s is not found", other.Name)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); err != nil {
+		t.Errorf("expect PlatformAdmin is got from the client, but got %v", err)
+	}
+
+	configmaps := &corev1.ConfigMapList{}
+	if err := c.List(context.TODO(), configmaps, client.InNamespace("default")); err != nil {
+		t.Fatalf("failed to list configmaps, %v", err)
+	}
+	if len(configmaps.Items) != 1 || configmaps.Items[0].Name != generated.Name {
+		t.Errorf("expect only the generated configmap is listed, but got %v", configmaps.Items)
+	}
+}
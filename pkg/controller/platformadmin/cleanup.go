@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// listPageSize is the number of objects listed by each request of listPages, so the apiserver is not asked for
+// all the generated objects of a namespace with many PlatformAdmins at once.
+var listPageSize int64 = 500
+
+// listPages lists the objects in pages and calls fn with each of them, the errors of fn are aggregated while a
+// failed list stops listing. The list is reused by the pages, so fn must not keep the objects.
+// The informer cache does not support continue, it returns a truncated page without continue token instead. Such
+// a full first page is discarded and the objects are listed again without limit, which is cheap from the cache.
+func (r *ReconcilePlatformAdmin) listPages(ctx context.Context, list client.ObjectList, fn func(client.Object) error, opts ...client.ListOption) error {
+	var errs []error
+	continueToken := ""
+	for {
+		pageOpts := append([]client.ListOption{client.Limit(listPageSize), client.Continue(continueToken)}, opts...)
+		if err := r.List(ctx, list, pageOpts...); err != nil {
+			return kerrors.NewAggregate(append(errs, err))
+		}
+		if continueToken == "" && list.GetContinue() == "" && int64(meta.LenList(list)) >= listPageSize {
+			if err := r.List(ctx, list, opts...); err != nil {
+				return kerrors.NewAggregate(append(errs, err))
+			}
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return kerrors.NewAggregate(append(errs, err))
+		}
+		for _, item := range items {
+			if err := fn(item.(client.Object)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if continueToken = list.GetContinue(); continueToken == "" {
+			return kerrors.NewAggregate(errs)
+		}
+	}
+}
+
+// releaseUnneeded releases the objects generated by PlatformAdmin with the label in its namespace, except the
+// needed ones. The failures of listing and releasing are returned, so they are retried by the next reconcile.
+func (r *ReconcilePlatformAdmin) releaseUnneeded(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, list client.ObjectList, label string, needed map[string]struct{}, release func(client.Object) error) error {
+	return r.forEachOwned(ctx, platformAdmin, list, label, func(obj client.Object) error {
+		if _, ok := needed[obj.GetName()]; ok {
+			return nil
+		}
+		if err := release(obj); err != nil {
+			return errors.Wrapf(err, "failed to release %s %s", resourceKind(obj), obj.GetName())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// pagingClient pages the lists like the apiserver, the continue token is the name of the last listed object.
+// truncate mimics the informer cache, which returns the first page without continue token, and ignoreLimit mimics
+// the fake client, which lists all the objects.
+type pagingClient struct {
+	client.Client
+	truncate    bool
+	ignoreLimit bool
+	lists       int
+	// listErr fails the list of the page with the continue token
+	listErr  map[string]error
+	patchErr error
+}
+
+func (c *pagingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if err, ok := c.listErr[listOpts.Continue]; ok {
+		return err
+	}
+	if err := c.Client.List(ctx, list, opts...); err != nil || c.ignoreLimit {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	// The fake client lists in random order, the pages are sorted to be stable
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].(client.Object).GetName() < items[j].(client.Object).GetName()
+	})
+	offset := sort.Search(len(items), func(i int) bool {
+		return items[i].(client.Object).GetName() > listOpts.Continue
+	})
+	end, continueToken := len(items), ""
+	if listOpts.Limit > 0 && offset+int(listOpts.Limit) < len(items) {
+		end = offset + int(listOpts.Limit)
+		if !c.truncate {
+			continueToken = items[end-1].(client.Object).GetName()
+		}
+	}
+	if err := meta.SetList(list, items[offset:end]); err != nil {
+		return err
+	}
+	list.SetContinue(continueToken)
+	return nil
+}
+
+func (c *pagingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if c.patchErr != nil {
+		return c.patchErr
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// newTestConfigMaps returns the configmaps generated by PlatformAdmin, which are named in order.
+func newTestConfigMaps(namespace string, count int) []client.Object {
+	objs := make([]client.Object, 0, count)
+	for i := 0; i < count; i++ {
+		objs = append(objs, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      fmt.Sprintf("configmap-%04d", i),
+			Labels:    map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelConfigmap},
+		}})
+	}
+	return objs
+}
+
+func TestListPages(t *testing.T) {
+	const count = 1203
+	tests := []struct {
+		name        string
+		truncate    bool
+		ignoreLimit bool
+		expectLists int
+	}{
+		{name: "paged by apiserver", expectLists: 3},
+		{name: "truncated by cache", truncate: true, expectLists: 2},
+		{name: "limit is ignored", ignoreLimit: true, expectLists: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(), newTestConfigMaps("default", count)...)
+			c := &pagingClient{Client: r.Client, truncate: tt.truncate, ignoreLimit: tt.ignoreLimit}
+			r.Client = c
+
+			visited := make(map[string]struct{})
+			if err := r.listPages(context.TODO(), &corev1.ConfigMapList{}, func(obj client.Object) error {
+				visited[obj.GetName()] = struct{}{}
+				return nil
+			}, client.InNamespace("default")); err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if len(visited) != count {
+				t.Errorf("expect %d configmaps are visited, but got %d", count, len(visited))
+			}
+			if c.lists != tt.expectLists {
+				t.Errorf("expect %d lists, but got %d", tt.expectLists, c.lists)
+			}
+		})
+	}
+}
+
+func TestListPagesErrors(t *testing.T) {
+	r := newTestReconciler(newTestConfiguration(), newTestConfigMaps("default", 1001)...)
+	listErr := apierrors.NewServiceUnavailable("apiserver is down")
+	r.Client = &pagingClient{Client: r.Client, listErr: map[string]error{"configmap-0999": listErr}}
+
+	visited := 0
+	err := r.listPages(context.TODO(), &corev1.ConfigMapList{}, func(obj client.Object) error {
+		visited++
+		if obj.GetName() == "configmap-0007" {
+			return fmt.Errorf("failed to handle %s", obj.GetName())
+		}
+		return nil
+	}, client.InNamespace("default"))
+	if !errors.Is(err, listErr) || !strings.Contains(err.Error(), "failed to handle configmap-0007") {
+		t.Errorf("expect errors of list and configmap-0007, but got %v", err)
+	}
+	if visited != 1000 {
+		t.Errorf("expect the configmaps of the listed pages are visited, but got %d", visited)
+	}
+}
+
+func TestReleaseUnneeded(t *testing.T) {
+	const count = 600
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	owner := metav1.OwnerReference{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: pa.Name, UID: pa.UID}
+	other := metav1.OwnerReference{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: "other", UID: "other-uid"}
+	newService := func(name string, labeled bool, owners ...metav1.OwnerReference) *corev1.Service {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: pa.Namespace, Name: name, OwnerReferences: owners}}
+		if labeled {
+			service.Labels = map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}
+		}
+		return service
+	}
+
+	objs := []client.Object{
+		pa,
+		newService("needed", true, owner),
+		newService("shared", true, owner, other),
+		newService("unlabeled", false, owner),
+	}
+	for i := 0; i < count; i++ {
+		objs = append(objs, newService(fmt.Sprintf("released-%04d", i), true, owner))
+	}
+	r := newTestReconciler(newTestConfiguration(), objs...)
+	// The deletions are recorded as events, which are dropped instead of filling up the channel
+	r.recorder = &record.FakeRecorder{}
+	c := &pagingClient{Client: r.Client}
+	r.Client = c
+
+	removeOwner := func(obj client.Object) error {
+		return r.removeOwner(context.TODO(), pa, obj)
+	}
+	if err := r.releaseUnneeded(context.TODO(), pa, &corev1.ServiceList{}, LabelService, map[string]struct{}{"needed": {}}, removeOwner); err != nil {
+		t.Fatalf("expect no error, but got %v", err)
+	}
+	if c.lists < 2 {
+		t.Errorf("expect the services are listed in pages, but got %d lists", c.lists)
+	}
+
+	services := &corev1.ServiceList{}
+	if err := r.List(context.TODO(), services, client.InNamespace(pa.Namespace)); err != nil {
+		t.Fatalf("failed to list services, %v", err)
+	}
+	owners := make(map[string][]types.UID)
+	for _, service := range services.Items {
+		for _, ref := range service.OwnerReferences {
+			owners[service.Name] = append(owners[service.Name], ref.UID)
+		}
+		if _, ok := owners[service.Name]; !ok {
+			owners[service.Name] = nil
+		}
+	}
+	expect := map[string][]types.UID{
+		"needed":    {pa.UID},
+		"shared":    {other.UID},
+		"unlabeled": {pa.UID},
+	}
+	if len(owners) != len(expect) {
+		t.Errorf("expect only the needed, shared and unlabeled services are left, but got %d services", len(owners))
+	}
+	for name, uids := range expect {
+		if fmt.Sprint(owners[name]) != fmt.Sprint(uids) {
+			t.Errorf("expect service %s is owned by %v, but got %v", name, uids, owners[name])
+		}
+	}
+
+	// the failures of release are returned
+	c.patchErr = apierrors.NewConflict(corev1.Resource("services"), "shared", errors.New("conflict"))
+	if err := r.Create(context.TODO(), newService("shared-again", true, owner, other)); err != nil {
+		t.Fatalf("failed to create service, %v", err)
+	}
+	err := r.releaseUnneeded(context.TODO(), pa, &corev1.ServiceList{}, LabelService, nil, removeOwner)
+	if err == nil || !strings.Contains(err.Error(), "failed to release Service shared-again") {
+		t.Errorf("expect error of service shared-again, but got %v", err)
+	}
+}
+
+func TestReconcileConfigmapListError(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	listErr := apierrors.NewServiceUnavailable("apiserver is down")
+	r.Client = &listFailingClient{Client: r.Client, list: &corev1.ConfigMapList{}, err: listErr}
+
+	ok, err := r.reconcileConfigmap(context.TODO(), pa, pa.Status.DeepCopy(), r.getConfiguration())
+	if ok || !errors.Is(err, listErr) {
+		t.Errorf("expect the list error is returned, but got %v, %v", ok, err)
+	}
+}
+
+// listFailingClient fails the lists of the same type as list.
+type listFailingClient struct {
+	client.Client
+	list runtime.Object
+	err  error
+}
+
+func (c *listFailingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if fmt.Sprintf("%T", list) == fmt.Sprintf("%T", c.list) {
+		return c.err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
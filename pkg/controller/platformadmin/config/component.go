@@ -0,0 +1,304 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// ErrInvalidComponent is returned when a component is invalid on its own, e.g. a container without image.
+var ErrInvalidComponent = errors.New("invalid component")
+
+// ComponentOption customizes the component created by NewComponent.
+type ComponentOption func(*Component)
+
+// WithDeployment sets the deployment spec of component.
+func WithDeployment(deployment *appsv1.DeploymentSpec) ComponentOption {
+	return func(c *Component) {
+		c.Deployment = deployment
+	}
+}
+
+// WithService sets the service spec of component, the service is named after the component.
+func WithService(service *corev1.ServiceSpec) ComponentOption {
+	return func(c *Component) {
+		c.Service = service
+	}
+}
+
+// WithWorkloadType sets the workload type of component, Deployment or DaemonSet.
+func WithWorkloadType(workloadType string) ComponentOption {
+	return func(c *Component) {
+		c.WorkloadType = workloadType
+	}
+}
+
+// WithServiceTopology sets the service topology of component, nodepool, zone or none.
+func WithServiceTopology(serviceTopology string) ComponentOption {
+	return func(c *Component) {
+		c.ServiceTopology = serviceTopology
+	}
+}
+
+// WithUpdateStrategy sets the update strategy of the deployment of component.
+func WithUpdateStrategy(strategy *appsv1.DeploymentStrategy) ComponentOption {
+	return func(c *Component) {
+		c.UpdateStrategy = strategy
+	}
+}
+
+// WithAutoscaling sets the autoscaling of the deployment of component.
+func WithAutoscaling(autoscaling *iotv1alpha2.ComponentAutoscaling) ComponentOption {
+	return func(c *Component) {
+		c.Autoscaling = autoscaling
+	}
+}
+
+// WithReplicas sets the replicas of component in every pool.
+func WithReplicas(replicas int32) ComponentOption {
+	return func(c *Component) {
+		c.Replicas = &replicas
+	}
+}
+
+// WithPersistentVolumeClaim sets the template of the claim created for component in each pool.
+func WithPersistentVolumeClaim(claim *PersistentVolumeClaimTemplate) ComponentOption {
+	return func(c *Component) {
+		c.PersistentVolumeClaim = claim
+	}
+}
+
+// WithMetrics sets the metrics endpoint of component.
+func WithMetrics(metrics *ComponentMetrics) ComponentOption {
+	return func(c *Component) {
+		c.Metrics = metrics
+	}
+}
+
+// WithSupportedArchitectures sets the node architectures the images of component are built for.
+func WithSupportedArchitectures(architectures ...string) ComponentOption {
+	return func(c *Component) {
+		c.SupportedArchitectures = architectures
+	}
+}
+
+// NewComponent creates a component with the name, the component is not validated, call Validate before using it.
+func NewComponent(name string, opts ...ComponentOption) *Component {
+	component := &Component{Name: name}
+	for _, opt := range opts {
+		opt(component)
+	}
+	return component
+}
+
+// Validate checks the component on its own: the name is a DNS-1123 label since the objects are named after it,
+// a workload component has a deployment whose containers all have images, and the service ports are sane.
+// The collisions with other components are checked by ValidateComponentSet.
+func (c *Component) Validate() error {
+	if c == nil {
+		return fmt.Errorf("%w: component can not be nil", ErrInvalidComponent)
+	}
+	var problems []string
+	if c.Name == "" {
+		problems = append(problems, "name can not be empty")
+	} else if errs := validation.IsDNS1123Label(c.Name); len(errs) > 0 {
+		problems = append(problems, fmt.Sprintf("name %q is invalid: %s", c.Name, strings.Join(errs, ",")))
+	}
+
+	switch c.WorkloadType {
+	case "", iotv1alpha2.WorkloadTypeDeployment, iotv1alpha2.WorkloadTypeDaemonSet:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown workload type %q", c.WorkloadType))
+	}
+	switch {
+	case c.Deployment == nil && c.WorkloadType != "":
+		problems = append(problems, fmt.Sprintf("workload type %s requires deployment", c.WorkloadType))
+	case c.Deployment == nil && c.Service == nil:
+		problems = append(problems, "neither deployment nor service is specified")
+	}
+	if c.Deployment != nil {
+		problems = append(problems, validateDeployment(c.Deployment)...)
+	}
+	if c.Service != nil {
+		problems = append(problems, validateServicePorts("service", c.Service.Ports)...)
+	}
+	if c.HTTPSOverrides != nil {
+		for _, port := range c.HTTPSOverrides.Ports {
+			if !isValidPort(port.Port) {
+				problems = append(problems, fmt.Sprintf("https overrides has invalid port %d", port.Port))
+			}
+		}
+	}
+
+	switch c.ServiceTopology {
+	case "", iotv1alpha2.ServiceTopologyNodePool, iotv1alpha2.ServiceTopologyZone, iotv1alpha2.ServiceTopologyNone:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown service topology %q", c.ServiceTopology))
+	}
+	if err := ValidateProbe(c.LivenessProbe); err != nil {
+		problems = append(problems, fmt.Sprintf("liveness probe is invalid: %v", err))
+	}
+	if err := ValidateProbe(c.ReadinessProbe); err != nil {
+		problems = append(problems, fmt.Sprintf("readiness probe is invalid: %v", err))
+	}
+	if err := ValidateProbe(c.StartupProbe); err != nil {
+		problems = append(problems, fmt.Sprintf("startup probe is invalid: %v", err))
+	}
+	if err := ValidateUpdateStrategy(c.UpdateStrategy); err != nil {
+		problems = append(problems, fmt.Sprintf("update strategy is invalid: %v", err))
+	}
+	if err := ValidateAutoscaling(c.Autoscaling); err != nil {
+		problems = append(problems, fmt.Sprintf("autoscaling is invalid: %v", err))
+	}
+	if c.Replicas != nil && *c.Replicas < 0 {
+		problems = append(problems, fmt.Sprintf("replicas must not be negative, but got %d", *c.Replicas))
+	}
+	problems = append(problems, validateMetrics(c.Metrics, c.Service)...)
+	problems = append(problems, validateArchitectures(c.SupportedArchitectures)...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %s: %s", ErrInvalidComponent, c.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateComponents validates every component, the errors of all invalid components are aggregated.
+func ValidateComponents(components []*Component) error {
+	var errs []error
+	for _, component := range components {
+		if err := component.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateDeployment checks that the deployment has containers and every container has an image.
+func validateDeployment(deployment *appsv1.DeploymentSpec) []string {
+	var problems []string
+	podSpec := &deployment.Template.Spec
+	if len(podSpec.Containers) == 0 {
+		problems = append(problems, "deployment has no containers")
+	}
+	for _, container := range podSpec.InitContainers {
+		if container.Image == "" {
+			problems = append(problems, fmt.Sprintf("init container %s has no image", container.Name))
+		}
+	}
+	for _, container := range podSpec.Containers {
+		if container.Image == "" {
+			problems = append(problems, fmt.Sprintf("container %s has no image", container.Name))
+		}
+	}
+	return problems
+}
+
+// validateServicePorts checks the range of ports and target ports, and that the ports are named uniquely,
+// a service with more than one port requires every port to be named.
+func validateServicePorts(what string, ports []corev1.ServicePort) []string {
+	var problems []string
+	names := make(map[string]struct{}, len(ports))
+	for _, port := range ports {
+		if !isValidPort(port.Port) {
+			problems = append(problems, fmt.Sprintf("%s has invalid port %d", what, port.Port))
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal != 0 && !isValidPort(port.TargetPort.IntVal) {
+			problems = append(problems, fmt.Sprintf("%s has invalid target port %d", what, port.TargetPort.IntVal))
+		}
+		if port.Name == "" {
+			if len(ports) > 1 {
+				problems = append(problems, fmt.Sprintf("port %d of %s must be named since %s has more than one port", port.Port, what, what))
+			}
+			continue
+		}
+		if _, ok := names[port.Name]; ok {
+			problems = append(problems, fmt.Sprintf("%s has duplicate port name %s", what, port.Name))
+		}
+		names[port.Name] = struct{}{}
+	}
+	return problems
+}
+
+// validateMetrics checks that the metrics are served through the service of component, the named port must be one
+// of the service ports.
+func validateMetrics(metrics *ComponentMetrics, service *corev1.ServiceSpec) []string {
+	if metrics == nil {
+		return nil
+	}
+	var problems []string
+	if service == nil {
+		problems = append(problems, "metrics requires service")
+	}
+	if metrics.PortName == "" && metrics.Port == 0 {
+		problems = append(problems, "metrics requires port name or port")
+	}
+	if metrics.Port != 0 && !isValidPort(metrics.Port) {
+		problems = append(problems, fmt.Sprintf("metrics has invalid port %d", metrics.Port))
+	}
+	if metrics.PortName != "" && service != nil && MetricsServicePort(metrics, service) == nil {
+		problems = append(problems, fmt.Sprintf("metrics port %s is not a service port", metrics.PortName))
+	}
+	if metrics.Path != "" && !strings.HasPrefix(metrics.Path, "/") {
+		problems = append(problems, fmt.Sprintf("metrics path %q must start with /", metrics.Path))
+	}
+	return problems
+}
+
+// MetricsServicePort returns the service port named by the metrics, it is nil if there is no such port.
+func MetricsServicePort(metrics *ComponentMetrics, service *corev1.ServiceSpec) *corev1.ServicePort {
+	for i := range service.Ports {
+		if service.Ports[i].Name == metrics.PortName {
+			return &service.Ports[i]
+		}
+	}
+	return nil
+}
+
+func isValidPort(port int32) bool {
+	return port > 0 && port <= 65535
+}
+
+// validateArchitectures checks the supported architectures are valid label values without duplicates.
+func validateArchitectures(architectures []string) []string {
+	var problems []string
+	seen := make(map[string]struct{}, len(architectures))
+	for _, arch := range architectures {
+		if arch == "" {
+			problems = append(problems, "supported architecture can not be empty")
+			continue
+		}
+		if errs := validation.IsValidLabelValue(arch); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("supported architecture %q is invalid: %s", arch, strings.Join(errs, ",")))
+			continue
+		}
+		if _, ok := seen[arch]; ok {
+			problems = append(problems, fmt.Sprintf("supported architecture %s is duplicated", arch))
+		}
+		seen[arch] = struct{}{}
+	}
+	return problems
+}
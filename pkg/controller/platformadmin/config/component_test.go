@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func newTestDeployment(images ...string) *appsv1.DeploymentSpec {
+	deployment := &appsv1.DeploymentSpec{}
+	for i, image := range images {
+		deployment.Template.Spec.Containers = append(deployment.Template.Spec.Containers, corev1.Container{Name: string(rune('a' + i)), Image: image})
+	}
+	return deployment
+}
+
+func newTestService(ports ...corev1.ServicePort) *corev1.ServiceSpec {
+	return &corev1.ServiceSpec{Ports: ports}
+}
+
+func TestNewComponent(t *testing.T) {
+	deployment := newTestDeployment("edgexfoundry/core-data:2.3.0")
+	service := newTestService(corev1.ServicePort{Port: 59880})
+	strategy := &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	autoscaling := &iotv1alpha2.ComponentAutoscaling{MaxReplicas: 3}
+	claim := &PersistentVolumeClaimTemplate{}
+	metrics := &ComponentMetrics{Port: 59880}
+
+	component := NewComponent("edgex-core-data",
+		WithDeployment(deployment),
+		WithService(service),
+		WithWorkloadType(iotv1alpha2.WorkloadTypeDaemonSet),
+		WithServiceTopology(iotv1alpha2.ServiceTopologyZone),
+		WithUpdateStrategy(strategy),
+		WithAutoscaling(autoscaling),
+		WithPersistentVolumeClaim(claim),
+		WithMetrics(metrics),
+		WithReplicas(3),
+	)
+	if component.Name != "edgex-core-data" {
+		t.Errorf("expect name edgex-core-data, but got %s", component.Name)
+	}
+	if component.Deployment != deployment || component.Service != service {
+		t.Errorf("expect deployment and service are set, but got %v, %v", component.Deployment, component.Service)
+	}
+	if component.WorkloadType != iotv1alpha2.WorkloadTypeDaemonSet || component.ServiceTopology != iotv1alpha2.ServiceTopologyZone {
+		t.Errorf("expect daemonset workload in zone topology, but got %s, %s", component.WorkloadType, component.ServiceTopology)
+	}
+	if component.UpdateStrategy != strategy || component.Autoscaling != autoscaling || component.PersistentVolumeClaim != claim {
+		t.Errorf("expect update strategy, autoscaling and claim are set, but got %v, %v, %v", component.UpdateStrategy, component.Autoscaling, component.PersistentVolumeClaim)
+	}
+	if component.Metrics != metrics {
+		t.Errorf("expect metrics are set, but got %v", component.Metrics)
+	}
+	if component.Replicas == nil || *component.Replicas != 3 {
+		t.Errorf("expect 3 replicas, but got %v", component.Replicas)
+	}
+
+	// the later option wins
+	component = NewComponent("edgex-core-data", WithWorkloadType(iotv1alpha2.WorkloadTypeDaemonSet), WithWorkloadType(iotv1alpha2.WorkloadTypeDeployment))
+	if component.WorkloadType != iotv1alpha2.WorkloadTypeDeployment {
+		t.Errorf("expect workload type %s, but got %s", iotv1alpha2.WorkloadTypeDeployment, component.WorkloadType)
+	}
+}
+
+func TestComponentValidate(t *testing.T) {
+	image := "edgexfoundry/core-data:2.3.0"
+	tests := []struct {
+		name      string
+		component *Component
+		// expectErrors are the substrings of error, no error is expected if it is empty
+		expectErrors []string
+	}{
+		{
+			name:      "deployment and service",
+			component: NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithService(newTestService(corev1.ServicePort{Port: 59880}))),
+		},
+		{
+			name:      "service only",
+			component: NewComponent("edgex-redis", WithService(newTestService(corev1.ServicePort{Name: "tcp", Port: 6379}, corev1.ServicePort{Name: "admin", Port: 6380}))),
+		},
+		{
+			name:      "daemonset with probes and string target port",
+			component: NewComponent("edgex-device-virtual", WithDeployment(newTestDeployment(image)), WithWorkloadType(iotv1alpha2.WorkloadTypeDaemonSet), WithService(newTestService(corev1.ServicePort{Port: 59900, TargetPort: intstr.FromString("http")}))),
+		},
+		{
+			name:         "nil component",
+			expectErrors: []string{"component can not be nil"},
+		},
+		{
+			name:         "empty name",
+			component:    NewComponent("", WithDeployment(newTestDeployment(image))),
+			expectErrors: []string{"name can not be empty"},
+		},
+		{
+			name:         "name is not a DNS-1123 label",
+			component:    NewComponent("Edgex_Core", WithDeployment(newTestDeployment(image))),
+			expectErrors: []string{`name "Edgex_Core" is invalid`},
+		},
+		{
+			name:         "neither deployment nor service",
+			component:    NewComponent("edgex-core-data"),
+			expectErrors: []string{"neither deployment nor service is specified"},
+		},
+		{
+			name:         "workload type without deployment",
+			component:    NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Port: 59880})), WithWorkloadType(iotv1alpha2.WorkloadTypeDaemonSet)),
+			expectErrors: []string{"workload type DaemonSet requires deployment"},
+		},
+		{
+			name:         "unknown workload type and service topology",
+			component:    NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithWorkloadType("StatefulSet"), WithServiceTopology("region")),
+			expectErrors: []string{`unknown workload type "StatefulSet"`, `unknown service topology "region"`},
+		},
+		{
+			name:         "deployment without containers",
+			component:    NewComponent("edgex-core-data", WithDeployment(newTestDeployment())),
+			expectErrors: []string{"deployment has no containers"},
+		},
+		{
+			name: "containers without images",
+			component: func() *Component {
+				deployment := newTestDeployment(image, "")
+				deployment.Template.Spec.InitContainers = []corev1.Container{{Name: "init"}}
+				return NewComponent("edgex-core-data", WithDeployment(deployment))
+			}(),
+			expectErrors: []string{"init container init has no image", "container b has no image"},
+		},
+		{
+			name:         "invalid service ports",
+			component:    NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Name: "http", Port: 0}, corev1.ServicePort{Name: "grpc", Port: 59880, TargetPort: intstr.FromInt(70000)}))),
+			expectErrors: []string{"service has invalid port 0", "service has invalid target port 70000"},
+		},
+		{
+			name:         "duplicate port names",
+			component:    NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Name: "http", Port: 59880}, corev1.ServicePort{Name: "http", Port: 59881}))),
+			expectErrors: []string{"service has duplicate port name http"},
+		},
+		{
+			name:         "unnamed port among multiple ports",
+			component:    NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Name: "http", Port: 59880}, corev1.ServicePort{Port: 59881}))),
+			expectErrors: []string{"port 59881 of service must be named"},
+		},
+		{
+			name: "invalid https overrides port",
+			component: func() *Component {
+				component := NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)))
+				component.HTTPSOverrides = &HTTPSOverrides{Ports: []corev1.ServicePort{{Port: 65536}}}
+				return component
+			}(),
+			expectErrors: []string{"https overrides has invalid port 65536"},
+		},
+		{
+			name: "invalid probe, update strategy and autoscaling",
+			component: func() *Component {
+				component := NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)),
+					WithUpdateStrategy(&appsv1.DeploymentStrategy{Type: "Unknown"}),
+					WithAutoscaling(&iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(3), MaxReplicas: 2}))
+				component.ReadinessProbe = &corev1.Probe{Handler: corev1.Handler{Exec: &corev1.ExecAction{}, TCPSocket: &corev1.TCPSocketAction{}}}
+				return component
+			}(),
+			expectErrors: []string{"readiness probe is invalid", "update strategy is invalid", "autoscaling is invalid"},
+		},
+		{
+			name:         "negative replicas",
+			component:    NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithReplicas(-1)),
+			expectErrors: []string{"replicas must not be negative"},
+		},
+		{
+			name: "metrics of named service port",
+			component: NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Name: "http", Port: 59880})),
+				WithMetrics(&ComponentMetrics{PortName: "http", Path: "/api/v2/metrics"})),
+		},
+		{
+			name:         "metrics without service",
+			component:    NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithMetrics(&ComponentMetrics{Port: 59880})),
+			expectErrors: []string{"metrics requires service"},
+		},
+		{
+			name: "invalid metrics",
+			component: NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Name: "http", Port: 59880})),
+				WithMetrics(&ComponentMetrics{PortName: "metrics", Port: 70000, Path: "metrics"})),
+			expectErrors: []string{"metrics has invalid port 70000", "metrics port metrics is not a service port", `metrics path "metrics" must start with /`},
+		},
+		{
+			name:         "metrics without port",
+			component:    NewComponent("edgex-core-data", WithService(newTestService(corev1.ServicePort{Port: 59880})), WithMetrics(&ComponentMetrics{})),
+			expectErrors: []string{"metrics requires port name or port"},
+		},
+		{
+			name:      "supported architectures",
+			component: NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithSupportedArchitectures("amd64", "arm64")),
+		},
+		{
+			name:         "invalid supported architectures",
+			component:    NewComponent("edgex-core-data", WithDeployment(newTestDeployment(image)), WithSupportedArchitectures("amd64", "", "arm/v7", "amd64")),
+			expectErrors: []string{"supported architecture can not be empty", `supported architecture "arm/v7" is invalid`, "supported architecture amd64 is duplicated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.component.Validate()
+			if len(tt.expectErrors) == 0 {
+				if err != nil {
+					t.Errorf("expect no error, but got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidComponent) {
+				t.Fatalf("expect error %v, but got %v", ErrInvalidComponent, err)
+			}
+			for _, expectError := range tt.expectErrors {
+				if !strings.Contains(err.Error(), expectError) {
+					t.Errorf("expect error %s, but got %v", expectError, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateComponents(t *testing.T) {
+	valid := NewComponent("edgex-core-data", WithDeployment(newTestDeployment("edgexfoundry/core-data:2.3.0")))
+	if err := ValidateComponents([]*Component{valid}); err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+	if err := ValidateComponents(nil); err != nil {
+		t.Errorf("expect no error of no components, but got %v", err)
+	}
+
+	err := ValidateComponents([]*Component{valid, NewComponent("edgex-a"), NewComponent("edgex-b", WithDeployment(newTestDeployment("")))})
+	if !errors.Is(err, ErrInvalidComponent) {
+		t.Fatalf("expect error %v, but got %v", ErrInvalidComponent, err)
+	}
+	for _, expectError := range []string{"edgex-a: neither deployment nor service", "edgex-b: container a has no image"} {
+		if !strings.Contains(err.Error(), expectError) {
+			t.Errorf("expect error %s, but got %v", expectError, err)
+		}
+	}
+	if strings.Contains(err.Error(), "edgex-core-data") {
+		t.Errorf("expect valid component is not reported, but got %v", err)
+	}
+}
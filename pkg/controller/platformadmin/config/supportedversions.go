@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const (
+	// SupportedVersionsConfigMapName is the name of configmap in the working namespace of yurt-manager, which
+	// publishes the supported versions of PlatformAdmin to the clients, e.g. yurt CLI and UI.
+	SupportedVersionsConfigMapName = "platformadmin-supported-versions"
+	// SupportedVersionsKey is the data key of the supported versions configmap, its content is the json
+	// document of SupportedVersions.
+	SupportedVersionsKey = "versions.json"
+)
+
+// SupportedVersion describes a version of PlatformAdmin, and the component sets available for it.
+type SupportedVersion struct {
+	Name string `json:"name"`
+	// Security and NoSecty tell whether the security and nosecty component sets exist for the version
+	Security bool `json:"security"`
+	NoSecty  bool `json:"nosecty"`
+	// SecurityComponents and NoSectyComponents are the names of components per security mode
+	SecurityComponents []string `json:"securityComponents,omitempty"`
+	NoSectyComponents  []string `json:"nosectyComponents,omitempty"`
+}
+
+// SupportedVersions returns the versions of the configuration sorted by name. It is the only source of the supported
+// versions, so the published ones and the ones accepted by the webhook can not disagree.
+func SupportedVersions(conf *PlatformAdminControllerConfiguration) []SupportedVersion {
+	if conf == nil {
+		return nil
+	}
+	versions := make(map[string]*SupportedVersion)
+	get := func(name string) *SupportedVersion {
+		if _, ok := versions[name]; !ok {
+			versions[name] = &SupportedVersion{Name: name}
+		}
+		return versions[name]
+	}
+	for name, components := range conf.SecurityComponents {
+		version := get(name)
+		version.Security = true
+		version.SecurityComponents = componentNames(components)
+	}
+	for name, components := range conf.NoSectyComponents {
+		version := get(name)
+		version.NoSecty = true
+		version.NoSectyComponents = componentNames(components)
+	}
+
+	supported := make([]SupportedVersion, 0, len(versions))
+	for _, version := range versions {
+		supported = append(supported, *version)
+	}
+	sort.Slice(supported, func(i, j int) bool { return supported[i].Name < supported[j].Name })
+	return supported
+}
+
+// FindSupportedVersion returns the supported version with the name, and false if it is not supported.
+func FindSupportedVersion(versions []SupportedVersion, name string) (SupportedVersion, bool) {
+	for _, version := range versions {
+		if version.Name == name {
+			return version, true
+		}
+	}
+	return SupportedVersion{}, false
+}
+
+// EncodeSupportedVersions encodes the supported versions into the content of the supported versions configmap.
+func EncodeSupportedVersions(versions []SupportedVersion) (string, error) {
+	content, err := json.Marshal(versions)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func componentNames(components []*Component) []string {
+	var names []string
+	for _, component := range components {
+		if component != nil {
+			names = append(names, component.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSupportedVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   *PlatformAdminControllerConfiguration
+		expect []SupportedVersion
+	}{
+		{
+			name: "nil configuration",
+		},
+		{
+			name: "both modes and nosecty only",
+			conf: &PlatformAdminControllerConfiguration{
+				SecurityComponents: map[string][]*Component{
+					"levski": {{Name: "edgex-redis"}, {Name: "edgex-core-command"}},
+				},
+				NoSectyComponents: map[string][]*Component{
+					"levski":    {{Name: "edgex-core-command"}},
+					"minnesota": {{Name: "edgex-core-metadata"}, nil},
+				},
+			},
+			expect: []SupportedVersion{
+				{
+					Name:               "levski",
+					Security:           true,
+					NoSecty:            true,
+					SecurityComponents: []string{"edgex-core-command", "edgex-redis"},
+					NoSectyComponents:  []string{"edgex-core-command"},
+				},
+				{
+					Name:              "minnesota",
+					NoSecty:           true,
+					NoSectyComponents: []string{"edgex-core-metadata"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SupportedVersions(tt.conf); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestEmbedSupportedVersions(t *testing.T) {
+	// the embed versions support both modes
+	versions := SupportedVersions(NewPlatformAdminControllerConfiguration())
+	if len(versions) == 0 {
+		t.Fatalf("expect embed versions, but got nothing")
+	}
+	for _, version := range versions {
+		if !version.Security || !version.NoSecty {
+			t.Errorf("expect version %s supports both modes, but got %v", version.Name, version)
+		}
+	}
+	if _, ok := FindSupportedVersion(versions, "levski"); !ok {
+		t.Errorf("expect levski is supported, but got %v", versions)
+	}
+	if _, ok := FindSupportedVersion(versions, "unknown"); ok {
+		t.Errorf("expect unknown is not supported")
+	}
+}
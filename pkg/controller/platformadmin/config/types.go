@@ -19,11 +19,19 @@ package config
 import (
 	"embed"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
 )
 
 type EdgeXConfig struct {
@@ -40,6 +48,66 @@ type Component struct {
 	Name       string                 `yaml:"name" json:"name"`
 	Service    *corev1.ServiceSpec    `yaml:"service,omitempty" json:"service,omitempty"`
 	Deployment *appsv1.DeploymentSpec `yaml:"deployment,omitempty" json:"deployment,omitempty"`
+	// ServiceTopology is one of nodepool, zone and none, and defaults to nodepool
+	ServiceTopology string `yaml:"serviceTopology,omitempty" json:"serviceTopology,omitempty"`
+	// WorkloadType is one of Deployment and DaemonSet, and defaults to Deployment
+	WorkloadType string `yaml:"workloadType,omitempty" json:"workloadType,omitempty"`
+	// LivenessProbe, ReadinessProbe and StartupProbe override the probes of the first container of deployment,
+	// a nil probe keeps the one of deployment and an empty probe removes it
+	LivenessProbe  *corev1.Probe `yaml:"livenessProbe,omitempty" json:"livenessProbe,omitempty"`
+	ReadinessProbe *corev1.Probe `yaml:"readinessProbe,omitempty" json:"readinessProbe,omitempty"`
+	StartupProbe   *corev1.Probe `yaml:"startupProbe,omitempty" json:"startupProbe,omitempty"`
+	// HTTPSOverrides are applied when the https scheme is selected by PlatformAdmin
+	HTTPSOverrides *HTTPSOverrides `yaml:"httpsOverrides,omitempty" json:"httpsOverrides,omitempty"`
+	// PersistentVolumeClaim is the template of the claim created for the component in each pool
+	PersistentVolumeClaim *PersistentVolumeClaimTemplate `yaml:"persistentVolumeClaim,omitempty" json:"persistentVolumeClaim,omitempty"`
+	// UpdateStrategy overrides the strategy of deployment, e.g. Recreate for the device services which can not
+	// run two instances at the same time
+	UpdateStrategy *appsv1.DeploymentStrategy `yaml:"updateStrategy,omitempty" json:"updateStrategy,omitempty"`
+	// Autoscaling scales the deployment of component in every pool by a HorizontalPodAutoscaler
+	Autoscaling *iotv1alpha2.ComponentAutoscaling `yaml:"autoscaling,omitempty" json:"autoscaling,omitempty"`
+	// Replicas is the number of replicas of component in every pool, and defaults to 1
+	Replicas *int32 `yaml:"replicas,omitempty" json:"replicas,omitempty"`
+	// Metrics tells where the component exposes its metrics, so they can be scraped through its service
+	Metrics *ComponentMetrics `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+	// SupportedArchitectures are the values of kubernetes.io/arch the images of component are built for, e.g. amd64
+	// and arm64, the component is scheduled to any architecture if it is empty
+	SupportedArchitectures []string `yaml:"supportedArchitectures,omitempty" json:"supportedArchitectures,omitempty"`
+}
+
+// HTTPSOverrides are the changes of a component to serve and access the other components over https.
+type HTTPSOverrides struct {
+	// Ports replace the service ports with the same name, and the others are appended
+	Ports []corev1.ServicePort `yaml:"ports,omitempty" json:"ports,omitempty"`
+	// Env is merged into the env of the first container of deployment
+	Env []corev1.EnvVar `yaml:"env,omitempty" json:"env,omitempty"`
+	// Variables are merged into the common variable configmaps, e.g. SERVICE_SERVERBINDADDR
+	Variables map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"`
+}
+
+// PersistentVolumeClaimTemplate describes the persistent storage of a stateful component, e.g. redis.
+// A claim named <component>-<pool> is created for every pool the component is deployed to.
+type PersistentVolumeClaimTemplate struct {
+	// Name is the name of volume in the deployment, the volume with the same name declared by the deployment
+	// (e.g. an emptyDir placeholder) is replaced by the claim
+	Name             string            `yaml:"name" json:"name"`
+	StorageClassName *string           `yaml:"storageClassName,omitempty" json:"storageClassName,omitempty"`
+	Size             resource.Quantity `yaml:"size" json:"size"`
+	// AccessModes defaults to ReadWriteOnce
+	AccessModes []corev1.PersistentVolumeAccessMode `yaml:"accessModes,omitempty" json:"accessModes,omitempty"`
+	// MountPath is where the volume is mounted into the first container of deployment,
+	// no mount is added if it is empty(e.g. the deployment mounts the placeholder volume already)
+	MountPath string `yaml:"mountPath,omitempty" json:"mountPath,omitempty"`
+}
+
+// ComponentMetrics describes the metrics endpoint of a component. At least one of PortName and Port is required.
+type ComponentMetrics struct {
+	// PortName is the name of service port serving the metrics, which the ServiceMonitor scrapes
+	PortName string `yaml:"portName,omitempty" json:"portName,omitempty"`
+	// Port is the container port serving the metrics, it defaults to the target port of the named service port
+	Port int32 `yaml:"port,omitempty" json:"port,omitempty"`
+	// Path defaults to /metrics
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
 }
 
 var (
@@ -54,26 +122,167 @@ var (
 	nosectyFile  = filepath.Join(folder, "config-nosecty.json")
 )
 
+const (
+	// FrameworkConfigMapName is the name of configmap in the working namespace of yurt-manager, which can
+	// override the embed component templates without restarting yurt-manager.
+	FrameworkConfigMapName = "platformadmin-framework"
+	// SecurityConfigKey and NoSectyConfigKey are the data keys of framework configmap, their contents have
+	// the same format as the embed config files.
+	SecurityConfigKey = "config.json"
+	NoSectyConfigKey  = "config-nosecty.json"
+)
+
+// MaxAdditionalComponents caps the number of additional components carried by the annotations of a PlatformAdmin,
+// so a malformed or abusive annotation can not make the controller generate countless workloads. No cap if not positive.
+var MaxAdditionalComponents = 32
+
+// ErrTooManyAdditionalComponents is returned when the additional components exceed MaxAdditionalComponents.
+var ErrTooManyAdditionalComponents = errors.New("too many additional components")
+
+// ValidateAdditionalComponentsCount checks the number of additional components against MaxAdditionalComponents.
+func ValidateAdditionalComponentsCount(count int) error {
+	if MaxAdditionalComponents > 0 && count > MaxAdditionalComponents {
+		return fmt.Errorf("%w: %d exceeds the maximum %d", ErrTooManyAdditionalComponents, count, MaxAdditionalComponents)
+	}
+	return nil
+}
+
+// ValidateProbe checks that the probe specifies at most one handler, an empty probe is valid since it means
+// removing the probe.
+func ValidateProbe(probe *corev1.Probe) error {
+	if probe == nil {
+		return nil
+	}
+	var handlers []string
+	if probe.Exec != nil {
+		handlers = append(handlers, "exec")
+	}
+	if probe.HTTPGet != nil {
+		handlers = append(handlers, "httpGet")
+	}
+	if probe.TCPSocket != nil {
+		handlers = append(handlers, "tcpSocket")
+	}
+	if len(handlers) > 1 {
+		return fmt.Errorf("may not specify more than one handler type, but got %s", strings.Join(handlers, ","))
+	}
+	return nil
+}
+
+// ValidateUpdateStrategy checks the type of update strategy, and that the rolling update parameters are only
+// specified for the RollingUpdate type.
+func ValidateUpdateStrategy(strategy *appsv1.DeploymentStrategy) error {
+	if strategy == nil {
+		return nil
+	}
+	switch strategy.Type {
+	case appsv1.RecreateDeploymentStrategyType:
+		if strategy.RollingUpdate != nil {
+			return fmt.Errorf("rollingUpdate may not be specified when strategy type is %s", appsv1.RecreateDeploymentStrategyType)
+		}
+	case "", appsv1.RollingUpdateDeploymentStrategyType:
+	default:
+		return fmt.Errorf("unknown strategy type %q, must be %s or %s", strategy.Type,
+			appsv1.RecreateDeploymentStrategyType, appsv1.RollingUpdateDeploymentStrategyType)
+	}
+	return nil
+}
+
+// ValidateAutoscaling checks the limits of replicas and the target CPU utilization of autoscaling.
+func ValidateAutoscaling(autoscaling *iotv1alpha2.ComponentAutoscaling) error {
+	if autoscaling == nil {
+		return nil
+	}
+	if autoscaling.MaxReplicas < 1 {
+		return fmt.Errorf("maxReplicas must be at least 1, but got %d", autoscaling.MaxReplicas)
+	}
+	if min := autoscaling.MinReplicas; min != nil {
+		if *min < 1 {
+			return fmt.Errorf("minReplicas must be at least 1, but got %d", *min)
+		}
+		if *min > autoscaling.MaxReplicas {
+			return fmt.Errorf("minReplicas %d is greater than maxReplicas %d", *min, autoscaling.MaxReplicas)
+		}
+	}
+	if target := autoscaling.TargetCPUUtilizationPercentage; target != nil && *target < 1 {
+		return fmt.Errorf("targetCPUUtilizationPercentage must be at least 1, but got %d", *target)
+	}
+	return nil
+}
+
+// ErrInvalidComponentSet is returned when the components to deploy collide with each other.
+var ErrInvalidComponentSet = errors.New("invalid component set")
+
+// ValidateComponentSet checks the components to deploy together, which are valid on their own but collide with
+// each other: the duplicate component names, the duplicate service names and the node ports allocated more than
+// once. The collisions are found before any object is created, so a component set is never applied partially.
+func ValidateComponentSet(components []*Component) error {
+	var problems []string
+	componentNames := make(map[string]int)
+	serviceNames := make(map[string]int)
+	nodePorts := make(map[int32][]string)
+	for _, component := range components {
+		if component == nil {
+			continue
+		}
+		componentNames[component.Name]++
+		if component.Service == nil {
+			continue
+		}
+		// The service is named after the component
+		serviceNames[component.Name]++
+		for _, port := range component.Service.Ports {
+			if port.NodePort != 0 {
+				nodePorts[port.NodePort] = append(nodePorts[port.NodePort], component.Name+"/"+port.Name)
+			}
+		}
+	}
+
+	if duplicates := duplicateNames(componentNames); len(duplicates) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate components %s", strings.Join(duplicates, ",")))
+	}
+	if duplicates := duplicateNames(serviceNames); len(duplicates) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate services %s", strings.Join(duplicates, ",")))
+	}
+	var ports []int32
+	for port, owners := range nodePorts {
+		if len(owners) > 1 {
+			ports = append(ports, port)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	for _, port := range ports {
+		problems = append(problems, fmt.Sprintf("node port %d is used by %s", port, strings.Join(nodePorts[port], ",")))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidComponentSet, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func duplicateNames(counts map[string]int) []string {
+	var duplicates []string
+	for name, count := range counts {
+		if count > 1 {
+			duplicates = append(duplicates, name)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
 // PlatformAdminControllerConfiguration contains elements describing PlatformAdminController.
 type PlatformAdminControllerConfiguration struct {
 	SecurityComponents map[string][]*Component
 	NoSectyComponents  map[string][]*Component
 	SecurityConfigMaps map[string][]corev1.ConfigMap
 	NoSectyConfigMaps  map[string][]corev1.ConfigMap
+	// Namespaces restricts the controller to PlatformAdmins in these namespaces, all namespaces are watched if empty.
+	Namespaces []string
 }
 
 func NewPlatformAdminControllerConfiguration() *PlatformAdminControllerConfiguration {
-	var (
-		edgexconfig        = EdgeXConfig{}
-		edgexnosectyconfig = EdgeXConfig{}
-		conf               = PlatformAdminControllerConfiguration{
-			SecurityComponents: make(map[string][]*Component),
-			NoSectyComponents:  make(map[string][]*Component),
-			SecurityConfigMaps: make(map[string][]corev1.ConfigMap),
-			NoSectyConfigMaps:  make(map[string][]corev1.ConfigMap),
-		}
-	)
-
 	securityContent, err := EdgeXFS.ReadFile(securityFile)
 	if err != nil {
 		klog.Errorf("Fail to open the embed EdgeX security config: %v", err)
@@ -85,23 +294,93 @@ func NewPlatformAdminControllerConfiguration() *PlatformAdminControllerConfigura
 		return nil
 	}
 
-	if err = json.Unmarshal(securityContent, &edgexconfig); err != nil {
-		klog.Errorf("Fail to unmarshal the embed EdgeX security config: %v", err)
+	conf, err := newPlatformAdminControllerConfiguration(securityContent, nosectyContent)
+	if err != nil {
+		klog.Errorf("Fail to load the embed EdgeX config: %v", err)
 		return nil
 	}
+	return conf
+}
+
+// LoadPlatformAdminControllerConfiguration loads the component templates from the framework configmap,
+// the embed config is used for the keys which are not set in the configmap. A configuration that fails
+// the validation is never returned, so that callers can keep the last known good one.
+func LoadPlatformAdminControllerConfiguration(cm *corev1.ConfigMap) (*PlatformAdminControllerConfiguration, error) {
+	securityContent, err := EdgeXFS.ReadFile(securityFile)
+	if err != nil {
+		return nil, err
+	}
+	nosectyContent, err := EdgeXFS.ReadFile(nosectyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if content, ok := cm.Data[SecurityConfigKey]; ok {
+		securityContent = []byte(content)
+	}
+	if content, ok := cm.Data[NoSectyConfigKey]; ok {
+		nosectyContent = []byte(content)
+	}
+
+	conf, err := newPlatformAdminControllerConfiguration(securityContent, nosectyContent)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func newPlatformAdminControllerConfiguration(securityContent, nosectyContent []byte) (*PlatformAdminControllerConfiguration, error) {
+	var (
+		edgexconfig        = EdgeXConfig{}
+		edgexnosectyconfig = EdgeXConfig{}
+		conf               = PlatformAdminControllerConfiguration{
+			SecurityComponents: make(map[string][]*Component),
+			NoSectyComponents:  make(map[string][]*Component),
+			SecurityConfigMaps: make(map[string][]corev1.ConfigMap),
+			NoSectyConfigMaps:  make(map[string][]corev1.ConfigMap),
+		}
+	)
+
+	if err := json.Unmarshal(securityContent, &edgexconfig); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal the EdgeX security config, %w", err)
+	}
 	for _, version := range edgexconfig.Versions {
 		conf.SecurityComponents[version.Name] = version.Components
 		conf.SecurityConfigMaps[version.Name] = version.ConfigMaps
 	}
 
 	if err := json.Unmarshal(nosectyContent, &edgexnosectyconfig); err != nil {
-		klog.Errorf("Fail to unmarshal the embed EdgeX nosecty config: %v", err)
-		return nil
+		return nil, fmt.Errorf("fail to unmarshal the EdgeX nosecty config, %w", err)
 	}
 	for _, version := range edgexnosectyconfig.Versions {
 		conf.NoSectyComponents[version.Name] = version.Components
 		conf.NoSectyConfigMaps[version.Name] = version.ConfigMaps
 	}
 
-	return &conf
+	return &conf, nil
+}
+
+// Validate checks the component templates of all versions.
+func (c *PlatformAdminControllerConfiguration) Validate() error {
+	var errs []error
+	for version, components := range c.SecurityComponents {
+		errs = append(errs, validateComponents(version, components)...)
+	}
+	for version, components := range c.NoSectyComponents {
+		errs = append(errs, validateComponents(version, components)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validateComponents(version string, components []*Component) []error {
+	var errs []error
+	for _, component := range components {
+		if err := component.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("version %s: %w", version, err))
+		}
+	}
+	return errs
 }
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestValidateComponentSet(t *testing.T) {
+	newComponent := func(name string, nodePorts ...int32) *Component {
+		component := &Component{Name: name, Service: &corev1.ServiceSpec{}}
+		for _, nodePort := range nodePorts {
+			component.Service.Ports = append(component.Service.Ports, corev1.ServicePort{Name: "http", Port: 59882, NodePort: nodePort})
+		}
+		return component
+	}
+	newDeploymentComponent := func(name string) *Component {
+		return &Component{Name: name, Deployment: &appsv1.DeploymentSpec{}}
+	}
+
+	tests := []struct {
+		name       string
+		components []*Component
+		// expectMessages are the offenders reported, no error is expected if empty
+		expectMessages []string
+	}{
+		{
+			name:       "no collision",
+			components: []*Component{newComponent("edgex-core-command", 30082), newComponent("edgex-core-data", 30080), newDeploymentComponent("edgex-sys-mgmt-agent")},
+		},
+		{
+			name:           "duplicate components",
+			components:     []*Component{newDeploymentComponent("device-virtual"), newDeploymentComponent("device-virtual")},
+			expectMessages: []string{"duplicate components device-virtual"},
+		},
+		{
+			name:           "duplicate services",
+			components:     []*Component{newComponent("edgex-core-command"), newComponent("edgex-core-command")},
+			expectMessages: []string{"duplicate components edgex-core-command", "duplicate services edgex-core-command"},
+		},
+		{
+			name:           "node port collision across components",
+			components:     []*Component{newComponent("edgex-core-command", 30082), newComponent("device-virtual", 30082)},
+			expectMessages: []string{"node port 30082 is used by edgex-core-command/http,device-virtual/http"},
+		},
+		{
+			name:           "node port collision within a component",
+			components:     []*Component{newComponent("edgex-core-command", 30082, 30082)},
+			expectMessages: []string{"node port 30082 is used by edgex-core-command/http,edgex-core-command/http"},
+		},
+		{
+			name:       "node ports allocated by apiserver",
+			components: []*Component{newComponent("edgex-core-command", 0), newComponent("edgex-core-data", 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateComponentSet(tt.components)
+			if len(tt.expectMessages) == 0 {
+				if err != nil {
+					t.Errorf("expect no error, but got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidComponentSet) {
+				t.Fatalf("expect error %v, but got %v", ErrInvalidComponentSet, err)
+			}
+			for _, message := range tt.expectMessages {
+				if !strings.Contains(err.Error(), message) {
+					t.Errorf("expect error contains %q, but got %v", message, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUpdateStrategy(t *testing.T) {
+	maxUnavailable := intstr.FromInt(0)
+	tests := []struct {
+		name        string
+		strategy    *appsv1.DeploymentStrategy
+		expectError bool
+	}{
+		{name: "not set"},
+		{name: "recreate", strategy: &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}},
+		{
+			name: "rolling update",
+			strategy: &appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+			},
+		},
+		{
+			name: "recreate with rolling update parameters",
+			strategy: &appsv1.DeploymentStrategy{
+				Type:          appsv1.RecreateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+			},
+			expectError: true,
+		},
+		{name: "unknown type", strategy: &appsv1.DeploymentStrategy{Type: "BlueGreen"}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateUpdateStrategy(tt.strategy); (err != nil) != tt.expectError {
+				t.Errorf("expect error %v, but got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestValidateAutoscaling(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoscaling *iotv1alpha2.ComponentAutoscaling
+		expectError bool
+	}{
+		{name: "not set"},
+		{name: "max replicas only", autoscaling: &iotv1alpha2.ComponentAutoscaling{MaxReplicas: 3}},
+		{
+			name:        "min replicas and target",
+			autoscaling: &iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(2), MaxReplicas: 3, TargetCPUUtilizationPercentage: pointer.Int32Ptr(80)},
+		},
+		{name: "zero max replicas", autoscaling: &iotv1alpha2.ComponentAutoscaling{}, expectError: true},
+		{name: "zero min replicas", autoscaling: &iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(0), MaxReplicas: 3}, expectError: true},
+		{name: "min replicas greater than max replicas", autoscaling: &iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(4), MaxReplicas: 3}, expectError: true},
+		{name: "zero target", autoscaling: &iotv1alpha2.ComponentAutoscaling{MaxReplicas: 3, TargetCPUUtilizationPercentage: pointer.Int32Ptr(0)}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAutoscaling(tt.autoscaling); (err != nil) != tt.expectError {
+				t.Errorf("expect error %v, but got %v", tt.expectError, err)
+			}
+		})
+	}
+}
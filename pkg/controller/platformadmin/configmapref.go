@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// desiredConfigMapNames returns the names of configmaps desired by the PlatformAdmin for its version and security
+// mode, the configmaps of the previous mode are still desired during the migration of security mode.
+func desiredConfigMapNames(platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration) map[string]struct{} {
+	names := make(map[string]struct{})
+	add := func(security bool) {
+		configmaps := conf.NoSectyConfigMaps[platformAdmin.Spec.Version]
+		if security {
+			configmaps = conf.SecurityConfigMaps[platformAdmin.Spec.Version]
+		}
+		for i := range configmaps {
+			names[configmaps[i].Name] = struct{}{}
+		}
+	}
+	add(platformAdmin.Spec.Security)
+	if util.IsSecurityMigrating(platformAdmin.Spec.Security, &platformAdmin.Status) {
+		add(*platformAdmin.Status.CurrentSecurity)
+	}
+	return names
+}
+
+// configMapReferencedBy returns the other PlatformAdmin which generates components into the same namespace and
+// still desires the configmap, it is nil if the configmap is not referenced by others. The owner references are
+// not trusted here, since they may be out of sync(e.g. removed by others).
+func (r *ReconcilePlatformAdmin) configMapReferencedBy(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, name string, conf *config.PlatformAdminControllerConfiguration) (*iotv1alpha2.PlatformAdmin, error) {
+	platformAdmins := &iotv1alpha2.PlatformAdminList{}
+	if err := r.List(ctx, platformAdmins); err != nil {
+		return nil, err
+	}
+	for i := range platformAdmins.Items {
+		other := &platformAdmins.Items[i]
+		if other.DeletionTimestamp != nil || (other.Namespace == platformAdmin.Namespace && other.Name == platformAdmin.Name) ||
+			workloadNamespace(other) != workloadNamespace(platformAdmin) {
+			continue
+		}
+		if _, ok := desiredConfigMapNames(other, conf)[name]; ok {
+			return other, nil
+		}
+	}
+	return nil, nil
+}
+
+// releaseConfigMap releases the configmap which is not desired by the PlatformAdmin anymore. The configmap still
+// referenced by other PlatformAdmins only loses the owner reference of this one, it is never deleted. Otherwise the
+// protection finalizer is removed first, and the configmap is deleted or orphaned by removeOwner. The configmap not
+// owned by the PlatformAdmin is never touched, and the one of a PlatformAdmin skipping the configmap management is
+// kept for the users.
+func (r *ReconcilePlatformAdmin) releaseConfigMap(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, configmap *corev1.ConfigMap, conf *config.PlatformAdminControllerConfiguration) error {
+	if !isOwnedBy(configmap, platformAdmin) {
+		return nil
+	}
+	referencedBy, err := r.configMapReferencedBy(ctx, platformAdmin, configmap.Name, conf)
+	if err != nil {
+		return err
+	}
+	if referencedBy != nil {
+		log.FromContext(ctx).V(4).Info("ConfigMap is still referenced, only remove the owner reference", "configmap", configmap.Name,
+			"referencedBy", referencedBy.Namespace+"/"+referencedBy.Name)
+		return r.removeOwnerReference(ctx, platformAdmin, configmap)
+	}
+
+	if controllerutil.ContainsFinalizer(configmap, iotv1alpha2.ConfigMapFinalizer) {
+		oldConfigmap := configmap.DeepCopy()
+		controllerutil.RemoveFinalizer(configmap, iotv1alpha2.ConfigMapFinalizer)
+		if err := r.Patch(ctx, configmap, client.MergeFromWithOptions(oldConfigmap, client.MergeFromWithOptimisticLock{})); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		recordOperation(kindConfigMap, operationPatch)
+	}
+	if platformAdmin.Spec.SkipConfigmapManagement {
+		log.FromContext(ctx).V(4).Info("ConfigMap is managed by users, only remove the owner reference", "configmap", configmap.Name)
+		return r.removeOwnerReference(ctx, platformAdmin, configmap)
+	}
+	return r.removeOwner(ctx, platformAdmin, configmap)
+}
+
+// removeOwnerReference only removes the owner reference of PlatformAdmin from the object, which is kept even if no
+// owner is left. The object generated into another namespace records one owner by label, which is left to the
+// PlatformAdmin referencing it.
+func (r *ReconcilePlatformAdmin) removeOwnerReference(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
+	if isCrossNamespace(platformAdmin) {
+		return nil
+	}
+	first := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+		}
+		first = false
+
+		var owners []metav1.OwnerReference
+		var removed *metav1.OwnerReference
+		for i, owner := range obj.GetOwnerReferences() {
+			if owner.UID == platformAdmin.UID {
+				removed = &obj.GetOwnerReferences()[i]
+				continue
+			}
+			owners = append(owners, owner)
+		}
+		if removed == nil {
+			return nil
+		}
+		if len(owners) > 0 && removed.Controller != nil && *removed.Controller {
+			owners[0].Controller = pointer.BoolPtr(true)
+		}
+		oldObj := obj.DeepCopyObject().(client.Object)
+		obj.SetOwnerReferences(owners)
+		if err := r.Patch(ctx, obj, client.MergeFromWithOptions(oldObj, client.MergeFromWithOptimisticLock{})); err != nil {
+			return err
+		}
+		recordOperation(resourceKind(obj), operationPatch)
+		return nil
+	})
+}
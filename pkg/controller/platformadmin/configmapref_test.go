@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+func TestSharedConfigMapOnDelete(t *testing.T) {
+	const configmapName = "common-variable-levski"
+	first := newTestPlatformAdmin("default", "edgex-hangzhou", "hangzhou")
+	first.UID = "uid-hangzhou"
+	second := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+	second.UID = "uid-beijing"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), first, second)
+	reconcilePlatformAdmin(t, r, first)
+	reconcilePlatformAdmin(t, r, second)
+
+	key := types.NamespacedName{Namespace: "default", Name: configmapName}
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(cm, iotv1alpha2.ConfigMapFinalizer) {
+		t.Errorf("expect configmap is protected by finalizer, but got %v", cm.Finalizers)
+	}
+
+	// the configmap is still desired by the second one, only the owner reference of the first one is removed
+	if err := r.Delete(context.TODO(), first); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, first)
+	cm = &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("expect configmap is kept, but got %v", err)
+	}
+	if cm.DeletionTimestamp != nil {
+		t.Errorf("expect configmap is not deleted, but got deletion timestamp %v", cm.DeletionTimestamp)
+	}
+	if isOwnedBy(cm, first) || !isOwnedBy(cm, second) {
+		t.Errorf("expect configmap is only owned by the second PlatformAdmin, but got %v", cm.OwnerReferences)
+	}
+	if !controllerutil.ContainsFinalizer(cm, iotv1alpha2.ConfigMapFinalizer) {
+		t.Errorf("expect finalizer is kept, but got %v", cm.Finalizers)
+	}
+
+	// the last one releases the configmap
+	if err := r.Delete(context.TODO(), second); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, second)
+	if err := r.Get(context.TODO(), key, &corev1.ConfigMap{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect configmap is deleted, but got %v", err)
+	}
+}
+
+func TestRemoveOwnerReference(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "common-variable-levski",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: pa.Name, UID: pa.UID, Controller: pointer.BoolPtr(true)},
+			{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: "edgex-beijing", UID: "uid-beijing"},
+		},
+	}}
+	r := newTestReconciler(newTestConfiguration(), pa, cm)
+	if err := r.removeOwnerReference(context.TODO(), pa, cm); err != nil {
+		t.Fatalf("failed to remove owner reference, %v", err)
+	}
+
+	latest := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}, latest); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if len(latest.OwnerReferences) != 1 || latest.OwnerReferences[0].UID != "uid-beijing" {
+		t.Fatalf("expect only the other owner is left, but got %v", latest.OwnerReferences)
+	}
+	if controller := latest.OwnerReferences[0].Controller; controller == nil || !*controller {
+		t.Errorf("expect the remaining owner is promoted to controller, but got %v", controller)
+	}
+}
+
+func TestDesiredConfigMapNames(t *testing.T) {
+	conf := &config.PlatformAdminControllerConfiguration{
+		SecurityConfigMaps: map[string][]corev1.ConfigMap{
+			"levski": {{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}}, {ObjectMeta: metav1.ObjectMeta{Name: "security-levski"}}},
+		},
+		NoSectyConfigMaps: map[string][]corev1.ConfigMap{
+			"levski": {{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}}},
+		},
+	}
+	tests := []struct {
+		name            string
+		version         string
+		security        bool
+		currentSecurity *bool
+		expect          map[string]struct{}
+	}{
+		{
+			name:    "no security",
+			version: "levski",
+			expect:  map[string]struct{}{"common-variable-levski": {}},
+		},
+		{
+			name:     "security",
+			version:  "levski",
+			security: true,
+			expect:   map[string]struct{}{"common-variable-levski": {}, "security-levski": {}},
+		},
+		{
+			name:            "migrating from security",
+			version:         "levski",
+			currentSecurity: pointer.BoolPtr(true),
+			expect:          map[string]struct{}{"common-variable-levski": {}, "security-levski": {}},
+		},
+		{
+			name:    "unknown version",
+			version: "minnesota",
+			expect:  map[string]struct{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.Spec.Version = tt.version
+			pa.Spec.Security = tt.security
+			pa.Status.CurrentSecurity = tt.currentSecurity
+			if got := desiredConfigMapNames(pa, conf); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// configmapTemplateContext is the data which the values of configmap templates are rendered with, e.g.
+// "edgex-core-data.{{.Namespace}}.svc" or "{{.PoolName}}".
+type configmapTemplateContext struct {
+	// PoolName is the first of Pools, which is PlatformAdmin.Spec.PoolName if it is set
+	PoolName string
+	// Pools are all the pools of PlatformAdmin, e.g. "{{range .Pools}}{{.}};{{end}}"
+	Pools []string
+	// Namespace is the namespace which the components are deployed into
+	Namespace string
+	Name      string
+	Version   string
+	Security  bool
+}
+
+// configmapTemplateError reports the value of configmap template which can not be rendered,
+// retrying can not fix it until the configuration is updated.
+type configmapTemplateError struct {
+	configMap string
+	key       string
+	err       error
+}
+
+func (e *configmapTemplateError) Error() string {
+	return fmt.Sprintf("failed to render key %s of configmap %s, %v", e.key, e.configMap, e.err)
+}
+
+func (e *configmapTemplateError) Unwrap() error {
+	return e.err
+}
+
+func newConfigmapTemplateContext(platformAdmin *iotv1alpha2.PlatformAdmin) configmapTemplateContext {
+	context := configmapTemplateContext{
+		Pools:     util.PlatformAdminPools(platformAdmin),
+		Namespace: workloadNamespace(platformAdmin),
+		Name:      platformAdmin.Name,
+		Version:   platformAdmin.Spec.Version,
+		Security:  platformAdmin.Spec.Security,
+	}
+	if len(context.Pools) > 0 {
+		context.PoolName = context.Pools[0]
+	}
+	return context
+}
+
+// renderConfigmapData renders the values of configmap template with the fields of PlatformAdmin. The values
+// without actions are passed through as is, and nothing is returned if any value fails, so a half-rendered
+// configmap is never written. The keys are rendered in order, so the first invalid key is reported.
+func renderConfigmapData(name string, data map[string]string, platformAdmin *iotv1alpha2.PlatformAdmin) (map[string]string, error) {
+	if data == nil {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := newConfigmapTemplateContext(platformAdmin)
+	rendered := make(map[string]string, len(data))
+	for _, key := range keys {
+		value := data[key]
+		if !strings.Contains(value, "{{") {
+			rendered[key] = value
+			continue
+		}
+		// The missing fields are rejected, instead of being rendered as "<no value>"
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, &configmapTemplateError{configMap: name, key: key, err: err}
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, values); err != nil {
+			return nil, &configmapTemplateError{configMap: name, key: key, err: err}
+		}
+		rendered[key] = buf.String()
+	}
+	return rendered, nil
+}
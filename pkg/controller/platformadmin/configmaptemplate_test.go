@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+func TestRenderConfigmapData(t *testing.T) {
+	platformAdmin := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	platformAdmin.Spec.Security = true
+	platformAdmin.Spec.Pools = []string{"beijing"}
+	tests := []struct {
+		name             string
+		data             map[string]string
+		expect           map[string]string
+		expectInvalidKey string
+	}{
+		{
+			name:   "template referencing pool name",
+			data:   map[string]string{"EDGEX_POOL": "{{.PoolName}}", "CORE_DATA_HOST": "edgex-core-data.{{.Namespace}}.svc"},
+			expect: map[string]string{"EDGEX_POOL": "hangzhou", "CORE_DATA_HOST": "edgex-core-data.default.svc"},
+		},
+		{
+			name:   "template ranging over pools",
+			data:   map[string]string{"EDGEX_POOLS": "{{range .Pools}}{{.}};{{end}}"},
+			expect: map[string]string{"EDGEX_POOLS": "hangzhou;beijing;"},
+		},
+		{
+			name:   "all fields",
+			data:   map[string]string{"INSTANCE": "{{.Name}}-{{.Version}}-{{.Security}}"},
+			expect: map[string]string{"INSTANCE": "edgex-levski-true"},
+		},
+		{
+			name:   "no template is passed through",
+			data:   map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "PATTERN": "{ {not a template} }"},
+			expect: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "PATTERN": "{ {not a template} }"},
+		},
+		{
+			name:             "syntax error",
+			data:             map[string]string{"A": "{{.PoolName}}", "B": "{{.PoolName"},
+			expectInvalidKey: "B",
+		},
+		{
+			name:             "unknown field",
+			data:             map[string]string{"A": "{{.NodeName}}"},
+			expectInvalidKey: "A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := renderConfigmapData("common-variables", tt.data, platformAdmin)
+			if tt.expectInvalidKey != "" {
+				invalid := (*configmapTemplateError)(nil)
+				if !errors.As(err, &invalid) || invalid.key != tt.expectInvalidKey {
+					t.Fatalf("expect invalid key %s, but got %v", tt.expectInvalidKey, err)
+				}
+				if rendered != nil {
+					t.Errorf("expect nothing is rendered, but got %v", rendered)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(rendered, tt.expect) {
+				t.Errorf("expect data %v, but got %v", tt.expect, rendered)
+			}
+			// the rendering is idempotent, so the unchanged input does not update the configmap
+			if again, _ := renderConfigmapData("common-variables", tt.data, platformAdmin); !reflect.DeepEqual(again, rendered) {
+				t.Errorf("expect the same data %v, but got %v", rendered, again)
+			}
+		})
+	}
+}
+
+func TestReconcileConfigmapTemplate(t *testing.T) {
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	conf.NoSectyConfigMaps[testVersion][0].Data["EDGEX_POOL"] = "{{.PoolName}}"
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(conf, pa)
+
+	reconcilePlatformAdmin(t, r, pa)
+	configmap := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}, configmap); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if configmap.Data["EDGEX_POOL"] != "hangzhou" {
+		t.Errorf("expect EDGEX_POOL hangzhou, but got %s", configmap.Data["EDGEX_POOL"])
+	}
+
+	// the broken template is reported by condition, and the configmap is not written
+	broken := newTestConfiguration(newTestComponent(testComponent, testImage))
+	broken.NoSectyConfigMaps[testVersion][0].Data["EDGEX_POOL"] = "{{.PoolName"
+	pb := newTestPlatformAdmin("default", "edgex-b", "beijing")
+	r = newTestReconciler(broken, pb)
+	reconcilePlatformAdmin(t, r, pb)
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pb), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	condition := util.GetPlatformAdminCondition(latest.Status, iotv1alpha2.ConfigmapAvailableCondition)
+	if condition == nil || condition.Reason != iotv1alpha2.ConfigmapProvisioningFailedReason || !strings.Contains(condition.Message, "EDGEX_POOL") {
+		t.Errorf("expect condition %s naming key EDGEX_POOL, but got %v", iotv1alpha2.ConfigmapProvisioningFailedReason, condition)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pb.Namespace, Name: "common-variable-levski"}, &corev1.ConfigMap{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect configmap is not created, but got %v", err)
+	}
+}
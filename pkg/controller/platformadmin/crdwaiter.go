@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	utildiscovery "github.com/openyurtio/openyurt/pkg/util/discovery"
+)
+
+// discoverGVK checks whether the kind is served by the apiserver, it is replaced by the tests.
+var discoverGVK = utildiscovery.DiscoverGVK
+
+// crdWaiter waits for the CRD of the kind to be installed, then it adds the controller and exits. It runs on every
+// replica without leader election, since the controller is registered with the manager of each replica, while the
+// controller itself only runs on the leader.
+type crdWaiter struct {
+	gvk      schema.GroupVersionKind
+	interval time.Duration
+	discover func(schema.GroupVersionKind) bool
+	add      func(ctx context.Context) error
+}
+
+var _ manager.LeaderElectionRunnable = &crdWaiter{}
+
+func (w *crdWaiter) NeedLeaderElection() bool {
+	return false
+}
+
+// Start polls the discovery until the kind is installed or the manager is stopped. The manager is stopped if the
+// controller fails to be added, so yurt-manager is restarted instead of running without the controller.
+func (w *crdWaiter) Start(ctx context.Context) error {
+	err := wait.PollImmediateUntil(w.interval, func() (bool, error) {
+		if w.discover(w.gvk) {
+			return true, nil
+		}
+		klog.InfoS("Wait for the CRD to be installed", "controller", ControllerName, "kind", w.gvk.String(), "interval", w.interval)
+		return false, nil
+	}, ctx.Done())
+	if err != nil {
+		// The manager is stopped before the CRD is installed
+		return nil
+	}
+
+	klog.InfoS("CRD is installed, add controller", "controller", ControllerName, "kind", w.gvk.String())
+	return w.add(ctx)
+}
+
+// addWhenInstalled adds the controller after the manager is started. The informers of manager can not be indexed
+// anymore, so the PlatformAdmins are read from their own cache, and the YurtAppSets are listed by labels.
+func (r *ReconcilePlatformAdmin) addWhenInstalled(ctx context.Context, mgr manager.Manager) error {
+	r.discoverDependencies()
+	if err := r.setupPlatformAdminCache(ctx, mgr); err != nil {
+		klog.ErrorS(err, "Failed to set up the cache of PlatformAdmins", "controller", ControllerName)
+		return err
+	}
+	return addController(mgr, r)
+}
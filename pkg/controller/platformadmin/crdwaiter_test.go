@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCRDWaiter(t *testing.T) {
+	tests := []struct {
+		name string
+		// installAfter is the delay of installing the CRD, it is never installed if it is zero
+		installAfter time.Duration
+		expectAdded  bool
+	}{
+		{name: "installed after a delay", installAfter: 50 * time.Millisecond, expectAdded: true},
+		{name: "never installed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var installed, added, discovered int32
+			if tt.installAfter > 0 {
+				time.AfterFunc(tt.installAfter, func() { atomic.StoreInt32(&installed, 1) })
+			}
+			w := &crdWaiter{
+				gvk:      controllerKind,
+				interval: 10 * time.Millisecond,
+				discover: func(gvk schema.GroupVersionKind) bool {
+					atomic.AddInt32(&discovered, 1)
+					return gvk == controllerKind && atomic.LoadInt32(&installed) == 1
+				},
+				add: func(ctx context.Context) error {
+					atomic.AddInt32(&added, 1)
+					return nil
+				},
+			}
+			if w.NeedLeaderElection() {
+				t.Errorf("expect the waiter runs without leader election, but got it needs")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			done := make(chan error)
+			go func() {
+				done <- w.Start(ctx)
+			}()
+			if !tt.expectAdded {
+				time.Sleep(100 * time.Millisecond)
+				cancel()
+			}
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("expect the waiter exits without error, but got %v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("expect the waiter exits, but it is still running")
+			}
+
+			if added := atomic.LoadInt32(&added) == 1; added != tt.expectAdded {
+				t.Errorf("expect the controller is added %v, but got %v", tt.expectAdded, added)
+			}
+			if atomic.LoadInt32(&discovered) < 2 {
+				t.Errorf("expect the discovery is polled until the CRD is installed, but got %d polls", discovered)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// deletionPolicy returns the deletion policy of PlatformAdmin, which defaults to Delete.
+func deletionPolicy(platformAdmin *iotv1alpha2.PlatformAdmin) string {
+	if platformAdmin.Spec.DeletionPolicy == "" {
+		return iotv1alpha2.DeletionPolicyDelete
+	}
+	return platformAdmin.Spec.DeletionPolicy
+}
+
+func isOrphan(platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	return deletionPolicy(platformAdmin) == iotv1alpha2.DeletionPolicyOrphan
+}
+
+func removeLabels(obj client.Object, keys ...string) {
+	labels := obj.GetLabels()
+	for _, key := range keys {
+		delete(labels, key)
+	}
+	obj.SetLabels(labels)
+}
+
+// recordCleanup records the object deleted or orphaned by the deletion policy of PlatformAdmin.
+func (r *ReconcilePlatformAdmin) recordCleanup(platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) {
+	action := "deleted"
+	if isOrphan(platformAdmin) {
+		action = "orphaned"
+	}
+	r.recorder.Eventf(platformAdmin.DeepCopy(), corev1.EventTypeNormal, eventReasonCleanup,
+		"%s %s/%s is %s by deletion policy %s", resourceKind(obj), obj.GetNamespace(), obj.GetName(), action, deletionPolicy(platformAdmin))
+}
+
+// releasePool removes the pools of PlatformAdmin from the released yurtappset, the pools are kept running if the
+// yurtappset is orphaned.
+func (r *ReconcilePlatformAdmin) releasePool(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, yas *appsv1alpha1.YurtAppSet) error {
+	if isOrphan(platformAdmin) {
+		return nil
+	}
+	return r.removePools(ctx, yas, util.PlatformAdminPools(platformAdmin))
+}
+
+// releaseDaemonPool is the releasePool of yurtappdaemon.
+func (r *ReconcilePlatformAdmin) releaseDaemonPool(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, yad *appsv1alpha1.YurtAppDaemon) error {
+	if isOrphan(platformAdmin) {
+		return nil
+	}
+	return r.removeDaemonPools(ctx, yad, util.PlatformAdminPools(platformAdmin))
+}
+
+// cleanupWorkloads releases the poddisruptionbudgets, networkpolicies, servicemonitors, persistentvolumeclaims and
+// workloads of PlatformAdmin, which are left to the garbage collector otherwise. The objects generated into another
+// namespace are not garbage collected without owner references, and the owner references of orphaned objects must be
+// removed before the garbage collector deletes them. The services, configmaps and secrets are always released by removing the owner.
+func (r *ReconcilePlatformAdmin) cleanupWorkloads(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin) error {
+	var selector client.ListOption
+	switch {
+	case isCrossNamespace(platformAdmin):
+		selector = client.MatchingLabels{iotv1alpha2.LabelPlatformAdmin: platformAdminLabelValue(platformAdmin)}
+	case isOrphan(platformAdmin):
+		// The objects owned by others are skipped by removeOwner
+		selector = client.HasLabels{iotv1alpha2.LabelPlatformAdminGenerate}
+	default:
+		return nil
+	}
+
+	var errs []error
+	lists := []client.ObjectList{
+		&policyv1.PodDisruptionBudgetList{},
+		&networkingv1.NetworkPolicyList{},
+		&corev1.PersistentVolumeClaimList{},
+		&autoscalingv2beta2.HorizontalPodAutoscalerList{},
+		&appsv1alpha1.YurtAppDaemonList{},
+	}
+	if !r.yurtAppSetMissing {
+		lists = append(lists, &appsv1alpha1.YurtAppSetList{})
+	}
+	if r.serviceMonitorAvailable {
+		lists = append(lists, newServiceMonitorList())
+	}
+	for _, list := range lists {
+		if err := r.listPages(ctx, list, func(obj client.Object) error {
+			if err := r.removeOwner(ctx, platformAdmin, obj); err != nil {
+				return errors.Wrapf(err, "failed to remove owner from %s %s", resourceKind(obj), obj.GetName())
+			}
+			return nil
+		}, client.InNamespace(workloadNamespace(platformAdmin)), selector); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// checkReleased checks the service and yurtappset of the component released by PlatformAdmin. The deleted objects
+// are not found, and the orphaned objects are kept with the pool but without the generate label and owner references.
+func checkReleased(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, name string, orphan bool) {
+	t.Helper()
+	key := types.NamespacedName{Namespace: pa.Namespace, Name: name}
+	for _, obj := range []client.Object{&corev1.Service{}, &appsv1alpha1.YurtAppSet{}} {
+		err := r.Get(context.TODO(), key, obj)
+		if !orphan {
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("expect %T %s is deleted, but got %v", obj, name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expect %T %s is orphaned, but got %v", obj, name, err)
+		}
+		if _, ok := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]; ok || len(obj.GetOwnerReferences()) != 0 {
+			t.Errorf("expect %T %s is not managed, but got labels %v and owners %v", obj, name, obj.GetLabels(), obj.GetOwnerReferences())
+		}
+		if yas, ok := obj.(*appsv1alpha1.YurtAppSet); ok && len(yas.Spec.Topology.Pools) != 1 {
+			t.Errorf("expect the pool of orphaned yurtappset %s is kept, but got %v", name, yas.Spec.Topology.Pools)
+		}
+	}
+}
+
+// hasCleanupEvent checks whether the events contain a cleanup event of the deletion policy.
+func hasCleanupEvent(r *ReconcilePlatformAdmin, policy string) bool {
+	found := false
+	for {
+		select {
+		case event := <-r.recorder.(*record.FakeRecorder).Events:
+			if strings.Contains(event, eventReasonCleanup) && strings.HasSuffix(event, "by deletion policy "+policy) {
+				found = true
+			}
+		default:
+			return found
+		}
+	}
+}
+
+func TestDeletionPolicyOnComponentRemoval(t *testing.T) {
+	for _, policy := range []string{iotv1alpha2.DeletionPolicyDelete, iotv1alpha2.DeletionPolicyOrphan} {
+		t.Run(policy, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			pa.Spec.DeletionPolicy = policy
+			pa.Annotations = newAdditionalComponentAnnotations("device-virtual")
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Annotations = nil
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			checkReleased(t, r, pa, "device-virtual", policy == iotv1alpha2.DeletionPolicyOrphan)
+			if !hasCleanupEvent(r, policy) {
+				t.Errorf("expect cleanup event of deletion policy %s, but got nothing", policy)
+			}
+
+			// the orphaned objects are not adopted again
+			reconcilePlatformAdmin(t, r, pa)
+			checkReleased(t, r, pa, "device-virtual", policy == iotv1alpha2.DeletionPolicyOrphan)
+		})
+	}
+}
+
+func TestDeletionPolicyOnDelete(t *testing.T) {
+	tests := []struct {
+		policy string
+		// expectGarbageCollected is whether the yurtappset is left to the garbage collector with the owner reference
+		expectGarbageCollected bool
+	}{
+		{policy: iotv1alpha2.DeletionPolicyDelete, expectGarbageCollected: true},
+		{policy: iotv1alpha2.DeletionPolicyOrphan},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			pa.Spec.DeletionPolicy = tt.policy
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if err := r.Delete(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to delete PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); !apierrors.IsNotFound(err) {
+				t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+			}
+			if !hasCleanupEvent(r, tt.policy) {
+				t.Errorf("expect cleanup event of deletion policy %s, but got nothing", tt.policy)
+			}
+
+			if !tt.expectGarbageCollected {
+				checkReleased(t, r, pa, testComponent, true)
+				return
+			}
+			// the service is deleted, and the yurtappset without the pool is deleted by the garbage collector
+			if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &corev1.Service{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expect service is deleted, but got %v", err)
+			}
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			if !isOwnedBy(yas, pa) || len(yas.Spec.Topology.Pools) != 0 {
+				t.Errorf("expect yurtappset is owned without the pool, but got owners %v and pools %v", yas.OwnerReferences, yas.Spec.Topology.Pools)
+			}
+		})
+	}
+}
+
+func TestDeletionPolicyAcrossNamespaces(t *testing.T) {
+	pa := newTestPlatformAdmin("management", "edgex", "hangzhou")
+	pa.Spec.WorkloadNamespace = "default"
+	pa.Spec.DeletionPolicy = iotv1alpha2.DeletionPolicyOrphan
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	for _, obj := range []client.Object{&corev1.Service{}, &appsv1alpha1.YurtAppSet{}} {
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: testComponent}, obj); err != nil {
+			t.Fatalf("expect %T is orphaned, but got %v", obj, err)
+		}
+		if len(obj.GetLabels()) != 0 {
+			t.Errorf("expect the labels of %T are removed, but got %v", obj, obj.GetLabels())
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// drainRequeueAfter is the interval to check again whether the replicas of the draining pool are gone
+var drainRequeueAfter = 5 * time.Second
+
+// drainDeadline returns the time when the drain of the pool expires, and false if the PlatformAdmin is not drained.
+// The grace period starts from the deletion, so the deadline is kept across reconciles without any state.
+func drainDeadline(platformAdmin *iotv1alpha2.PlatformAdmin) (time.Time, bool) {
+	gracePeriod := platformAdmin.Spec.DeletionGracePeriodSeconds
+	if gracePeriod == nil || *gracePeriod <= 0 || platformAdmin.DeletionTimestamp == nil || isOrphan(platformAdmin) {
+		return time.Time{}, false
+	}
+	return platformAdmin.DeletionTimestamp.Add(time.Duration(*gracePeriod) * time.Second), true
+}
+
+// drainPools scales the pools of PlatformAdmin to 0 in the yurtappsets before the pools are removed from them, and
+// returns how long to wait until the ready replicas of the pools are gone. The pools are removed anyway once the grace
+// period expires, in case the pods are stuck. The progress is recorded by the Terminating condition.
+func (r *ReconcilePlatformAdmin) drainPools(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, names []string) (time.Duration, error) {
+	deadline, ok := drainDeadline(platformAdmin)
+	if !ok {
+		return 0, nil
+	}
+	logger := log.FromContext(ctx)
+	pools := util.PlatformAdminPools(platformAdmin)
+
+	var draining []string
+	for _, name := range names {
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: name}, yas); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return 0, err
+		}
+		scaled, err := r.scaleDownPool(ctx, platformAdmin, yas)
+		if err != nil {
+			return 0, err
+		}
+		// The status of yurtappset may not observe the scale down yet
+		if scaled || hasReadyReplicas(yas, pools) {
+			draining = append(draining, name)
+		}
+	}
+	if len(draining) == 0 {
+		logger.V(4).Info("Pool is drained", "pools", joinedPools(platformAdmin))
+		return 0, nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		message := fmt.Sprintf("Drain of pool %s expired, the replicas of %s are still ready", joinedPools(platformAdmin), strings.Join(draining, ","))
+		logger.Info("Drain of pool expired, remove the pool anyway", "pools", joinedPools(platformAdmin), "yurtappsets", draining)
+		r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.DrainTimeoutReason, message)
+		return 0, r.setTerminatingCondition(ctx, platformAdmin, iotv1alpha2.DrainTimeoutReason, message)
+	}
+
+	message := fmt.Sprintf("Waiting for the replicas of pool %s in %s to be gone", joinedPools(platformAdmin), strings.Join(draining, ","))
+	if err := r.setTerminatingCondition(ctx, platformAdmin, iotv1alpha2.DrainingReason, message); err != nil {
+		return 0, err
+	}
+	if remaining < drainRequeueAfter {
+		return remaining, nil
+	}
+	return drainRequeueAfter, nil
+}
+
+// hasReadyReplicas checks whether any of the pools has ready replicas in yurtappset.
+func hasReadyReplicas(yas *appsv1alpha1.YurtAppSet, pools []string) bool {
+	for _, poolName := range pools {
+		if yas.Status.PoolReadyReplicas[poolName] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleDownPool sets the replicas of the pools of PlatformAdmin to 0, and returns whether the yurtappset is changed.
+func (r *ReconcilePlatformAdmin) scaleDownPool(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, yas *appsv1alpha1.YurtAppSet) (bool, error) {
+	oldYas := yas.DeepCopy()
+	pools := sets.NewString(util.PlatformAdminPools(platformAdmin)...)
+	for i := range yas.Spec.Topology.Pools {
+		pool := &yas.Spec.Topology.Pools[i]
+		if pools.Has(pool.Name) && (pool.Replicas == nil || *pool.Replicas != 0) {
+			pool.Replicas = pointer.Int32Ptr(0)
+		}
+	}
+	if equality.Semantic.DeepEqual(oldYas.Spec, yas.Spec) {
+		return false, nil
+	}
+	if err := r.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
+		return false, err
+	}
+	recordOperation(kindYurtAppSet, operationPatch)
+	log.FromContext(ctx).Info("Scale down the pool before removing it", "yurtappset", yas.Name, "pools", joinedPools(platformAdmin))
+	return true, nil
+}
+
+// setTerminatingCondition writes the Terminating condition, since the status is not written by Reconcile
+// once PlatformAdmin is deleted.
+func (r *ReconcilePlatformAdmin) setTerminatingCondition(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, reason, message string) error {
+	status := platformAdmin.Status.DeepCopy()
+	util.SetPlatformAdminCondition(status, util.NewPlatformAdminCondition(iotv1alpha2.TerminatingCondition, corev1.ConditionTrue, reason, message))
+	if equality.Semantic.DeepEqual(platformAdmin.Status, *status) {
+		return nil
+	}
+	platformAdmin.Status = *status
+	return r.Status().Update(ctx, platformAdmin)
+}
+
+// drainedYurtAppSets returns the names of yurtappsets which the pool of PlatformAdmin is drained from, they are the
+// ones recorded in status and the ones of components. The daemon components are not drained.
+func drainedYurtAppSets(managed []string, components []*config.Component) []string {
+	names := sets.NewString(managed...)
+	for _, component := range components {
+		if !isDaemonComponent(component) {
+			names.Insert(component.Name)
+		}
+	}
+	return names.List()
+}
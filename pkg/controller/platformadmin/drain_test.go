@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// setPoolReadyReplicas fakes the status of yurtappset reported by the yurtappset controller.
+func setPoolReadyReplicas(t *testing.T, r *ReconcilePlatformAdmin, yas *appsv1alpha1.YurtAppSet, poolName string, ready int32) {
+	t.Helper()
+	yas.Status.PoolReadyReplicas = map[string]int32{poolName: ready}
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update the status of YurtAppSet, %v", err)
+	}
+}
+
+func TestDrainPool(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	pa.Spec.DeletionGracePeriodSeconds = pointer.Int64Ptr(600)
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	setPoolReadyReplicas(t, r, getYurtAppSet(t, r, pa.Namespace, testComponent), "hangzhou", 1)
+
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	result := reconcilePlatformAdmin(t, r, pa)
+	if result.RequeueAfter <= 0 {
+		t.Errorf("expect requeue while draining, but got %v", result)
+	}
+
+	// the pool is scaled to 0 instead of being removed
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if len(yas.Spec.Topology.Pools) != 1 || yas.Spec.Topology.Pools[0].Replicas == nil || *yas.Spec.Topology.Pools[0].Replicas != 0 {
+		t.Errorf("expect pool is scaled to 0, but got %v", yas.Spec.Topology.Pools)
+	}
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("expect PlatformAdmin is kept while draining, but got %v", err)
+	}
+	if cond := util.GetPlatformAdminCondition(latest.Status, iotv1alpha2.TerminatingCondition); cond == nil || cond.Reason != iotv1alpha2.DrainingReason {
+		t.Errorf("expect Terminating condition with reason %s, but got %v", iotv1alpha2.DrainingReason, cond)
+	}
+
+	// the replicas are still ready
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); err != nil {
+		t.Fatalf("expect PlatformAdmin is kept while replicas are ready, but got %v", err)
+	}
+
+	// the pool is removed once the replicas are gone
+	setPoolReadyReplicas(t, r, yas, "hangzhou", 0)
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+	}
+	if pools := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools; len(pools) != 0 {
+		t.Errorf("expect pool is removed, but got %v", pools)
+	}
+}
+
+func TestDrainPoolExpired(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	pa.Spec.DeletionGracePeriodSeconds = pointer.Int64Ptr(60)
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.ResourceVersion = ""
+	yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": 1}
+
+	// the PlatformAdmin was deleted before the grace period
+	deleted := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), deleted); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	deleted.ResourceVersion = ""
+	deletionTimestamp := metav1.NewTime(time.Now().Add(-time.Hour))
+	deleted.DeletionTimestamp = &deletionTimestamp
+	r = newTestReconciler(r.getConfiguration(), deleted, yas)
+
+	reconcilePlatformAdmin(t, r, deleted)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted after the grace period, but got %v", err)
+	}
+	if pools := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools; len(pools) != 0 {
+		t.Errorf("expect pool is removed, but got %v", pools)
+	}
+}
+
+func TestDrainDeadline(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name              string
+		gracePeriod       *int64
+		deletionTimestamp *metav1.Time
+		policy            string
+		expect            bool
+	}{
+		{name: "no grace period", deletionTimestamp: &now},
+		{name: "zero grace period", gracePeriod: pointer.Int64Ptr(0), deletionTimestamp: &now},
+		{name: "not deleted", gracePeriod: pointer.Int64Ptr(30)},
+		{name: "orphan policy", gracePeriod: pointer.Int64Ptr(30), deletionTimestamp: &now, policy: iotv1alpha2.DeletionPolicyOrphan},
+		{name: "drained", gracePeriod: pointer.Int64Ptr(30), deletionTimestamp: &now, expect: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.Spec.DeletionGracePeriodSeconds = tt.gracePeriod
+			pa.Spec.DeletionPolicy = tt.policy
+			pa.DeletionTimestamp = tt.deletionTimestamp
+			deadline, ok := drainDeadline(pa)
+			if ok != tt.expect {
+				t.Fatalf("expect drained %v, but got %v", tt.expect, ok)
+			}
+			if ok && !deadline.Equal(now.Add(30*time.Second)) {
+				t.Errorf("expect deadline 30s after deletion, but got %v", deadline)
+			}
+		})
+	}
+}
+
+func TestDrainedYurtAppSets(t *testing.T) {
+	daemon := newTestComponent("edgex-device-virtual", testImage)
+	daemon.WorkloadType = iotv1alpha2.WorkloadTypeDaemonSet
+	components := []*config.Component{newTestComponent(testComponent, testImage), daemon}
+	expect := []string{testComponent, "edgex-redis"}
+	if got := drainedYurtAppSets([]string{"edgex-redis", testComponent}, components); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %v, but got %v", expect, got)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// droppedPools returns the pools recorded in status which are removed from the spec of PlatformAdmin, the ones
+// claimed by another PlatformAdmin generating into the same namespace since then are left to it.
+func (r *ReconcilePlatformAdmin) droppedPools(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) ([]string, error) {
+	dropped := sets.NewString(platformAdminStatus.CurrentPools...).Delete(util.PlatformAdminPools(platformAdmin)...)
+	if dropped.Len() == 0 {
+		return nil, nil
+	}
+
+	others, err := r.workloadPlatformAdmins(ctx, workloadNamespace(platformAdmin))
+	if err != nil {
+		return nil, err
+	}
+	for i := range others {
+		other := &others[i]
+		if (other.Namespace == platformAdmin.Namespace && other.Name == platformAdmin.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		dropped.Delete(util.PlatformAdminPools(other)...)
+	}
+	return dropped.List(), nil
+}
+
+// releaseDroppedPools removes the pools dropped from the spec of PlatformAdmin from its yurtappsets and yurtappdaemons,
+// the workloads are kept for the remaining pools. The pools are recorded in status once the dropped ones are released,
+// so the pools failing to be released are retried by the next reconcile.
+func (r *ReconcilePlatformAdmin) releaseDroppedPools(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) error {
+	dropped, err := r.droppedPools(ctx, platformAdmin, platformAdminStatus)
+	if err != nil {
+		return err
+	}
+	if len(dropped) > 0 {
+		log.FromContext(ctx).Info("Release the pools removed from PlatformAdmin", "pools", dropped)
+		var errs []error
+		if err := r.forEachOwned(ctx, platformAdmin, &appsv1alpha1.YurtAppSetList{}, LabelDeployment, func(obj client.Object) error {
+			return client.IgnoreNotFound(r.removePools(ctx, obj.(*appsv1alpha1.YurtAppSet), dropped))
+		}); err != nil {
+			errs = append(errs, err)
+		}
+		if err := r.forEachOwned(ctx, platformAdmin, &appsv1alpha1.YurtAppDaemonList{}, LabelYurtAppDaemon, func(obj client.Object) error {
+			return client.IgnoreNotFound(r.removeDaemonPools(ctx, obj.(*appsv1alpha1.YurtAppDaemon), dropped))
+		}); err != nil {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return kerrors.NewAggregate(errs)
+		}
+	}
+	platformAdminStatus.CurrentPools = util.PlatformAdminPools(platformAdmin)
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestReleaseDroppedPools(t *testing.T) {
+	tests := []struct {
+		name        string
+		poolName    string
+		pools       []string
+		otherPools  []string
+		expectPools []string
+	}{
+		{name: "pool removed", poolName: "hangzhou", expectPools: []string{"hangzhou", "shanghai"}},
+		{name: "poolName changed", poolName: "guangzhou", pools: []string{"beijing"}, expectPools: []string{"beijing", "guangzhou", "shanghai"}},
+		{name: "pool moved into poolName", poolName: "beijing", pools: []string{"hangzhou"}, expectPools: []string{"beijing", "hangzhou", "shanghai"}},
+		{name: "removed pool claimed by another", poolName: "hangzhou", otherPools: []string{"beijing"}, expectPools: []string{"beijing", "hangzhou", "shanghai"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-edgex"
+			pa.Spec.Pools = []string{"beijing"}
+			other := newTestPlatformAdmin("default", "edgex-other", "shanghai")
+			other.UID = "uid-other"
+			var nodePools []client.Object
+			for _, name := range []string{"hangzhou", "beijing", "shanghai", "guangzhou"} {
+				nodePools = append(nodePools, &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			}
+			daemonComponent := newTestComponent("edgex-device-virtual", testImage)
+			daemonComponent.WorkloadType = iotv1alpha2.WorkloadTypeDaemonSet
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage), daemonComponent), append(nodePools, pa, other)...)
+			reconcilePlatformAdmin(t, r, pa)
+			reconcilePlatformAdmin(t, r, other)
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if !reflect.DeepEqual(pa.Status.CurrentPools, []string{"hangzhou", "beijing"}) {
+				t.Errorf("expect current pools are recorded, but got %v", pa.Status.CurrentPools)
+			}
+			pa.Spec.PoolName = tt.poolName
+			pa.Spec.Pools = tt.pools
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			if tt.otherPools != nil {
+				if err := r.Get(context.TODO(), client.ObjectKeyFromObject(other), other); err != nil {
+					t.Fatalf("failed to get PlatformAdmin, %v", err)
+				}
+				other.Spec.Pools = tt.otherPools
+				if err := r.Update(context.TODO(), other); err != nil {
+					t.Fatalf("failed to update PlatformAdmin, %v", err)
+				}
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			var names []string
+			for _, pool := range getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools {
+				names = append(names, pool.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.expectPools) {
+				t.Errorf("expect pools %v in yurtappset, but got %v", tt.expectPools, names)
+			}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if !reflect.DeepEqual(pa.Status.CurrentPools, append([]string{tt.poolName}, tt.pools...)) {
+				t.Errorf("expect current pools %v, but got %v", append([]string{tt.poolName}, tt.pools...), pa.Status.CurrentPools)
+			}
+
+			// the dropped pools are released from the yurtappdaemon as well
+			daemonPools := selectedPools(getYurtAppDaemon(t, r, pa.Namespace, "edgex-device-virtual"))
+			sort.Strings(daemonPools)
+			if !reflect.DeepEqual(daemonPools, tt.expectPools) {
+				t.Errorf("expect yurtappdaemon selects pools %v, but got %v", tt.expectPools, daemonPools)
+			}
+		})
+	}
+}
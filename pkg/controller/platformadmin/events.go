@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// recordOperationEvent records a Normal event of PlatformAdmin when an object is created or updated for it, e.g.
+// CreatedYurtAppSet, so the reconciles which change nothing record no event.
+func (r *ReconcilePlatformAdmin) recordOperationEvent(platformAdmin *iotv1alpha2.PlatformAdmin, kind, name string, result controllerutil.OperationResult) {
+	var verb string
+	switch result {
+	case controllerutil.OperationResultCreated:
+		verb = "Created"
+	case controllerutil.OperationResultUpdated:
+		verb = "Updated"
+	default:
+		return
+	}
+	r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeNormal, verb+kind,
+		fmt.Sprintf("%s %s %s", verb, strings.ToLower(kind), name))
+}
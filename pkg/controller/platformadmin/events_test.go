@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa,
+		newTestNode("node-1", "hangzhou"), newTestEndpoints(pa.Namespace, testComponent, "node-1"))
+
+	expectEvents := func(step string, expect map[string]int) {
+		t.Helper()
+		counts := countRecordedEvents(r)
+		for reason, count := range expect {
+			if got := counts[reason]; got != count {
+				t.Errorf("%s: expect %d events %s, but got %d", step, count, reason, got)
+			}
+		}
+	}
+
+	// the objects are created, while the components are not ready yet
+	reconcilePlatformAdmin(t, r, pa)
+	expectEvents("first reconcile", map[string]int{
+		"CreatedConfigMap":      1,
+		"CreatedService":        1,
+		"CreatedYurtAppSet":     1,
+		iotv1alpha2.ReadyReason: 0,
+	})
+
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+	setPoolReadyReplicas(t, r, yas, "hangzhou", 1)
+	reconcilePlatformAdmin(t, r, pa)
+	expectEvents("ready", map[string]int{
+		iotv1alpha2.ReadyReason: 1,
+		"CreatedYurtAppSet":     0,
+	})
+
+	// nothing is recorded by the reconciles changing nothing
+	reconcilePlatformAdmin(t, r, pa)
+	if events := countRecordedEvents(r); len(events) != 0 {
+		t.Errorf("expect no event of the no-op reconcile, but got %v", events)
+	}
+}
+
+func TestProvisioningFailedEvents(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin)
+	}{
+		{
+			name: "service is broken",
+			modify: func(r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin) {
+				r.Client = &createErrorClient{Client: r.Client, service: testComponent, err: errors.New("service is broken")}
+			},
+		},
+		{
+			name: "annotation is broken",
+			modify: func(r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin) {
+				pa.Annotations = map[string]string{iotv1alpha1.AnnotationAdditionalDeployments: "{broken"}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)))
+			tc.modify(r, pa)
+			if err := r.Create(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to create PlatformAdmin, %v", err)
+			}
+
+			// the failure retried with backoff is reported only once
+			for i := 0; i < 2; i++ {
+				if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)}); err == nil {
+					t.Errorf("expect error of the broken component, but got nil")
+				}
+			}
+			if count := countEvents(r, iotv1alpha2.ComponentProvisioningFailedReason); count != 1 {
+				t.Errorf("expect 1 warning event %s, but got %d", iotv1alpha2.ComponentProvisioningFailedReason, count)
+			}
+		})
+	}
+}
+
+// countRecordedEvents drains the events and counts them by reason.
+func countRecordedEvents(r *ReconcilePlatformAdmin) map[string]int {
+	counts := make(map[string]int)
+	for {
+		event, ok := findEvent(r, "")
+		if !ok {
+			return counts
+		}
+		counts[strings.Fields(event)[1]]++
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// isGeneratedConfigMap checks whether the configmap is generated by PlatformAdmins, the other configmaps with the
+// name of a template are provided by the users, e.g. while the configmap management of PlatformAdmin is skipped.
+func isGeneratedConfigMap(configmap *corev1.ConfigMap) bool {
+	_, labeled := configmap.Labels[iotv1alpha2.LabelPlatformAdmin]
+	return labeled || platformAdminOwners(configmap) > 0
+}
+
+// adoptedKeys returns the keys of the existing configmap whose values are kept instead of the ones of template. They
+// are recorded when the configmap provided by the users is adopted, i.e. the keys of template which it already has.
+func adoptedKeys(existing *corev1.ConfigMap, desired *corev1.ConfigMap) []string {
+	if existing == nil {
+		return nil
+	}
+	if recorded := existing.Annotations[iotv1alpha2.AnnotationAdoptedKeys]; recorded != "" {
+		return strings.Split(recorded, ",")
+	}
+	if isGeneratedConfigMap(existing) {
+		return nil
+	}
+
+	var keys []string
+	for k := range desired.Data {
+		if _, ok := existing.Data[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	for k := range desired.BinaryData {
+		if _, ok := existing.BinaryData[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setAdoptedData sets the data of template to the applied configmap except for the adopted keys, which are left to
+// the users and recorded by annotation.
+func setAdoptedData(configmap *corev1.ConfigMap, desired *corev1.ConfigMap, adopted []string) {
+	configmap.Data = nil
+	configmap.BinaryData = nil
+	kept := make(map[string]struct{}, len(adopted))
+	for _, k := range adopted {
+		kept[k] = struct{}{}
+	}
+	for k, v := range desired.Data {
+		if _, ok := kept[k]; ok {
+			continue
+		}
+		if configmap.Data == nil {
+			configmap.Data = make(map[string]string)
+		}
+		configmap.Data[k] = v
+	}
+	for k, v := range desired.BinaryData {
+		if _, ok := kept[k]; ok {
+			continue
+		}
+		if configmap.BinaryData == nil {
+			configmap.BinaryData = make(map[string][]byte)
+		}
+		configmap.BinaryData[k] = v
+	}
+	if len(adopted) == 0 {
+		return
+	}
+	if configmap.Annotations == nil {
+		configmap.Annotations = make(map[string]string)
+	}
+	configmap.Annotations[iotv1alpha2.AnnotationAdoptedKeys] = strings.Join(adopted, ",")
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+func TestAdoptedKeys(t *testing.T) {
+	desired := &corev1.ConfigMap{
+		Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "EDGEX_ADDED": "true"},
+		BinaryData: map[string][]byte{"ca.crt": []byte("ca")},
+	}
+	owner := metav1.OwnerReference{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: "edgex", UID: "uid"}
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		expect   []string
+	}{
+		{
+			name: "not found",
+		},
+		{
+			name: "generated by PlatformAdmin",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}},
+				Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true"},
+			},
+		},
+		{
+			name: "generated into another namespace",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{iotv1alpha2.LabelPlatformAdmin: "default.edgex"}},
+				Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true"},
+			},
+		},
+		{
+			name: "provided by users",
+			existing: &corev1.ConfigMap{
+				Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true", "USER_KEY": "user"},
+				BinaryData: map[string][]byte{"ca.crt": []byte("user")},
+			},
+			expect: []string{"EDGEX_SECURITY_SECRET_STORE", "ca.crt"},
+		},
+		{
+			name: "adopted before",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:     map[string]string{iotv1alpha2.AnnotationAdoptedKeys: "EDGEX_SECURITY_SECRET_STORE"},
+					OwnerReferences: []metav1.OwnerReference{owner},
+				},
+				Data: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true", "EDGEX_ADDED": "true"},
+			},
+			expect: []string{"EDGEX_SECURITY_SECRET_STORE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adoptedKeys(tt.existing, desired); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect adopted keys %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestSkipConfigmapManagement(t *testing.T) {
+	const configmapName = "common-variable-levski"
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-edgex"
+	pa.Spec.SkipConfigmapManagement = true
+	// the configmap of users is labeled like a generated one by the GitOps flow, but not owned by PlatformAdmin
+	userLabels := map[string]string{
+		iotv1alpha2.LabelPlatformAdminGenerate:  LabelConfigmap,
+		iotv1alpha2.LabelPlatformAdminNamespace: pa.Namespace,
+		iotv1alpha2.LabelPlatformAdminName:      pa.Name,
+	}
+	user := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: configmapName, Labels: userLabels},
+		Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "sealed"},
+	}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, user)
+	reconcilePlatformAdmin(t, r, pa)
+
+	key := types.NamespacedName{Namespace: "default", Name: configmapName}
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if cm.Data["EDGEX_SECURITY_SECRET_STORE"] != "sealed" || len(cm.OwnerReferences) != 0 || len(cm.Finalizers) != 0 {
+		t.Errorf("expect configmap of users is not touched, but got data %v, owners %v and finalizers %v", cm.Data, cm.OwnerReferences, cm.Finalizers)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ConfigmapAvailableCondition)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != iotv1alpha2.ExternallyManagedReason {
+		t.Errorf("expect configmap available condition with reason %s, but got %v", iotv1alpha2.ExternallyManagedReason, cond)
+	}
+	// the components are still deployed
+	getYurtAppSet(t, r, pa.Namespace, testComponent)
+
+	// the configmap of users is kept after PlatformAdmin is deleted
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	cm = &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		t.Fatalf("expect configmap of users is kept, but got %v", err)
+	}
+	if cm.Data["EDGEX_SECURITY_SECRET_STORE"] != "sealed" {
+		t.Errorf("expect data of users is kept, but got %v", cm.Data)
+	}
+}
+
+func TestSkipConfigmapManagementKeepsGenerated(t *testing.T) {
+	const configmapName = "common-variable-levski"
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-edgex"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the generated configmap is handed over to users
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.SkipConfigmapManagement = true
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: configmapName}, cm); err != nil {
+		t.Fatalf("expect configmap is kept, but got %v", err)
+	}
+	if isOwnedBy(cm, pa) || controllerutil.ContainsFinalizer(cm, iotv1alpha2.ConfigMapFinalizer) {
+		t.Errorf("expect configmap is released to users, but got owners %v and finalizers %v", cm.OwnerReferences, cm.Finalizers)
+	}
+}
+
+func TestReenableConfigmapManagement(t *testing.T) {
+	const configmapName = "common-variable-levski"
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-edgex"
+	pa.Spec.SkipConfigmapManagement = true
+	user := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: configmapName},
+		Data:       map[string]string{"EDGEX_SECURITY_SECRET_STORE": "sealed", "USER_KEY": "user"},
+	}
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	conf.NoSectyConfigMaps[testVersion][0].Data["EDGEX_ADDED"] = "true"
+	r := newTestReconciler(conf, pa, user)
+	reconcilePlatformAdmin(t, r, pa)
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.SkipConfigmapManagement = false
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+
+	// the configmap of users is adopted, the management is resumed idempotently
+	expectData := map[string]string{"EDGEX_SECURITY_SECRET_STORE": "sealed", "USER_KEY": "user", "EDGEX_ADDED": "true"}
+	for i := 0; i < 2; i++ {
+		reconcilePlatformAdmin(t, r, pa)
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: configmapName}, cm); err != nil {
+			t.Fatalf("failed to get configmap, %v", err)
+		}
+		if !reflect.DeepEqual(cm.Data, expectData) {
+			t.Errorf("expect data %v after reconcile %d, but got %v", expectData, i+1, cm.Data)
+		}
+		if !isOwnedBy(cm, pa) || cm.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelConfigmap {
+			t.Errorf("expect configmap is adopted, but got labels %v and owners %v", cm.Labels, cm.OwnerReferences)
+		}
+		if cm.Annotations[iotv1alpha2.AnnotationAdoptedKeys] != "EDGEX_SECURITY_SECRET_STORE" {
+			t.Errorf("expect adopted keys are recorded, but got %v", cm.Annotations)
+		}
+	}
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ConfigmapAvailableCondition)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != "" {
+		t.Errorf("expect configmap available condition without reason, but got %v", cond)
+	}
+}
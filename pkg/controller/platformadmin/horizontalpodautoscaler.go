@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// isAutoscaled checks whether the deployment of component in the pool is scaled by a HorizontalPodAutoscaler,
+// the daemon components run on every node of the pool and are not scaled.
+func isAutoscaled(component *config.Component) bool {
+	return component.Autoscaling != nil && component.Deployment != nil && !isDaemonComponent(component)
+}
+
+// autoscalerName returns the name of the HorizontalPodAutoscaler of component in the pool.
+func autoscalerName(component *config.Component, poolName string) string {
+	return fmt.Sprintf("%s-%s", component.Name, poolName)
+}
+
+// autoscalerNames returns the names of the HorizontalPodAutoscalers of component in the pools of PlatformAdmin.
+func autoscalerNames(platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) []string {
+	var names []string
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		names = append(names, autoscalerName(component, poolName))
+	}
+	return names
+}
+
+// minReplicas returns the lower limit of the replicas of autoscaling, which defaults to 1.
+func minReplicas(autoscaling *iotv1alpha2.ComponentAutoscaling) int32 {
+	if autoscaling.MinReplicas == nil {
+		return 1
+	}
+	return *autoscaling.MinReplicas
+}
+
+// poolDeployment returns the deployment created by the yurtappset in the pool. Its name is generated by the
+// yurtappset controller, so it is found by the pool label and the controller reference.
+// It is nil if the deployment is not created yet.
+func (r *ReconcilePlatformAdmin) poolDeployment(ctx context.Context, yas *appsv1alpha1.YurtAppSet, poolName string) (*appsv1.Deployment, error) {
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(yas.Namespace), client.MatchingLabels{appsv1alpha1.PoolNameLabelKey: poolName}); err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		if metav1.IsControlledBy(&deployments.Items[i], yas) {
+			return &deployments.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// handleHorizontalPodAutoscaler creates or updates the HorizontalPodAutoscaler of component in the pool, which targets
+// the deployment of the pool instead of the yurtappset, since the yurtappset spreads the replicas over the pools.
+// It is possible for hpa to be nil when there is no error, e.g. the deployment of pool is not created yet!
+func (r *ReconcilePlatformAdmin) handleHorizontalPodAutoscaler(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, yas *appsv1alpha1.YurtAppSet, poolName string) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	if !isAutoscaled(component) {
+		return nil, nil
+	}
+	deployment, err := r.poolDeployment(ctx, yas, poolName)
+	if err != nil || deployment == nil {
+		return nil, err
+	}
+
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      autoscalerName(component, poolName),
+			Namespace: workloadNamespace(platformAdmin),
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(
+		ctx,
+		r.Client,
+		hpa,
+		func() error {
+			if hpa.Labels == nil {
+				hpa.Labels = make(map[string]string)
+			}
+			hpa.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelHorizontalPodAutoscaler
+			propagateMetadata(platformAdmin, hpa)
+			hpa.Spec = newHorizontalPodAutoscalerSpec(component.Autoscaling, deployment.Name)
+			return r.setOwner(platformAdmin, hpa)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordOperationResult(kindHorizontalPodAutoscaler, result)
+	return hpa, nil
+}
+
+// newHorizontalPodAutoscalerSpec generates the spec of HorizontalPodAutoscaler targeting the deployment.
+func newHorizontalPodAutoscalerSpec(autoscaling *iotv1alpha2.ComponentAutoscaling, deploymentName string) autoscalingv2beta2.HorizontalPodAutoscalerSpec {
+	spec := autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+			Name:       deploymentName,
+		},
+		MinReplicas: pointer.Int32Ptr(minReplicas(autoscaling)),
+		MaxReplicas: autoscaling.MaxReplicas,
+	}
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		spec.Metrics = []autoscalingv2beta2.MetricSpec{
+			{
+				Type: autoscalingv2beta2.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2beta2.MetricTarget{
+						Type:               autoscalingv2beta2.UtilizationMetricType,
+						AverageUtilization: pointer.Int32Ptr(*autoscaling.TargetCPUUtilizationPercentage),
+					},
+				},
+			},
+		}
+	}
+	return spec
+}
+
+// followAutoscaler sets the replicas of the pool to the ones desired by the HorizontalPodAutoscaler. The yurtappset
+// controller resets the replicas of deployment to the ones of pool, so the pool follows the autoscaler instead of
+// fighting with it.
+func (r *ReconcilePlatformAdmin) followAutoscaler(ctx context.Context, yas *appsv1alpha1.YurtAppSet, hpa *autoscalingv2beta2.HorizontalPodAutoscaler, poolName string) error {
+	desired := hpa.Status.DesiredReplicas
+	if desired <= 0 {
+		return nil
+	}
+	oldYas := yas.DeepCopy()
+	for i := range yas.Spec.Topology.Pools {
+		pool := &yas.Spec.Topology.Pools[i]
+		if pool.Name == poolName && (pool.Replicas == nil || *pool.Replicas != desired) {
+			pool.Replicas = pointer.Int32Ptr(desired)
+		}
+	}
+	if equality.Semantic.DeepEqual(oldYas.Spec, yas.Spec) {
+		return nil
+	}
+	if err := r.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
+		return err
+	}
+	recordOperation(kindYurtAppSet, operationPatch)
+	log.FromContext(ctx).Info("Scale the pool as the HorizontalPodAutoscaler desires", "yurtappset", yas.Name, "pool", poolName, "replicas", desired)
+	return nil
+}
+
+// isAutoscaledPoolReady checks whether the pool of the autoscaled component is ready. The replicas may exceed the
+// base replicas while the autoscaler scales out, so the pool is ready as long as the minimal replicas are ready.
+func isAutoscaledPoolReady(yas *appsv1alpha1.YurtAppSet, poolName string, autoscaling *iotv1alpha2.ComponentAutoscaling) bool {
+	if _, ok := yas.Status.PoolReplicas[poolName]; !ok {
+		return false
+	}
+	if yas.Status.PoolReadyReplicas == nil {
+		// The per-pool ready replicas is not reported yet, see isPoolReady
+		return false
+	}
+	return yas.Status.PoolReadyReplicas[poolName] >= minReplicas(autoscaling)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// createPoolDeployment fakes the deployment created by the yurtappset controller in the pool.
+func createPoolDeployment(t *testing.T, r *ReconcilePlatformAdmin, yas *appsv1alpha1.YurtAppSet, poolName string) *appsv1.Deployment {
+	t.Helper()
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: yas.Namespace,
+			Name:      yas.Name + "-" + poolName + "-x7k2p",
+			Labels:    map[string]string{appsv1alpha1.PoolNameLabelKey: poolName},
+		},
+	}
+	if err := controllerutil.SetControllerReference(yas, deployment, r.Scheme()); err != nil {
+		t.Fatalf("failed to set controller reference, %v", err)
+	}
+	if err := r.Create(context.TODO(), deployment); err != nil {
+		t.Fatalf("failed to create deployment, %v", err)
+	}
+	return deployment
+}
+
+func getAutoscaler(t *testing.T, r *ReconcilePlatformAdmin, namespace, name string) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	t.Helper()
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, hpa)
+	return hpa, err
+}
+
+func setComponentAutoscaling(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, autoscaling *iotv1alpha2.ComponentAutoscaling) {
+	t.Helper()
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.Components = nil
+	if autoscaling != nil {
+		pa.Spec.Components = []iotv1alpha2.Component{{Name: testComponent, Autoscaling: autoscaling}}
+	}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+}
+
+func TestHorizontalPodAutoscaler(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.Components = []iotv1alpha2.Component{{
+		Name:        testComponent,
+		Autoscaling: &iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(2), MaxReplicas: 4},
+	}}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	hpaName := testComponent + "-hangzhou"
+
+	// the autoscaler waits for the deployment of pool
+	reconcilePlatformAdmin(t, r, pa)
+	if _, err := getAutoscaler(t, r, pa.Namespace, hpaName); !apierrors.IsNotFound(err) {
+		t.Fatalf("expect no autoscaler before the deployment is created, but got %v", err)
+	}
+
+	deployment := createPoolDeployment(t, r, getYurtAppSet(t, r, pa.Namespace, testComponent), "hangzhou")
+	reconcilePlatformAdmin(t, r, pa)
+	hpa, err := getAutoscaler(t, r, pa.Namespace, hpaName)
+	if err != nil {
+		t.Fatalf("expect autoscaler is created, but got %v", err)
+	}
+	if hpa.Spec.ScaleTargetRef.Kind != "Deployment" || hpa.Spec.ScaleTargetRef.Name != deployment.Name {
+		t.Errorf("expect autoscaler targets deployment %s, but got %v", deployment.Name, hpa.Spec.ScaleTargetRef)
+	}
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 4 || len(hpa.Spec.Metrics) != 0 {
+		t.Errorf("expect replicas between 2 and 4 without metrics, but got %v", hpa.Spec)
+	}
+	if hpa.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelHorizontalPodAutoscaler || !isOwnedBy(hpa, pa) {
+		t.Errorf("expect autoscaler is labeled and owned by PlatformAdmin, but got %v, %v", hpa.Labels, hpa.OwnerReferences)
+	}
+
+	// the limits are updated
+	setComponentAutoscaling(t, r, pa, &iotv1alpha2.ComponentAutoscaling{MaxReplicas: 6, TargetCPUUtilizationPercentage: pointer.Int32Ptr(70)})
+	reconcilePlatformAdmin(t, r, pa)
+	hpa, err = getAutoscaler(t, r, pa.Namespace, hpaName)
+	if err != nil {
+		t.Fatalf("failed to get autoscaler, %v", err)
+	}
+	if *hpa.Spec.MinReplicas != 1 || hpa.Spec.MaxReplicas != 6 {
+		t.Errorf("expect replicas between 1 and 6, but got %v", hpa.Spec)
+	}
+	if len(hpa.Spec.Metrics) != 1 || *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 70 {
+		t.Errorf("expect cpu utilization target 70, but got %v", hpa.Spec.Metrics)
+	}
+
+	// the pool follows the desired replicas of autoscaler
+	hpa.Status.DesiredReplicas = 3
+	if err := r.Status().Update(context.TODO(), hpa); err != nil {
+		t.Fatalf("failed to update the status of autoscaler, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	pools := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools
+	if len(pools) != 1 || pools[0].Replicas == nil || *pools[0].Replicas != 3 {
+		t.Errorf("expect pool is scaled to 3, but got %v", pools)
+	}
+
+	// the autoscaler is cleaned up with the autoscaling of component
+	setComponentAutoscaling(t, r, pa, nil)
+	reconcilePlatformAdmin(t, r, pa)
+	if _, err := getAutoscaler(t, r, pa.Namespace, hpaName); !apierrors.IsNotFound(err) {
+		t.Errorf("expect autoscaler is deleted, but got %v", err)
+	}
+}
+
+func TestIsAutoscaledPoolReady(t *testing.T) {
+	autoscaling := &iotv1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(2), MaxReplicas: 5}
+	tests := []struct {
+		name   string
+		status appsv1alpha1.YurtAppSetStatus
+		expect bool
+	}{
+		{name: "pool not reported"},
+		{
+			name:   "scaled out beyond the base replicas",
+			status: appsv1alpha1.YurtAppSetStatus{PoolReplicas: map[string]int32{"hangzhou": 4}, PoolReadyReplicas: map[string]int32{"hangzhou": 3}},
+			expect: true,
+		},
+		{
+			name:   "less than min replicas are ready",
+			status: appsv1alpha1.YurtAppSetStatus{PoolReplicas: map[string]int32{"hangzhou": 4}, PoolReadyReplicas: map[string]int32{"hangzhou": 1}},
+		},
+		{
+			name:   "per-pool ready replicas not reported",
+			status: appsv1alpha1.YurtAppSetStatus{PoolReplicas: map[string]int32{"hangzhou": 4}, ReadyReplicas: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yas := &appsv1alpha1.YurtAppSet{Status: tt.status}
+			if got := isAutoscaledPoolReady(yas, "hangzhou", autoscaling); got != tt.expect {
+				t.Errorf("expect ready %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// platformAdminOwners counts the PlatformAdmins in the owner references of the object.
+func platformAdminOwners(obj client.Object) int {
+	return len(platformAdminOwnerReferences(obj))
+}
+
+// setIdentityLabels labels the object with the PlatformAdmin and the component which it is generated for, it must
+// be called after the owner is set. The name of PlatformAdmin is only labeled while it is the only owner, since the
+// object shared by several PlatformAdmins can not tell them by one label. It returns true if the labels are changed.
+func setIdentityLabels(platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object, component string) bool {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string)
+	}
+	desired := map[string]string{iotv1alpha2.LabelPlatformAdminNamespace: platformAdmin.Namespace}
+	if component != "" {
+		desired[iotv1alpha2.LabelComponent] = component
+	}
+	if platformAdminOwners(obj) <= 1 {
+		desired[iotv1alpha2.LabelPlatformAdminName] = platformAdmin.Name
+	}
+
+	changed := false
+	if _, ok := desired[iotv1alpha2.LabelPlatformAdminName]; !ok {
+		if _, ok := objLabels[iotv1alpha2.LabelPlatformAdminName]; ok {
+			delete(objLabels, iotv1alpha2.LabelPlatformAdminName)
+			changed = true
+		}
+	}
+	for k, v := range desired {
+		if objLabels[k] != v {
+			objLabels[k] = v
+			changed = true
+		}
+	}
+	if changed {
+		obj.SetLabels(objLabels)
+	}
+	return changed
+}
+
+// isIdentityIndexed checks whether the kind of list is labeled with the identity and indexed by it. The index of
+// YurtAppSet is not registered if its CRD is installed after the manager is started.
+func (r *ReconcilePlatformAdmin) isIdentityIndexed(list client.ObjectList) bool {
+	switch list.(type) {
+	case *corev1.ServiceList, *corev1.ConfigMapList, *policyv1.PodDisruptionBudgetList, *networkingv1.NetworkPolicyList:
+		return true
+	case *appsv1alpha1.YurtAppSetList:
+		return r.yurtAppSetIndexed
+	}
+	return false
+}
+
+// hasIdentity checks whether the object is labeled as generated for the PlatformAdmin alone.
+func hasIdentity(obj client.Object, platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	objLabels := obj.GetLabels()
+	return objLabels[iotv1alpha2.LabelPlatformAdminNamespace] == platformAdmin.Namespace &&
+		objLabels[iotv1alpha2.LabelPlatformAdminName] == platformAdmin.Name
+}
+
+// forEachOwned calls fn with the objects generated with the label in the workload namespace of PlatformAdmin and
+// owned by it. The objects labeled with its identity are listed by the index, or by the identity labels if the kind
+// is not indexed. The objects without the name label, i.e. the ones created before the identity labels or shared by
+// several PlatformAdmins, are matched by owner.
+func (r *ReconcilePlatformAdmin) forEachOwned(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, list client.ObjectList, label string, fn func(client.Object) error) error {
+	var errs []error
+	identity := []client.ListOption{client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: label}}
+	if r.isIdentityIndexed(list) {
+		identity = append(identity, client.MatchingFields{util.IndexerPathForPlatformAdmin: util.PlatformAdminIndexKey(platformAdmin.Namespace, platformAdmin.Name)})
+	} else {
+		identity = append(identity, client.MatchingLabels{
+			iotv1alpha2.LabelPlatformAdminNamespace: platformAdmin.Namespace,
+			iotv1alpha2.LabelPlatformAdminName:      platformAdmin.Name,
+		})
+	}
+	// The index only narrows the list, the identity is checked again for the readers without the index
+	if err := r.listPages(ctx, list, func(obj client.Object) error {
+		if !hasIdentity(obj, platformAdmin) {
+			return nil
+		}
+		return fn(obj)
+	}, append(identity, client.InNamespace(workloadNamespace(platformAdmin)))...); err != nil {
+		errs = append(errs, err)
+	}
+
+	generated, _ := labels.NewRequirement(iotv1alpha2.LabelPlatformAdminGenerate, selection.Equals, []string{label})
+	unnamed, _ := labels.NewRequirement(iotv1alpha2.LabelPlatformAdminName, selection.DoesNotExist, nil)
+	if err := r.listPages(ctx, list, func(obj client.Object) error {
+		if !isOwnedBy(obj, platformAdmin) {
+			return nil
+		}
+		return fn(obj)
+	}, client.InNamespace(workloadNamespace(platformAdmin)), client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*generated, *unnamed)}); err != nil {
+		errs = append(errs, err)
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// registerGeneratedFieldIndexers registers the index of identity labels for the configmaps, services,
+// poddisruptionbudgets and networkpolicies listed by forEachOwned. The configmaps and services are indexed by the
+// label-scoped cache if it is set up, since they are listed from it, the others are always listed from the manager.
+func (r *ReconcilePlatformAdmin) registerGeneratedFieldIndexers(mgr manager.Manager) error {
+	var generatedIndexer client.FieldIndexer = mgr.GetFieldIndexer()
+	if r.generatedCache != nil {
+		generatedIndexer = r.generatedCache
+	}
+	if err := util.RegisterGeneratedFieldIndexers(generatedIndexer, &corev1.ConfigMap{}, &corev1.Service{}); err != nil {
+		return err
+	}
+	return util.RegisterGeneratedFieldIndexers(mgr.GetFieldIndexer(), &policyv1.PodDisruptionBudget{}, &networkingv1.NetworkPolicy{})
+}
+
+// registerYurtAppSetFieldIndexer registers the index of identity labels for YurtAppSets, it must be registered
+// before the manager is started and the CRD of YurtAppSet is installed.
+func (r *ReconcilePlatformAdmin) registerYurtAppSetFieldIndexer(mgr manager.Manager) error {
+	if err := util.RegisterGeneratedFieldIndexers(mgr.GetFieldIndexer(), &appsv1alpha1.YurtAppSet{}); err != nil {
+		return err
+	}
+	r.yurtAppSetIndexed = true
+	return nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func newTestOwnerReference(name string, uid types.UID) metav1.OwnerReference {
+	return metav1.OwnerReference{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: name, UID: uid}
+}
+
+func TestSetIdentityLabels(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	tests := []struct {
+		name          string
+		owners        []metav1.OwnerReference
+		labels        map[string]string
+		component     string
+		expectLabels  map[string]string
+		expectChanged bool
+	}{
+		{
+			name:      "only owner",
+			owners:    []metav1.OwnerReference{newTestOwnerReference(pa.Name, pa.UID)},
+			component: testComponent,
+			expectLabels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+				iotv1alpha2.LabelPlatformAdminName:      "edgex",
+				iotv1alpha2.LabelComponent:              testComponent,
+			},
+			expectChanged: true,
+		},
+		{
+			name:   "labeled already",
+			owners: []metav1.OwnerReference{newTestOwnerReference(pa.Name, pa.UID)},
+			labels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+				iotv1alpha2.LabelPlatformAdminName:      "edgex",
+			},
+			expectLabels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+				iotv1alpha2.LabelPlatformAdminName:      "edgex",
+			},
+		},
+		{
+			name:   "shared by several owners",
+			owners: []metav1.OwnerReference{newTestOwnerReference(pa.Name, pa.UID), newTestOwnerReference("edgex-beijing", "uid-beijing")},
+			labels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+				iotv1alpha2.LabelPlatformAdminName:      "edgex-beijing",
+			},
+			expectLabels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+			},
+			expectChanged: true,
+		},
+		{
+			name:      "generated into another namespace",
+			component: testComponent,
+			expectLabels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminNamespace: "default",
+				iotv1alpha2.LabelPlatformAdminName:      "edgex",
+				iotv1alpha2.LabelComponent:              testComponent,
+			},
+			expectChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: testComponent, OwnerReferences: tt.owners, Labels: tt.labels}}
+			if changed := setIdentityLabels(pa, service, tt.component); changed != tt.expectChanged {
+				t.Errorf("expect changed %v, but got %v", tt.expectChanged, changed)
+			}
+			if len(service.Labels) != len(tt.expectLabels) {
+				t.Fatalf("expect labels %v, but got %v", tt.expectLabels, service.Labels)
+			}
+			for k, v := range tt.expectLabels {
+				if service.Labels[k] != v {
+					t.Errorf("expect labels %v, but got %v", tt.expectLabels, service.Labels)
+				}
+			}
+		})
+	}
+}
+
+func TestForEachOwned(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	newService := func(name string, labels map[string]string, owners ...metav1.OwnerReference) client.Object {
+		serviceLabels := map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}
+		for k, v := range labels {
+			serviceLabels[k] = v
+		}
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: serviceLabels, OwnerReferences: owners}}
+	}
+	identity := func(name string) map[string]string {
+		return map[string]string{iotv1alpha2.LabelPlatformAdminNamespace: "default", iotv1alpha2.LabelPlatformAdminName: name}
+	}
+	owner := newTestOwnerReference(pa.Name, pa.UID)
+	other := newTestOwnerReference("edgex-beijing", "uid-beijing")
+
+	tests := []struct {
+		name        string
+		list        client.ObjectList
+		objs        []client.Object
+		expectNames []string
+	}{
+		{
+			name: "labeled with identity",
+			list: &corev1.ServiceList{},
+			objs: []client.Object{
+				newService("labeled", identity("edgex"), owner),
+				newService("labeled-by-other", identity("edgex-beijing"), other),
+			},
+			expectNames: []string{"labeled"},
+		},
+		{
+			name: "created before the identity labels",
+			list: &corev1.ServiceList{},
+			objs: []client.Object{
+				newService("legacy", nil, owner),
+				newService("legacy-of-other", nil, other),
+			},
+			expectNames: []string{"legacy"},
+		},
+		{
+			name: "shared by several owners",
+			list: &corev1.ServiceList{},
+			objs: []client.Object{
+				newService("shared", map[string]string{iotv1alpha2.LabelPlatformAdminNamespace: "default"}, other, owner),
+				newService("labeled", identity("edgex"), owner),
+			},
+			expectNames: []string{"labeled", "shared"},
+		},
+		{
+			name: "kind without identity labels",
+			list: &corev1.SecretList{},
+			objs: []client.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "owned", OwnerReferences: []metav1.OwnerReference{owner},
+					Labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "not-owned", OwnerReferences: []metav1.OwnerReference{other},
+					Labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}}},
+			},
+			expectNames: []string{"owned"},
+		},
+		{
+			// the index of yurtappset is not registered if the controller is added after the manager is started
+			name: "labeled yurtappset without index",
+			list: &appsv1alpha1.YurtAppSetList{},
+			objs: []client.Object{
+				&appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "labeled", OwnerReferences: []metav1.OwnerReference{owner},
+					Labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService, iotv1alpha2.LabelPlatformAdminNamespace: "default", iotv1alpha2.LabelPlatformAdminName: "edgex"}}},
+				&appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "labeled-by-other", OwnerReferences: []metav1.OwnerReference{other},
+					Labels: map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService, iotv1alpha2.LabelPlatformAdminNamespace: "default", iotv1alpha2.LabelPlatformAdminName: "edgex-beijing"}}},
+			},
+			expectNames: []string{"labeled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(), append(tt.objs, pa)...)
+			var names []string
+			if err := r.forEachOwned(context.TODO(), pa, tt.list, LabelService, func(obj client.Object) error {
+				names = append(names, obj.GetName())
+				return nil
+			}); err != nil {
+				t.Fatalf("failed to list owned objects, %v", err)
+			}
+			sort.Strings(names)
+			if len(names) != len(tt.expectNames) {
+				t.Fatalf("expect owned objects %v, but got %v", tt.expectNames, names)
+			}
+			for i := range names {
+				if names[i] != tt.expectNames[i] {
+					t.Errorf("expect owned objects %v, but got %v", tt.expectNames, names)
+				}
+			}
+		})
+	}
+}
+
+func TestIdentityLabelsOfGeneratedObjects(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	minAvailable := intstr.FromInt(1)
+	pa.Spec.PodDisruptionBudget = &iotv1alpha2.PodDisruptionBudgetSpec{MinAvailable: &minAvailable}
+	pa.Spec.NetworkPolicy = true
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	for _, obj := range []client.Object{&corev1.Service{}, &appsv1alpha1.YurtAppSet{}, &policyv1.PodDisruptionBudget{}, &networkingv1.NetworkPolicy{}} {
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: testComponent}, obj); err != nil {
+			t.Fatalf("failed to get %T, %v", obj, err)
+		}
+		labels := obj.GetLabels()
+		if labels[iotv1alpha2.LabelPlatformAdminNamespace] != "default" || labels[iotv1alpha2.LabelPlatformAdminName] != "edgex" ||
+			labels[iotv1alpha2.LabelComponent] != testComponent {
+			t.Errorf("expect %T is labeled with the identity, but got %v", obj, labels)
+		}
+	}
+}
+
+func TestIsIdentityIndexed(t *testing.T) {
+	r := newTestReconciler(newTestConfiguration())
+	for _, list := range []client.ObjectList{&corev1.ServiceList{}, &corev1.ConfigMapList{}, &policyv1.PodDisruptionBudgetList{}, &networkingv1.NetworkPolicyList{}} {
+		if !r.isIdentityIndexed(list) {
+			t.Errorf("expect %T is indexed by the identity, but got not", list)
+		}
+	}
+	if r.isIdentityIndexed(&corev1.SecretList{}) {
+		t.Errorf("expect %T is not indexed by the identity, but got indexed", &corev1.SecretList{})
+	}
+}
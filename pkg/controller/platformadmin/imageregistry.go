@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+// applyImageRegistry replaces the registry of the images of components with PlatformAdmin.Spec.ImageRegistry, the
+// repository and the tag or digest are kept.
+func applyImageRegistry(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	registry := strings.TrimSuffix(platformAdmin.Spec.ImageRegistry, "/")
+	if registry == "" {
+		return components
+	}
+
+	for i, component := range components {
+		if component.Deployment == nil {
+			continue
+		}
+		overridden := copyDeployment(component)
+		podSpec := &overridden.Deployment.Template.Spec
+		for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+			for j := range containers {
+				containers[j].Image = replaceImageRegistry(containers[j].Image, registry)
+			}
+		}
+		components[i] = overridden
+	}
+	return components
+}
+
+// replaceImageRegistry replaces the registry of image, the first part of the name is taken as the registry like
+// docker does if it contains "." or ":" or is "localhost". The images without registry are pulled from the
+// registry as they are, e.g. "edgexfoundry/core-command:2.3.0" is pulled as "<registry>/edgexfoundry/core-command:2.3.0",
+// and the official images of Docker Hub are pulled from the library project as docker does, e.g. "eclipse-mosquitto:2.0.15"
+// is pulled as "<registry>/library/eclipse-mosquitto:2.0.15". The repositories of other registries are kept as they are.
+func replaceImageRegistry(image, registry string) string {
+	if image == "" {
+		return image
+	}
+	dockerHub := true
+	if i := strings.Index(image, "/"); i >= 0 {
+		if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			dockerHub = host == "docker.io" || host == "index.docker.io"
+			image = image[i+1:]
+		}
+	}
+	if dockerHub && !strings.Contains(image, "/") {
+		image = "library/" + image
+	}
+	return registry + "/" + image
+}
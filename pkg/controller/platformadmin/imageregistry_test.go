@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReplaceImageRegistry(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  string
+		expect string
+	}{
+		{name: "image without registry", image: "edgexfoundry/core-command:2.3.0", expect: "harbor.local/edgexfoundry/core-command:2.3.0"},
+		{name: "official image", image: "eclipse-mosquitto:2.0.15", expect: "harbor.local/library/eclipse-mosquitto:2.0.15"},
+		{name: "official image with registry", image: "docker.io/eclipse-mosquitto:2.0.15", expect: "harbor.local/library/eclipse-mosquitto:2.0.15"},
+		{name: "official image with digest", image: "redis@sha256:0123456789abcdef", expect: "harbor.local/library/redis@sha256:0123456789abcdef"},
+		{name: "official image with index registry", image: "index.docker.io/redis:7.0", expect: "harbor.local/library/redis:7.0"},
+		{name: "single-segment image with other registry", image: "quay.example.com/app:1", expect: "harbor.local/app:1"},
+		{name: "single-segment image with other registry and digest", image: "quay.example.com/app@sha256:0123456789abcdef", expect: "harbor.local/app@sha256:0123456789abcdef"},
+		{name: "single-segment image with localhost registry", image: "localhost:5000/app", expect: "harbor.local/app"},
+		{name: "image with registry", image: "docker.io/edgexfoundry/core-command:2.3.0", expect: "harbor.local/edgexfoundry/core-command:2.3.0"},
+		{name: "image with registry port", image: "registry:5000/edgexfoundry/core-command:2.3.0", expect: "harbor.local/edgexfoundry/core-command:2.3.0"},
+		{name: "image with localhost registry", image: "localhost/edgexfoundry/core-command", expect: "harbor.local/edgexfoundry/core-command"},
+		{name: "image with digest", image: "edgexfoundry/core-command@sha256:0123456789abcdef", expect: "harbor.local/edgexfoundry/core-command@sha256:0123456789abcdef"},
+		{name: "image with registry and digest", image: "docker.io/edgexfoundry/core-command@sha256:0123456789abcdef", expect: "harbor.local/edgexfoundry/core-command@sha256:0123456789abcdef"},
+		{name: "empty image", image: "", expect: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceImageRegistry(tt.image, "harbor.local"); got != tt.expect {
+				t.Errorf("expect image %s, but got %s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestImageRegistry(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.ImageRegistry = "harbor.local/edge/"
+	component := newTestComponent(testComponent, testImage)
+	r := newTestReconciler(newTestConfiguration(component), pa)
+
+	reconcilePlatformAdmin(t, r, pa)
+	checkImage := func(expect string) {
+		t.Helper()
+		yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+		if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != expect {
+			t.Errorf("expect image %s, but got %s", expect, image)
+		}
+	}
+	checkImage("harbor.local/edge/edgexfoundry/core-command:2.3.0")
+	if image := component.Deployment.Template.Spec.Containers[0].Image; image != testImage {
+		t.Errorf("expect the component of configuration is not changed, but got %s", image)
+	}
+
+	// the existing yurtappset is rolled with the images of the new registry
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.ImageRegistry = "registry.example.com:5000"
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	checkImage("registry.example.com:5000/edgexfoundry/core-command:2.3.0")
+
+	// the images of templates are restored without registry
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.ImageRegistry = ""
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	checkImage(testImage)
+}
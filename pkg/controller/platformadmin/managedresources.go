@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// maxManagedResources caps the managed resources listed in the status of PlatformAdmin,
+// so lots of additional components do not bloat the object.
+var maxManagedResources = 128
+
+func newResourceRef(obj client.Object) iotv1alpha2.ResourceRef {
+	return iotv1alpha2.ResourceRef{
+		Kind:      resourceKind(obj),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		UID:       obj.GetUID(),
+	}
+}
+
+func isSameResource(a, b iotv1alpha2.ResourceRef) bool {
+	return a.Kind == b.Kind && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// recordManagedResource adds the object to the managed resources, or refreshes its uid if it is recreated.
+func recordManagedResource(platformAdminStatus *iotv1alpha2.PlatformAdminStatus, obj client.Object) {
+	ref := newResourceRef(obj)
+	for i := range platformAdminStatus.ManagedResources {
+		if isSameResource(platformAdminStatus.ManagedResources[i], ref) {
+			platformAdminStatus.ManagedResources[i].UID = ref.UID
+			return
+		}
+	}
+	platformAdminStatus.ManagedResources = append(platformAdminStatus.ManagedResources, ref)
+}
+
+// forgetManagedResource removes the object from the managed resources after it is released by PlatformAdmin.
+func forgetManagedResource(platformAdminStatus *iotv1alpha2.PlatformAdminStatus, obj client.Object) {
+	ref := newResourceRef(obj)
+	var refs []iotv1alpha2.ResourceRef
+	for _, r := range platformAdminStatus.ManagedResources {
+		if !isSameResource(r, ref) {
+			refs = append(refs, r)
+		}
+	}
+	platformAdminStatus.ManagedResources = refs
+}
+
+// limitManagedResources sorts the managed resources, so the status is not updated just for a different order,
+// and caps them with the number of omitted ones. The omitted objects are recorded again by the next reconcile,
+// so they are listed once the others are released.
+func limitManagedResources(platformAdminStatus *iotv1alpha2.PlatformAdminStatus) {
+	refs := platformAdminStatus.ManagedResources
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	platformAdminStatus.OmittedManagedResources = 0
+	if maxManagedResources > 0 && len(refs) > maxManagedResources {
+		platformAdminStatus.OmittedManagedResources = int32(len(refs) - maxManagedResources)
+		platformAdminStatus.ManagedResources = refs[:maxManagedResources]
+	}
+}
+
+// managedYurtAppSets returns the names of yurtappsets recorded in the managed resources, which may not be
+// desired anymore, e.g. an additional component is removed while the pool fails to be removed from its workload.
+func managedYurtAppSets(platformAdmin *iotv1alpha2.PlatformAdmin) []string {
+	var names []string
+	for _, ref := range platformAdmin.Status.ManagedResources {
+		if ref.Kind == kindYurtAppSet && ref.Namespace == workloadNamespace(platformAdmin) {
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// newAdditionalComponentAnnotations returns the annotations of an additional component with a service.
+func newAdditionalComponentAnnotations(name string) map[string]string {
+	deployments, _ := json.Marshal([]iotv1alpha1.DeploymentTemplateSpec{{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       *newTestComponent(name, testImage).Deployment,
+	}})
+	services, _ := json.Marshal([]iotv1alpha1.ServiceTemplateSpec{{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 1001}}},
+	}})
+	return map[string]string{
+		iotv1alpha1.AnnotationAdditionalDeployments: string(deployments),
+		iotv1alpha1.AnnotationAdditionalServices:    string(services),
+	}
+}
+
+// managedResourceNames returns the managed resources as kind/name, the namespace is the same in the tests.
+func managedResourceNames(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin) []string {
+	t.Helper()
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	var names []string
+	for _, ref := range pa.Status.ManagedResources {
+		if ref.Namespace != pa.Namespace {
+			t.Errorf("expect namespace %s of %s/%s, but got %s", pa.Namespace, ref.Kind, ref.Name, ref.Namespace)
+		}
+		names = append(names, ref.Kind+"/"+ref.Name)
+	}
+	return names
+}
+
+func TestManagedResources(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	pa.Annotations = newAdditionalComponentAnnotations("device-virtual")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	// create: the objects of the annotation component are listed in order
+	reconcilePlatformAdmin(t, r, pa)
+	expect := []string{
+		"ConfigMap/common-variable-levski",
+		"Service/device-virtual",
+		"Service/" + testComponent,
+		"YurtAppSet/device-virtual",
+		"YurtAppSet/" + testComponent,
+	}
+	if names := managedResourceNames(t, r, pa); !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect managed resources %v, but got %v", expect, names)
+	}
+	if pa.Status.OmittedManagedResources != 0 {
+		t.Errorf("expect no omitted managed resources, but got %d", pa.Status.OmittedManagedResources)
+	}
+
+	// reconcile again: the list is stable, so the status is not updated
+	resourceVersion := pa.ResourceVersion
+	reconcilePlatformAdmin(t, r, pa)
+	if names := managedResourceNames(t, r, pa); !reflect.DeepEqual(names, expect) || pa.ResourceVersion != resourceVersion {
+		t.Errorf("expect managed resources %v at resource version %s, but got %v at %s", expect, resourceVersion, names, pa.ResourceVersion)
+	}
+
+	// remove: the released objects of the annotation component are pruned
+	pa.Annotations = nil
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	expect = []string{
+		"ConfigMap/common-variable-levski",
+		"Service/" + testComponent,
+		"YurtAppSet/" + testComponent,
+	}
+	if names := managedResourceNames(t, r, pa); !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect managed resources %v, but got %v", expect, names)
+	}
+}
+
+func TestManagedResourcesCap(t *testing.T) {
+	defer func(max int) { maxManagedResources = max }(maxManagedResources)
+	maxManagedResources = 2
+
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Annotations = newAdditionalComponentAnnotations("device-virtual")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	for i := 0; i < 2; i++ {
+		reconcilePlatformAdmin(t, r, pa)
+		expect := []string{"ConfigMap/common-variable-levski", "Service/device-virtual"}
+		if names := managedResourceNames(t, r, pa); !reflect.DeepEqual(names, expect) || pa.Status.OmittedManagedResources != 3 {
+			t.Errorf("expect managed resources %v with 3 omitted, but got %v with %d omitted", expect, names, pa.Status.OmittedManagedResources)
+		}
+	}
+}
+
+func TestDeleteReleasesManagedYurtAppSets(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	pa.Annotations = newAdditionalComponentAnnotations("device-virtual")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the annotation is dropped right before deletion, so the component is only known from the status
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Annotations = nil
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	for _, name := range []string{"device-virtual", testComponent} {
+		if pools := getYurtAppSet(t, r, pa.Namespace, name).Spec.Topology.Pools; len(pools) != 0 {
+			t.Errorf("expect the pool is removed from yurtappset %s, but got %v", name, pools)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+const (
+	// RedisComponentName is the redis of component templates, it is kept whatever the message bus is,
+	// because edgex also uses redis as database.
+	RedisComponentName = "edgex-redis"
+	// MQTTBrokerComponentName is the broker which is only deployed when mqtt message bus is selected.
+	MQTTBrokerComponentName = "edgex-mqtt-broker"
+
+	mqttBrokerImage = "eclipse-mosquitto:2.0.15"
+	mqttBrokerPort  = 1883
+	redisPort       = 6379
+)
+
+// messageBusComponents includes the mqtt broker into the components if mqtt message bus is selected,
+// and excludes it otherwise.
+func messageBusComponents(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	var result []*config.Component
+	var broker *config.Component
+	for _, component := range components {
+		if component.Name == MQTTBrokerComponentName {
+			broker = component
+			continue
+		}
+		result = append(result, component)
+	}
+
+	if platformAdmin.Spec.MessageBus != iotv1alpha2.PlatformAdminMessageBusMQTT {
+		return result
+	}
+	if broker == nil {
+		broker = newMQTTBrokerComponent()
+	}
+	return append(result, broker)
+}
+
+// messageBusVariables returns the variables injected into the common variable configmaps for the message bus.
+func messageBusVariables(messageBus string) map[string]string {
+	switch messageBus {
+	case iotv1alpha2.PlatformAdminMessageBusRedis:
+		return map[string]string{
+			"MESSAGEQUEUE_TYPE":     "redis",
+			"MESSAGEQUEUE_PROTOCOL": "redis",
+			"MESSAGEQUEUE_HOST":     RedisComponentName,
+			"MESSAGEQUEUE_PORT":     strconv.Itoa(redisPort),
+		}
+	case iotv1alpha2.PlatformAdminMessageBusMQTT:
+		return map[string]string{
+			"MESSAGEQUEUE_TYPE":     "mqtt",
+			"MESSAGEQUEUE_PROTOCOL": "tcp",
+			"MESSAGEQUEUE_HOST":     MQTTBrokerComponentName,
+			"MESSAGEQUEUE_PORT":     strconv.Itoa(mqttBrokerPort),
+		}
+	default:
+		return nil
+	}
+}
+
+// newMQTTBrokerComponent generates the mqtt broker, it is used when the component templates do not provide one.
+func newMQTTBrokerComponent() *config.Component {
+	labels := map[string]string{"app": MQTTBrokerComponentName}
+	return &config.Component{
+		Name: MQTTBrokerComponentName,
+		Service: &corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:     "tcp-1883",
+					Protocol: corev1.ProtocolTCP,
+					Port:     mqttBrokerPort,
+				},
+			},
+			Selector: labels,
+		},
+		Deployment: &appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            MQTTBrokerComponentName,
+							Image:           mqttBrokerImage,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Args:            []string{"/usr/sbin/mosquitto", "-c", "/mosquitto-no-auth.conf"},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "tcp-1883",
+									Protocol:      corev1.ProtocolTCP,
+									ContainerPort: mqttBrokerPort,
+								},
+							},
+						},
+					},
+					Hostname: MQTTBrokerComponentName,
+				},
+			},
+		},
+	}
+}
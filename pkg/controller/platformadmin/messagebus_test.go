@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func getMessageQueueType(t *testing.T, r *ReconcilePlatformAdmin, namespace string) string {
+	t.Helper()
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "common-variable-levski"}, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	return cm.Data["MESSAGEQUEUE_TYPE"]
+}
+
+func TestMessageBus(t *testing.T) {
+	tests := []struct {
+		messageBus   string
+		expectBroker bool
+	}{
+		{messageBus: iotv1alpha2.PlatformAdminMessageBusRedis, expectBroker: false},
+		{messageBus: iotv1alpha2.PlatformAdminMessageBusMQTT, expectBroker: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.messageBus, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.Spec.MessageBus = tt.messageBus
+			r := newTestReconciler(newTestConfiguration(newTestComponent(RedisComponentName, "redis:7.0.5-alpine")), pa)
+
+			reconcilePlatformAdmin(t, r, pa)
+			getYurtAppSet(t, r, pa.Namespace, RedisComponentName)
+			err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: MQTTBrokerComponentName}, &appsv1alpha1.YurtAppSet{})
+			if tt.expectBroker && err != nil {
+				t.Errorf("expect mqtt broker is created, but got %v", err)
+			}
+			if !tt.expectBroker && !apierrors.IsNotFound(err) {
+				t.Errorf("expect no mqtt broker, but got %v", err)
+			}
+			if got := getMessageQueueType(t, r, pa.Namespace); got != tt.messageBus {
+				t.Errorf("expect message queue type %s, but got %s", tt.messageBus, got)
+			}
+		})
+	}
+}
+
+func TestSwitchMessageBus(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.MessageBus = iotv1alpha2.PlatformAdminMessageBusMQTT
+	pa.UID = "uid-hangzhou"
+	other := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+	other.UID = "uid-beijing"
+	other.Spec.MessageBus = iotv1alpha2.PlatformAdminMessageBusMQTT
+	r := newTestReconciler(newTestConfiguration(newTestComponent(RedisComponentName, "redis:7.0.5-alpine")), pa, other)
+
+	reconcilePlatformAdmin(t, r, pa)
+	reconcilePlatformAdmin(t, r, other)
+	if pools := getYurtAppSet(t, r, pa.Namespace, MQTTBrokerComponentName).Spec.Topology.Pools; len(pools) != 2 {
+		t.Fatalf("expect mqtt broker is deployed into 2 pools, but got %v", pools)
+	}
+
+	// switch to redis, the pool of PlatformAdmin is removed from the mqtt broker
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.MessageBus = iotv1alpha2.PlatformAdminMessageBusRedis
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	pools := getYurtAppSet(t, r, pa.Namespace, MQTTBrokerComponentName).Spec.Topology.Pools
+	if len(pools) != 1 || pools[0].Name != "beijing" {
+		t.Errorf("expect mqtt broker is only deployed into pool beijing, but got %v", pools)
+	}
+	if got := getMessageQueueType(t, r, pa.Namespace); got != iotv1alpha2.PlatformAdminMessageBusRedis {
+		t.Errorf("expect message queue type redis, but got %s", got)
+	}
+
+	// switch the other one, the mqtt broker is deleted since nobody needs it
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(other), other); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	other.Spec.MessageBus = iotv1alpha2.PlatformAdminMessageBusRedis
+	if err := r.Update(context.TODO(), other); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, other)
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: MQTTBrokerComponentName}, &appsv1alpha1.YurtAppSet{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect mqtt broker is deleted, but got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	metricsSubsystem = "platformadmin"
+
+	reconcileResultSuccess = "success"
+	reconcileResultRequeue = "requeue"
+	reconcileResultError   = "error"
+
+	operationCreate = "create"
+	operationUpdate = "update"
+	operationPatch  = "patch"
+	operationDelete = "delete"
+
+	kindYurtAppSet = "YurtAppSet"
+	kindService    = "Service"
+	kindConfigMap  = "ConfigMap"
+	kindSecret     = "Secret"
+
+	kindPodDisruptionBudget = "PodDisruptionBudget"
+	kindNetworkPolicy       = "NetworkPolicy"
+	kindYurtAppDaemon       = "YurtAppDaemon"
+
+	kindPersistentVolumeClaim   = "PersistentVolumeClaim"
+	kindHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
+	kindServiceMonitor          = "ServiceMonitor"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "reconcile_duration_seconds",
+			Help:      "duration of PlatformAdmin reconciles, labeled by result(success, requeue or error)",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"result"})
+	readyComponents = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "ready_components",
+			Help:      "number of ready components of a PlatformAdmin",
+		},
+		[]string{"namespace", "name"})
+	unreadyComponents = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "unready_components",
+			Help:      "number of unready components of a PlatformAdmin",
+		},
+		[]string{"namespace", "name"})
+	resourceOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "resource_operations_total",
+			Help:      "counter of create, update, patch and delete operations performed by PlatformAdmin controller",
+		},
+		[]string{"kind", "operation"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, readyComponents, unreadyComponents, resourceOperations)
+}
+
+// reconcileResult classifies the result of a reconcile for metrics.
+func reconcileResult(result reconcile.Result, err error) string {
+	if err != nil {
+		return reconcileResultError
+	}
+	if result.Requeue || result.RequeueAfter > 0 {
+		return reconcileResultRequeue
+	}
+	return reconcileResultSuccess
+}
+
+// setComponentMetrics records the component numbers which are written to the PlatformAdmin status.
+func setComponentMetrics(namespace, name string, ready, unready int32) {
+	readyComponents.WithLabelValues(namespace, name).Set(float64(ready))
+	unreadyComponents.WithLabelValues(namespace, name).Set(float64(unready))
+}
+
+// deleteComponentMetrics removes the series of a deleted PlatformAdmin.
+func deleteComponentMetrics(namespace, name string) {
+	readyComponents.DeleteLabelValues(namespace, name)
+	unreadyComponents.DeleteLabelValues(namespace, name)
+}
+
+func recordOperation(kind, operation string) {
+	resourceOperations.WithLabelValues(kind, operation).Inc()
+}
+
+// recordOperationResult records the operation performed by controllerutil.CreateOrUpdate.
+func recordOperationResult(kind string, result controllerutil.OperationResult) {
+	switch result {
+	case controllerutil.OperationResultCreated:
+		recordOperation(kind, operationCreate)
+	case controllerutil.OperationResultUpdated:
+		recordOperation(kind, operationUpdate)
+	}
+}
+
+// resourceKind returns the kind label of objects managed by PlatformAdmin controller.
+func resourceKind(obj client.Object) string {
+	switch obj.(type) {
+	case *appsv1alpha1.YurtAppSet:
+		return kindYurtAppSet
+	case *appsv1alpha1.YurtAppDaemon:
+		return kindYurtAppDaemon
+	case *corev1.Service:
+		return kindService
+	case *corev1.ConfigMap:
+		return kindConfigMap
+	case *corev1.Secret:
+		return kindSecret
+	case *policyv1.PodDisruptionBudget:
+		return kindPodDisruptionBudget
+	case *networkingv1.NetworkPolicy:
+		return kindNetworkPolicy
+	case *corev1.PersistentVolumeClaim:
+		return kindPersistentVolumeClaim
+	case *autoscalingv2beta2.HorizontalPodAutoscaler:
+		return kindHorizontalPodAutoscaler
+	default:
+		return obj.GetObjectKind().GroupVersionKind().Kind
+	}
+}
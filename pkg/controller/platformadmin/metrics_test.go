@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func reconcileCount(t *testing.T, result string) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := reconcileDuration.WithLabelValues(result).(prometheus.Histogram).Write(m); err != nil {
+		t.Fatalf("failed to read reconcile duration metric, %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func operationCount(kind, operation string) float64 {
+	return testutil.ToFloat64(resourceOperations.WithLabelValues(kind, operation))
+}
+
+func TestReconcileMetrics(t *testing.T) {
+	pa := newTestPlatformAdmin("metrics", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa,
+		newTestNode("node1", "hangzhou"), newTestEndpoints("metrics", testComponent, "node1"))
+
+	requeueCount := reconcileCount(t, reconcileResultRequeue)
+	successCount := reconcileCount(t, reconcileResultSuccess)
+	yasCreated := operationCount(kindYurtAppSet, operationCreate)
+	svcCreated := operationCount(kindService, operationCreate)
+	cmCreated := operationCount(kindConfigMap, operationCreate)
+	yasPatched := operationCount(kindYurtAppSet, operationPatch)
+
+	// the component is provisioned but not ready yet
+	reconcilePlatformAdmin(t, r, pa)
+	if v := testutil.ToFloat64(readyComponents.WithLabelValues(pa.Namespace, pa.Name)); v != 0 {
+		t.Errorf("expect 0 ready components, but got %v", v)
+	}
+	if v := testutil.ToFloat64(unreadyComponents.WithLabelValues(pa.Namespace, pa.Name)); v != 1 {
+		t.Errorf("expect 1 unready components, but got %v", v)
+	}
+	if v := reconcileCount(t, reconcileResultRequeue) - requeueCount; v != 1 {
+		t.Errorf("expect 1 requeue reconcile, but got %v", v)
+	}
+	if v := operationCount(kindYurtAppSet, operationCreate) - yasCreated; v != 1 {
+		t.Errorf("expect 1 YurtAppSet created, but got %v", v)
+	}
+	if v := operationCount(kindService, operationCreate) - svcCreated; v != 1 {
+		t.Errorf("expect 1 Service created, but got %v", v)
+	}
+	if v := operationCount(kindConfigMap, operationCreate) - cmCreated; v != 1 {
+		t.Errorf("expect 1 ConfigMap created, but got %v", v)
+	}
+
+	// the component becomes ready
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.Replicas = 1
+	yas.Status.PoolReadyReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.ReadyReplicas = 1
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet status, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if v := testutil.ToFloat64(readyComponents.WithLabelValues(pa.Namespace, pa.Name)); v != 1 {
+		t.Errorf("expect 1 ready components, but got %v", v)
+	}
+	if v := testutil.ToFloat64(unreadyComponents.WithLabelValues(pa.Namespace, pa.Name)); v != 0 {
+		t.Errorf("expect 0 unready components, but got %v", v)
+	}
+	if v := reconcileCount(t, reconcileResultSuccess) - successCount; v != 1 {
+		t.Errorf("expect 1 successful reconcile, but got %v", v)
+	}
+
+	// the series are cleared after the PlatformAdmin is deleted
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if v := operationCount(kindYurtAppSet, operationPatch) - yasPatched; v != 1 {
+		t.Errorf("expect 1 YurtAppSet patched, but got %v", v)
+	}
+	if readyComponents.DeleteLabelValues(pa.Namespace, pa.Name) {
+		t.Errorf("expect ready components series of %s/%s is cleared", pa.Namespace, pa.Name)
+	}
+	if unreadyComponents.DeleteLabelValues(pa.Namespace, pa.Name) {
+		t.Errorf("expect unready components series of %s/%s is cleared", pa.Namespace, pa.Name)
+	}
+}
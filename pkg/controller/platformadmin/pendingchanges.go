@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// desiredComponentObjects returns the workload pod specs and services which would be generated for the components.
+func desiredComponentObjects(components []*config.Component) []util.ComponentObjects {
+	objects := make([]util.ComponentObjects, 0, len(components))
+	for _, component := range components {
+		obj := util.ComponentObjects{Name: component.Name}
+		if component.Deployment != nil {
+			obj.PodSpec = &newDeploymentTemplate(component).Spec.Template.Spec
+		}
+		if component.Service != nil {
+			obj.Service = &corev1.ServiceSpec{Ports: desiredServicePorts(nil, component.Service.Ports)}
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// recordPendingChanges summarizes the differences between the live components and configmaps of PlatformAdmin and
+// the desired ones into its status, so users can tell what a version upgrade or a spec change is rolling out. The
+// previous summary is kept once the live objects are updated, until the PlatformAdmin is ready. The components whose
+// yurtappsets are unmanaged are left out, since they are never updated by the controller, and so are the configmaps
+// managed by users.
+func (r *ReconcilePlatformAdmin) recordPendingChanges(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration) error {
+	additional, err := r.additionalComponentsConfigMap(ctx, platformAdmin)
+	if err != nil {
+		return err
+	}
+	allComponents, err := assembleComponents(platformAdmin, conf, additional)
+	if err != nil {
+		return err
+	}
+	var desiredConfigMapList []corev1.ConfigMap
+	if !platformAdmin.Spec.SkipConfigmapManagement {
+		if desiredConfigMapList, err = desiredConfigMaps(platformAdmin, conf); err != nil {
+			return err
+		}
+	}
+
+	var yurtAppSets []appsv1alpha1.YurtAppSet
+	unmanaged := make(map[string]struct{})
+	if !r.yurtAppSetMissing {
+		if err := r.forEachOwned(ctx, platformAdmin, &appsv1alpha1.YurtAppSetList{}, LabelDeployment, func(obj client.Object) error {
+			yas := obj.(*appsv1alpha1.YurtAppSet)
+			if isUnmanaged(yas) {
+				unmanaged[yas.Name] = struct{}{}
+				return nil
+			}
+			yurtAppSets = append(yurtAppSets, *yas)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	var yurtAppDaemons []appsv1alpha1.YurtAppDaemon
+	if err := r.forEachOwned(ctx, platformAdmin, &appsv1alpha1.YurtAppDaemonList{}, LabelYurtAppDaemon, func(obj client.Object) error {
+		yurtAppDaemons = append(yurtAppDaemons, *obj.(*appsv1alpha1.YurtAppDaemon))
+		return nil
+	}); err != nil {
+		return err
+	}
+	var services []corev1.Service
+	if err := r.forEachOwned(ctx, platformAdmin, &corev1.ServiceList{}, LabelService, func(obj client.Object) error {
+		if _, ok := unmanaged[obj.GetName()]; !ok {
+			services = append(services, *obj.(*corev1.Service))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var liveConfigMaps []corev1.ConfigMap
+	if !platformAdmin.Spec.SkipConfigmapManagement {
+		if err := r.forEachOwned(ctx, platformAdmin, &corev1.ConfigMapList{}, LabelConfigmap, func(obj client.Object) error {
+			liveConfigMaps = append(liveConfigMaps, *obj.(*corev1.ConfigMap))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	var desiredComponents []*config.Component
+	for _, component := range filterDisabledComponents(platformAdmin, allComponents) {
+		if _, ok := unmanaged[component.Name]; !ok {
+			desiredComponents = append(desiredComponents, component)
+		}
+	}
+	diff := util.ComputeDiff(util.LiveComponentObjects(yurtAppSets, yurtAppDaemons, services), desiredComponentObjects(desiredComponents),
+		liveConfigMaps, desiredConfigMapList)
+	if diff.IsEmpty() {
+		return nil
+	}
+	changes, omitted := diff.Summarize(util.MaxPendingChanges, util.MaxPendingChangeLength)
+	platformAdminStatus.PendingChanges = changes
+	platformAdminStatus.OmittedPendingChanges = int32(omitted)
+	return nil
+}
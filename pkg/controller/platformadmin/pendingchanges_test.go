@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestPendingChanges(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa,
+		newTestNode("node-1", "hangzhou"), newTestEndpoints(pa.Namespace, testComponent, "node-1"))
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the objects generated by the controller are the same as the desired ones, whatever the server populates
+	status := &iotv1alpha2.PlatformAdminStatus{}
+	if err := r.recordPendingChanges(context.TODO(), pa, status, r.getConfiguration()); err != nil {
+		t.Fatalf("failed to record pending changes, %v", err)
+	}
+	if len(status.PendingChanges) != 0 {
+		t.Errorf("expect no pending changes of the generated objects, but got %q", status.PendingChanges)
+	}
+
+	// the image of component changes, and the change is reported until the PlatformAdmin is ready
+	newImage := "edgexfoundry/core-command:3.0.0"
+	r.configuration.Store(newTestConfiguration(newTestComponent(testComponent, newImage)))
+	expect := []string{fmt.Sprintf("component %s: image of %s %s -> %s", testComponent, testComponent, testImage, newImage)}
+	for i := 0; i < 2; i++ {
+		reconcilePlatformAdmin(t, r, pa)
+		latest := &iotv1alpha2.PlatformAdmin{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		if latest.Status.Ready || !reflect.DeepEqual(latest.Status.PendingChanges, expect) {
+			t.Errorf("expect pending changes %q before ready, but got %q", expect, latest.Status.PendingChanges)
+		}
+	}
+
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+	yas.Status.ObservedGeneration = yas.Generation
+	setPoolReadyReplicas(t, r, yas, "hangzhou", 1)
+	reconcilePlatformAdmin(t, r, pa)
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if !latest.Status.Ready || len(latest.Status.PendingChanges) != 0 || latest.Status.OmittedPendingChanges != 0 {
+		t.Errorf("expect pending changes are cleared once ready, but got ready %v and changes %q", latest.Status.Ready, latest.Status.PendingChanges)
+	}
+}
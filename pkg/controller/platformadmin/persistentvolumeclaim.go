@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// hasClaim checks whether the component needs a persistent volume claim in every pool, the daemon components
+// run on every node of the pool and can not share a claim.
+func hasClaim(component *config.Component) bool {
+	return component.PersistentVolumeClaim != nil && component.Deployment != nil && !isDaemonComponent(component)
+}
+
+// claimName returns the name of the persistent volume claim of component in the pool.
+func claimName(component *config.Component, poolName string) string {
+	return fmt.Sprintf("%s-%s", component.Name, poolName)
+}
+
+// handlePersistentVolumeClaim creates the persistent volume claim of component in the pool of PlatformAdmin.
+// The spec of a bound claim is mostly immutable, so the existing claim is left as it is and never recreated,
+// which would lose the data of the component.
+// It is possible for pvc to be nil when there is no error!
+func (r *ReconcilePlatformAdmin) handlePersistentVolumeClaim(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, poolName string) (*corev1.PersistentVolumeClaim, error) {
+	if !hasClaim(component) {
+		return nil, nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	key := types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: claimName(component, poolName)}
+	err := r.Get(ctx, key, pvc)
+	if err == nil {
+		return pvc, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	template := component.PersistentVolumeClaim
+	accessModes := template.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	pvc = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminGenerate: LabelPersistentVolumeClaim,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: template.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: template.Size},
+			},
+		},
+	}
+	propagateMetadata(platformAdmin, pvc)
+	if err := r.setOwner(platformAdmin, pvc); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, pvc); err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).Info("Create PersistentVolumeClaim", "component", component.Name, "pvc", pvc.Name)
+	recordOperation(kindPersistentVolumeClaim, operationCreate)
+	return pvc, nil
+}
+
+// injectClaimVolume adds the volume of the claim into the pod template, the volume with the same name is replaced.
+// The claim name of template is only a placeholder, every pool points it to its own claim by the pool patch.
+func injectClaimVolume(podSpec *corev1.PodSpec, component *config.Component) {
+	template := component.PersistentVolumeClaim
+	volume := corev1.Volume{
+		Name: template.Name,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: component.Name},
+		},
+	}
+	replaced := false
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == volume.Name {
+			podSpec.Volumes[i] = volume
+			replaced = true
+		}
+	}
+	if !replaced {
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+
+	if template.MountPath == "" || len(podSpec.Containers) == 0 {
+		return
+	}
+	container := &podSpec.Containers[0]
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == volume.Name {
+			return
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: volume.Name, MountPath: template.MountPath})
+}
+
+// newClaimPatch generates the strategic merge patch of pool, which points the volume of the claim to the one
+// of the pool. The volumes are merged by name.
+func newClaimPatch(component *config.Component, poolName string) *runtime.RawExtension {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumes": []interface{}{
+						map[string]interface{}{
+							"name": component.PersistentVolumeClaim.Name,
+							"persistentVolumeClaim": map[string]interface{}{
+								"claimName": claimName(component, poolName),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	raw, _ := json.Marshal(patch)
+	return &runtime.RawExtension{Raw: raw}
+}
+
+// isPatchEqual compares the pool patches by their content, since the apiserver may format them differently.
+func isPatchEqual(a, b *runtime.RawExtension) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	var objA, objB interface{}
+	if err := json.Unmarshal(a.Raw, &objA); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b.Raw, &objB); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(objA, objB)
+}
+
+// componentTemplateHash returns the hash of the deployment template of component. The hash of the component
+// without claim is kept as it was, so the existing yurtappsets are not updated for nothing.
+func componentTemplateHash(component *config.Component) string {
+	if !hasClaim(component) {
+		return util.ComputeTemplateHash(component.Deployment)
+	}
+	return util.ComputeTemplateHash(&newDeploymentTemplate(component).Spec)
+}
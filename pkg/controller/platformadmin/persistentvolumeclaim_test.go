@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+	"github.com/openyurtio/openyurt/pkg/controller/yurtappset/adapter"
+)
+
+// newTestStatefulComponent returns a component with a claim, which replaces the emptyDir placeholder of deployment.
+func newTestStatefulComponent(name string) *config.Component {
+	component := newTestComponent(name, testImage)
+	component.Deployment.Template.Spec.Volumes = []corev1.Volume{
+		{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	storageClassName := "local-path"
+	component.PersistentVolumeClaim = &config.PersistentVolumeClaimTemplate{
+		Name:             "data",
+		StorageClassName: &storageClassName,
+		Size:             resource.MustParse("1Gi"),
+		MountPath:        "/data",
+	}
+	return component
+}
+
+func getPersistentVolumeClaim(t *testing.T, r *ReconcilePlatformAdmin, namespace, name string) *corev1.PersistentVolumeClaim {
+	t.Helper()
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, pvc); err != nil {
+		t.Fatalf("failed to get persistentvolumeclaim %s, %v", name, err)
+	}
+	return pvc
+}
+
+func TestPersistentVolumeClaim(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestStatefulComponent(testComponent)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the claim is created for the pool
+	name := testComponent + "-hangzhou"
+	pvc := getPersistentVolumeClaim(t, r, pa.Namespace, name)
+	if pvc.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelPersistentVolumeClaim {
+		t.Errorf("expect persistentvolumeclaim is labeled, but got %v", pvc.Labels)
+	}
+	if !isOwnedBy(pvc, pa) {
+		t.Errorf("expect persistentvolumeclaim is owned by PlatformAdmin, but got %v", pvc.OwnerReferences)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "1Gi" {
+		t.Errorf("expect size 1Gi, but got %s", size.String())
+	}
+	if expect := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}; !reflect.DeepEqual(pvc.Spec.AccessModes, expect) {
+		t.Errorf("expect access modes %v, but got %v", expect, pvc.Spec.AccessModes)
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "local-path" {
+		t.Errorf("expect storage class local-path, but got %v", pvc.Spec.StorageClassName)
+	}
+
+	// the placeholder volume is replaced and mounted, and the pool points it to the claim of the pool
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	podSpec := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].EmptyDir != nil || podSpec.Volumes[0].PersistentVolumeClaim == nil {
+		t.Errorf("expect placeholder volume is replaced by the claim, but got %v", podSpec.Volumes)
+	}
+	if expect := []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}; !reflect.DeepEqual(podSpec.Containers[0].VolumeMounts, expect) {
+		t.Errorf("expect volume mounts %v, but got %v", expect, podSpec.Containers[0].VolumeMounts)
+	}
+	if len(yas.Spec.Topology.Pools) != 1 || yas.Spec.Topology.Pools[0].Patch == nil {
+		t.Fatalf("expect pool is patched, but got %v", yas.Spec.Topology.Pools)
+	}
+	deployment := &appsv1.Deployment{Spec: yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec}
+	patched := &appsv1.Deployment{}
+	if err := adapter.CreateNewPatchedObject(yas.Spec.Topology.Pools[0].Patch, deployment, patched); err != nil {
+		t.Fatalf("failed to apply the pool patch, %v", err)
+	}
+	volumes := patched.Spec.Template.Spec.Volumes
+	if len(volumes) != 1 || volumes[0].PersistentVolumeClaim == nil || volumes[0].PersistentVolumeClaim.ClaimName != name {
+		t.Errorf("expect the volume of pool uses claim %s, but got %v", name, volumes)
+	}
+
+	// the existing claim is not recreated or updated
+	reconcilePlatformAdmin(t, r, pa)
+	if latest := getPersistentVolumeClaim(t, r, pa.Namespace, name); latest.ResourceVersion != pvc.ResourceVersion {
+		t.Errorf("expect persistentvolumeclaim is untouched, but resource version changed from %s to %s", pvc.ResourceVersion, latest.ResourceVersion)
+	}
+	if latest := getYurtAppSet(t, r, pa.Namespace, testComponent); latest.ResourceVersion != yas.ResourceVersion {
+		t.Errorf("expect yurtappset is untouched, but resource version changed from %s to %s", yas.ResourceVersion, latest.ResourceVersion)
+	}
+}
+
+func TestPersistentVolumeClaimOnDelete(t *testing.T) {
+	for _, policy := range []string{iotv1alpha2.DeletionPolicyDelete, iotv1alpha2.DeletionPolicyOrphan} {
+		t.Run(policy, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			pa.Spec.DeletionPolicy = policy
+			r := newTestReconciler(newTestConfiguration(newTestStatefulComponent(testComponent)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if err := r.Delete(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to delete PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			pvc := getPersistentVolumeClaim(t, r, pa.Namespace, testComponent+"-hangzhou")
+			if policy == iotv1alpha2.DeletionPolicyDelete {
+				// the claim is left to the garbage collector
+				if !isOwnedBy(pvc, pa) {
+					t.Errorf("expect persistentvolumeclaim is owned by PlatformAdmin, but got %v", pvc.OwnerReferences)
+				}
+				return
+			}
+			if _, ok := pvc.Labels[iotv1alpha2.LabelPlatformAdminGenerate]; ok || len(pvc.OwnerReferences) != 0 {
+				t.Errorf("expect persistentvolumeclaim is orphaned, but got labels %v and owners %v", pvc.Labels, pvc.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestIsPatchEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		a      *runtime.RawExtension
+		b      *runtime.RawExtension
+		expect bool
+	}{
+		{
+			name:   "both are nil",
+			expect: true,
+		},
+		{
+			name: "one is nil",
+			a:    &runtime.RawExtension{Raw: []byte(`{}`)},
+		},
+		{
+			name:   "formatted differently",
+			a:      &runtime.RawExtension{Raw: []byte(`{"a":1,"b":{"c":"d"}}`)},
+			b:      &runtime.RawExtension{Raw: []byte(`{ "b": {"c": "d"}, "a": 1 }`)},
+			expect: true,
+		},
+		{
+			name: "different content",
+			a:    &runtime.RawExtension{Raw: []byte(`{"a":1}`)},
+			b:    &runtime.RawExtension{Raw: []byte(`{"a":2}`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPatchEqual(tt.a, tt.b); got != tt.expect {
+				t.Errorf("expect %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestComponentTemplateHash(t *testing.T) {
+	// the hash of the component without claim is not changed
+	plain := newTestComponent(testComponent, testImage)
+	if hash, expect := componentTemplateHash(plain), util.ComputeTemplateHash(plain.Deployment); hash != expect {
+		t.Errorf("expect hash %s, but got %s", expect, hash)
+	}
+
+	// the change of claim updates the template
+	stateful := newTestStatefulComponent(testComponent)
+	hash := componentTemplateHash(stateful)
+	stateful.PersistentVolumeClaim.MountPath = "/var/lib/data"
+	if componentTemplateHash(stateful) == hash {
+		t.Errorf("expect hash is changed with the mount path, but got the same %s", hash)
+	}
+}
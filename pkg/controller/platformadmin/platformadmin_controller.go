@@ -18,26 +18,45 @@ package platformadmin
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -48,31 +67,73 @@ import (
 	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
 	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
 	utilclient "github.com/openyurtio/openyurt/pkg/util/client"
-	utildiscovery "github.com/openyurtio/openyurt/pkg/util/discovery"
 )
 
 func init() {
 	flag.IntVar(&concurrentReconciles, "platformadmin-workers", concurrentReconciles, "Max concurrent workers for PlatformAdmin controller.")
+	flag.IntVar(&componentWorkers, "platformadmin-component-workers", componentWorkers, "Max concurrent workers reconciling the components within one reconcile of PlatformAdmin.")
+	flag.StringVar(&platformAdminNamespaces, "platformadmin-namespace", platformAdminNamespaces, "Comma separated namespaces which PlatformAdmin controller is restricted to, all namespaces are watched if empty.")
+	flag.DurationVar(&rateLimiterBaseDelay, "platformadmin-rate-limiter-base-delay", rateLimiterBaseDelay, "Base delay of the exponential backoff when a PlatformAdmin fails to reconcile.")
+	flag.DurationVar(&rateLimiterMaxDelay, "platformadmin-rate-limiter-max-delay", rateLimiterMaxDelay, "Max delay of the exponential backoff when a PlatformAdmin fails to reconcile.")
+	flag.Float64Var(&clientQPS, "platformadmin-client-qps", clientQPS, "QPS of the client of PlatformAdmin controller to the apiserver, the QPS of yurt-manager is used if it is not positive.")
+	flag.IntVar(&clientBurst, "platformadmin-client-burst", clientBurst, "Burst of the client of PlatformAdmin controller to the apiserver, the burst of yurt-manager is used if it is not positive.")
+	flag.BoolVar(&labelScopedCache, "platformadmin-label-scoped-cache", labelScopedCache, "Watch only the configmaps and services generated by PlatformAdmins instead of all of them in the cluster. The edits of the configmap of additional components are picked up on the next reconcile of PlatformAdmin, since it is not watched in this mode.")
+	flag.DurationVar(&crdPollInterval, "platformadmin-crd-poll-interval", crdPollInterval, "Interval of checking whether the CRD of PlatformAdmin is installed, if it is not installed when yurt-manager starts.")
+	flag.IntVar(&config.MaxAdditionalComponents, "platformadmin-max-additional-components", config.MaxAdditionalComponents, "Max number of additional components carried by the annotations of a PlatformAdmin, no cap if it is not positive.")
 }
 
 var (
-	concurrentReconciles = 3
-	controllerKind       = iotv1alpha2.SchemeGroupVersion.WithKind("PlatformAdmin")
+	concurrentReconciles    = 3
+	componentWorkers        = 4
+	rateLimiterBaseDelay    = 5 * time.Millisecond
+	rateLimiterMaxDelay     = 1000 * time.Second
+	clientQPS               = 0.0
+	clientBurst             = 0
+	rejectedRequeueAfter    = time.Minute
+	platformAdminNamespaces = ""
+	labelScopedCache        = false
+	crdPollInterval         = 30 * time.Second
+	controllerKind          = iotv1alpha2.SchemeGroupVersion.WithKind("PlatformAdmin")
+	yurtAppSetKind          = appsv1alpha1.SchemeGroupVersion.WithKind("YurtAppSet")
+	// dependencyMissingRequeueAfter is the interval to probe again whether the missing CRDs are installed
+	dependencyMissingRequeueAfter = 5 * time.Minute
+	// poolConflictRequeueAfter is the interval to check again whether the pool claimed by others is released
+	poolConflictRequeueAfter = 30 * time.Second
+	// deniedRequeueAfter is the interval to retry the requests denied by RBAC, a resource quota or a terminating namespace
+	deniedRequeueAfter = 5 * time.Minute
 )
 
 const (
 	ControllerName = "PlatformAdmin"
 
-	LabelConfigmap  = "Configmap"
-	LabelService    = "Service"
-	LabelDeployment = "Deployment"
+	LabelConfigmap = "Configmap"
+	LabelService   = "Service"
+	LabelSecret    = "Secret"
+
+	LabelDeployment          = "Deployment"
+	LabelPodDisruptionBudget = "PodDisruptionBudget"
+	LabelNetworkPolicy       = "NetworkPolicy"
+	LabelYurtAppDaemon       = "YurtAppDaemon"
+
+	LabelPersistentVolumeClaim   = "PersistentVolumeClaim"
+	LabelHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
 
 	AnnotationServiceTopologyKey           = "openyurt.io/topologyKeys"
 	AnnotationServiceTopologyValueNodePool = "openyurt.io/nodepool"
+	AnnotationServiceTopologyValueZone     = "kubernetes.io/zone"
 
 	ConfigMapName = "common-variables"
+
+	// eventReasonDriftRepaired is the reason of event when the fields of workloads edited by others are restored
+	eventReasonDriftRepaired = "DriftRepaired"
+	// eventReasonCleanup is the reason of event when an object is deleted or orphaned by the deletion policy
+	eventReasonCleanup = "Cleanup"
+	// eventReasonUnmanaged is the reason of event when the workload of a component is found unmanaged
+	eventReasonUnmanaged = "Unmanaged"
 )
 
+// Format prefixes the message with the name of controller. The logs of controller are structured and do not use it,
+// it is kept for the messages which are not logged, e.g. the messages of events.
 func Format(format string, args ...interface{}) string {
 	s := fmt.Sprintf(format, args...)
 	return fmt.Sprintf("%s: %s", ControllerName, s)
@@ -81,81 +142,358 @@ func Format(format string, args ...interface{}) string {
 // ReconcilePlatformAdmin reconciles a PlatformAdmin object
 type ReconcilePlatformAdmin struct {
 	client.Client
-	scheme       *runtime.Scheme
-	recorder     record.EventRecorder
-	Configration config.PlatformAdminControllerConfiguration
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	// configuration holds a *config.PlatformAdminControllerConfiguration. It is never mutated in place but
+	// swapped as a whole when the framework configmap changes, so every reconcile sees a consistent snapshot.
+	configuration      atomic.Value
+	frameworkNamespace string
+	// namespaces restricts the scope of controller, all namespaces are watched if it is empty
+	namespaces sets.String
+	// yurtAppSetMissing means the CRD of YurtAppSet is not installed when the controller is added,
+	// so YurtAppSets are not watched
+	yurtAppSetMissing bool
+	// yurtAppSetIndexed means the YurtAppSets are indexed by the identity of PlatformAdmin, the index can not be
+	// registered if the CRD is installed after the manager is started
+	yurtAppSetIndexed bool
+	// serviceMonitorAvailable means the CRD of ServiceMonitor is installed when the controller is added,
+	// otherwise the metrics of components are only annotated to their services
+	serviceMonitorAvailable bool
+	// generatedCache and frameworkCache are the label-scoped caches of configmaps and services, they are nil
+	// unless the label-scoped cache is enabled, and the informers of manager are used instead
+	generatedCache cache.Cache
+	frameworkCache cache.Cache
+	// platformAdminCache is the cache of PlatformAdmins if the controller is added after the manager is started,
+	// and the informer of manager is used otherwise
+	platformAdminCache cache.Cache
+	// summary keeps the readiness of PlatformAdmins served at SummaryPath
+	summary *summaryRegistry
 }
 
 var _ reconcile.Reconciler = &ReconcilePlatformAdmin{}
 
 // Add creates a new PlatformAdmin Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
+// If the CRD of PlatformAdmin is not installed yet, e.g. it is applied together with yurt-manager, the controller
+// is added by a waiter once the CRD is installed.
 func Add(c *appconfig.CompletedConfig, mgr manager.Manager) error {
-	if !utildiscovery.DiscoverGVK(controllerKind) {
-		return nil
+	r := newReconciler(c, mgr)
+	reconciler := r.(*ReconcilePlatformAdmin)
+	if labelScopedCache {
+		klog.InfoS("Watch only the configmaps and services generated by PlatformAdmins", "controller", ControllerName)
+		if err := reconciler.setupLabelScopedCaches(mgr); err != nil {
+			return err
+		}
+	}
+	// The handlers of metrics server are fixed once the manager is started, so the summary is served even if the
+	// controller is added later
+	if err := mgr.AddMetricsExtraHandler(SummaryPath, reconciler.summary); err != nil {
+		return err
+	}
+
+	if !discoverGVK(controllerKind) {
+		klog.InfoS("PlatformAdmin is not installed, the controller is added once it is installed", "controller", ControllerName,
+			"kind", controllerKind.String(), "interval", crdPollInterval)
+		// The indexes of configmaps and services can only be registered before the manager is started
+		if err := reconciler.registerGeneratedFieldIndexers(mgr); err != nil {
+			klog.ErrorS(err, "Failed to register the field indexers of generated objects", "controller", ControllerName)
+			return err
+		}
+		return mgr.Add(&crdWaiter{
+			gvk:      controllerKind,
+			interval: crdPollInterval,
+			discover: discoverGVK,
+			add: func(ctx context.Context) error {
+				return reconciler.addWhenInstalled(ctx, mgr)
+			},
+		})
 	}
 
-	klog.Infof("platformadmin-controller add controller %s", controllerKind.String())
-	return add(mgr, newReconciler(c, mgr))
+	klog.InfoS("Add controller", "controller", ControllerName, "kind", controllerKind.String())
+	reconciler.discoverDependencies()
+	return add(mgr, r)
+}
+
+// discoverDependencies checks whether the CRDs of YurtAppSet and ServiceMonitor are installed. The controller is
+// still added without YurtAppSet, so the PlatformAdmins report the missing dependency instead of failing silently,
+// and they are reconciled once the CRD is applied.
+func (r *ReconcilePlatformAdmin) discoverDependencies() {
+	if !discoverGVK(yurtAppSetKind) {
+		klog.InfoS("YurtAppSet is not installed, PlatformAdmins can not be reconciled until it is installed", "controller", ControllerName, "kind", yurtAppSetKind.String())
+		r.yurtAppSetMissing = true
+	}
+	if discoverGVK(serviceMonitorKind) {
+		r.serviceMonitorAvailable = true
+	} else {
+		klog.InfoS("ServiceMonitor is not installed, the metrics of components are only annotated to services", "controller", ControllerName, "kind", serviceMonitorKind.String())
+	}
 }
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(c *appconfig.CompletedConfig, mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcilePlatformAdmin{
-		Client:       utilclient.NewClientFromManager(mgr, ControllerName),
-		scheme:       mgr.GetScheme(),
-		recorder:     mgr.GetEventRecorderFor(ControllerName),
-		Configration: c.ComponentConfig.PlatformAdminController,
+	// The requests of the controller are instrumented, so its load on the apiserver can be told from other controllers
+	cli := utilclient.NewClientFromManagerWithOptions(mgr, ControllerName,
+		utilclient.WithRateLimit(float32(clientQPS), clientBurst), utilclient.WithInstrumentation())
+	r := &ReconcilePlatformAdmin{
+		Client:             cli,
+		scheme:             mgr.GetScheme(),
+		recorder:           mgr.GetEventRecorderFor(ControllerName),
+		frameworkNamespace: c.ComponentConfig.Generic.WorkingNamespace,
+		summary:            newSummaryRegistry(),
+	}
+	conf := c.ComponentConfig.PlatformAdminController
+	if len(platformAdminNamespaces) != 0 {
+		conf.Namespaces = strings.Split(platformAdminNamespaces, ",")
+	}
+	r.namespaces = sets.NewString(conf.Namespaces...)
+	r.configuration.Store(&conf)
+	return r
+}
+
+// inScope checks whether the namespace is watched by the controller.
+func (r *ReconcilePlatformAdmin) inScope(namespace string) bool {
+	return r.namespaces.Len() == 0 || r.namespaces.Has(namespace)
+}
+
+// scopePredicate drops the events of objects which are out of the namespaces of controller. The objects generated
+// into another namespace are in scope as long as their PlatformAdmin is.
+func (r *ReconcilePlatformAdmin) scopePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		if request, ok := platformAdminFromLabel(obj.GetLabels()); ok && r.inScope(request.Namespace) {
+			return true
+		}
+		return r.inScope(obj.GetNamespace())
+	})
+}
+
+// getConfiguration returns the snapshot of component templates currently in use.
+func (r *ReconcilePlatformAdmin) getConfiguration() *config.PlatformAdminControllerConfiguration {
+	return r.configuration.Load().(*config.PlatformAdminControllerConfiguration)
+}
+
+// reloadConfiguration swaps in the component templates of framework configmap,
+// a broken configuration is refused and the last known good one is kept.
+func (r *ReconcilePlatformAdmin) reloadConfiguration(cm *corev1.ConfigMap) error {
+	conf, err := config.LoadPlatformAdminControllerConfiguration(cm)
+	if err != nil {
+		return err
+	}
+	// The scope of controller is not part of the framework configmap
+	conf.Namespaces = r.getConfiguration().Namespaces
+	r.configuration.Store(conf)
+	return nil
+}
+
+// mapFrameworkToPlatformAdmins reloads the component templates when the framework configmap changes,
+// and enqueues all PlatformAdmins so that the new templates are rendered on their next reconcile.
+func (r *ReconcilePlatformAdmin) mapFrameworkToPlatformAdmins(obj client.Object) []reconcile.Request {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm.Namespace != r.frameworkNamespace || cm.Name != config.FrameworkConfigMapName {
+		return nil
+	}
+
+	if err := r.reloadConfiguration(cm); err != nil {
+		klog.ErrorS(err, "Refuse to load configmap, keep the last known good configuration", "controller", ControllerName, "configmap", klog.KObj(cm))
+		return nil
+	}
+	klog.InfoS("Configuration is reloaded", "controller", ControllerName, "configmap", klog.KObj(cm))
+	if err := r.publishSupportedVersions(context.TODO()); err != nil {
+		klog.ErrorS(err, "Failed to publish supported versions", "controller", ControllerName)
+	}
+
+	platformAdmins := &iotv1alpha2.PlatformAdminList{}
+	if err := r.List(context.TODO(), platformAdmins); err != nil {
+		klog.ErrorS(err, "List PlatformAdmins error", "controller", ControllerName)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, platformAdmin := range platformAdmins.Items {
+		if !r.inScope(platformAdmin.Namespace) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdmin.Name},
+		})
+	}
+	return requests
+}
+
+// mapGeneratedToPlatformAdmins enqueues the PlatformAdmins in the same namespace of the objects generated by
+// PlatformAdmin controller. The generate label is used instead of owner references, because the owner reference
+// is removed when the object is not needed by a PlatformAdmin, but the controller still needs to converge it.
+// The objects generated into another namespace enqueue the PlatformAdmin recorded by their label, and the other
+// PlatformAdmins generating objects into that namespace, since a shared object(e.g. a yurtappset deleted by others)
+// carries the label of one PlatformAdmin only.
+func (r *ReconcilePlatformAdmin) mapGeneratedToPlatformAdmins(obj client.Object) []reconcile.Request {
+	if _, ok := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]; !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	enqueued := make(map[types.NamespacedName]struct{})
+	enqueue := func(request types.NamespacedName) {
+		if _, ok := enqueued[request]; ok {
+			return
+		}
+		enqueued[request] = struct{}{}
+		requests = append(requests, reconcile.Request{NamespacedName: request})
+	}
+	if request, ok := platformAdminFromLabel(obj.GetLabels()); ok {
+		enqueue(request)
+	}
+	platformAdmins := &iotv1alpha2.PlatformAdminList{}
+	if err := r.List(context.TODO(), platformAdmins, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.ErrorS(err, "List PlatformAdmins error", "controller", ControllerName, "namespace", obj.GetNamespace())
+		return requests
+	}
+	for _, platformAdmin := range platformAdmins.Items {
+		enqueue(types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdmin.Name})
+	}
+	workloadPlatformAdmins, err := r.workloadPlatformAdmins(context.TODO(), obj.GetNamespace())
+	if err != nil {
+		klog.ErrorS(err, "List PlatformAdmins by workload namespace error", "controller", ControllerName, "namespace", obj.GetNamespace())
+		return requests
+	}
+	for _, platformAdmin := range workloadPlatformAdmins {
+		enqueue(types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdmin.Name})
+	}
+	return requests
+}
+
+// newControllerOptions returns the options of controller from the flags. The rate limiter is the same as
+// the default one of controller-runtime, except that the backoff of failed items is configurable.
+func newControllerOptions(r reconcile.Reconciler) controller.Options {
+	return controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: concurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(rateLimiterBaseDelay, rateLimiterMaxDelay),
+			// 10 qps, 100 bucket size. This is only for retry speed and its only the overall factor (not per item)
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+		),
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// The field indexers are registered first, so the manager fails fast before any watch is set up
+	klog.V(4).InfoS("Register the field indexers", "controller", ControllerName)
+	if err := util.RegisterFieldIndexers(mgr.GetFieldIndexer()); err != nil {
+		klog.ErrorS(err, "Failed to register the field indexers", "controller", ControllerName)
+		return err
+	}
+	reconciler := r.(*ReconcilePlatformAdmin)
+	if err := reconciler.registerGeneratedFieldIndexers(mgr); err != nil {
+		klog.ErrorS(err, "Failed to register the field indexers of generated objects", "controller", ControllerName)
+		return err
+	}
+	if !reconciler.yurtAppSetMissing {
+		if err := reconciler.registerYurtAppSetFieldIndexer(mgr); err != nil {
+			klog.ErrorS(err, "Failed to register the field indexer of YurtAppSets", "controller", ControllerName)
+			return err
+		}
+	}
+	return addController(mgr, reconciler)
+}
+
+// addController creates the controller and sets up its watches, the field indexers must be registered before.
+func addController(mgr manager.Manager, reconciler *ReconcilePlatformAdmin) error {
 	// Create a new controller
-	c, err := controller.New(ControllerName, mgr, controller.Options{
-		Reconciler: r, MaxConcurrentReconciles: concurrentReconciles,
-	})
+	c, err := controller.New(ControllerName, mgr, newControllerOptions(reconciler))
 	if err != nil {
 		return err
 	}
 
+	scope := reconciler.scopePredicate()
+
 	// Watch for changes to PlatformAdmin
-	err = c.Watch(&source.Kind{Type: &iotv1alpha2.PlatformAdmin{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(reconciler.platformAdminSource(), &handler.EnqueueRequestForObject{}, scope)
 	if err != nil {
 		return err
 	}
 
-	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForOwner{
-		IsController: false,
-		OwnerType:    &iotv1alpha2.PlatformAdmin{},
-	})
+	generated, content := generatedPredicate(), contentUpdatePredicate()
+	err = c.Watch(reconciler.generatedSource(&corev1.ConfigMap{}), handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), generated, content, scope)
 	if err != nil {
 		return err
 	}
 
-	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{
-		IsController: false,
-		OwnerType:    &iotv1alpha2.PlatformAdmin{},
-	})
+	err = c.Watch(reconciler.frameworkSource(), handler.EnqueueRequestsFromMapFunc(reconciler.mapFrameworkToPlatformAdmins), content)
 	if err != nil {
 		return err
 	}
 
-	err = c.Watch(&source.Kind{Type: &appsv1alpha1.YurtAppSet{}}, &handler.EnqueueRequestForOwner{
-		IsController: false,
-		OwnerType:    &iotv1alpha2.PlatformAdmin{},
-	})
+	// The configmaps of additional components are not generated, so they are not watched by the label-scoped cache
+	if reconciler.generatedCache == nil {
+		err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapAdditionalComponentsToPlatformAdmins), content, scope)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Watch(reconciler.generatedSource(&corev1.Service{}), handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), generated, content, scope)
 	if err != nil {
 		return err
 	}
 
-	klog.V(4).Info("registering the field indexers of platformadmin controller")
-	if err := util.RegisterFieldIndexers(mgr.GetFieldIndexer()); err != nil {
-		klog.Errorf("failed to register field indexers for platformadmin controller, %v", err)
+	// Watching a kind which is not installed fails the start of manager, the status flaps during rollouts are dropped
+	if !reconciler.yurtAppSetMissing {
+		err = c.Watch(&source.Kind{Type: &appsv1alpha1.YurtAppSet{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), yurtAppSetUpdatePredicate(), scope)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Watch(&source.Kind{Type: &appsv1alpha1.YurtAppDaemon{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &policyv1.PodDisruptionBudget{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &networkingv1.NetworkPolicy{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+	if err != nil {
+		return err
+	}
+
+	// The desired replicas of autoscalers are followed by the pools of yurtappsets
+	err = c.Watch(&source.Kind{Type: &autoscalingv2beta2.HorizontalPodAutoscaler{}}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+	if err != nil {
+		return err
+	}
+
+	if reconciler.serviceMonitorAvailable {
+		err = c.Watch(&source.Kind{Type: newServiceMonitorObject()}, handler.EnqueueRequestsFromMapFunc(reconciler.mapGeneratedToPlatformAdmins), scope)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The supported versions are published on startup, and republished whenever the configuration is reloaded
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := reconciler.publishSupportedVersions(ctx); err != nil {
+			klog.ErrorS(err, "Failed to publish supported versions", "controller", ControllerName)
+		}
 		return nil
+	})); err != nil {
+		return err
 	}
 
-	return nil
+	// The summary is rebuilt whenever the controller is started, e.g. on the new leader after a failover
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := reconciler.rebuildSummary(ctx); err != nil {
+			klog.ErrorS(err, "Failed to rebuild the summary of PlatformAdmins", "controller", ControllerName)
+		}
+		return nil
+	}))
 }
 
 // +kubebuilder:rbac:groups=iot.openyurt.io,resources=platformadmins,verbs=get;list;watch;create;update;patch;delete
@@ -163,21 +501,50 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // +kubebuilder:rbac:groups=iot.openyurt.io,resources=platformadmins/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappsets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=yurtappdaemons,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch;patch
 // +kubebuilder:rbac:groups=core,resources=configmaps;services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps/status;services/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=endpoints;nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+
+// loggerFor derives the logger of reconciling the PlatformAdmin from the context. The logger of controller-runtime
+// in the context already carries the name and namespace of request, the PlatformAdmin is added as one key
+// so the logs can be filtered by it.
+func loggerFor(ctx context.Context, request types.NamespacedName) logr.Logger {
+	return log.FromContext(ctx).WithValues("controller", ControllerName, "platformadmin", request.String())
+}
 
 // Reconcile reads that state of the cluster for a PlatformAdmin object and makes changes based on the state read
 // and what is in the PlatformAdmin.Spec
-func (r *ReconcilePlatformAdmin) Reconcile(ctx context.Context, request reconcile.Request) (_ reconcile.Result, reterr error) {
-	klog.Infof(Format("Reconcile PlatformAdmin %s/%s", request.Namespace, request.Name))
+func (r *ReconcilePlatformAdmin) Reconcile(ctx context.Context, request reconcile.Request) (result reconcile.Result, reterr error) {
+	if !r.inScope(request.Namespace) {
+		return reconcile.Result{}, nil
+	}
+	// The logger is passed down through the context, so every log of this reconcile can be filtered by PlatformAdmin
+	logger := loggerFor(ctx, request.NamespacedName)
+	ctx = log.IntoContext(ctx, logger)
+	logger.Info("Reconcile PlatformAdmin")
+	startTime := time.Now()
+	defer func() {
+		reconcileDuration.WithLabelValues(reconcileResult(result, reterr)).Observe(time.Since(startTime).Seconds())
+	}()
 
 	// Fetch the PlatformAdmin instance
 	platformAdmin := &iotv1alpha2.PlatformAdmin{}
 	if err := r.Get(ctx, request.NamespacedName, platformAdmin); err != nil {
 		if apierrors.IsNotFound(err) {
+			deleteComponentMetrics(request.Namespace, request.Name)
+			r.summary.remove(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
-		klog.Errorf(Format("Get PlatformAdmin %s/%s error %v", request.Namespace, request.Name, err))
+		logger.Error(err, "Get PlatformAdmin error")
 		return reconcile.Result{}, err
 	}
 
@@ -188,85 +555,256 @@ func (r *ReconcilePlatformAdmin) Reconcile(ctx context.Context, request reconcil
 	// resource are patched back to the API server.
 	defer func(isDeleted *bool) {
 		if !*isDeleted {
-			platformAdmin.Status = *platformAdminStatus
+			util.SetPlatformAdminReadyCondition(platformAdminStatus)
+			setComponentMetrics(platformAdmin.Namespace, platformAdmin.Name, platformAdminStatus.ReadyComponentNum, platformAdminStatus.UnreadyComponentNum)
+			r.summary.set(request.NamespacedName, platformAdminStatus)
 
-			if err := r.Status().Update(ctx, platformAdmin); err != nil {
-				klog.Errorf(Format("Update the status of PlatformAdmin %s/%s failed", platformAdmin.Namespace, platformAdmin.Name))
-				reterr = kerrors.NewAggregate([]error{reterr, err})
+			// The status is only written when it is changed, the periodic requeues would flood the apiserver otherwise
+			if equality.Semantic.DeepEqual(platformAdmin.Status, *platformAdminStatus) {
+				logger.V(4).Info("The status of PlatformAdmin is not changed, skip the update")
+			} else {
+				platformAdmin.Status = *platformAdminStatus
+				if err := r.Status().Update(ctx, platformAdmin); err != nil {
+					logger.Error(err, "Update the status of PlatformAdmin failed")
+					reterr = kerrors.NewAggregate([]error{reterr, err})
+				}
 			}
 
 			if reterr != nil {
-				klog.ErrorS(reterr, Format("Reconcile PlatformAdmin %s/%s failed", platformAdmin.Namespace, platformAdmin.Name))
+				logger.Error(reterr, "Reconcile PlatformAdmin failed")
 			}
 		}
 	}(&isDeleted)
 
+	// Take a snapshot of configuration, so the whole reconcile works on the same component templates
+	conf := r.getConfiguration()
+
 	if platformAdmin.DeletionTimestamp != nil {
 		isDeleted = true
-		return r.reconcileDelete(ctx, platformAdmin)
+		return r.reconcileDelete(ctx, platformAdmin, conf)
+	}
+
+	if isPaused(platformAdmin) {
+		return r.reconcilePaused(ctx, platformAdmin, platformAdminStatus)
 	}
+	util.RemovePlatformAdminCondition(platformAdminStatus, iotv1alpha2.PausedCondition)
 
-	return r.reconcileNormal(ctx, platformAdmin, platformAdminStatus)
+	if platformAdmin.Annotations[iotv1alpha2.AnnotationDryRun] == "true" {
+		return r.reconcileDryRun(ctx, platformAdmin, platformAdminStatus, conf)
+	}
+
+	return r.reconcileNormal(ctx, platformAdmin, platformAdminStatus, conf)
 }
 
-func (r *ReconcilePlatformAdmin) reconcileDelete(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin) (reconcile.Result, error) {
-	klog.V(4).Infof(Format("ReconcileDelete PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
-	yas := &appsv1alpha1.YurtAppSet{}
-	var desiredComponents []*config.Component
-	if platformAdmin.Spec.Security {
-		desiredComponents = r.Configration.SecurityComponents[platformAdmin.Spec.Version]
-	} else {
-		desiredComponents = r.Configration.NoSectyComponents[platformAdmin.Spec.Version]
+func (r *ReconcilePlatformAdmin) reconcileDelete(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(4).Info("ReconcileDelete PlatformAdmin")
+	// The deletion is not blocked by a broken configmap, the objects of its components are released by status and owner references
+	additional, err := r.additionalComponentsConfigMap(ctx, platformAdmin)
+	if err != nil {
+		logger.Info("Ignore the additional components configmap", "error", err.Error())
+	}
+	components, err := desiredComponents(platformAdmin, conf, additional)
+	if err != nil && additional != nil {
+		logger.Info("Ignore the additional components configmap", "error", err.Error())
+		components, err = desiredComponents(platformAdmin, conf, nil)
+	}
+	if err != nil {
+		logger.Error(err, "Assemble components error")
+		return reconcile.Result{}, err
 	}
 
-	additionalComponents, err := annotationToComponent(platformAdmin.Annotations)
+	// The pool is shared with the PlatformAdmin claiming it before, so it is not released from the workloads
+	managed := managedYurtAppSets(platformAdmin)
+	claimedBy, err := r.poolClaimedBy(ctx, platformAdmin)
 	if err != nil {
-		klog.Errorf(Format("annotationToComponent error %v", err))
+		logger.Error(err, "Check the claim of pool error")
 		return reconcile.Result{}, err
 	}
-	desiredComponents = append(desiredComponents, additionalComponents...)
+	if claimedBy != nil {
+		logger.Info("Pool is claimed by another PlatformAdmin, skip releasing it", "pools", joinedPools(platformAdmin), "claimedBy", klog.KObj(claimedBy))
+		managed, components = nil, nil
+	}
 
-	//TODO: handle PlatformAdmin.Spec.Components
+	// The pool is drained before it is removed, so the components can shut down gracefully
+	requeueAfter, err := r.drainPools(ctx, platformAdmin, drainedYurtAppSets(managed, components))
+	if err != nil {
+		logger.Error(err, "Drain pool error", "pools", joinedPools(platformAdmin))
+		return reconcile.Result{}, err
+	}
+	if requeueAfter > 0 {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
 
-	for _, dc := range desiredComponents {
-		if err := r.Get(
-			ctx,
-			types.NamespacedName{Namespace: platformAdmin.Namespace, Name: dc.Name},
-			yas); err != nil {
-			klog.V(4).ErrorS(err, Format("Get YurtAppSet %s/%s error", platformAdmin.Namespace, dc.Name))
-			continue
+	// The pools dropped from the spec but not released yet are released along with the current ones
+	if !isOrphan(platformAdmin) {
+		if err := r.releaseDroppedPools(ctx, platformAdmin, platformAdmin.Status.DeepCopy()); err != nil {
+			logger.Error(err, "Release dropped pools error")
+			return reconcile.Result{}, err
 		}
+	}
 
-		oldYas := yas.DeepCopy()
+	// The yurtappsets recorded in status are released too, in case their components are not desired anymore
+	released := make(map[string]struct{})
+	for _, name := range managed {
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: name}, yas); err != nil {
+			if !isWorkloadGone(err) {
+				logger.Error(err, "Get YurtAppSet error", "yurtappset", name)
+				return reconcile.Result{}, err
+			}
+			logger.V(4).Info("YurtAppSet is already gone", "yurtappset", name, "error", err.Error())
+		} else if err := client.IgnoreNotFound(r.releasePool(ctx, platformAdmin, yas)); err != nil {
+			logger.Error(err, "Remove pool from YurtAppSet error", "yurtappset", name, "pools", joinedPools(platformAdmin))
+			return reconcile.Result{}, err
+		}
+		released[name] = struct{}{}
+	}
 
-		for i, pool := range yas.Spec.Topology.Pools {
-			if pool.Name == platformAdmin.Spec.PoolName {
-				yas.Spec.Topology.Pools[i] = yas.Spec.Topology.Pools[len(yas.Spec.Topology.Pools)-1]
-				yas.Spec.Topology.Pools = yas.Spec.Topology.Pools[:len(yas.Spec.Topology.Pools)-1]
+	for _, dc := range components {
+		// Both kinds of workload are checked, since the workload type of component may be changed before deletion
+		yas := &appsv1alpha1.YurtAppSet{}
+		if _, ok := released[dc.Name]; ok {
+			logger.V(4).Info("YurtAppSet is already released", "component", dc.Name, "yurtappset", dc.Name)
+		} else if err := r.Get(
+			ctx,
+			types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: dc.Name},
+			yas); err != nil {
+			if !isWorkloadGone(err) {
+				logger.Error(err, "Get YurtAppSet error", "component", dc.Name, "yurtappset", dc.Name)
+				return reconcile.Result{}, err
 			}
+			logger.V(4).Info("YurtAppSet is already gone", "component", dc.Name, "yurtappset", dc.Name, "error", err.Error())
+		} else if err := client.IgnoreNotFound(r.releasePool(ctx, platformAdmin, yas)); err != nil {
+			logger.Error(err, "Remove pool from YurtAppSet error", "component", dc.Name, "yurtappset", dc.Name, "pools", joinedPools(platformAdmin))
+			return reconcile.Result{}, err
 		}
-		if err := r.Client.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
-			klog.V(4).ErrorS(err, Format("Patch YurtAppSet %s/%s error", platformAdmin.Namespace, dc.Name))
+
+		yad := &appsv1alpha1.YurtAppDaemon{}
+		if err := r.Get(
+			ctx,
+			types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: dc.Name},
+			yad); err != nil {
+			if !isWorkloadGone(err) {
+				logger.Error(err, "Get YurtAppDaemon error", "component", dc.Name, "yurtappdaemon", dc.Name)
+				return reconcile.Result{}, err
+			}
+			logger.V(4).Info("YurtAppDaemon is already gone", "component", dc.Name, "yurtappdaemon", dc.Name, "error", err.Error())
+		} else if err := client.IgnoreNotFound(r.releaseDaemonPool(ctx, platformAdmin, yad)); err != nil {
+			logger.Error(err, "Remove pool from YurtAppDaemon error", "component", dc.Name, "yurtappdaemon", dc.Name, "pools", joinedPools(platformAdmin))
 			return reconcile.Result{}, err
 		}
 	}
 
+	if err := r.cleanupSecrets(ctx, platformAdmin); err != nil {
+		logger.Error(err, "Cleanup secrets error")
+		return reconcile.Result{}, err
+	}
+
+	// The finalizer is kept until the services and configmaps are released, so they are not orphaned
+	if err := r.cleanupServicesAndConfigmaps(ctx, platformAdmin, conf); err != nil {
+		logger.Error(err, "Cleanup services and configmaps error")
+		return reconcile.Result{}, err
+	}
+	// The objects not garbage collected in another namespace, or not to be garbage collected by the Orphan policy,
+	// are released before the finalizer is removed
+	if err := r.cleanupWorkloads(ctx, platformAdmin); err != nil {
+		logger.Error(err, "Cleanup workloads error", "namespace", workloadNamespace(platformAdmin), "deletionPolicy", deletionPolicy(platformAdmin))
+		return reconcile.Result{}, err
+	}
+
 	controllerutil.RemoveFinalizer(platformAdmin, iotv1alpha2.PlatformAdminFinalizer)
 	if err := r.Client.Update(ctx, platformAdmin); err != nil {
-		klog.Errorf(Format("Update PlatformAdmin %s error %v", klog.KObj(platformAdmin), err))
+		logger.Error(err, "Remove the finalizer of PlatformAdmin error")
 		return reconcile.Result{}, err
 	}
+	deleteComponentMetrics(platformAdmin.Namespace, platformAdmin.Name)
 
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) (reconcile.Result, error) {
-	klog.V(4).Infof(Format("ReconcileNormal PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
-	controllerutil.AddFinalizer(platformAdmin, iotv1alpha2.PlatformAdminFinalizer)
+// cleanupServicesAndConfigmaps removes the PlatformAdmin from the owners of the generated services and configmaps
+// in its namespace, the objects are deleted when it is the last owner. They may be owned by several PlatformAdmins,
+// so the deletion is not left to the garbage collector. The configmaps still desired by other PlatformAdmins are
+// never deleted, see releaseConfigMap.
+func (r *ReconcilePlatformAdmin) cleanupServicesAndConfigmaps(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration) error {
+	var errs []error
+	if err := r.releaseUnneeded(ctx, platformAdmin, &corev1.ServiceList{}, LabelService, nil, func(obj client.Object) error {
+		return r.removeOwner(ctx, platformAdmin, obj)
+	}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &corev1.ConfigMapList{}, LabelConfigmap, nil, func(obj client.Object) error {
+		return r.releaseConfigMap(ctx, platformAdmin, obj.(*corev1.ConfigMap), conf)
+	}); err != nil {
+		errs = append(errs, err)
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration) (reconcile.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(4).Info("ReconcileNormal PlatformAdmin")
+	// The webhook can be bypassed, so the pool claimed by another PlatformAdmin is checked again before anything is managed
+	claimedBy, err := r.poolClaimedBy(ctx, platformAdmin)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if claimedBy != nil {
+		message := fmt.Sprintf("pool %s in namespace %s is claimed by PlatformAdmin %s/%s, the components are not managed until it is resolved",
+			strings.Join(claimedPools(platformAdmin, claimedBy), ","), workloadNamespace(platformAdmin), claimedBy.Namespace, claimedBy.Name)
+		if util.GetPlatformAdminCondition(*platformAdminStatus, iotv1alpha2.PoolConflictCondition) == nil {
+			logger.Info("Pool is claimed by another PlatformAdmin", "pools", joinedPools(platformAdmin), "claimedBy", klog.KObj(claimedBy))
+			r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.PoolConflictReason, message)
+		}
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.PoolConflictCondition, corev1.ConditionTrue, iotv1alpha2.PoolConflictReason, message))
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.PoolConflictReason, message))
+		platformAdminStatus.Ready = false
+		return reconcile.Result{RequeueAfter: poolConflictRequeueAfter}, nil
+	}
+	util.RemovePlatformAdminCondition(platformAdminStatus, iotv1alpha2.PoolConflictCondition)
+
+	// The finalizer is persisted before anything is created, otherwise a PlatformAdmin deleted before its first
+	// successful reconcile is removed without releasing the pool and the generated objects
+	if !controllerutil.ContainsFinalizer(platformAdmin, iotv1alpha2.PlatformAdminFinalizer) {
+		oldPlatformAdmin := platformAdmin.DeepCopy()
+		controllerutil.AddFinalizer(platformAdmin, iotv1alpha2.PlatformAdminFinalizer)
+		if err := r.Patch(ctx, platformAdmin, client.MergeFromWithOptions(oldPlatformAdmin, client.MergeFromWithOptimisticLock{})); err != nil {
+			logger.Error(err, "Add finalizer to PlatformAdmin error")
+			return reconcile.Result{}, err
+		}
+	}
+	platformAdminStatus.PreviewComponents = nil
+	defer limitManagedResources(platformAdminStatus)
+
+	platformAdminStatus.Initialized = true
+	// The components of a PlatformAdmin never reconciled are deployed with the security mode of spec directly
+	if platformAdminStatus.CurrentSecurity == nil {
+		security := platformAdmin.Spec.Security
+		platformAdminStatus.CurrentSecurity = &security
+	}
+	// The pending changes are only informative, so the failure to compute them never blocks the reconcile
+	if err := r.recordPendingChanges(ctx, platformAdmin, platformAdminStatus, conf); err != nil {
+		logger.V(4).Info("Failed to compute the pending changes", "error", err.Error())
+	}
 
-	platformAdmin.Status.Initialized = true
-	klog.V(4).Infof(Format("ReconcileConfigmap PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
-	if ok, err := r.reconcileConfigmap(ctx, platformAdmin, platformAdminStatus); !ok {
+	logger.V(4).Info("ReconcileConfigmap PlatformAdmin")
+	if platformAdmin.Spec.SkipConfigmapManagement {
+		// The configmaps are provided by the users, the ones generated before are left as is
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, iotv1alpha2.ExternallyManagedReason,
+			"the configmaps are managed by users"))
+	} else if ok, err := r.reconcileConfigmap(ctx, platformAdmin, platformAdminStatus, conf); !ok {
+		if invalid := (*configmapTemplateError)(nil); errors.As(err, &invalid) {
+			// Retrying can not fix the template, the PlatformAdmin is reconciled again once the configuration is updated
+			logger.Info("Configmap template is invalid", "error", invalid.Error())
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ConfigmapProvisioningFailedReason, invalid.Error()))
+			r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.ConfigmapProvisioningFailedReason, invalid.Error())
+			return reconcile.Result{}, nil
+		}
+		if denied := util.DeniedErrors(err); len(denied) > 0 {
+			r.reportDenied(ctx, platformAdmin, platformAdminStatus, iotv1alpha2.ConfigmapAvailableCondition, denied)
+			return reconcile.Result{RequeueAfter: deniedRequeueAfter}, nil
+		}
 		if err != nil {
 			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ConfigmapProvisioningFailedReason, err.Error()))
 			return reconcile.Result{}, errors.Wrapf(err,
@@ -274,316 +812,2168 @@ func (r *ReconcilePlatformAdmin) reconcileNormal(ctx context.Context, platformAd
 		}
 		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ConfigmapProvisioningReason, ""))
 		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	} else {
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, "", ""))
+	}
+
+	logger.V(4).Info("ReconcileSecret PlatformAdmin")
+	if ok, err := r.reconcileSecret(ctx, platformAdmin, platformAdminStatus); !ok {
+		if err != nil {
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecretAvailableCondition, corev1.ConditionFalse, iotv1alpha2.SecretProvisioningFailedReason, err.Error()))
+			return reconcile.Result{}, errors.Wrapf(err,
+				"unexpected error while reconciling secret for %s", platformAdmin.Namespace+"/"+platformAdmin.Name)
+		}
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecretAvailableCondition, corev1.ConditionFalse, iotv1alpha2.SecretProvisioningReason, ""))
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
 	}
-	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, "", ""))
+	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecretAvailableCondition, corev1.ConditionTrue, "", ""))
 
-	klog.V(4).Infof(Format("ReconcileComponent PlatformAdmin %s/%s", platformAdmin.Namespace, platformAdmin.Name))
-	if ok, err := r.reconcileComponent(ctx, platformAdmin, platformAdminStatus); !ok {
+	logger.V(4).Info("ReconcileComponent PlatformAdmin")
+	if ok, err := r.reconcileComponent(ctx, platformAdmin, platformAdminStatus, conf); !ok {
 		if err != nil {
+			if isDependencyMissing(err) {
+				// The client refreshes its RESTMapper on the next request, so the CRD is probed again after requeue
+				message := fmt.Sprintf("%s %s is not installed, %v", yurtAppSetKind.GroupVersion().String(), yurtAppSetKind.Kind, err)
+				logger.Info("Dependency of PlatformAdmin is missing", "kind", yurtAppSetKind.String(), "error", err.Error())
+				util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.DependencyMissingReason, message))
+				return reconcile.Result{RequeueAfter: dependencyMissingRequeueAfter}, nil
+			}
+			if errors.Is(err, config.ErrTooManyAdditionalComponents) {
+				// Retrying can not fix the annotations, the PlatformAdmin is reconciled again once it is updated
+				logger.Info("Too many additional components", "error", err.Error())
+				util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentProvisioningFailedReason, err.Error()))
+				r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.ComponentProvisioningFailedReason, err.Error())
+				return reconcile.Result{}, nil
+			}
+			if invalid := (*additionalComponentsError)(nil); errors.As(err, &invalid) {
+				// Retrying can not fix the configmap, the PlatformAdmin is reconciled again once the configmap is updated
+				logger.Info("Additional components are invalid", "error", invalid.Error())
+				util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.AdditionalComponentsInvalidReason, invalid.Error()))
+				r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.AdditionalComponentsInvalidReason, invalid.Error())
+				return reconcile.Result{}, nil
+			}
+			if errors.Is(err, config.ErrInvalidComponent) || errors.Is(err, config.ErrInvalidComponentSet) {
+				// Retrying can not fix the invalid or colliding components, the PlatformAdmin is reconciled again once it is updated
+				logger.Info("Components are invalid", "error", err.Error())
+				util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentSpecInvalidReason, err.Error()))
+				r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.ComponentSpecInvalidReason, err.Error())
+				return reconcile.Result{}, nil
+			}
+			if denied := util.DeniedErrors(err); len(denied) > 0 {
+				r.reportDenied(ctx, platformAdmin, platformAdminStatus, iotv1alpha2.ComponentAvailableCondition, denied)
+				return reconcile.Result{RequeueAfter: deniedRequeueAfter}, nil
+			}
+			if rejected := rejectedError(err); rejected != nil {
+				// Retrying can not fix the rejected component, it is reported to users instead of backing off with errors
+				logger.Info("Component is rejected", "error", rejected.Error())
+				util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentRejectedReason, rejected.Error()))
+				r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.ComponentRejectedReason, rejected.Error())
+				return reconcile.Result{RequeueAfter: rejectedRequeueAfter}, nil
+			}
+			// The failure retried with backoff is reported once, until it is resolved or turns into another one
+			if previous := util.GetPlatformAdminCondition(platformAdmin.Status, iotv1alpha2.ComponentAvailableCondition); previous == nil ||
+				previous.Reason != iotv1alpha2.ComponentProvisioningReason || previous.Message != err.Error() {
+				r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, iotv1alpha2.ComponentProvisioningFailedReason, err.Error())
+			}
 			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentProvisioningReason, err.Error()))
 			return reconcile.Result{}, errors.Wrapf(err,
 				"unexpected error while reconciling component for %s", platformAdmin.Namespace+"/"+platformAdmin.Name)
 		}
-		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentProvisioningReason, ""))
+		// The condition with the reason of unready components is set by reconcileComponent
 		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionTrue, "", ""))
 
+	if !platformAdmin.Status.Ready {
+		r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeNormal, iotv1alpha2.ReadyReason, "All the components of PlatformAdmin are ready")
+	}
 	platformAdminStatus.Ready = true
-	if err := r.Client.Update(ctx, platformAdmin); err != nil {
-		klog.Errorf(Format("Update PlatformAdmin %s error %v", klog.KObj(platformAdmin), err))
-		return reconcile.Result{}, err
+	platformAdminStatus.CurrentVersion = platformAdmin.Spec.Version
+	platformAdminStatus.PendingChanges = nil
+	platformAdminStatus.OmittedPendingChanges = 0
+	// The generation is advanced only after everything of it succeeds, the failed reconciles never reach here
+	platformAdminStatus.ObservedGeneration = platformAdmin.Generation
+
+	// The migration of security mode is completed by this reconcile, the configmaps of the previous mode
+	// are released by the next one
+	return reconcile.Result{Requeue: util.IsSecurityMigrating(platformAdmin.Spec.Security, &platformAdmin.Status)}, nil
+}
+
+// reportDenied reports the requests denied by RBAC, a resource quota or a terminating namespace with the condition,
+// retrying them with backoff only floods the apiserver until operators fix the cause.
+func (r *ReconcilePlatformAdmin) reportDenied(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus,
+	condType iotv1alpha2.PlatformAdminConditionType, denied []*util.DeniedError) {
+	messages := make([]string, 0, len(denied))
+	for _, d := range denied {
+		messages = append(messages, d.Message)
 	}
+	message := util.TruncateMessage(strings.Join(messages, "; "), util.MaxConditionMessageLength)
+	log.FromContext(ctx).Info("Requests of PlatformAdmin are denied", "reason", denied[0].Reason, "message", message)
+	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(condType, corev1.ConditionFalse, denied[0].Reason, message))
+	r.recorder.Event(platformAdmin.DeepCopy(), corev1.EventTypeWarning, denied[0].Reason, message)
+}
+
+// isPaused checks whether the PlatformAdmin is paused by spec or annotation.
+func isPaused(platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	return platformAdmin.Spec.Paused || platformAdmin.Annotations[iotv1alpha2.AnnotationPaused] == "true"
+}
 
+// reconcilePaused leaves the objects of PlatformAdmin as they are, and only reports the Paused condition.
+// The other conditions are kept, so they still describe the state before pausing.
+func (r *ReconcilePlatformAdmin) reconcilePaused(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) (reconcile.Result, error) {
+	log.FromContext(ctx).V(4).Info("PlatformAdmin is paused, skip reconciling")
+	util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.PausedCondition, corev1.ConditionTrue, iotv1alpha2.PausedReason, "the objects of PlatformAdmin are not managed until it is resumed"))
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcilePlatformAdmin) reconcileConfigmap(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, _ *iotv1alpha2.PlatformAdminStatus) (bool, error) {
-	var configmaps []corev1.ConfigMap
-	needConfigMaps := make(map[string]struct{})
+// reconcileDryRun computes the objects of PlatformAdmin and records them into status instead of writing them to the cluster.
+// The finalizer is not added in dry-run mode, so a PlatformAdmin which is only previewed can be deleted instantly.
+func (r *ReconcilePlatformAdmin) reconcileDryRun(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration) (reconcile.Result, error) {
+	log.FromContext(ctx).V(4).Info("ReconcileDryRun PlatformAdmin")
+	additional, err := r.additionalComponentsConfigMap(ctx, platformAdmin)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	desiredComponents, err := desiredComponents(platformAdmin, conf, additional)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 
+	var configmaps []corev1.ConfigMap
 	if platformAdmin.Spec.Security {
-		configmaps = r.Configration.SecurityConfigMaps[platformAdmin.Spec.Version]
+		configmaps = conf.SecurityConfigMaps[platformAdmin.Spec.Version]
 	} else {
-		configmaps = r.Configration.NoSectyConfigMaps[platformAdmin.Spec.Version]
+		configmaps = conf.NoSectyConfigMaps[platformAdmin.Spec.Version]
 	}
-	for _, configmap := range configmaps {
-		// Supplement runtime information
-		configmap.Namespace = platformAdmin.Namespace
-		configmap.Labels = make(map[string]string)
-		configmap.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelConfigmap
-
-		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, &configmap, func() error {
-			return controllerutil.SetOwnerReference(platformAdmin, &configmap, (r.Scheme()))
-		})
-		if err != nil {
-			return false, err
-		}
 
-		needConfigMaps[configmap.Name] = struct{}{}
+	var preview []iotv1alpha2.PreviewComponent
+	for _, configmap := range configmaps {
+		preview = append(preview, iotv1alpha2.PreviewComponent{Name: configmap.Name, Kind: kindConfigMap})
 	}
-
-	configmaplist := &corev1.ConfigMapList{}
-	if err := r.List(ctx, configmaplist, client.InNamespace(platformAdmin.Namespace), client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: LabelConfigmap}); err == nil {
-		for _, c := range configmaplist.Items {
-			if _, ok := needConfigMaps[c.Name]; !ok {
-				r.removeOwner(ctx, platformAdmin, &c)
+	for _, dc := range desiredComponents {
+		if dc.Service != nil {
+			pc := iotv1alpha2.PreviewComponent{Name: dc.Name, Kind: kindService}
+			for _, port := range dc.Service.Ports {
+				pc.Ports = append(pc.Ports, port.Port)
+			}
+			preview = append(preview, pc)
+		}
+		if dc.Deployment != nil {
+			pc := iotv1alpha2.PreviewComponent{Name: dc.Name, Kind: kindYurtAppSet}
+			if isDaemonComponent(dc) {
+				pc.Kind = kindYurtAppDaemon
 			}
+			for _, container := range dc.Deployment.Template.Spec.Containers {
+				pc.Images = append(pc.Images, container.Image)
+			}
+			preview = append(preview, pc)
 		}
 	}
 
-	return true, nil
+	if !reflect.DeepEqual(platformAdminStatus.PreviewComponents, preview) {
+		r.recorder.Eventf(platformAdmin.DeepCopy(), corev1.EventTypeNormal, "DryRun",
+			"%d ConfigMaps and %d components would be generated for version %s", len(configmaps), len(desiredComponents), platformAdmin.Spec.Version)
+	}
+	platformAdminStatus.PreviewComponents = preview
+	return reconcile.Result{}, nil
 }
 
-func (r *ReconcilePlatformAdmin) reconcileComponent(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) (bool, error) {
-	var desireComponents []*config.Component
-	needComponents := make(map[string]struct{})
-	var readyComponent int32 = 0
+// desiredConfigMaps renders the configmaps of the version of PlatformAdmin, with the variables of message bus,
+// secrets and schemes and the overrides of user merged into the data.
+func desiredConfigMaps(platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration) ([]corev1.ConfigMap, error) {
+	var configmaps []corev1.ConfigMap
+	var components []*config.Component
 
+	// The additional components never have https overrides, so only the templates are needed by the scheme variables
 	if platformAdmin.Spec.Security {
-		desireComponents = r.Configration.SecurityComponents[platformAdmin.Spec.Version]
+		configmaps = conf.SecurityConfigMaps[platformAdmin.Spec.Version]
+		components = conf.SecurityComponents[platformAdmin.Spec.Version]
 	} else {
-		desireComponents = r.Configration.NoSectyComponents[platformAdmin.Spec.Version]
+		configmaps = conf.NoSectyConfigMaps[platformAdmin.Spec.Version]
+		components = conf.NoSectyComponents[platformAdmin.Spec.Version]
+	}
+	components = filterDisabledComponents(platformAdmin, components)
+	desiredList := make([]corev1.ConfigMap, 0, len(configmaps))
+	for i := range configmaps {
+		desired := configmaps[i].DeepCopy()
+		data, err := renderConfigmapData(desired.Name, desired.Data, platformAdmin)
+		if err != nil {
+			return nil, err
+		}
+		desired.Data = data
+		for k, v := range messageBusVariables(platformAdmin.Spec.MessageBus) {
+			if desired.Data == nil {
+				desired.Data = make(map[string]string)
+			}
+			desired.Data[k] = v
+		}
+		for k, v := range secretVariables(platformAdmin, desired.Name) {
+			if desired.Data == nil {
+				desired.Data = make(map[string]string)
+			}
+			desired.Data[k] = v
+		}
+		for k, v := range schemeVariables(platformAdmin, components, desired.Name) {
+			if desired.Data == nil {
+				desired.Data = make(map[string]string)
+			}
+			desired.Data[k] = v
+		}
+		// The overrides of user win, they are always merged on top of the template, so removing
+		// an override restores the template value
+		for k, v := range platformAdmin.Spec.ConfigMapOverrides[desired.Name] {
+			if len(v) == 0 {
+				delete(desired.Data, k)
+				continue
+			}
+			if desired.Data == nil {
+				desired.Data = make(map[string]string)
+			}
+			desired.Data[k] = v
+		}
+		desiredList = append(desiredList, *desired)
 	}
+	return desiredList, nil
+}
+
+func (r *ReconcilePlatformAdmin) reconcileConfigmap(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration) (bool, error) {
+	logger := log.FromContext(ctx)
+	needConfigMaps := make(map[string]struct{})
 
-	additionalComponents, err := annotationToComponent(platformAdmin.Annotations)
+	// The data is rendered before anything is written, so a broken template leaves the existing configmaps as is
+	configmaps, err := desiredConfigMaps(platformAdmin, conf)
 	if err != nil {
+		logger.Error(err, "Render configmap error")
 		return false, err
 	}
-	desireComponents = append(desireComponents, additionalComponents...)
-
-	//TODO: handle PlatformAdmin.Spec.Components
+	for i := range configmaps {
+		desired := &configmaps[i]
+		// Supplement runtime information
+		configmap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      desired.Name,
+				Namespace: workloadNamespace(platformAdmin),
+			},
+		}
 
+		// The data is always applied, so the configmap follows the version of PlatformAdmin. The keys added by
+		// others are kept, while the keys of template are owned by PlatformAdmin controller
+		result, err := r.applyOwned(ctx, configmap, func(existing client.Object) error {
+			var existingConfigmap *corev1.ConfigMap
+			if existing != nil {
+				existingConfigmap = existing.(*corev1.ConfigMap)
+			}
+			// The labels and annotations of template are applied along with the generate label, the ones added by
+			// others are not owned by PlatformAdmin controller and kept by the apply
+			configmap.Labels = map[string]string{}
+			for k, v := range desired.Labels {
+				configmap.Labels[k] = v
+			}
+			configmap.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelConfigmap
+			if len(desired.Annotations) > 0 {
+				configmap.Annotations = make(map[string]string, len(desired.Annotations))
+				for k, v := range desired.Annotations {
+					configmap.Annotations[k] = v
+				}
+			}
+			// The configmap shared by PlatformAdmins is not deleted until the last one desiring it is gone
+			controllerutil.AddFinalizer(configmap, iotv1alpha2.ConfigMapFinalizer)
+			propagateMetadata(platformAdmin, configmap)
+			protectMetadata(platformAdmin, configmap)
+			// The configmap provided by the users, e.g. while the configmap management is skipped, is adopted
+			// with the values of its keys kept
+			setAdoptedData(configmap, desired, adoptedKeys(existingConfigmap, desired))
+			if err := r.setOwner(platformAdmin, configmap); err != nil {
+				return err
+			}
+			setIdentityLabels(platformAdmin, configmap, "")
+			return nil
+		})
+		if err != nil {
+			logger.Error(err, "Reconcile configmap error", "configmap", desired.Name)
+			return false, util.ClassifyAPIError(err)
+		}
+		logger.V(4).Info("Reconciled configmap", "configmap", desired.Name, "result", result)
+		recordOperationResult(kindConfigMap, result)
+		r.recordOperationEvent(platformAdmin, kindConfigMap, configmap.Name, result)
+		recordManagedResource(platformAdminStatus, configmap)
+
+		needConfigMaps[desired.Name] = struct{}{}
+	}
+
+	// The configmaps of the previous security mode are kept for its components during the migration
+	if util.IsSecurityMigrating(platformAdmin.Spec.Security, platformAdminStatus) {
+		previous := conf.NoSectyConfigMaps[platformAdmin.Spec.Version]
+		if *platformAdminStatus.CurrentSecurity {
+			previous = conf.SecurityConfigMaps[platformAdmin.Spec.Version]
+		}
+		for _, configmap := range previous {
+			needConfigMaps[configmap.Name] = struct{}{}
+		}
+	}
+
+	if err := r.releaseUnneeded(ctx, platformAdmin, &corev1.ConfigMapList{}, LabelConfigmap, needConfigMaps, func(obj client.Object) error {
+		if err := r.releaseConfigMap(ctx, platformAdmin, obj.(*corev1.ConfigMap), conf); err != nil {
+			return err
+		}
+		forgetManagedResource(platformAdminStatus, obj)
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ReconcilePlatformAdmin) reconcileComponent(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration) (bool, error) {
+	logger := log.FromContext(ctx)
+	needComponents := make(map[string]struct{})
+	var readyComponent int32 = 0
+
+	additional, err := r.additionalComponentsConfigMap(ctx, platformAdmin)
+	if err != nil {
+		return false, err
+	}
+	allComponents, err := assembleComponents(platformAdmin, conf, additional)
+	if err != nil {
+		return false, err
+	}
+	desireComponents := filterDisabledComponents(platformAdmin, allComponents)
+	// The invalid and colliding components are rejected before anything is created, instead of failing halfway
+	if err := config.ValidateComponents(desireComponents); err != nil {
+		return false, err
+	}
+	if err := config.ValidateComponentSet(desireComponents); err != nil {
+		return false, err
+	}
+
+	// The components exclusive to the previous security mode are kept until the components of the new mode are ready
+	migrating := util.IsSecurityMigrating(platformAdmin.Spec.Security, platformAdminStatus)
+	previousComponents, err := previousSecurityComponents(platformAdmin, platformAdminStatus, conf, additional, desireComponents)
+	if err != nil {
+		return false, err
+	}
+
+	// The unknown names are only warned about, the other components are still reconciled
+	if unknown := unknownComponents(platformAdmin, allComponents); len(unknown) > 0 {
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.UnknownComponentCondition, corev1.ConditionTrue, iotv1alpha2.UnknownComponentReason,
+			fmt.Sprintf("components %s in componentEnv or disabledComponents do not exist", strings.Join(unknown, ","))))
+	} else {
+		util.RemovePlatformAdminCondition(platformAdminStatus, iotv1alpha2.UnknownComponentCondition)
+	}
+
+	var conflicts, unmanaged, archUnsupported []string
 	defer func() {
 		platformAdminStatus.ReadyComponentNum = readyComponent
 		platformAdminStatus.UnreadyComponentNum = int32(len(desireComponents)) - readyComponent
+		if len(conflicts) > 0 {
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentConflictCondition, corev1.ConditionTrue, iotv1alpha2.ComponentConflictReason,
+				fmt.Sprintf("workloads %s are not generated by PlatformAdmin, add annotation %s=\"true\" to adopt them", strings.Join(conflicts, ","), iotv1alpha2.AnnotationAdoptable)))
+		} else {
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentConflictCondition, corev1.ConditionFalse, "", ""))
+		}
 	}()
 
-NextC:
+	needYurtAppSets := make(map[string]struct{})
+	needYurtAppDaemons := make(map[string]struct{})
+	needPodDisruptionBudgets := make(map[string]struct{})
+	needNetworkPolicies := make(map[string]struct{})
+	needHorizontalPodAutoscalers := make(map[string]struct{})
+	needServiceMonitors := make(map[string]struct{})
+	// unreadyComponents records the reason why each component is not ready
+	unreadyComponents := make(map[string]string)
+	// unreadyDetails records the most relevant condition of the workloads of unready components
+	unreadyDetails := make(map[string]string)
 	for _, desireComponent := range desireComponents {
-		readyService := false
-		readyDeployment := false
 		needComponents[desireComponent.Name] = struct{}{}
+		// The workload of the other kind is cleaned up, when the workload type of component is changed
+		if isDaemonComponent(desireComponent) {
+			needYurtAppDaemons[desireComponent.Name] = struct{}{}
+		} else {
+			needYurtAppSets[desireComponent.Name] = struct{}{}
+		}
+	}
+
+	// The components are reconciled by a bounded number of workers, and every worker only writes the result of
+	// its own component. The results are folded in the order of components, so the status is deterministic.
+	results := make([]componentResult, len(desireComponents))
+	var group errgroup.Group
+	group.SetLimit(componentWorkerCount())
+	for i := range desireComponents {
+		i := i
+		group.Go(func() error {
+			results[i] = r.reconcileComponentObjects(ctx, platformAdmin, desireComponents[i], conf)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	var errs []error
+	componentStatuses := make([]iotv1alpha2.ComponentStatus, 0, len(results))
+	for i, result := range results {
+		name := desireComponents[i].Name
+		componentStatuses = append(componentStatuses, newComponentStatus(name, result))
+		for _, obj := range result.managed {
+			recordManagedResource(platformAdminStatus, obj)
+		}
+		if result.podDisruptionBudget != "" {
+			needPodDisruptionBudgets[result.podDisruptionBudget] = struct{}{}
+		}
+		if result.networkPolicy != "" {
+			needNetworkPolicies[result.networkPolicy] = struct{}{}
+		}
+		for _, name := range result.horizontalPodAutoscalers {
+			needHorizontalPodAutoscalers[name] = struct{}{}
+		}
+		if result.serviceMonitor != "" {
+			needServiceMonitors[result.serviceMonitor] = struct{}{}
+		}
+		if result.conflict != "" {
+			conflicts = append(conflicts, result.conflict)
+		}
+		if result.unmanaged {
+			unmanaged = append(unmanaged, name)
+		}
+		if result.archUnsupported {
+			archUnsupported = append(archUnsupported, name)
+		}
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+		if result.detail != "" {
+			unreadyDetails[name] = result.detail
+		}
+		if result.unreadyReason != "" {
+			unreadyComponents[name] = result.unreadyReason
+			continue
+		}
+		readyComponent++
+	}
 
-		if _, err := r.handleService(ctx, platformAdmin, desireComponent); err != nil {
+	// The components are sorted, so the status is not changed by the order of configuration
+	sort.Slice(componentStatuses, func(i, j int) bool { return componentStatuses[i].Name < componentStatuses[j].Name })
+	platformAdminStatus.Components = componentStatuses
+
+	if migrating && (len(unreadyComponents) > 0 || len(errs) > 0) {
+		var kept, pending []string
+		for _, component := range previousComponents {
+			kept = append(kept, component.Name)
+			needComponents[component.Name] = struct{}{}
+			needPodDisruptionBudgets[component.Name] = struct{}{}
+			needNetworkPolicies[component.Name] = struct{}{}
+			for _, name := range autoscalerNames(platformAdmin, component) {
+				needHorizontalPodAutoscalers[name] = struct{}{}
+			}
+			needServiceMonitors[component.Name] = struct{}{}
+			if isDaemonComponent(component) {
+				needYurtAppDaemons[component.Name] = struct{}{}
+			} else {
+				needYurtAppSets[component.Name] = struct{}{}
+			}
+		}
+		for _, component := range desireComponents {
+			if _, ok := unreadyComponents[component.Name]; ok {
+				pending = append(pending, component.Name)
+			}
+		}
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecurityMigrationCondition, corev1.ConditionTrue, iotv1alpha2.SecurityMigrationProvisioningReason,
+			util.TruncateMessage(fmt.Sprintf("migrating from security %t to %t, components [%s] of the previous mode are kept until components [%s] are ready",
+				*platformAdminStatus.CurrentSecurity, platformAdmin.Spec.Security, strings.Join(kept, ","), strings.Join(pending, ",")), util.MaxConditionMessageLength)))
+	}
+	// unreleased records the workloads whose pool fails to be released
+	var unreleased []string
+
+	// removeOwner releases the objects that we do not need, they are deleted once no PlatformAdmin owns them
+	removeOwner := func(obj client.Object) error {
+		if err := r.removeOwner(ctx, platformAdmin, obj); err != nil {
+			return err
+		}
+		forgetManagedResource(platformAdminStatus, obj)
+		return nil
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &corev1.ServiceList{}, LabelService, needComponents, removeOwner); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &policyv1.PodDisruptionBudgetList{}, LabelPodDisruptionBudget, needPodDisruptionBudgets, removeOwner); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &networkingv1.NetworkPolicyList{}, LabelNetworkPolicy, needNetworkPolicies, removeOwner); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &autoscalingv2beta2.HorizontalPodAutoscalerList{}, LabelHorizontalPodAutoscaler, needHorizontalPodAutoscalers, removeOwner); err != nil {
+		errs = append(errs, err)
+	}
+	if r.serviceMonitorAvailable {
+		if err := r.releaseUnneeded(ctx, platformAdmin, newServiceMonitorList(), LabelServiceMonitor, needServiceMonitors, removeOwner); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// The pool of PlatformAdmin is removed like reconcileDelete, the workload may be shared with others. The workloads
+	// failing to be released are kept owned and reported by the migration instead of failing the reconcile.
+	if err := r.releaseUnneeded(ctx, platformAdmin, &appsv1alpha1.YurtAppSetList{}, LabelDeployment, needYurtAppSets, func(obj client.Object) error {
+		if err := r.releasePool(ctx, platformAdmin, obj.(*appsv1alpha1.YurtAppSet)); err != nil {
+			logger.Error(err, "Remove pool from YurtAppSet failed", "yurtappset", obj.GetName(), "pools", joinedPools(platformAdmin))
+			unreleased = append(unreleased, obj.GetName())
+			return nil
+		}
+		return removeOwner(obj)
+	}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.releaseUnneeded(ctx, platformAdmin, &appsv1alpha1.YurtAppDaemonList{}, LabelYurtAppDaemon, needYurtAppDaemons, func(obj client.Object) error {
+		if err := r.releaseDaemonPool(ctx, platformAdmin, obj.(*appsv1alpha1.YurtAppDaemon)); err != nil {
+			logger.Error(err, "Remove pool from YurtAppDaemon failed", "yurtappdaemon", obj.GetName(), "pools", joinedPools(platformAdmin))
+			unreleased = append(unreleased, obj.GetName())
+			return nil
+		}
+		return removeOwner(obj)
+	}); err != nil {
+		errs = append(errs, err)
+	}
+
+	// The pools removed from the spec are released from the workloads which are still needed
+	if err := r.releaseDroppedPools(ctx, platformAdmin, platformAdminStatus); err != nil {
+		errs = append(errs, err)
+	}
+
+	r.recordUnmanagedComponents(platformAdmin, platformAdminStatus, unmanaged)
+	if len(archUnsupported) > 0 {
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ArchUnsupportedCondition, corev1.ConditionTrue, iotv1alpha2.ArchUnsupportedReason,
+			util.TruncateMessage(fmt.Sprintf("components %s are not deployed, none of the nodes in pool %s has their supported architectures",
+				strings.Join(archUnsupported, ","), joinedPools(platformAdmin)), util.MaxConditionMessageLength)))
+	} else {
+		util.RemovePlatformAdminCondition(platformAdminStatus, iotv1alpha2.ArchUnsupportedCondition)
+	}
+
+	if len(unreadyComponents) > 0 {
+		reason, message := unreadyComponentsSummary(unreadyComponents, unreadyDetails)
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, reason, message))
+	}
+	if len(errs) > 0 {
+		return false, kerrors.NewAggregate(errs)
+	}
+	if migrating && len(unreadyComponents) == 0 {
+		// The migration is completed only after the workloads of the previous mode are released
+		if len(unreleased) > 0 {
+			err := fmt.Errorf("failed to release workloads %s of the previous security mode", strings.Join(unreleased, ","))
+			util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecurityMigrationCondition, corev1.ConditionTrue, iotv1alpha2.SecurityMigrationReleasingReason, err.Error()))
 			return false, err
 		}
-		readyService = true
+		security := platformAdmin.Spec.Security
+		platformAdminStatus.CurrentSecurity = &security
+		util.SetPlatformAdminCondition(platformAdminStatus, util.NewPlatformAdminCondition(iotv1alpha2.SecurityMigrationCondition, corev1.ConditionFalse, iotv1alpha2.SecurityMigrationCompletedReason,
+			fmt.Sprintf("components are migrated to security %t", security)))
+	}
+	return readyComponent == int32(len(desireComponents)), nil
+}
 
-		yas := &appsv1alpha1.YurtAppSet{}
-		err := r.Get(
-			ctx,
-			types.NamespacedName{
-				Namespace: platformAdmin.Namespace,
-				Name:      desireComponent.Name},
-			yas)
+// previousSecurityComponents returns the components which are only desired by the previous security mode, while
+// the components are being migrated to the security mode of spec. It is empty if no migration is in progress.
+func previousSecurityComponents(platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, conf *config.PlatformAdminControllerConfiguration, additional *corev1.ConfigMap, desired []*config.Component) ([]*config.Component, error) {
+	if !util.IsSecurityMigrating(platformAdmin.Spec.Security, platformAdminStatus) {
+		return nil, nil
+	}
+	previous := platformAdmin.DeepCopy()
+	previous.Spec.Security = *platformAdminStatus.CurrentSecurity
+	components, err := desiredComponents(previous, conf, additional)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredNames := sets.NewString()
+	for _, component := range desired {
+		desiredNames.Insert(component.Name)
+	}
+	var exclusive []*config.Component
+	for _, component := range components {
+		if !desiredNames.Has(component.Name) {
+			exclusive = append(exclusive, component)
+		}
+	}
+	return exclusive, nil
+}
+
+// componentResult is the outcome of reconciling the objects of one component.
+type componentResult struct {
+	// unreadyReason is the reason why the component is not ready, the component is ready if it is empty
+	unreadyReason string
+	// detail is the most relevant condition of the workload of the unready component
+	detail string
+	// conflict is the name of the workload which is not generated by PlatformAdmin
+	conflict string
+	// unmanaged means the workload is annotated as unmanaged, so it is not patched
+	unmanaged bool
+	// archUnsupported means none of the nodes in the pool has a supported architecture of the component
+	archUnsupported bool
+	// podDisruptionBudget, networkPolicy, horizontalPodAutoscalers and serviceMonitor are the names of the objects
+	// which are still needed, there is a HorizontalPodAutoscaler in every pool
+	podDisruptionBudget      string
+	networkPolicy            string
+	horizontalPodAutoscalers []string
+	serviceMonitor           string
+	// managed are the objects created or adopted for the component
+	managed []client.Object
+	err     error
+}
+
+// newComponentStatus returns the status of component from the result of reconciling it.
+func newComponentStatus(name string, result componentResult) iotv1alpha2.ComponentStatus {
+	status := iotv1alpha2.ComponentStatus{Name: name, Ready: result.unreadyReason == ""}
+	if status.Ready {
+		return status
+	}
+	status.Reason = result.unreadyReason
+	status.Message = result.detail
+	if result.err != nil {
+		status.Message = result.err.Error()
+	}
+	status.Message = util.TruncateMessage(status.Message, util.MaxConditionMessageLength)
+	return status
+}
+
+// componentWorkerCount returns the number of workers reconciling the components of a PlatformAdmin, at least one.
+func componentWorkerCount() int {
+	if componentWorkers < 1 {
+		return 1
+	}
+	return componentWorkers
+}
+
+// reconcileComponentObjects reconciles the service, poddisruptionbudget, networkpolicy and workload of the component.
+// It runs concurrently with the other components, so it only reads the shared state and returns what it has done.
+func (r *ReconcilePlatformAdmin) reconcileComponentObjects(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, desireComponent *config.Component, conf *config.PlatformAdminControllerConfiguration) componentResult {
+	logger := log.FromContext(ctx)
+	result := componentResult{}
+	// failComponent records the error of component, so a broken component does not block the others
+	failComponent := func(err error) componentResult {
+		result.err = err
+		result.unreadyReason = iotv1alpha2.ComponentProvisioningFailedReason
+		// The objects of the failed component are kept until it is reconciled successfully
+		result.podDisruptionBudget = desireComponent.Name
+		result.networkPolicy = desireComponent.Name
+		result.horizontalPodAutoscalers = autoscalerNames(platformAdmin, desireComponent)
+		result.serviceMonitor = desireComponent.Name
+		return result
+	}
+
+	service, err := r.handleService(ctx, platformAdmin, desireComponent)
+	if err != nil {
+		return failComponent(err)
+	}
+	if service != nil {
+		result.managed = append(result.managed, service)
+	}
+	pdb, err := r.handlePodDisruptionBudget(ctx, platformAdmin, desireComponent)
+	if err != nil {
+		return failComponent(classifyComponentError(desireComponent.Name, err))
+	}
+	if pdb != nil {
+		result.podDisruptionBudget = pdb.Name
+	}
+	networkPolicy, err := r.handleNetworkPolicy(ctx, platformAdmin, desireComponent)
+	if err != nil {
+		return failComponent(classifyComponentError(desireComponent.Name, err))
+	}
+	if networkPolicy != nil {
+		result.networkPolicy = networkPolicy.Name
+	}
+	serviceMonitor, err := r.handleServiceMonitor(ctx, platformAdmin, desireComponent)
+	if err != nil {
+		return failComponent(classifyComponentError(desireComponent.Name, err))
+	}
+	if serviceMonitor != nil {
+		result.serviceMonitor = serviceMonitor.GetName()
+	}
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		pvc, err := r.handlePersistentVolumeClaim(ctx, platformAdmin, desireComponent, poolName)
 		if err != nil {
-			if !apierrors.IsNotFound(err) {
-				return false, err
+			return failComponent(classifyComponentError(desireComponent.Name, err))
+		}
+		if pvc != nil {
+			result.managed = append(result.managed, pvc)
+		}
+	}
+
+	if isDaemonComponent(desireComponent) {
+		reason, err := r.reconcileYurtAppDaemon(ctx, platformAdmin, desireComponent)
+		if err != nil {
+			return failComponent(err)
+		}
+		if reason == iotv1alpha2.ComponentConflictReason {
+			result.conflict = desireComponent.Name
+		}
+		result.unreadyReason = reason
+		return result
+	}
+
+	// The workload which can not run on any node of the pool is not deployed, the existing one is left as is
+	supported, err := r.isArchitectureSupported(ctx, platformAdmin, desireComponent)
+	if err != nil {
+		return failComponent(err)
+	}
+	if !supported {
+		logger.Info("None of the nodes in the pool has a supported architecture, skip deploying it", "component", desireComponent.Name, "architectures", desireComponent.SupportedArchitectures)
+		result.archUnsupported = true
+		result.unreadyReason = iotv1alpha2.ArchUnsupportedReason
+		if isAutoscaled(desireComponent) {
+			result.horizontalPodAutoscalers = autoscalerNames(platformAdmin, desireComponent)
+		}
+		return result
+	}
+
+	yas := &appsv1alpha1.YurtAppSet{}
+	err = r.Get(
+		ctx,
+		types.NamespacedName{
+			Namespace: workloadNamespace(platformAdmin),
+			Name:      desireComponent.Name},
+		yas)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return failComponent(err)
+		}
+		yas, err = r.handleYurtAppSet(ctx, platformAdmin, desireComponent, conf)
+		if err != nil {
+			return failComponent(classifyComponentError(desireComponent.Name, err))
+		}
+		result.managed = append(result.managed, yas)
+		result.unreadyReason = iotv1alpha2.DeploymentNotReadyReason
+		return result
+	}
+	if !isAdoptable(yas, platformAdmin) {
+		// The yurtappset is created by others(e.g. a previous manual install), it is not hijacked
+		logger.Info("YurtAppSet is not generated by PlatformAdmin, skip it", "component", desireComponent.Name, "yurtappset", yas.Name)
+		result.conflict = yas.Name
+		result.unreadyReason = iotv1alpha2.ComponentConflictReason
+		return result
+	}
+	if isUnmanaged(yas) {
+		// The template and topology are overridden manually, the readiness is still told by the live status
+		logger.V(4).Info("YurtAppSet is unmanaged, skip patching it", "component", desireComponent.Name, "yurtappset", yas.Name)
+		result.unmanaged = true
+		result.managed = append(result.managed, yas)
+		if isAutoscaled(desireComponent) {
+			// The autoscaler is kept, while the pool does not follow it until the yurtappset is managed again
+			result.horizontalPodAutoscalers = autoscalerNames(platformAdmin, desireComponent)
+		}
+		readyDeployment := yas.Status.ObservedGeneration == yas.Generation && isComponentPoolReady(yas, platformAdmin, desireComponent)
+		return r.componentReadiness(ctx, platformAdmin, desireComponent, yas, result, readyDeployment)
+	}
+
+	// The outdated pools are removed before any other change, since the patch refreshes the yurtappset
+	missingPools, err := r.ensurePools(ctx, platformAdmin, desireComponent, yas)
+	if err != nil {
+		return failComponent(classifyComponentError(desireComponent.Name, err))
+	}
+	poolUpToDate := len(missingPools) == 0
+
+	oldYas := yas.DeepCopy()
+	templateHash := componentTemplateHash(desireComponent)
+	upToDate := yas.Annotations[iotv1alpha2.AnnotationTemplateHash] == templateHash
+	if platformAdmin.Spec.NetworkPolicy && !isTemplateLabeled(yas.Spec.WorkloadTemplate.DeploymentTemplate) {
+		// The pods generated before the NetworkPolicy is enabled are not labeled, and are denied by it
+		upToDate = false
+	}
+	if !upToDate {
+		// The component template has changed(e.g. the version of PlatformAdmin is upgraded),
+		// so the workload template is updated to the desired one.
+		logger.Info("Update the workload template of YurtAppSet", "component", desireComponent.Name, "yurtappset", yas.Name)
+		yas.Spec.WorkloadTemplate.DeploymentTemplate = newDeploymentTemplate(desireComponent)
+		if yas.Annotations == nil {
+			yas.Annotations = make(map[string]string)
+		}
+		yas.Annotations[iotv1alpha2.AnnotationTemplateHash] = templateHash
+	}
+	// The fields the service and the controller rely on are restored if they are edited by others
+	drifted := repairYurtAppSetDrift(yas, platformAdmin, desireComponent)
+
+	for _, poolName := range missingPools {
+		yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, newPool(platformAdmin, desireComponent, poolName))
+	}
+	syncPoolReplicas(platformAdmin, desireComponent, yas)
+	propagateMetadata(platformAdmin, yas)
+	protectMetadata(platformAdmin, yas)
+	// The existing owner reference is kept, so the controller reference set on creation is not overwritten
+	if !isOwnedBy(yas, platformAdmin) {
+		if err := r.setOwner(platformAdmin, yas); err != nil {
+			return failComponent(err)
+		}
+	}
+	setIdentityLabels(platformAdmin, yas, desireComponent.Name)
+	if !reflect.DeepEqual(oldYas, yas) {
+		if err := r.Client.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
+			logger.Error(err, "Patch YurtAppSet failed", "component", desireComponent.Name, "yurtappset", yas.Name)
+			return failComponent(classifyComponentError(desireComponent.Name, err))
+		}
+		recordOperation(kindYurtAppSet, operationPatch)
+		r.recordOperationEvent(platformAdmin, kindYurtAppSet, yas.Name, controllerutil.OperationResultUpdated)
+		if len(drifted) > 0 {
+			logger.Info("Repair the drift of YurtAppSet", "component", desireComponent.Name, "yurtappset", yas.Name, "fields", drifted)
+			r.recorder.Eventf(platformAdmin.DeepCopy(), corev1.EventTypeNormal, eventReasonDriftRepaired,
+				"The drifted fields %s of yurtappset %s are repaired", strings.Join(drifted, ","), yas.Name)
+		}
+	}
+	result.managed = append(result.managed, yas)
+
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		hpa, err := r.handleHorizontalPodAutoscaler(ctx, platformAdmin, desireComponent, yas, poolName)
+		if err != nil {
+			return failComponent(classifyComponentError(desireComponent.Name, err))
+		}
+		if hpa == nil {
+			continue
+		}
+		result.horizontalPodAutoscalers = append(result.horizontalPodAutoscalers, hpa.Name)
+		if err := r.followAutoscaler(ctx, yas, hpa, poolName); err != nil {
+			return failComponent(classifyComponentError(desireComponent.Name, err))
+		}
+	}
+
+	// The status is considered only after the yurtappset controller has observed the latest template and pool
+	readyDeployment := upToDate && poolUpToDate && yas.Status.ObservedGeneration == yas.Generation && isComponentPoolReady(yas, platformAdmin, desireComponent)
+	return r.componentReadiness(ctx, platformAdmin, desireComponent, yas, result, readyDeployment)
+}
+
+// isArchitectureSupported checks whether any node in the pools of PlatformAdmin has one of the supported architectures
+// of component. The pools without nodes are considered supported, since the nodes may join them later.
+func (r *ReconcilePlatformAdmin) isArchitectureSupported(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (bool, error) {
+	if len(component.SupportedArchitectures) == 0 {
+		return true, nil
+	}
+	supported := sets.NewString(component.SupportedArchitectures...)
+	hasNodes := false
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		nodes := &corev1.NodeList{}
+		if err := r.List(ctx, nodes, client.MatchingLabels{appsv1alpha1.LabelCurrentNodePool: poolName}); err != nil {
+			return false, err
+		}
+		for _, node := range nodes.Items {
+			hasNodes = true
+			if supported.Has(node.Labels[corev1.LabelArchStable]) {
+				return true, nil
 			}
-			_, err = r.handleYurtAppSet(ctx, platformAdmin, desireComponent)
-			if err != nil {
-				return false, err
+		}
+	}
+	return !hasNodes, nil
+}
+
+// componentReadiness records why the component is not ready in the result, by the readiness of its yurtappset and
+// its service.
+func (r *ReconcilePlatformAdmin) componentReadiness(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, yas *appsv1alpha1.YurtAppSet, result componentResult, readyDeployment bool) componentResult {
+	readyService := false
+	if readyDeployment {
+		// The ready replicas do not guarantee that the service can be reached in the pool
+		var err error
+		if readyService, err = r.isServiceReady(ctx, platformAdmin, component); err != nil {
+			result.err = err
+			result.unreadyReason = iotv1alpha2.ComponentProvisioningFailedReason
+			return result
+		}
+	}
+	switch {
+	case !readyDeployment:
+		result.unreadyReason = iotv1alpha2.DeploymentNotReadyReason
+		result.detail = r.workloadDetail(ctx, platformAdmin, yas)
+	case !readyService:
+		result.unreadyReason = iotv1alpha2.EndpointsNotReadyReason
+	}
+	return result
+}
+
+// isComponentPoolReady checks whether the workload of component is ready in every pool of PlatformAdmin, the autoscaled
+// pool is ready with the minimum replicas of autoscaling.
+func isComponentPoolReady(yas *appsv1alpha1.YurtAppSet, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) bool {
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		if isAutoscaled(component) {
+			if !isAutoscaledPoolReady(yas, poolName, component.Autoscaling) {
+				return false
 			}
-		} else {
-			oldYas := yas.DeepCopy()
-
-			if _, ok := yas.Status.PoolReplicas[platformAdmin.Spec.PoolName]; ok {
-				if yas.Status.ReadyReplicas == yas.Status.Replicas {
-					readyDeployment = true
-					if readyDeployment && readyService {
-						readyComponent++
-					}
+		} else if !isPoolReady(yas, poolName) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnmanaged checks whether the yurtappset is annotated as unmanaged.
+func isUnmanaged(yas *appsv1alpha1.YurtAppSet) bool {
+	return yas.Annotations[iotv1alpha2.AnnotationUnmanaged] == "true"
+}
+
+// recordUnmanagedComponents records the unmanaged components in the status, an event is emitted for the components
+// which are found unmanaged for the first time.
+func (r *ReconcilePlatformAdmin) recordUnmanagedComponents(platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus, unmanaged []string) {
+	sort.Strings(unmanaged)
+	known := sets.NewString(platformAdminStatus.UnmanagedComponents...)
+	for _, name := range unmanaged {
+		if !known.Has(name) {
+			r.recorder.Eventf(platformAdmin.DeepCopy(), corev1.EventTypeWarning, eventReasonUnmanaged,
+				"The yurtappset of component %s is annotated with %s, its template and topology are not managed", name, iotv1alpha2.AnnotationUnmanaged)
+		}
+	}
+	platformAdminStatus.UnmanagedComponents = unmanaged
+}
+
+// componentRejectedError indicates that the object of a component is rejected by the apiserver(e.g. by the
+// validating webhook of yurtappset), which is a configuration problem and can not be fixed by retrying.
+type componentRejectedError struct {
+	component string
+	err       error
+}
+
+func (e *componentRejectedError) Error() string {
+	return fmt.Sprintf("component %s is rejected: %v", e.component, e.err)
+}
+
+func (e *componentRejectedError) Unwrap() error {
+	return e.err
+}
+
+// classifyComponentError wraps the error of rejected requests, the requests denied by RBAC, a resource quota or a
+// terminating namespace are classified by util.ClassifyAPIError instead. Other errors(e.g. conflicts and timeouts)
+// are returned as is.
+func classifyComponentError(component string, err error) error {
+	if err = util.ClassifyAPIError(err); util.DeniedErrors(err) != nil {
+		return err
+	}
+	if apierrors.IsInvalid(err) || apierrors.IsForbidden(err) {
+		return &componentRejectedError{component: component, err: err}
+	}
+	return err
+}
+
+// rejectedError returns the error if the components fail only because they are rejected, and nil if
+// any component fails for other reasons, since retrying with backoff can fix those components.
+func rejectedError(err error) error {
+	errs := []error{err}
+	if agg, ok := err.(kerrors.Aggregate); ok {
+		errs = agg.Errors()
+	}
+	for _, e := range errs {
+		var rejected *componentRejectedError
+		if !errors.As(e, &rejected) {
+			return nil
+		}
+	}
+	return err
+}
+
+// isWorkloadGone checks whether the workload to release is not found, it is deleted already or its kind is not
+// installed in the cluster. The pools of PlatformAdmin are released from it anyway.
+func isWorkloadGone(err error) bool {
+	return apierrors.IsNotFound(err) || isDependencyMissing(err)
+}
+
+// isDependencyMissing checks whether any error is caused by a kind which is not installed in the cluster.
+func isDependencyMissing(err error) bool {
+	errs := []error{err}
+	if agg, ok := err.(kerrors.Aggregate); ok {
+		errs = agg.Errors()
+	}
+	for _, e := range errs {
+		if meta.IsNoMatchError(e) || runtime.IsNotRegisteredError(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// unreadyComponentsSummary summarizes the unready components for the ComponentAvailable condition, the reason
+// is picked in the order of the provisioning progress, i.e. DeploymentNotReady goes before EndpointsNotReady.
+// The details of workloads follow the reasons, and the message is truncated to a sane length.
+func unreadyComponentsSummary(unreadyComponents map[string]string, details map[string]string) (string, string) {
+	names := make([]string, 0, len(unreadyComponents))
+	for name := range unreadyComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reason := iotv1alpha2.ComponentProvisioningReason
+	var messages []string
+	for _, candidate := range []string{iotv1alpha2.ComponentProvisioningFailedReason, iotv1alpha2.ComponentConflictReason, iotv1alpha2.ArchUnsupportedReason, iotv1alpha2.DeploymentNotReadyReason, iotv1alpha2.EndpointsNotReadyReason} {
+		var components []string
+		for _, name := range names {
+			if unreadyComponents[name] == candidate {
+				components = append(components, name)
+			}
+		}
+		if len(components) == 0 {
+			continue
+		}
+		if reason == iotv1alpha2.ComponentProvisioningReason {
+			reason = candidate
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", candidate, strings.Join(components, ",")))
+	}
+	for _, name := range names {
+		if detail, ok := details[name]; ok {
+			messages = append(messages, fmt.Sprintf("%s: %s", name, detail))
+		}
+	}
+	return reason, util.TruncateMessage(strings.Join(messages, "; "), util.MaxConditionMessageLength)
+}
+
+// workloadDetail returns the most relevant condition of the yurtappset and the deployments in the pools
+// of PlatformAdmin, which explains why the component is not ready, e.g. the pods can not be created.
+func (r *ReconcilePlatformAdmin) workloadDetail(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, yas *appsv1alpha1.YurtAppSet) string {
+	conditions := util.YurtAppSetConditions(yas)
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		deployments := &appsv1.DeploymentList{}
+		if err := r.List(ctx, deployments, client.InNamespace(yas.Namespace), client.MatchingLabels{appsv1alpha1.PoolNameLabelKey: poolName}); err != nil {
+			log.FromContext(ctx).V(4).Info("List deployments of YurtAppSet error", "yurtappset", yas.Name, "pool", poolName, "error", err.Error())
+			continue
+		}
+		for i := range deployments.Items {
+			if metav1.IsControlledBy(&deployments.Items[i], yas) {
+				conditions = append(conditions, util.DeploymentConditions(&deployments.Items[i])...)
+			}
+		}
+	}
+
+	if condition := util.MostRelevantCondition(conditions); condition != nil {
+		return condition.String()
+	}
+	return ""
+}
+
+// isServiceReady checks whether the service of component has at least one ready address on the nodes of every pool.
+// It is true if the component does not need service.
+func (r *ReconcilePlatformAdmin) isServiceReady(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (bool, error) {
+	if component.Service == nil {
+		return true, nil
+	}
+
+	endpoints := &corev1.Endpoints{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: component.Name}, endpoints); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	unready := sets.NewString(util.PlatformAdminPools(platformAdmin)...)
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.NodeName == nil {
+				continue
+			}
+			node := &corev1.Node{}
+			if err := r.Get(ctx, types.NamespacedName{Name: *address.NodeName}, node); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
 				}
-				continue NextC
-			}
-			pool := appsv1alpha1.Pool{
-				Name:     platformAdmin.Spec.PoolName,
-				Replicas: pointer.Int32Ptr(1),
-			}
-			pool.NodeSelectorTerm.MatchExpressions = append(pool.NodeSelectorTerm.MatchExpressions,
-				corev1.NodeSelectorRequirement{
-					Key:      appsv1alpha1.LabelCurrentNodePool,
-					Operator: corev1.NodeSelectorOpIn,
-					Values:   []string{platformAdmin.Spec.PoolName},
-				})
-			flag := false
-			for _, up := range yas.Spec.Topology.Pools {
-				if up.Name == pool.Name {
-					flag = true
+				return false, err
+			}
+			unready.Delete(node.Labels[appsv1alpha1.LabelCurrentNodePool])
+			if unready.Len() == 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (r *ReconcilePlatformAdmin) handleService(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*corev1.Service, error) {
+	// It is possible that the component does not need service.
+	// Therefore, you need to be careful when calling this function.
+	// It is still possible for service to be nil when there is no error!
+	if component.Service == nil {
+		return nil, nil
+	}
+
+	topology, err := serviceTopologyValue(component.ServiceTopology)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.Name,
+			Namespace: workloadNamespace(platformAdmin),
+		},
+	}
+	result, err := r.applyOwned(
+		ctx,
+		service,
+		func(existing client.Object) error {
+			// Only the spec of component template is applied, the fields defaulted or allocated by the apiserver
+			// and the ones set by others are not owned by PlatformAdmin controller
+			var existingPorts []corev1.ServicePort
+			if existing != nil {
+				existingPorts = existing.(*corev1.Service).Spec.Ports
+			}
+			service.Spec = *component.Service.DeepCopy()
+			// The node ports allocated to the existing ports are kept
+			service.Spec.Ports = desiredServicePorts(existingPorts, component.Service.Ports)
+			service.Labels = map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}
+			// The topology is not applied once the component opts out of it, so it is removed from the service
+			if topology != "" {
+				service.Annotations = map[string]string{AnnotationServiceTopologyKey: topology}
+			}
+			setMetricsAnnotations(service, component)
+			propagateMetadata(platformAdmin, service)
+			protectMetadata(platformAdmin, service)
+			if err := r.setOwner(platformAdmin, service); err != nil {
+				return err
+			}
+			setIdentityLabels(platformAdmin, service, component.Name)
+			return nil
+		},
+	)
+
+	if err != nil {
+		return nil, util.ClassifyAPIError(err)
+	}
+	log.FromContext(ctx).V(4).Info("Reconciled service", "component", component.Name, "service", service.Name, "result", result)
+	recordOperationResult(kindService, result)
+	r.recordOperationEvent(platformAdmin, kindService, service.Name, result)
+	return service, nil
+}
+
+// desiredServicePorts defaults the ports of component like the apiserver does, so an unchanged service is not
+// updated, and keeps the node ports allocated to the existing ports.
+func desiredServicePorts(existing []corev1.ServicePort, desired []corev1.ServicePort) []corev1.ServicePort {
+	ports := make([]corev1.ServicePort, 0, len(desired))
+	for _, port := range desired {
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+		if port.NodePort == 0 {
+			for _, e := range existing {
+				if e.Name == port.Name && e.Port == port.Port {
+					port.NodePort = e.NodePort
 					break
 				}
 			}
-			if !flag {
-				yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, pool)
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// serviceTopologyValue returns the value of topology annotation for the service topology of component,
+// and an empty value means the annotation should be removed.
+func serviceTopologyValue(serviceTopology string) (string, error) {
+	switch serviceTopology {
+	case "", iotv1alpha2.ServiceTopologyNodePool:
+		return AnnotationServiceTopologyValueNodePool, nil
+	case iotv1alpha2.ServiceTopologyZone:
+		return AnnotationServiceTopologyValueZone, nil
+	case iotv1alpha2.ServiceTopologyNone:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown service topology %q", serviceTopology)
+	}
+}
+
+func (r *ReconcilePlatformAdmin) handlePodDisruptionBudget(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*policyv1.PodDisruptionBudget, error) {
+	// The PodDisruptionBudget is optional, and the component without deployment has no pods to protect.
+	// It is possible for pdb to be nil when there is no error!
+	if platformAdmin.Spec.PodDisruptionBudget == nil || component.Deployment == nil {
+		return nil, nil
+	}
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.Name,
+			Namespace: workloadNamespace(platformAdmin),
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(
+		ctx,
+		r.Client,
+		pdb,
+		func() error {
+			if pdb.Labels == nil {
+				pdb.Labels = make(map[string]string)
+			}
+			pdb.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelPodDisruptionBudget
+			propagateMetadata(platformAdmin, pdb)
+			pdb.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": component.Name},
 			}
-			if err := controllerutil.SetOwnerReference(platformAdmin, yas, r.Scheme()); err != nil {
-				return false, err
+			pdb.Spec.MinAvailable = platformAdmin.Spec.PodDisruptionBudget.MinAvailable
+			if err := r.setOwner(platformAdmin, pdb); err != nil {
+				return err
 			}
-			if err := r.Client.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
-				klog.Errorf(Format("Patch yurtappset %s/%s failed: %v", yas.Namespace, yas.Name, err))
-				return false, err
+			setIdentityLabels(platformAdmin, pdb, component.Name)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordOperationResult(kindPodDisruptionBudget, result)
+	return pdb, nil
+}
+
+func (r *ReconcilePlatformAdmin) handleNetworkPolicy(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*networkingv1.NetworkPolicy, error) {
+	// The NetworkPolicy is optional, and the component without service accepts no traffic.
+	// It is possible for networkPolicy to be nil when there is no error!
+	if !platformAdmin.Spec.NetworkPolicy || component.Service == nil {
+		return nil, nil
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.Name,
+			Namespace: workloadNamespace(platformAdmin),
+		},
+	}
+	result, err := controllerutil.CreateOrUpdate(
+		ctx,
+		r.Client,
+		networkPolicy,
+		func() error {
+			if networkPolicy.Labels == nil {
+				networkPolicy.Labels = make(map[string]string)
 			}
+			networkPolicy.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelNetworkPolicy
+			propagateMetadata(platformAdmin, networkPolicy)
+			networkPolicy.Spec = newNetworkPolicySpec(component)
+			if err := r.setOwner(platformAdmin, networkPolicy); err != nil {
+				return err
+			}
+			setIdentityLabels(platformAdmin, networkPolicy, component.Name)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordOperationResult(kindNetworkPolicy, result)
+	return networkPolicy, nil
+}
+
+// newNetworkPolicySpec only allows the ingress to the service ports of component from the pods
+// of PlatformAdmin components in the same namespace.
+func newNetworkPolicySpec(component *config.Component) networkingv1.NetworkPolicySpec {
+	var ports []networkingv1.NetworkPolicyPort
+	for _, servicePort := range component.Service.Ports {
+		protocol := servicePort.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		// The policy applies to pods, so the target port is used
+		port := servicePort.TargetPort
+		if port.Type == intstr.Int && port.IntVal == 0 {
+			port = intstr.FromInt(int(servicePort.Port))
+		}
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+
+	return networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": component.Name},
+		},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						PodSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: iotv1alpha2.LabelPlatformAdminGenerate, Operator: metav1.LabelSelectorOpExists},
+							},
+						},
+					},
+				},
+				Ports: ports,
+			},
+		},
+	}
+}
+
+// handleYurtAppSet creates the yurtappset of component with the pools of all PlatformAdmins sharing it.
+func (r *ReconcilePlatformAdmin) handleYurtAppSet(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, conf *config.PlatformAdminControllerConfiguration) (*appsv1alpha1.YurtAppSet, error) {
+	yas := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      make(map[string]string),
+			Annotations: make(map[string]string),
+			Name:        component.Name,
+			Namespace:   workloadNamespace(platformAdmin),
+		},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": component.Name},
+			},
+			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+				DeploymentTemplate: newDeploymentTemplate(component),
+			},
+		},
+	}
+
+	yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelDeployment
+	yas.Annotations[iotv1alpha2.AnnotationTemplateHash] = componentTemplateHash(component)
+	propagateMetadata(platformAdmin, yas)
+	protectMetadata(platformAdmin, yas)
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, newPool(platformAdmin, component, poolName))
+	}
+	if err := r.setController(platformAdmin, yas); err != nil {
+		return nil, err
+	}
+	setIdentityLabels(platformAdmin, yas, component.Name)
+	if err := r.restoreSharedPools(ctx, platformAdmin, component, conf, yas); err != nil {
+		return nil, err
+	}
+	if err := r.Create(ctx, yas); err != nil {
+		return nil, util.ClassifyAPIError(err)
+	}
+	log.FromContext(ctx).Info("Create YurtAppSet", "component", component.Name, "yurtappset", yas.Name, "pools", joinedPools(platformAdmin))
+	recordOperation(kindYurtAppSet, operationCreate)
+	r.recordOperationEvent(platformAdmin, kindYurtAppSet, yas.Name, controllerutil.OperationResultCreated)
+	return yas, nil
+}
+
+// isPoolReady checks whether the workload of the pool is ready, so the readiness of a PlatformAdmin
+// is not affected by other pools sharing the same yurtappset.
+func isPoolReady(yas *appsv1alpha1.YurtAppSet, poolName string) bool {
+	replicas, ok := yas.Status.PoolReplicas[poolName]
+	if !ok {
+		return false
+	}
+	if yas.Status.PoolReadyReplicas == nil {
+		// The per-pool ready replicas is not reported yet, the global one would count the pools of others
+		return false
+	}
+	return yas.Status.PoolReadyReplicas[poolName] == replicas
+}
+
+// newDeploymentTemplate generates the deployment template of yurtappset from the component.
+func newDeploymentTemplate(component *config.Component) *appsv1alpha1.DeploymentTemplateSpec {
+	template := &appsv1alpha1.DeploymentTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app":                                  component.Name,
+				iotv1alpha2.LabelPlatformAdminGenerate: LabelDeployment,
+			},
+		},
+		Spec: *component.Deployment.DeepCopy(),
+	}
+	// The labels of template are only copied to the deployments, so the pods are labeled by the pod template
+	if template.Spec.Template.Labels == nil {
+		template.Spec.Template.Labels = make(map[string]string)
+	}
+	template.Spec.Template.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelDeployment
+	if hasClaim(component) {
+		injectClaimVolume(&template.Spec.Template.Spec, component)
+	}
+	return template
+}
+
+// repairYurtAppSetDrift restores the fields of yurtappset owned by the controller and returns the paths of the
+// drifted ones: the generate label, the app label of selector and the app label of pod template, which the service
+// of component selects the pods by. The other fields, e.g. the annotations added by users, are left alone.
+// The generate label missing on a yurtappset not owned by PlatformAdmin yet is not a drift, it is being adopted.
+func repairYurtAppSetDrift(yas *appsv1alpha1.YurtAppSet, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) []string {
+	var drifted []string
+	if yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelDeployment {
+		if isOwnedBy(yas, platformAdmin) {
+			drifted = append(drifted, "metadata.labels")
+		}
+		if yas.Labels == nil {
+			yas.Labels = make(map[string]string)
+		}
+		// The adopted yurtappset is labeled, so it is managed like the generated ones from now on
+		yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelDeployment
+	}
+
+	if yas.Spec.Selector == nil {
+		yas.Spec.Selector = &metav1.LabelSelector{}
+	}
+	if yas.Spec.Selector.MatchLabels["app"] != component.Name {
+		drifted = append(drifted, "spec.selector.matchLabels")
+		if yas.Spec.Selector.MatchLabels == nil {
+			yas.Spec.Selector.MatchLabels = make(map[string]string)
+		}
+		yas.Spec.Selector.MatchLabels["app"] = component.Name
+	}
+
+	if template := yas.Spec.WorkloadTemplate.DeploymentTemplate; template != nil && template.Spec.Template.Labels["app"] != component.Name {
+		drifted = append(drifted, "spec.workloadTemplate.deploymentTemplate.spec.template.metadata.labels")
+		if template.Spec.Template.Labels == nil {
+			template.Spec.Template.Labels = make(map[string]string)
+		}
+		template.Spec.Template.Labels["app"] = component.Name
+	}
+	return drifted
+}
+
+// isTemplateLabeled checks whether the pod template of workload carries the generate label,
+// which the NetworkPolicy of components selects the peers by.
+func isTemplateLabeled(template *appsv1alpha1.DeploymentTemplateSpec) bool {
+	if template == nil {
+		return false
+	}
+	_, ok := template.Spec.Template.Labels[iotv1alpha2.LabelPlatformAdminGenerate]
+	return ok
+}
+
+// isAdoptable checks whether the existing workload can be managed by the PlatformAdmin, only the workloads
+// generated by PlatformAdmin or explicitly annotated as adoptable are adopted.
+func isAdoptable(obj metav1.Object, platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	if _, ok := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]; ok {
+		return true
+	}
+	if obj.GetAnnotations()[iotv1alpha2.AnnotationAdoptable] == "true" {
+		return true
+	}
+	return isOwnedBy(obj, platformAdmin)
+}
+
+// isOwnedBy checks whether the object is owned by the PlatformAdmin.
+func isOwnedBy(obj metav1.Object, platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	if isCrossNamespace(platformAdmin) {
+		return obj.GetLabels()[iotv1alpha2.LabelPlatformAdmin] == platformAdminLabelValue(platformAdmin)
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == platformAdmin.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// newPool generates the pool of PlatformAdmin with the given name in the topology of yurtappset, the supported architectures of
+// component, the extra node selector requirements and tolerations of PlatformAdmin are appended to the pool. The pool of a component with
+// persistent volume claim is patched to use the claim of the pool.
+func newPool(platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, poolName string) appsv1alpha1.Pool {
+	pool := appsv1alpha1.Pool{
+		Name:     poolName,
+		Replicas: pointer.Int32Ptr(componentReplicas(component)),
+	}
+	pool.NodeSelectorTerm.MatchExpressions = append(pool.NodeSelectorTerm.MatchExpressions,
+		corev1.NodeSelectorRequirement{
+			Key:      appsv1alpha1.LabelCurrentNodePool,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{poolName},
+		})
+	if len(component.SupportedArchitectures) > 0 {
+		architectures := append([]string(nil), component.SupportedArchitectures...)
+		sort.Strings(architectures)
+		pool.NodeSelectorTerm.MatchExpressions = append(pool.NodeSelectorTerm.MatchExpressions,
+			corev1.NodeSelectorRequirement{
+				Key:      corev1.LabelArchStable,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   architectures,
+			})
+	}
+	for i := range platformAdmin.Spec.NodeSelectorRequirements {
+		pool.NodeSelectorTerm.MatchExpressions = append(pool.NodeSelectorTerm.MatchExpressions,
+			*platformAdmin.Spec.NodeSelectorRequirements[i].DeepCopy())
+	}
+	for i := range platformAdmin.Spec.Tolerations {
+		pool.Tolerations = append(pool.Tolerations, *platformAdmin.Spec.Tolerations[i].DeepCopy())
+	}
+	if hasClaim(component) {
+		pool.Patch = newClaimPatch(component, poolName)
+	}
+	return pool
+}
+
+// joinedPools returns the pools of PlatformAdmin joined by commas, for the logs and messages.
+func joinedPools(platformAdmin *iotv1alpha2.PlatformAdmin) string {
+	return strings.Join(util.PlatformAdminPools(platformAdmin), ",")
+}
+
+// componentReplicas returns the replicas of component in a pool, which defaults to 1. The replicas of an autoscaled
+// component follow its HorizontalPodAutoscaler after the pool is created.
+func componentReplicas(component *config.Component) int32 {
+	if component.Replicas == nil || isAutoscaled(component) {
+		return 1
+	}
+	return *component.Replicas
+}
+
+// syncPoolReplicas sets the replicas of component to the existing pools of PlatformAdmin, so the pools are scaled once
+// the replicas are changed. The replicas of an autoscaled component are left to followAutoscaler.
+func syncPoolReplicas(platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, yas *appsv1alpha1.YurtAppSet) {
+	if isAutoscaled(component) {
+		return
+	}
+	desired := componentReplicas(component)
+	pools := sets.NewString(util.PlatformAdminPools(platformAdmin)...)
+	for i := range yas.Spec.Topology.Pools {
+		pool := &yas.Spec.Topology.Pools[i]
+		if pools.Has(pool.Name) && (pool.Replicas == nil || *pool.Replicas != desired) {
+			pool.Replicas = pointer.Int32Ptr(desired)
 		}
 	}
+}
 
-	// Remove the service owner that we do not need
-	servicelist := &corev1.ServiceList{}
-	if err := r.List(ctx, servicelist, client.InNamespace(platformAdmin.Namespace), client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}); err == nil {
-		for _, s := range servicelist.Items {
-			if _, ok := needComponents[s.Name]; !ok {
-				r.removeOwner(ctx, platformAdmin, &s)
+// ensurePools checks whether the pools of PlatformAdmin in yurtappset match the desired ones, and returns the pools
+// which are missing. The node selector term and tolerations of an existing pool are immutable(see the yurtappset webhook),
+// so the outdated pools are removed first and the caller is expected to append the missing pools again.
+// The pool is recreated for the changed patch too, which only happens when the claim of component is changed.
+func (r *ReconcilePlatformAdmin) ensurePools(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, yas *appsv1alpha1.YurtAppSet) ([]string, error) {
+	var missing, outdated []string
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		desired := newPool(platformAdmin, component, poolName)
+		found := false
+		for _, pool := range yas.Spec.Topology.Pools {
+			if pool.Name != desired.Name {
+				continue
 			}
+			found = true
+			if !reflect.DeepEqual(pool.NodeSelectorTerm, desired.NodeSelectorTerm) || !reflect.DeepEqual(pool.Tolerations, desired.Tolerations) ||
+				!isPatchEqual(pool.Patch, desired.Patch) {
+				outdated = append(outdated, poolName)
+			}
+			break
+		}
+		if !found {
+			missing = append(missing, poolName)
+		}
+	}
+	if len(outdated) > 0 {
+		log.FromContext(ctx).Info("Recreate pools of YurtAppSet for the node selector term, tolerations or patch changed", "yurtappset", yas.Name, "pools", outdated)
+		if err := r.removePools(ctx, yas, outdated); err != nil {
+			return nil, err
 		}
 	}
+	return append(missing, outdated...), nil
+}
 
-	// Remove the yurtappset owner that we do not need
-	yurtappsetlist := &appsv1alpha1.YurtAppSetList{}
-	if err := r.List(ctx, yurtappsetlist, client.InNamespace(platformAdmin.Namespace), client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: LabelDeployment}); err == nil {
-		for _, s := range yurtappsetlist.Items {
-			if _, ok := needComponents[s.Name]; !ok {
-				r.removeOwner(ctx, platformAdmin, &s)
-			}
+// removePools removes the given pools from the topology of yurtappset.
+func (r *ReconcilePlatformAdmin) removePools(ctx context.Context, yas *appsv1alpha1.YurtAppSet, poolNames []string) error {
+	oldYas := yas.DeepCopy()
+
+	removed := sets.NewString(poolNames...)
+	var pools []appsv1alpha1.Pool
+	for _, pool := range yas.Spec.Topology.Pools {
+		if !removed.Has(pool.Name) {
+			pools = append(pools, pool)
 		}
 	}
+	if len(pools) == len(oldYas.Spec.Topology.Pools) {
+		return nil
+	}
 
-	return readyComponent == int32(len(desireComponents)), nil
+	yas.Spec.Topology.Pools = pools
+	if err := r.Client.Patch(ctx, yas, client.MergeFrom(oldYas)); err != nil {
+		return err
+	}
+	recordOperation(kindYurtAppSet, operationPatch)
+	return nil
 }
 
-func (r *ReconcilePlatformAdmin) handleService(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*corev1.Service, error) {
-	// It is possible that the component does not need service.
-	// Therefore, you need to be careful when calling this function.
-	// It is still possible for service to be nil when there is no error!
-	if component.Service == nil {
-		return nil, nil
+// isDaemonComponent checks whether the component is deployed with a yurtappdaemon.
+func isDaemonComponent(component *config.Component) bool {
+	return component.WorkloadType == iotv1alpha2.WorkloadTypeDaemonSet
+}
+
+// reconcileYurtAppDaemon creates or updates the yurtappdaemon of component, and returns the reason
+// why the component is not ready, an empty reason means the component is ready.
+func (r *ReconcilePlatformAdmin) reconcileYurtAppDaemon(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (string, error) {
+	logger := log.FromContext(ctx)
+	// The yurtappdaemon selects nodepools by labels, so the nodepools of PlatformAdmin are labeled first
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		if err := r.labelNodePool(ctx, poolName); err != nil {
+			return "", err
+		}
 	}
 
-	service := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels:      make(map[string]string),
-			Annotations: make(map[string]string),
-			Name:        component.Name,
-			Namespace:   platformAdmin.Namespace,
-		},
-		Spec: *component.Service,
+	yad := &appsv1alpha1.YurtAppDaemon{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: component.Name}, yad); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		if _, err := r.handleYurtAppDaemon(ctx, platformAdmin, component); err != nil {
+			return "", classifyComponentError(component.Name, err)
+		}
+		return iotv1alpha2.DeploymentNotReadyReason, nil
+	}
+	if !isAdoptable(yad, platformAdmin) {
+		logger.Info("YurtAppDaemon is not generated by PlatformAdmin, skip it", "component", component.Name, "yurtappdaemon", yad.Name)
+		return iotv1alpha2.ComponentConflictReason, nil
 	}
-	service.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelService
-	service.Annotations[AnnotationServiceTopologyKey] = AnnotationServiceTopologyValueNodePool
 
-	_, err := controllerutil.CreateOrUpdate(
-		ctx,
-		r.Client,
-		service,
-		func() error {
-			return controllerutil.SetOwnerReference(platformAdmin, service, r.Scheme())
-		},
-	)
+	oldYad := yad.DeepCopy()
+	if yad.Labels == nil {
+		yad.Labels = make(map[string]string)
+	}
+	yad.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelYurtAppDaemon
+	templateHash := util.ComputeTemplateHash(component.Deployment)
+	upToDate := yad.Annotations[iotv1alpha2.AnnotationTemplateHash] == templateHash
+	if platformAdmin.Spec.NetworkPolicy && !isTemplateLabeled(yad.Spec.WorkloadTemplate.DeploymentTemplate) {
+		upToDate = false
+	}
+	if !upToDate {
+		logger.Info("Update the workload template of YurtAppDaemon", "component", component.Name, "yurtappdaemon", yad.Name)
+		yad.Spec.WorkloadTemplate.DeploymentTemplate = newDeploymentTemplate(component)
+		if yad.Annotations == nil {
+			yad.Annotations = make(map[string]string)
+		}
+		yad.Annotations[iotv1alpha2.AnnotationTemplateHash] = templateHash
+	}
+
+	pools := sets.NewString(selectedPools(yad)...)
+	poolUpToDate := pools.HasAll(util.PlatformAdminPools(platformAdmin)...)
+	if !poolUpToDate {
+		setSelectedPools(yad, pools.Insert(util.PlatformAdminPools(platformAdmin)...).List())
+	}
+	propagateMetadata(platformAdmin, yad)
+	if !isOwnedBy(yad, platformAdmin) {
+		if err := r.setOwner(platformAdmin, yad); err != nil {
+			return "", err
+		}
+	}
+	if !reflect.DeepEqual(oldYad, yad) {
+		if err := r.Client.Patch(ctx, yad, client.MergeFrom(oldYad)); err != nil {
+			logger.Error(err, "Patch YurtAppDaemon failed", "component", component.Name, "yurtappdaemon", yad.Name)
+			return "", classifyComponentError(component.Name, err)
+		}
+		recordOperation(kindYurtAppDaemon, operationPatch)
+	}
 
+	// The yurtappdaemon reports no replicas, the workload of the pool is considered ready once the pool is selected
+	if !upToDate || !poolUpToDate || yad.Status.ObservedGeneration != yad.Generation ||
+		!sets.NewString(yad.Status.NodePools...).HasAll(util.PlatformAdminPools(platformAdmin)...) {
+		return iotv1alpha2.DeploymentNotReadyReason, nil
+	}
+	readyService, err := r.isServiceReady(ctx, platformAdmin, component)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return service, nil
+	if !readyService {
+		return iotv1alpha2.EndpointsNotReadyReason, nil
+	}
+	return "", nil
 }
 
-func (r *ReconcilePlatformAdmin) handleYurtAppSet(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*appsv1alpha1.YurtAppSet, error) {
-	yas := &appsv1alpha1.YurtAppSet{
+func (r *ReconcilePlatformAdmin) handleYurtAppDaemon(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*appsv1alpha1.YurtAppDaemon, error) {
+	yad := &appsv1alpha1.YurtAppDaemon{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels:      make(map[string]string),
 			Annotations: make(map[string]string),
 			Name:        component.Name,
-			Namespace:   platformAdmin.Namespace,
+			Namespace:   workloadNamespace(platformAdmin),
 		},
-		Spec: appsv1alpha1.YurtAppSetSpec{
+		Spec: appsv1alpha1.YurtAppDaemonSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{"app": component.Name},
 			},
 			WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
-				DeploymentTemplate: &appsv1alpha1.DeploymentTemplateSpec{
-					ObjectMeta: metav1.ObjectMeta{
-						Labels: map[string]string{"app": component.Name},
-					},
-					Spec: *component.Deployment,
-				},
+				DeploymentTemplate: newDeploymentTemplate(component),
 			},
 		},
 	}
 
-	yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelDeployment
-	pool := appsv1alpha1.Pool{
-		Name:     platformAdmin.Spec.PoolName,
-		Replicas: pointer.Int32Ptr(1),
-	}
-	pool.NodeSelectorTerm.MatchExpressions = append(pool.NodeSelectorTerm.MatchExpressions,
-		corev1.NodeSelectorRequirement{
-			Key:      appsv1alpha1.LabelCurrentNodePool,
-			Operator: corev1.NodeSelectorOpIn,
-			Values:   []string{platformAdmin.Spec.PoolName},
-		})
-	yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, pool)
-	if err := controllerutil.SetControllerReference(platformAdmin, yas, r.Scheme()); err != nil {
+	yad.Labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelYurtAppDaemon
+	yad.Annotations[iotv1alpha2.AnnotationTemplateHash] = util.ComputeTemplateHash(component.Deployment)
+	propagateMetadata(platformAdmin, yad)
+	setSelectedPools(yad, sets.NewString(util.PlatformAdminPools(platformAdmin)...).List())
+	if err := r.setController(platformAdmin, yad); err != nil {
 		return nil, err
 	}
-	if err := r.Create(ctx, yas); err != nil {
+	if err := r.Create(ctx, yad); err != nil {
 		return nil, err
 	}
-	return yas, nil
+	recordOperation(kindYurtAppDaemon, operationCreate)
+	return yad, nil
+}
+
+// labelNodePool labels the nodepool with its name, which the nodepool selector of yurtappdaemon matches.
+func (r *ReconcilePlatformAdmin) labelNodePool(ctx context.Context, poolName string) error {
+	nodePool := &appsv1alpha1.NodePool{}
+	if err := r.Get(ctx, types.NamespacedName{Name: poolName}, nodePool); err != nil {
+		return err
+	}
+	if nodePool.Labels[appsv1alpha1.LabelCurrentNodePool] == nodePool.Name {
+		return nil
+	}
+	oldNodePool := nodePool.DeepCopy()
+	metav1.SetMetaDataLabel(&nodePool.ObjectMeta, appsv1alpha1.LabelCurrentNodePool, nodePool.Name)
+	return r.Patch(ctx, nodePool, client.MergeFrom(oldNodePool))
+}
+
+// selectedPools returns the pools selected by the nodepool selector of yurtappdaemon.
+func selectedPools(yad *appsv1alpha1.YurtAppDaemon) []string {
+	if yad.Spec.NodePoolSelector == nil {
+		return nil
+	}
+	for _, requirement := range yad.Spec.NodePoolSelector.MatchExpressions {
+		if requirement.Key == appsv1alpha1.LabelCurrentNodePool && requirement.Operator == metav1.LabelSelectorOpIn {
+			return requirement.Values
+		}
+	}
+	return nil
+}
+
+// setSelectedPools sets the pools selected by the nodepool selector of yurtappdaemon, the other
+// requirements of an adopted yurtappdaemon are kept.
+func setSelectedPools(yad *appsv1alpha1.YurtAppDaemon, pools []string) {
+	if yad.Spec.NodePoolSelector == nil {
+		yad.Spec.NodePoolSelector = &metav1.LabelSelector{}
+	}
+	selector := yad.Spec.NodePoolSelector
+	for i := range selector.MatchExpressions {
+		requirement := &selector.MatchExpressions[i]
+		if requirement.Key == appsv1alpha1.LabelCurrentNodePool && requirement.Operator == metav1.LabelSelectorOpIn {
+			requirement.Values = pools
+			return
+		}
+	}
+	selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      appsv1alpha1.LabelCurrentNodePool,
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   pools,
+	})
+}
+
+// removeDaemonPools removes the given pools from the nodepool selector of yurtappdaemon.
+func (r *ReconcilePlatformAdmin) removeDaemonPools(ctx context.Context, yad *appsv1alpha1.YurtAppDaemon, poolNames []string) error {
+	oldPools := selectedPools(yad)
+	removed := sets.NewString(poolNames...)
+	if !removed.HasAny(oldPools...) {
+		return nil
+	}
+
+	oldYad := yad.DeepCopy()
+	var pools []string
+	for _, pool := range oldPools {
+		if !removed.Has(pool) {
+			pools = append(pools, pool)
+		}
+	}
+	setSelectedPools(yad, pools)
+	if err := r.Client.Patch(ctx, yad, client.MergeFrom(oldYad)); err != nil {
+		return err
+	}
+	recordOperation(kindYurtAppDaemon, operationPatch)
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
+// removeOwner removes the owner reference of PlatformAdmin from the object with a merge patch, and retries on conflict.
+// If the removed reference is the controller reference, one of the remaining owners is promoted to controller.
+// When no owner is left, the object exclusively managed by PlatformAdmin controller is deleted, or is orphaned by
+// removing the generate label if the deletion policy of PlatformAdmin is Orphan.
 func (r *ReconcilePlatformAdmin) removeOwner(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
-	owners := obj.GetOwnerReferences()
+	first := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			// Refresh the object, since the last patch is rejected for a stale resource version
+			if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+		}
+		first = false
 
-	for i, owner := range owners {
-		if owner.UID == platformAdmin.UID {
-			owners[i] = owners[len(owners)-1]
-			owners = owners[:len(owners)-1]
+		if isCrossNamespace(platformAdmin) {
+			return r.removeOwnerLabel(ctx, platformAdmin, obj)
+		}
 
-			if len(owners) == 0 {
-				return r.Delete(ctx, obj)
-			} else {
-				obj.SetOwnerReferences(owners)
-				return r.Update(ctx, obj)
+		var owners []metav1.OwnerReference
+		var removed *metav1.OwnerReference
+		for i, owner := range obj.GetOwnerReferences() {
+			if owner.UID == platformAdmin.UID {
+				removed = &obj.GetOwnerReferences()[i]
+				continue
+			}
+			owners = append(owners, owner)
+		}
+		if removed == nil {
+			return nil
+		}
+
+		oldObj := obj.DeepCopyObject().(client.Object)
+		orphaned := false
+		if len(owners) == 0 {
+			if _, ok := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]; ok {
+				if !isOrphan(platformAdmin) {
+					return r.deleteReleased(ctx, platformAdmin, obj)
+				}
+				orphaned = true
+				removeLabels(obj, iotv1alpha2.LabelPlatformAdminGenerate, iotv1alpha2.LabelPlatformAdminName,
+					iotv1alpha2.LabelPlatformAdminNamespace, iotv1alpha2.LabelComponent)
+			}
+		} else {
+			// The name label is set again by the reconcile of the remaining owner
+			removeLabels(obj, iotv1alpha2.LabelPlatformAdminName)
+			if removed.Controller != nil && *removed.Controller {
+				// Promote a remaining owner, so the object is not left without controller
+				owners[0].Controller = pointer.BoolPtr(true)
 			}
 		}
+
+		obj.SetOwnerReferences(owners)
+		if err := r.Patch(ctx, obj, client.MergeFromWithOptions(oldObj, client.MergeFromWithOptimisticLock{})); err != nil {
+			return err
+		}
+		recordOperation(resourceKind(obj), operationPatch)
+		if orphaned {
+			r.recordCleanup(platformAdmin, obj)
+		}
+		return nil
+	})
+}
+
+// removeOwnerLabel is the removeOwner of the objects generated into another namespace, there is only one owner
+// recorded by the label, so the generated object is deleted or orphaned by the deletion policy.
+func (r *ReconcilePlatformAdmin) removeOwnerLabel(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
+	if !isOwnedBy(obj, platformAdmin) {
+		return nil
+	}
+	_, generated := obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate]
+	if generated && !isOrphan(platformAdmin) {
+		return r.deleteReleased(ctx, platformAdmin, obj)
+	}
+
+	oldObj := obj.DeepCopyObject().(client.Object)
+	removeLabels(obj, iotv1alpha2.LabelPlatformAdmin, iotv1alpha2.LabelPlatformAdminGenerate, iotv1alpha2.LabelPlatformAdminName,
+		iotv1alpha2.LabelPlatformAdminNamespace, iotv1alpha2.LabelComponent)
+	if err := r.Patch(ctx, obj, client.MergeFromWithOptions(oldObj, client.MergeFromWithOptimisticLock{})); err != nil {
+		return err
 	}
+	recordOperation(resourceKind(obj), operationPatch)
+	if generated {
+		r.recordCleanup(platformAdmin, obj)
+	}
+	return nil
+}
+
+// deleteReleased deletes the object released by PlatformAdmin.
+func (r *ReconcilePlatformAdmin) deleteReleased(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
+	if err := r.Delete(ctx, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	recordOperation(resourceKind(obj), operationDelete)
+	r.recordCleanup(platformAdmin, obj)
 	return nil
 }
 
+// desiredComponents returns the components to deploy, which are the assembled components except the disabled ones.
+func desiredComponents(platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration, additional *corev1.ConfigMap) ([]*config.Component, error) {
+	components, err := assembleComponents(platformAdmin, conf, additional)
+	if err != nil {
+		return nil, err
+	}
+	return filterDisabledComponents(platformAdmin, components), nil
+}
+
+// assembleComponents assembles the components of the PlatformAdmin version and the additional components from annotation
+// and from the referenced configmap, which is nil if PlatformAdmin does not refer to one.
+func assembleComponents(platformAdmin *iotv1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration, additional *corev1.ConfigMap) ([]*config.Component, error) {
+	var components []*config.Component
+	if platformAdmin.Spec.Security {
+		components = append(components, conf.SecurityComponents[platformAdmin.Spec.Version]...)
+	} else {
+		components = append(components, conf.NoSectyComponents[platformAdmin.Spec.Version]...)
+	}
+
+	additions, err := additionalComponents(platformAdmin.Annotations, additional)
+	if err != nil {
+		return nil, err
+	}
+	components = append(components, additions...)
+	components = messageBusComponents(platformAdmin, components)
+
+	//TODO: handle the image of PlatformAdmin.Spec.Components
+	// The overrides below change the deployment of components, so the template hash follows and the existing
+	// workloads are rolled with them.
+	components = overrideComponents(platformAdmin, components)
+	components = applyComponentEnv(platformAdmin, components)
+	components = applyImageRegistry(platformAdmin, components)
+	components = applyImagePullSecrets(platformAdmin, components)
+	components = applyProbes(components)
+	components = applyUpdateStrategies(components)
+	components = applyScheme(platformAdmin, components)
+
+	return components, nil
+}
+
+// filterDisabledComponents removes the components in PlatformAdmin.Spec.DisabledComponents. The objects of components
+// disabled after deployment are no longer needed, so they are cleaned up like the removed additional components.
+func filterDisabledComponents(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	if len(platformAdmin.Spec.DisabledComponents) == 0 {
+		return components
+	}
+
+	disabled := sets.NewString(platformAdmin.Spec.DisabledComponents...)
+	result := make([]*config.Component, 0, len(components))
+	for _, component := range components {
+		if disabled.Has(component.Name) {
+			continue
+		}
+		result = append(result, component)
+	}
+	return result
+}
+
+// overrideComponents applies the service topology, workload type, probes, update strategy, autoscaling and replicas set in
+// PlatformAdmin.Spec.Components.
+// The components of configuration are shared by all PlatformAdmins, so the overridden ones are copied.
+func overrideComponents(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	overrides := make(map[string]iotv1alpha2.Component)
+	for _, c := range platformAdmin.Spec.Components {
+		if c.ServiceTopology != "" || c.WorkloadType != "" || c.LivenessProbe != nil || c.ReadinessProbe != nil || c.StartupProbe != nil ||
+			c.UpdateStrategy != nil || c.Autoscaling != nil || c.Replicas != nil {
+			overrides[c.Name] = c
+		}
+	}
+	if len(overrides) == 0 {
+		return components
+	}
+
+	for i, component := range components {
+		override, ok := overrides[component.Name]
+		if !ok {
+			continue
+		}
+		overridden := *component
+		if override.ServiceTopology != "" {
+			overridden.ServiceTopology = override.ServiceTopology
+		}
+		if override.WorkloadType != "" {
+			overridden.WorkloadType = override.WorkloadType
+		}
+		if override.LivenessProbe != nil {
+			overridden.LivenessProbe = override.LivenessProbe
+		}
+		if override.ReadinessProbe != nil {
+			overridden.ReadinessProbe = override.ReadinessProbe
+		}
+		if override.StartupProbe != nil {
+			overridden.StartupProbe = override.StartupProbe
+		}
+		if override.UpdateStrategy != nil {
+			overridden.UpdateStrategy = override.UpdateStrategy
+		}
+		if override.Autoscaling != nil {
+			overridden.Autoscaling = override.Autoscaling
+		}
+		if override.Replicas != nil {
+			overridden.Replicas = override.Replicas
+		}
+		components[i] = &overridden
+	}
+	return components
+}
+
+// copyDeployment returns a copy of component whose deployment can be changed, the components of configuration
+// are shared by all PlatformAdmins.
+func copyDeployment(component *config.Component) *config.Component {
+	overridden := *component
+	overridden.Deployment = component.Deployment.DeepCopy()
+	return &overridden
+}
+
+// applyComponentEnv merges the env vars of PlatformAdmin.Spec.ComponentEnv into every container of the
+// components, the env vars of user win over the ones of template with the same name.
+func applyComponentEnv(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	if len(platformAdmin.Spec.ComponentEnv) == 0 {
+		return components
+	}
+
+	for i, component := range components {
+		env, ok := platformAdmin.Spec.ComponentEnv[component.Name]
+		if !ok || len(env) == 0 || component.Deployment == nil {
+			continue
+		}
+		overridden := copyDeployment(component)
+		containers := overridden.Deployment.Template.Spec.Containers
+		for j := range containers {
+			containers[j].Env = mergeEnv(containers[j].Env, env)
+		}
+		components[i] = overridden
+	}
+	return components
+}
+
+// applyImagePullSecrets appends the image pull secrets of PlatformAdmin to the deployment of components, the ones
+// already listed by the template are skipped.
+func applyImagePullSecrets(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	if len(platformAdmin.Spec.ImagePullSecrets) == 0 {
+		return components
+	}
+
+	for i, component := range components {
+		if component.Deployment == nil {
+			continue
+		}
+		overridden := copyDeployment(component)
+		podSpec := &overridden.Deployment.Template.Spec
+		listed := sets.NewString()
+		for _, secret := range podSpec.ImagePullSecrets {
+			listed.Insert(secret.Name)
+		}
+		for _, secret := range platformAdmin.Spec.ImagePullSecrets {
+			if listed.Has(secret.Name) {
+				continue
+			}
+			listed.Insert(secret.Name)
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, secret)
+		}
+		components[i] = overridden
+	}
+	return components
+}
+
+// applyProbes sets the probe overrides of components to the first container of deployment, a nil override
+// leaves the probe of template untouched and an empty one removes it.
+func applyProbes(components []*config.Component) []*config.Component {
+	for i, component := range components {
+		if component.Deployment == nil || len(component.Deployment.Template.Spec.Containers) == 0 {
+			continue
+		}
+		if component.LivenessProbe == nil && component.ReadinessProbe == nil && component.StartupProbe == nil {
+			continue
+		}
+		overridden := copyDeployment(component)
+		container := &overridden.Deployment.Template.Spec.Containers[0]
+		container.LivenessProbe = overrideProbe(container.LivenessProbe, component.LivenessProbe)
+		container.ReadinessProbe = overrideProbe(container.ReadinessProbe, component.ReadinessProbe)
+		container.StartupProbe = overrideProbe(container.StartupProbe, component.StartupProbe)
+		components[i] = overridden
+	}
+	return components
+}
+
+// applyUpdateStrategies sets the update strategy overrides of components to the deployment.
+func applyUpdateStrategies(components []*config.Component) []*config.Component {
+	for i, component := range components {
+		if component.Deployment == nil || component.UpdateStrategy == nil {
+			continue
+		}
+		overridden := copyDeployment(component)
+		overridden.Deployment.Strategy = *component.UpdateStrategy.DeepCopy()
+		components[i] = overridden
+	}
+	return components
+}
+
+// overrideProbe returns the probe of container after the override is applied.
+func overrideProbe(probe *corev1.Probe, override *corev1.Probe) *corev1.Probe {
+	switch {
+	case override == nil:
+		return probe
+	case reflect.DeepEqual(*override, corev1.Probe{}):
+		return nil
+	default:
+		return override.DeepCopy()
+	}
+}
+
+// mergeEnv overrides the env vars with the same name in place, and appends the others in order.
+func mergeEnv(env []corev1.EnvVar, overrides []corev1.EnvVar) []corev1.EnvVar {
+	for _, override := range overrides {
+		found := false
+		for i := range env {
+			if env[i].Name == override.Name {
+				env[i] = *override.DeepCopy()
+				found = true
+				break
+			}
+		}
+		if !found {
+			env = append(env, *override.DeepCopy())
+		}
+	}
+	return env
+}
+
+// unknownComponents returns the names in PlatformAdmin.Spec.ComponentEnv and PlatformAdmin.Spec.DisabledComponents
+// which match none of the components, the components should be the ones before the disabled ones are filtered out.
+func unknownComponents(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []string {
+	names := sets.NewString()
+	for _, component := range components {
+		names.Insert(component.Name)
+	}
+	unknown := sets.NewString()
+	for name := range platformAdmin.Spec.ComponentEnv {
+		if !names.Has(name) {
+			unknown.Insert(name)
+		}
+	}
+	for _, name := range platformAdmin.Spec.DisabledComponents {
+		if !names.Has(name) {
+			unknown.Insert(name)
+		}
+	}
+	return unknown.List()
+}
+
 // For version compatibility, v1alpha1's additionalservice and additionaldeployment are placed in
 // v2alpha2's annotation, this function is to convert the annotation to component.
 func annotationToComponent(annotation map[string]string) ([]*config.Component, error) {
-	var components []*config.Component = []*config.Component{}
+	components, err := additionalComponents(annotation, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ValidateComponents(components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// additionalComponents converts the additional deployments and services of the annotations and of the configmap
+// referenced by PlatformAdmin.Spec.AdditionalComponentsRef to components, the configmap is optional.
+func additionalComponents(annotation map[string]string, configmap *corev1.ConfigMap) ([]*config.Component, error) {
 	var additionalDeployments []iotv1alpha1.DeploymentTemplateSpec = make([]iotv1alpha1.DeploymentTemplateSpec, 0)
-	if _, ok := annotation["AdditionalDeployments"]; ok {
-		err := json.Unmarshal([]byte(annotation["AdditionalDeployments"]), &additionalDeployments)
-		if err != nil {
+	if data, ok := annotation[iotv1alpha1.AnnotationAdditionalDeployments]; ok {
+		var err error
+		if additionalDeployments, err = iotv1alpha1.DecodeAdditionalDeployments(data); err != nil {
 			return nil, err
 		}
 	}
 	var additionalServices []iotv1alpha1.ServiceTemplateSpec = make([]iotv1alpha1.ServiceTemplateSpec, 0)
-	if _, ok := annotation["AdditionalServices"]; ok {
-		err := json.Unmarshal([]byte(annotation["AdditionalServices"]), &additionalServices)
+	if data, ok := annotation[iotv1alpha1.AnnotationAdditionalServices]; ok {
+		var err error
+		if additionalServices, err = iotv1alpha1.DecodeAdditionalServices(data); err != nil {
+			return nil, err
+		}
+	}
+	if configmap != nil {
+		deployments, services, err := configMapToTemplates(configmap)
 		if err != nil {
 			return nil, err
 		}
+		additionalDeployments = append(additionalDeployments, deployments...)
+		additionalServices = append(additionalServices, services...)
 	}
+	return templatesToComponents(additionalDeployments, additionalServices)
+}
+
+// templatesToComponents pairs the additional deployments and services by name, the duplicate names are rejected.
+func templatesToComponents(additionalDeployments []iotv1alpha1.DeploymentTemplateSpec, additionalServices []iotv1alpha1.ServiceTemplateSpec) ([]*config.Component, error) {
+	var components []*config.Component = []*config.Component{}
 	if len(additionalDeployments) == 0 && len(additionalServices) == 0 {
 		return components, nil
 	}
 	var services map[string]*corev1.ServiceSpec = make(map[string]*corev1.ServiceSpec)
 	var usedServices map[string]struct{} = make(map[string]struct{})
-	for _, additionalservice := range additionalServices {
-		services[additionalservice.Name] = &additionalservice.Spec
-	}
-	for _, additionalDeployment := range additionalDeployments {
-		var component config.Component
-		component.Name = additionalDeployment.Name
-		component.Deployment = &additionalDeployment.Spec
-		service, ok := services[component.Name]
-		if ok {
+	for i := range additionalServices {
+		if _, ok := services[additionalServices[i].Name]; ok {
+			return nil, fmt.Errorf("duplicate additional service %s", additionalServices[i].Name)
+		}
+		services[additionalServices[i].Name] = &additionalServices[i].Spec
+	}
+	deployments := make(map[string]struct{}, len(additionalDeployments))
+	for i := range additionalDeployments {
+		additionalDeployment := &additionalDeployments[i]
+		if _, ok := deployments[additionalDeployment.Name]; ok {
+			return nil, fmt.Errorf("duplicate additional deployment %s", additionalDeployment.Name)
+		}
+		deployments[additionalDeployment.Name] = struct{}{}
+
+		component := config.NewComponent(additionalDeployment.Name, config.WithDeployment(&additionalDeployment.Spec))
+		if service, ok := services[component.Name]; ok {
 			component.Service = service
 			usedServices[component.Name] = struct{}{}
 		}
-		components = append(components, &component)
+		components = append(components, component)
 	}
 	if len(usedServices) < len(services) {
 		for name, service := range services {
@@ -591,12 +2981,17 @@ func annotationToComponent(annotation map[string]string) ([]*config.Component, e
 			if ok {
 				continue
 			}
-			var component config.Component
-			component.Name = name
-			component.Service = service
-			components = append(components, &component)
+			components = append(components, config.NewComponent(name, config.WithService(service)))
 		}
 	}
 
+	if err := config.ValidateAdditionalComponentsCount(len(components)); err != nil {
+		return nil, err
+	}
+
+	// The components are sorted, so the desired components are stable across reconciles
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
 	return components, nil
 }
@@ -0,0 +1,3631 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openyurtio/openyurt/pkg/apis"
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+const (
+	testVersion   = "levski"
+	testComponent = "edgex-core-command"
+	testImage     = "edgexfoundry/core-command:2.3.0"
+)
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apis.AddToScheme(scheme)
+	return scheme
+}
+
+func newTestReconciler(conf *config.PlatformAdminControllerConfiguration, objs ...client.Object) *ReconcilePlatformAdmin {
+	scheme := newTestScheme()
+	r := &ReconcilePlatformAdmin{
+		Client:             newApplyClient(fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()),
+		scheme:             scheme,
+		recorder:           record.NewFakeRecorder(100),
+		frameworkNamespace: "kube-system",
+		summary:            newSummaryRegistry(),
+	}
+	r.configuration.Store(conf)
+	return r
+}
+
+func newTestComponent(name, image string) *config.Component {
+	return &config.Component{
+		Name: name,
+		Service: &corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 59882},
+			},
+			Selector: map[string]string{"app": name},
+		},
+		Deployment: &appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: name, Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func newTestConfiguration(components ...*config.Component) *config.PlatformAdminControllerConfiguration {
+	return &config.PlatformAdminControllerConfiguration{
+		SecurityComponents: map[string][]*config.Component{testVersion: components},
+		NoSectyComponents:  map[string][]*config.Component{testVersion: components},
+		SecurityConfigMaps: map[string][]corev1.ConfigMap{
+			testVersion: {{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}, Data: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true"}}},
+		},
+		NoSectyConfigMaps: map[string][]corev1.ConfigMap{
+			testVersion: {{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}, Data: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false"}}},
+		},
+	}
+}
+
+func newTestPlatformAdmin(namespace, name, poolName string) *iotv1alpha2.PlatformAdmin {
+	return &iotv1alpha2.PlatformAdmin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{},
+		},
+		Spec: iotv1alpha2.PlatformAdminSpec{
+			Version:  testVersion,
+			PoolName: poolName,
+			Platform: iotv1alpha2.PlatformAdminPlatformEdgeX,
+		},
+	}
+}
+
+func reconcilePlatformAdmin(t *testing.T, r *ReconcilePlatformAdmin, platformAdmin *iotv1alpha2.PlatformAdmin) reconcile.Result {
+	t.Helper()
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: platformAdmin.Namespace, Name: platformAdmin.Name},
+	})
+	if err != nil {
+		t.Fatalf("failed to reconcile PlatformAdmin %s/%s, %v", platformAdmin.Namespace, platformAdmin.Name, err)
+	}
+	return result
+}
+
+func getYurtAppSet(t *testing.T, r *ReconcilePlatformAdmin, namespace, name string) *appsv1alpha1.YurtAppSet {
+	t.Helper()
+	yas := &appsv1alpha1.YurtAppSet{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, yas); err != nil {
+		t.Fatalf("failed to get YurtAppSet %s/%s, %v", namespace, name, err)
+	}
+	return yas
+}
+
+func newTestNode(name, poolName string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{appsv1alpha1.LabelCurrentNodePool: poolName},
+		},
+	}
+}
+
+// newTestEndpoints generates the endpoints of component service with ready addresses on the nodes.
+func newTestEndpoints(namespace, name string, nodeNames ...string) *corev1.Endpoints {
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	subset := corev1.EndpointSubset{}
+	for i := range nodeNames {
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{
+			IP:       fmt.Sprintf("10.0.0.%d", i+1),
+			NodeName: &nodeNames[i],
+		})
+	}
+	endpoints.Subsets = append(endpoints.Subsets, subset)
+	return endpoints
+}
+
+func newFrameworkConfigMap(t *testing.T, conf *config.PlatformAdminControllerConfiguration) *corev1.ConfigMap {
+	t.Helper()
+	edgexConfig := config.EdgeXConfig{
+		Versions: []*config.Version{
+			{
+				Name:       testVersion,
+				ConfigMaps: conf.NoSectyConfigMaps[testVersion],
+				Components: conf.NoSectyComponents[testVersion],
+			},
+		},
+	}
+	content, err := json.Marshal(edgexConfig)
+	if err != nil {
+		t.Fatalf("failed to marshal config, %v", err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kube-system",
+			Name:      config.FrameworkConfigMapName,
+		},
+		Data: map[string]string{
+			config.SecurityConfigKey: string(content),
+			config.NoSectyConfigKey:  string(content),
+		},
+	}
+}
+
+func TestReloadConfiguration(t *testing.T) {
+	pa1 := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa2 := newTestPlatformAdmin("beijing", "edgex", "beijing")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa1, pa2)
+
+	reconcilePlatformAdmin(t, r, pa1)
+	yas := getYurtAppSet(t, r, pa1.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != testImage {
+		t.Errorf("expect image %s, but got %s", testImage, image)
+	}
+
+	// a broken configuration is refused, and the last known good one is kept
+	broken := newFrameworkConfigMap(t, newTestConfiguration(newTestComponent(testComponent, "")))
+	if requests := r.mapFrameworkToPlatformAdmins(broken); len(requests) != 0 {
+		t.Errorf("expect no requests for a broken configuration, but got %v", requests)
+	}
+	if image := r.getConfiguration().NoSectyComponents[testVersion][0].Deployment.Template.Spec.Containers[0].Image; image != testImage {
+		t.Errorf("expect the last known good image %s, but got %s", testImage, image)
+	}
+
+	// configmaps of other namespaces are ignored
+	newImage := "edgexfoundry/core-command:2.3.1"
+	updated := newFrameworkConfigMap(t, newTestConfiguration(newTestComponent(testComponent, newImage)))
+	other := updated.DeepCopy()
+	other.Namespace = "default"
+	if requests := r.mapFrameworkToPlatformAdmins(other); len(requests) != 0 {
+		t.Errorf("expect no requests for configmap in other namespace, but got %v", requests)
+	}
+
+	if requests := r.mapFrameworkToPlatformAdmins(updated); len(requests) != 2 {
+		t.Errorf("expect all PlatformAdmins are enqueued, but got %v", requests)
+	}
+
+	reconcilePlatformAdmin(t, r, pa2)
+	yas = getYurtAppSet(t, r, pa2.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != newImage {
+		t.Errorf("expect image %s, but got %s", newImage, image)
+	}
+}
+
+func TestUpgradeVersion(t *testing.T) {
+	newVersion := "minnesota"
+	newImage := "edgexfoundry/core-command:3.0.0"
+	newComponent := newTestComponent(testComponent, newImage)
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	conf.NoSectyComponents[newVersion] = []*config.Component{newComponent}
+	conf.NoSectyConfigMaps[newVersion] = []corev1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}, Data: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "EDGEX_VERSION": newVersion}},
+	}
+
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(conf, pa, newTestNode("node1", "hangzhou"), newTestEndpoints(pa.Namespace, testComponent, "node1"))
+
+	reconcilePlatformAdmin(t, r, pa)
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.Replicas = 1
+	yas.Status.PoolReadyReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.ReadyReplicas = 1
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet status, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if pa.Status.CurrentVersion != testVersion {
+		t.Errorf("expect current version %s, but got %s", testVersion, pa.Status.CurrentVersion)
+	}
+
+	// bump the version
+	pa.Spec.Version = newVersion
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != newImage {
+		t.Errorf("expect image %s, but got %s", newImage, image)
+	}
+	if hash := util.ComputeTemplateHash(newComponent.Deployment); yas.Annotations[iotv1alpha2.AnnotationTemplateHash] != hash {
+		t.Errorf("expect template hash %s, but got %s", hash, yas.Annotations[iotv1alpha2.AnnotationTemplateHash])
+	}
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if cm.Data["EDGEX_VERSION"] != newVersion {
+		t.Errorf("expect configmap of version %s, but got %v", newVersion, cm.Data)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if pa.Status.CurrentVersion != testVersion {
+		t.Errorf("expect current version %s before components are ready, but got %s", testVersion, pa.Status.CurrentVersion)
+	}
+
+	// the components become ready at the new version
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if pa.Status.CurrentVersion != newVersion {
+		t.Errorf("expect current version %s, but got %s", newVersion, pa.Status.CurrentVersion)
+	}
+}
+
+func TestReadinessPerPool(t *testing.T) {
+	paA := newTestPlatformAdmin("default", "edgex-a", "hangzhou")
+	paB := newTestPlatformAdmin("default", "edgex-b", "beijing")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), paA, paB,
+		newTestNode("node1", "hangzhou"), newTestNode("node2", "beijing"), newTestEndpoints("default", testComponent, "node1"))
+
+	reconcilePlatformAdmin(t, r, paA)
+	reconcilePlatformAdmin(t, r, paB)
+
+	// pool hangzhou is ready, but pool beijing is not
+	yas := getYurtAppSet(t, r, paA.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1, "beijing": 1}
+	yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": 1, "beijing": 0}
+	yas.Status.Replicas = 2
+	yas.Status.ReadyReplicas = 1
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet status, %v", err)
+	}
+
+	tests := []struct {
+		platformAdmin *iotv1alpha2.PlatformAdmin
+		expectReady   bool
+	}{
+		{platformAdmin: paA, expectReady: true},
+		{platformAdmin: paB, expectReady: false},
+	}
+	for _, tt := range tests {
+		reconcilePlatformAdmin(t, r, tt.platformAdmin)
+		pa := &iotv1alpha2.PlatformAdmin{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(tt.platformAdmin), pa); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		if pa.Status.Ready != tt.expectReady {
+			t.Errorf("expect PlatformAdmin %s ready %v, but got %v", pa.Name, tt.expectReady, pa.Status.Ready)
+		}
+		if tt.expectReady && pa.Status.ReadyComponentNum != 1 {
+			t.Errorf("expect 1 ready component of PlatformAdmin %s, but got %d", pa.Name, pa.Status.ReadyComponentNum)
+		}
+		if !tt.expectReady && pa.Status.UnreadyComponentNum != 1 {
+			t.Errorf("expect 1 unready component of PlatformAdmin %s, but got %d", pa.Name, pa.Status.UnreadyComponentNum)
+		}
+	}
+}
+
+func TestMultiplePools(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-edgex"
+	pa.Spec.Pools = []string{"beijing"}
+	other := newTestPlatformAdmin("default", "edgex-other", "shanghai")
+	other.UID = "uid-other"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, other,
+		newTestNode("node1", "hangzhou"), newTestNode("node2", "beijing"), newTestNode("node3", "shanghai"),
+		newTestEndpoints("default", testComponent, "node1", "node2", "node3"))
+	reconcilePlatformAdmin(t, r, pa)
+	reconcilePlatformAdmin(t, r, other)
+
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	poolNames := func(yas *appsv1alpha1.YurtAppSet) []string {
+		var names []string
+		for _, pool := range yas.Spec.Topology.Pools {
+			names = append(names, pool.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	if names := poolNames(yas); !reflect.DeepEqual(names, []string{"beijing", "hangzhou", "shanghai"}) {
+		t.Fatalf("expect pools of both PlatformAdmins in yurtappset, but got %v", names)
+	}
+
+	tests := []struct {
+		name         string
+		readyReplica map[string]int32
+		expectReady  bool
+	}{
+		{name: "one of the pools is not ready", readyReplica: map[string]int32{"hangzhou": 1, "beijing": 0}},
+		{name: "all pools are ready", readyReplica: map[string]int32{"hangzhou": 1, "beijing": 1}, expectReady: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1, "beijing": 1}
+			yas.Status.PoolReadyReplicas = tt.readyReplica
+			if err := r.Status().Update(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to update YurtAppSet status, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			latest := &iotv1alpha2.PlatformAdmin{}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if latest.Status.Ready != tt.expectReady {
+				t.Errorf("expect PlatformAdmin ready %v, but got %v", tt.expectReady, latest.Status.Ready)
+			}
+		})
+	}
+
+	// only the pools of the deleted PlatformAdmin are released
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if names := poolNames(getYurtAppSet(t, r, pa.Namespace, testComponent)); !reflect.DeepEqual(names, []string{"shanghai"}) {
+		t.Errorf("expect only pool shanghai is kept in yurtappset, but got %v", names)
+	}
+}
+
+func TestDeleteAfterPoolNameMoved(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-edgex"
+	other := newTestPlatformAdmin("default", "edgex-other", "shanghai")
+	other.UID = "uid-other"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, other)
+	reconcilePlatformAdmin(t, r, pa)
+	reconcilePlatformAdmin(t, r, other)
+
+	// the previous poolName is kept in the pools
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.PoolName = "beijing"
+	pa.Spec.Pools = []string{"hangzhou"}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	poolNames := func() []string {
+		var names []string
+		for _, pool := range getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools {
+			names = append(names, pool.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	if names := poolNames(); !reflect.DeepEqual(names, []string{"beijing", "hangzhou", "shanghai"}) {
+		t.Fatalf("expect pools of both PlatformAdmins in yurtappset, but got %v", names)
+	}
+
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if names := poolNames(); !reflect.DeepEqual(names, []string{"shanghai"}) {
+		t.Errorf("expect only pool shanghai is kept in yurtappset, but got %v", names)
+	}
+}
+
+func TestObservedGeneration(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Generation = 1
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa,
+		newTestNode("node1", "hangzhou"), newTestEndpoints("default", testComponent, "node1"))
+	getPlatformAdmin := func() *iotv1alpha2.PlatformAdmin {
+		t.Helper()
+		latest := &iotv1alpha2.PlatformAdmin{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		return latest
+	}
+	setPoolReady := func(ready bool) {
+		t.Helper()
+		yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+		yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+		yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": 0}
+		if ready {
+			yas.Status.PoolReadyReplicas["hangzhou"] = 1
+		}
+		if err := r.Status().Update(context.TODO(), yas); err != nil {
+			t.Fatalf("failed to update YurtAppSet status, %v", err)
+		}
+	}
+
+	// the components are not ready yet
+	reconcilePlatformAdmin(t, r, pa)
+	if latest := getPlatformAdmin(); latest.Status.ObservedGeneration != 0 || util.IsPlatformAdminReady(latest) {
+		t.Errorf("expect generation is not observed before the components are ready, but got %d", latest.Status.ObservedGeneration)
+	}
+
+	setPoolReady(true)
+	reconcilePlatformAdmin(t, r, pa)
+	latest := getPlatformAdmin()
+	if latest.Status.ObservedGeneration != 1 || !util.IsPlatformAdminReady(latest) {
+		t.Errorf("expect generation 1 is observed and ready, but got %d and ready %v", latest.Status.ObservedGeneration, latest.Status.Ready)
+	}
+
+	// the spec is changed, the readiness of previous generation does not count
+	latest.Generation = 2
+	latest.Spec.ComponentEnv = map[string][]corev1.EnvVar{testComponent: {{Name: "LOG_LEVEL", Value: "DEBUG"}}}
+	if err := r.Update(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	latest = getPlatformAdmin()
+	if !latest.Status.Ready || util.IsPlatformAdminReady(latest) {
+		t.Errorf("expect PlatformAdmin with a new generation is not ready, but got ready %v", latest.Status.Ready)
+	}
+
+	// the reconcile of the new generation does not succeed, so the generation is not advanced
+	setPoolReady(false)
+	reconcilePlatformAdmin(t, r, pa)
+	if latest = getPlatformAdmin(); latest.Status.ObservedGeneration != 1 || util.IsPlatformAdminReady(latest) {
+		t.Errorf("expect generation 1 is kept, but got %d", latest.Status.ObservedGeneration)
+	}
+
+	// the reconcile fails with an error
+	latest.Annotations = map[string]string{iotv1alpha1.AnnotationAdditionalServices: "{"}
+	if err := r.Update(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)}); err == nil {
+		t.Errorf("expect reconcile fails with the malformed annotation, but got nil")
+	}
+	if latest = getPlatformAdmin(); latest.Status.ObservedGeneration != 1 {
+		t.Errorf("expect generation 1 is kept after the failed reconcile, but got %d", latest.Status.ObservedGeneration)
+	}
+}
+
+func TestEndpointsReadiness(t *testing.T) {
+	tests := []struct {
+		name         string
+		objs         []client.Object
+		expectReady  bool
+		expectReason string
+	}{
+		{
+			name:         "no endpoints",
+			expectReason: iotv1alpha2.EndpointsNotReadyReason,
+		},
+		{
+			name:         "ready endpoint in other pool",
+			objs:         []client.Object{newTestNode("node2", "beijing"), newTestEndpoints("default", testComponent, "node2")},
+			expectReason: iotv1alpha2.EndpointsNotReadyReason,
+		},
+		{
+			name:        "ready endpoint in the pool",
+			objs:        []client.Object{newTestNode("node1", "hangzhou"), newTestEndpoints("default", testComponent, "node1")},
+			expectReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), append(tt.objs, pa)...)
+
+			// the deployment is not ready yet
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+			if cond == nil || cond.Reason != iotv1alpha2.DeploymentNotReadyReason {
+				t.Errorf("expect reason %s, but got %v", iotv1alpha2.DeploymentNotReadyReason, cond)
+			}
+
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			yas.Status.PoolReplicas = map[string]int32{pa.Spec.PoolName: 1}
+			yas.Status.Replicas = 1
+			yas.Status.PoolReadyReplicas = map[string]int32{pa.Spec.PoolName: 1}
+			yas.Status.ReadyReplicas = 1
+			if err := r.Status().Update(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to update YurtAppSet status, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if pa.Status.Ready != tt.expectReady {
+				t.Errorf("expect ready %v, but got %v", tt.expectReady, pa.Status.Ready)
+			}
+			cond = util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+			if tt.expectReady {
+				if cond == nil || cond.Status != corev1.ConditionTrue {
+					t.Errorf("expect component available, but got %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tt.expectReason || !strings.Contains(cond.Message, testComponent) {
+				t.Errorf("expect reason %s for component %s, but got %v", tt.expectReason, testComponent, cond)
+			}
+		})
+	}
+}
+
+func TestScopePredicate(t *testing.T) {
+	r := newTestReconciler(newTestConfiguration())
+	r.namespaces = sets.NewString("edge-a", "edge-b")
+	scope := r.scopePredicate()
+
+	tests := []struct {
+		name      string
+		namespace string
+		expect    bool
+	}{
+		{name: "namespace in scope", namespace: "edge-a", expect: true},
+		{name: "namespace out of scope", namespace: "default", expect: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin(tt.namespace, "edgex", "hangzhou")
+			if got := scope.Create(event.CreateEvent{Object: pa}); got != tt.expect {
+				t.Errorf("expect create event accepted %v, but got %v", tt.expect, got)
+			}
+			if got := scope.Update(event.UpdateEvent{ObjectOld: pa, ObjectNew: pa}); got != tt.expect {
+				t.Errorf("expect update event accepted %v, but got %v", tt.expect, got)
+			}
+			if got := scope.Delete(event.DeleteEvent{Object: pa}); got != tt.expect {
+				t.Errorf("expect delete event accepted %v, but got %v", tt.expect, got)
+			}
+			if got := scope.Generic(event.GenericEvent{Object: pa}); got != tt.expect {
+				t.Errorf("expect generic event accepted %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+
+	// all namespaces are in scope if no namespace is specified
+	r.namespaces = sets.NewString()
+	if !r.scopePredicate().Create(event.CreateEvent{Object: newTestPlatformAdmin("default", "edgex", "hangzhou")}) {
+		t.Errorf("expect all namespaces are in scope")
+	}
+}
+
+func TestReconcileOutOfScope(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	r.namespaces = sets.NewString("edge-a")
+
+	reconcilePlatformAdmin(t, r, pa)
+	yas := &appsv1alpha1.YurtAppSet{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, yas); !apierrors.IsNotFound(err) {
+		t.Errorf("expect no YurtAppSet for PlatformAdmin out of scope, but got %v", err)
+	}
+}
+
+func TestIsPoolReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		status appsv1alpha1.YurtAppSetStatus
+		expect bool
+	}{
+		{name: "pool not reported"},
+		{
+			name: "pool is ready while the pool of another PlatformAdmin is not",
+			status: appsv1alpha1.YurtAppSetStatus{
+				Replicas: 3, ReadyReplicas: 1,
+				PoolReplicas:      map[string]int32{"hangzhou": 1, "beijing": 2},
+				PoolReadyReplicas: map[string]int32{"hangzhou": 1, "beijing": 0},
+			},
+			expect: true,
+		},
+		{
+			name: "pool is not ready while the pool of another PlatformAdmin is",
+			status: appsv1alpha1.YurtAppSetStatus{
+				Replicas: 3, ReadyReplicas: 2,
+				PoolReplicas:      map[string]int32{"hangzhou": 1, "beijing": 2},
+				PoolReadyReplicas: map[string]int32{"beijing": 2},
+			},
+		},
+		{
+			name:   "per-pool ready replicas not reported",
+			status: appsv1alpha1.YurtAppSetStatus{Replicas: 1, ReadyReplicas: 1, PoolReplicas: map[string]int32{"hangzhou": 1}},
+		},
+		{
+			name: "per-pool ready replicas not reported while the pool of another PlatformAdmin is not ready",
+			status: appsv1alpha1.YurtAppSetStatus{
+				Replicas: 3, ReadyReplicas: 1,
+				PoolReplicas: map[string]int32{"hangzhou": 1, "beijing": 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yas := &appsv1alpha1.YurtAppSet{Status: tt.status}
+			if got := isPoolReady(yas, "hangzhou"); got != tt.expect {
+				t.Errorf("expect ready %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestMapGeneratedToPlatformAdmins(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	other := newTestPlatformAdmin("beijing", "edgex", "beijing")
+	r := newTestReconciler(newTestConfiguration(), pa, other)
+
+	// the yurtappset is labeled but not owned by any PlatformAdmin
+	labeled := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      testComponent,
+			Labels:    map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelDeployment},
+		},
+	}
+	unlabeled := &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "user-workload",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		obj    *appsv1alpha1.YurtAppSet
+		expect []reconcile.Request
+	}{
+		{
+			name:   "un-owned but labeled yurtappset",
+			obj:    labeled,
+			expect: []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "edgex"}}},
+		},
+		{
+			name: "unlabeled yurtappset",
+			obj:  unlabeled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer queue.ShutDown()
+
+			newObj := tt.obj.DeepCopy()
+			newObj.Spec.Topology.Pools = append(newObj.Spec.Topology.Pools, appsv1alpha1.Pool{Name: "hangzhou"})
+			handler.EnqueueRequestsFromMapFunc(r.mapGeneratedToPlatformAdmins).Update(event.UpdateEvent{ObjectOld: tt.obj, ObjectNew: newObj}, queue)
+
+			if queue.Len() != len(tt.expect) {
+				t.Fatalf("expect %d requests, but got %d", len(tt.expect), queue.Len())
+			}
+			for _, expect := range tt.expect {
+				item, _ := queue.Get()
+				if item != expect {
+					t.Errorf("expect request %v, but got %v", expect, item)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Annotations[iotv1alpha2.AnnotationDryRun] = "true"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &appsv1alpha1.YurtAppSet{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect no YurtAppSet in dry-run mode, but got %v", err)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect no Service in dry-run mode, but got %v", err)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if len(pa.Finalizers) != 0 {
+		t.Errorf("expect no finalizer in dry-run mode, but got %v", pa.Finalizers)
+	}
+	expectPreview := []iotv1alpha2.PreviewComponent{
+		{Name: "common-variable-levski", Kind: "ConfigMap"},
+		{Name: testComponent, Kind: "Service", Ports: []int32{59882}},
+		{Name: testComponent, Kind: "YurtAppSet", Images: []string{testImage}},
+	}
+	if !reflect.DeepEqual(pa.Status.PreviewComponents, expectPreview) {
+		t.Errorf("expect preview %v, but got %v", expectPreview, pa.Status.PreviewComponents)
+	}
+	if len(r.recorder.(*record.FakeRecorder).Events) != 1 {
+		t.Errorf("expect a dry-run event")
+	}
+
+	// removing the annotation resumes normal reconciliation
+	delete(pa.Annotations, iotv1alpha2.AnnotationDryRun)
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if len(pa.Status.PreviewComponents) != 0 {
+		t.Errorf("expect preview is cleared, but got %v", pa.Status.PreviewComponents)
+	}
+}
+
+func TestRemoveOwner(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	other := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+	other.UID = "uid-beijing"
+	ownerRef := func(platformAdmin *iotv1alpha2.PlatformAdmin, isController bool) metav1.OwnerReference {
+		return metav1.OwnerReference{
+			APIVersion: iotv1alpha2.GroupVersion.String(),
+			Kind:       "PlatformAdmin",
+			Name:       platformAdmin.Name,
+			UID:        platformAdmin.UID,
+			Controller: pointer.BoolPtr(isController),
+		}
+	}
+	newService := func(labeled bool, owners ...metav1.OwnerReference) *corev1.Service {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            testComponent,
+				OwnerReferences: owners,
+			},
+		}
+		if labeled {
+			svc.Labels = map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService}
+		}
+		return svc
+	}
+
+	tests := []struct {
+		name         string
+		svc          *corev1.Service
+		expectExist  bool
+		expectOwners []metav1.OwnerReference
+	}{
+		{
+			name:        "last owner of generated object",
+			svc:         newService(true, ownerRef(pa, true)),
+			expectExist: false,
+		},
+		{
+			name:        "last owner of object not generated by controller",
+			svc:         newService(false, ownerRef(pa, true)),
+			expectExist: true,
+		},
+		{
+			name:         "controller owner with other owners",
+			svc:          newService(true, ownerRef(pa, true), ownerRef(other, false)),
+			expectExist:  true,
+			expectOwners: []metav1.OwnerReference{ownerRef(other, true)},
+		},
+		{
+			name:         "non-controller owner with other owners",
+			svc:          newService(true, ownerRef(other, true), ownerRef(pa, false)),
+			expectExist:  true,
+			expectOwners: []metav1.OwnerReference{ownerRef(other, true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(), tt.svc)
+			svc := &corev1.Service{}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(tt.svc), svc); err != nil {
+				t.Fatalf("failed to get Service, %v", err)
+			}
+			if err := r.removeOwner(context.TODO(), pa, svc); err != nil {
+				t.Fatalf("failed to remove owner, %v", err)
+			}
+
+			got := &corev1.Service{}
+			err := r.Get(context.TODO(), client.ObjectKeyFromObject(tt.svc), got)
+			if !tt.expectExist {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expect Service is deleted, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get Service, %v", err)
+			}
+			if !reflect.DeepEqual(got.OwnerReferences, tt.expectOwners) {
+				t.Errorf("expect owners %v, but got %v", tt.expectOwners, got.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestRemoveOwnerConflict(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      testComponent,
+			Labels:    map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: pa.Name, UID: pa.UID},
+				{APIVersion: iotv1alpha2.GroupVersion.String(), Kind: "PlatformAdmin", Name: "edgex-beijing", UID: "uid-beijing"},
+			},
+		},
+	}
+	r := newTestReconciler(newTestConfiguration(), svc)
+
+	stale := &corev1.Service{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(svc), stale); err != nil {
+		t.Fatalf("failed to get Service, %v", err)
+	}
+	// the object is changed by others, so the first patch conflicts
+	latest := stale.DeepCopy()
+	latest.Annotations = map[string]string{"changed": "true"}
+	if err := r.Update(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to update Service, %v", err)
+	}
+
+	if err := r.removeOwner(context.TODO(), pa, stale); err != nil {
+		t.Fatalf("expect conflict is retried, but got %v", err)
+	}
+	got := &corev1.Service{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(svc), got); err != nil {
+		t.Fatalf("failed to get Service, %v", err)
+	}
+	if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].UID != "uid-beijing" {
+		t.Errorf("expect only owner uid-beijing, but got %v", got.OwnerReferences)
+	}
+	if got.Annotations["changed"] != "true" {
+		t.Errorf("expect the change of others is kept, but got %v", got.Annotations)
+	}
+}
+
+func TestConfigMapOverrides(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	cmName := "common-variable-levski"
+
+	tests := []struct {
+		name      string
+		overrides map[string]map[string]string
+		expect    map[string]string
+	}{
+		{
+			name:      "add",
+			overrides: map[string]map[string]string{cmName: {"EDGEX_SECURITY_SECRET_STORE": "true", "SERVICE_HOST": "edgex"}},
+			expect:    map[string]string{"EDGEX_SECURITY_SECRET_STORE": "true", "SERVICE_HOST": "edgex"},
+		},
+		{
+			name:      "update",
+			overrides: map[string]map[string]string{cmName: {"SERVICE_HOST": "edgex-hangzhou"}},
+			expect:    map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "SERVICE_HOST": "edgex-hangzhou"},
+		},
+		{
+			name:      "delete key",
+			overrides: map[string]map[string]string{cmName: {"EDGEX_SECURITY_SECRET_STORE": ""}},
+			expect:    map[string]string{},
+		},
+		{
+			name:   "remove override",
+			expect: map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.ConfigMapOverrides = tt.overrides
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			cm := &corev1.ConfigMap{}
+			if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: cmName}, cm); err != nil {
+				t.Fatalf("failed to get configmap, %v", err)
+			}
+			if len(cm.Data) != len(tt.expect) || (len(tt.expect) != 0 && !reflect.DeepEqual(cm.Data, tt.expect)) {
+				t.Errorf("expect data %v, but got %v", tt.expect, cm.Data)
+			}
+
+			// the merge is idempotent
+			reconcilePlatformAdmin(t, r, pa)
+			again := &corev1.ConfigMap{}
+			if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: cmName}, again); err != nil {
+				t.Fatalf("failed to get configmap, %v", err)
+			}
+			if again.ResourceVersion != cm.ResourceVersion {
+				t.Errorf("expect configmap is not updated by repeated reconcile, but resource version changed from %s to %s", cm.ResourceVersion, again.ResourceVersion)
+			}
+		})
+	}
+}
+
+func TestPoolNodeSelectorRequirements(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.NodeSelectorRequirements = []corev1.NodeSelectorRequirement{
+		{Key: "gpu", Operator: corev1.NodeSelectorOpExists},
+	}
+	pa.Spec.Tolerations = []corev1.Toleration{
+		{Key: "edge", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	tests := []struct {
+		name         string
+		requirements []corev1.NodeSelectorRequirement
+		tolerations  []corev1.Toleration
+	}{
+		{
+			name:         "create",
+			requirements: pa.Spec.NodeSelectorRequirements,
+			tolerations:  pa.Spec.Tolerations,
+		},
+		{
+			name: "patch",
+			requirements: []corev1.NodeSelectorRequirement{
+				{Key: "gpu", Operator: corev1.NodeSelectorOpExists},
+				{Key: "arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}},
+			},
+		},
+		{
+			name: "remove",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.NodeSelectorRequirements = tt.requirements
+			pa.Spec.Tolerations = tt.tolerations
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			if len(yas.Spec.Topology.Pools) != 1 {
+				t.Fatalf("expect 1 pool, but got %v", yas.Spec.Topology.Pools)
+			}
+			pool := yas.Spec.Topology.Pools[0]
+			expectRequirements := append([]corev1.NodeSelectorRequirement{{
+				Key:      appsv1alpha1.LabelCurrentNodePool,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{pa.Spec.PoolName},
+			}}, tt.requirements...)
+			if !reflect.DeepEqual(pool.NodeSelectorTerm.MatchExpressions, expectRequirements) {
+				t.Errorf("expect node selector requirements %v, but got %v", expectRequirements, pool.NodeSelectorTerm.MatchExpressions)
+			}
+			if !reflect.DeepEqual(pool.Tolerations, tt.tolerations) {
+				t.Errorf("expect tolerations %v, but got %v", tt.tolerations, pool.Tolerations)
+			}
+		})
+	}
+}
+
+func TestPodDisruptionBudget(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	minAvailable := intstr.FromInt(1)
+	percentage := intstr.FromString("50%")
+	tests := []struct {
+		name         string
+		minAvailable *intstr.IntOrString
+		disabled     bool
+	}{
+		{
+			name:         "create",
+			minAvailable: &minAvailable,
+		},
+		{
+			name:         "update min available",
+			minAvailable: &percentage,
+		},
+		{
+			name:     "cleanup",
+			disabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.PodDisruptionBudget = nil
+			if !tt.disabled {
+				pa.Spec.PodDisruptionBudget = &iotv1alpha2.PodDisruptionBudgetSpec{MinAvailable: tt.minAvailable}
+			}
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			pdb := &policyv1.PodDisruptionBudget{}
+			err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, pdb)
+			if tt.disabled {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expect poddisruptionbudget is deleted, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get poddisruptionbudget, %v", err)
+			}
+			if !reflect.DeepEqual(pdb.Spec.MinAvailable, tt.minAvailable) {
+				t.Errorf("expect min available %v, but got %v", tt.minAvailable, pdb.Spec.MinAvailable)
+			}
+			if pdb.Spec.Selector == nil || pdb.Spec.Selector.MatchLabels["app"] != testComponent {
+				t.Errorf("expect poddisruptionbudget selects pods of %s, but got %v", testComponent, pdb.Spec.Selector)
+			}
+			if pdb.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelPodDisruptionBudget {
+				t.Errorf("expect poddisruptionbudget is labeled, but got %v", pdb.Labels)
+			}
+			if !isOwnedBy(pdb, pa) {
+				t.Errorf("expect poddisruptionbudget is owned by PlatformAdmin, but got %v", pdb.OwnerReferences)
+			}
+		})
+	}
+}
+
+// writeCountingClient counts the writes sent to the objects of PlatformAdmin.
+type writeCountingClient struct {
+	client.Client
+	writes int
+}
+
+func (c *writeCountingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.writes++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.writes++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// statusCountingClient counts the writes sent to the status of PlatformAdmin.
+type statusCountingClient struct {
+	client.Client
+	statusWrites int
+}
+
+func (c *statusCountingClient) Status() client.StatusWriter {
+	return &countingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type countingStatusWriter struct {
+	client.StatusWriter
+	client *statusCountingClient
+}
+
+func (w *countingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.client.statusWrites++
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *countingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.client.statusWrites++
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestSkipUnchangedStatus(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	counting := &statusCountingClient{Client: r.Client}
+	r.Client = counting
+
+	// the first reconcile reports the status, and the next one converges on the generated objects
+	reconcilePlatformAdmin(t, r, pa)
+	if counting.statusWrites != 1 {
+		t.Errorf("expect the status is written by the first reconcile, but got %d writes", counting.statusWrites)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	status := pa.Status.DeepCopy()
+	counting.statusWrites = 0
+	reconcilePlatformAdmin(t, r, pa)
+	if counting.statusWrites != 0 {
+		t.Errorf("expect no status write for an unchanged status, but got %d writes", counting.statusWrites)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if !reflect.DeepEqual(&pa.Status, status) {
+		t.Errorf("expect status %v is kept, but got %v", status, pa.Status)
+	}
+
+	// the status is written again once it is changed
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.Replicas = 1
+	yas.Status.PoolReadyReplicas = map[string]int32{pa.Spec.PoolName: 1}
+	yas.Status.ReadyReplicas = 1
+	if err := counting.Client.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet status, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if counting.statusWrites != 1 {
+		t.Errorf("expect the changed status is written, but got %d writes", counting.statusWrites)
+	}
+}
+
+func TestPausedPlatformAdmin(t *testing.T) {
+	tests := []struct {
+		name  string
+		pause func(pa *iotv1alpha2.PlatformAdmin)
+	}{
+		{
+			name:  "paused by spec",
+			pause: func(pa *iotv1alpha2.PlatformAdmin) { pa.Spec.Paused = true },
+		},
+		{
+			name: "paused by annotation",
+			pause: func(pa *iotv1alpha2.PlatformAdmin) {
+				metav1.SetMetaDataAnnotation(&pa.ObjectMeta, iotv1alpha2.AnnotationPaused, "true")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "edgex-uid"
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			conditions := pa.Status.Conditions
+			tt.pause(pa)
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			// the manual edit of YurtAppSet is not reverted while paused
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			yas.Spec.Topology.Pools = nil
+			if err := r.Update(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to update YurtAppSet, %v", err)
+			}
+
+			counting := &writeCountingClient{Client: r.Client}
+			r.Client = counting
+			reconcilePlatformAdmin(t, r, pa)
+			if counting.writes != 0 {
+				t.Errorf("expect no writes while paused, but got %d", counting.writes)
+			}
+			if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 0 {
+				t.Errorf("expect YurtAppSet is not reconciled while paused, but got pools %v", yas.Spec.Topology.Pools)
+			}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.PausedCondition); cond == nil || cond.Status != corev1.ConditionTrue {
+				t.Errorf("expect paused condition, but got %v", cond)
+			}
+			for _, c := range conditions {
+				if cond := util.GetPlatformAdminCondition(pa.Status, c.Type); cond == nil || !reflect.DeepEqual(*cond, c) {
+					t.Errorf("expect condition %v is kept while paused, but got %v", c, cond)
+				}
+			}
+
+			// resuming reconciles the YurtAppSet again
+			pa.Spec.Paused = false
+			delete(pa.Annotations, iotv1alpha2.AnnotationPaused)
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 1 {
+				t.Errorf("expect pool is restored after resuming, but got %v", yas.Spec.Topology.Pools)
+			}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.PausedCondition); cond != nil {
+				t.Errorf("expect paused condition is removed after resuming, but got %v", cond)
+			}
+		})
+	}
+}
+
+func TestDeletePausedPlatformAdmin(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.Paused = true
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted while paused, but got %v", err)
+	}
+	if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 0 {
+		t.Errorf("expect pool is removed from YurtAppSet, but got %v", yas.Spec.Topology.Pools)
+	}
+}
+
+// statusSubresourceClient writes only the status of PlatformAdmin with Status().Update like the apiserver, the
+// changes to the metadata are dropped.
+type statusSubresourceClient struct {
+	client.Client
+}
+
+func (c *statusSubresourceClient) Status() client.StatusWriter {
+	return &subresourceStatusWriter{StatusWriter: c.Client.Status(), client: c.Client}
+}
+
+type subresourceStatusWriter struct {
+	client.StatusWriter
+	client client.Client
+}
+
+func (w *subresourceStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	platformAdmin, ok := obj.(*iotv1alpha2.PlatformAdmin)
+	if !ok {
+		return w.StatusWriter.Update(ctx, obj, opts...)
+	}
+	stored := &iotv1alpha2.PlatformAdmin{}
+	if err := w.client.Get(ctx, client.ObjectKeyFromObject(platformAdmin), stored); err != nil {
+		return err
+	}
+	stored.Status = platformAdmin.Status
+	if err := w.StatusWriter.Update(ctx, stored, opts...); err != nil {
+		return err
+	}
+	stored.DeepCopyInto(platformAdmin)
+	return nil
+}
+
+func TestDeleteBeforeReady(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	r.Client = &statusSubresourceClient{Client: r.Client}
+
+	// the components are never ready, so the first reconcile returns before the end of reconcileNormal
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if pa.Status.Ready {
+		t.Fatalf("expect PlatformAdmin is not ready, but got ready")
+	}
+	if !controllerutil.ContainsFinalizer(pa, iotv1alpha2.PlatformAdminFinalizer) {
+		t.Errorf("expect finalizer is persisted by the first reconcile, but got %v", pa.Finalizers)
+	}
+	if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 1 {
+		t.Fatalf("expect pool is added to YurtAppSet, but got %v", yas.Spec.Topology.Pools)
+	}
+
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+	}
+	if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 0 {
+		t.Errorf("expect pool is removed from YurtAppSet, but got %v", yas.Spec.Topology.Pools)
+	}
+}
+
+// deleteErrorClient fails the deletion of the objects of the given kind.
+type deleteErrorClient struct {
+	client.Client
+	err error
+}
+
+func (c *deleteErrorClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*corev1.ConfigMap); ok {
+		return c.err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestDeleteCleanupServicesAndConfigmaps(t *testing.T) {
+	const configmapName = "common-variable-levski"
+	tests := []struct {
+		name                 string
+		shared               bool
+		deleteErr            error
+		expectServiceExist   bool
+		expectConfigmapExist bool
+		expectPlatformAdmin  bool
+	}{
+		{
+			name: "single owner",
+		},
+		{
+			name:                 "shared owner",
+			shared:               true,
+			expectServiceExist:   true,
+			expectConfigmapExist: true,
+		},
+		{
+			// the other objects are still cleaned up, and the finalizer is kept for the failed one
+			name:                 "cleanup fails",
+			deleteErr:            errors.New("configmap is broken"),
+			expectConfigmapExist: true,
+			expectPlatformAdmin:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			other := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+			other.UID = "uid-beijing"
+			platformAdmins := []client.Object{pa}
+			if tt.shared {
+				// the configmap desired by the other PlatformAdmin is kept
+				platformAdmins = append(platformAdmins, other)
+			}
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), platformAdmins...)
+			reconcilePlatformAdmin(t, r, pa)
+			if tt.shared {
+				reconcilePlatformAdmin(t, r, other)
+			}
+
+			if err := r.Delete(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to delete PlatformAdmin, %v", err)
+			}
+			if tt.deleteErr != nil {
+				r.Client = &deleteErrorClient{Client: r.Client, err: tt.deleteErr}
+				if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)}); err == nil {
+					t.Errorf("expect error of the cleanup, but got nil")
+				}
+			} else {
+				reconcilePlatformAdmin(t, r, pa)
+			}
+
+			err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{})
+			if exist := !apierrors.IsNotFound(err); exist != tt.expectPlatformAdmin {
+				t.Errorf("expect PlatformAdmin exists %v, but got %v", tt.expectPlatformAdmin, err)
+			}
+
+			objs := map[client.Object]bool{
+				&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: pa.Namespace, Name: testComponent}}:   tt.expectServiceExist,
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: pa.Namespace, Name: configmapName}}: tt.expectConfigmapExist,
+			}
+			for obj, expectExist := range objs {
+				err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+				if !expectExist {
+					if !apierrors.IsNotFound(err) {
+						t.Errorf("expect %s is deleted, but got %v", obj.GetName(), err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("failed to get %s, %v", obj.GetName(), err)
+				}
+				if tt.shared && (isOwnedBy(obj, pa) || !isOwnedBy(obj, other)) {
+					t.Errorf("expect %s is only owned by the other PlatformAdmin, but got %v", obj.GetName(), obj.GetOwnerReferences())
+				}
+			}
+		})
+	}
+}
+
+// getErrorClient fails to get the yurtappsets.
+type getErrorClient struct {
+	client.Client
+	err error
+}
+
+func (c *getErrorClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*appsv1alpha1.YurtAppSet); ok {
+		return c.err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestDeleteYurtAppSetGone(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(t *testing.T, r *ReconcilePlatformAdmin, yas *appsv1alpha1.YurtAppSet)
+	}{
+		{
+			name: "yurtappset is deleted",
+			mutate: func(t *testing.T, r *ReconcilePlatformAdmin, yas *appsv1alpha1.YurtAppSet) {
+				if err := r.Delete(context.TODO(), yas); err != nil {
+					t.Fatalf("failed to delete YurtAppSet, %v", err)
+				}
+			},
+		},
+		{
+			name: "pool is already removed",
+			mutate: func(t *testing.T, r *ReconcilePlatformAdmin, yas *appsv1alpha1.YurtAppSet) {
+				yas.Spec.Topology.Pools = nil
+				if err := r.Update(context.TODO(), yas); err != nil {
+					t.Fatalf("failed to update YurtAppSet, %v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+			tt.mutate(t, r, getYurtAppSet(t, r, pa.Namespace, testComponent))
+
+			if err := r.Delete(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to delete PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteYurtAppSetGetError(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+
+	// the finalizer is kept while the pool can not be released
+	c := r.Client
+	r.Client = &getErrorClient{Client: c, err: apierrors.NewServiceUnavailable("apiserver is not available")}
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)}); err == nil {
+		t.Errorf("expect error of getting yurtappset, but got nil")
+	}
+	r.Client = c
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("expect PlatformAdmin is kept, but got %v", err)
+	}
+	if !controllerutil.ContainsFinalizer(latest, iotv1alpha2.PlatformAdminFinalizer) {
+		t.Errorf("expect the finalizer is kept, but got %v", latest.Finalizers)
+	}
+	if pools := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools; len(pools) != 1 {
+		t.Errorf("expect the pool is kept in yurtappset, but got %v", pools)
+	}
+
+	// the finalizer is removed once the pool is released
+	reconcilePlatformAdmin(t, r, pa)
+	if pools := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.Topology.Pools; len(pools) != 0 {
+		t.Errorf("expect the pool is removed from yurtappset, but got %v", pools)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+	}
+}
+
+func TestServiceTopology(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	component := newTestComponent(testComponent, testImage)
+	uiComponent := newTestComponent("edgex-ui-go", testImage)
+	uiComponent.ServiceTopology = iotv1alpha2.ServiceTopologyNone
+	r := newTestReconciler(newTestConfiguration(component, uiComponent), pa)
+
+	// the components in spec override the service topology of configuration
+	tests := []struct {
+		name           string
+		components     []iotv1alpha2.Component
+		expectTopology map[string]string
+	}{
+		{
+			name: "default topology of configuration",
+			expectTopology: map[string]string{
+				testComponent: AnnotationServiceTopologyValueNodePool,
+				"edgex-ui-go": "",
+			},
+		},
+		{
+			name: "zone",
+			components: []iotv1alpha2.Component{
+				{Name: testComponent, ServiceTopology: iotv1alpha2.ServiceTopologyZone},
+			},
+			expectTopology: map[string]string{
+				testComponent: AnnotationServiceTopologyValueZone,
+				"edgex-ui-go": "",
+			},
+		},
+		{
+			name: "switch to none on live service",
+			components: []iotv1alpha2.Component{
+				{Name: testComponent, ServiceTopology: iotv1alpha2.ServiceTopologyNone},
+				{Name: "edgex-ui-go", ServiceTopology: iotv1alpha2.ServiceTopologyNodePool},
+			},
+			expectTopology: map[string]string{
+				testComponent: "",
+				"edgex-ui-go": AnnotationServiceTopologyValueNodePool,
+			},
+		},
+		{
+			name: "override removed on live service",
+			expectTopology: map[string]string{
+				testComponent: AnnotationServiceTopologyValueNodePool,
+				"edgex-ui-go": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.Components = tt.components
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			for name, expect := range tt.expectTopology {
+				svc := &corev1.Service{}
+				if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: name}, svc); err != nil {
+					t.Fatalf("failed to get service %s, %v", name, err)
+				}
+				topology, ok := svc.Annotations[AnnotationServiceTopologyKey]
+				if expect == "" && ok {
+					t.Errorf("expect service %s has no topology annotation, but got %s", name, topology)
+				} else if topology != expect {
+					t.Errorf("expect service %s has topology %s, but got %s", name, expect, topology)
+				}
+			}
+		})
+	}
+
+	// the components of configuration are not modified by the override
+	if component.ServiceTopology != "" || uiComponent.ServiceTopology != iotv1alpha2.ServiceTopologyNone {
+		t.Errorf("expect configuration is not modified, but got %q and %q", component.ServiceTopology, uiComponent.ServiceTopology)
+	}
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.NetworkPolicy = true
+	component := newTestComponent(testComponent, testImage)
+	conf := newTestConfiguration(component)
+	r := newTestReconciler(conf, pa)
+
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	tests := []struct {
+		name        string
+		ports       []corev1.ServicePort
+		disabled    bool
+		expectPorts []networkingv1.NetworkPolicyPort
+	}{
+		{
+			name:        "service ports",
+			ports:       component.Service.Ports,
+			expectPorts: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: intstrPtr(intstr.FromInt(59882))}},
+		},
+		{
+			name: "ports are changed",
+			ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt(59882)},
+				{Name: "metrics", Protocol: corev1.ProtocolUDP, Port: 9090, TargetPort: intstr.FromString("metrics")},
+			},
+			expectPorts: []networkingv1.NetworkPolicyPort{
+				{Protocol: &tcp, Port: intstrPtr(intstr.FromInt(59882))},
+				{Protocol: &udp, Port: intstrPtr(intstr.FromString("metrics"))},
+			},
+		},
+		{
+			name:     "cleanup",
+			disabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.NetworkPolicy = !tt.disabled
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			component.Service.Ports = tt.ports
+			reconcilePlatformAdmin(t, r, pa)
+
+			networkPolicy := &networkingv1.NetworkPolicy{}
+			err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, networkPolicy)
+			if tt.disabled {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expect networkpolicy is deleted, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get networkpolicy, %v", err)
+			}
+			if networkPolicy.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelNetworkPolicy || !isOwnedBy(networkPolicy, pa) {
+				t.Errorf("expect networkpolicy is labeled and owned by PlatformAdmin, but got %v", networkPolicy.ObjectMeta)
+			}
+			if networkPolicy.Spec.PodSelector.MatchLabels["app"] != testComponent {
+				t.Errorf("expect networkpolicy selects pods of %s, but got %v", testComponent, networkPolicy.Spec.PodSelector)
+			}
+			if len(networkPolicy.Spec.Ingress) != 1 {
+				t.Fatalf("expect 1 ingress rule, but got %v", networkPolicy.Spec.Ingress)
+			}
+			rule := networkPolicy.Spec.Ingress[0]
+			if !reflect.DeepEqual(rule.Ports, tt.expectPorts) {
+				t.Errorf("expect ingress ports %v, but got %v", tt.expectPorts, rule.Ports)
+			}
+			if len(rule.From) != 1 || rule.From[0].NamespaceSelector != nil || rule.From[0].PodSelector == nil ||
+				rule.From[0].PodSelector.MatchExpressions[0].Key != iotv1alpha2.LabelPlatformAdminGenerate {
+				t.Errorf("expect ingress from the generated pods in the same namespace, but got %v", rule.From)
+			}
+
+			// the pods of component are selected by the policy and admitted as the peers
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			podLabels := labels.Set(yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels)
+			for _, selector := range []*metav1.LabelSelector{&networkPolicy.Spec.PodSelector, rule.From[0].PodSelector} {
+				s, err := metav1.LabelSelectorAsSelector(selector)
+				if err != nil {
+					t.Fatalf("failed to convert selector %v, %v", selector, err)
+				}
+				if !s.Matches(podLabels) {
+					t.Errorf("expect pod template labels %v match the selector %v, but got not", podLabels, selector)
+				}
+			}
+		})
+	}
+}
+
+func intstrPtr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
+
+func TestNetworkPolicyLabelsExistingTemplate(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the yurtappset is generated before the pods are labeled
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	delete(yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels, iotv1alpha2.LabelPlatformAdminGenerate)
+	if err := r.Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); isTemplateLabeled(yas.Spec.WorkloadTemplate.DeploymentTemplate) {
+		t.Errorf("expect template is not changed without networkpolicy, but got %v", yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels)
+	}
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.NetworkPolicy = true
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if yas := getYurtAppSet(t, r, pa.Namespace, testComponent); !isTemplateLabeled(yas.Spec.WorkloadTemplate.DeploymentTemplate) {
+		t.Errorf("expect template is labeled with networkpolicy, but got %v", yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels)
+	}
+}
+
+// createErrorClient fails the creation of the service with the given name.
+type createErrorClient struct {
+	client.Client
+	service string
+	err     error
+}
+
+func (c *createErrorClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*corev1.Service); ok && obj.GetName() == c.service {
+		return c.err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *createErrorClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if _, ok := obj.(*corev1.Service); ok && obj.GetName() == c.service && patch.Type() == types.ApplyPatchType {
+		return c.err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcileContinuesOnComponentError(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	components := []*config.Component{
+		newTestComponent("edgex-core-command", testImage),
+		newTestComponent("edgex-core-data", testImage),
+		newTestComponent("edgex-core-metadata", testImage),
+	}
+	r := newTestReconciler(newTestConfiguration(components...), pa)
+	r.Client = &createErrorClient{Client: r.Client, service: "edgex-core-data", err: errors.New("service is broken")}
+
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)}); err == nil {
+		t.Errorf("expect error of the broken component, but got nil")
+	}
+
+	// the components after the broken one are still created
+	for _, name := range []string{"edgex-core-command", "edgex-core-metadata"} {
+		getYurtAppSet(t, r, pa.Namespace, name)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "edgex-core-data"}, &appsv1alpha1.YurtAppSet{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect yurtappset of the broken component is not created, but got %v", err)
+	}
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if pa.Status.ReadyComponentNum != 0 || pa.Status.UnreadyComponentNum != 3 {
+		t.Errorf("expect 0 ready and 3 unready components, but got %d and %d", pa.Status.ReadyComponentNum, pa.Status.UnreadyComponentNum)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+	if cond == nil || cond.Status != corev1.ConditionFalse || !strings.Contains(cond.Message, "service is broken") {
+		t.Errorf("expect component available condition with the error, but got %v", cond)
+	}
+}
+
+func TestRejectedError(t *testing.T) {
+	rejected := &componentRejectedError{component: testComponent, err: errors.New("denied")}
+	tests := []struct {
+		name         string
+		err          error
+		expectReject bool
+	}{
+		{
+			name:         "rejected",
+			err:          rejected,
+			expectReject: true,
+		},
+		{
+			name:         "all components are rejected",
+			err:          kerrors.NewAggregate([]error{rejected, &componentRejectedError{component: "edgex-ui-go", err: errors.New("denied")}}),
+			expectReject: true,
+		},
+		{
+			name: "some components fail for other reasons",
+			err:  kerrors.NewAggregate([]error{rejected, errors.New("timeout")}),
+		},
+		{
+			name: "not rejected",
+			err:  errors.New("timeout"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rejectedError(tt.err); (got != nil) != tt.expectReject {
+				t.Errorf("expect rejected %v, but got %v", tt.expectReject, got)
+			}
+		})
+	}
+}
+
+func getYurtAppDaemon(t *testing.T, r *ReconcilePlatformAdmin, namespace, name string) *appsv1alpha1.YurtAppDaemon {
+	t.Helper()
+	yad := &appsv1alpha1.YurtAppDaemon{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, yad); err != nil {
+		t.Fatalf("failed to get yurtappdaemon %s, %v", name, err)
+	}
+	return yad
+}
+
+func TestYurtAppDaemonComponent(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	nodePool := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	daemonComponent := newTestComponent("edgex-device-virtual", testImage)
+	daemonComponent.WorkloadType = iotv1alpha2.WorkloadTypeDaemonSet
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage), daemonComponent), pa, nodePool)
+
+	// both kinds of workload are generated for one PlatformAdmin
+	reconcilePlatformAdmin(t, r, pa)
+	getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yad := getYurtAppDaemon(t, r, pa.Namespace, "edgex-device-virtual")
+	if yad.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelYurtAppDaemon || !isOwnedBy(yad, pa) {
+		t.Errorf("expect yurtappdaemon is generated by PlatformAdmin, but got %v %v", yad.Labels, yad.OwnerReferences)
+	}
+	if pools := selectedPools(yad); !reflect.DeepEqual(pools, []string{"hangzhou"}) {
+		t.Errorf("expect yurtappdaemon selects pool hangzhou, but got %v", pools)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(nodePool), nodePool); err != nil {
+		t.Fatalf("failed to get nodepool, %v", err)
+	}
+	if nodePool.Labels[appsv1alpha1.LabelCurrentNodePool] != "hangzhou" {
+		t.Errorf("expect nodepool is labeled with its name, but got %v", nodePool.Labels)
+	}
+
+	// the yurtappdaemon is ready once it selects the pool
+	yad.Status.ObservedGeneration = yad.Generation
+	yad.Status.NodePools = []string{"hangzhou"}
+	if err := r.Status().Update(context.TODO(), yad); err != nil {
+		t.Fatalf("failed to update yurtappdaemon status, %v", err)
+	}
+	reason, err := r.reconcileYurtAppDaemon(context.TODO(), pa, daemonComponent)
+	if err != nil || reason != iotv1alpha2.EndpointsNotReadyReason {
+		t.Errorf("expect endpoints are waited for, but got %q %v", reason, err)
+	}
+
+	// the yurtappset is cleaned up when the workload type of component is changed
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.Components = []iotv1alpha2.Component{{Name: testComponent, WorkloadType: iotv1alpha2.WorkloadTypeDaemonSet}}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &appsv1alpha1.YurtAppSet{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect yurtappset is deleted, but got %v", err)
+	}
+	getYurtAppDaemon(t, r, pa.Namespace, testComponent)
+
+	// the pool is removed from yurtappdaemons with PlatformAdmin
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	for _, name := range []string{testComponent, "edgex-device-virtual"} {
+		if pools := selectedPools(getYurtAppDaemon(t, r, pa.Namespace, name)); len(pools) != 0 {
+			t.Errorf("expect pool is removed from yurtappdaemon %s, but got %v", name, pools)
+		}
+	}
+}
+
+func TestComponentEnv(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.ComponentEnv = map[string][]corev1.EnvVar{
+		testComponent:   {{Name: "LOG_LEVEL", Value: "DEBUG"}, {Name: "FEATURE", Value: "on"}},
+		"edgex-unknown": {{Name: "LOG_LEVEL", Value: "DEBUG"}},
+	}
+	component := newTestComponent(testComponent, testImage)
+	component.Deployment.Template.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "INFO"}, {Name: "PORT", Value: "59882"}}
+	r := newTestReconciler(newTestConfiguration(component), pa)
+
+	// the env vars of user override the ones of template
+	reconcilePlatformAdmin(t, r, pa)
+	expectEnv := []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "DEBUG"}, {Name: "PORT", Value: "59882"}, {Name: "FEATURE", Value: "on"}}
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if env := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, expectEnv) {
+		t.Errorf("expect env %v, but got %v", expectEnv, env)
+	}
+	if env := component.Deployment.Template.Spec.Containers[0].Env; env[0].Value != "INFO" || len(env) != 2 {
+		t.Errorf("expect the component of configuration is not changed, but got %v", env)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.UnknownComponentCondition)
+	if cond == nil || cond.Status != corev1.ConditionTrue || !strings.Contains(cond.Message, "edgex-unknown") {
+		t.Errorf("expect unknown component is reported, but got %v", cond)
+	}
+
+	// the change of env vars is propagated to the existing yurtappset
+	pa.Spec.ComponentEnv = map[string][]corev1.EnvVar{testComponent: {{Name: "LOG_LEVEL", Value: "WARN"}}}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	expectEnv = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "WARN"}, {Name: "PORT", Value: "59882"}}
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if env := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, expectEnv) {
+		t.Errorf("expect env %v, but got %v", expectEnv, env)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.UnknownComponentCondition); cond != nil {
+		t.Errorf("expect unknown component condition is removed, but got %v", cond)
+	}
+}
+
+func TestImagePullSecrets(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Annotations = newAdditionalComponentAnnotations("device-virtual")
+	pa.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "regcred"}, {Name: "mirror"}}
+	component := newTestComponent(testComponent, testImage)
+	component.Deployment.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "regcred"}}
+	r := newTestReconciler(newTestConfiguration(component), pa)
+
+	// the secrets are appended to the templates of both the embed and the additional components, without duplicates
+	reconcilePlatformAdmin(t, r, pa)
+	expectSecrets := []corev1.LocalObjectReference{{Name: "regcred"}, {Name: "mirror"}}
+	for _, name := range []string{testComponent, "device-virtual"} {
+		yas := getYurtAppSet(t, r, pa.Namespace, name)
+		if secrets := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.ImagePullSecrets; !reflect.DeepEqual(secrets, expectSecrets) {
+			t.Errorf("expect image pull secrets %v of %s, but got %v", expectSecrets, name, secrets)
+		}
+	}
+	if secrets := component.Deployment.Template.Spec.ImagePullSecrets; len(secrets) != 1 {
+		t.Errorf("expect the component of configuration is not changed, but got %v", secrets)
+	}
+
+	// the change of secrets is propagated to the existing yurtappsets
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "harbor"}}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	expectSecrets = []corev1.LocalObjectReference{{Name: "regcred"}, {Name: "harbor"}}
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if secrets := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.ImagePullSecrets; !reflect.DeepEqual(secrets, expectSecrets) {
+		t.Errorf("expect image pull secrets %v, but got %v", expectSecrets, secrets)
+	}
+	expectSecrets = []corev1.LocalObjectReference{{Name: "harbor"}}
+	yas = getYurtAppSet(t, r, pa.Namespace, "device-virtual")
+	if secrets := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.ImagePullSecrets; !reflect.DeepEqual(secrets, expectSecrets) {
+		t.Errorf("expect image pull secrets %v of additional component, but got %v", expectSecrets, secrets)
+	}
+}
+
+func TestSupportedArchitectures(t *testing.T) {
+	newArchNode := func(name, arch string) *corev1.Node {
+		node := newTestNode(name, "hangzhou")
+		node.Labels[corev1.LabelArchStable] = arch
+		return node
+	}
+	archRequirement := func(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin) *corev1.NodeSelectorRequirement {
+		t.Helper()
+		yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+		if len(yas.Spec.Topology.Pools) != 1 {
+			t.Fatalf("expect one pool, but got %v", yas.Spec.Topology.Pools)
+		}
+		for _, requirement := range yas.Spec.Topology.Pools[0].NodeSelectorTerm.MatchExpressions {
+			if requirement.Key == corev1.LabelArchStable {
+				return &requirement
+			}
+		}
+		return nil
+	}
+
+	t.Run("affinity on create and patch", func(t *testing.T) {
+		pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+		pa.UID = "edgex-uid"
+		r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, newArchNode("node-a", "amd64"))
+		reconcilePlatformAdmin(t, r, pa)
+		if requirement := archRequirement(t, r, pa); requirement != nil {
+			t.Errorf("expect no arch requirement, but got %v", requirement)
+		}
+
+		// the pool of the existing yurtappset is recreated with the supported architectures
+		component := newTestComponent(testComponent, testImage)
+		component.SupportedArchitectures = []string{"arm64", "amd64"}
+		r.configuration.Store(newTestConfiguration(component))
+		reconcilePlatformAdmin(t, r, pa)
+		expect := &corev1.NodeSelectorRequirement{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64", "arm64"}}
+		if requirement := archRequirement(t, r, pa); !reflect.DeepEqual(requirement, expect) {
+			t.Errorf("expect arch requirement %v, but got %v", expect, requirement)
+		}
+
+		// the yurtappset is created with the supported architectures
+		r = newTestReconciler(newTestConfiguration(component), pa, newArchNode("node-a", "amd64"))
+		reconcilePlatformAdmin(t, r, pa)
+		if requirement := archRequirement(t, r, pa); !reflect.DeepEqual(requirement, expect) {
+			t.Errorf("expect arch requirement %v on creation, but got %v", expect, requirement)
+		}
+	})
+
+	tests := []struct {
+		name                  string
+		nodes                 []*corev1.Node
+		expectArchUnsupported bool
+	}{
+		{
+			name:  "pool without nodes",
+			nodes: nil,
+		},
+		{
+			name:  "one node has a supported architecture",
+			nodes: []*corev1.Node{newArchNode("node-a", "amd64"), newArchNode("node-b", "arm64")},
+		},
+		{
+			name:                  "no node has a supported architecture",
+			nodes:                 []*corev1.Node{newArchNode("node-a", "amd64"), newArchNode("node-b", "riscv64")},
+			expectArchUnsupported: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "edgex-uid"
+			component := newTestComponent(testComponent, testImage)
+			component.SupportedArchitectures = []string{"arm64"}
+			// the node of another pool is not considered
+			otherNode := newTestNode("node-c", "beijing")
+			otherNode.Labels[corev1.LabelArchStable] = "arm64"
+			objs := []client.Object{pa, otherNode}
+			for _, node := range tt.nodes {
+				objs = append(objs, node)
+			}
+			r := newTestReconciler(newTestConfiguration(component), objs...)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &appsv1alpha1.YurtAppSet{})
+			condition := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ArchUnsupportedCondition)
+			if !tt.expectArchUnsupported {
+				if err != nil {
+					t.Errorf("expect yurtappset is created, but got %v", err)
+				}
+				if condition != nil {
+					t.Errorf("expect no ArchUnsupported condition, but got %v", condition)
+				}
+				return
+			}
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("expect yurtappset is not created, but got %v", err)
+			}
+			if condition == nil || condition.Status != corev1.ConditionTrue || !strings.Contains(condition.Message, testComponent) {
+				t.Errorf("expect ArchUnsupported condition of %s, but got %v", testComponent, condition)
+			}
+			if available := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition); available == nil || available.Reason != iotv1alpha2.ArchUnsupportedReason {
+				t.Errorf("expect ComponentAvailable condition with reason %s, but got %v", iotv1alpha2.ArchUnsupportedReason, available)
+			}
+		})
+	}
+}
+
+func TestComponentStatuses(t *testing.T) {
+	const data = "edgex-core-data"
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage), newTestComponent(data, testImage)), pa,
+		newTestNode("node-1", "hangzhou"), newTestEndpoints(pa.Namespace, testComponent, "node-1"))
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the workload of core-command is ready in the pool
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+	yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": 1}
+	yas.Status.ObservedGeneration = yas.Generation
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update the status of YurtAppSet, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if len(pa.Status.Components) != 2 {
+		t.Fatalf("expect 2 component statuses, but got %v", pa.Status.Components)
+	}
+	// the statuses are sorted by name
+	if status := pa.Status.Components[0]; status.Name != testComponent || !status.Ready || status.Reason != "" {
+		t.Errorf("expect %s is ready, but got %v", testComponent, status)
+	}
+	if status := pa.Status.Components[1]; status.Name != data || status.Ready || status.Reason != iotv1alpha2.DeploymentNotReadyReason {
+		t.Errorf("expect %s is not ready for its deployment, but got %v", data, status)
+	}
+
+	// the status of component removed from the configuration disappears
+	r.configuration.Store(newTestConfiguration(newTestComponent(testComponent, testImage)))
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if len(pa.Status.Components) != 1 || pa.Status.Components[0].Name != testComponent {
+		t.Errorf("expect only the status of %s is left, but got %v", testComponent, pa.Status.Components)
+	}
+}
+
+func TestComponentReplicas(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.Components = []iotv1alpha2.Component{{Name: testComponent, Replicas: pointer.Int32Ptr(3)}}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	checkReplicas := func(t *testing.T, expect int32) {
+		t.Helper()
+		yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+		if len(yas.Spec.Topology.Pools) != 1 {
+			t.Fatalf("expect the pool is kept, but got %v", yas.Spec.Topology.Pools)
+		}
+		if replicas := yas.Spec.Topology.Pools[0].Replicas; replicas == nil || *replicas != expect {
+			t.Errorf("expect %d replicas in the pool, but got %v", expect, replicas)
+		}
+	}
+	setReplicas := func(t *testing.T, replicas *int32) {
+		t.Helper()
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		pa.Spec.Components[0].Replicas = replicas
+		if err := r.Update(context.TODO(), pa); err != nil {
+			t.Fatalf("failed to update PlatformAdmin, %v", err)
+		}
+		reconcilePlatformAdmin(t, r, pa)
+	}
+
+	reconcilePlatformAdmin(t, r, pa)
+	checkReplicas(t, 3)
+
+	// the existing pool is scaled, and zero replicas scale the component down with the pool kept
+	setReplicas(t, pointer.Int32Ptr(0))
+	checkReplicas(t, 0)
+
+	// the replicas default to 1
+	setReplicas(t, nil)
+	checkReplicas(t, 1)
+}
+
+func TestDisabledComponents(t *testing.T) {
+	const redis = "edgex-redis"
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage), newTestComponent(redis, "redis:7.0.5"))
+
+	checkDeployed := func(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, name string, expectDeployed bool) {
+		t.Helper()
+		yasErr := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: name}, &appsv1alpha1.YurtAppSet{})
+		svcErr := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: name}, &corev1.Service{})
+		if expectDeployed && (yasErr != nil || svcErr != nil) {
+			t.Errorf("expect component %s is deployed, but got %v and %v", name, yasErr, svcErr)
+		}
+		if !expectDeployed && (!apierrors.IsNotFound(yasErr) || !apierrors.IsNotFound(svcErr)) {
+			t.Errorf("expect component %s is not deployed, but got %v and %v", name, yasErr, svcErr)
+		}
+	}
+	checkComponentNum := func(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, expectNum int32) {
+		t.Helper()
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		if num := pa.Status.ReadyComponentNum + pa.Status.UnreadyComponentNum; num != expectNum {
+			t.Errorf("expect %d components are counted, but got %d", expectNum, num)
+		}
+	}
+
+	t.Run("disable at create", func(t *testing.T) {
+		pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+		pa.UID = "edgex-uid"
+		pa.Spec.DisabledComponents = []string{redis, "edgex-unknown"}
+		r := newTestReconciler(conf, pa)
+
+		reconcilePlatformAdmin(t, r, pa)
+		checkDeployed(t, r, pa, testComponent, true)
+		checkDeployed(t, r, pa, redis, false)
+		checkComponentNum(t, r, pa, 1)
+		// the unknown name is warned about without blocking the others
+		cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.UnknownComponentCondition)
+		if cond == nil || cond.Status != corev1.ConditionTrue || !strings.Contains(cond.Message, "edgex-unknown") || strings.Contains(cond.Message, redis) {
+			t.Errorf("expect only edgex-unknown is reported as unknown, but got %v", cond)
+		}
+	})
+
+	t.Run("disable after deploy", func(t *testing.T) {
+		pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+		pa.UID = "edgex-uid"
+		r := newTestReconciler(conf, pa)
+
+		reconcilePlatformAdmin(t, r, pa)
+		checkDeployed(t, r, pa, redis, true)
+		checkComponentNum(t, r, pa, 2)
+
+		// the yurtappset shared with another PlatformAdmin only loses the pool of this one
+		yas := getYurtAppSet(t, r, pa.Namespace, redis)
+		other := newTestPlatformAdmin("default", "edgex-other", "shanghai")
+		other.UID = "edgex-other-uid"
+		if err := controllerutil.SetOwnerReference(other, yas, r.Scheme()); err != nil {
+			t.Fatalf("failed to set owner reference, %v", err)
+		}
+		yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, newPool(other, newTestComponent(redis, testImage), other.Spec.PoolName))
+		if err := r.Update(context.TODO(), yas); err != nil {
+			t.Fatalf("failed to update yurtappset, %v", err)
+		}
+
+		pa.Spec.DisabledComponents = []string{redis}
+		if err := r.Update(context.TODO(), pa); err != nil {
+			t.Fatalf("failed to update PlatformAdmin, %v", err)
+		}
+		reconcilePlatformAdmin(t, r, pa)
+		checkDeployed(t, r, pa, testComponent, true)
+		checkComponentNum(t, r, pa, 1)
+		yas = getYurtAppSet(t, r, pa.Namespace, redis)
+		if len(yas.Spec.Topology.Pools) != 1 || yas.Spec.Topology.Pools[0].Name != "shanghai" {
+			t.Errorf("expect only pool shanghai is left, but got %v", yas.Spec.Topology.Pools)
+		}
+		if isOwnedBy(yas, pa) {
+			t.Errorf("expect owner reference of PlatformAdmin is removed, but got %v", yas.OwnerReferences)
+		}
+		// the service is only owned by this PlatformAdmin, so it is deleted with the ownership
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: redis}, &corev1.Service{}); !apierrors.IsNotFound(err) {
+			t.Errorf("expect service %s is deleted, but got %v", redis, err)
+		}
+		if cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.UnknownComponentCondition); cond != nil {
+			t.Errorf("expect no unknown component, but got %v", cond)
+		}
+	})
+}
+
+func TestRepairYurtAppSetDrift(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the fields owned by the controller are edited together with the ones it does not own
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	delete(yas.Labels, iotv1alpha2.LabelPlatformAdminGenerate)
+	yas.Spec.Selector.MatchLabels["app"] = "others"
+	delete(yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels, "app")
+	metav1.SetMetaDataAnnotation(&yas.ObjectMeta, "example.com/note", "edited by user")
+	yas.Spec.RevisionHistoryLimit = pointer.Int32Ptr(5)
+	if err := r.Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update yurtappset, %v", err)
+	}
+	// drain the events of creation
+	for len(r.recorder.(*record.FakeRecorder).Events) > 0 {
+		<-r.recorder.(*record.FakeRecorder).Events
+	}
+
+	reconcilePlatformAdmin(t, r, pa)
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelDeployment {
+		t.Errorf("expect generate label is restored, but got %v", yas.Labels)
+	}
+	if yas.Spec.Selector.MatchLabels["app"] != testComponent {
+		t.Errorf("expect selector is restored, but got %v", yas.Spec.Selector.MatchLabels)
+	}
+	if label := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Labels["app"]; label != testComponent {
+		t.Errorf("expect pod template label is restored, but got %q", label)
+	}
+	if yas.Annotations["example.com/note"] != "edited by user" {
+		t.Errorf("expect annotation of user is kept, but got %v", yas.Annotations)
+	}
+	if yas.Spec.RevisionHistoryLimit == nil || *yas.Spec.RevisionHistoryLimit != 5 {
+		t.Errorf("expect revisionHistoryLimit is kept, but got %v", yas.Spec.RevisionHistoryLimit)
+	}
+	if event, ok := findEvent(r, corev1.EventTypeNormal+" "+eventReasonDriftRepaired); !ok {
+		t.Errorf("expect drift repaired event, but got nothing")
+	} else if !strings.Contains(event, "spec.selector.matchLabels") {
+		t.Errorf("expect drift repaired event, but got %s", event)
+	}
+
+	// nothing is repaired when there is no drift
+	reconcilePlatformAdmin(t, r, pa)
+	select {
+	case event := <-r.recorder.(*record.FakeRecorder).Events:
+		t.Errorf("expect no event, but got %s", event)
+	default:
+	}
+}
+
+func TestComponentProbes(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	templateProbe := &corev1.Probe{Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(59882)}}}
+	component := newTestComponent(testComponent, testImage)
+	component.Deployment.Template.Spec.Containers[0].LivenessProbe = templateProbe
+	r := newTestReconciler(newTestConfiguration(component), pa)
+
+	getContainer := func() corev1.Container {
+		yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+		return yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0]
+	}
+	setReadinessProbe := func(probe *corev1.Probe) {
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		pa.Spec.Components = []iotv1alpha2.Component{{Name: testComponent, ReadinessProbe: probe}}
+		if err := r.Update(context.TODO(), pa); err != nil {
+			t.Fatalf("failed to update PlatformAdmin, %v", err)
+		}
+		reconcilePlatformAdmin(t, r, pa)
+	}
+
+	// no override: the template is untouched
+	reconcilePlatformAdmin(t, r, pa)
+	if container := getContainer(); container.ReadinessProbe != nil || !reflect.DeepEqual(container.LivenessProbe, templateProbe) {
+		t.Errorf("expect probes of template, but got %v and %v", container.ReadinessProbe, container.LivenessProbe)
+	}
+
+	// add
+	probe := &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/api/v2/ping", Port: intstr.FromInt(59882)}}, PeriodSeconds: 10}
+	setReadinessProbe(probe)
+	if container := getContainer(); !reflect.DeepEqual(container.ReadinessProbe, probe) || !reflect.DeepEqual(container.LivenessProbe, templateProbe) {
+		t.Errorf("expect readiness probe %v is added, but got %v and %v", probe, container.ReadinessProbe, container.LivenessProbe)
+	}
+
+	// change
+	probe = probe.DeepCopy()
+	probe.PeriodSeconds = 30
+	setReadinessProbe(probe)
+	if container := getContainer(); !reflect.DeepEqual(container.ReadinessProbe, probe) {
+		t.Errorf("expect readiness probe is changed to %v, but got %v", probe, container.ReadinessProbe)
+	}
+
+	// removal: an empty probe removes the probe, the other probes are untouched
+	setReadinessProbe(&corev1.Probe{})
+	if container := getContainer(); container.ReadinessProbe != nil || !reflect.DeepEqual(container.LivenessProbe, templateProbe) {
+		t.Errorf("expect readiness probe is removed, but got %v and %v", container.ReadinessProbe, container.LivenessProbe)
+	}
+	if component.Deployment.Template.Spec.Containers[0].ReadinessProbe != nil {
+		t.Errorf("expect the component of configuration is not changed, but got %v", component.Deployment.Template.Spec.Containers[0].ReadinessProbe)
+	}
+}
+
+func TestComponentUpdateStrategy(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	deviceComponent := newTestComponent("edgex-device-virtual", testImage)
+	deviceComponent.UpdateStrategy = &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage), deviceComponent), pa)
+
+	getStrategy := func(name string) appsv1.DeploymentStrategy {
+		return getYurtAppSet(t, r, pa.Namespace, name).Spec.WorkloadTemplate.DeploymentTemplate.Spec.Strategy
+	}
+
+	// the strategy of configuration is written into the created yurtappset
+	reconcilePlatformAdmin(t, r, pa)
+	if strategy := getStrategy("edgex-device-virtual"); strategy.Type != appsv1.RecreateDeploymentStrategyType || strategy.RollingUpdate != nil {
+		t.Errorf("expect Recreate strategy, but got %v", strategy)
+	}
+	if strategy := getStrategy(testComponent); !reflect.DeepEqual(strategy, appsv1.DeploymentStrategy{}) {
+		t.Errorf("expect the strategy of template, but got %v", strategy)
+	}
+
+	// switching the strategy on a live PlatformAdmin patches the existing yurtappset
+	maxUnavailable := intstr.FromInt(0)
+	rollingUpdate := &appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.Components = []iotv1alpha2.Component{{Name: testComponent, UpdateStrategy: rollingUpdate}}
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	if strategy := getStrategy(testComponent); !reflect.DeepEqual(strategy, *rollingUpdate) {
+		t.Errorf("expect strategy %v, but got %v", *rollingUpdate, strategy)
+	}
+	if strategy := getStrategy("edgex-device-virtual"); strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		t.Errorf("expect Recreate strategy is kept, but got %v", strategy)
+	}
+}
+
+// logEntry is a log recorded by recordingLogger, the key/value pairs of the logger and the log are merged.
+type logEntry struct {
+	msg           string
+	err           error
+	keysAndValues map[string]interface{}
+}
+
+// recordingLogger records the logs into entries shared by the loggers derived from it, so the helpers can be
+// tested with an injected logger instead of the global one.
+type recordingLogger struct {
+	entries       *[]logEntry
+	keysAndValues []interface{}
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{entries: &[]logEntry{}}
+}
+
+func (l *recordingLogger) Enabled() bool { return true }
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.record(msg, nil, keysAndValues)
+}
+
+func (l *recordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.record(msg, err, keysAndValues)
+}
+
+func (l *recordingLogger) V(_ int) logr.Logger { return l }
+
+func (l *recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return &recordingLogger{entries: l.entries, keysAndValues: append(append([]interface{}{}, l.keysAndValues...), keysAndValues...)}
+}
+
+func (l *recordingLogger) WithName(_ string) logr.Logger { return l }
+
+func (l *recordingLogger) record(msg string, err error, keysAndValues []interface{}) {
+	entry := logEntry{msg: msg, err: err, keysAndValues: make(map[string]interface{})}
+	all := append(append([]interface{}{}, l.keysAndValues...), keysAndValues...)
+	for i := 0; i+1 < len(all); i += 2 {
+		entry.keysAndValues[fmt.Sprint(all[i])] = all[i+1]
+	}
+	*l.entries = append(*l.entries, entry)
+}
+
+// find returns the first entry with the message.
+func (l *recordingLogger) find(msg string) *logEntry {
+	for i := range *l.entries {
+		if (*l.entries)[i].msg == msg {
+			return &(*l.entries)[i]
+		}
+	}
+	return nil
+}
+
+func TestStructuredLogging(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	component := newTestComponent(testComponent, testImage)
+
+	tests := []struct {
+		name         string
+		run          func(ctx context.Context, r *ReconcilePlatformAdmin) error
+		expectMsg    string
+		expectValues map[string]interface{}
+	}{
+		{
+			name: "handleService",
+			run: func(ctx context.Context, r *ReconcilePlatformAdmin) error {
+				_, err := r.handleService(ctx, pa, component)
+				return err
+			},
+			expectMsg:    "Reconciled service",
+			expectValues: map[string]interface{}{"component": testComponent, "service": testComponent},
+		},
+		{
+			name: "handleYurtAppSet",
+			run: func(ctx context.Context, r *ReconcilePlatformAdmin) error {
+				_, err := r.handleYurtAppSet(ctx, pa, component, newTestConfiguration(component))
+				return err
+			},
+			expectMsg:    "Create YurtAppSet",
+			expectValues: map[string]interface{}{"component": testComponent, "yurtappset": testComponent, "pools": "hangzhou"},
+		},
+		{
+			name: "reconcileConfigmap",
+			run: func(ctx context.Context, r *ReconcilePlatformAdmin) error {
+				_, err := r.reconcileConfigmap(ctx, pa, &pa.Status, r.getConfiguration())
+				return err
+			},
+			expectMsg:    "Reconciled configmap",
+			expectValues: map[string]interface{}{"configmap": "common-variable-levski"},
+		},
+		{
+			name: "Reconcile",
+			run: func(ctx context.Context, r *ReconcilePlatformAdmin) error {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pa.Namespace, Name: pa.Name}})
+				return err
+			},
+			expectMsg:    "Create YurtAppSet",
+			expectValues: map[string]interface{}{"controller": ControllerName, "platformadmin": "default/edgex", "component": testComponent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(component), pa.DeepCopy())
+			logger := newRecordingLogger()
+			if err := tt.run(log.IntoContext(context.TODO(), logger), r); err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+
+			entry := logger.find(tt.expectMsg)
+			if entry == nil {
+				t.Fatalf("expect log %q, but got %v", tt.expectMsg, *logger.entries)
+			}
+			for k, v := range tt.expectValues {
+				if entry.keysAndValues[k] != v {
+					t.Errorf("expect %s=%v in log %q, but got %v", k, v, tt.expectMsg, entry.keysAndValues)
+				}
+			}
+		})
+	}
+}
+
+// noKindClient fails the requests of YurtAppSets like the CRD of YurtAppSet is not installed.
+type noKindClient struct {
+	client.Client
+}
+
+func (c *noKindClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*appsv1alpha1.YurtAppSet); ok {
+		return &meta.NoKindMatchError{GroupKind: yurtAppSetKind.GroupKind(), SearchedVersions: []string{yurtAppSetKind.Version}}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileDependencyMissing(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	r.Client = &noKindClient{Client: r.Client}
+
+	result := reconcilePlatformAdmin(t, r, pa)
+	if result.RequeueAfter != dependencyMissingRequeueAfter {
+		t.Errorf("expect requeue after %v, but got %+v", dependencyMissingRequeueAfter, result)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+	if cond == nil || cond.Reason != iotv1alpha2.DependencyMissingReason || !strings.Contains(cond.Message, "apps.openyurt.io/v1alpha1 YurtAppSet") {
+		t.Errorf("expect dependency missing condition, but got %v", cond)
+	}
+}
+
+func TestUnreadyComponentWorkloadDetail(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            testComponent + "-hangzhou-x8k2p",
+			Namespace:       pa.Namespace,
+			Labels:          map[string]string{appsv1alpha1.PoolNameLabelKey: "hangzhou"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(yas, appsv1alpha1.SchemeGroupVersion.WithKind("YurtAppSet"))},
+		},
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable", Message: "Deployment does not have minimum availability."},
+			{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", Message: "pods is forbidden: exceeded quota"},
+		}},
+	}
+	if err := r.Create(context.TODO(), deployment); err != nil {
+		t.Fatalf("failed to create deployment, %v", err)
+	}
+
+	// the most relevant condition of the workloads is copied into the condition of PlatformAdmin
+	reconcilePlatformAdmin(t, r, pa)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+	expectMessage := fmt.Sprintf("%s: Deployment/%s FailedCreate: pods is forbidden: exceeded quota", testComponent, deployment.Name)
+	if cond == nil || cond.Reason != iotv1alpha2.DeploymentNotReadyReason || !strings.Contains(cond.Message, expectMessage) {
+		t.Errorf("expect message contains %q, but got %v", expectMessage, cond)
+	}
+}
+
+func TestAdoptYurtAppSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expectAdopt bool
+	}{
+		{
+			name: "refuse user created yurtappset",
+		},
+		{
+			name:        "adopt annotated yurtappset",
+			annotations: map[string]string{iotv1alpha2.AnnotationAdoptable: "true"},
+			expectAdopt: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.UID = "edgex-uid"
+			userYas := &appsv1alpha1.YurtAppSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testComponent,
+					Namespace:   pa.Namespace,
+					Annotations: tt.annotations,
+				},
+				Spec: appsv1alpha1.YurtAppSetSpec{
+					WorkloadTemplate: appsv1alpha1.WorkloadTemplate{
+						DeploymentTemplate: newDeploymentTemplate(newTestComponent(testComponent, "user/core-command:1.0")),
+					},
+				},
+			}
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, userYas)
+			reconcilePlatformAdmin(t, r, pa)
+
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentConflictCondition)
+			if cond == nil {
+				t.Fatalf("expect condition %s, but got nil", iotv1alpha2.ComponentConflictCondition)
+			}
+
+			if !tt.expectAdopt {
+				if len(yas.OwnerReferences) != 0 || len(yas.Spec.Topology.Pools) != 0 || image != "user/core-command:1.0" {
+					t.Errorf("expect yurtappset is not changed, but got %v", yas)
+				}
+				if cond.Status != corev1.ConditionTrue || !strings.Contains(cond.Message, testComponent) {
+					t.Errorf("expect conflict condition naming %s, but got %v", testComponent, cond)
+				}
+				return
+			}
+
+			if !isOwnedBy(yas, pa) {
+				t.Errorf("expect yurtappset is owned by PlatformAdmin, but got %v", yas.OwnerReferences)
+			}
+			if yas.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelDeployment {
+				t.Errorf("expect label %s, but got %v", iotv1alpha2.LabelPlatformAdminGenerate, yas.Labels)
+			}
+			if image != testImage {
+				t.Errorf("expect workload template is reconciled to image %s, but got %s", testImage, image)
+			}
+			if len(yas.Spec.Topology.Pools) != 1 || yas.Spec.Topology.Pools[0].Name != pa.Spec.PoolName {
+				t.Errorf("expect pool %s, but got %v", pa.Spec.PoolName, yas.Spec.Topology.Pools)
+			}
+			if cond.Status != corev1.ConditionFalse {
+				t.Errorf("expect no conflict, but got %v", cond)
+			}
+		})
+	}
+}
+
+// patchErrorClient fails the patches of yurtappsets with the given error.
+type patchErrorClient struct {
+	client.Client
+	err error
+}
+
+func (c *patchErrorClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if _, ok := obj.(*appsv1alpha1.YurtAppSet); ok {
+		return c.err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestRejectedYurtAppSetPatch(t *testing.T) {
+	yasGroupKind := appsv1alpha1.GroupVersion.WithKind("YurtAppSet").GroupKind()
+	tests := []struct {
+		name        string
+		err         error
+		expectError bool
+	}{
+		{
+			name: "rejected by webhook",
+			err: apierrors.NewInvalid(yasGroupKind, testComponent, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "topology", "pools"), "may not be changed in an update"),
+			}),
+		},
+		{
+			name:        "conflict",
+			err:         apierrors.NewConflict(appsv1alpha1.GroupVersion.WithResource("yurtappsets").GroupResource(), testComponent, errors.New("the object has been modified")),
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			// the pool is changed, so the yurtappset is patched
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Spec.NodeSelectorRequirements = []corev1.NodeSelectorRequirement{{Key: "gpu", Operator: corev1.NodeSelectorOpExists}}
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			r.Client = &patchErrorClient{Client: r.Client, err: tt.err}
+			result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expect error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if result.RequeueAfter != rejectedRequeueAfter {
+				t.Errorf("expect requeue after %v, but got %v", rejectedRequeueAfter, result)
+			}
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+			if cond == nil || cond.Reason != iotv1alpha2.ComponentRejectedReason ||
+				!strings.Contains(cond.Message, testComponent) || !strings.Contains(cond.Message, "may not be changed in an update") {
+				t.Errorf("expect rejected condition of component %s, but got %v", testComponent, cond)
+			}
+			if event, ok := findEvent(r, corev1.EventTypeWarning); !ok {
+				t.Errorf("expect warning event %s, but got nothing", iotv1alpha2.ComponentRejectedReason)
+			} else if !strings.HasPrefix(event, corev1.EventTypeWarning+" "+iotv1alpha2.ComponentRejectedReason) {
+				t.Errorf("expect warning event %s, but got %s", iotv1alpha2.ComponentRejectedReason, event)
+			}
+		})
+	}
+}
+
+func TestRejectedComponentObjects(t *testing.T) {
+	minAvailable := intstr.FromInt(1)
+	tests := []struct {
+		name  string
+		obj   client.Object
+		err   error
+		setup func(pa *iotv1alpha2.PlatformAdmin)
+	}{
+		{
+			name: "poddisruptionbudget rejected by webhook",
+			obj:  &policyv1.PodDisruptionBudget{},
+			err: apierrors.NewInvalid(policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget").GroupKind(), testComponent, field.ErrorList{
+				field.Invalid(field.NewPath("spec", "minAvailable"), minAvailable.String(), "denied by the admission policy"),
+			}),
+			setup: func(pa *iotv1alpha2.PlatformAdmin) {
+				pa.Spec.PodDisruptionBudget = &iotv1alpha2.PodDisruptionBudgetSpec{MinAvailable: &minAvailable}
+			},
+		},
+		{
+			name: "networkpolicy rejected by webhook",
+			obj:  &networkingv1.NetworkPolicy{},
+			err: apierrors.NewInvalid(networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy").GroupKind(), testComponent, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "ingress"), "denied by the admission policy"),
+			}),
+			setup: func(pa *iotv1alpha2.PlatformAdmin) { pa.Spec.NetworkPolicy = true },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			tt.setup(pa)
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			r.Client = &deniedCreateClient{Client: r.Client, obj: tt.obj, err: tt.err}
+
+			// the rejected objects are reported instead of backing off with errors
+			result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)})
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if result.RequeueAfter != rejectedRequeueAfter {
+				t.Errorf("expect requeue after %v, but got %v", rejectedRequeueAfter, result)
+			}
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+			if cond == nil || cond.Reason != iotv1alpha2.ComponentRejectedReason ||
+				!strings.Contains(cond.Message, testComponent) || !strings.Contains(cond.Message, "denied by the admission policy") {
+				t.Errorf("expect rejected condition of component %s, but got %v", testComponent, cond)
+			}
+		})
+	}
+}
+
+// deniedCreateClient fails the creation and the apply of the objects of the given type with the error.
+type deniedCreateClient struct {
+	client.Client
+	obj client.Object
+	err error
+}
+
+func (c *deniedCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if reflect.TypeOf(obj) == reflect.TypeOf(c.obj) {
+		return c.err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *deniedCreateClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if reflect.TypeOf(obj) == reflect.TypeOf(c.obj) && patch.Type() == types.ApplyPatchType {
+		return c.err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestDeniedRequests(t *testing.T) {
+	rbacDenied := func(resource string) error {
+		return apierrors.NewForbidden(corev1.Resource(resource), "edgex",
+			fmt.Errorf(`User "system:serviceaccount:kube-system:yurt-manager" cannot create resource %q in API group "" in the namespace "default"`, resource))
+	}
+	quotaExceeded := apierrors.NewForbidden(corev1.Resource("services"), testComponent,
+		errors.New("exceeded quota: edgex, requested: services=1, used: services=10, limited: services=10"))
+	namespaceTerminating := apierrors.NewForbidden(appsv1alpha1.GroupVersion.WithResource("yurtappsets").GroupResource(), testComponent,
+		errors.New("unable to create new content in namespace default because it is being terminated"))
+	namespaceTerminating.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: corev1.NamespaceTerminatingCause, Field: "metadata.namespace"}}
+
+	tests := []struct {
+		name            string
+		obj             client.Object
+		err             error
+		expectCondition iotv1alpha2.PlatformAdminConditionType
+		expectReason    string
+	}{
+		{
+			name:            "configmap denied by rbac",
+			obj:             &corev1.ConfigMap{},
+			err:             rbacDenied("configmaps"),
+			expectCondition: iotv1alpha2.ConfigmapAvailableCondition,
+			expectReason:    iotv1alpha2.RBACDeniedReason,
+		},
+		{
+			name:            "service exceeds quota",
+			obj:             &corev1.Service{},
+			err:             quotaExceeded,
+			expectCondition: iotv1alpha2.ComponentAvailableCondition,
+			expectReason:    iotv1alpha2.QuotaExceededReason,
+		},
+		{
+			name:            "yurtappset denied by rbac",
+			obj:             &appsv1alpha1.YurtAppSet{},
+			err:             rbacDenied("yurtappsets"),
+			expectCondition: iotv1alpha2.ComponentAvailableCondition,
+			expectReason:    iotv1alpha2.RBACDeniedReason,
+		},
+		{
+			name:            "yurtappset in terminating namespace",
+			obj:             &appsv1alpha1.YurtAppSet{},
+			err:             namespaceTerminating,
+			expectCondition: iotv1alpha2.ComponentAvailableCondition,
+			expectReason:    iotv1alpha2.NamespaceTerminatingReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			r.Client = &deniedCreateClient{Client: r.Client, obj: tt.obj, err: tt.err}
+
+			// the denied requests are retried with a long delay instead of backing off with errors
+			result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pa)})
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if result.RequeueAfter != deniedRequeueAfter {
+				t.Errorf("expect requeue after %v, but got %v", deniedRequeueAfter, result)
+			}
+
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, tt.expectCondition)
+			if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != tt.expectReason || !strings.Contains(cond.Message, tt.err.Error()) {
+				t.Errorf("expect condition %s with reason %s, but got %v", tt.expectCondition, tt.expectReason, cond)
+			}
+			if event, ok := findEvent(r, corev1.EventTypeWarning); !ok {
+				t.Errorf("expect warning event %s, but got nothing", tt.expectReason)
+			} else if !strings.HasPrefix(event, corev1.EventTypeWarning+" "+tt.expectReason) {
+				t.Errorf("expect warning event %s, but got %s", tt.expectReason, event)
+			}
+		})
+	}
+}
+
+func TestAnnotationToComponent(t *testing.T) {
+	newDeployment := func(name string) iotv1alpha1.DeploymentTemplateSpec {
+		return iotv1alpha1.DeploymentTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       *newTestComponent(name, testImage).Deployment,
+		}
+	}
+	newService := func(name string, port int32) iotv1alpha1.ServiceTemplateSpec {
+		return iotv1alpha1.ServiceTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: port}}},
+		}
+	}
+	toAnnotations := func(deployments []iotv1alpha1.DeploymentTemplateSpec, services []iotv1alpha1.ServiceTemplateSpec) map[string]string {
+		annotations := make(map[string]string)
+		if deployments != nil {
+			data, _ := json.Marshal(deployments)
+			annotations[iotv1alpha1.AnnotationAdditionalDeployments] = string(data)
+		}
+		if services != nil {
+			data, _ := json.Marshal(services)
+			annotations[iotv1alpha1.AnnotationAdditionalServices] = string(data)
+		}
+		return annotations
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		// expectPorts are the service ports of expectNames, 0 means the component has no service
+		expectNames []string
+		expectPorts []int32
+		expectError string
+	}{
+		{
+			name:        "no additional components",
+			annotations: map[string]string{},
+		},
+		{
+			name: "multiple services",
+			annotations: toAnnotations(
+				[]iotv1alpha1.DeploymentTemplateSpec{newDeployment("device-b"), newDeployment("device-a")},
+				[]iotv1alpha1.ServiceTemplateSpec{newService("device-a", 1001), newService("device-b", 1002)}),
+			expectNames: []string{"device-a", "device-b"},
+			expectPorts: []int32{1001, 1002},
+		},
+		{
+			name: "service only and deployment only",
+			annotations: toAnnotations(
+				[]iotv1alpha1.DeploymentTemplateSpec{newDeployment("device-c")},
+				[]iotv1alpha1.ServiceTemplateSpec{newService("device-z", 1003), newService("device-a", 1001)}),
+			expectNames: []string{"device-a", "device-c", "device-z"},
+			expectPorts: []int32{1001, 0, 1003},
+		},
+		{
+			name: "duplicate deployments",
+			annotations: toAnnotations(
+				[]iotv1alpha1.DeploymentTemplateSpec{newDeployment("device-a"), newDeployment("device-a")}, nil),
+			expectError: "duplicate additional deployment device-a",
+		},
+		{
+			name: "duplicate services",
+			annotations: toAnnotations(nil,
+				[]iotv1alpha1.ServiceTemplateSpec{newService("device-a", 1001), newService("device-a", 1002)}),
+			expectError: "duplicate additional service device-a",
+		},
+		{
+			name: "invalid component",
+			annotations: toAnnotations(
+				[]iotv1alpha1.DeploymentTemplateSpec{{ObjectMeta: metav1.ObjectMeta{Name: "device-a"}, Spec: *newTestComponent("device-a", "").Deployment}}, nil),
+			expectError: "invalid component device-a: container device-a has no image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, err := annotationToComponent(tt.annotations)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expect error %s, but got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if len(components) != len(tt.expectNames) {
+				t.Fatalf("expect components %v, but got %d components", tt.expectNames, len(components))
+			}
+			for i, component := range components {
+				if component.Name != tt.expectNames[i] {
+					t.Errorf("expect component %s at %d, but got %s", tt.expectNames[i], i, component.Name)
+				}
+				var port int32
+				if component.Service != nil {
+					port = component.Service.Ports[0].Port
+				}
+				if port != tt.expectPorts[i] {
+					t.Errorf("expect service port %d of component %s, but got %d", tt.expectPorts[i], component.Name, port)
+				}
+			}
+
+			// the order is stable across calls
+			again, _ := annotationToComponent(tt.annotations)
+			for i := range again {
+				if again[i].Name != components[i].Name {
+					t.Errorf("expect stable order %v, but got %s at %d", tt.expectNames, again[i].Name, i)
+				}
+			}
+		})
+	}
+}
+
+func TestAdditionalComponentsCap(t *testing.T) {
+	newAnnotations := func(count int) map[string]string {
+		services := make([]iotv1alpha1.ServiceTemplateSpec, 0, count)
+		for i := 0; i < count; i++ {
+			services = append(services, iotv1alpha1.ServiceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("device-%d", i)},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 1001}}},
+			})
+		}
+		data, _ := json.Marshal(services)
+		return map[string]string{iotv1alpha1.AnnotationAdditionalServices: string(data)}
+	}
+
+	if components, err := annotationToComponent(newAnnotations(config.MaxAdditionalComponents)); err != nil || len(components) != config.MaxAdditionalComponents {
+		t.Errorf("expect %d components at the cap, but got %d, %v", config.MaxAdditionalComponents, len(components), err)
+	}
+	_, err := annotationToComponent(newAnnotations(config.MaxAdditionalComponents + 1))
+	expectError := fmt.Sprintf("%d exceeds the maximum %d", config.MaxAdditionalComponents+1, config.MaxAdditionalComponents)
+	if !errors.Is(err, config.ErrTooManyAdditionalComponents) || !strings.Contains(err.Error(), expectError) {
+		t.Errorf("expect error %s, but got %v", expectError, err)
+	}
+
+	// the PlatformAdmin over the cap is reported instead of retried
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Annotations = newAnnotations(config.MaxAdditionalComponents + 1)
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	if result := reconcilePlatformAdmin(t, r, pa); result.Requeue || result.RequeueAfter != 0 {
+		t.Errorf("expect no requeue, but got %+v", result)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+	if cond == nil || cond.Reason != iotv1alpha2.ComponentProvisioningFailedReason {
+		t.Errorf("expect component provisioning failed condition, but got %v", cond)
+	}
+	if event, ok := findEvent(r, corev1.EventTypeWarning); !ok {
+		t.Errorf("expect warning event, but got nothing")
+	} else if !strings.HasPrefix(event, corev1.EventTypeWarning) {
+		t.Errorf("expect warning event, but got %s", event)
+	}
+}
+
+func TestInvalidComponentSet(t *testing.T) {
+	newNodePortComponent := func(name string, nodePort int32) *config.Component {
+		component := newTestComponent(name, testImage)
+		component.Service.Ports[0].NodePort = nodePort
+		return component
+	}
+	tests := []struct {
+		name        string
+		components  []*config.Component
+		annotations map[string]string
+	}{
+		{
+			name:        "additional component with the name of embed component",
+			components:  []*config.Component{newTestComponent(testComponent, testImage)},
+			annotations: newAdditionalComponentAnnotations(testComponent),
+		},
+		{
+			name:       "node port collision",
+			components: []*config.Component{newNodePortComponent(testComponent, 30082), newNodePortComponent("edgex-core-data", 30082)},
+		},
+		{
+			name:       "additional component without image",
+			components: []*config.Component{newTestComponent(testComponent, testImage)},
+			annotations: func() map[string]string {
+				deployments, _ := json.Marshal([]iotv1alpha1.DeploymentTemplateSpec{{
+					ObjectMeta: metav1.ObjectMeta{Name: "device-virtual"},
+					Spec:       *newTestComponent("device-virtual", "").Deployment,
+				}})
+				return map[string]string{iotv1alpha1.AnnotationAdditionalDeployments: string(deployments)}
+			}(),
+		},
+		{
+			name:       "component with invalid name",
+			components: []*config.Component{newTestComponent("Edgex_Core", testImage)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			if tt.annotations != nil {
+				pa.Annotations = tt.annotations
+			}
+			r := newTestReconciler(newTestConfiguration(tt.components...), pa)
+			if result := reconcilePlatformAdmin(t, r, pa); result.Requeue || result.RequeueAfter != 0 {
+				t.Errorf("expect no requeue, but got %+v", result)
+			}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.ComponentAvailableCondition)
+			if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != iotv1alpha2.ComponentSpecInvalidReason {
+				t.Errorf("expect component spec invalid condition, but got %v", cond)
+			}
+			if event, ok := findEvent(r, corev1.EventTypeWarning); !ok {
+				t.Errorf("expect warning event, but got nothing")
+			} else if !strings.HasPrefix(event, corev1.EventTypeWarning+" "+iotv1alpha2.ComponentSpecInvalidReason) {
+				t.Errorf("expect warning event of invalid component set, but got %s", event)
+			}
+
+			// nothing of the components is created
+			services := &corev1.ServiceList{}
+			yurtAppSets := &appsv1alpha1.YurtAppSetList{}
+			for _, list := range []client.ObjectList{services, yurtAppSets} {
+				if err := r.List(context.TODO(), list); err != nil {
+					t.Fatalf("failed to list %T, %v", list, err)
+				}
+			}
+			if len(services.Items) != 0 || len(yurtAppSets.Items) != 0 {
+				t.Errorf("expect no service and yurtappset, but got %d services and %d yurtappsets", len(services.Items), len(yurtAppSets.Items))
+			}
+		})
+	}
+}
+
+type fakeFieldIndexer struct {
+	err error
+}
+
+func (f *fakeFieldIndexer) IndexField(_ context.Context, _ client.Object, _ string, _ client.IndexerFunc) error {
+	return f.err
+}
+
+// fakeManager only provides the field indexer, add() must fail before using anything else.
+type fakeManager struct {
+	manager.Manager
+	fieldIndexer client.FieldIndexer
+}
+
+func (m *fakeManager) GetFieldIndexer() client.FieldIndexer {
+	return m.fieldIndexer
+}
+
+func TestAddFailsWhenFieldIndexersFail(t *testing.T) {
+	mgr := &fakeManager{fieldIndexer: &fakeFieldIndexer{err: errors.New("informer has started")}}
+	if err := add(mgr, newTestReconciler(newTestConfiguration())); err == nil {
+		t.Errorf("expect add fails when field indexers can not be registered")
+	}
+}
+
+func TestNewControllerOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        map[string]string
+		expectDelays []time.Duration
+	}{
+		{
+			name:         "default backoff",
+			expectDelays: []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond},
+		},
+		{
+			name: "configured backoff",
+			flags: map[string]string{
+				"platformadmin-rate-limiter-base-delay": "1s",
+				"platformadmin-rate-limiter-max-delay":  "3s",
+			},
+			expectDelays: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDelay, maxDelay := rateLimiterBaseDelay, rateLimiterMaxDelay
+			defer func() {
+				rateLimiterBaseDelay, rateLimiterMaxDelay = baseDelay, maxDelay
+			}()
+			for name, value := range tt.flags {
+				if err := flag.Set(name, value); err != nil {
+					t.Fatalf("failed to set flag %s, %v", name, err)
+				}
+			}
+
+			r := newTestReconciler(newTestConfiguration())
+			options := newControllerOptions(r)
+			if options.Reconciler != r || options.MaxConcurrentReconciles != concurrentReconciles {
+				t.Errorf("expect reconciler and workers are set, but got %+v", options)
+			}
+			item := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "edgex"}}
+			for i, expect := range tt.expectDelays {
+				if delay := options.RateLimiter.When(item); delay != expect {
+					t.Errorf("expect delay %v of retry %d, but got %v", expect, i, delay)
+				}
+			}
+			options.RateLimiter.Forget(item)
+			if delay := options.RateLimiter.When(item); delay != tt.expectDelays[0] {
+				t.Errorf("expect delay %v after forgetting, but got %v", tt.expectDelays[0], delay)
+			}
+		})
+	}
+}
+
+// latencyClient delays the requests of services and yurtappsets, and records how many of them are in flight.
+type latencyClient struct {
+	client.Client
+	latency time.Duration
+
+	mu          sync.Mutex
+	inflight    int
+	maxInflight int
+}
+
+func (c *latencyClient) delay(obj client.Object) func() {
+	switch obj.(type) {
+	case *corev1.Service, *appsv1alpha1.YurtAppSet:
+	default:
+		return func() {}
+	}
+	c.mu.Lock()
+	c.inflight++
+	if c.inflight > c.maxInflight {
+		c.maxInflight = c.inflight
+	}
+	c.mu.Unlock()
+	time.Sleep(c.latency)
+	return func() {
+		c.mu.Lock()
+		c.inflight--
+		c.mu.Unlock()
+	}
+}
+
+func (c *latencyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	defer c.delay(obj)()
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *latencyClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	defer c.delay(obj)()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *latencyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer c.delay(obj)()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcileComponentsInParallel(t *testing.T) {
+	defer func(workers int) { componentWorkers = workers }(componentWorkers)
+
+	var components []*config.Component
+	objs := []client.Object{newTestNode("node1", "hangzhou")}
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("edgex-component-%d", i)
+		components = append(components, newTestComponent(name, testImage))
+		objs = append(objs, newTestEndpoints("default", name, "node1"))
+	}
+	// the even components are ready, the odd ones are not
+	setPoolReady := func(r *ReconcilePlatformAdmin) {
+		t.Helper()
+		for i, component := range components {
+			yas := getYurtAppSet(t, r, "default", component.Name)
+			yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+			yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": int32(1 - i%2)}
+			if err := r.Status().Update(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to update YurtAppSet status, %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name              string
+		workers           int
+		expectMaxInflight int
+	}{
+		{name: "components are reconciled one by one", workers: 1, expectMaxInflight: 1},
+		{name: "workers are at least one", workers: 0, expectMaxInflight: 1},
+		{name: "components are reconciled by bounded workers", workers: 4, expectMaxInflight: 4},
+	}
+	var statuses []iotv1alpha2.PlatformAdminStatus
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			componentWorkers = tt.workers
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			r := newTestReconciler(newTestConfiguration(components...), append(objs, pa)...)
+			c := &latencyClient{Client: r.Client, latency: 20 * time.Millisecond}
+			r.Client = c
+
+			reconcilePlatformAdmin(t, r, pa)
+			setPoolReady(r)
+			reconcilePlatformAdmin(t, r, pa)
+
+			if c.maxInflight != tt.expectMaxInflight {
+				t.Errorf("expect %d requests in flight at most, but got %d", tt.expectMaxInflight, c.maxInflight)
+			}
+			for _, component := range components {
+				if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: component.Name}, &corev1.Service{}); err != nil {
+					t.Errorf("expect service of component %s is created, but got %v", component.Name, err)
+				}
+			}
+			latest := &iotv1alpha2.PlatformAdmin{}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if latest.Status.ReadyComponentNum != 3 || latest.Status.UnreadyComponentNum != 3 {
+				t.Errorf("expect 3 ready and 3 unready components, but got %d and %d", latest.Status.ReadyComponentNum, latest.Status.UnreadyComponentNum)
+			}
+			statuses = append(statuses, latest.Status)
+		})
+	}
+
+	// the status does not depend on the number of workers
+	for i := 1; i < len(statuses); i++ {
+		if !reflect.DeepEqual(statuses[0].ManagedResources, statuses[i].ManagedResources) {
+			t.Errorf("expect managed resources %v, but got %v", statuses[0].ManagedResources, statuses[i].ManagedResources)
+		}
+		for _, condition := range statuses[0].Conditions {
+			got := util.GetPlatformAdminCondition(statuses[i], condition.Type)
+			if got == nil || got.Status != condition.Status || got.Reason != condition.Reason || got.Message != condition.Message {
+				t.Errorf("expect condition %v, but got %v", condition, got)
+			}
+		}
+	}
+}
+
+func TestSecurityMigration(t *testing.T) {
+	const (
+		securityComponent = "edgex-security-proxy-setup"
+		insecureComponent = "edgex-insecure-ui"
+	)
+	conf := &config.PlatformAdminControllerConfiguration{
+		SecurityComponents: map[string][]*config.Component{
+			testVersion: {newTestComponent(testComponent, testImage), newTestComponent(securityComponent, testImage)},
+		},
+		NoSectyComponents: map[string][]*config.Component{
+			testVersion: {newTestComponent(testComponent, testImage), newTestComponent(insecureComponent, testImage)},
+		},
+		SecurityConfigMaps: map[string][]corev1.ConfigMap{
+			testVersion: {{ObjectMeta: metav1.ObjectMeta{Name: "security-variable-levski"}}},
+		},
+		NoSectyConfigMaps: map[string][]corev1.ConfigMap{
+			testVersion: {{ObjectMeta: metav1.ObjectMeta{Name: "common-variable-levski"}}},
+		},
+	}
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	r := newTestReconciler(conf, pa, newTestNode("node1", "hangzhou"), newTestEndpoints("default", testComponent, "node1"),
+		newTestEndpoints("default", securityComponent, "node1"), newTestEndpoints("default", insecureComponent, "node1"))
+	getPlatformAdmin := func() *iotv1alpha2.PlatformAdmin {
+		t.Helper()
+		latest := &iotv1alpha2.PlatformAdmin{}
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		return latest
+	}
+	setPoolReady := func(names ...string) {
+		t.Helper()
+		for _, name := range names {
+			yas := getYurtAppSet(t, r, pa.Namespace, name)
+			yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+			yas.Status.PoolReadyReplicas = map[string]int32{"hangzhou": 1}
+			if err := r.Status().Update(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to update YurtAppSet status, %v", err)
+			}
+		}
+	}
+	// The yurtappset without the pool may be deleted by removeOwner, both of them mean the pool is released
+	hasPool := func(name string) bool {
+		t.Helper()
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: name}, yas); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false
+			}
+			t.Fatalf("failed to get YurtAppSet %s, %v", name, err)
+		}
+		for _, pool := range yas.Spec.Topology.Pools {
+			if pool.Name == "hangzhou" {
+				return true
+			}
+		}
+		return false
+	}
+	isConfigMapOwned := func(name string) bool {
+		t.Helper()
+		configmap := &corev1.ConfigMap{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: name}, configmap); err != nil {
+			return false
+		}
+		return isOwnedBy(configmap, getPlatformAdmin())
+	}
+	expectMigration := func(reason string, currentSecurity bool) {
+		t.Helper()
+		latest := getPlatformAdmin()
+		if latest.Status.CurrentSecurity == nil || *latest.Status.CurrentSecurity != currentSecurity {
+			t.Errorf("expect current security %v, but got %v", currentSecurity, latest.Status.CurrentSecurity)
+		}
+		condition := util.GetPlatformAdminCondition(latest.Status, iotv1alpha2.SecurityMigrationCondition)
+		if reason == "" {
+			if condition != nil {
+				t.Errorf("expect no condition %s, but got %v", iotv1alpha2.SecurityMigrationCondition, condition)
+			}
+			return
+		}
+		if condition == nil || condition.Reason != reason {
+			t.Errorf("expect condition %s with reason %s, but got %v", iotv1alpha2.SecurityMigrationCondition, reason, condition)
+		}
+	}
+
+	// the components of insecure mode are deployed directly
+	reconcilePlatformAdmin(t, r, pa)
+	setPoolReady(testComponent, insecureComponent)
+	reconcilePlatformAdmin(t, r, pa)
+	if latest := getPlatformAdmin(); !latest.Status.Ready {
+		t.Fatalf("expect PlatformAdmin is ready in insecure mode, but got %v", latest.Status.Conditions)
+	}
+	expectMigration("", false)
+
+	// the security mode is toggled, the old component is kept until the new one is ready
+	latest := getPlatformAdmin()
+	latest.Spec.Security = true
+	if err := r.Update(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	reconcilePlatformAdmin(t, r, pa)
+	if !hasPool(securityComponent) {
+		t.Errorf("expect the component %s of the new mode is deployed", securityComponent)
+	}
+	if !hasPool(insecureComponent) {
+		t.Errorf("expect the component %s of the previous mode is kept before the new ones are ready", insecureComponent)
+	}
+	if !isConfigMapOwned("common-variable-levski") || !isConfigMapOwned("security-variable-levski") {
+		t.Errorf("expect the configmaps of both modes are owned during the migration")
+	}
+	expectMigration(iotv1alpha2.SecurityMigrationProvisioningReason, false)
+
+	// the new component is ready, the old one is released
+	setPoolReady(securityComponent)
+	if result := reconcilePlatformAdmin(t, r, pa); !result.Requeue {
+		t.Errorf("expect requeue after the migration is completed, but got %v", result)
+	}
+	if hasPool(insecureComponent) {
+		t.Errorf("expect the component %s of the previous mode is released after the new ones are ready", insecureComponent)
+	}
+	if !hasPool(securityComponent) || !hasPool(testComponent) {
+		t.Errorf("expect the components of the new mode are kept")
+	}
+	expectMigration(iotv1alpha2.SecurityMigrationCompletedReason, true)
+	if latest := getPlatformAdmin(); !latest.Status.Ready {
+		t.Errorf("expect PlatformAdmin is ready after the migration, but got %v", latest.Status.Conditions)
+	}
+
+	// the configmaps of the previous mode are released by the requeued reconcile
+	reconcilePlatformAdmin(t, r, pa)
+	if isConfigMapOwned("common-variable-levski") || !isConfigMapOwned("security-variable-levski") {
+		t.Errorf("expect only the configmaps of the new mode are owned after the migration")
+	}
+}
+
+// countEvents drains the events of the fake recorder and counts the ones with the reason.
+func countEvents(r *ReconcilePlatformAdmin, reason string) int {
+	count := 0
+	for {
+		select {
+		case event := <-r.recorder.(*record.FakeRecorder).Events:
+			if strings.Contains(event, " "+reason+" ") {
+				count++
+			}
+		default:
+			return count
+		}
+	}
+}
+
+// findEvent returns the first event with the given prefix, e.g. the type and reason of event, the events before it
+// are dropped.
+func findEvent(r *ReconcilePlatformAdmin, prefix string) (string, bool) {
+	for {
+		select {
+		case event := <-r.recorder.(*record.FakeRecorder).Events:
+			if strings.HasPrefix(event, prefix) {
+				return event, true
+			}
+		default:
+			return "", false
+		}
+	}
+}
+
+func TestUnmanagedYurtAppSet(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa,
+		newTestNode("node-1", "hangzhou"), newTestEndpoints(pa.Namespace, testComponent, "node-1"))
+	reconcilePlatformAdmin(t, r, pa)
+
+	// the image is hotfixed manually, and the yurtappset is marked as unmanaged
+	hotfixImage := "edgexfoundry/core-command:2.3.1-hotfix"
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Annotations[iotv1alpha2.AnnotationUnmanaged] = "true"
+	yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image = hotfixImage
+	yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, appsv1alpha1.Pool{Name: "hotfix"})
+	if err := r.Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet, %v", err)
+	}
+	setPoolReadyReplicas(t, r, getYurtAppSet(t, r, pa.Namespace, testComponent), "hangzhou", 1)
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Status.PoolReplicas = map[string]int32{"hangzhou": 1}
+	yas.Status.ObservedGeneration = yas.Generation
+	if err := r.Status().Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update the status of YurtAppSet, %v", err)
+	}
+	countEvents(r, eventReasonUnmanaged)
+
+	// the template of component changes, while the unmanaged yurtappset is left alone
+	r.configuration.Store(newTestConfiguration(newTestComponent(testComponent, "edgexfoundry/core-command:2.3.2")))
+	for i := 0; i < 2; i++ {
+		reconcilePlatformAdmin(t, r, pa)
+	}
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != hotfixImage {
+		t.Errorf("expect the hotfix image %s is kept, but got %s", hotfixImage, image)
+	}
+	if len(yas.Spec.Topology.Pools) != 2 {
+		t.Errorf("expect the topology is kept, but got %v", yas.Spec.Topology.Pools)
+	}
+	if count := countEvents(r, eventReasonUnmanaged); count != 1 {
+		t.Errorf("expect one event on the first detection, but got %d", count)
+	}
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if !reflect.DeepEqual(latest.Status.UnmanagedComponents, []string{testComponent}) {
+		t.Errorf("expect unmanaged components [%s], but got %v", testComponent, latest.Status.UnmanagedComponents)
+	}
+	if latest.Status.ReadyComponentNum != 1 {
+		t.Errorf("expect the readiness of unmanaged component is told by its status, but got %d ready components", latest.Status.ReadyComponentNum)
+	}
+
+	// the management is resumed once the annotation is removed
+	delete(yas.Annotations, iotv1alpha2.AnnotationUnmanaged)
+	if err := r.Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != "edgexfoundry/core-command:2.3.2" {
+		t.Errorf("expect the template is updated after the annotation is removed, but got image %s", image)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if len(latest.Status.UnmanagedComponents) != 0 {
+		t.Errorf("expect no unmanaged components, but got %v", latest.Status.UnmanagedComponents)
+	}
+}
+
+func TestDeleteUnmanagedYurtAppSet(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+	yas.Annotations[iotv1alpha2.AnnotationUnmanaged] = "true"
+	if err := r.Update(context.TODO(), yas); err != nil {
+		t.Fatalf("failed to update YurtAppSet, %v", err)
+	}
+
+	// the deletion trumps the annotation
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	yas = getYurtAppSet(t, r, pa.Namespace, testComponent)
+	if len(yas.Spec.Topology.Pools) != 0 {
+		t.Errorf("expect the pool is removed from the unmanaged yurtappset, but got %v", yas.Spec.Topology.Pools)
+	}
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+const (
+	envtestTimeout  = 30 * time.Second
+	envtestInterval = 100 * time.Millisecond
+)
+
+// startTestEnvironment starts an apiserver with the CRDs of yurt-manager and runs the PlatformAdmin controller
+// against it. The test is skipped if the binaries of envtest are not provided by KUBEBUILDER_ASSETS, so the
+// unit tests can still run without them.
+func startTestEnvironment(t *testing.T) client.Client {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skip the envtest of PlatformAdmin controller")
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "charts", "yurt-manager", "crds")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start test environment, %v", err)
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop test environment, %v", err)
+		}
+	})
+
+	scheme := newTestScheme()
+	mgr, err := manager.New(cfg, manager.Options{Scheme: scheme, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatalf("failed to create manager, %v", err)
+	}
+	r := &ReconcilePlatformAdmin{
+		Client:             mgr.GetClient(),
+		scheme:             scheme,
+		recorder:           mgr.GetEventRecorderFor(ControllerName),
+		frameworkNamespace: "kube-system",
+	}
+	r.configuration.Store(newTestConfiguration(newTestComponent(testComponent, testImage)))
+	if err := add(mgr, r); err != nil {
+		t.Fatalf("failed to add PlatformAdmin controller, %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := mgr.Start(ctx); err != nil {
+			t.Errorf("failed to start manager, %v", err)
+		}
+	}()
+	// The manager is stopped before the apiserver, the cleanups run in last added first called order
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// The objects are read from the apiserver directly, so the checks do not depend on the cache of manager
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("failed to create client, %v", err)
+	}
+	return c
+}
+
+// eventually polls the condition until it is met, and fails the test with the last error on timeout.
+func eventually(t *testing.T, description string, condition func() error) {
+	t.Helper()
+	var lastErr error
+	err := wait.PollImmediate(envtestInterval, envtestTimeout, func() (bool, error) {
+		lastErr = condition()
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("timed out waiting for %s, %v", description, lastErr)
+	}
+}
+
+// checkGenerated checks that the object is labeled and owned by the PlatformAdmin.
+func checkGenerated(obj metav1.Object, label string, platformAdmin *iotv1alpha2.PlatformAdmin) error {
+	if obj.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate] != label {
+		return fmt.Errorf("expect %s is labeled with %s, but got %v", obj.GetName(), label, obj.GetLabels())
+	}
+	if !isOwnedBy(obj, platformAdmin) {
+		return fmt.Errorf("expect %s is owned by PlatformAdmin, but got %v", obj.GetName(), obj.GetOwnerReferences())
+	}
+	return nil
+}
+
+func TestPlatformAdminLifecycle(t *testing.T) {
+	c := startTestEnvironment(t)
+	ctx := context.TODO()
+
+	nodePool := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	if err := c.Create(ctx, nodePool); err != nil {
+		t.Fatalf("failed to create nodepool, %v", err)
+	}
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	if err := c.Create(ctx, pa); err != nil {
+		t.Fatalf("failed to create PlatformAdmin, %v", err)
+	}
+
+	// create: the configmap, service and yurtappset of the component are generated
+	eventually(t, "the objects of PlatformAdmin are generated", func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(pa), pa); err != nil {
+			return err
+		}
+		if !controllerutil.ContainsFinalizer(pa, iotv1alpha2.PlatformAdminFinalizer) {
+			return fmt.Errorf("expect finalizer is added, but got %v", pa.Finalizers)
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}, cm); err != nil {
+			return err
+		}
+		if err := checkGenerated(cm, LabelConfigmap, pa); err != nil {
+			return err
+		}
+		svc := &corev1.Service{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, svc); err != nil {
+			return err
+		}
+		if err := checkGenerated(svc, LabelService, pa); err != nil {
+			return err
+		}
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, yas); err != nil {
+			return err
+		}
+		if err := checkGenerated(yas, LabelDeployment, pa); err != nil {
+			return err
+		}
+		if len(yas.Spec.Topology.Pools) != 1 || yas.Spec.Topology.Pools[0].Name != "hangzhou" {
+			return fmt.Errorf("expect yurtappset has pool hangzhou, but got %v", yas.Spec.Topology.Pools)
+		}
+		return nil
+	})
+
+	// add an additional deployment: a new yurtappset is generated
+	deployments := []iotv1alpha1.DeploymentTemplateSpec{{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgex-device-virtual"},
+		Spec:       *newTestComponent("edgex-device-virtual", testImage).Deployment,
+	}}
+	data, err := json.Marshal(deployments)
+	if err != nil {
+		t.Fatalf("failed to marshal additional deployments, %v", err)
+	}
+	if err := wait.PollImmediate(envtestInterval, envtestTimeout, func() (bool, error) {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(pa), pa); err != nil {
+			return false, err
+		}
+		metav1.SetMetaDataAnnotation(&pa.ObjectMeta, iotv1alpha1.AnnotationAdditionalDeployments, string(data))
+		// The status is updated by the controller concurrently, conflicts are retried
+		if err := c.Update(ctx, pa); err != nil {
+			return false, ignoreConflict(err)
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to add additional deployments, %v", err)
+	}
+	eventually(t, "the yurtappset of additional deployment is generated", func() error {
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: "edgex-device-virtual"}, yas); err != nil {
+			return err
+		}
+		return checkGenerated(yas, LabelDeployment, pa)
+	})
+
+	// delete: the pool is removed from the yurtappsets and the finalizer is cleared
+	if err := c.Delete(ctx, pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	eventually(t, "PlatformAdmin is deleted", func() error {
+		err := c.Get(ctx, client.ObjectKeyFromObject(pa), &iotv1alpha2.PlatformAdmin{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("expect PlatformAdmin is deleted, but it still exists")
+	})
+	// There is no garbage collector in the test environment, so the yurtappsets are left without the pool
+	for _, name := range []string{testComponent, "edgex-device-virtual"} {
+		yas := &appsv1alpha1.YurtAppSet{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: name}, yas); err != nil {
+			t.Fatalf("failed to get yurtappset %s, %v", name, err)
+		}
+		if len(yas.Spec.Topology.Pools) != 0 {
+			t.Errorf("expect pool is removed from yurtappset %s, but got %v", name, yas.Spec.Topology.Pools)
+		}
+	}
+}
+
+// appliedBy checks whether the object is applied by the field manager of PlatformAdmin controller.
+func appliedBy(obj metav1.Object) error {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return nil
+		}
+	}
+	return fmt.Errorf("expect %s is applied by %s, but got managed fields %v", obj.GetName(), FieldManager, obj.GetManagedFields())
+}
+
+func TestServerSideApply(t *testing.T) {
+	c := startTestEnvironment(t)
+	ctx := context.TODO()
+
+	nodePool := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	if err := c.Create(ctx, nodePool); err != nil {
+		t.Fatalf("failed to create nodepool, %v", err)
+	}
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	if err := c.Create(ctx, pa); err != nil {
+		t.Fatalf("failed to create PlatformAdmin, %v", err)
+	}
+	key := types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}
+	eventually(t, "the configmap is applied", func() error {
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			return err
+		}
+		return appliedBy(cm)
+	})
+	eventually(t, "the service is applied", func() error {
+		svc := &corev1.Service{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, svc); err != nil {
+			return err
+		}
+		return appliedBy(svc)
+	})
+
+	// another manager annotates the configmap and changes a key of template
+	if err := wait.PollImmediate(envtestInterval, envtestTimeout, func() (bool, error) {
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			return false, err
+		}
+		metav1.SetMetaDataAnnotation(&cm.ObjectMeta, "sidecar.istio.io/status", "injected")
+		cm.Data["EDGEX_SECURITY_SECRET_STORE"] = "true"
+		if err := c.Update(ctx, cm, client.FieldOwner("injector")); err != nil {
+			return false, ignoreConflict(err)
+		}
+		return true, nil
+	}); err != nil {
+		t.Fatalf("failed to update configmap, %v", err)
+	}
+
+	// the configmap is applied twice, by the change above and by the change of PlatformAdmin
+	for i, trigger := range []func() error{
+		func() error { return nil },
+		func() error {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(pa), pa); err != nil {
+				return err
+			}
+			metav1.SetMetaDataAnnotation(&pa.ObjectMeta, iotv1alpha2.PropagatePrefix+"team", "edge")
+			return c.Update(ctx, pa)
+		},
+	} {
+		if err := wait.PollImmediate(envtestInterval, envtestTimeout, func() (bool, error) {
+			return true, ignoreConflict(trigger())
+		}); err != nil {
+			t.Fatalf("failed to trigger apply %d, %v", i+1, err)
+		}
+		eventually(t, fmt.Sprintf("the configmap is applied %d", i+1), func() error {
+			cm := &corev1.ConfigMap{}
+			if err := c.Get(ctx, key, cm); err != nil {
+				return err
+			}
+			if cm.Data["EDGEX_SECURITY_SECRET_STORE"] != "false" {
+				return fmt.Errorf("expect the data of template is enforced, but got %v", cm.Data)
+			}
+			if i == 1 && cm.Annotations["team"] != "edge" {
+				return fmt.Errorf("expect the annotation of PlatformAdmin is propagated, but got %v", cm.Annotations)
+			}
+			if cm.Annotations["sidecar.istio.io/status"] != "injected" {
+				return fmt.Errorf("expect the annotation of others is kept, but got %v", cm.Annotations)
+			}
+			return nil
+		})
+	}
+}
+
+func ignoreConflict(err error) error {
+	if apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
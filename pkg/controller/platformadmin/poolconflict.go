@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// poolClaimedBy returns the oldest PlatformAdmin which claims any pool of PlatformAdmin before it, they would patch the same
+// yurtappsets in turn otherwise. It is nil if the pools are not claimed by others. The PlatformAdmins generating components
+// into the same namespace claim a pool in the order of creation, the ones being deleted do not claim anything.
+func (r *ReconcilePlatformAdmin) poolClaimedBy(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin) (*iotv1alpha2.PlatformAdmin, error) {
+	var claimedBy *iotv1alpha2.PlatformAdmin
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		platformAdmins := &iotv1alpha2.PlatformAdminList{}
+		if err := r.List(ctx, platformAdmins, client.MatchingFields{util.IndexerPathForNodepool: poolName}); err != nil {
+			return nil, err
+		}
+		for i := range platformAdmins.Items {
+			other := &platformAdmins.Items[i]
+			// The index is not honored by every client(e.g. the fake client of tests), so the pool is checked again
+			if len(claimedPools(platformAdmin, other)) == 0 || other.DeletionTimestamp != nil ||
+				(other.Namespace == platformAdmin.Namespace && other.Name == platformAdmin.Name) ||
+				workloadNamespace(other) != workloadNamespace(platformAdmin) {
+				continue
+			}
+			if createdBefore(other, platformAdmin) && (claimedBy == nil || createdBefore(other, claimedBy)) {
+				claimedBy = other
+			}
+		}
+	}
+	return claimedBy, nil
+}
+
+// claimedPools returns the pools of PlatformAdmin which are the pools of the other one too.
+func claimedPools(platformAdmin, other *iotv1alpha2.PlatformAdmin) []string {
+	otherPools := sets.NewString(util.PlatformAdminPools(other)...)
+	var pools []string
+	for _, poolName := range util.PlatformAdminPools(platformAdmin) {
+		if otherPools.Has(poolName) {
+			pools = append(pools, poolName)
+		}
+	}
+	return pools
+}
+
+// createdBefore checks whether a is created before b, the names break the tie of timestamps in seconds.
+func createdBefore(a, b *iotv1alpha2.PlatformAdmin) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
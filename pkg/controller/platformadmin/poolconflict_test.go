@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+func TestPoolClaimedBy(t *testing.T) {
+	now := metav1.Now()
+	newPlatformAdmin := func(namespace, name, poolName string, created time.Duration) *iotv1alpha2.PlatformAdmin {
+		platformAdmin := newTestPlatformAdmin(namespace, name, poolName)
+		platformAdmin.CreationTimestamp = metav1.NewTime(now.Add(created))
+		return platformAdmin
+	}
+	deleting := newPlatformAdmin("default", "deleting", "shanghai", -time.Hour)
+	deleting.Finalizers = []string{iotv1alpha2.PlatformAdminFinalizer}
+	deleting.DeletionTimestamp = &now
+	crossNamespace := newPlatformAdmin("edge", "cross", "shenzhen", -time.Hour)
+	crossNamespace.Spec.WorkloadNamespace = "default"
+	multiPool := newPlatformAdmin("default", "multi", "beijing", 2*time.Minute)
+	multiPool.Spec.Pools = []string{"hangzhou"}
+
+	tests := []struct {
+		name          string
+		platformAdmin *iotv1alpha2.PlatformAdmin
+		expect        string
+	}{
+		{name: "pool is not claimed by others", platformAdmin: newPlatformAdmin("default", "edgex", "beijing", 0)},
+		{name: "pool is claimed by an older one", platformAdmin: newPlatformAdmin("default", "newer", "hangzhou", time.Minute), expect: "default/older"},
+		{name: "pool is claimed by a newer one", platformAdmin: newPlatformAdmin("default", "older", "hangzhou", 0)},
+		{name: "same pool in another namespace", platformAdmin: newPlatformAdmin("edge", "edgex", "hangzhou", time.Minute)},
+		{name: "pool is claimed by one being deleted", platformAdmin: newPlatformAdmin("default", "edgex", "shanghai", 0)},
+		{name: "pool is claimed by one generating into the same namespace", platformAdmin: newPlatformAdmin("default", "edgex", "shenzhen", 0), expect: "edge/cross"},
+		{name: "one of the pools is claimed by an older one", platformAdmin: multiPool, expect: "default/older"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(newTestConfiguration(), newPlatformAdmin("default", "older", "hangzhou", 0),
+				newPlatformAdmin("default", "newer", "hangzhou", time.Minute), deleting, crossNamespace)
+			claimedBy, err := r.poolClaimedBy(context.TODO(), tt.platformAdmin)
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			got := ""
+			if claimedBy != nil {
+				got = claimedBy.Namespace + "/" + claimedBy.Name
+			}
+			if got != tt.expect {
+				t.Errorf("expect pool is claimed by %q, but got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestReconcilePoolConflict(t *testing.T) {
+	older := newTestPlatformAdmin("default", "older", "hangzhou")
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	// the newer one bypasses the webhook, and the finalizer was added before the conflict
+	newer := newTestPlatformAdmin("default", "newer", "hangzhou")
+	newer.CreationTimestamp = metav1.Now()
+	newer.Finalizers = []string{iotv1alpha2.PlatformAdminFinalizer}
+	newer.Spec.Version = "minnesota"
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	conf.NoSectyComponents["minnesota"] = []*config.Component{newTestComponent(testComponent, "edgexfoundry/core-command:3.0.0")}
+	r := newTestReconciler(conf, older, newer)
+
+	reconcilePlatformAdmin(t, r, older)
+	reconcilePlatformAdmin(t, r, newer)
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(newer), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if condition := util.GetPlatformAdminCondition(latest.Status, iotv1alpha2.PoolConflictCondition); condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Errorf("expect condition %s is true, but got %v", iotv1alpha2.PoolConflictCondition, condition)
+	}
+	if latest.Status.Ready {
+		t.Errorf("expect the PlatformAdmin with pool conflict is not ready")
+	}
+	// the template of the older one is not overwritten by the newer one
+	yas := getYurtAppSet(t, r, older.Namespace, testComponent)
+	if image := yas.Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0].Image; image != testImage {
+		t.Errorf("expect image %s of the older PlatformAdmin, but got %s", testImage, image)
+	}
+
+	// the deletion of the newer one does not release the pool of the older one
+	if err := r.Delete(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, newer)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(newer), latest); !apierrors.IsNotFound(err) {
+		t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+	}
+	if yas := getYurtAppSet(t, r, older.Namespace, testComponent); len(yas.Spec.Topology.Pools) != 1 {
+		t.Errorf("expect pool of the older PlatformAdmin is kept, but got %v", yas.Spec.Topology.Pools)
+	}
+
+	// the conflict is resolved, the older one is still managed
+	reconcilePlatformAdmin(t, r, older)
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(older), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if condition := util.GetPlatformAdminCondition(latest.Status, iotv1alpha2.PoolConflictCondition); condition != nil {
+		t.Errorf("expect no condition %s, but got %v", iotv1alpha2.PoolConflictCondition, condition)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+// metadataChanged checks the metadata which the PlatformAdmin controller relies on: the labels and owner references
+// map the object to PlatformAdmins, the annotations carry the template hash and the protection, and the deletion is
+// followed by the finalizers. The resource version and managed fields are ignored.
+func metadataChanged(oldObj, newObj client.Object) bool {
+	return !reflect.DeepEqual(oldObj.GetLabels(), newObj.GetLabels()) ||
+		!reflect.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations()) ||
+		!reflect.DeepEqual(oldObj.GetOwnerReferences(), newObj.GetOwnerReferences()) ||
+		!reflect.DeepEqual(oldObj.GetFinalizers(), newObj.GetFinalizers()) ||
+		!reflect.DeepEqual(oldObj.GetDeletionTimestamp(), newObj.GetDeletionTimestamp())
+}
+
+// YurtAppSetChanged checks whether the update of YurtAppSet matters to PlatformAdmins. Besides the metadata and
+// spec(e.g. topology and the template hash), only the status which changes the readiness or the drain of pools
+// passes, so the flaps of ready replicas during a rollout do not enqueue the PlatformAdmins.
+func YurtAppSetChanged(oldYas, newYas *appsv1alpha1.YurtAppSet) bool {
+	if metadataChanged(oldYas, newYas) || !reflect.DeepEqual(oldYas.Spec, newYas.Spec) {
+		return true
+	}
+	if oldYas.Status.ObservedGeneration != newYas.Status.ObservedGeneration ||
+		!reflect.DeepEqual(oldYas.Status.PoolReplicas, newYas.Status.PoolReplicas) {
+		return true
+	}
+	for poolName := range newYas.Status.PoolReplicas {
+		if isPoolReady(oldYas, poolName) != isPoolReady(newYas, poolName) {
+			return true
+		}
+		// The pool is drained once none of its replicas is ready
+		if (oldYas.Status.PoolReadyReplicas[poolName] > 0) != (newYas.Status.PoolReadyReplicas[poolName] > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentChanged checks whether the update of a configmap or service matters to PlatformAdmins, i.e. the metadata,
+// the data of configmap or the spec of service is changed. The status of service is ignored.
+func ContentChanged(oldObj, newObj client.Object) bool {
+	if metadataChanged(oldObj, newObj) {
+		return true
+	}
+	switch newObj := newObj.(type) {
+	case *corev1.ConfigMap:
+		oldConfigMap, ok := oldObj.(*corev1.ConfigMap)
+		return !ok || !reflect.DeepEqual(oldConfigMap.Data, newObj.Data) || !reflect.DeepEqual(oldConfigMap.BinaryData, newObj.BinaryData)
+	case *corev1.Service:
+		oldService, ok := oldObj.(*corev1.Service)
+		return !ok || !reflect.DeepEqual(oldService.Spec, newObj.Spec)
+	}
+	return true
+}
+
+// yurtAppSetUpdatePredicate drops the updates of YurtAppSets checked by YurtAppSetChanged.
+func yurtAppSetUpdatePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(evt event.UpdateEvent) bool {
+			oldYas, oldOk := evt.ObjectOld.(*appsv1alpha1.YurtAppSet)
+			newYas, newOk := evt.ObjectNew.(*appsv1alpha1.YurtAppSet)
+			if !oldOk || !newOk {
+				return true
+			}
+			return YurtAppSetChanged(oldYas, newYas)
+		},
+	}
+}
+
+// contentUpdatePredicate drops the updates of configmaps and services checked by ContentChanged.
+func contentUpdatePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(evt event.UpdateEvent) bool {
+			if evt.ObjectOld == nil || evt.ObjectNew == nil {
+				return true
+			}
+			return ContentChanged(evt.ObjectOld, evt.ObjectNew)
+		},
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func newTestPredicateYurtAppSet() *appsv1alpha1.YurtAppSet {
+	return &appsv1alpha1.YurtAppSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            testComponent,
+			ResourceVersion: "1",
+			Annotations:     map[string]string{iotv1alpha2.AnnotationTemplateHash: "hash"},
+		},
+		Spec: appsv1alpha1.YurtAppSetSpec{
+			Topology: appsv1alpha1.Topology{Pools: []appsv1alpha1.Pool{{Name: "hangzhou"}}},
+		},
+		Status: appsv1alpha1.YurtAppSetStatus{
+			ObservedGeneration: 1,
+			Replicas:           3,
+			ReadyReplicas:      1,
+			PoolReplicas:       map[string]int32{"hangzhou": 3},
+			PoolReadyReplicas:  map[string]int32{"hangzhou": 1},
+		},
+	}
+}
+
+func TestYurtAppSetChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(yas *appsv1alpha1.YurtAppSet)
+		expect bool
+	}{
+		{
+			name: "resource version and managed fields only",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.ResourceVersion = "2"
+				yas.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "yurt-manager"}}
+			},
+		},
+		{
+			name: "ready replicas flap without crossing readiness",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.ReadyReplicas = 2
+				yas.Status.PoolReadyReplicas["hangzhou"] = 2
+			},
+		},
+		{
+			name: "conditions of status",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.Conditions = []appsv1alpha1.YurtAppSetCondition{{Type: "PoolProvisioned", Status: corev1.ConditionTrue}}
+			},
+		},
+		{
+			name: "pool becomes ready",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.ReadyReplicas = 3
+				yas.Status.PoolReadyReplicas["hangzhou"] = 3
+			},
+			expect: true,
+		},
+		{
+			name: "pool is drained",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.ReadyReplicas = 0
+				yas.Status.PoolReadyReplicas["hangzhou"] = 0
+			},
+			expect: true,
+		},
+		{
+			name: "replicas of pool",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.PoolReplicas["hangzhou"] = 1
+			},
+			expect: true,
+		},
+		{
+			name: "observed generation",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Status.ObservedGeneration = 2
+			},
+			expect: true,
+		},
+		{
+			name: "topology",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, appsv1alpha1.Pool{Name: "beijing"})
+			},
+			expect: true,
+		},
+		{
+			name: "template hash",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.Annotations[iotv1alpha2.AnnotationTemplateHash] = "new-hash"
+			},
+			expect: true,
+		},
+		{
+			name: "owner references",
+			update: func(yas *appsv1alpha1.YurtAppSet) {
+				yas.OwnerReferences = []metav1.OwnerReference{newTestOwnerReference("edgex", "uid-hangzhou")}
+			},
+			expect: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldYas := newTestPredicateYurtAppSet()
+			newYas := oldYas.DeepCopy()
+			tt.update(newYas)
+			if changed := YurtAppSetChanged(oldYas, newYas); changed != tt.expect {
+				t.Errorf("expect changed %v, but got %v", tt.expect, changed)
+			}
+			if passed := yurtAppSetUpdatePredicate().Update(event.UpdateEvent{ObjectOld: oldYas, ObjectNew: newYas}); passed != tt.expect {
+				t.Errorf("expect the predicate passes %v, but got %v", tt.expect, passed)
+			}
+		})
+	}
+}
+
+func TestContentChanged(t *testing.T) {
+	configmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "common-variable-levski", ResourceVersion: "1"},
+		Data:       map[string]string{"key": "value"},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: testComponent, ResourceVersion: "1"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 59882}}},
+	}
+	tests := []struct {
+		name   string
+		old    client.Object
+		update func(obj client.Object)
+		expect bool
+	}{
+		{
+			name: "resource version of configmap",
+			old:  configmap,
+			update: func(obj client.Object) {
+				obj.SetResourceVersion("2")
+				obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+			},
+		},
+		{
+			name: "data of configmap",
+			old:  configmap,
+			update: func(obj client.Object) {
+				obj.(*corev1.ConfigMap).Data["key"] = "new-value"
+			},
+			expect: true,
+		},
+		{
+			name: "binary data of configmap",
+			old:  configmap,
+			update: func(obj client.Object) {
+				obj.(*corev1.ConfigMap).BinaryData = map[string][]byte{"key": []byte("value")}
+			},
+			expect: true,
+		},
+		{
+			name: "status of service",
+			old:  service,
+			update: func(obj client.Object) {
+				obj.SetResourceVersion("2")
+				obj.(*corev1.Service).Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+			},
+		},
+		{
+			name: "spec of service",
+			old:  service,
+			update: func(obj client.Object) {
+				obj.(*corev1.Service).Spec.Ports[0].Port = 59883
+			},
+			expect: true,
+		},
+		{
+			name: "labels of service",
+			old:  service,
+			update: func(obj client.Object) {
+				obj.SetLabels(map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelService})
+			},
+			expect: true,
+		},
+		{
+			name: "finalizers of configmap",
+			old:  configmap,
+			update: func(obj client.Object) {
+				obj.SetFinalizers([]string{iotv1alpha2.ConfigMapFinalizer})
+			},
+			expect: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newObj := tt.old.DeepCopyObject().(client.Object)
+			tt.update(newObj)
+			if changed := ContentChanged(tt.old, newObj); changed != tt.expect {
+				t.Errorf("expect changed %v, but got %v", tt.expect, changed)
+			}
+			if passed := contentUpdatePredicate().Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: newObj}); passed != tt.expect {
+				t.Errorf("expect the predicate passes %v, but got %v", tt.expect, passed)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// propagateMetadata copies the labels and annotations of PlatformAdmin under iotv1alpha2.PropagatePrefix to the
+// generated object, and removes the ones which were propagated before but are no longer on PlatformAdmin.
+// It returns true if the metadata of object is changed.
+func propagateMetadata(platformAdmin *iotv1alpha2.PlatformAdmin, obj metav1.Object) bool {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	labelsChanged := propagate(platformAdmin.Labels, labels, annotations, iotv1alpha2.AnnotationPropagatedLabels)
+	annotationsChanged := propagate(platformAdmin.Annotations, annotations, annotations, iotv1alpha2.AnnotationPropagatedAnnotations)
+	if labelsChanged {
+		obj.SetLabels(labels)
+	}
+	if labelsChanged || annotationsChanged {
+		obj.SetAnnotations(annotations)
+	}
+	return labelsChanged || annotationsChanged
+}
+
+// propagate syncs the prefixed entries of source into target, the propagated keys are recorded
+// into the record annotation of annotations.
+func propagate(source, target, annotations map[string]string, recordKey string) bool {
+	changed := false
+	desired := sets.NewString()
+	for k, v := range source {
+		if !strings.HasPrefix(k, iotv1alpha2.PropagatePrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(k, iotv1alpha2.PropagatePrefix)
+		if len(key) == 0 {
+			continue
+		}
+		desired.Insert(key)
+		if old, ok := target[key]; !ok || old != v {
+			target[key] = v
+			changed = true
+		}
+	}
+
+	var recorded []string
+	if len(annotations[recordKey]) != 0 {
+		recorded = strings.Split(annotations[recordKey], ",")
+	}
+	for _, key := range recorded {
+		if desired.Has(key) {
+			continue
+		}
+		if _, ok := target[key]; ok {
+			delete(target, key)
+			changed = true
+		}
+	}
+
+	record := strings.Join(desired.List(), ",")
+	if annotations[recordKey] != record {
+		if len(record) == 0 {
+			delete(annotations, recordKey)
+		} else {
+			annotations[recordKey] = record
+		}
+		changed = true
+	}
+	return changed
+}
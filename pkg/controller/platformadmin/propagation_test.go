@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestPropagateMetadata(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Labels = map[string]string{
+		iotv1alpha2.PropagatePrefix + "team": "edge",
+		"app":                                "edgex",
+	}
+	pa.Annotations = map[string]string{
+		iotv1alpha2.PropagatePrefix + "owner": "alice",
+	}
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	tests := []struct {
+		name              string
+		labels            map[string]string
+		annotations       map[string]string
+		expectLabels      map[string]string
+		expectAnnotations map[string]string
+	}{
+		{
+			name:              "add",
+			labels:            pa.Labels,
+			annotations:       pa.Annotations,
+			expectLabels:      map[string]string{"team": "edge"},
+			expectAnnotations: map[string]string{"owner": "alice"},
+		},
+		{
+			name: "update",
+			labels: map[string]string{
+				iotv1alpha2.PropagatePrefix + "team": "cloud",
+				iotv1alpha2.PropagatePrefix + "env":  "prod",
+			},
+			annotations:       pa.Annotations,
+			expectLabels:      map[string]string{"team": "cloud", "env": "prod"},
+			expectAnnotations: map[string]string{"owner": "alice"},
+		},
+		{
+			name:        "removal",
+			labels:      map[string]string{iotv1alpha2.PropagatePrefix + "env": "prod"},
+			annotations: map[string]string{},
+			expectLabels: map[string]string{
+				"env": "prod",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			pa.Labels = tt.labels
+			pa.Annotations = tt.annotations
+			if err := r.Update(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to update PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+
+			yas := getYurtAppSet(t, r, pa.Namespace, testComponent)
+			svc := &corev1.Service{}
+			if err := r.Get(context.TODO(), client.ObjectKey{Namespace: pa.Namespace, Name: testComponent}, svc); err != nil {
+				t.Fatalf("failed to get Service, %v", err)
+			}
+			for _, obj := range []client.Object{yas, svc} {
+				if got := propagated(obj.GetLabels(), "team", "env", "app"); !reflect.DeepEqual(got, tt.expectLabels) {
+					t.Errorf("expect labels %v of %T, but got %v", tt.expectLabels, obj, got)
+				}
+				if got := propagated(obj.GetAnnotations(), "owner"); !reflect.DeepEqual(got, tt.expectAnnotations) {
+					t.Errorf("expect annotations %v of %T, but got %v", tt.expectAnnotations, obj, got)
+				}
+			}
+		})
+	}
+}
+
+func propagated(m map[string]string, keys ...string) map[string]string {
+	var result map[string]string
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			if result == nil {
+				result = make(map[string]string)
+			}
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// isProtected checks whether the generated objects of PlatformAdmin are protected from the changes of others.
+func isProtected(platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	return platformAdmin.Annotations[iotv1alpha2.AnnotationProtectResources] == "true"
+}
+
+// protectMetadata sets the label which the protection webhook looks for on the generated object of a protected
+// PlatformAdmin, and removes it once the protection is turned off. It returns true if the labels of object are changed.
+func protectMetadata(platformAdmin *iotv1alpha2.PlatformAdmin, obj metav1.Object) bool {
+	labels := obj.GetLabels()
+	_, labeled := labels[iotv1alpha2.LabelProtected]
+	if isProtected(platformAdmin) == labeled {
+		return false
+	}
+	if labeled {
+		delete(labels, iotv1alpha2.LabelProtected)
+	} else {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[iotv1alpha2.LabelProtected] = "true"
+	}
+	obj.SetLabels(labels)
+	return true
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestProtectMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		protected     bool
+		labels        map[string]string
+		expectChanged bool
+		expectLabeled bool
+	}{
+		{name: "protected and not labeled", protected: true, expectChanged: true, expectLabeled: true},
+		{name: "protected and labeled", protected: true, labels: map[string]string{iotv1alpha2.LabelProtected: "true"}, expectLabeled: true},
+		{name: "not protected and labeled", labels: map[string]string{iotv1alpha2.LabelProtected: "true"}, expectChanged: true},
+		{name: "not protected and not labeled", labels: map[string]string{"app": "edgex"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			if tt.protected {
+				platformAdmin.Annotations[iotv1alpha2.AnnotationProtectResources] = "true"
+			}
+			obj := &corev1.ConfigMap{}
+			obj.Labels = tt.labels
+			if changed := protectMetadata(platformAdmin, obj); changed != tt.expectChanged {
+				t.Errorf("expect changed %v, but got %v", tt.expectChanged, changed)
+			}
+			if _, labeled := obj.Labels[iotv1alpha2.LabelProtected]; labeled != tt.expectLabeled {
+				t.Errorf("expect labeled %v, but got %v", tt.expectLabeled, obj.Labels)
+			}
+		})
+	}
+}
+
+func TestProtectResources(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Annotations[iotv1alpha2.AnnotationProtectResources] = "true"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	expectLabeled := func(expect bool) {
+		t.Helper()
+		objs := map[string]client.Object{
+			"configmap":  &corev1.ConfigMap{},
+			"service":    &corev1.Service{},
+			"yurtappset": &appsv1alpha1.YurtAppSet{},
+		}
+		names := map[string]string{"configmap": "common-variable-levski", "service": testComponent, "yurtappset": testComponent}
+		for kind, obj := range objs {
+			if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: names[kind]}, obj); err != nil {
+				t.Fatalf("failed to get %s %s, %v", kind, names[kind], err)
+			}
+			if _, labeled := obj.GetLabels()[iotv1alpha2.LabelProtected]; labeled != expect {
+				t.Errorf("expect %s %s labeled %v, but got %v", kind, obj.GetName(), expect, obj.GetLabels())
+			}
+		}
+	}
+
+	// the generated objects are labeled for the webhook
+	reconcilePlatformAdmin(t, r, pa)
+	reconcilePlatformAdmin(t, r, pa)
+	expectLabeled(true)
+
+	// the protection is turned off, the existing objects are unlabeled
+	latest := &iotv1alpha2.PlatformAdmin{}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), latest); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	delete(latest.Annotations, iotv1alpha2.AnnotationProtectResources)
+	if err := r.Update(context.TODO(), latest); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	expectLabeled(false)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+// applyScheme adjusts the components to the scheme of PlatformAdmin. The https overrides of components are
+// applied for https, and the http probes of containers follow the scheme. The templates are kept as they are
+// if no scheme is set.
+func applyScheme(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component) []*config.Component {
+	scheme := platformAdmin.Spec.Scheme
+	if scheme == "" {
+		return components
+	}
+
+	for i, component := range components {
+		overridden := *component
+		overrides := component.HTTPSOverrides
+		if scheme != iotv1alpha2.PlatformAdminSchemeHTTPS {
+			overrides = nil
+		}
+
+		if overrides != nil && len(overrides.Ports) > 0 && component.Service != nil {
+			overridden.Service = component.Service.DeepCopy()
+			overridden.Service.Ports = overrideServicePorts(overridden.Service.Ports, overrides.Ports)
+		}
+		if component.Deployment != nil && len(component.Deployment.Template.Spec.Containers) > 0 {
+			overridden.Deployment = component.Deployment.DeepCopy()
+			containers := overridden.Deployment.Template.Spec.Containers
+			if overrides != nil && len(overrides.Env) > 0 {
+				containers[0].Env = mergeEnv(containers[0].Env, overrides.Env)
+			}
+			for j := range containers {
+				setProbeScheme(containers[j].LivenessProbe, scheme)
+				setProbeScheme(containers[j].ReadinessProbe, scheme)
+				setProbeScheme(containers[j].StartupProbe, scheme)
+			}
+		}
+		components[i] = &overridden
+	}
+	return components
+}
+
+// overrideServicePorts replaces the ports with the same name, and appends the others.
+func overrideServicePorts(ports []corev1.ServicePort, overrides []corev1.ServicePort) []corev1.ServicePort {
+	result := append([]corev1.ServicePort{}, ports...)
+	for _, override := range overrides {
+		replaced := false
+		for i := range result {
+			if result[i].Name == override.Name {
+				result[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, override)
+		}
+	}
+	return result
+}
+
+func setProbeScheme(probe *corev1.Probe, scheme string) {
+	if probe == nil || probe.HTTPGet == nil {
+		return
+	}
+	probe.HTTPGet.Scheme = corev1.URIScheme(strings.ToUpper(scheme))
+}
+
+// schemeVariables returns the variables injected into the common variable configmaps for the scheme,
+// which are collected from the https overrides of components.
+func schemeVariables(platformAdmin *iotv1alpha2.PlatformAdmin, components []*config.Component, configmapName string) map[string]string {
+	if platformAdmin.Spec.Scheme != iotv1alpha2.PlatformAdminSchemeHTTPS || !strings.HasPrefix(configmapName, commonVariablePrefix) {
+		return nil
+	}
+	variables := make(map[string]string)
+	for _, component := range components {
+		if component.HTTPSOverrides == nil {
+			continue
+		}
+		for k, v := range component.HTTPSOverrides.Variables {
+			variables[k] = v
+		}
+	}
+	return variables
+}
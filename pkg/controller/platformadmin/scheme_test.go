@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+const httpsPort = 59883
+
+// newSchemeComponent returns a component with a http probe, which serves https on another port.
+func newSchemeComponent() *config.Component {
+	component := newTestComponent(testComponent, testImage)
+	component.Deployment.Template.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/api/v2/ping", Port: intstr.FromInt(59882)},
+		},
+	}
+	component.HTTPSOverrides = &config.HTTPSOverrides{
+		Ports: []corev1.ServicePort{
+			{Name: "http", Protocol: corev1.ProtocolTCP, Port: 59882, TargetPort: intstr.FromInt(httpsPort)},
+		},
+		Env:       []corev1.EnvVar{{Name: "SERVICE_PROTOCOL", Value: "https"}},
+		Variables: map[string]string{"SERVICE_SERVERBINDADDR": "0.0.0.0", "SERVICE_TLS": "true"},
+	}
+	return component
+}
+
+// checkScheme checks the service, yurtappset and configmap of the component against the scheme.
+func checkScheme(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, https bool) {
+	t.Helper()
+	expectTargetPort, expectProbeScheme, expectEnv, expectTLS := 59882, corev1.URISchemeHTTP, "", ""
+	if https {
+		expectTargetPort, expectProbeScheme, expectEnv, expectTLS = httpsPort, corev1.URISchemeHTTPS, "https", "true"
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, svc); err != nil {
+		t.Fatalf("failed to get service, %v", err)
+	}
+	if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].TargetPort.IntValue() != expectTargetPort {
+		t.Errorf("expect target port %d, but got %v", expectTargetPort, svc.Spec.Ports)
+	}
+
+	container := getYurtAppSet(t, r, pa.Namespace, testComponent).Spec.WorkloadTemplate.DeploymentTemplate.Spec.Template.Spec.Containers[0]
+	if scheme := container.ReadinessProbe.HTTPGet.Scheme; scheme != expectProbeScheme {
+		t.Errorf("expect probe scheme %s, but got %s", expectProbeScheme, scheme)
+	}
+	var env string
+	for _, e := range container.Env {
+		if e.Name == "SERVICE_PROTOCOL" {
+			env = e.Value
+		}
+	}
+	if env != expectEnv {
+		t.Errorf("expect env SERVICE_PROTOCOL %q, but got %q", expectEnv, env)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if cm.Data["SERVICE_TLS"] != expectTLS {
+		t.Errorf("expect variable SERVICE_TLS %q, but got %q", expectTLS, cm.Data["SERVICE_TLS"])
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := []struct {
+		scheme      string
+		expectHTTPS bool
+	}{
+		{scheme: iotv1alpha2.PlatformAdminSchemeHTTP},
+		{scheme: iotv1alpha2.PlatformAdminSchemeHTTPS, expectHTTPS: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+			pa.Spec.Scheme = tt.scheme
+			r := newTestReconciler(newTestConfiguration(newSchemeComponent()), pa)
+
+			reconcilePlatformAdmin(t, r, pa)
+			checkScheme(t, r, pa, tt.expectHTTPS)
+		})
+	}
+}
+
+func TestSwitchScheme(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.Scheme = iotv1alpha2.PlatformAdminSchemeHTTP
+	r := newTestReconciler(newTestConfiguration(newSchemeComponent()), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	checkScheme(t, r, pa, false)
+
+	for _, scheme := range []string{iotv1alpha2.PlatformAdminSchemeHTTPS, iotv1alpha2.PlatformAdminSchemeHTTP} {
+		if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+			t.Fatalf("failed to get PlatformAdmin, %v", err)
+		}
+		pa.Spec.Scheme = scheme
+		if err := r.Update(context.TODO(), pa); err != nil {
+			t.Fatalf("failed to update PlatformAdmin, %v", err)
+		}
+		reconcilePlatformAdmin(t, r, pa)
+		checkScheme(t, r, pa, scheme == iotv1alpha2.PlatformAdminSchemeHTTPS)
+	}
+}
+
+func TestApplySchemeKeepsTemplate(t *testing.T) {
+	component := newSchemeComponent()
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.Scheme = iotv1alpha2.PlatformAdminSchemeHTTPS
+
+	applyScheme(pa, []*config.Component{component})
+	if component.Service.Ports[0].TargetPort.IntValue() == httpsPort {
+		t.Errorf("expect service of template is not changed, but got %v", component.Service.Ports)
+	}
+	if probe := component.Deployment.Template.Spec.Containers[0].ReadinessProbe; probe.HTTPGet.Scheme != "" {
+		t.Errorf("expect probe of template is not changed, but got %s", probe.HTTPGet.Scheme)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+const (
+	// RedisSecretName holds the credentials of redis in security mode
+	RedisSecretName = "edgex-redis-credentials"
+	// ConsulACLSecretName holds the bootstrap token of consul ACL in security mode
+	ConsulACLSecretName = "edgex-consul-acl-token"
+
+	redisUsername          = "edgex"
+	commonVariablePrefix   = "common-variable"
+	secretPasswordByteSize = 16
+)
+
+// securitySecret describes a secret generated for the security mode, the name of secret is
+// written into the common variable configmaps by variable, so the components can consume it.
+type securitySecret struct {
+	name     string
+	variable string
+	generate func() (map[string][]byte, error)
+}
+
+var securitySecrets = []securitySecret{
+	{
+		name:     RedisSecretName,
+		variable: "EDGEX_REDIS_SECRET_NAME",
+		generate: func() (map[string][]byte, error) {
+			password, err := randomHex(secretPasswordByteSize)
+			if err != nil {
+				return nil, err
+			}
+			return map[string][]byte{
+				"username": []byte(redisUsername),
+				"password": []byte(password),
+			}, nil
+		},
+	},
+	{
+		name:     ConsulACLSecretName,
+		variable: "EDGEX_CONSUL_ACL_SECRET_NAME",
+		generate: func() (map[string][]byte, error) {
+			token, err := randomUUID()
+			if err != nil {
+				return nil, err
+			}
+			return map[string][]byte{"token": []byte(token)}, nil
+		},
+	},
+}
+
+// reconcileSecret creates the secrets required by the security mode if they are missing. The data of
+// existing secrets is never regenerated, and the secrets created by users are used as is.
+func (r *ReconcilePlatformAdmin) reconcileSecret(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, platformAdminStatus *iotv1alpha2.PlatformAdminStatus) (bool, error) {
+	needSecrets := make(map[string]struct{})
+	// The secrets are kept for the components of security mode, until they are migrated to the insecure mode
+	if util.IsSecurityMigrating(platformAdmin.Spec.Security, platformAdminStatus) && *platformAdminStatus.CurrentSecurity {
+		for _, desired := range securitySecrets {
+			needSecrets[desired.name] = struct{}{}
+		}
+	}
+
+	if platformAdmin.Spec.Security {
+		for _, desired := range securitySecrets {
+			needSecrets[desired.name] = struct{}{}
+
+			secret := &corev1.Secret{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: workloadNamespace(platformAdmin), Name: desired.name}, secret)
+			if err == nil {
+				if _, ok := secret.Labels[iotv1alpha2.LabelPlatformAdminGenerate]; !ok || isOwnedBy(secret, platformAdmin) {
+					continue
+				}
+				// The generated secret is shared by PlatformAdmins in the same namespace
+				oldSecret := secret.DeepCopy()
+				if err := r.setOwner(platformAdmin, secret); err != nil {
+					return false, err
+				}
+				if err := r.Patch(ctx, secret, client.MergeFrom(oldSecret)); err != nil {
+					return false, err
+				}
+				recordOperation(kindSecret, operationPatch)
+				continue
+			}
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+
+			data, err := desired.generate()
+			if err != nil {
+				return false, fmt.Errorf("failed to generate secret %s, %w", desired.name, err)
+			}
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      desired.name,
+					Namespace: workloadNamespace(platformAdmin),
+					Labels:    map[string]string{iotv1alpha2.LabelPlatformAdminGenerate: LabelSecret},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: data,
+			}
+			propagateMetadata(platformAdmin, secret)
+			if err := r.setOwner(platformAdmin, secret); err != nil {
+				return false, err
+			}
+			if err := r.Create(ctx, secret); err != nil {
+				return false, err
+			}
+			log.FromContext(ctx).Info("Generate secret", "secret", secret.Name)
+			recordOperation(kindSecret, operationCreate)
+		}
+	}
+
+	secretlist := &corev1.SecretList{}
+	if err := r.List(ctx, secretlist, client.InNamespace(workloadNamespace(platformAdmin)), client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: LabelSecret}); err == nil {
+		for _, s := range secretlist.Items {
+			if _, ok := needSecrets[s.Name]; !ok {
+				r.removeOwner(ctx, platformAdmin, &s)
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// secretVariables returns the variables injected into the common variable configmap for the secrets.
+func secretVariables(platformAdmin *iotv1alpha2.PlatformAdmin, configmapName string) map[string]string {
+	if !platformAdmin.Spec.Security || !strings.HasPrefix(configmapName, commonVariablePrefix) {
+		return nil
+	}
+	variables := make(map[string]string, len(securitySecrets))
+	for _, s := range securitySecrets {
+		variables[s.variable] = s.name
+	}
+	return variables
+}
+
+// cleanupSecrets removes the PlatformAdmin from the owners of generated secrets, the secrets are deleted
+// once no PlatformAdmin owns them, so the credentials are not left behind.
+func (r *ReconcilePlatformAdmin) cleanupSecrets(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin) error {
+	secretlist := &corev1.SecretList{}
+	if err := r.List(ctx, secretlist, client.InNamespace(workloadNamespace(platformAdmin)), client.MatchingLabels{iotv1alpha2.LabelPlatformAdminGenerate: LabelSecret}); err != nil {
+		return err
+	}
+	for i := range secretlist.Items {
+		if err := r.removeOwner(ctx, platformAdmin, &secretlist.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// randomUUID generates a version 4 uuid, which is the format of consul ACL tokens.
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+func getSecrets(t *testing.T, r *ReconcilePlatformAdmin, namespace string) map[string]*corev1.Secret {
+	t.Helper()
+	secrets := make(map[string]*corev1.Secret)
+	for _, s := range securitySecrets {
+		secret := &corev1.Secret{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: s.name}, secret); err != nil {
+			t.Fatalf("failed to get secret %s, %v", s.name, err)
+		}
+		secrets[s.name] = secret
+	}
+	return secrets
+}
+
+func TestReconcileSecret(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.Security = true
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+
+	// the secrets are generated on first reconcile
+	reconcilePlatformAdmin(t, r, pa)
+	secrets := getSecrets(t, r, pa.Namespace)
+	for name, secret := range secrets {
+		if secret.Labels[iotv1alpha2.LabelPlatformAdminGenerate] != LabelSecret {
+			t.Errorf("expect secret %s is labeled, but got %v", name, secret.Labels)
+		}
+		if !isOwnedBy(secret, pa) {
+			t.Errorf("expect secret %s is owned by PlatformAdmin, but got %v", name, secret.OwnerReferences)
+		}
+	}
+	if len(secrets[RedisSecretName].Data["password"]) == 0 {
+		t.Errorf("expect redis password is generated, but got %v", secrets[RedisSecretName].Data)
+	}
+	if len(secrets[ConsulACLSecretName].Data["token"]) != 36 {
+		t.Errorf("expect consul acl token is an uuid, but got %s", secrets[ConsulACLSecretName].Data["token"])
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: "common-variable-levski"}, cm); err != nil {
+		t.Fatalf("failed to get configmap, %v", err)
+	}
+	if cm.Data["EDGEX_REDIS_SECRET_NAME"] != RedisSecretName || cm.Data["EDGEX_CONSUL_ACL_SECRET_NAME"] != ConsulACLSecretName {
+		t.Errorf("expect secret names are wired into configmap, but got %v", cm.Data)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if cond := util.GetPlatformAdminCondition(pa.Status, iotv1alpha2.SecretAvailableCondition); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expect secret available condition, but got %v", cond)
+	}
+
+	// the passwords are never regenerated
+	reconcilePlatformAdmin(t, r, pa)
+	for name, secret := range getSecrets(t, r, pa.Namespace) {
+		if !reflect.DeepEqual(secret.Data, secrets[name].Data) {
+			t.Errorf("expect secret %s is not regenerated, but got %v", name, secret.Data)
+		}
+	}
+
+	// the secrets are cleaned up with PlatformAdmin
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	reconcilePlatformAdmin(t, r, pa)
+	for _, s := range securitySecrets {
+		err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: s.name}, &corev1.Secret{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("expect secret %s is deleted, but got %v", s.name, err)
+		}
+	}
+}
+
+func TestReconcileSecretUserProvided(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.Security = true
+	userSecret := &corev1.Secret{
+		Data: map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+	}
+	userSecret.Name = RedisSecretName
+	userSecret.Namespace = pa.Namespace
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa, userSecret)
+
+	reconcilePlatformAdmin(t, r, pa)
+	secret := getSecrets(t, r, pa.Namespace)[RedisSecretName]
+	if !reflect.DeepEqual(secret.Data, userSecret.Data) || len(secret.OwnerReferences) != 0 {
+		t.Errorf("expect secret created by user is used as is, but got %v", secret)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+const (
+	LabelServiceMonitor = "ServiceMonitor"
+
+	// AnnotationPrometheusScrape, AnnotationPrometheusPort and AnnotationPrometheusPath are the conventional scrape
+	// hints of prometheus, they are set to the services of components exposing metrics.
+	AnnotationPrometheusScrape = "prometheus.io/scrape"
+	AnnotationPrometheusPort   = "prometheus.io/port"
+	AnnotationPrometheusPath   = "prometheus.io/path"
+
+	defaultMetricsPath = "/metrics"
+)
+
+// serviceMonitorKind is the kind of prometheus operator, it is handled as unstructured so the controller does not
+// depend on the prometheus operator.
+var serviceMonitorKind = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// newServiceMonitorObject returns an empty ServiceMonitor.
+func newServiceMonitorObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(serviceMonitorKind)
+	return obj
+}
+
+// newServiceMonitorList returns an empty list of ServiceMonitors.
+func newServiceMonitorList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(serviceMonitorKind.GroupVersion().WithKind(serviceMonitorKind.Kind + "List"))
+	return list
+}
+
+// metricsPath returns the path of metrics, which defaults to /metrics.
+func metricsPath(metrics *config.ComponentMetrics) string {
+	if metrics.Path == "" {
+		return defaultMetricsPath
+	}
+	return metrics.Path
+}
+
+// metricsPort returns the container port serving the metrics, it defaults to the target port of the named service
+// port, or the service port itself if the target port is not a number.
+func metricsPort(component *config.Component) int32 {
+	if component.Metrics.Port != 0 {
+		return component.Metrics.Port
+	}
+	port := config.MetricsServicePort(component.Metrics, component.Service)
+	if port == nil {
+		return 0
+	}
+	if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal != 0 {
+		return port.TargetPort.IntVal
+	}
+	return port.Port
+}
+
+// setMetricsAnnotations sets the scrape hints of the component to its service, and removes them once the component
+// stops exposing metrics.
+func setMetricsAnnotations(service *corev1.Service, component *config.Component) {
+	if component.Metrics == nil || metricsPort(component) == 0 {
+		for _, key := range []string{AnnotationPrometheusScrape, AnnotationPrometheusPort, AnnotationPrometheusPath} {
+			delete(service.Annotations, key)
+		}
+		return
+	}
+	if service.Annotations == nil {
+		service.Annotations = make(map[string]string)
+	}
+	service.Annotations[AnnotationPrometheusScrape] = "true"
+	service.Annotations[AnnotationPrometheusPort] = strconv.Itoa(int(metricsPort(component)))
+	service.Annotations[AnnotationPrometheusPath] = metricsPath(component.Metrics)
+}
+
+// newServiceMonitorSpec selects the service of component by its component label, and scrapes the named port or the
+// target port of metrics.
+func newServiceMonitorSpec(component *config.Component) map[string]interface{} {
+	endpoint := map[string]interface{}{"path": metricsPath(component.Metrics)}
+	if component.Metrics.PortName != "" {
+		endpoint["port"] = component.Metrics.PortName
+	} else {
+		endpoint["targetPort"] = int64(metricsPort(component))
+	}
+	return map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				iotv1alpha2.LabelPlatformAdminGenerate: LabelService,
+				iotv1alpha2.LabelComponent:             component.Name,
+			},
+		},
+		"endpoints": []interface{}{endpoint},
+	}
+}
+
+// handleServiceMonitor creates or updates the ServiceMonitor of component when it is enabled by PlatformAdmin.
+// It is possible for the ServiceMonitor to be nil when there is no error, e.g. the CRD is not installed and
+// the metrics are only hinted by the annotations of service!
+func (r *ReconcilePlatformAdmin) handleServiceMonitor(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component) (*unstructured.Unstructured, error) {
+	if platformAdmin.Spec.Monitoring == nil || !platformAdmin.Spec.Monitoring.ServiceMonitor || component.Metrics == nil || component.Service == nil {
+		return nil, nil
+	}
+	if !r.serviceMonitorAvailable {
+		log.FromContext(ctx).V(4).Info("ServiceMonitor is not installed, the metrics are only annotated", "component", component.Name)
+		return nil, nil
+	}
+
+	serviceMonitor := newServiceMonitorObject()
+	serviceMonitor.SetNamespace(workloadNamespace(platformAdmin))
+	serviceMonitor.SetName(component.Name)
+	result, err := controllerutil.CreateOrUpdate(
+		ctx,
+		r.Client,
+		serviceMonitor,
+		func() error {
+			labels := serviceMonitor.GetLabels()
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[iotv1alpha2.LabelPlatformAdminGenerate] = LabelServiceMonitor
+			serviceMonitor.SetLabels(labels)
+			propagateMetadata(platformAdmin, serviceMonitor)
+			if err := unstructured.SetNestedField(serviceMonitor.Object, newServiceMonitorSpec(component), "spec"); err != nil {
+				return err
+			}
+			return r.setOwner(platformAdmin, serviceMonitor)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordOperationResult(kindServiceMonitor, result)
+	return serviceMonitor, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+// newTestServiceMonitorReconciler returns a reconciler whose client knows ServiceMonitor, as if the CRD is installed.
+func newTestServiceMonitorReconciler(conf *config.PlatformAdminControllerConfiguration, objs ...client.Object) *ReconcilePlatformAdmin {
+	r := newTestReconciler(conf)
+	r.scheme.AddKnownTypeWithName(serviceMonitorKind, &unstructured.Unstructured{})
+	r.scheme.AddKnownTypeWithName(newServiceMonitorList().GroupVersionKind(), &unstructured.UnstructuredList{})
+	metav1.AddToGroupVersion(r.scheme, serviceMonitorKind.GroupVersion())
+	r.Client = newApplyClient(fakeclient.NewClientBuilder().WithScheme(r.scheme).WithObjects(objs...).Build())
+	r.serviceMonitorAvailable = true
+	return r
+}
+
+func getServiceMonitor(t *testing.T, r *ReconcilePlatformAdmin, namespace, name string) (*unstructured.Unstructured, error) {
+	t.Helper()
+	serviceMonitor := newServiceMonitorObject()
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, serviceMonitor)
+	return serviceMonitor, err
+}
+
+func setMonitoring(t *testing.T, r *ReconcilePlatformAdmin, pa *iotv1alpha2.PlatformAdmin, monitoring *iotv1alpha2.PlatformAdminMonitoring) {
+	t.Helper()
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	pa.Spec.Monitoring = monitoring
+	if err := r.Update(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to update PlatformAdmin, %v", err)
+	}
+}
+
+func newTestMetricsComponent(metrics *config.ComponentMetrics) *config.Component {
+	component := newTestComponent(testComponent, testImage)
+	component.Metrics = metrics
+	return component
+}
+
+func TestSetMetricsAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		metrics     *config.ComponentMetrics
+		targetPort  intstr.IntOrString
+		expectPort  string
+		expectPath  string
+		annotations map[string]string
+	}{
+		{
+			name:       "port number",
+			metrics:    &config.ComponentMetrics{Port: 9090, Path: "/api/v2/metrics"},
+			expectPort: "9090",
+			expectPath: "/api/v2/metrics",
+		},
+		{
+			name:       "target port of named port",
+			metrics:    &config.ComponentMetrics{PortName: "http"},
+			targetPort: intstr.FromInt(8080),
+			expectPort: "8080",
+			expectPath: "/metrics",
+		},
+		{
+			name:       "named target port",
+			metrics:    &config.ComponentMetrics{PortName: "http"},
+			targetPort: intstr.FromString("http"),
+			expectPort: "59882",
+			expectPath: "/metrics",
+		},
+		{
+			name: "metrics are removed",
+			annotations: map[string]string{
+				AnnotationPrometheusScrape: "true",
+				AnnotationPrometheusPort:   "59882",
+				AnnotationPrometheusPath:   "/metrics",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := newTestMetricsComponent(tt.metrics)
+			component.Service.Ports[0].TargetPort = tt.targetPort
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			setMetricsAnnotations(service, component)
+			if tt.metrics == nil {
+				if len(service.Annotations) != 0 {
+					t.Errorf("expect the scrape annotations are removed, but got %v", service.Annotations)
+				}
+				return
+			}
+			if service.Annotations[AnnotationPrometheusScrape] != "true" || service.Annotations[AnnotationPrometheusPort] != tt.expectPort ||
+				service.Annotations[AnnotationPrometheusPath] != tt.expectPath {
+				t.Errorf("expect scrape port %s and path %s, but got %v", tt.expectPort, tt.expectPath, service.Annotations)
+			}
+		})
+	}
+}
+
+func TestServiceMonitor(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "edgex-uid"
+	pa.Spec.Monitoring = &iotv1alpha2.PlatformAdminMonitoring{ServiceMonitor: true}
+	r := newTestServiceMonitorReconciler(newTestConfiguration(newTestMetricsComponent(&config.ComponentMetrics{PortName: "http"})), pa)
+
+	reconcilePlatformAdmin(t, r, pa)
+	service := &corev1.Service{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, service); err != nil {
+		t.Fatalf("failed to get service, %v", err)
+	}
+	if service.Annotations[AnnotationPrometheusScrape] != "true" || service.Annotations[AnnotationPrometheusPort] != "59882" {
+		t.Errorf("expect service is annotated for scraping, but got %v", service.Annotations)
+	}
+	serviceMonitor, err := getServiceMonitor(t, r, pa.Namespace, testComponent)
+	if err != nil {
+		t.Fatalf("expect service monitor is created, but got %v", err)
+	}
+	if serviceMonitor.GetLabels()[iotv1alpha2.LabelPlatformAdminGenerate] != LabelServiceMonitor || !isOwnedBy(serviceMonitor, pa) {
+		t.Errorf("expect service monitor is labeled and owned by PlatformAdmin, but got %v, %v", serviceMonitor.GetLabels(), serviceMonitor.GetOwnerReferences())
+	}
+	selector, _, _ := unstructured.NestedStringMap(serviceMonitor.Object, "spec", "selector", "matchLabels")
+	for k, v := range selector {
+		if service.Labels[k] != v {
+			t.Errorf("expect service monitor selects the service, but got selector %v and labels %v", selector, service.Labels)
+		}
+	}
+	endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	if len(endpoints) != 1 || endpoints[0].(map[string]interface{})["port"] != "http" || endpoints[0].(map[string]interface{})["path"] != "/metrics" {
+		t.Errorf("expect service monitor scrapes /metrics of port http, but got %v", endpoints)
+	}
+
+	// the service monitor is cleaned up once monitoring is disabled, and the annotations are kept
+	setMonitoring(t, r, pa, nil)
+	reconcilePlatformAdmin(t, r, pa)
+	if _, err := getServiceMonitor(t, r, pa.Namespace, testComponent); !apierrors.IsNotFound(err) {
+		t.Errorf("expect service monitor is deleted, but got %v", err)
+	}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, service); err != nil {
+		t.Fatalf("failed to get service, %v", err)
+	}
+	if service.Annotations[AnnotationPrometheusScrape] != "true" {
+		t.Errorf("expect service is still annotated for scraping, but got %v", service.Annotations)
+	}
+}
+
+func TestServiceMonitorNotInstalled(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.Spec.Monitoring = &iotv1alpha2.PlatformAdminMonitoring{ServiceMonitor: true}
+	// the client does not know ServiceMonitor, so any request of it fails the component
+	r := newTestReconciler(newTestConfiguration(newTestMetricsComponent(&config.ComponentMetrics{Port: 9090})), pa)
+
+	reconcilePlatformAdmin(t, r, pa)
+	service := &corev1.Service{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, service); err != nil {
+		t.Fatalf("failed to get service, %v", err)
+	}
+	if service.Annotations[AnnotationPrometheusScrape] != "true" || service.Annotations[AnnotationPrometheusPort] != "9090" {
+		t.Errorf("expect service is annotated for scraping, but got %v", service.Annotations)
+	}
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	for _, condition := range pa.Status.Conditions {
+		if condition.Reason == iotv1alpha2.ComponentProvisioningFailedReason {
+			t.Errorf("expect component is not failed by the missing ServiceMonitor, but got %v", condition)
+		}
+	}
+}
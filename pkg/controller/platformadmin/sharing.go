@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
+)
+
+// workloadPlatformAdmins returns the PlatformAdmins which generate their objects in the namespace. The result is
+// filtered again, because the index is not honored by every client(e.g. the fake client of tests).
+func (r *ReconcilePlatformAdmin) workloadPlatformAdmins(ctx context.Context, namespace string) ([]iotv1alpha2.PlatformAdmin, error) {
+	platformAdmins := &iotv1alpha2.PlatformAdminList{}
+	if err := r.List(ctx, platformAdmins, client.MatchingFields{util.IndexerPathForWorkloadNamespace: namespace}); err != nil {
+		return nil, err
+	}
+	var result []iotv1alpha2.PlatformAdmin
+	for _, platformAdmin := range platformAdmins.Items {
+		if workloadNamespace(&platformAdmin) == namespace && r.inScope(platformAdmin.Namespace) {
+			result = append(result, platformAdmin)
+		}
+	}
+	return result, nil
+}
+
+// sharingPlatformAdmins returns the other PlatformAdmins which deploy the component with the same yurtappset
+// as the PlatformAdmin. The PlatformAdmins being deleted are skipped, their pools are being removed.
+func (r *ReconcilePlatformAdmin) sharingPlatformAdmins(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, conf *config.PlatformAdminControllerConfiguration) ([]iotv1alpha2.PlatformAdmin, error) {
+	platformAdmins, err := r.workloadPlatformAdmins(ctx, workloadNamespace(platformAdmin))
+	if err != nil {
+		return nil, err
+	}
+	var result []iotv1alpha2.PlatformAdmin
+	for i := range platformAdmins {
+		other := &platformAdmins[i]
+		if (other.Namespace == platformAdmin.Namespace && other.Name == platformAdmin.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		additional, err := r.additionalComponentsConfigMap(ctx, other)
+		if err != nil {
+			continue
+		}
+		components, err := desiredComponents(other, conf, additional)
+		if err != nil {
+			// The broken PlatformAdmin reports the error on its own reconcile
+			continue
+		}
+		for _, c := range components {
+			if c.Name == component.Name && !isDaemonComponent(c) {
+				result = append(result, *other)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// restoreSharedPools appends the pools of the PlatformAdmins sharing the yurtappset to be created. A yurtappset
+// deleted by others is created again by the first PlatformAdmin reconciled, and the pools of the others would be
+// lost until their next reconcile otherwise.
+func (r *ReconcilePlatformAdmin) restoreSharedPools(ctx context.Context, platformAdmin *iotv1alpha2.PlatformAdmin, component *config.Component, conf *config.PlatformAdminControllerConfiguration, yas *appsv1alpha1.YurtAppSet) error {
+	others, err := r.sharingPlatformAdmins(ctx, platformAdmin, component, conf)
+	if err != nil {
+		return err
+	}
+	var restored []string
+	for i := range others {
+		other := &others[i]
+		appended := false
+		for _, poolName := range util.PlatformAdminPools(other) {
+			if containsPool(yas, poolName) {
+				continue
+			}
+			yas.Spec.Topology.Pools = append(yas.Spec.Topology.Pools, newPool(other, component, poolName))
+			appended = true
+		}
+		if !appended {
+			continue
+		}
+		// The PlatformAdmins across namespaces record themselves by label on their own reconcile
+		if !isCrossNamespace(other) {
+			if err := controllerutil.SetOwnerReference(other, yas, r.Scheme()); err != nil {
+				return err
+			}
+		}
+		restored = append(restored, types.NamespacedName{Namespace: other.Namespace, Name: other.Name}.String())
+	}
+	if len(restored) > 0 {
+		log.FromContext(ctx).Info("Restore pools of PlatformAdmins sharing YurtAppSet", "component", component.Name, "yurtappset", yas.Name, "platformadmins", restored)
+	}
+	return nil
+}
+
+func containsPool(yas *appsv1alpha1.YurtAppSet, poolName string) bool {
+	for _, pool := range yas.Spec.Topology.Pools {
+		if pool.Name == poolName {
+			return true
+		}
+	}
+	return false
+}
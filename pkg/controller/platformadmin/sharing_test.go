@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func poolNames(yas *appsv1alpha1.YurtAppSet) []string {
+	var names []string
+	for _, pool := range yas.Spec.Topology.Pools {
+		names = append(names, pool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRecreateSharedYurtAppSet(t *testing.T) {
+	tests := []struct {
+		name string
+		// workloadNamespace is the spec.workloadNamespace of the PlatformAdmins
+		workloadNamespace string
+		// deleting is whether the other PlatformAdmin is being deleted
+		deleting    bool
+		expectPools []string
+	}{
+		{name: "same namespace", expectPools: []string{"beijing", "hangzhou"}},
+		{name: "across namespaces", workloadNamespace: "edge", expectPools: []string{"beijing", "hangzhou"}},
+		{name: "other PlatformAdmin is being deleted", deleting: true, expectPools: []string{"hangzhou"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa1 := newTestPlatformAdmin("default", "edgex-hangzhou", "hangzhou")
+			pa1.UID = "uid-hangzhou"
+			pa1.Spec.WorkloadNamespace = tt.workloadNamespace
+			pa2 := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+			pa2.UID = "uid-beijing"
+			pa2.Spec.WorkloadNamespace = tt.workloadNamespace
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa1, pa2)
+			reconcilePlatformAdmin(t, r, pa1)
+			reconcilePlatformAdmin(t, r, pa2)
+
+			namespace := workloadNamespace(pa1)
+			yas := getYurtAppSet(t, r, namespace, testComponent)
+			if pools := poolNames(yas); !reflect.DeepEqual(pools, []string{"beijing", "hangzhou"}) {
+				t.Fatalf("expect pools of both PlatformAdmins, but got %v", pools)
+			}
+			if tt.deleting {
+				// The finalizer of PlatformAdmin keeps it until it is reconciled
+				if err := r.Delete(context.TODO(), pa2); err != nil {
+					t.Fatalf("failed to delete PlatformAdmin, %v", err)
+				}
+			}
+
+			// The yurtappset is deleted by others, and only the PlatformAdmin of hangzhou is reconciled
+			if err := r.Delete(context.TODO(), yas); err != nil {
+				t.Fatalf("failed to delete YurtAppSet, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa1)
+			yas = getYurtAppSet(t, r, namespace, testComponent)
+			if pools := poolNames(yas); !reflect.DeepEqual(pools, tt.expectPools) {
+				t.Errorf("expect pools %v, but got %v", tt.expectPools, pools)
+			}
+			if tt.workloadNamespace == "" && !tt.deleting && !isOwnedBy(yas, pa2) {
+				t.Errorf("expect the restored yurtappset is owned by the sharing PlatformAdmin, but got %v", yas.OwnerReferences)
+			}
+
+			// The other PlatformAdmin converges without recreating its pool
+			reconcilePlatformAdmin(t, r, pa2)
+			if pools := poolNames(getYurtAppSet(t, r, namespace, testComponent)); !reflect.DeepEqual(pools, tt.expectPools) {
+				t.Errorf("expect pools %v after the other PlatformAdmin is reconciled, but got %v", tt.expectPools, pools)
+			}
+		})
+	}
+}
+
+func TestSharingPlatformAdmins(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex-hangzhou", "hangzhou")
+	sharing := newTestPlatformAdmin("default", "edgex-beijing", "beijing")
+	disabled := newTestPlatformAdmin("default", "edgex-shanghai", "shanghai")
+	disabled.Spec.DisabledComponents = []string{testComponent}
+	elsewhere := newTestPlatformAdmin("default", "edgex-shenzhen", "shenzhen")
+	elsewhere.Spec.WorkloadNamespace = "edge"
+	component := newTestComponent(testComponent, testImage)
+	conf := newTestConfiguration(component)
+	r := newTestReconciler(conf, pa, sharing, disabled, elsewhere)
+
+	others, err := r.sharingPlatformAdmins(context.TODO(), pa, component, conf)
+	if err != nil {
+		t.Fatalf("failed to list sharing PlatformAdmins, %v", err)
+	}
+	var names []string
+	for _, other := range others {
+		names = append(names, other.Name)
+	}
+	if expect := []string{"edgex-beijing"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect sharing PlatformAdmins %v, but got %v", expect, names)
+	}
+}
+
+func TestMapGeneratedToWorkloadPlatformAdmins(t *testing.T) {
+	pa1 := newTestPlatformAdmin("management", "edgex-hangzhou", "hangzhou")
+	pa1.Spec.WorkloadNamespace = "edge"
+	pa2 := newTestPlatformAdmin("operations", "edgex-beijing", "beijing")
+	pa2.Spec.WorkloadNamespace = "edge"
+	pa3 := newTestPlatformAdmin("operations", "edgex-shanghai", "shanghai")
+	r := newTestReconciler(newTestConfiguration(), pa1, pa2, pa3)
+
+	// The yurtappset carries the label of the PlatformAdmin which created it only
+	yas := &appsv1alpha1.YurtAppSet{}
+	yas.Namespace = "edge"
+	yas.Name = testComponent
+	yas.Labels = map[string]string{
+		iotv1alpha2.LabelPlatformAdminGenerate: LabelDeployment,
+		iotv1alpha2.LabelPlatformAdmin:         platformAdminLabelValue(pa1),
+	}
+	expect := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "management", Name: "edgex-hangzhou"}},
+		{NamespacedName: types.NamespacedName{Namespace: "operations", Name: "edgex-beijing"}},
+	}
+	if requests := r.mapGeneratedToPlatformAdmins(yas); !reflect.DeepEqual(requests, expect) {
+		t.Errorf("expect requests %v, but got %v", expect, requests)
+	}
+}
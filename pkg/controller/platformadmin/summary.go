@@ -0,0 +1,220 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// SummaryPath is the path of the summary of PlatformAdmins, it is served by the metrics server of yurt-manager.
+const SummaryPath = "/platformadmin/summary"
+
+// ReadinessCount is the readiness of a group of PlatformAdmins and their components.
+type ReadinessCount struct {
+	Total             int   `json:"total"`
+	Ready             int   `json:"ready"`
+	Unready           int   `json:"unready"`
+	ReadyComponents   int32 `json:"readyComponents"`
+	UnreadyComponents int32 `json:"unreadyComponents"`
+	// UnreadyConditions counts the PlatformAdmins by the types of their conditions which are not true
+	UnreadyConditions map[string]int `json:"unreadyConditions,omitempty"`
+}
+
+// Summary is the readiness of all PlatformAdmins reconciled by the controller, with a breakdown by namespace.
+type Summary struct {
+	ReadinessCount `json:",inline"`
+	Namespaces     map[string]*ReadinessCount `json:"namespaces"`
+}
+
+// platformAdminReadiness is the readiness of a PlatformAdmin told by its status.
+type platformAdminReadiness struct {
+	ready             bool
+	readyComponents   int32
+	unreadyComponents int32
+	unreadyConditions []string
+}
+
+func newPlatformAdminReadiness(status *iotv1alpha2.PlatformAdminStatus) platformAdminReadiness {
+	readiness := platformAdminReadiness{
+		ready:             status.Ready,
+		readyComponents:   status.ReadyComponentNum,
+		unreadyComponents: status.UnreadyComponentNum,
+	}
+	for _, cond := range status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			readiness.unreadyConditions = append(readiness.unreadyConditions, string(cond.Type))
+		}
+	}
+	return readiness
+}
+
+func (c *ReadinessCount) add(readiness platformAdminReadiness) {
+	c.Total++
+	if readiness.ready {
+		c.Ready++
+	} else {
+		c.Unready++
+	}
+	c.ReadyComponents += readiness.readyComponents
+	c.UnreadyComponents += readiness.unreadyComponents
+	for _, condition := range readiness.unreadyConditions {
+		if c.UnreadyConditions == nil {
+			c.UnreadyConditions = make(map[string]int)
+		}
+		c.UnreadyConditions[condition]++
+	}
+}
+
+// summaryRegistry keeps the readiness of PlatformAdmins in memory, so the summary is served without listing them.
+// It is updated by the reconciles, and rebuilt from the PlatformAdmins when the controller is started, since only
+// the leader reconciles. A nil registry records nothing.
+type summaryRegistry struct {
+	mu      sync.RWMutex
+	entries map[types.NamespacedName]platformAdminReadiness
+	// touched records the PlatformAdmins reconciled while the registry is being rebuilt, their readiness is newer
+	// than the listed one. It is nil unless a rebuild is in progress.
+	touched map[types.NamespacedName]struct{}
+}
+
+func newSummaryRegistry() *summaryRegistry {
+	return &summaryRegistry{entries: make(map[types.NamespacedName]platformAdminReadiness)}
+}
+
+// set records the readiness of PlatformAdmin written to its status.
+func (s *summaryRegistry) set(key types.NamespacedName, status *iotv1alpha2.PlatformAdminStatus) {
+	if s == nil {
+		return
+	}
+	readiness := newPlatformAdminReadiness(status)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = readiness
+	s.touch(key)
+}
+
+// remove forgets the deleted PlatformAdmin.
+func (s *summaryRegistry) remove(key types.NamespacedName) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	s.touch(key)
+}
+
+func (s *summaryRegistry) touch(key types.NamespacedName) {
+	if s.touched != nil {
+		s.touched[key] = struct{}{}
+	}
+}
+
+// startRebuild starts recording the PlatformAdmins reconciled before the listed readiness is swapped in.
+func (s *summaryRegistry) startRebuild() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touched = make(map[types.NamespacedName]struct{})
+}
+
+// finishRebuild swaps in the listed readiness of all PlatformAdmins, except the ones reconciled since the rebuild
+// is started. The rebuild is abandoned if entries is nil.
+func (s *summaryRegistry) finishRebuild(entries map[types.NamespacedName]platformAdminReadiness) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touched := s.touched
+	s.touched = nil
+	if entries == nil {
+		return
+	}
+	for key := range touched {
+		delete(entries, key)
+		if readiness, ok := s.entries[key]; ok {
+			entries[key] = readiness
+		}
+	}
+	s.entries = entries
+}
+
+// summary aggregates the readiness of PlatformAdmins.
+func (s *summaryRegistry) summary() *Summary {
+	summary := &Summary{Namespaces: make(map[string]*ReadinessCount)}
+	if s == nil {
+		return summary
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, readiness := range s.entries {
+		summary.add(readiness)
+		if _, ok := summary.Namespaces[key.Namespace]; !ok {
+			summary.Namespaces[key.Namespace] = &ReadinessCount{}
+		}
+		summary.Namespaces[key.Namespace].add(readiness)
+	}
+	return summary
+}
+
+// ServeHTTP serves the summary as json.
+func (s *summaryRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := json.Marshal(s.summary())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// rebuildSummary rebuilds the registry from the status of PlatformAdmins in the scope of controller. It runs when
+// the controller is started, e.g. on the new leader after a failover, whose registry is not updated by reconciles.
+func (r *ReconcilePlatformAdmin) rebuildSummary(ctx context.Context) error {
+	r.summary.startRebuild()
+	entries := make(map[types.NamespacedName]platformAdminReadiness)
+	if err := r.listPages(ctx, &iotv1alpha2.PlatformAdminList{}, func(obj client.Object) error {
+		platformAdmin := obj.(*iotv1alpha2.PlatformAdmin)
+		if r.inScope(platformAdmin.Namespace) {
+			entries[client.ObjectKeyFromObject(platformAdmin)] = newPlatformAdminReadiness(&platformAdmin.Status)
+		}
+		return nil
+	}); err != nil {
+		r.summary.finishRebuild(nil)
+		return err
+	}
+	r.summary.finishRebuild(entries)
+	klog.V(4).InfoS("Rebuilt the summary of PlatformAdmins", "controller", ControllerName, "count", len(entries))
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// getSummary requests the summary from the handler of registry.
+func getSummary(t *testing.T, s *summaryRegistry) *Summary {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, SummaryPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expect status %d, but got %d", http.StatusOK, rec.Code)
+	}
+	summary := &Summary{}
+	if err := json.Unmarshal(rec.Body.Bytes(), summary); err != nil {
+		t.Fatalf("failed to decode summary %s, %v", rec.Body.String(), err)
+	}
+	return summary
+}
+
+func newTestStatus(ready bool, readyComponents, unreadyComponents int32, unreadyConditions ...iotv1alpha2.PlatformAdminConditionType) *iotv1alpha2.PlatformAdminStatus {
+	status := &iotv1alpha2.PlatformAdminStatus{Ready: ready, ReadyComponentNum: readyComponents, UnreadyComponentNum: unreadyComponents}
+	for _, condition := range unreadyConditions {
+		status.Conditions = append(status.Conditions, iotv1alpha2.PlatformAdminCondition{Type: condition, Status: corev1.ConditionFalse})
+	}
+	return status
+}
+
+func TestSummaryHandler(t *testing.T) {
+	s := newSummaryRegistry()
+	s.set(types.NamespacedName{Namespace: "hangzhou", Name: "edgex"}, newTestStatus(true, 5, 0))
+	s.set(types.NamespacedName{Namespace: "hangzhou", Name: "edgex-2"}, newTestStatus(false, 3, 2, iotv1alpha2.ComponentAvailableCondition))
+	s.set(types.NamespacedName{Namespace: "beijing", Name: "edgex"}, newTestStatus(false, 0, 5, iotv1alpha2.ComponentAvailableCondition, iotv1alpha2.ConfigmapAvailableCondition))
+	s.set(types.NamespacedName{Namespace: "beijing", Name: "removed"}, newTestStatus(true, 1, 0))
+	s.remove(types.NamespacedName{Namespace: "beijing", Name: "removed"})
+
+	expect := &Summary{
+		ReadinessCount: ReadinessCount{Total: 3, Ready: 1, Unready: 2, ReadyComponents: 8, UnreadyComponents: 7,
+			UnreadyConditions: map[string]int{string(iotv1alpha2.ComponentAvailableCondition): 2, string(iotv1alpha2.ConfigmapAvailableCondition): 1}},
+		Namespaces: map[string]*ReadinessCount{
+			"hangzhou": {Total: 2, Ready: 1, Unready: 1, ReadyComponents: 8, UnreadyComponents: 2,
+				UnreadyConditions: map[string]int{string(iotv1alpha2.ComponentAvailableCondition): 1}},
+			"beijing": {Total: 1, Unready: 1, UnreadyComponents: 5,
+				UnreadyConditions: map[string]int{string(iotv1alpha2.ComponentAvailableCondition): 1, string(iotv1alpha2.ConfigmapAvailableCondition): 1}},
+		},
+	}
+	if summary := getSummary(t, s); !reflect.DeepEqual(summary, expect) {
+		t.Errorf("expect summary %+v, but got %+v", expect, summary)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, SummaryPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expect status %d of post, but got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	// the empty registry is served with zero counts
+	if summary := getSummary(t, newSummaryRegistry()); summary.Total != 0 || len(summary.Namespaces) != 0 {
+		t.Errorf("expect empty summary, but got %+v", summary)
+	}
+}
+
+func TestSummaryConcurrency(t *testing.T) {
+	s := newSummaryRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := types.NamespacedName{Namespace: fmt.Sprintf("ns-%d", i%3), Name: fmt.Sprintf("edgex-%d", j%10)}
+				s.set(key, newTestStatus(j%2 == 0, 1, 1))
+				_ = s.summary()
+				if j%5 == 0 {
+					s.remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if summary := s.summary(); summary.Total != summary.Ready+summary.Unready {
+		t.Errorf("expect total is the sum of ready and unready, but got %+v", summary)
+	}
+}
+
+func TestRebuildSummary(t *testing.T) {
+	newPlatformAdmin := func(namespace, name string, ready bool) client.Object {
+		pa := newTestPlatformAdmin(namespace, name, name)
+		pa.Status = *newTestStatus(ready, 2, 0)
+		return pa
+	}
+	r := newTestReconciler(newTestConfiguration(),
+		newPlatformAdmin("hangzhou", "edgex", true), newPlatformAdmin("hangzhou", "edgex-2", false),
+		newPlatformAdmin("beijing", "edgex", true), newPlatformAdmin("out-of-scope", "edgex", true))
+	r.namespaces = sets.NewString("hangzhou", "beijing")
+	// the entry left by the previous term of leadership is dropped
+	r.summary.set(types.NamespacedName{Namespace: "hangzhou", Name: "deleted"}, newTestStatus(true, 1, 0))
+
+	if err := r.rebuildSummary(context.TODO()); err != nil {
+		t.Fatalf("failed to rebuild summary, %v", err)
+	}
+	summary := getSummary(t, r.summary)
+	if summary.Total != 3 || summary.Ready != 2 || summary.Unready != 1 || summary.ReadyComponents != 6 {
+		t.Errorf("expect 3 PlatformAdmins with 2 ready, but got %+v", summary.ReadinessCount)
+	}
+	if len(summary.Namespaces) != 2 || summary.Namespaces["hangzhou"].Total != 2 || summary.Namespaces["beijing"].Total != 1 {
+		t.Errorf("expect the PlatformAdmins in scope are counted by namespace, but got %+v", summary.Namespaces)
+	}
+}
+
+func TestRebuildSummaryKeepsReconciled(t *testing.T) {
+	s := newSummaryRegistry()
+	reconciled := types.NamespacedName{Namespace: "hangzhou", Name: "edgex"}
+	deleted := types.NamespacedName{Namespace: "hangzhou", Name: "deleted"}
+
+	// the PlatformAdmins are reconciled while the list is in flight, the listed readiness of them is stale
+	s.startRebuild()
+	s.set(reconciled, newTestStatus(true, 2, 0))
+	s.remove(deleted)
+	s.finishRebuild(map[types.NamespacedName]platformAdminReadiness{
+		reconciled:                            newPlatformAdminReadiness(newTestStatus(false, 0, 2)),
+		deleted:                               newPlatformAdminReadiness(newTestStatus(true, 2, 0)),
+		{Namespace: "beijing", Name: "edgex"}: newPlatformAdminReadiness(newTestStatus(true, 2, 0)),
+	})
+	summary := s.summary()
+	if summary.Total != 2 || summary.Ready != 2 || summary.Namespaces["hangzhou"].Total != 1 {
+		t.Errorf("expect the reconciled readiness wins over the listed one, but got %+v", summary.ReadinessCount)
+	}
+}
+
+func TestReconcileUpdatesSummary(t *testing.T) {
+	pa := newTestPlatformAdmin("default", "edgex", "hangzhou")
+	pa.UID = "uid-hangzhou"
+	r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+	reconcilePlatformAdmin(t, r, pa)
+	if summary := getSummary(t, r.summary); summary.Total != 1 || summary.Unready != 1 || summary.UnreadyComponents != 1 {
+		t.Errorf("expect the unready PlatformAdmin is counted, but got %+v", summary.ReadinessCount)
+	}
+
+	if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+		t.Fatalf("failed to get PlatformAdmin, %v", err)
+	}
+	if err := r.Delete(context.TODO(), pa); err != nil {
+		t.Fatalf("failed to delete PlatformAdmin, %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		reconcilePlatformAdmin(t, r, pa)
+	}
+	if summary := getSummary(t, r.summary); summary.Total != 0 {
+		t.Errorf("expect the deleted PlatformAdmin is removed, but got %+v", summary.ReadinessCount)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+// publishSupportedVersions publishes the supported versions of the configuration in use into the configmap of
+// framework namespace, so the clients can present the valid versions without knowing the component templates.
+func (r *ReconcilePlatformAdmin) publishSupportedVersions(ctx context.Context) error {
+	content, err := config.EncodeSupportedVersions(config.SupportedVersions(r.getConfiguration()))
+	if err != nil {
+		return err
+	}
+
+	configmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: r.frameworkNamespace, Name: config.SupportedVersionsConfigMapName},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configmap, func() error {
+		configmap.Data = map[string]string{config.SupportedVersionsKey: content}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	recordOperationResult(kindConfigMap, result)
+	klog.V(4).InfoS("Published supported versions", "controller", ControllerName, "configmap", klog.KObj(configmap), "result", result)
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+func getSupportedVersions(t *testing.T, r *ReconcilePlatformAdmin) []config.SupportedVersion {
+	t.Helper()
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: r.frameworkNamespace, Name: config.SupportedVersionsConfigMapName}, cm); err != nil {
+		t.Fatalf("failed to get supported versions configmap, %v", err)
+	}
+	var versions []config.SupportedVersion
+	if err := json.Unmarshal([]byte(cm.Data[config.SupportedVersionsKey]), &versions); err != nil {
+		t.Fatalf("failed to decode supported versions, %v", err)
+	}
+	return versions
+}
+
+func TestPublishSupportedVersions(t *testing.T) {
+	conf := newTestConfiguration(newTestComponent(testComponent, testImage))
+	conf.NoSectyComponents["minnesota"] = []*config.Component{newTestComponent("edgex-core-metadata", testImage)}
+	r := newTestReconciler(conf)
+
+	if err := r.publishSupportedVersions(context.TODO()); err != nil {
+		t.Fatalf("failed to publish supported versions, %v", err)
+	}
+	expect := []config.SupportedVersion{
+		{
+			Name:               testVersion,
+			Security:           true,
+			NoSecty:            true,
+			SecurityComponents: []string{testComponent},
+			NoSectyComponents:  []string{testComponent},
+		},
+		{
+			Name:              "minnesota",
+			NoSecty:           true,
+			NoSectyComponents: []string{"edgex-core-metadata"},
+		},
+	}
+	if got := getSupportedVersions(t, r); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect supported versions %v, but got %v", expect, got)
+	}
+
+	// the published versions follow the reloaded configuration
+	r.mapFrameworkToPlatformAdmins(newFrameworkConfigMap(t, newTestConfiguration(newTestComponent("edgex-ui-go", testImage))))
+	expect = []config.SupportedVersion{
+		{
+			Name:               testVersion,
+			Security:           true,
+			NoSecty:            true,
+			SecurityComponents: []string{"edgex-ui-go"},
+			NoSectyComponents:  []string{"edgex-ui-go"},
+		},
+	}
+	if got := getSupportedVersions(t, r); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect supported versions %v after reload, but got %v", expect, got)
+	}
+}
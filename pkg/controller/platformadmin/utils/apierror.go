@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// DeniedError is the failure of a request which the cluster denies for a reason that retrying soon can not fix,
+// e.g. the RBAC rules of yurt-manager, a resource quota or a terminating namespace. Reason is the condition reason
+// reported on PlatformAdmin, and Message tells operators what to do about it.
+type DeniedError struct {
+	Reason  string
+	Message string
+	Err     error
+}
+
+func (e *DeniedError) Error() string {
+	return e.Message
+}
+
+func (e *DeniedError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyAPIError wraps the error of a request denied by RBAC, a resource quota or a terminating namespace into
+// DeniedError, the other errors(e.g. the rejections of webhooks, conflicts and timeouts) are returned as is.
+func ClassifyAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var denied *DeniedError
+	if errors.As(err, &denied) {
+		return err
+	}
+	if !apierrors.IsForbidden(err) {
+		return err
+	}
+	message := err.Error()
+	switch {
+	case apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause):
+		return &DeniedError{Reason: iotv1alpha2.NamespaceTerminatingReason, Err: err,
+			Message: fmt.Sprintf("the namespace is terminating, nothing can be created in it until it is created again: %s", message)}
+	case strings.Contains(message, "exceeded quota"):
+		return &DeniedError{Reason: iotv1alpha2.QuotaExceededReason, Err: err,
+			Message: fmt.Sprintf("the resource quota of the namespace is exceeded, raise the quota or release the resources: %s", message)}
+	case strings.Contains(message, " cannot ") && !strings.Contains(message, "admission webhook"):
+		// The authorizer tells the user, verb and resource, e.g. User "system:serviceaccount:kube-system:yurt-manager" cannot create resource "services"
+		return &DeniedError{Reason: iotv1alpha2.RBACDeniedReason, Err: err,
+			Message: fmt.Sprintf("yurt-manager is not permitted by RBAC, grant the permission to its service account: %s", message)}
+	}
+	return err
+}
+
+// DeniedErrors returns the DeniedErrors of err if it fails only because the requests are denied, and nil if any
+// error is caused by something else, since retrying with backoff can fix those. err may be an aggregate of the
+// errors of components.
+func DeniedErrors(err error) []*DeniedError {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if agg, ok := err.(kerrors.Aggregate); ok {
+		errs = agg.Errors()
+	}
+	denied := make([]*DeniedError, 0, len(errs))
+	for _, e := range errs {
+		var d *DeniedError
+		if !errors.As(ClassifyAPIError(e), &d) {
+			return nil
+		}
+		denied = append(denied, d)
+	}
+	return denied
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+var servicesResource = corev1.Resource("services")
+
+func newRBACDeniedError() error {
+	return apierrors.NewForbidden(servicesResource, "edgex-core-data",
+		errors.New(`User "system:serviceaccount:kube-system:yurt-manager" cannot create resource "services" in API group "" in the namespace "default"`))
+}
+
+func newQuotaExceededError() error {
+	return apierrors.NewForbidden(servicesResource, "edgex-core-data",
+		errors.New("exceeded quota: edgex, requested: services=1, used: services=10, limited: services=10"))
+}
+
+func newNamespaceTerminatingError() error {
+	err := apierrors.NewForbidden(servicesResource, "edgex-core-data",
+		errors.New("unable to create new content in namespace default because it is being terminated"))
+	err.ErrStatus.Details.Causes = append(err.ErrStatus.Details.Causes, metav1.StatusCause{
+		Type:    corev1.NamespaceTerminatingCause,
+		Message: "namespace default is being terminated",
+		Field:   "metadata.namespace",
+	})
+	return err
+}
+
+func TestClassifyAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		// expectReason is the reason of DeniedError, the error is returned as is if it is empty
+		expectReason string
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:         "rbac",
+			err:          newRBACDeniedError(),
+			expectReason: iotv1alpha2.RBACDeniedReason,
+		},
+		{
+			name:         "quota",
+			err:          newQuotaExceededError(),
+			expectReason: iotv1alpha2.QuotaExceededReason,
+		},
+		{
+			name:         "namespace terminating",
+			err:          newNamespaceTerminatingError(),
+			expectReason: iotv1alpha2.NamespaceTerminatingReason,
+		},
+		{
+			name:         "wrapped",
+			err:          fmt.Errorf("failed to create service, %w", newQuotaExceededError()),
+			expectReason: iotv1alpha2.QuotaExceededReason,
+		},
+		{
+			name: "rejected by webhook",
+			err:  apierrors.NewForbidden(servicesResource, "edgex-core-data", errors.New(`admission webhook "vservice.kb.io" denied the request: cannot use port 80`)),
+		},
+		{
+			name: "invalid",
+			err:  apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Service").GroupKind(), "edgex-core-data", field.ErrorList{field.Required(field.NewPath("spec", "ports"), "")}),
+		},
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(servicesResource, "edgex-core-data", errors.New("the object has been modified")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyAPIError(tt.err)
+			var denied *DeniedError
+			if tt.expectReason == "" {
+				if err != tt.err {
+					t.Errorf("expect error %v is returned as is, but got %v", tt.err, err)
+				}
+				return
+			}
+			if !errors.As(err, &denied) {
+				t.Fatalf("expect DeniedError, but got %v", err)
+			}
+			if denied.Reason != tt.expectReason {
+				t.Errorf("expect reason %s, but got %s", tt.expectReason, denied.Reason)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expect the error wraps %v, but got %v", tt.err, err)
+			}
+			if again := ClassifyAPIError(err); again != err {
+				t.Errorf("expect DeniedError is returned as is, but got %v", again)
+			}
+		})
+	}
+}
+
+func TestDeniedErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectDenied int
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:         "single",
+			err:          newRBACDeniedError(),
+			expectDenied: 1,
+		},
+		{
+			name:         "all components are denied",
+			err:          kerrors.NewAggregate([]error{newRBACDeniedError(), newQuotaExceededError()}),
+			expectDenied: 2,
+		},
+		{
+			name: "one component fails for other reasons",
+			err:  kerrors.NewAggregate([]error{newRBACDeniedError(), errors.New("connection refused")}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if denied := DeniedErrors(tt.err); len(denied) != tt.expectDenied {
+				t.Errorf("expect %d denied errors, but got %v", tt.expectDenied, denied)
+			}
+		})
+	}
+}
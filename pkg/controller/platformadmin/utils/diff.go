@@ -0,0 +1,415 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	// MaxPendingChanges bounds the number of changes summarized into the status of PlatformAdmin
+	MaxPendingChanges = 20
+	// MaxPendingChangeLength bounds the length of each summarized change
+	MaxPendingChangeLength = 256
+)
+
+// ComponentObjects are the objects of a component compared by the diff. The pod spec is the template of its
+// workload, and a nil pod spec or service means the object does not exist or is not desired.
+type ComponentObjects struct {
+	Name    string
+	PodSpec *corev1.PodSpec
+	Service *corev1.ServiceSpec
+}
+
+// ImageChange is the change of image of a container, From is empty for an added container and To is empty for
+// a removed one.
+type ImageChange struct {
+	Container string
+	From      string
+	To        string
+}
+
+// ComponentDiff is the difference between the live objects of a component and the desired ones.
+type ComponentDiff struct {
+	Name string
+	// Added means the component has no live objects, and Removed means it is not desired anymore
+	Added   bool
+	Removed bool
+	Images  []ImageChange
+	// EnvAdded, EnvRemoved and EnvChanged are the names of env vars of the containers, sorted
+	EnvAdded   []string
+	EnvRemoved []string
+	EnvChanged []string
+	// PortsAdded and PortsRemoved are the service ports formatted as name:port/protocol, sorted
+	PortsAdded   []string
+	PortsRemoved []string
+}
+
+// ConfigMapDiff is the difference between the data of a live configmap and the desired one.
+type ConfigMapDiff struct {
+	Name        string
+	Added       bool
+	Removed     bool
+	KeysAdded   []string
+	KeysRemoved []string
+	KeysChanged []string
+}
+
+// Diff is the difference between the live objects of a PlatformAdmin and the desired ones, sorted by name.
+type Diff struct {
+	Components []ComponentDiff
+	ConfigMaps []ConfigMapDiff
+}
+
+// LiveComponentObjects collects the objects of components from the workloads and services, which are named after
+// the components.
+func LiveComponentObjects(yurtAppSets []appsv1alpha1.YurtAppSet, yurtAppDaemons []appsv1alpha1.YurtAppDaemon, services []corev1.Service) []ComponentObjects {
+	objects := make(map[string]*ComponentObjects)
+	get := func(name string) *ComponentObjects {
+		if _, ok := objects[name]; !ok {
+			objects[name] = &ComponentObjects{Name: name}
+		}
+		return objects[name]
+	}
+	for i := range yurtAppSets {
+		if template := yurtAppSets[i].Spec.WorkloadTemplate.DeploymentTemplate; template != nil {
+			get(yurtAppSets[i].Name).PodSpec = &template.Spec.Template.Spec
+		}
+	}
+	for i := range yurtAppDaemons {
+		if template := yurtAppDaemons[i].Spec.WorkloadTemplate.DeploymentTemplate; template != nil {
+			get(yurtAppDaemons[i].Name).PodSpec = &template.Spec.Template.Spec
+		}
+	}
+	for i := range services {
+		get(services[i].Name).Service = &services[i].Spec
+	}
+
+	list := make([]ComponentObjects, 0, len(objects))
+	for _, obj := range objects {
+		list = append(list, *obj)
+	}
+	return list
+}
+
+// ComputeDiff compares the live objects of components and configmaps with the desired ones. The fields populated
+// by the server, e.g. the cluster ip and node ports of services and the defaulted probes, are not compared, so
+// only the changes made by the desired objects are reported.
+func ComputeDiff(liveComponents, desiredComponents []ComponentObjects, liveConfigMaps, desiredConfigMaps []corev1.ConfigMap) *Diff {
+	return &Diff{
+		Components: DiffComponents(liveComponents, desiredComponents),
+		ConfigMaps: DiffConfigMaps(liveConfigMaps, desiredConfigMaps),
+	}
+}
+
+// DiffComponents compares the components by name, the components without any change are left out.
+func DiffComponents(live, desired []ComponentObjects) []ComponentDiff {
+	liveByName := make(map[string]ComponentObjects, len(live))
+	for _, obj := range live {
+		liveByName[obj.Name] = obj
+	}
+	desiredByName := make(map[string]ComponentObjects, len(desired))
+	for _, obj := range desired {
+		desiredByName[obj.Name] = obj
+	}
+
+	var diffs []ComponentDiff
+	for _, name := range sortedUnion(liveByName, desiredByName) {
+		liveObj, isLive := liveByName[name]
+		desiredObj, isDesired := desiredByName[name]
+		switch {
+		case !isLive:
+			diffs = append(diffs, ComponentDiff{Name: name, Added: true})
+		case !isDesired:
+			diffs = append(diffs, ComponentDiff{Name: name, Removed: true})
+		default:
+			if diff := diffComponent(&liveObj, &desiredObj); diff != nil {
+				diffs = append(diffs, *diff)
+			}
+		}
+	}
+	return diffs
+}
+
+func diffComponent(live, desired *ComponentObjects) *ComponentDiff {
+	diff := &ComponentDiff{Name: desired.Name}
+	if live.PodSpec != nil || desired.PodSpec != nil {
+		livePod, desiredPod := podSpecOrEmpty(live.PodSpec), podSpecOrEmpty(desired.PodSpec)
+		diff.Images = diffImages(livePod, desiredPod)
+		diff.EnvAdded, diff.EnvRemoved, diff.EnvChanged = diffStrings(envVars(livePod), envVars(desiredPod))
+	}
+	if live.Service != nil || desired.Service != nil {
+		diff.PortsAdded, diff.PortsRemoved = diffPorts(servicePorts(live.Service), servicePorts(desired.Service))
+	}
+	if len(diff.Images) == 0 && len(diff.EnvAdded) == 0 && len(diff.EnvRemoved) == 0 && len(diff.EnvChanged) == 0 &&
+		len(diff.PortsAdded) == 0 && len(diff.PortsRemoved) == 0 {
+		return nil
+	}
+	return diff
+}
+
+func podSpecOrEmpty(podSpec *corev1.PodSpec) *corev1.PodSpec {
+	if podSpec == nil {
+		return &corev1.PodSpec{}
+	}
+	return podSpec
+}
+
+// diffImages compares the images of init containers and containers by name.
+func diffImages(live, desired *corev1.PodSpec) []ImageChange {
+	images := func(podSpec *corev1.PodSpec) map[string]string {
+		m := make(map[string]string)
+		for _, c := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+			m[c.Name] = c.Image
+		}
+		return m
+	}
+	liveImages, desiredImages := images(live), images(desired)
+	var changes []ImageChange
+	for _, name := range sortedUnion(liveImages, desiredImages) {
+		if liveImages[name] != desiredImages[name] {
+			changes = append(changes, ImageChange{Container: name, From: liveImages[name], To: desiredImages[name]})
+		}
+	}
+	return changes
+}
+
+// envVars returns the env vars of all containers keyed by name, the ones with the same name in several containers
+// are keyed by container. The api version of field refs is defaulted like the apiserver does.
+func envVars(podSpec *corev1.PodSpec) map[string]corev1.EnvVar {
+	vars := make(map[string]corev1.EnvVar)
+	seen := make(map[string]int)
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			seen[env.Name]++
+		}
+	}
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.APIVersion == "" {
+				env = *env.DeepCopy()
+				env.ValueFrom.FieldRef.APIVersion = "v1"
+			}
+			key := env.Name
+			if seen[env.Name] > 1 {
+				key = c.Name + "/" + env.Name
+			}
+			vars[key] = env
+		}
+	}
+	return vars
+}
+
+// servicePorts returns the ports of service keyed by name:port/protocol, the protocol and target port are
+// defaulted like the apiserver does and the node ports are ignored.
+func servicePorts(service *corev1.ServiceSpec) map[string]intstr.IntOrString {
+	ports := make(map[string]intstr.IntOrString)
+	if service == nil {
+		return ports
+	}
+	for _, port := range service.Ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		targetPort := port.TargetPort
+		if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+			targetPort = intstr.FromInt(int(port.Port))
+		}
+		ports[fmt.Sprintf("%s:%d/%s", port.Name, port.Port, protocol)] = targetPort
+	}
+	return ports
+}
+
+// diffPorts compares the ports, the port with another target port is both removed and added.
+func diffPorts(live, desired map[string]intstr.IntOrString) (added, removed []string) {
+	for _, key := range sortedUnion(live, desired) {
+		liveTarget, isLive := live[key]
+		desiredTarget, isDesired := desired[key]
+		switch {
+		case !isLive:
+			added = append(added, key)
+		case !isDesired:
+			removed = append(removed, key)
+		case liveTarget != desiredTarget:
+			added = append(added, key+"->"+desiredTarget.String())
+			removed = append(removed, key+"->"+liveTarget.String())
+		}
+	}
+	return added, removed
+}
+
+// DiffConfigMaps compares the data and binary data of configmaps by name, the configmaps without any change are
+// left out.
+func DiffConfigMaps(live, desired []corev1.ConfigMap) []ConfigMapDiff {
+	liveByName := make(map[string]*corev1.ConfigMap, len(live))
+	for i := range live {
+		liveByName[live[i].Name] = &live[i]
+	}
+	desiredByName := make(map[string]*corev1.ConfigMap, len(desired))
+	for i := range desired {
+		desiredByName[desired[i].Name] = &desired[i]
+	}
+
+	var diffs []ConfigMapDiff
+	for _, name := range sortedUnion(liveByName, desiredByName) {
+		liveConfigMap, isLive := liveByName[name]
+		desiredConfigMap, isDesired := desiredByName[name]
+		switch {
+		case !isLive:
+			diffs = append(diffs, ConfigMapDiff{Name: name, Added: true})
+		case !isDesired:
+			diffs = append(diffs, ConfigMapDiff{Name: name, Removed: true})
+		default:
+			diff := ConfigMapDiff{Name: name}
+			diff.KeysAdded, diff.KeysRemoved, diff.KeysChanged = diffStrings(configMapData(liveConfigMap), configMapData(desiredConfigMap))
+			if len(diff.KeysAdded) > 0 || len(diff.KeysRemoved) > 0 || len(diff.KeysChanged) > 0 {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+	return diffs
+}
+
+func configMapData(configmap *corev1.ConfigMap) map[string]interface{} {
+	data := make(map[string]interface{}, len(configmap.Data)+len(configmap.BinaryData))
+	for k, v := range configmap.Data {
+		data[k] = v
+	}
+	for k, v := range configmap.BinaryData {
+		data[k] = v
+	}
+	return data
+}
+
+// diffStrings compares the values of two maps by key.
+func diffStrings[V any](live, desired map[string]V) (added, removed, changed []string) {
+	for _, key := range sortedUnion(live, desired) {
+		liveValue, isLive := live[key]
+		desiredValue, isDesired := desired[key]
+		switch {
+		case !isLive:
+			added = append(added, key)
+		case !isDesired:
+			removed = append(removed, key)
+		case !reflect.DeepEqual(liveValue, desiredValue):
+			changed = append(changed, key)
+		}
+	}
+	return added, removed, changed
+}
+
+// sortedUnion returns the sorted keys of both maps.
+func sortedUnion[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsEmpty checks whether the live objects are the same as the desired ones.
+func (d *Diff) IsEmpty() bool {
+	return d == nil || (len(d.Components) == 0 && len(d.ConfigMaps) == 0)
+}
+
+// String formats the change of component in one line.
+func (d *ComponentDiff) String() string {
+	switch {
+	case d.Added:
+		return fmt.Sprintf("component %s is added", d.Name)
+	case d.Removed:
+		return fmt.Sprintf("component %s is removed", d.Name)
+	}
+	var parts []string
+	for _, image := range d.Images {
+		parts = append(parts, fmt.Sprintf("image of %s %s -> %s", image.Container, orNone(image.From), orNone(image.To)))
+	}
+	if changes := formatChanges(d.EnvAdded, d.EnvRemoved, d.EnvChanged); changes != "" {
+		parts = append(parts, "env "+changes)
+	}
+	if changes := formatChanges(d.PortsAdded, d.PortsRemoved, nil); changes != "" {
+		parts = append(parts, "ports "+changes)
+	}
+	return fmt.Sprintf("component %s: %s", d.Name, strings.Join(parts, ", "))
+}
+
+// String formats the change of configmap in one line.
+func (d *ConfigMapDiff) String() string {
+	switch {
+	case d.Added:
+		return fmt.Sprintf("configmap %s is added", d.Name)
+	case d.Removed:
+		return fmt.Sprintf("configmap %s is removed", d.Name)
+	}
+	return fmt.Sprintf("configmap %s: keys %s", d.Name, formatChanges(d.KeysAdded, d.KeysRemoved, d.KeysChanged))
+}
+
+// Summarize formats the changes one per line, at most maxChanges lines of at most maxLength bytes are returned,
+// and the number of the changes left out.
+func (d *Diff) Summarize(maxChanges, maxLength int) ([]string, int) {
+	if d.IsEmpty() {
+		return nil, 0
+	}
+	var changes []string
+	for i := range d.ConfigMaps {
+		changes = append(changes, TruncateMessage(d.ConfigMaps[i].String(), maxLength))
+	}
+	for i := range d.Components {
+		changes = append(changes, TruncateMessage(d.Components[i].String(), maxLength))
+	}
+	if len(changes) <= maxChanges {
+		return changes, 0
+	}
+	return changes[:maxChanges], len(changes) - maxChanges
+}
+
+func formatChanges(added, removed, changed []string) string {
+	var parts []string
+	for _, s := range added {
+		parts = append(parts, "+"+s)
+	}
+	for _, s := range removed {
+		parts = append(parts, "-"+s)
+	}
+	for _, s := range changed {
+		parts = append(parts, "~"+s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newTestPodSpec(image string, env ...corev1.EnvVar) *corev1.PodSpec {
+	return &corev1.PodSpec{Containers: []corev1.Container{{Name: "core-command", Image: image, Env: env}}}
+}
+
+func TestDiffComponents(t *testing.T) {
+	fieldRef := func(apiVersion string) corev1.EnvVar {
+		return corev1.EnvVar{Name: "POD_IP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: apiVersion, FieldPath: "status.podIP"}}}
+	}
+	tests := []struct {
+		name    string
+		live    []ComponentObjects
+		desired []ComponentObjects
+		expect  []ComponentDiff
+	}{
+		{
+			name:    "unchanged",
+			live:    []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0")}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0")}},
+		},
+		{
+			name:    "image changed",
+			live:    []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0")}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:3.0.0")}},
+			expect: []ComponentDiff{{Name: "edgex-core-command", Images: []ImageChange{
+				{Container: "core-command", From: "edgexfoundry/core-command:2.3.0", To: "edgexfoundry/core-command:3.0.0"},
+			}}},
+		},
+		{
+			name: "env changed",
+			live: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0",
+				corev1.EnvVar{Name: "SERVICE_HOST", Value: "edgex-core-command"}, corev1.EnvVar{Name: "LOG_LEVEL", Value: "INFO"})}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0",
+				corev1.EnvVar{Name: "SERVICE_HOST", Value: "edgex-core-command"}, corev1.EnvVar{Name: "LOG_LEVEL", Value: "DEBUG"},
+				corev1.EnvVar{Name: "EDGEX_SECURITY_SECRET_STORE", Value: "false"})}},
+			expect: []ComponentDiff{{Name: "edgex-core-command", EnvAdded: []string{"EDGEX_SECURITY_SECRET_STORE"}, EnvChanged: []string{"LOG_LEVEL"}}},
+		},
+		{
+			name:    "defaulted api version of field ref",
+			live:    []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0", fieldRef("v1"))}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0", fieldRef(""))}},
+		},
+		{
+			name: "defaulted probes",
+			live: []ComponentObjects{{Name: "edgex-core-command", PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "core-command", Image: "edgexfoundry/core-command:2.3.0",
+				LivenessProbe: &corev1.Probe{TimeoutSeconds: 1, PeriodSeconds: 10, SuccessThreshold: 1, FailureThreshold: 3},
+			}}}}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "core-command", Image: "edgexfoundry/core-command:2.3.0", LivenessProbe: &corev1.Probe{},
+			}}}}},
+		},
+		{
+			name: "server populated service fields",
+			live: []ComponentObjects{{Name: "edgex-core-command", Service: &corev1.ServiceSpec{
+				ClusterIP: "10.96.0.10",
+				Ports:     []corev1.ServicePort{{Name: "http", Port: 59882, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(59882), NodePort: 30082}},
+			}}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", Service: &corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 59882}},
+			}}},
+		},
+		{
+			name: "ports changed",
+			live: []ComponentObjects{{Name: "edgex-core-command", Service: &corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 48082}, {Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)}},
+			}}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", Service: &corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "http", Port: 59882}, {Name: "metrics", Port: 9090, TargetPort: intstr.FromString("metrics")}},
+			}}},
+			expect: []ComponentDiff{{
+				Name:         "edgex-core-command",
+				PortsAdded:   []string{"http:59882/TCP", "metrics:9090/TCP->metrics"},
+				PortsRemoved: []string{"http:48082/TCP", "metrics:9090/TCP->9090"},
+			}},
+		},
+		{
+			name:    "components added and removed",
+			live:    []ComponentObjects{{Name: "edgex-device-virtual", PodSpec: newTestPodSpec("edgexfoundry/device-virtual:2.3.0")}},
+			desired: []ComponentObjects{{Name: "edgex-core-command", PodSpec: newTestPodSpec("edgexfoundry/core-command:2.3.0")}},
+			expect:  []ComponentDiff{{Name: "edgex-core-command", Added: true}, {Name: "edgex-device-virtual", Removed: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffComponents(tt.live, tt.desired); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect diff %+v, but got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestDiffConfigMaps(t *testing.T) {
+	newConfigMap := func(name string, data map[string]string) corev1.ConfigMap {
+		return corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: data}
+	}
+	live := []corev1.ConfigMap{
+		newConfigMap("common-variables", map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "MESSAGEQUEUE_HOST": "edgex-redis", "REGISTRY_HOST": "edgex-core-consul"}),
+		newConfigMap("unchanged", map[string]string{"a": "b"}),
+		newConfigMap("removed", nil),
+	}
+	desired := []corev1.ConfigMap{
+		newConfigMap("common-variables", map[string]string{"EDGEX_SECURITY_SECRET_STORE": "false", "MESSAGEQUEUE_HOST": "edgex-mqtt-broker", "MESSAGEQUEUE_TYPE": "mqtt"}),
+		newConfigMap("unchanged", map[string]string{"a": "b"}),
+		newConfigMap("added", nil),
+	}
+	expect := []ConfigMapDiff{
+		{Name: "added", Added: true},
+		{Name: "common-variables", KeysAdded: []string{"MESSAGEQUEUE_TYPE"}, KeysRemoved: []string{"REGISTRY_HOST"}, KeysChanged: []string{"MESSAGEQUEUE_HOST"}},
+		{Name: "removed", Removed: true},
+	}
+	if got := DiffConfigMaps(live, desired); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect diff %+v, but got %+v", expect, got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	diff := &Diff{
+		ConfigMaps: []ConfigMapDiff{{Name: "common-variables", KeysAdded: []string{"MESSAGEQUEUE_TYPE"}, KeysChanged: []string{"MESSAGEQUEUE_HOST"}}},
+		Components: []ComponentDiff{
+			{Name: "edgex-core-command", Images: []ImageChange{{Container: "core-command", From: "edgexfoundry/core-command:2.3.0", To: "edgexfoundry/core-command:3.0.0"}},
+				EnvRemoved: []string{"LOG_LEVEL"}, PortsAdded: []string{"http:59882/TCP"}},
+			{Name: "edgex-device-virtual", Added: true},
+		},
+	}
+	changes, omitted := diff.Summarize(MaxPendingChanges, MaxPendingChangeLength)
+	expect := []string{
+		"configmap common-variables: keys +MESSAGEQUEUE_TYPE ~MESSAGEQUEUE_HOST",
+		"component edgex-core-command: image of core-command edgexfoundry/core-command:2.3.0 -> edgexfoundry/core-command:3.0.0, env -LOG_LEVEL, ports +http:59882/TCP",
+		"component edgex-device-virtual is added",
+	}
+	if !reflect.DeepEqual(changes, expect) || omitted != 0 {
+		t.Errorf("expect changes %q, but got %q and %d omitted", expect, changes, omitted)
+	}
+
+	var components []ComponentDiff
+	for i := 0; i < MaxPendingChanges+5; i++ {
+		components = append(components, ComponentDiff{Name: fmt.Sprintf("component-%d", i), EnvAdded: []string{strings.Repeat("A", MaxPendingChangeLength)}})
+	}
+	changes, omitted = (&Diff{Components: components}).Summarize(MaxPendingChanges, MaxPendingChangeLength)
+	if len(changes) != MaxPendingChanges || omitted != 5 {
+		t.Errorf("expect %d changes and 5 omitted, but got %d and %d", MaxPendingChanges, len(changes), omitted)
+	}
+	for _, change := range changes {
+		if len(change) > MaxPendingChangeLength {
+			t.Errorf("expect the change is at most %d bytes, but got %d", MaxPendingChangeLength, len(change))
+		}
+	}
+
+	if changes, omitted := (&Diff{}).Summarize(MaxPendingChanges, MaxPendingChangeLength); changes != nil || omitted != 0 {
+		t.Errorf("expect no changes of empty diff, but got %q and %d omitted", changes, omitted)
+	}
+}
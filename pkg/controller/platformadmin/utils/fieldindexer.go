@@ -18,7 +18,7 @@ package util
 
 import (
 	"context"
-	"sync"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -26,25 +26,86 @@ import (
 )
 
 const (
+	// IndexerPathForNodepool indexes the PlatformAdmins by their pools, a pool is claimed by one PlatformAdmin in a namespace.
 	IndexerPathForNodepool = "spec.poolName"
+	// IndexerPathForWorkloadNamespace indexes the PlatformAdmins by the namespace which their objects are generated in.
+	IndexerPathForWorkloadNamespace = "spec.workloadNamespace"
+	// IndexerPathForAdditionalComponentsRef indexes the PlatformAdmins by the configmap of their additional components.
+	IndexerPathForAdditionalComponentsRef = "spec.additionalComponentsRef.name"
+	// IndexerPathForPlatformAdmin indexes the generated objects by the namespace/name of their PlatformAdmin, which
+	// is told by the identity labels.
+	IndexerPathForPlatformAdmin = "metadata.labels.platformadmin"
 )
 
-var registerOnce sync.Once
+// PlatformAdminIndexKey returns the key of IndexerPathForPlatformAdmin of the PlatformAdmin.
+func PlatformAdminIndexKey(namespace, name string) string {
+	return namespace + "/" + name
+}
 
+// RegisterFieldIndexers registers the field indexers of platformadmin controller. It is idempotent, the index which
+// has been registered by others is treated as success, any other error is returned.
 func RegisterFieldIndexers(fi client.FieldIndexer) error {
-	var err error
-	registerOnce.Do(func() {
-		// register the fieldIndexer for device
-		if err = fi.IndexField(context.TODO(), &v1alpha2.PlatformAdmin{}, IndexerPathForNodepool, func(rawObj client.Object) []string {
-			platformAdmin, ok := rawObj.(*v1alpha2.PlatformAdmin)
-			if ok {
-				return []string{platformAdmin.Spec.PoolName}
-			}
+	// register the fieldIndexer for device
+	err := fi.IndexField(context.TODO(), &v1alpha2.PlatformAdmin{}, IndexerPathForNodepool, func(rawObj client.Object) []string {
+		platformAdmin, ok := rawObj.(*v1alpha2.PlatformAdmin)
+		if ok {
+			return PlatformAdminPools(platformAdmin)
+		}
+		return []string{}
+	})
+	if err != nil && !IsIndexerConflict(err) {
+		return err
+	}
+
+	// register the fieldIndexer for the namespace of generated objects, which defaults to the namespace of PlatformAdmin
+	err = fi.IndexField(context.TODO(), &v1alpha2.PlatformAdmin{}, IndexerPathForWorkloadNamespace, func(rawObj client.Object) []string {
+		platformAdmin, ok := rawObj.(*v1alpha2.PlatformAdmin)
+		if !ok {
 			return []string{}
-		}); err != nil {
-			return
 		}
+		if platformAdmin.Spec.WorkloadNamespace != "" {
+			return []string{platformAdmin.Spec.WorkloadNamespace}
+		}
+		return []string{platformAdmin.Namespace}
 	})
+	if err != nil && !IsIndexerConflict(err) {
+		return err
+	}
+
+	// register the fieldIndexer for the referenced configmap of additional components
+	err = fi.IndexField(context.TODO(), &v1alpha2.PlatformAdmin{}, IndexerPathForAdditionalComponentsRef, func(rawObj client.Object) []string {
+		platformAdmin, ok := rawObj.(*v1alpha2.PlatformAdmin)
+		if !ok || platformAdmin.Spec.AdditionalComponentsRef == nil {
+			return []string{}
+		}
+		return []string{platformAdmin.Spec.AdditionalComponentsRef.Name}
+	})
+	if err != nil && !IsIndexerConflict(err) {
+		return err
+	}
+	return nil
+}
+
+// RegisterGeneratedFieldIndexers registers IndexerPathForPlatformAdmin for the kinds of generated objects, the
+// objects without the identity labels are not indexed. It is idempotent as RegisterFieldIndexers.
+func RegisterGeneratedFieldIndexers(fi client.FieldIndexer, objs ...client.Object) error {
+	for _, obj := range objs {
+		err := fi.IndexField(context.TODO(), obj, IndexerPathForPlatformAdmin, func(rawObj client.Object) []string {
+			labels := rawObj.GetLabels()
+			namespace, name := labels[v1alpha2.LabelPlatformAdminNamespace], labels[v1alpha2.LabelPlatformAdminName]
+			if namespace == "" || name == "" {
+				return []string{}
+			}
+			return []string{PlatformAdminIndexKey(namespace, name)}
+		})
+		if err != nil && !IsIndexerConflict(err) {
+			return err
+		}
+	}
+	return nil
+}
 
-	return err
+// IsIndexerConflict checks whether the error is returned because the index has already been registered.
+func IsIndexerConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "indexer conflict")
 }
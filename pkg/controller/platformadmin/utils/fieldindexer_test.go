@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+type fakeFieldIndexer struct {
+	err      error
+	indexers map[string]client.IndexerFunc
+}
+
+func (f *fakeFieldIndexer) IndexField(_ context.Context, _ client.Object, field string, extractValue client.IndexerFunc) error {
+	if f.indexers != nil {
+		f.indexers[field] = extractValue
+	}
+	return f.err
+}
+
+func TestRegisterFieldIndexers(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expectError bool
+	}{
+		{name: "registered", err: nil, expectError: false},
+		{name: "registered by others", err: fmt.Errorf("indexer conflict: map[field:%s:{}]", IndexerPathForNodepool), expectError: false},
+		{name: "failed to register", err: errors.New("informer has started"), expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterFieldIndexers(&fakeFieldIndexer{err: tt.err})
+			if tt.expectError != (err != nil) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNodepoolIndexer(t *testing.T) {
+	fi := &fakeFieldIndexer{indexers: make(map[string]client.IndexerFunc)}
+	if err := RegisterFieldIndexers(fi); err != nil {
+		t.Fatalf("failed to register the field indexers, %v", err)
+	}
+	extractValue, ok := fi.indexers[IndexerPathForNodepool]
+	if !ok {
+		t.Fatalf("expect index %s is registered, but got nothing", IndexerPathForNodepool)
+	}
+
+	platformAdmin := &v1alpha2.PlatformAdmin{}
+	platformAdmin.Spec.PoolName = "hangzhou"
+	if values := extractValue(platformAdmin); !reflect.DeepEqual(values, []string{"hangzhou"}) {
+		t.Errorf("expect index values %v, but got %v", []string{"hangzhou"}, values)
+	}
+	platformAdmin.Spec.Pools = []string{"beijing"}
+	if values := extractValue(platformAdmin); !reflect.DeepEqual(values, []string{"hangzhou", "beijing"}) {
+		t.Errorf("expect index values %v, but got %v", []string{"hangzhou", "beijing"}, values)
+	}
+}
+
+func TestWorkloadNamespaceIndexer(t *testing.T) {
+	fi := &fakeFieldIndexer{indexers: make(map[string]client.IndexerFunc)}
+	if err := RegisterFieldIndexers(fi); err != nil {
+		t.Fatalf("failed to register the field indexers, %v", err)
+	}
+	extractValue, ok := fi.indexers[IndexerPathForWorkloadNamespace]
+	if !ok {
+		t.Fatalf("expect index %s is registered, but got nothing", IndexerPathForWorkloadNamespace)
+	}
+
+	tests := []struct {
+		name              string
+		workloadNamespace string
+		expect            []string
+	}{
+		{name: "namespace of PlatformAdmin", expect: []string{"default"}},
+		{name: "another namespace", workloadNamespace: "edge", expect: []string{"edge"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := &v1alpha2.PlatformAdmin{}
+			platformAdmin.Namespace = "default"
+			platformAdmin.Spec.WorkloadNamespace = tt.workloadNamespace
+			if values := extractValue(platformAdmin); !reflect.DeepEqual(values, tt.expect) {
+				t.Errorf("expect index values %v, but got %v", tt.expect, values)
+			}
+		})
+	}
+}
+
+func TestAdditionalComponentsRefIndexer(t *testing.T) {
+	fi := &fakeFieldIndexer{indexers: make(map[string]client.IndexerFunc)}
+	if err := RegisterFieldIndexers(fi); err != nil {
+		t.Fatalf("failed to register the field indexers, %v", err)
+	}
+	extractValue, ok := fi.indexers[IndexerPathForAdditionalComponentsRef]
+	if !ok {
+		t.Fatalf("expect index %s is registered, but got nothing", IndexerPathForAdditionalComponentsRef)
+	}
+
+	tests := []struct {
+		name   string
+		ref    *corev1.LocalObjectReference
+		expect []string
+	}{
+		{name: "no reference", expect: []string{}},
+		{name: "reference to configmap", ref: &corev1.LocalObjectReference{Name: "additional"}, expect: []string{"additional"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := &v1alpha2.PlatformAdmin{}
+			platformAdmin.Spec.AdditionalComponentsRef = tt.ref
+			if values := extractValue(platformAdmin); !reflect.DeepEqual(values, tt.expect) {
+				t.Errorf("expect index values %v, but got %v", tt.expect, values)
+			}
+		})
+	}
+}
+
+func TestPlatformAdminIndexer(t *testing.T) {
+	fi := &fakeFieldIndexer{indexers: make(map[string]client.IndexerFunc)}
+	if err := RegisterGeneratedFieldIndexers(fi, &corev1.Service{}); err != nil {
+		t.Fatalf("failed to register the field indexers, %v", err)
+	}
+	extractValue, ok := fi.indexers[IndexerPathForPlatformAdmin]
+	if !ok {
+		t.Fatalf("expect index %s is registered, but got nothing", IndexerPathForPlatformAdmin)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		expect []string
+	}{
+		{name: "no identity labels", expect: []string{}},
+		{name: "shared by PlatformAdmins", labels: map[string]string{v1alpha2.LabelPlatformAdminNamespace: "default"}, expect: []string{}},
+		{
+			name:   "identity labels",
+			labels: map[string]string{v1alpha2.LabelPlatformAdminNamespace: "default", v1alpha2.LabelPlatformAdminName: "edgex"},
+			expect: []string{"default/edgex"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &corev1.Service{}
+			service.Labels = tt.labels
+			if values := extractValue(service); !reflect.DeepEqual(values, tt.expect) {
+				t.Errorf("expect index values %v, but got %v", tt.expect, values)
+			}
+		})
+	}
+
+	if err := RegisterGeneratedFieldIndexers(&fakeFieldIndexer{err: errors.New("informer has started")}, &corev1.Service{}); err == nil {
+		t.Errorf("expect error of registering, but got nil")
+	}
+}
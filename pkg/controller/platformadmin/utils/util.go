@@ -17,8 +17,15 @@ limitations under the License.
 package util
 
 import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+
+	"github.com/davecgh/go-spew/spew"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
 
 	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
 )
@@ -47,17 +54,76 @@ func GetPlatformAdminCondition(status iotv1alpha2.PlatformAdminStatus, condType
 
 // SetPlatformAdminCondition updates the PlatformAdmin to include the provided condition. If the condition that
 // we are about to add already exists and has the same status, reason and message then we are not going to update.
+// An existing condition is replaced in place, so the order of conditions is stable and the status is not changed
+// only because of the order.
 func SetPlatformAdminCondition(status *iotv1alpha2.PlatformAdminStatus, condition *iotv1alpha2.PlatformAdminCondition) {
 	currentCond := GetPlatformAdminCondition(*status, condition.Type)
-	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason {
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
 		return
 	}
 
 	if currentCond != nil && currentCond.Status == condition.Status {
 		condition.LastTransitionTime = currentCond.LastTransitionTime
 	}
-	newConditions := filterOutCondition(status.Conditions, condition.Type)
-	status.Conditions = append(newConditions, *condition)
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condition.Type {
+			status.Conditions[i] = *condition
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, *condition)
+}
+
+// readySubConditions are the conditions which the Ready condition is summarized from, in the order of
+// reconciliation, so the reason of the Ready condition points to the earliest failing step.
+var readySubConditions = []iotv1alpha2.PlatformAdminConditionType{
+	iotv1alpha2.ConfigmapAvailableCondition,
+	iotv1alpha2.ComponentAvailableCondition,
+}
+
+// NewPlatformAdminReadyCondition creates the Ready condition summarized from the conditions of status.
+// The Ready condition is unknown if a condition has not been reported yet, and false with the reason
+// and message of the first condition which is false.
+func NewPlatformAdminReadyCondition(status iotv1alpha2.PlatformAdminStatus) *iotv1alpha2.PlatformAdminCondition {
+	for _, condType := range readySubConditions {
+		cond := GetPlatformAdminCondition(status, condType)
+		if cond == nil {
+			return NewPlatformAdminCondition(iotv1alpha2.ReadyCondition, corev1.ConditionUnknown, iotv1alpha2.ReadyUnknownReason,
+				fmt.Sprintf("condition %s is not reported", condType))
+		}
+		if cond.Status == corev1.ConditionTrue {
+			continue
+		}
+		reason := cond.Reason
+		if reason == "" {
+			reason = string(condType)
+		}
+		return NewPlatformAdminCondition(iotv1alpha2.ReadyCondition, cond.Status, reason, cond.Message)
+	}
+	return NewPlatformAdminCondition(iotv1alpha2.ReadyCondition, corev1.ConditionTrue, iotv1alpha2.ReadyReason, "")
+}
+
+// SetPlatformAdminReadyCondition updates the Ready condition of status, the LastTransitionTime is kept
+// as long as the status of the Ready condition is not changed.
+func SetPlatformAdminReadyCondition(status *iotv1alpha2.PlatformAdminStatus) {
+	SetPlatformAdminCondition(status, NewPlatformAdminReadyCondition(*status))
+}
+
+// IsPlatformAdminReady checks whether the components of PlatformAdmin are ready with its latest spec, a PlatformAdmin
+// which was ready with a previous generation is not ready until the controller observes the current one.
+func IsPlatformAdminReady(platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	return platformAdmin.Status.Ready && platformAdmin.Status.ObservedGeneration == platformAdmin.Generation
+}
+
+// IsSecurityMigrating checks whether the components are still deployed with another security mode than the provided one,
+// which is the spec.security of PlatformAdmin. The status of a PlatformAdmin never reconciled records no security mode.
+func IsSecurityMigrating(security bool, status *iotv1alpha2.PlatformAdminStatus) bool {
+	return status.CurrentSecurity != nil && *status.CurrentSecurity != security
+}
+
+// RemovePlatformAdminCondition removes the condition with the provided type.
+func RemovePlatformAdminCondition(status *iotv1alpha2.PlatformAdminStatus, condType iotv1alpha2.PlatformAdminConditionType) {
+	status.Conditions = filterOutCondition(status.Conditions, condType)
 }
 
 func filterOutCondition(conditions []iotv1alpha2.PlatformAdminCondition, condType iotv1alpha2.PlatformAdminConditionType) []iotv1alpha2.PlatformAdminCondition {
@@ -70,3 +136,40 @@ func filterOutCondition(conditions []iotv1alpha2.PlatformAdminCondition, condTyp
 	}
 	return newConditions
 }
+
+// ComputeTemplateHash returns a hash value calculated from the deployment template of component
+func ComputeTemplateHash(template *appsv1.DeploymentSpec) string {
+	hasher := fnv.New32a()
+	DeepHashObject(hasher, *template)
+
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+}
+
+// DeepHashObject writes specified object to hash using the spew library
+// which follows pointers and prints actual values of the nested objects
+// ensuring the hash does not change when a pointer changes.
+func DeepHashObject(hasher hash.Hash, objectToWrite interface{}) {
+	hasher.Reset()
+	printer := spew.ConfigState{
+		Indent:         " ",
+		SortKeys:       true,
+		DisableMethods: true,
+		SpewKeys:       true,
+	}
+	printer.Fprintf(hasher, "%#v", objectToWrite)
+}
+
+// PlatformAdminPools returns the nodepools of PlatformAdmin, which are PoolName followed by Pools in order. The empty
+// and duplicated ones are skipped, so a PlatformAdmin setting only PoolName has a single pool.
+func PlatformAdminPools(platformAdmin *iotv1alpha2.PlatformAdmin) []string {
+	pools := make([]string, 0, 1+len(platformAdmin.Spec.Pools))
+	seen := make(map[string]struct{}, cap(pools))
+	for _, pool := range append([]string{platformAdmin.Spec.PoolName}, platformAdmin.Spec.Pools...) {
+		if _, ok := seen[pool]; ok || pool == "" {
+			continue
+		}
+		seen[pool] = struct{}{}
+		pools = append(pools, pool)
+	}
+	return pools
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestNewPlatformAdminReadyCondition(t *testing.T) {
+	configmapAvailable := *NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, "", "")
+	componentAvailable := *NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionTrue, "", "")
+
+	tests := []struct {
+		name         string
+		conditions   []iotv1alpha2.PlatformAdminCondition
+		expectStatus corev1.ConditionStatus
+		expectReason string
+	}{
+		{
+			name:         "no condition is reported",
+			expectStatus: corev1.ConditionUnknown,
+			expectReason: iotv1alpha2.ReadyUnknownReason,
+		},
+		{
+			name:         "all conditions are true",
+			conditions:   []iotv1alpha2.PlatformAdminCondition{configmapAvailable, componentAvailable},
+			expectStatus: corev1.ConditionTrue,
+			expectReason: iotv1alpha2.ReadyReason,
+		},
+		{
+			name: "component is not available",
+			conditions: []iotv1alpha2.PlatformAdminCondition{
+				configmapAvailable,
+				*NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.DeploymentNotReadyReason, "edgex-core-command"),
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: iotv1alpha2.DeploymentNotReadyReason,
+		},
+		{
+			name: "the first failing condition is reported",
+			conditions: []iotv1alpha2.PlatformAdminCondition{
+				*NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ComponentProvisioningReason, ""),
+				*NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionFalse, iotv1alpha2.ConfigmapProvisioningReason, ""),
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: iotv1alpha2.ConfigmapProvisioningReason,
+		},
+		{
+			name: "failing condition without reason",
+			conditions: []iotv1alpha2.PlatformAdminCondition{
+				configmapAvailable,
+				*NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, "", ""),
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: string(iotv1alpha2.ComponentAvailableCondition),
+		},
+		{
+			name: "other conditions are ignored",
+			conditions: []iotv1alpha2.PlatformAdminCondition{
+				configmapAvailable,
+				componentAvailable,
+				*NewPlatformAdminCondition(iotv1alpha2.PausedCondition, corev1.ConditionTrue, iotv1alpha2.PausedReason, ""),
+			},
+			expectStatus: corev1.ConditionTrue,
+			expectReason: iotv1alpha2.ReadyReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := NewPlatformAdminReadyCondition(iotv1alpha2.PlatformAdminStatus{Conditions: tt.conditions})
+			if cond.Type != iotv1alpha2.ReadyCondition {
+				t.Errorf("expect condition type %s, but got %s", iotv1alpha2.ReadyCondition, cond.Type)
+			}
+			if cond.Status != tt.expectStatus || cond.Reason != tt.expectReason {
+				t.Errorf("expect status %s and reason %s, but got %s and %s", tt.expectStatus, tt.expectReason, cond.Status, cond.Reason)
+			}
+		})
+	}
+}
+
+func TestSetPlatformAdminReadyConditionTransitionTime(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	status := &iotv1alpha2.PlatformAdminStatus{
+		Conditions: []iotv1alpha2.PlatformAdminCondition{
+			*NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, "", ""),
+			*NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.DeploymentNotReadyReason, ""),
+			{Type: iotv1alpha2.ReadyCondition, Status: corev1.ConditionFalse, Reason: iotv1alpha2.DeploymentNotReadyReason, LastTransitionTime: past},
+		},
+	}
+
+	// nothing is changed
+	SetPlatformAdminReadyCondition(status)
+	if cond := GetPlatformAdminCondition(*status, iotv1alpha2.ReadyCondition); !cond.LastTransitionTime.Equal(&past) {
+		t.Errorf("expect transition time %v is kept, but got %v", past, cond.LastTransitionTime)
+	}
+
+	// the reason is changed while the status is not
+	SetPlatformAdminCondition(status, NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.EndpointsNotReadyReason, ""))
+	SetPlatformAdminReadyCondition(status)
+	cond := GetPlatformAdminCondition(*status, iotv1alpha2.ReadyCondition)
+	if cond.Reason != iotv1alpha2.EndpointsNotReadyReason {
+		t.Errorf("expect reason %s, but got %s", iotv1alpha2.EndpointsNotReadyReason, cond.Reason)
+	}
+	if !cond.LastTransitionTime.Equal(&past) {
+		t.Errorf("expect transition time %v is kept, but got %v", past, cond.LastTransitionTime)
+	}
+
+	// the status is changed
+	SetPlatformAdminCondition(status, NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionTrue, "", ""))
+	SetPlatformAdminReadyCondition(status)
+	cond = GetPlatformAdminCondition(*status, iotv1alpha2.ReadyCondition)
+	if cond.Status != corev1.ConditionTrue {
+		t.Errorf("expect ready condition is true, but got %s", cond.Status)
+	}
+	if !past.Before(&cond.LastTransitionTime) {
+		t.Errorf("expect transition time is updated, but got %v", cond.LastTransitionTime)
+	}
+}
+
+func TestSetPlatformAdminCondition(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	status := &iotv1alpha2.PlatformAdminStatus{
+		Conditions: []iotv1alpha2.PlatformAdminCondition{
+			{Type: iotv1alpha2.ConfigmapAvailableCondition, Status: corev1.ConditionTrue, LastTransitionTime: past},
+			{Type: iotv1alpha2.ComponentAvailableCondition, Status: corev1.ConditionFalse, Reason: iotv1alpha2.DeploymentNotReadyReason, LastTransitionTime: past},
+			{Type: iotv1alpha2.ReadyCondition, Status: corev1.ConditionFalse, Reason: iotv1alpha2.DeploymentNotReadyReason, LastTransitionTime: past},
+		},
+	}
+
+	// an equal condition changes nothing
+	expect := status.DeepCopy()
+	SetPlatformAdminCondition(status, NewPlatformAdminCondition(iotv1alpha2.ConfigmapAvailableCondition, corev1.ConditionTrue, "", ""))
+	if !reflect.DeepEqual(status, expect) {
+		t.Errorf("expect status %v, but got %v", expect, status)
+	}
+
+	// a changed condition is replaced in place
+	SetPlatformAdminCondition(status, NewPlatformAdminCondition(iotv1alpha2.ComponentAvailableCondition, corev1.ConditionFalse, iotv1alpha2.EndpointsNotReadyReason, ""))
+	expect.Conditions[1].Reason = iotv1alpha2.EndpointsNotReadyReason
+	if !reflect.DeepEqual(status, expect) {
+		t.Errorf("expect status %v, but got %v", expect, status)
+	}
+
+	// a new condition is appended
+	SetPlatformAdminCondition(status, NewPlatformAdminCondition(iotv1alpha2.PausedCondition, corev1.ConditionTrue, iotv1alpha2.PausedReason, ""))
+	if len(status.Conditions) != 4 || status.Conditions[3].Type != iotv1alpha2.PausedCondition {
+		t.Errorf("expect paused condition is appended, but got %v", status.Conditions)
+	}
+}
+
+func TestIsPlatformAdminReady(t *testing.T) {
+	tests := []struct {
+		name               string
+		ready              bool
+		generation         int64
+		observedGeneration int64
+		expect             bool
+	}{
+		{name: "ready with the latest generation", ready: true, generation: 2, observedGeneration: 2, expect: true},
+		{name: "ready with a previous generation", ready: true, generation: 3, observedGeneration: 2},
+		{name: "not ready", generation: 2, observedGeneration: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := &iotv1alpha2.PlatformAdmin{}
+			platformAdmin.Generation = tt.generation
+			platformAdmin.Status.Ready = tt.ready
+			platformAdmin.Status.ObservedGeneration = tt.observedGeneration
+			if ready := IsPlatformAdminReady(platformAdmin); ready != tt.expect {
+				t.Errorf("expect ready %v, but got %v", tt.expect, ready)
+			}
+		})
+	}
+}
+
+func TestIsSecurityMigrating(t *testing.T) {
+	secure, insecure := true, false
+	tests := []struct {
+		name            string
+		security        bool
+		currentSecurity *bool
+		expect          bool
+	}{
+		{name: "never reconciled", security: true},
+		{name: "deployed with the same mode", security: true, currentSecurity: &secure},
+		{name: "deployed with the previous mode", security: true, currentSecurity: &insecure, expect: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &iotv1alpha2.PlatformAdminStatus{CurrentSecurity: tt.currentSecurity}
+			if migrating := IsSecurityMigrating(tt.security, status); migrating != tt.expect {
+				t.Errorf("expect migrating %v, but got %v", tt.expect, migrating)
+			}
+		})
+	}
+}
+
+func TestPlatformAdminPools(t *testing.T) {
+	tests := []struct {
+		name     string
+		poolName string
+		pools    []string
+		expect   []string
+	}{
+		{
+			name:     "pool name only",
+			poolName: "hangzhou",
+			expect:   []string{"hangzhou"},
+		},
+		{
+			name:     "pool name and pools",
+			poolName: "hangzhou",
+			pools:    []string{"beijing", "shanghai"},
+			expect:   []string{"hangzhou", "beijing", "shanghai"},
+		},
+		{
+			name:     "duplicated pools",
+			poolName: "hangzhou",
+			pools:    []string{"beijing", "hangzhou", "beijing"},
+			expect:   []string{"hangzhou", "beijing"},
+		},
+		{
+			name:   "pools only",
+			pools:  []string{"beijing", ""},
+			expect: []string{"beijing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := &iotv1alpha2.PlatformAdmin{Spec: iotv1alpha2.PlatformAdminSpec{PoolName: tt.poolName, Pools: tt.pools}}
+			if got := PlatformAdminPools(platformAdmin); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect pools %v, but got %v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+// MaxConditionMessageLength bounds the message copied from the workloads into the condition of PlatformAdmin.
+const MaxConditionMessageLength = 1024
+
+// WorkloadCondition is a condition of the workloads of a component, collected from the yurtappset
+// and the deployments of its pools.
+type WorkloadCondition struct {
+	// Source is the kind and name of the object reporting the condition, e.g. Deployment/edgex-core-command-hangzhou-x8k2p
+	Source  string
+	Type    string
+	Status  corev1.ConditionStatus
+	Reason  string
+	Message string
+}
+
+// YurtAppSetConditions converts the conditions of yurtappset.
+func YurtAppSetConditions(yas *appsv1alpha1.YurtAppSet) []WorkloadCondition {
+	conditions := make([]WorkloadCondition, 0, len(yas.Status.Conditions))
+	for _, c := range yas.Status.Conditions {
+		conditions = append(conditions, WorkloadCondition{
+			Source:  "YurtAppSet/" + yas.Name,
+			Type:    string(c.Type),
+			Status:  c.Status,
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+	return conditions
+}
+
+// DeploymentConditions converts the conditions of deployment.
+func DeploymentConditions(deployment *appsv1.Deployment) []WorkloadCondition {
+	conditions := make([]WorkloadCondition, 0, len(deployment.Status.Conditions))
+	for _, c := range deployment.Status.Conditions {
+		conditions = append(conditions, WorkloadCondition{
+			Source:  "Deployment/" + deployment.Name,
+			Type:    string(c.Type),
+			Status:  c.Status,
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+	return conditions
+}
+
+// severity ranks how well the condition explains an unready workload, 0 means the condition is healthy.
+// The failures to create the workloads and pods go first, since the others are usually caused by them.
+func (c *WorkloadCondition) severity() int {
+	switch {
+	case c.Type == string(appsv1alpha1.PoolProvisioned) && c.Status == corev1.ConditionFalse:
+		return 5
+	case c.Type == string(appsv1.DeploymentReplicaFailure) && c.Status == corev1.ConditionTrue:
+		return 4
+	case c.Type == string(appsv1.DeploymentProgressing) && c.Status == corev1.ConditionFalse:
+		return 3
+	case c.Type == string(appsv1alpha1.PoolFailure) && c.Status == corev1.ConditionTrue:
+		return 2
+	case c.Type == string(appsv1.DeploymentAvailable) && c.Status == corev1.ConditionFalse:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MostRelevantCondition picks the condition which explains best why the workloads are not ready,
+// nil is returned if all conditions are healthy. The ties are broken by source, type and message,
+// so the same conditions always give the same result.
+func MostRelevantCondition(conditions []WorkloadCondition) *WorkloadCondition {
+	var candidates []WorkloadCondition
+	for i := range conditions {
+		if conditions[i].severity() > 0 {
+			candidates = append(candidates, conditions[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if a.severity() != b.severity() {
+			return a.severity() > b.severity()
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Message < b.Message
+	})
+	return &candidates[0]
+}
+
+// String formats the condition for the message of PlatformAdmin condition.
+func (c *WorkloadCondition) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Source, c.Reason, c.Message)
+}
+
+// TruncateMessage truncates the message to at most maxLength bytes, and marks that it is truncated.
+func TruncateMessage(message string, maxLength int) string {
+	const suffix = "..."
+	if len(message) <= maxLength {
+		return message
+	}
+	if maxLength <= len(suffix) {
+		return message[:maxLength]
+	}
+	return message[:maxLength-len(suffix)] + suffix
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+)
+
+func TestMostRelevantCondition(t *testing.T) {
+	newDeployment := func(name string, conditions ...appsv1.DeploymentCondition) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     appsv1.DeploymentStatus{Conditions: conditions},
+		}
+	}
+	progressing := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "ReplicaSetUpdated", Message: "ReplicaSet is progressing"}
+	unavailable := appsv1.DeploymentCondition{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable", Message: "Deployment does not have minimum availability."}
+	replicaFailure := appsv1.DeploymentCondition{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", Message: "pods is forbidden: exceeded quota"}
+	deadlineExceeded := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded", Message: "ReplicaSet has timed out progressing."}
+
+	tests := []struct {
+		name          string
+		yas           *appsv1alpha1.YurtAppSet
+		deployments   []*appsv1.Deployment
+		expectSource  string
+		expectMessage string
+	}{
+		{
+			name: "healthy",
+			yas: &appsv1alpha1.YurtAppSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex-core-command"},
+				Status: appsv1alpha1.YurtAppSetStatus{Conditions: []appsv1alpha1.YurtAppSetCondition{
+					{Type: appsv1alpha1.PoolProvisioned, Status: corev1.ConditionTrue},
+				}},
+			},
+			deployments: []*appsv1.Deployment{
+				newDeployment("edgex-core-command-hangzhou", progressing, appsv1.DeploymentCondition{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}),
+			},
+		},
+		{
+			name:          "progressing",
+			yas:           &appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Name: "edgex-core-command"}},
+			deployments:   []*appsv1.Deployment{newDeployment("edgex-core-command-hangzhou", progressing, unavailable)},
+			expectSource:  "Deployment/edgex-core-command-hangzhou",
+			expectMessage: unavailable.Message,
+		},
+		{
+			name: "replica failure",
+			yas: &appsv1alpha1.YurtAppSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex-core-command"},
+				Status: appsv1alpha1.YurtAppSetStatus{Conditions: []appsv1alpha1.YurtAppSetCondition{
+					{Type: appsv1alpha1.PoolFailure, Status: corev1.ConditionTrue, Reason: "Error", Message: "pool hangzhou failed"},
+				}},
+			},
+			deployments: []*appsv1.Deployment{
+				newDeployment("edgex-core-command-hangzhou", deadlineExceeded, unavailable),
+				newDeployment("edgex-core-command-shanghai", progressing, unavailable, replicaFailure),
+			},
+			expectSource:  "Deployment/edgex-core-command-shanghai",
+			expectMessage: replicaFailure.Message,
+		},
+		{
+			name: "the workload can not be provisioned",
+			yas: &appsv1alpha1.YurtAppSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex-core-command"},
+				Status: appsv1alpha1.YurtAppSetStatus{Conditions: []appsv1alpha1.YurtAppSetCondition{
+					{Type: appsv1alpha1.PoolProvisioned, Status: corev1.ConditionFalse, Reason: "Error", Message: "admission webhook denied the request"},
+				}},
+			},
+			deployments:   []*appsv1.Deployment{newDeployment("edgex-core-command-hangzhou", replicaFailure)},
+			expectSource:  "YurtAppSet/edgex-core-command",
+			expectMessage: "admission webhook denied the request",
+		},
+		{
+			name: "ties are broken by source",
+			yas:  &appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Name: "edgex-core-command"}},
+			deployments: []*appsv1.Deployment{
+				newDeployment("edgex-core-command-shanghai", unavailable),
+				newDeployment("edgex-core-command-hangzhou", unavailable),
+			},
+			expectSource:  "Deployment/edgex-core-command-hangzhou",
+			expectMessage: unavailable.Message,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := YurtAppSetConditions(tt.yas)
+			for _, deployment := range tt.deployments {
+				conditions = append(conditions, DeploymentConditions(deployment)...)
+			}
+			cond := MostRelevantCondition(conditions)
+			if tt.expectSource == "" {
+				if cond != nil {
+					t.Errorf("expect no condition, but got %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Source != tt.expectSource || cond.Message != tt.expectMessage {
+				t.Errorf("expect condition of %s with message %q, but got %v", tt.expectSource, tt.expectMessage, cond)
+			}
+		})
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		maxLength int
+		expect    string
+	}{
+		{name: "short message", message: "image pull backoff", maxLength: 1024, expect: "image pull backoff"},
+		{name: "exact length", message: "abcdef", maxLength: 6, expect: "abcdef"},
+		{name: "long message", message: "abcdefgh", maxLength: 6, expect: "abc..."},
+		{name: "tiny length", message: "abcdefgh", maxLength: 2, expect: "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateMessage(tt.message, tt.maxLength); got != tt.expect {
+				t.Errorf("expect %q, but got %q", tt.expect, got)
+			}
+		})
+	}
+
+	if got := TruncateMessage(strings.Repeat("x", 2048), MaxConditionMessageLength); len(got) != MaxConditionMessageLength {
+		t.Errorf("expect message is truncated to %d, but got %d", MaxConditionMessageLength, len(got))
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+// workloadNamespace returns the namespace which the objects of PlatformAdmin are generated in.
+func workloadNamespace(platformAdmin *iotv1alpha2.PlatformAdmin) string {
+	if platformAdmin.Spec.WorkloadNamespace != "" {
+		return platformAdmin.Spec.WorkloadNamespace
+	}
+	return platformAdmin.Namespace
+}
+
+// isCrossNamespace checks whether the objects of PlatformAdmin are generated in another namespace,
+// they are tracked by the LabelPlatformAdmin label instead of owner references in that case.
+func isCrossNamespace(platformAdmin *iotv1alpha2.PlatformAdmin) bool {
+	return workloadNamespace(platformAdmin) != platformAdmin.Namespace
+}
+
+// platformAdminLabelValue returns the value of LabelPlatformAdmin, the namespace never contains dots,
+// so the value can be split into namespace and name at the first dot.
+func platformAdminLabelValue(platformAdmin *iotv1alpha2.PlatformAdmin) string {
+	return platformAdmin.Namespace + "." + platformAdmin.Name
+}
+
+// platformAdminFromLabel returns the PlatformAdmin which the object is generated for across namespaces.
+func platformAdminFromLabel(labels map[string]string) (types.NamespacedName, bool) {
+	value, ok := labels[iotv1alpha2.LabelPlatformAdmin]
+	if !ok {
+		return types.NamespacedName{}, false
+	}
+	parts := strings.SplitN(value, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
+
+// setOwner records PlatformAdmin as an owner of the object.
+func (r *ReconcilePlatformAdmin) setOwner(platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
+	if isCrossNamespace(platformAdmin) {
+		setPlatformAdminLabel(platformAdmin, obj)
+		return nil
+	}
+	return controllerutil.SetOwnerReference(platformAdmin, obj, r.Scheme())
+}
+
+// setController records PlatformAdmin as the controller of the object.
+func (r *ReconcilePlatformAdmin) setController(platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) error {
+	if isCrossNamespace(platformAdmin) {
+		setPlatformAdminLabel(platformAdmin, obj)
+		return nil
+	}
+	return controllerutil.SetControllerReference(platformAdmin, obj, r.Scheme())
+}
+
+func setPlatformAdminLabel(platformAdmin *iotv1alpha2.PlatformAdmin, obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[iotv1alpha2.LabelPlatformAdmin] = platformAdminLabelValue(platformAdmin)
+	obj.SetLabels(labels)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platformadmin
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha2 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestWorkloadNamespaceLifecycle(t *testing.T) {
+	tests := []struct {
+		name              string
+		workloadNamespace string
+		crossNamespace    bool
+	}{
+		{
+			name: "same namespace",
+		},
+		{
+			name:              "workload namespace is the namespace of PlatformAdmin",
+			workloadNamespace: "management",
+		},
+		{
+			name:              "another namespace",
+			workloadNamespace: "site-1",
+			crossNamespace:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pa := newTestPlatformAdmin("management", "edgex", "hangzhou")
+			pa.UID = "uid-hangzhou"
+			pa.Spec.WorkloadNamespace = tt.workloadNamespace
+			pa.Spec.NetworkPolicy = true
+			r := newTestReconciler(newTestConfiguration(newTestComponent(testComponent, testImage)), pa)
+			reconcilePlatformAdmin(t, r, pa)
+
+			namespace := pa.Namespace
+			if tt.crossNamespace {
+				namespace = tt.workloadNamespace
+			}
+			generated := func() []client.Object {
+				return []client.Object{
+					&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "common-variable-levski"}},
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: testComponent}},
+					&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: testComponent}},
+					&appsv1alpha1.YurtAppSet{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: testComponent}},
+				}
+			}
+
+			// create: the objects are generated into the workload namespace, and tracked by label across namespaces
+			for _, obj := range generated() {
+				if err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+					t.Fatalf("failed to get %T %s, %v", obj, obj.GetName(), err)
+				}
+				label, labeled := obj.GetLabels()[iotv1alpha2.LabelPlatformAdmin]
+				if tt.crossNamespace {
+					if label != "management.edgex" || len(obj.GetOwnerReferences()) != 0 {
+						t.Errorf("expect %T %s is tracked by label, but got labels %v and owners %v", obj, obj.GetName(), obj.GetLabels(), obj.GetOwnerReferences())
+					}
+					continue
+				}
+				if labeled || !isOwnedBy(obj, pa) {
+					t.Errorf("expect %T %s is tracked by owner reference, but got labels %v and owners %v", obj, obj.GetName(), obj.GetLabels(), obj.GetOwnerReferences())
+				}
+			}
+			if tt.crossNamespace {
+				err := r.Get(context.TODO(), types.NamespacedName{Namespace: pa.Namespace, Name: testComponent}, &appsv1alpha1.YurtAppSet{})
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expect no yurtappset in the namespace of PlatformAdmin, but got %v", err)
+				}
+			}
+
+			// reconcile again: the generated objects are recognized as owned, so nothing conflicts
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); err != nil {
+				t.Fatalf("failed to get PlatformAdmin, %v", err)
+			}
+			if cond := getCondition(pa, iotv1alpha2.ComponentConflictCondition); cond == nil || cond.Status != corev1.ConditionFalse {
+				t.Errorf("expect no conflict, but got %v", cond)
+			}
+
+			// delete: the objects across namespaces are deleted explicitly, the others are left to the garbage collector
+			if err := r.Delete(context.TODO(), pa); err != nil {
+				t.Fatalf("failed to delete PlatformAdmin, %v", err)
+			}
+			reconcilePlatformAdmin(t, r, pa)
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(pa), pa); !apierrors.IsNotFound(err) {
+				t.Errorf("expect PlatformAdmin is deleted, but got %v", err)
+			}
+			expectDeleted := sets.NewString("common-variable-levski/*v1.ConfigMap", testComponent+"/*v1.Service")
+			if tt.crossNamespace {
+				expectDeleted.Insert(testComponent+"/*v1.NetworkPolicy", testComponent+"/*v1alpha1.YurtAppSet")
+			}
+			for _, obj := range generated() {
+				key := obj.GetName() + "/" + reflect.TypeOf(obj).String()
+				err := r.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj)
+				if expectDeleted.Has(key) {
+					if !apierrors.IsNotFound(err) {
+						t.Errorf("expect %s is deleted, but got %v", key, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("expect %s is kept, but got %v", key, err)
+				}
+			}
+		})
+	}
+}
+
+func getCondition(pa *iotv1alpha2.PlatformAdmin, condType iotv1alpha2.PlatformAdminConditionType) *iotv1alpha2.PlatformAdminCondition {
+	for i := range pa.Status.Conditions {
+		if pa.Status.Conditions[i].Type == condType {
+			return &pa.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestWorkloadNamespaceEvents(t *testing.T) {
+	pa := newTestPlatformAdmin("management", "edgex", "hangzhou")
+	pa.Spec.WorkloadNamespace = "site-1"
+	r := newTestReconciler(newTestConfiguration(), pa)
+	r.namespaces = sets.NewString("management")
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "site-1",
+			Name:      testComponent,
+			Labels: map[string]string{
+				iotv1alpha2.LabelPlatformAdminGenerate: LabelService,
+				iotv1alpha2.LabelPlatformAdmin:         platformAdminLabelValue(pa),
+			},
+		},
+	}
+	if !r.scopePredicate().Create(event.CreateEvent{Object: svc}) {
+		t.Errorf("expect the object generated for PlatformAdmin in scope is in scope")
+	}
+	expect := []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(pa)}}
+	if requests := r.mapGeneratedToPlatformAdmins(svc); !reflect.DeepEqual(requests, expect) {
+		t.Errorf("expect requests %v, but got %v", expect, requests)
+	}
+
+	delete(svc.Labels, iotv1alpha2.LabelPlatformAdmin)
+	if r.scopePredicate().Create(event.CreateEvent{Object: svc}) {
+		t.Errorf("expect the object out of scope is dropped")
+	}
+}
@@ -18,18 +18,147 @@ limitations under the License.
 package adapter
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
+)
+
+var (
+	v1EndpointSliceGVR      = discoveryv1.SchemeGroupVersion.WithResource("endpointslices")
+	v1beta1EndpointSliceGVR = discoveryv1beta1.SchemeGroupVersion.WithResource("endpointslices")
+
+	// patchBackoff bounds the retries of a trigger patch that fails with a transient error.
+	patchBackoff = wait.Backoff{
+		Steps:    4,
+		Duration: 10 * time.Millisecond,
+		Factor:   2.0,
+		Jitter:   0.1,
+	}
+
+	// CallTimeout bounds every call of the adapters to the apiserver, e.g. a trigger patch or a list before the cache
+	// is synced, so a wedged call does not block the worker forever. No timeout is applied if it is not positive.
+	CallTimeout = 5 * time.Second
+)
+
+const (
+	// maxConcurrentPatches bounds the number of trigger patches sent in parallel for one service.
+	maxConcurrentPatches = 8
+
+	// UpdateTriggerAnnotation is patched to the endpoints and endpointslices, so yurthub receives their update
+	// events and filters them again with the latest service topology.
+	UpdateTriggerAnnotation = "openyurt.io/update-trigger"
+
+	// SkipUpdateTriggerAnnotation set to "true" on a service keeps its endpoints and endpointslices from being patched
+	// with the trigger annotation, e.g. they are consumed by third-party controllers which reload on any change.
+	SkipUpdateTriggerAnnotation = "openyurt.io/skip-update-trigger"
 )
 
+// Adapter tells the endpoints or endpointslices of services and patches their trigger annotations. The methods
+// calling the apiserver accept the context of caller, and every call is bounded by CallTimeout. Their errors wrap
+// ctx.Err() once the context is canceled or the call times out, so callers can tell them by errors.Is from the
+// failures of apiserver. The GetEnqueueKeys methods only read the cache from the event handlers.
 type Adapter interface {
+	// GetEnqueueKeysBySvc returns the service-scoped keys to enqueue when the topology of service is changed, which
+	// is at most the namespace/name key of the service itself. The objects of service are resolved by ResolveSlices
+	// when the key is processed, since the names of endpointslices churn rapidly during the rollouts of large services.
 	GetEnqueueKeysBySvc(svc *corev1.Service) []string
-	UpdateTriggerAnnotations(namespace, name string) error
+	// ResolveSlices returns the names of the objects which currently belong to the service, i.e. the endpointslices
+	// labeled with the service name, or the endpoints with the same name as the service.
+	ResolveSlices(ctx context.Context, namespace, svcName string) ([]string, error)
+	// UpdateTriggerAnnotations updates the trigger annotation of the object, transient errors are retried with
+	// backoff before they are returned. If the object does not exist, the returned error satisfies apierrors.IsNotFound
+	// and callers should regard it as nothing to update. The malformed object(e.g. its ports are not resolved) is not
+	// patched, and the returned error wraps ErrMalformedObject.
+	UpdateTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error
+	// UpdateTriggerAnnotationsWithHash sets the trigger annotation to the hash of desired state instead of a timestamp,
+	// and the patch is skipped if the object already carries the same hash, so repeated calls are idempotent.
+	UpdateTriggerAnnotationsWithHash(ctx context.Context, namespace, name, hash string, opts ...PatchOption) error
+	// UpdateTriggerAnnotationsBySvc updates the trigger annotations of all objects that belong to the service.
+	UpdateTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error
+	// CleanupTriggerAnnotations removes the trigger annotation of the object, so it returns to the state before
+	// service topology is used. The object is not patched if its cached copy does not carry the annotation.
+	// If the object does not exist, the returned error satisfies apierrors.IsNotFound.
+	CleanupTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error
+	// CleanupTriggerAnnotationsBySvc removes the trigger annotations of all objects that belong to the service.
+	CleanupTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error
+	// GetEnqueueKeysByNodePool returns the keys of objects which reference any node of the nodepool and belong to
+	// a service with nodepool topology. svcTopologyTypes is keyed by service namespace/name. The endpointslices are
+	// listed by the index of node name, see RegisterFieldIndexers.
+	GetEnqueueKeysByNodePool(ctx context.Context, svcTopologyTypes map[string]string, allNpNodes sets.String) []string
+	// GetEnqueueKeysByNode returns the keys of objects which reference the node and belong to a service with nodepool
+	// topology, e.g. when the nodepool label of node is changed. svcTopologyTypes is keyed by service namespace/name.
+	GetEnqueueKeysByNode(ctx context.Context, svcTopologyTypes map[string]string, nodeName string) []string
+}
+
+// PatchOption changes the options of the trigger patches sent by the adapters.
+type PatchOption func(*metav1.PatchOptions)
+
+// DryRun makes the apiserver validate the trigger patch(e.g. RBAC, admission and the existence of object)
+// without persisting it, so the patch can be verified against a production cluster.
+func DryRun() PatchOption {
+	return func(options *metav1.PatchOptions) {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+}
+
+// newPatchOptions applies the options to the default options of trigger patches.
+func newPatchOptions(opts []PatchOption) metav1.PatchOptions {
+	options := metav1.PatchOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// CacheSyncedFunc reports whether the cache behind the controller-runtime client has been synced. The adapters
+// list objects through kubeClient directly when the cached list is empty and the cache is not synced yet,
+// so the keys are not lost right after startup. A nil CacheSyncedFunc means the cache is always synced.
+type CacheSyncedFunc func() bool
+
+func (f CacheSyncedFunc) synced() bool {
+	return f == nil || f()
+}
+
+// NewAdapter picks the adapter matching the api served by the cluster: discovery.k8s.io/v1 EndpointSlice
+// is preferred, discovery.k8s.io/v1beta1 EndpointSlice is used for old clusters(1.18~1.20), and Endpoints
+// is the fallback when EndpointSlice is not served at all.
+func NewAdapter(kubeClient kubernetes.Interface, client client.Client, mapper meta.RESTMapper, cacheSynced CacheSyncedFunc) Adapter {
+	if gvk, err := mapper.KindFor(v1EndpointSliceGVR); err == nil {
+		klog.V(4).Infof("%s is supported, use endpointslice v1 adapter", gvk.String())
+		return NewEndpointsV1Adapter(kubeClient, client, cacheSynced)
+	}
+
+	if gvk, err := mapper.KindFor(v1beta1EndpointSliceGVR); err == nil {
+		klog.V(4).Infof("%s is supported, use endpointslice v1beta1 adapter", gvk.String())
+		return NewEndpointsV1Beta1Adapter(kubeClient, client, cacheSynced)
+	}
+
+	klog.V(4).Infof("endpointslice is not supported, use endpoints adapter")
+	return NewEndpointsAdapter(kubeClient, client)
 }
 
 func getSvcSelector(key, value string) labels.Selector {
@@ -40,17 +169,204 @@ func getSvcSelector(key, value string) labels.Selector {
 	)
 }
 
-func appendKeys(keys []string, obj interface{}) []string {
+// isUpdateTriggerSkipped checks whether the service opts out of the trigger patches.
+func isUpdateTriggerSkipped(svc *corev1.Service) bool {
+	return svc.Annotations[SkipUpdateTriggerAnnotation] == "true"
+}
+
+// skipsUpdateTrigger checks whether the service owning the object opts out of the trigger patches. The object is
+// patched as usual if the service can not be got, e.g. the endpoints of a deleted service.
+func skipsUpdateTrigger(ctx context.Context, c client.Client, kind, namespace, name, svcName string) bool {
+	if svcName == "" {
+		return false
+	}
+	svc := &corev1.Service{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: svcName}, svc); err != nil {
+		return false
+	}
+	if isUpdateTriggerSkipped(svc) {
+		klog.V(4).Infof("skip updating trigger annotation of %s %s/%s, service %s is annotated with %s", kind, namespace, name, svcName, SkipUpdateTriggerAnnotation)
+		return true
+	}
+	return false
+}
+
+// AppendKeys appends the namespace/name key of obj to keys, which is the key enqueued by the adapters.
+// The keys are returned unchanged if the key of obj can not be generated.
+func AppendKeys(keys []string, obj interface{}) []string {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
+		return keys
+	}
+	return append(keys, key)
+}
+
+// serviceKey returns the namespace/name key of the service, which is enqueued for all objects of the service.
+func serviceKey(svc *corev1.Service) []string {
+	return []string{types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}.String()}
+}
+
+// CacheKey returns the namespace/name key of obj, or an empty string if it can not be generated.
+func CacheKey(obj interface{}) string {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// GetNodes returns the nodes referenced by the endpoints of obj, which is an Endpoints or an EndpointSlice of
+// discovery.k8s.io/v1 or discovery.k8s.io/v1beta1. An empty set is returned for other objects.
+func GetNodes(obj client.Object) sets.String {
+	switch o := obj.(type) {
+	case *corev1.Endpoints:
+		return getNodesInEndpoints(o)
+	case *discoveryv1.EndpointSlice:
+		return getNodesInEpSlice(o)
+	case *discoveryv1beta1.EndpointSlice:
+		return getNodesInEpSliceV1Beta1(o)
+	}
+	return sets.NewString()
+}
+
+// isNodePoolTypeSvc checks whether the service identified by namespace/name is configured with nodepool topology.
+func isNodePoolTypeSvc(namespace, name string, svcTopologyTypes map[string]string) bool {
+	svcKey := types.NamespacedName{Namespace: namespace, Name: name}.String()
+	topologyType, ok := svcTopologyTypes[svcKey]
+	if !ok {
+		return false
+	}
+
+	// Currently, nodepool and zone types are considered the same
+	return topologyType == servicetopology.AnnotationServiceTopologyValueNodePool ||
+		topologyType == servicetopology.AnnotationServiceTopologyValueZone
+}
+
+// UpdateTriggerPatch returns the patch which sets UpdateTriggerAnnotation to the current timestamp, it is
+// both a valid strategic merge patch and a valid merge patch.
+func UpdateTriggerPatch() []byte {
+	return UpdateTriggerHashPatch(fmt.Sprintf("%d", time.Now().Unix()))
+}
+
+// UpdateTriggerHashPatch returns the patch which sets UpdateTriggerAnnotation to the hash.
+func UpdateTriggerHashPatch(hash string) []byte {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{UpdateTriggerAnnotation: hash},
+		},
+	})
+	return patch
+}
+
+// CleanupTriggerPatch returns the JSON patch which removes UpdateTriggerAnnotation.
+func CleanupTriggerPatch() []byte {
+	path := "/metadata/annotations/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(UpdateTriggerAnnotation)
+	patch, _ := json.Marshal([]map[string]string{{"op": "remove", "path": path}})
+	return patch
+}
+
+// cleanupTrigger removes the trigger annotation by patchFn with retries. The patch is skipped if the cached object
+// does not carry the annotation, since removing a missing annotation fails the JSON patch.
+func cleanupTrigger(ctx context.Context, c client.Client, kind, namespace, name string, obj client.Object, patchFn func(context.Context) error) error {
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s %s/%s is not found, %w", kind, namespace, name, err)
+		}
+		return err
+	}
+	if _, ok := obj.GetAnnotations()[UpdateTriggerAnnotation]; !ok {
 		return nil
 	}
-	keys = append(keys, key)
-	return keys
+	return patchWithRetry(ctx, kind, namespace, name, patchFn)
+}
+
+// triggerHashMatched checks whether the cached object already carries the trigger hash. The cache may lag behind the
+// apiserver, while the object changed since then is reconciled again by its own event, so the stale match is fixed
+// by the next call. The object missed by the cache is patched as usual, and its absence is told by the patch.
+func triggerHashMatched(ctx context.Context, c client.Client, key types.NamespacedName, obj client.Object, hash string) bool {
+	if err := c.Get(ctx, key, obj); err != nil {
+		return false
+	}
+	return obj.GetAnnotations()[UpdateTriggerAnnotation] == hash
+}
+
+// isTransientError checks whether the patch may succeed when it is sent again.
+func isTransientError(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err)
+}
+
+// withCallTimeout derives the context of one call to the apiserver, which is bounded by CallTimeout.
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if CallTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, CallTimeout)
 }
 
-func getUpdateTriggerPatch() []byte {
-	patch := fmt.Sprintf(`{"metadata":{"annotations": {"openyurt.io/update-trigger": "%d"}}}`, time.Now().Unix())
-	return []byte(patch)
+// contextError wraps the error of a call with ctx.Err() if the context is done, the error returned by the clients
+// on cancellation does not always wrap it.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ctx.Err(), err)
+}
+
+// patchWithRetry calls patchFn until it succeeds or fails with a non-transient error, and at most
+// patchBackoff.Steps times. Every call is bounded by CallTimeout, and nothing is patched once ctx is done.
+// The NotFound error is wrapped with the object, and still satisfies apierrors.IsNotFound.
+func patchWithRetry(ctx context.Context, kind, namespace, name string, patchFn func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s %s/%s is not patched, %w", kind, namespace, name, err)
+	}
+	err := retry.OnError(patchBackoff, func(err error) bool {
+		return ctx.Err() == nil && isTransientError(err)
+	}, func() error {
+		callCtx, cancel := withCallTimeout(ctx)
+		defer cancel()
+		return contextError(callCtx, patchFn(callCtx))
+	})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s %s/%s is not found, %w", kind, namespace, name, err)
+	}
+	return err
+}
+
+// patchConcurrently calls patchFn for every name with at most maxConcurrentPatches workers,
+// and aggregates the errors of all calls. The objects deleted in the meantime and the malformed objects are skipped.
+// The remaining names are not patched once ctx is done.
+func patchConcurrently(ctx context.Context, names []string, patchFn func(name string) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	workers := make(chan struct{}, maxConcurrentPatches)
+	for i := range names {
+		name := names[i]
+		workers <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			<-workers
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%d objects are not patched, %w", len(names)-i, err))
+			mu.Unlock()
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			if err := patchFn(name); err != nil && !apierrors.IsNotFound(err) && !errors.Is(err, ErrMalformedObject) {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("failed to update trigger annotations of %s, %w", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
 }
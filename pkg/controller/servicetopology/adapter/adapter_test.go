@@ -0,0 +1,474 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	discoveryv1client "k8s.io/client-go/kubernetes/typed/discovery/v1"
+	discoveryv1beta1client "k8s.io/client-go/kubernetes/typed/discovery/v1beta1"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewAdapter(t *testing.T) {
+	tests := []struct {
+		name        string
+		served      []schema.GroupVersion
+		expectedAdp Adapter
+	}{
+		{
+			name:        "endpointslice v1 is served",
+			served:      []schema.GroupVersion{discoveryv1.SchemeGroupVersion, discoveryv1beta1.SchemeGroupVersion},
+			expectedAdp: &endpointslicev1{},
+		},
+		{
+			name:        "only endpointslice v1beta1 is served",
+			served:      []schema.GroupVersion{discoveryv1beta1.SchemeGroupVersion},
+			expectedAdp: &endpointslicev1beta1{},
+		},
+		{
+			name:        "endpointslice is not served",
+			expectedAdp: &endpoints{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(tt.served)
+			for _, gv := range tt.served {
+				mapper.Add(gv.WithKind("EndpointSlice"), meta.RESTScopeNamespace)
+			}
+
+			adp := NewAdapter(fake.NewSimpleClientset(), fakeclient.NewClientBuilder().Build(), mapper, nil)
+			if reflect.TypeOf(adp) != reflect.TypeOf(tt.expectedAdp) {
+				t.Errorf("expect adapter %T, but got %T", tt.expectedAdp, adp)
+			}
+		})
+	}
+}
+
+// countPatchActions returns the number of patch requests sent by the fake clientset.
+func countPatchActions(kubeClient *fake.Clientset) int {
+	count := 0
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "patch" {
+			count++
+		}
+	}
+	return count
+}
+
+// patchOptionsRecorder records the options of patch requests, which are dropped from the actions of the fake
+// clientset of this client-go version.
+type patchOptionsRecorder struct {
+	*fake.Clientset
+	mu      sync.Mutex
+	options []metav1.PatchOptions
+}
+
+func newPatchOptionsRecorder(objs ...runtime.Object) *patchOptionsRecorder {
+	return &patchOptionsRecorder{Clientset: fake.NewSimpleClientset(objs...)}
+}
+
+func (r *patchOptionsRecorder) record(opts metav1.PatchOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.options = append(r.options, opts)
+}
+
+func (r *patchOptionsRecorder) CoreV1() corev1client.CoreV1Interface {
+	return &recordingCoreV1{CoreV1Interface: r.Clientset.CoreV1(), recorder: r}
+}
+
+func (r *patchOptionsRecorder) DiscoveryV1() discoveryv1client.DiscoveryV1Interface {
+	return &recordingDiscoveryV1{DiscoveryV1Interface: r.Clientset.DiscoveryV1(), recorder: r}
+}
+
+func (r *patchOptionsRecorder) DiscoveryV1beta1() discoveryv1beta1client.DiscoveryV1beta1Interface {
+	return &recordingDiscoveryV1beta1{DiscoveryV1beta1Interface: r.Clientset.DiscoveryV1beta1(), recorder: r}
+}
+
+type recordingCoreV1 struct {
+	corev1client.CoreV1Interface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingCoreV1) Endpoints(namespace string) corev1client.EndpointsInterface {
+	return &recordingEndpoints{EndpointsInterface: c.CoreV1Interface.Endpoints(namespace), recorder: c.recorder}
+}
+
+type recordingEndpoints struct {
+	corev1client.EndpointsInterface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingEndpoints) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Endpoints, error) {
+	c.recorder.record(opts)
+	return c.EndpointsInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+type recordingDiscoveryV1 struct {
+	discoveryv1client.DiscoveryV1Interface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingDiscoveryV1) EndpointSlices(namespace string) discoveryv1client.EndpointSliceInterface {
+	return &recordingEndpointSlices{EndpointSliceInterface: c.DiscoveryV1Interface.EndpointSlices(namespace), recorder: c.recorder}
+}
+
+type recordingEndpointSlices struct {
+	discoveryv1client.EndpointSliceInterface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingEndpointSlices) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*discoveryv1.EndpointSlice, error) {
+	c.recorder.record(opts)
+	return c.EndpointSliceInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+type recordingDiscoveryV1beta1 struct {
+	discoveryv1beta1client.DiscoveryV1beta1Interface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingDiscoveryV1beta1) EndpointSlices(namespace string) discoveryv1beta1client.EndpointSliceInterface {
+	return &recordingV1beta1EndpointSlices{EndpointSliceInterface: c.DiscoveryV1beta1Interface.EndpointSlices(namespace), recorder: c.recorder}
+}
+
+type recordingV1beta1EndpointSlices struct {
+	discoveryv1beta1client.EndpointSliceInterface
+	recorder *patchOptionsRecorder
+}
+
+func (c *recordingV1beta1EndpointSlices) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*discoveryv1beta1.EndpointSlice, error) {
+	c.recorder.record(opts)
+	return c.EndpointSliceInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+func TestDryRunTriggerPatches(t *testing.T) {
+	ep := getEndpoints("default", "svc1", "node1")
+	v1Slice := getEndpointSlice("default", "svc1", "node1")
+	v1beta1Slice := getV1Beta1EndpointSlice("default", "svc1", "node1")
+
+	tests := []struct {
+		name       string
+		newAdapter func(kubeClient kubernetes.Interface) Adapter
+		objs       []runtime.Object
+		cObjs      []client.Object
+		objName    string
+	}{
+		{
+			name: "endpoints",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(ep).Build())
+			},
+			objName: ep.Name,
+			objs:    []runtime.Object{ep},
+		},
+		{
+			name: "endpointslice v1",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(v1Slice).Build(), nil)
+			},
+			objName: v1Slice.Name,
+			objs:    []runtime.Object{v1Slice},
+		},
+		{
+			name: "endpointslice v1beta1",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsV1Beta1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(v1beta1Slice).Build(), nil)
+			},
+			objName: v1beta1Slice.Name,
+			objs:    []runtime.Object{v1beta1Slice},
+		},
+	}
+	dryRunAll := []string{metav1.DryRunAll}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := newPatchOptionsRecorder(tt.objs...)
+			adp := tt.newAdapter(kubeClient)
+
+			ctx, name := context.TODO(), tt.objName
+			calls := []func() error{
+				func() error { return adp.UpdateTriggerAnnotations(ctx, "default", name, DryRun()) },
+				func() error { return adp.UpdateTriggerAnnotationsWithHash(ctx, "default", name, "hash", DryRun()) },
+				func() error { return adp.UpdateTriggerAnnotationsBySvc(ctx, "default", "svc1", DryRun()) },
+			}
+			for i, call := range calls {
+				if err := call(); err != nil {
+					t.Fatalf("call %d failed, %v", i, err)
+				}
+			}
+			if len(kubeClient.options) != len(calls) {
+				t.Fatalf("expect %d patches, but got %d", len(calls), len(kubeClient.options))
+			}
+			for i, opts := range kubeClient.options {
+				if !reflect.DeepEqual(opts.DryRun, dryRunAll) {
+					t.Errorf("expect patch %d is sent with dry run %v, but got %v", i, dryRunAll, opts.DryRun)
+				}
+			}
+
+			// the patches without options are persisted
+			kubeClient.options = nil
+			if err := adp.UpdateTriggerAnnotations(context.TODO(), "default", tt.objName); err != nil {
+				t.Fatalf("failed to update trigger annotations, %v", err)
+			}
+			if len(kubeClient.options) != 1 || len(kubeClient.options[0].DryRun) != 0 {
+				t.Errorf("expect one patch without dry run, but got %v", kubeClient.options)
+			}
+		})
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	ep := getEndpoints("default", "svc1", "node1")
+	ep.Annotations = map[string]string{UpdateTriggerAnnotation: "hash"}
+	v1Slice := getEndpointSlice("default", "svc1", "node1")
+	v1Slice.Annotations = map[string]string{UpdateTriggerAnnotation: "hash"}
+	v1beta1Slice := getV1Beta1EndpointSlice("default", "svc1", "node1")
+	v1beta1Slice.Annotations = map[string]string{UpdateTriggerAnnotation: "hash"}
+
+	tests := []struct {
+		name       string
+		newAdapter func(kubeClient kubernetes.Interface) Adapter
+		obj        client.Object
+	}{
+		{
+			name: "endpoints",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(ep).Build())
+			},
+			obj: ep,
+		},
+		{
+			name: "endpointslice v1",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(v1Slice).Build(), nil)
+			},
+			obj: v1Slice,
+		},
+		{
+			name: "endpointslice v1beta1",
+			newAdapter: func(kubeClient kubernetes.Interface) Adapter {
+				return NewEndpointsV1Beta1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(v1beta1Slice).Build(), nil)
+			},
+			obj: v1beta1Slice,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(tt.obj)
+			adp := tt.newAdapter(kubeClient)
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			name := tt.obj.GetName()
+			calls := map[string]func() error{
+				"UpdateTriggerAnnotations":         func() error { return adp.UpdateTriggerAnnotations(ctx, "default", name) },
+				"UpdateTriggerAnnotationsWithHash": func() error { return adp.UpdateTriggerAnnotationsWithHash(ctx, "default", name, "hash2") },
+				"UpdateTriggerAnnotationsBySvc":    func() error { return adp.UpdateTriggerAnnotationsBySvc(ctx, "default", "svc1") },
+				"CleanupTriggerAnnotations":        func() error { return adp.CleanupTriggerAnnotations(ctx, "default", name) },
+				"CleanupTriggerAnnotationsBySvc":   func() error { return adp.CleanupTriggerAnnotationsBySvc(ctx, "default", "svc1") },
+			}
+			for method, call := range calls {
+				if err := call(); !errors.Is(err, context.Canceled) {
+					t.Errorf("expect %s fails with %v, but got %v", method, context.Canceled, err)
+				}
+			}
+			if patches := countPatchActions(kubeClient); patches != 0 {
+				t.Errorf("expect no patch is attempted with canceled context, but got %d", patches)
+			}
+		})
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { CallTimeout = timeout }(CallTimeout)
+	CallTimeout = 10 * time.Millisecond
+
+	ep := getEndpoints("default", "svc1", "node1")
+	kubeClient := fake.NewSimpleClientset(ep)
+	// the fake clientset does not watch the context, the wedged call returns after the timeout
+	kubeClient.PrependReactor("patch", "endpoints", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(5 * CallTimeout)
+		return true, nil, apierrors.NewTimeoutError("request timeout", 1)
+	})
+	adp := NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(ep).Build())
+
+	err := adp.UpdateTriggerAnnotations(context.TODO(), ep.Namespace, ep.Name)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect error %v, but got %v", context.DeadlineExceeded, err)
+	}
+	// the call timed out is not retried
+	if patches := countPatchActions(kubeClient); patches != 1 {
+		t.Errorf("expect 1 patch, but got %d", patches)
+	}
+}
+
+// failPatches makes the first failures patch requests of resource fail with err, and failures < 0 means
+// all patch requests fail.
+func failPatches(kubeClient *fake.Clientset, resource string, failures int, err error) {
+	kubeClient.PrependReactor("patch", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
+		}
+		failures--
+		return true, nil, err
+	})
+}
+
+func TestAppendKeys(t *testing.T) {
+	ep := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"}}
+	tests := []struct {
+		name   string
+		keys   []string
+		obj    interface{}
+		expect []string
+	}{
+		{
+			name:   "append to empty keys",
+			obj:    ep,
+			expect: []string{"default/svc1"},
+		},
+		{
+			name:   "append to existing keys",
+			keys:   []string{"default/svc0"},
+			obj:    ep,
+			expect: []string{"default/svc0", "default/svc1"},
+		},
+		{
+			name:   "cluster scoped object",
+			obj:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+			expect: []string{"node1"},
+		},
+		{
+			name:   "object without key keeps the existing keys",
+			keys:   []string{"default/svc0"},
+			obj:    "not an object",
+			expect: []string{"default/svc0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if keys := AppendKeys(tt.keys, tt.obj); !reflect.DeepEqual(keys, tt.expect) {
+				t.Errorf("expect keys %v, but got %v", tt.expect, keys)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	if key := CacheKey(&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"}}); key != "default/svc1" {
+		t.Errorf("expect key default/svc1, but got %s", key)
+	}
+	if key := CacheKey("not an object"); key != "" {
+		t.Errorf("expect empty key, but got %s", key)
+	}
+}
+
+func TestUpdateTriggerPatch(t *testing.T) {
+	ep := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "svc1",
+		Annotations: map[string]string{"foo": "bar", UpdateTriggerAnnotation: "old"},
+	}}
+	original, _ := json.Marshal(ep)
+	applyPatch := func(patch []byte) *corev1.Endpoints {
+		t.Helper()
+		patched, err := strategicpatch.StrategicMergePatch(original, patch, &corev1.Endpoints{})
+		if err != nil {
+			t.Fatalf("failed to apply patch %s, %v", patch, err)
+		}
+		result := &corev1.Endpoints{}
+		if err := json.Unmarshal(patched, result); err != nil {
+			t.Fatalf("failed to unmarshal patched object, %v", err)
+		}
+		return result
+	}
+
+	// the hash is escaped, and the other annotations are kept
+	for _, hash := range []string{"hash1", `a"b\c`} {
+		patched := applyPatch(UpdateTriggerHashPatch(hash))
+		if patched.Annotations[UpdateTriggerAnnotation] != hash || patched.Annotations["foo"] != "bar" {
+			t.Errorf("expect trigger annotation %s with other annotations kept, but got %v", hash, patched.Annotations)
+		}
+	}
+
+	before := time.Now().Unix()
+	timestamp, err := strconv.ParseInt(applyPatch(UpdateTriggerPatch()).Annotations[UpdateTriggerAnnotation], 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Errorf("expect trigger annotation is the current timestamp, but got %d, %v", timestamp, err)
+	}
+}
+
+func TestGetNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		obj         client.Object
+		expectNodes []string
+	}{
+		{
+			name:        "endpoints",
+			obj:         getEndpoints("default", "svc1", "node1", "node2"),
+			expectNodes: []string{"node1", "node2"},
+		},
+		{
+			name:        "endpointslice v1",
+			obj:         getEndpointSlice("default", "svc1", "node2", "node1", "node2"),
+			expectNodes: []string{"node1", "node2"},
+		},
+		{
+			name:        "endpointslice v1beta1",
+			obj:         getV1Beta1EndpointSlice("default", "svc1", "node1"),
+			expectNodes: []string{"node1"},
+		},
+		{
+			name:        "other object",
+			obj:         getService("default", "svc1", false),
+			expectNodes: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if nodes := GetNodes(tt.obj).List(); !reflect.DeepEqual(nodes, tt.expectNodes) {
+				t.Errorf("expect nodes %v, but got %v", tt.expectNodes, nodes)
+			}
+		})
+	}
+}
@@ -21,9 +21,12 @@ import (
 	"context"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -41,11 +44,136 @@ type endpoints struct {
 
 func (s *endpoints) GetEnqueueKeysBySvc(svc *corev1.Service) []string {
 	var keys []string
-	return appendKeys(keys, svc)
+	// ExternalName service has no endpoints
+	if svc.Spec.Type == corev1.ServiceTypeExternalName || isUpdateTriggerSkipped(svc) {
+		return keys
+	}
+
+	// The endpoints has the same name as the service, but it may not exist(e.g. headless service without selector)
+	ep := &corev1.Endpoints{}
+	if err := s.client.Get(context.TODO(), types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}, ep); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Error getting endpoints %s/%s: %v", svc.Namespace, svc.Name, err)
+		}
+		return keys
+	}
+	return AppendKeys(keys, ep)
+}
+
+// ResolveSlices returns the endpoints with the same name as the service if it exists.
+func (s *endpoints) ResolveSlices(ctx context.Context, namespace, svcName string) ([]string, error) {
+	if err := s.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: svcName}, &corev1.Endpoints{}); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return []string{svcName}, nil
+}
+
+func (s *endpoints) UpdateTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerPatch(), opts)
+}
+
+func (s *endpoints) UpdateTriggerAnnotationsWithHash(ctx context.Context, namespace, name, hash string, opts ...PatchOption) error {
+	if triggerHashMatched(ctx, s.client, types.NamespacedName{Namespace: namespace, Name: name}, &corev1.Endpoints{}, hash) {
+		return nil
+	}
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerHashPatch(hash), opts)
+}
+
+// patchTrigger only patches the endpoints whose ports are resolved, the downstream filters can not handle the others.
+// The endpoints of the service annotated with SkipUpdateTriggerAnnotation is not patched either.
+func (s *endpoints) patchTrigger(ctx context.Context, namespace, name string, patch []byte, opts []PatchOption) error {
+	ep := &corev1.Endpoints{}
+	get := func(ctx context.Context) error {
+		got, err := s.kubeClient.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			got.DeepCopyInto(ep)
+		}
+		return err
+	}
+	if err := checkMalformed(ctx, s.client, "endpoints", namespace, name, ep, get, func() error { return validateEndpoints(ep) }); err != nil {
+		return err
+	}
+	// The endpoints has the same name as the service
+	if skipsUpdateTrigger(ctx, s.client, "endpoints", namespace, name, name) {
+		return nil
+	}
+	return patchWithRetry(ctx, "endpoints", namespace, name, func(ctx context.Context) error {
+		_, err := s.kubeClient.CoreV1().Endpoints(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, newPatchOptions(opts))
+		return err
+	})
+}
+
+// UpdateTriggerAnnotationsBySvc only patches one object, because the endpoints has the same name as the service.
+func (s *endpoints) UpdateTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	return s.UpdateTriggerAnnotations(ctx, namespace, svcName, opts...)
 }
 
-func (s *endpoints) UpdateTriggerAnnotations(namespace, name string) error {
-	patch := getUpdateTriggerPatch()
-	_, err := s.kubeClient.CoreV1().Endpoints(namespace).Patch(context.Background(), name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
-	return err
+func (s *endpoints) CleanupTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return cleanupTrigger(ctx, s.client, "endpoints", namespace, name, &corev1.Endpoints{}, func(ctx context.Context) error {
+		_, err := s.kubeClient.CoreV1().Endpoints(namespace).Patch(ctx, name, types.JSONPatchType, CleanupTriggerPatch(), newPatchOptions(opts))
+		return err
+	})
+}
+
+// CleanupTriggerAnnotationsBySvc only patches one object, because the endpoints has the same name as the service.
+func (s *endpoints) CleanupTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	return s.CleanupTriggerAnnotations(ctx, namespace, svcName, opts...)
+}
+
+func (s *endpoints) GetEnqueueKeysByNodePool(ctx context.Context, svcTopologyTypes map[string]string, allNpNodes sets.String) []string {
+	var keys []string
+	endpointsList := &corev1.EndpointsList{}
+	if err := s.client.List(ctx, endpointsList); err != nil {
+		klog.V(4).Infof("Error listing endpoints sets: %v", err)
+		return keys
+	}
+
+	for i := range endpointsList.Items {
+		ep := &endpointsList.Items[i]
+		if !isNodePoolTypeSvc(ep.Namespace, ep.Name, svcTopologyTypes) {
+			continue
+		}
+
+		if getNodesInEndpoints(ep).HasAny(allNpNodes.UnsortedList()...) {
+			keys = AppendKeys(keys, ep)
+		}
+	}
+	return keys
+}
+
+func (s *endpoints) GetEnqueueKeysByNode(ctx context.Context, svcTopologyTypes map[string]string, nodeName string) []string {
+	var keys []string
+	endpointsList := &corev1.EndpointsList{}
+	if err := s.client.List(ctx, endpointsList); err != nil {
+		klog.V(4).Infof("Error listing endpoints sets: %v", err)
+		return keys
+	}
+
+	for i := range endpointsList.Items {
+		ep := &endpointsList.Items[i]
+		if !isNodePoolTypeSvc(ep.Namespace, ep.Name, svcTopologyTypes) {
+			continue
+		}
+		if getNodesInEndpoints(ep).Has(nodeName) {
+			keys = AppendKeys(keys, ep)
+		}
+	}
+	return keys
+}
+
+func getNodesInEndpoints(ep *corev1.Endpoints) sets.String {
+	nodes := sets.NewString()
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.NodeName != nil {
+				nodes.Insert(*addr.NodeName)
+			}
+		}
+		for _, addr := range subset.NotReadyAddresses {
+			if addr.NodeName != nil {
+				nodes.Insert(*addr.NodeName)
+			}
+		}
+	}
+	return nodes
 }
@@ -19,13 +19,17 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"sort"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/fake"
-	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -38,7 +42,7 @@ func TestEndpointAdapterUpdateTriggerAnnotations(t *testing.T) {
 	c := fakeclient.NewClientBuilder().WithObjects(ep).Build()
 
 	adapter := NewEndpointsAdapter(kubeClient, c)
-	err := adapter.UpdateTriggerAnnotations(ep.Namespace, ep.Name)
+	err := adapter.UpdateTriggerAnnotations(context.TODO(), ep.Namespace, ep.Name)
 	if err != nil {
 		t.Errorf("update endpoints trigger annotations failed")
 	}
@@ -49,29 +53,362 @@ func TestEndpointAdapterUpdateTriggerAnnotations(t *testing.T) {
 	}
 }
 
-func TestEndpointAdapterGetEnqueueKeysBySvc(t *testing.T) {
-	svc := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "svc1",
-			Namespace: "default",
-		},
+func TestEndpointAdapterUpdateTriggerAnnotationsBySvc(t *testing.T) {
+	ep := getEndpoints("default", "svc1", "node1")
+
+	kubeClient := fake.NewSimpleClientset(ep)
+	c := fakeclient.NewClientBuilder().WithObjects(ep).Build()
+
+	adapter := NewEndpointsAdapter(kubeClient, c)
+	if err := adapter.UpdateTriggerAnnotationsBySvc(context.TODO(), ep.Namespace, "svc1"); err != nil {
+		t.Fatalf("update endpoints trigger annotations failed, %v", err)
+	}
+
+	newEp, err := kubeClient.CoreV1().Endpoints(ep.Namespace).Get(context.TODO(), ep.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get endpoints, %v", err)
+	}
+	if _, ok := newEp.Annotations["openyurt.io/update-trigger"]; !ok {
+		t.Errorf("endpoints has no trigger annotation")
 	}
-	expectResult := []string{getCacheKey(svc)}
+}
 
+func TestEndpointAdapterUpdateTriggerAnnotationsWithHash(t *testing.T) {
 	ep := getEndpoints("default", "svc1", "node1")
 
 	kubeClient := fake.NewSimpleClientset(ep)
-	stopper := make(chan struct{})
-	defer close(stopper)
 	c := fakeclient.NewClientBuilder().WithObjects(ep).Build()
 	adapter := NewEndpointsAdapter(kubeClient, c)
 
-	keys := adapter.GetEnqueueKeysBySvc(svc)
+	if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), ep.Namespace, ep.Name, "hash1"); err != nil {
+		t.Fatalf("update endpoints trigger annotations failed, %v", err)
+	}
+	newEp, err := kubeClient.CoreV1().Endpoints(ep.Namespace).Get(context.TODO(), ep.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get endpoints, %v", err)
+	}
+	if newEp.Annotations[UpdateTriggerAnnotation] != "hash1" {
+		t.Errorf("expect trigger hash hash1, but got %s", newEp.Annotations[UpdateTriggerAnnotation])
+	}
+
+	// the cache observes the patched endpoints
+	cached := &corev1.Endpoints{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(ep), cached); err != nil {
+		t.Fatalf("failed to get endpoints, %v", err)
+	}
+	cached.Annotations = newEp.Annotations
+	if err := c.Update(context.TODO(), cached); err != nil {
+		t.Fatalf("failed to update endpoints, %v", err)
+	}
+
+	tests := []struct {
+		hash         string
+		expectWrites int
+	}{
+		{hash: "hash1", expectWrites: 1},
+		{hash: "hash2", expectWrites: 2},
+	}
+	for _, tt := range tests {
+		if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), ep.Namespace, ep.Name, tt.hash); err != nil {
+			t.Fatalf("update endpoints trigger annotations failed, %v", err)
+		}
+		if writes := countPatchActions(kubeClient); writes != tt.expectWrites {
+			t.Errorf("expect %d writes after updating with %s, but got %d", tt.expectWrites, tt.hash, writes)
+		}
+	}
+}
+
+func TestEndpointAdapterUpdateTriggerAnnotationsErrors(t *testing.T) {
+	obj := getEndpoints("default", "svc1", "node1")
+	conflict := apierrors.NewConflict(corev1.Resource("endpoints"), obj.Name, errors.New("object has been modified"))
+	timeout := apierrors.NewTimeoutError("request timeout", 1)
+
+	tests := []struct {
+		name           string
+		objName        string
+		failures       int
+		err            error
+		expectNotFound bool
+		expectErr      bool
+		expectPatches  int
+	}{
+		{
+			// the object is fetched before it is patched
+			name:           "object is not found",
+			objName:        "not-exist",
+			expectNotFound: true,
+			expectErr:      true,
+		},
+		{
+			name:          "conflict is retried",
+			objName:       obj.Name,
+			failures:      2,
+			err:           conflict,
+			expectPatches: 3,
+		},
+		{
+			name:          "timeout is retried",
+			objName:       obj.Name,
+			failures:      1,
+			err:           timeout,
+			expectPatches: 2,
+		},
+		{
+			name:          "retries are bounded",
+			objName:       obj.Name,
+			failures:      -1,
+			err:           conflict,
+			expectErr:     true,
+			expectPatches: patchBackoff.Steps,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(obj)
+			failPatches(kubeClient, "endpoints", tt.failures, tt.err)
+			adapter := NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(obj).Build())
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), obj.Namespace, tt.objName)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expect error %v, but got %v", tt.expectErr, err)
+			}
+			if apierrors.IsNotFound(err) != tt.expectNotFound {
+				t.Errorf("expect not found error %v, but got %v", tt.expectNotFound, err)
+			}
+			if patches := countPatchActions(kubeClient); patches != tt.expectPatches {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatches, patches)
+			}
+		})
+	}
+}
+
+func TestEndpointAdapterCleanupTriggerAnnotations(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		objName        string
+		expectNotFound bool
+		expectPatches  int
+	}{
+		{
+			name:          "trigger annotation is removed",
+			annotations:   map[string]string{"foo": "bar", UpdateTriggerAnnotation: "1690000000"},
+			objName:       "svc1",
+			expectPatches: 1,
+		},
+		{
+			name:        "object without trigger annotation is not patched",
+			annotations: map[string]string{"foo": "bar"},
+			objName:     "svc1",
+		},
+		{
+			name:           "object is not found",
+			objName:        "not-exist",
+			expectNotFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := getEndpoints("default", "svc1", "node1")
+			ep.Annotations = tt.annotations
+			kubeClient := fake.NewSimpleClientset(ep)
+			adapter := NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(ep).Build())
+
+			err := adapter.CleanupTriggerAnnotationsBySvc(context.TODO(), ep.Namespace, tt.objName)
+			if apierrors.IsNotFound(err) != tt.expectNotFound {
+				t.Fatalf("expect not found error %v, but got %v", tt.expectNotFound, err)
+			}
+			if !tt.expectNotFound && err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if patches := countPatchActions(kubeClient); patches != tt.expectPatches {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatches, patches)
+			}
+
+			newEp, err := kubeClient.CoreV1().Endpoints(ep.Namespace).Get(context.TODO(), ep.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get endpoints, %v", err)
+			}
+			if _, ok := newEp.Annotations[UpdateTriggerAnnotation]; ok {
+				t.Errorf("expect trigger annotation is removed, but got %v", newEp.Annotations)
+			}
+			if tt.annotations != nil && newEp.Annotations["foo"] != "bar" {
+				t.Errorf("expect other annotations are kept, but got %v", newEp.Annotations)
+			}
+		})
+	}
+}
+
+func TestEndpointAdapterGetEnqueueKeysBySvc(t *testing.T) {
+	ep := getEndpoints("default", "svc1", "node1")
+	tests := []struct {
+		name         string
+		svc          *corev1.Service
+		expectResult []string
+	}{
+		{
+			name: "endpoints exists",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "default"},
+			},
+			expectResult: []string{CacheKey(ep)},
+		},
+		{
+			name: "endpoints does not exist",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc2", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+			},
+		},
+		{
+			name: "externalname service",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "default"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"},
+			},
+		},
+		{
+			name: "service skips update trigger",
+			svc:  getService("default", "svc1", true),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(ep)
+			c := fakeclient.NewClientBuilder().WithObjects(ep).Build()
+			adapter := NewEndpointsAdapter(kubeClient, c)
+
+			keys := adapter.GetEnqueueKeysBySvc(tt.svc)
+			if !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys %v, but got %v", tt.expectResult, keys)
+			}
+		})
+	}
+}
+
+func TestEndpointAdapterResolveSlices(t *testing.T) {
+	ep := getEndpoints("default", "svc1", "node1")
+	tests := []struct {
+		name         string
+		svcName      string
+		expectResult []string
+	}{
+		{
+			name:         "endpoints exists",
+			svcName:      "svc1",
+			expectResult: []string{"svc1"},
+		},
+		{
+			name:    "endpoints does not exist",
+			svcName: "svc2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fakeclient.NewClientBuilder().WithObjects(ep).Build()
+			adapter := NewEndpointsAdapter(fake.NewSimpleClientset(ep), c)
+
+			names, err := adapter.ResolveSlices(context.TODO(), "default", tt.svcName)
+			if err != nil {
+				t.Fatalf("expect no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.expectResult) {
+				t.Errorf("expect endpoints %v, but got %v", tt.expectResult, names)
+			}
+		})
+	}
+}
+
+func TestEndpointAdapterGetEnqueueKeysByNodePool(t *testing.T) {
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+	}
+	ep1 := getEndpoints("default", "svc1", "node1")
+	ep2 := getEndpoints("default", "svc2", "node3")
+	nodepoolNodes := sets.NewString("node1", "node2")
+	expectResult := []string{CacheKey(ep1)}
+
+	kubeClient := fake.NewSimpleClientset(ep1, ep2)
+	c := fakeclient.NewClientBuilder().WithObjects(ep1, ep2).Build()
+	adapter := NewEndpointsAdapter(kubeClient, c)
+
+	keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), svcTopologyTypes, nodepoolNodes)
 	if !reflect.DeepEqual(keys, expectResult) {
 		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
 	}
 }
 
+func TestEndpointAdapterGetEnqueueKeysByNode(t *testing.T) {
+	ep1 := getEndpoints("default", "svc1", "node1", "node2")
+	ep2 := getEndpoints("default", "svc2", "node3")
+	// the not ready addresses reference the node as well
+	ep3 := getEndpoints("default", "svc3", "node3")
+	notReadyNode := "node2"
+	ep3.Subsets[0].NotReadyAddresses = []corev1.EndpointAddress{{NodeName: &notReadyNode}}
+	// the service without topology is not enqueued
+	ep4 := getEndpoints("default", "svc4", "node2")
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+		"default/svc3": "openyurt.io/nodepool",
+	}
+	c := fakeclient.NewClientBuilder().WithObjects(ep1, ep2, ep3, ep4).Build()
+	adapter := NewEndpointsAdapter(fake.NewSimpleClientset(), c)
+
+	expectResult := []string{CacheKey(ep1), CacheKey(ep3)}
+	keys := adapter.GetEnqueueKeysByNode(context.TODO(), svcTopologyTypes, "node2")
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, expectResult) {
+		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
+	}
+}
+
+func TestEndpointAdapterSkipUpdateTrigger(t *testing.T) {
+	tests := []struct {
+		name         string
+		skipped      bool
+		expectPatch  int
+		expectResult []string
+	}{
+		{name: "annotated service", skipped: true},
+		{name: "unannotated service", expectPatch: 2, expectResult: []string{"default/svc1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := getEndpoints("default", "svc1", "node1")
+			svc := getService("default", "svc1", tt.skipped)
+			kubeClient := fake.NewSimpleClientset(ep)
+			c := fakeclient.NewClientBuilder().WithObjects(ep, svc).Build()
+			adapter := NewEndpointsAdapter(kubeClient, c)
+
+			if keys := adapter.GetEnqueueKeysBySvc(svc); !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys %v, but got %v", tt.expectResult, keys)
+			}
+			if err := adapter.UpdateTriggerAnnotations(context.TODO(), ep.Namespace, ep.Name); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), ep.Namespace, ep.Name, "hash"); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if count := countPatchActions(kubeClient); count != tt.expectPatch {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatch, count)
+			}
+		})
+	}
+}
+
+// getService returns the service annotated with SkipUpdateTriggerAnnotation if skipped.
+func getService(ns, name string, skipped bool) *corev1.Service {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+	if skipped {
+		svc.Annotations = map[string]string{SkipUpdateTriggerAnnotation: "true"}
+	}
+	return svc
+}
+
 func getEndpoints(ns, name string, nodes ...string) *corev1.Endpoints {
 	var addresses []corev1.EndpointAddress
 	for i := range nodes {
@@ -89,8 +426,3 @@ func getEndpoints(ns, name string, nodes ...string) *corev1.Endpoints {
 		},
 	}
 }
-
-func getCacheKey(obj interface{}) string {
-	key, _ := cache.MetaNamespaceKeyFunc(obj)
-	return key
-}
@@ -19,45 +19,240 @@ package adapter
 
 import (
 	"context"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func NewEndpointsV1Adapter(kubeClient kubernetes.Interface, client client.Client) Adapter {
-	return &endpointslicev1{
-		kubeClient: kubeClient,
-		client:     client,
+// endpointSliceMirroringController is the value of managed-by label on the endpointslices mirrored from Endpoints.
+const endpointSliceMirroringController = "endpointslicemirroring-controller.k8s.io"
+
+// EndpointSliceV1Option configures the endpointslice v1 adapter.
+type EndpointSliceV1Option func(*endpointslicev1)
+
+// WithMirroredEndpointSlices makes the adapter handle the endpointslices mirrored from Endpoints as well.
+// By default they are skipped and left to the Endpoints adapter, so one service is not triggered twice,
+// this option is for clusters which only handle endpointslices.
+func WithMirroredEndpointSlices() EndpointSliceV1Option {
+	return func(s *endpointslicev1) {
+		s.handleMirrored = true
+	}
+}
+
+// NewEndpointsV1Adapter creates the endpointslice v1 adapter. The endpointslices of a service are listed from
+// the cache by the IndexerPathForServiceName index and the ones of a node by the IndexerPathForNodeName index,
+// so RegisterFieldIndexers must have been called on the cache behind client, otherwise listing them fails.
+func NewEndpointsV1Adapter(kubeClient kubernetes.Interface, client client.Client, cacheSynced CacheSyncedFunc, opts ...EndpointSliceV1Option) Adapter {
+	s := &endpointslicev1{
+		kubeClient:  kubeClient,
+		client:      client,
+		cacheSynced: cacheSynced,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 type endpointslicev1 struct {
-	kubeClient kubernetes.Interface
-	client     client.Client
+	kubeClient     kubernetes.Interface
+	client         client.Client
+	cacheSynced    CacheSyncedFunc
+	handleMirrored bool
 }
 
+// skip checks whether the endpointslice is mirrored from Endpoints and is left to the Endpoints adapter.
+func (s *endpointslicev1) skip(epSlice *discoveryv1.EndpointSlice) bool {
+	return !s.handleMirrored && epSlice.Labels[discoveryv1.LabelManagedBy] == endpointSliceMirroringController
+}
+
+// GetEnqueueKeysBySvc returns the key of service, the endpointslices are resolved by ResolveSlices when it is processed.
+// Nothing is returned for the service annotated with SkipUpdateTriggerAnnotation.
 func (s *endpointslicev1) GetEnqueueKeysBySvc(svc *corev1.Service) []string {
+	if isUpdateTriggerSkipped(svc) {
+		return nil
+	}
+	return serviceKey(svc)
+}
+
+func (s *endpointslicev1) ResolveSlices(ctx context.Context, namespace, svcName string) ([]string, error) {
+	epSlices, err := s.listEndpointSlicesBySvc(ctx, namespace, svcName)
+	if err != nil {
+		if isIndexMissing(err, IndexerPathForServiceName) {
+			klog.Errorf("Error listing endpointslices sets: %v", err)
+		}
+		return nil, err
+	}
+
+	names := make([]string, 0, len(epSlices))
+	for i := range epSlices {
+		names = append(names, epSlices[i].Name)
+	}
+	return names, nil
+}
+
+// listEndpointSlicesBySvc lists the endpointslices of the service from the cache by the service name index, and
+// falls back to list them through kubeClient if nothing is found before the cache is synced. The label selector
+// is applied as well, it only checks the indexed endpointslices. The skipped endpointslices are filtered out.
+func (s *endpointslicev1) listEndpointSlicesBySvc(ctx context.Context, namespace, svcName string) ([]discoveryv1.EndpointSlice, error) {
+	selector := getSvcSelector(discoveryv1.LabelServiceName, svcName)
+	epSliceList := &discoveryv1.EndpointSliceList{}
+	if err := s.client.List(ctx, epSliceList, client.InNamespace(namespace),
+		client.MatchingFields{IndexerPathForServiceName: svcName}, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		if isIndexMissing(err, IndexerPathForServiceName) {
+			return nil, fmt.Errorf("index %s of endpointslices is not registered, RegisterFieldIndexers should be called before the cache is started: %w", IndexerPathForServiceName, err)
+		}
+		return nil, err
+	}
+	if len(epSliceList.Items) == 0 && !s.cacheSynced.synced() {
+		klog.V(4).Infof("cache is not synced, list endpointslices of service %s/%s from apiserver", namespace, svcName)
+		callCtx, cancel := withCallTimeout(ctx)
+		defer cancel()
+		var err error
+		epSliceList, err = s.kubeClient.DiscoveryV1().EndpointSlices(namespace).List(callCtx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, contextError(callCtx, err)
+		}
+	}
+
+	epSlices := make([]discoveryv1.EndpointSlice, 0, len(epSliceList.Items))
+	for i := range epSliceList.Items {
+		if !s.skip(&epSliceList.Items[i]) {
+			epSlices = append(epSlices, epSliceList.Items[i])
+		}
+	}
+	return epSlices, nil
+}
+
+func (s *endpointslicev1) UpdateTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerPatch(), opts)
+}
+
+func (s *endpointslicev1) UpdateTriggerAnnotationsWithHash(ctx context.Context, namespace, name, hash string, opts ...PatchOption) error {
+	if triggerHashMatched(ctx, s.client, types.NamespacedName{Namespace: namespace, Name: name}, &discoveryv1.EndpointSlice{}, hash) {
+		return nil
+	}
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerHashPatch(hash), opts)
+}
+
+// patchTrigger only patches the endpointslices whose ports are resolved, the downstream filters can not handle the others.
+// The endpointslices of the service annotated with SkipUpdateTriggerAnnotation are not patched either.
+func (s *endpointslicev1) patchTrigger(ctx context.Context, namespace, name string, patch []byte, opts []PatchOption) error {
+	epSlice := &discoveryv1.EndpointSlice{}
+	get := func(ctx context.Context) error {
+		got, err := s.kubeClient.DiscoveryV1().EndpointSlices(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			got.DeepCopyInto(epSlice)
+		}
+		return err
+	}
+	if err := checkMalformed(ctx, s.client, "endpointslice", namespace, name, epSlice, get, func() error { return validateEndpointSliceV1(epSlice) }); err != nil {
+		return err
+	}
+	if skipsUpdateTrigger(ctx, s.client, "endpointslice", namespace, name, epSlice.Labels[discoveryv1.LabelServiceName]) {
+		return nil
+	}
+	return patchWithRetry(ctx, "endpointslice", namespace, name, func(ctx context.Context) error {
+		_, err := s.kubeClient.DiscoveryV1().EndpointSlices(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, newPatchOptions(opts))
+		return err
+	})
+}
+
+func (s *endpointslicev1) UpdateTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	names, err := s.ResolveSlices(ctx, namespace, svcName)
+	if err != nil {
+		return err
+	}
+	return patchConcurrently(ctx, names, func(name string) error {
+		return s.UpdateTriggerAnnotations(ctx, namespace, name, opts...)
+	})
+}
+
+func (s *endpointslicev1) CleanupTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return cleanupTrigger(ctx, s.client, "endpointslice", namespace, name, &discoveryv1.EndpointSlice{}, func(ctx context.Context) error {
+		_, err := s.kubeClient.DiscoveryV1().EndpointSlices(namespace).Patch(ctx, name, types.JSONPatchType, CleanupTriggerPatch(), newPatchOptions(opts))
+		return err
+	})
+}
+
+// CleanupTriggerAnnotationsBySvc iterates the endpointslices labeled with the service name.
+func (s *endpointslicev1) CleanupTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	names, err := s.ResolveSlices(ctx, namespace, svcName)
+	if err != nil {
+		return err
+	}
+	return patchConcurrently(ctx, names, func(name string) error {
+		return s.CleanupTriggerAnnotations(ctx, namespace, name, opts...)
+	})
+}
+
+func (s *endpointslicev1) GetEnqueueKeysByNodePool(ctx context.Context, svcTopologyTypes map[string]string, allNpNodes sets.String) []string {
+	// The endpointslices spanning several nodes of the nodepool are listed once per node
+	keys := sets.NewString()
+	for _, nodeName := range allNpNodes.List() {
+		epSliceList := &discoveryv1.EndpointSliceList{}
+		if err := s.client.List(ctx, epSliceList, client.MatchingFields{IndexerPathForNodeName: nodeName}); err != nil {
+			if isIndexMissing(err, IndexerPathForNodeName) {
+				klog.Errorf("Error listing endpointslices sets, index %s is not registered: %v", IndexerPathForNodeName, err)
+			} else {
+				klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+			}
+			return nil
+		}
+
+		for i := range epSliceList.Items {
+			epSlice := &epSliceList.Items[i]
+			if s.skip(epSlice) {
+				continue
+			}
+			if !isNodePoolTypeSvc(epSlice.Namespace, epSlice.Labels[discoveryv1.LabelServiceName], svcTopologyTypes) {
+				continue
+			}
+			keys.Insert(AppendKeys(nil, epSlice)...)
+		}
+	}
+	return keys.List()
+}
+
+func (s *endpointslicev1) GetEnqueueKeysByNode(ctx context.Context, svcTopologyTypes map[string]string, nodeName string) []string {
 	var keys []string
-	selector := getSvcSelector(discoveryv1.LabelServiceName, svc.Name)
 	epSliceList := &discoveryv1.EndpointSliceList{}
-	if err := s.client.List(context.TODO(), epSliceList, &client.ListOptions{Namespace: svc.Namespace, LabelSelector: selector}); err != nil {
-		klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+	if err := s.client.List(ctx, epSliceList, client.MatchingFields{IndexerPathForNodeName: nodeName}); err != nil {
+		if isIndexMissing(err, IndexerPathForNodeName) {
+			klog.Errorf("Error listing endpointslices sets, index %s is not registered: %v", IndexerPathForNodeName, err)
+		} else {
+			klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+		}
 		return keys
 	}
 
-	for _, epSlice := range epSliceList.Items {
-		keys = appendKeys(keys, &epSlice)
+	for i := range epSliceList.Items {
+		epSlice := &epSliceList.Items[i]
+		// The nodes are checked again, the list is only narrowed by the index
+		if s.skip(epSlice) || !getNodesInEpSlice(epSlice).Has(nodeName) {
+			continue
+		}
+		if !isNodePoolTypeSvc(epSlice.Namespace, epSlice.Labels[discoveryv1.LabelServiceName], svcTopologyTypes) {
+			continue
+		}
+		keys = AppendKeys(keys, epSlice)
 	}
 	return keys
 }
 
-func (s *endpointslicev1) UpdateTriggerAnnotations(namespace, name string) error {
-	patch := getUpdateTriggerPatch()
-	_, err := s.kubeClient.DiscoveryV1().EndpointSlices(namespace).Patch(context.Background(), name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
-	return err
+func getNodesInEpSlice(epSlice *discoveryv1.EndpointSlice) sets.String {
+	nodes := sets.NewString()
+	for _, ep := range epSlice.Endpoints {
+		if ep.NodeName != nil {
+			nodes.Insert(*ep.NodeName)
+		}
+	}
+	return nodes
 }
@@ -19,14 +19,22 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -39,8 +47,8 @@ func TestEndpointSliceV1AdapterUpdateTriggerAnnotations(t *testing.T) {
 	c := fakeclient.NewClientBuilder().WithObjects(epSlice).Build()
 	stopper := make(chan struct{})
 	defer close(stopper)
-	adapter := NewEndpointsV1Adapter(kubeClient, c)
-	err := adapter.UpdateTriggerAnnotations(epSlice.Namespace, epSlice.Name)
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+	err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name)
 	if err != nil {
 		t.Errorf("update endpointsSlice trigger annotations failed")
 	}
@@ -51,6 +59,115 @@ func TestEndpointSliceV1AdapterUpdateTriggerAnnotations(t *testing.T) {
 	}
 }
 
+func TestEndpointSliceV1AdapterUpdateTriggerAnnotationsWithHash(t *testing.T) {
+	epSlice := getEndpointSlice("default", "svc1", "node1")
+
+	kubeClient := fake.NewSimpleClientset(epSlice)
+	c := fakeclient.NewClientBuilder().WithObjects(epSlice).Build()
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+
+	if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), epSlice.Namespace, epSlice.Name, "hash1"); err != nil {
+		t.Fatalf("update endpointslice trigger annotations failed, %v", err)
+	}
+	newEpSlice, err := kubeClient.DiscoveryV1().EndpointSlices(epSlice.Namespace).Get(context.TODO(), epSlice.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get endpointslice, %v", err)
+	}
+	if newEpSlice.Annotations[UpdateTriggerAnnotation] != "hash1" {
+		t.Errorf("expect trigger hash hash1, but got %s", newEpSlice.Annotations[UpdateTriggerAnnotation])
+	}
+
+	// the cache observes the patched endpointslice
+	cached := &discoveryv1.EndpointSlice{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(epSlice), cached); err != nil {
+		t.Fatalf("failed to get endpointslice, %v", err)
+	}
+	cached.Annotations = newEpSlice.Annotations
+	if err := c.Update(context.TODO(), cached); err != nil {
+		t.Fatalf("failed to update endpointslice, %v", err)
+	}
+
+	tests := []struct {
+		hash         string
+		expectWrites int
+	}{
+		{hash: "hash1", expectWrites: 1},
+		{hash: "hash2", expectWrites: 2},
+	}
+	for _, tt := range tests {
+		if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), epSlice.Namespace, epSlice.Name, tt.hash); err != nil {
+			t.Fatalf("update endpointslice trigger annotations failed, %v", err)
+		}
+		if writes := countPatchActions(kubeClient); writes != tt.expectWrites {
+			t.Errorf("expect %d writes after updating with %s, but got %d", tt.expectWrites, tt.hash, writes)
+		}
+	}
+}
+
+func TestEndpointSliceV1AdapterUpdateTriggerAnnotationsErrors(t *testing.T) {
+	obj := getEndpointSlice("default", "svc1", "node1")
+	conflict := apierrors.NewConflict(discoveryv1.Resource("endpointslices"), obj.Name, errors.New("object has been modified"))
+	timeout := apierrors.NewTimeoutError("request timeout", 1)
+
+	tests := []struct {
+		name           string
+		objName        string
+		failures       int
+		err            error
+		expectNotFound bool
+		expectErr      bool
+		expectPatches  int
+	}{
+		{
+			// the object is fetched before it is patched
+			name:           "object is not found",
+			objName:        "not-exist",
+			expectNotFound: true,
+			expectErr:      true,
+		},
+		{
+			name:          "conflict is retried",
+			objName:       obj.Name,
+			failures:      2,
+			err:           conflict,
+			expectPatches: 3,
+		},
+		{
+			name:          "timeout is retried",
+			objName:       obj.Name,
+			failures:      1,
+			err:           timeout,
+			expectPatches: 2,
+		},
+		{
+			name:          "retries are bounded",
+			objName:       obj.Name,
+			failures:      -1,
+			err:           conflict,
+			expectErr:     true,
+			expectPatches: patchBackoff.Steps,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(obj)
+			failPatches(kubeClient, "endpointslices", tt.failures, tt.err)
+			adapter := NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(obj).Build(), nil)
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), obj.Namespace, tt.objName)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expect error %v, but got %v", tt.expectErr, err)
+			}
+			if apierrors.IsNotFound(err) != tt.expectNotFound {
+				t.Errorf("expect not found error %v, but got %v", tt.expectNotFound, err)
+			}
+			if patches := countPatchActions(kubeClient); patches != tt.expectPatches {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatches, patches)
+			}
+		})
+	}
+}
+
 func TestEndpointSliceV1AdapterGetEnqueueKeysBySvc(t *testing.T) {
 	svcName := "svc1"
 	svcNamespace := "default"
@@ -61,13 +178,13 @@ func TestEndpointSliceV1AdapterGetEnqueueKeysBySvc(t *testing.T) {
 		},
 	}
 	epSlice := getEndpointSlice(svcNamespace, svcName, "node1")
-	expectResult := []string{getCacheKey(epSlice)}
+	expectResult := []string{CacheKey(svc)}
 
 	stopper := make(chan struct{})
 	defer close(stopper)
 	kubeClient := fake.NewSimpleClientset(epSlice)
 	c := fakeclient.NewClientBuilder().WithObjects(epSlice).Build()
-	adapter := NewEndpointsV1Adapter(kubeClient, c)
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
 
 	keys := adapter.GetEnqueueKeysBySvc(svc)
 	if !reflect.DeepEqual(keys, expectResult) {
@@ -75,6 +192,229 @@ func TestEndpointSliceV1AdapterGetEnqueueKeysBySvc(t *testing.T) {
 	}
 }
 
+func TestEndpointSliceV1AdapterResolveSlicesOfManySlices(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc1", Namespace: "default"}}
+	var objs []runtime.Object
+	var cObjs []client.Object
+	for i := 0; i < 200; i++ {
+		epSlice := getEndpointSlice(svc.Namespace, svc.Name, "node1")
+		epSlice.Name = fmt.Sprintf("%s-%d", svc.Name, i)
+		objs = append(objs, epSlice)
+		cObjs = append(cObjs, epSlice)
+	}
+	kubeClient := fake.NewSimpleClientset(objs...)
+	adapter := NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(cObjs...).Build(), nil)
+
+	// the service with 200 endpointslices is enqueued by one key
+	if keys := adapter.GetEnqueueKeysBySvc(svc); !reflect.DeepEqual(keys, []string{"default/svc1"}) {
+		t.Errorf("expect the key of service, but got %v", keys)
+	}
+	// and the endpointslices are resolved when the key is processed
+	names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name)
+	if err != nil {
+		t.Fatalf("failed to resolve endpointslices, %v", err)
+	}
+	if len(names) != 200 {
+		t.Errorf("expect 200 endpointslices, but got %d", len(names))
+	}
+	if err := adapter.UpdateTriggerAnnotationsBySvc(context.TODO(), svc.Namespace, svc.Name); err != nil {
+		t.Fatalf("failed to update trigger annotations, %v", err)
+	}
+	if patches := countPatchActions(kubeClient); patches != 200 {
+		t.Errorf("expect 200 patches, but got %d", patches)
+	}
+}
+
+func TestEndpointSliceV1AdapterResolveSlicesCacheNotSynced(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc1",
+			Namespace: "default",
+		},
+	}
+	epSlice := getEndpointSlice(svc.Namespace, svc.Name, "node1")
+
+	tests := []struct {
+		name         string
+		cacheSynced  bool
+		expectResult []string
+	}{
+		{
+			name:         "cache is not synced",
+			cacheSynced:  false,
+			expectResult: []string{epSlice.Name},
+		},
+		{
+			name:         "cache is synced",
+			cacheSynced:  true,
+			expectResult: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the cache is empty while the apiserver has the endpointslice
+			kubeClient := fake.NewSimpleClientset(epSlice)
+			c := fakeclient.NewClientBuilder().Build()
+			adapter := NewEndpointsV1Adapter(kubeClient, c, func() bool { return tt.cacheSynced })
+
+			names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name)
+			if err != nil {
+				t.Fatalf("failed to resolve endpointslices, %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.expectResult) {
+				t.Errorf("expect endpointslices %v, but got %v", tt.expectResult, names)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceV1AdapterUpdateTriggerAnnotationsBySvc(t *testing.T) {
+	svcName := "svc1"
+	svcNamespace := "default"
+	var objs []runtime.Object
+	var cObjs []client.Object
+	for i := 0; i < 20; i++ {
+		epSlice := getEndpointSlice(svcNamespace, svcName, "node1")
+		epSlice.Name = fmt.Sprintf("%s-%d", svcName, i)
+		objs = append(objs, epSlice)
+		cObjs = append(cObjs, epSlice)
+	}
+	otherSlice := getEndpointSlice(svcNamespace, "svc2", "node1")
+	objs = append(objs, otherSlice)
+	cObjs = append(cObjs, otherSlice)
+
+	kubeClient := fake.NewSimpleClientset(objs...)
+	c := fakeclient.NewClientBuilder().WithObjects(cObjs...).Build()
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+	if err := adapter.UpdateTriggerAnnotationsBySvc(context.TODO(), svcNamespace, svcName); err != nil {
+		t.Fatalf("update endpointslices trigger annotations failed, %v", err)
+	}
+
+	epSliceList, err := kubeClient.DiscoveryV1().EndpointSlices(svcNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list endpointslices, %v", err)
+	}
+	for _, epSlice := range epSliceList.Items {
+		_, ok := epSlice.Annotations["openyurt.io/update-trigger"]
+		if epSlice.Name == otherSlice.Name && ok {
+			t.Errorf("endpointslice %s of other service should not be updated", epSlice.Name)
+		} else if epSlice.Name != otherSlice.Name && !ok {
+			t.Errorf("endpointslice %s has no trigger annotation", epSlice.Name)
+		}
+	}
+}
+
+func TestEndpointSliceV1AdapterCleanupTriggerAnnotations(t *testing.T) {
+	epSlice := getEndpointSlice("default", "svc1", "node1")
+	epSlice.Annotations = map[string]string{UpdateTriggerAnnotation: "1690000000"}
+	pristine := getEndpointSlice("default", "svc2", "node1")
+
+	kubeClient := fake.NewSimpleClientset(epSlice, pristine)
+	c := fakeclient.NewClientBuilder().WithObjects(epSlice, pristine).Build()
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+
+	// the endpointslice without trigger annotation is not patched
+	if err := adapter.CleanupTriggerAnnotations(context.TODO(), pristine.Namespace, pristine.Name); err != nil {
+		t.Fatalf("cleanup endpointslice trigger annotations failed, %v", err)
+	}
+	if patches := countPatchActions(kubeClient); patches != 0 {
+		t.Errorf("expect no patches, but got %d", patches)
+	}
+
+	if err := adapter.CleanupTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name); err != nil {
+		t.Fatalf("cleanup endpointslice trigger annotations failed, %v", err)
+	}
+	if patches := countPatchActions(kubeClient); patches != 1 {
+		t.Errorf("expect 1 patch, but got %d", patches)
+	}
+	newEpSlice, err := kubeClient.DiscoveryV1().EndpointSlices(epSlice.Namespace).Get(context.TODO(), epSlice.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get endpointslice, %v", err)
+	}
+	if _, ok := newEpSlice.Annotations[UpdateTriggerAnnotation]; ok {
+		t.Errorf("expect trigger annotation is removed, but got %v", newEpSlice.Annotations)
+	}
+
+	if err := adapter.CleanupTriggerAnnotations(context.TODO(), "default", "not-exist"); !apierrors.IsNotFound(err) {
+		t.Errorf("expect not found error, but got %v", err)
+	}
+}
+
+func TestEndpointSliceV1AdapterCleanupTriggerAnnotationsBySvc(t *testing.T) {
+	svcName := "svc1"
+	svcNamespace := "default"
+	var objs []runtime.Object
+	var cObjs []client.Object
+	for i := 0; i < 10; i++ {
+		epSlice := getEndpointSlice(svcNamespace, svcName, "node1")
+		epSlice.Name = fmt.Sprintf("%s-%d", svcName, i)
+		// only the even endpointslices have been triggered
+		if i%2 == 0 {
+			epSlice.Annotations = map[string]string{UpdateTriggerAnnotation: "1690000000"}
+		}
+		objs = append(objs, epSlice)
+		cObjs = append(cObjs, epSlice)
+	}
+	otherSlice := getEndpointSlice(svcNamespace, "svc2", "node1")
+	otherSlice.Annotations = map[string]string{UpdateTriggerAnnotation: "1690000000"}
+	objs = append(objs, otherSlice)
+	cObjs = append(cObjs, otherSlice)
+
+	kubeClient := fake.NewSimpleClientset(objs...)
+	c := fakeclient.NewClientBuilder().WithObjects(cObjs...).Build()
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+	if err := adapter.CleanupTriggerAnnotationsBySvc(context.TODO(), svcNamespace, svcName); err != nil {
+		t.Fatalf("cleanup endpointslices trigger annotations failed, %v", err)
+	}
+	if patches := countPatchActions(kubeClient); patches != 5 {
+		t.Errorf("expect 5 patches, but got %d", patches)
+	}
+
+	epSliceList, err := kubeClient.DiscoveryV1().EndpointSlices(svcNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list endpointslices, %v", err)
+	}
+	for _, epSlice := range epSliceList.Items {
+		_, ok := epSlice.Annotations[UpdateTriggerAnnotation]
+		if epSlice.Name == otherSlice.Name && !ok {
+			t.Errorf("endpointslice %s of other service should not be cleaned up", epSlice.Name)
+		} else if epSlice.Name != otherSlice.Name && ok {
+			t.Errorf("endpointslice %s still has trigger annotation", epSlice.Name)
+		}
+	}
+}
+
+func TestEndpointSliceV1AdapterGetEnqueueKeysByNodePool(t *testing.T) {
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+	}
+	epSlice1 := getEndpointSlice("default", "svc1", "node1", "node2")
+	epSlice2 := getEndpointSlice("default", "svc2", "node3")
+	epSlice3 := getEndpointSlice("default", "svc3", "node2")
+	nodepoolNodes := sets.NewString("node1", "node2")
+	// the endpointslice spanning both nodes of nodepool is enqueued once, and the one of service without
+	// nodepool topology is skipped
+	expectResult := []string{CacheKey(epSlice1)}
+
+	kubeClient := fake.NewSimpleClientset(epSlice1, epSlice2, epSlice3)
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(epSlice1, epSlice2, epSlice3).Build())
+	adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+
+	// without the index, the endpointslices of nodepool are not listed
+	if keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), svcTopologyTypes, nodepoolNodes); len(keys) != 0 {
+		t.Errorf("expect no keys without the index, but got %v", keys)
+	}
+
+	if err := RegisterFieldIndexers(c, newEndpointSliceRESTMapper()); err != nil {
+		t.Fatalf("failed to register field indexers, %v", err)
+	}
+	keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), svcTopologyTypes, nodepoolNodes)
+	if !reflect.DeepEqual(keys, expectResult) {
+		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
+	}
+}
+
 func getEndpointSlice(svcNamespace, svcName string, nodes ...string) *discoveryv1.EndpointSlice {
 	var endpoints []discoveryv1.Endpoint
 	for i := range nodes {
@@ -91,3 +431,316 @@ func getEndpointSlice(svcNamespace, svcName string, nodes ...string) *discoveryv
 		Endpoints: endpoints,
 	}
 }
+
+func TestEndpointSliceV1AdapterSkipMirroredSlices(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc1",
+			Namespace: "default",
+		},
+	}
+	nativeSlice := getEndpointSlice(svc.Namespace, svc.Name, "node1")
+	mirroredSlice := getEndpointSlice(svc.Namespace, svc.Name, "node1")
+	mirroredSlice.Name = "svc1-mirrored"
+	mirroredSlice.Labels[discoveryv1.LabelManagedBy] = endpointSliceMirroringController
+
+	tests := []struct {
+		name         string
+		opts         []EndpointSliceV1Option
+		expectNames  []string
+		expectResult []string
+	}{
+		{
+			name:         "mirrored slice is skipped",
+			expectNames:  []string{nativeSlice.Name},
+			expectResult: []string{CacheKey(nativeSlice)},
+		},
+		{
+			name:         "mirrored slice is handled",
+			opts:         []EndpointSliceV1Option{WithMirroredEndpointSlices()},
+			expectNames:  []string{mirroredSlice.Name, nativeSlice.Name},
+			expectResult: []string{CacheKey(mirroredSlice), CacheKey(nativeSlice)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(nativeSlice, mirroredSlice)
+			c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(nativeSlice, mirroredSlice).Build())
+			if err := RegisterFieldIndexers(c, newEndpointSliceRESTMapper()); err != nil {
+				t.Fatalf("failed to register field indexers, %v", err)
+			}
+			adapter := NewEndpointsV1Adapter(kubeClient, c, nil, tt.opts...)
+
+			names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name)
+			if err != nil {
+				t.Fatalf("failed to resolve endpointslices, %v", err)
+			}
+			if !reflect.DeepEqual(names, tt.expectNames) {
+				t.Errorf("expect endpointslices %v, but got %v", tt.expectNames, names)
+			}
+
+			keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), map[string]string{"default/svc1": "openyurt.io/nodepool"}, sets.NewString("node1"))
+			if !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys by nodepool %v, but got %v", tt.expectResult, keys)
+			}
+
+			if err := adapter.UpdateTriggerAnnotationsBySvc(context.TODO(), svc.Namespace, svc.Name); err != nil {
+				t.Fatalf("failed to update trigger annotations, %v", err)
+			}
+			if patches := countPatchActions(kubeClient); patches != len(tt.expectResult) {
+				t.Errorf("expect %d patches, but got %d", len(tt.expectResult), patches)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceV1AdapterGetEnqueueKeysByNode(t *testing.T) {
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+		"default/svc3": "openyurt.io/nodepool",
+	}
+	epSlice1 := getEndpointSlice("default", "svc1", "node1", "node2")
+	epSlice2 := getEndpointSlice("default", "svc2", "node2", "node3")
+	epSlice3 := getEndpointSlice("default", "svc3", "node3")
+	// the service without topology is not enqueued
+	epSlice4 := getEndpointSlice("default", "svc4", "node2")
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(epSlice1, epSlice2, epSlice3, epSlice4).Build())
+	adapter := NewEndpointsV1Adapter(fake.NewSimpleClientset(), c, nil)
+
+	// without the index, the endpointslices of node are not listed
+	if keys := adapter.GetEnqueueKeysByNode(context.TODO(), svcTopologyTypes, "node2"); len(keys) != 0 {
+		t.Errorf("expect no keys without the index, but got %v", keys)
+	}
+
+	if err := RegisterFieldIndexers(c, newEndpointSliceRESTMapper()); err != nil {
+		t.Fatalf("failed to register field indexers, %v", err)
+	}
+	tests := []struct {
+		nodeName     string
+		expectResult []string
+	}{
+		{
+			nodeName:     "node1",
+			expectResult: []string{CacheKey(epSlice1)},
+		},
+		{
+			nodeName:     "node2",
+			expectResult: []string{CacheKey(epSlice1), CacheKey(epSlice2)},
+		},
+		{
+			nodeName: "node4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nodeName, func(t *testing.T) {
+			keys := adapter.GetEnqueueKeysByNode(context.TODO(), svcTopologyTypes, tt.nodeName)
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys %v, but got %v", tt.expectResult, keys)
+			}
+		})
+	}
+}
+
+// indexedClient serves the field selectors by the registered indexers like the cache of manager, which is not
+// supported by the fake client. Listing by an index which is not registered fails as the cache does.
+type indexedClient struct {
+	client.Client
+	indexers map[string]client.IndexerFunc
+}
+
+func newIndexedClient(c client.Client) *indexedClient {
+	return &indexedClient{Client: c, indexers: map[string]client.IndexerFunc{}}
+}
+
+func (c *indexedClient) IndexField(_ context.Context, _ client.Object, field string, extractValue client.IndexerFunc) error {
+	if _, ok := c.indexers[field]; ok {
+		return fmt.Errorf("indexer conflict: map[field:%s:{}]", field)
+	}
+	c.indexers[field] = extractValue
+	return nil
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	requirements := listOpts.FieldSelector.Requirements()
+	if len(requirements) != 1 {
+		return fmt.Errorf("non-exact field matches are not supported by the cache")
+	}
+	extractValue, ok := c.indexers[requirements[0].Field]
+	if !ok {
+		return fmt.Errorf("Index with name field:%s does not exist", requirements[0].Field)
+	}
+	innerOpts := []client.ListOption{client.InNamespace(listOpts.Namespace)}
+	if listOpts.LabelSelector != nil {
+		innerOpts = append(innerOpts, client.MatchingLabelsSelector{Selector: listOpts.LabelSelector})
+	}
+	if err := c.Client.List(ctx, list, innerOpts...); err != nil {
+		return err
+	}
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var matched []runtime.Object
+	for _, obj := range objs {
+		for _, value := range extractValue(obj.(client.Object)) {
+			if value == requirements[0].Value {
+				matched = append(matched, obj)
+				break
+			}
+		}
+	}
+	return meta.SetList(list, matched)
+}
+
+func newEndpointSliceRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice"), meta.RESTScopeNamespace)
+	return mapper
+}
+
+// newManyEndpointSlices seeds the endpointslices of many services, each service has several endpointslices.
+func newManyEndpointSlices(services, slicesPerService int) []client.Object {
+	objs := make([]client.Object, 0, services*slicesPerService)
+	for i := 0; i < services; i++ {
+		for j := 0; j < slicesPerService; j++ {
+			epSlice := getEndpointSlice("default", fmt.Sprintf("svc%d", i), "node1")
+			epSlice.Name = fmt.Sprintf("svc%d-%d", i, j)
+			objs = append(objs, epSlice)
+		}
+	}
+	return objs
+}
+
+func TestRegisterFieldIndexers(t *testing.T) {
+	tests := []struct {
+		name          string
+		mapper        meta.RESTMapper
+		registered    bool
+		expectIndexed bool
+	}{
+		{
+			name:          "endpointslice v1 is served",
+			mapper:        newEndpointSliceRESTMapper(),
+			expectIndexed: true,
+		},
+		{
+			name:          "index is registered by others",
+			mapper:        newEndpointSliceRESTMapper(),
+			registered:    true,
+			expectIndexed: true,
+		},
+		{
+			name:   "endpointslice is not served",
+			mapper: meta.NewDefaultRESTMapper(nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newIndexedClient(fakeclient.NewClientBuilder().Build())
+			if tt.registered {
+				c.indexers[IndexerPathForServiceName] = func(client.Object) []string { return nil }
+			}
+			if err := RegisterFieldIndexers(c, tt.mapper); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			for _, path := range []string{IndexerPathForServiceName, IndexerPathForNodeName} {
+				if _, ok := c.indexers[path]; ok != tt.expectIndexed {
+					t.Errorf("expect index %s registered %v, but got %v", path, tt.expectIndexed, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointSliceV1AdapterResolveSlicesIndex(t *testing.T) {
+	objs := newManyEndpointSlices(100, 3)
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc42", Namespace: "default"}}
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(objs...).Build())
+
+	// without the index, the endpointslices of service are not listed, and the error is returned
+	adapter := NewEndpointsV1Adapter(fake.NewSimpleClientset(), c, nil)
+	if names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name); err == nil || len(names) != 0 {
+		t.Errorf("expect no endpointslices and an error without the index, but got %v and %v", names, err)
+	}
+	if err := adapter.UpdateTriggerAnnotationsBySvc(context.TODO(), svc.Namespace, svc.Name); err == nil || !strings.Contains(err.Error(), "RegisterFieldIndexers") {
+		t.Errorf("expect error about the missing index, but got %v", err)
+	}
+
+	// with the index, the result is the same as listing by the label selector
+	if err := RegisterFieldIndexers(c, newEndpointSliceRESTMapper()); err != nil {
+		t.Fatalf("failed to register field indexers, %v", err)
+	}
+	epSliceList := &discoveryv1.EndpointSliceList{}
+	if err := c.List(context.TODO(), epSliceList, client.InNamespace(svc.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: svc.Name}); err != nil {
+		t.Fatalf("failed to list endpointslices, %v", err)
+	}
+	var expectResult []string
+	for i := range epSliceList.Items {
+		expectResult = append(expectResult, epSliceList.Items[i].Name)
+	}
+	if len(expectResult) != 3 {
+		t.Fatalf("expect 3 endpointslices of service, but got %v", expectResult)
+	}
+	if names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name); err != nil || !reflect.DeepEqual(names, expectResult) {
+		t.Errorf("expect endpointslices %v, but got %v and %v", expectResult, names, err)
+	}
+}
+
+func BenchmarkEndpointSliceV1AdapterResolveSlices(b *testing.B) {
+	objs := newManyEndpointSlices(1000, 3)
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc42", Namespace: "default"}}
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(objs...).Build())
+	if err := RegisterFieldIndexers(c, newEndpointSliceRESTMapper()); err != nil {
+		b.Fatalf("failed to register field indexers, %v", err)
+	}
+	adapter := NewEndpointsV1Adapter(fake.NewSimpleClientset(), c, nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if names, err := adapter.ResolveSlices(context.TODO(), svc.Namespace, svc.Name); err != nil || len(names) != 3 {
+			b.Fatalf("expect 3 endpointslices, but got %v and %v", names, err)
+		}
+	}
+}
+
+func TestEndpointSliceV1AdapterSkipUpdateTrigger(t *testing.T) {
+	tests := []struct {
+		name         string
+		skipped      bool
+		expectPatch  int
+		expectResult []string
+	}{
+		{name: "annotated service", skipped: true},
+		{name: "unannotated service", expectPatch: 2, expectResult: []string{"default/svc1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epSlice := getEndpointSlice("default", "svc1", "node1")
+			svc := getService("default", "svc1", tt.skipped)
+			kubeClient := fake.NewSimpleClientset(epSlice)
+			c := fakeclient.NewClientBuilder().WithObjects(epSlice, svc).Build()
+			adapter := NewEndpointsV1Adapter(kubeClient, c, nil)
+
+			if keys := adapter.GetEnqueueKeysBySvc(svc); !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys %v, but got %v", tt.expectResult, keys)
+			}
+			if err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), epSlice.Namespace, epSlice.Name, "hash"); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if count := countPatchActions(kubeClient); count != tt.expectPatch {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatch, count)
+			}
+		})
+	}
+}
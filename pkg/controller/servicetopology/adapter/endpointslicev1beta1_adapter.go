@@ -24,40 +24,179 @@ import (
 	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func NewEndpointsV1Beta1Adapter(kubeClient kubernetes.Interface, client client.Client) Adapter {
+func NewEndpointsV1Beta1Adapter(kubeClient kubernetes.Interface, client client.Client, cacheSynced CacheSyncedFunc) Adapter {
 	return &endpointslicev1beta1{
-		kubeClient: kubeClient,
-		client:     client,
+		kubeClient:  kubeClient,
+		client:      client,
+		cacheSynced: cacheSynced,
 	}
 }
 
 type endpointslicev1beta1 struct {
-	kubeClient kubernetes.Interface
-	client     client.Client
+	kubeClient  kubernetes.Interface
+	client      client.Client
+	cacheSynced CacheSyncedFunc
 }
 
+// GetEnqueueKeysBySvc returns the key of service, the endpointslices are resolved by ResolveSlices when it is processed.
+// Nothing is returned for the service annotated with SkipUpdateTriggerAnnotation.
 func (s *endpointslicev1beta1) GetEnqueueKeysBySvc(svc *corev1.Service) []string {
+	if isUpdateTriggerSkipped(svc) {
+		return nil
+	}
+	return serviceKey(svc)
+}
+
+func (s *endpointslicev1beta1) ResolveSlices(ctx context.Context, namespace, svcName string) ([]string, error) {
+	epSlices, err := s.listEndpointSlicesBySvc(ctx, namespace, svcName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(epSlices))
+	for i := range epSlices {
+		names = append(names, epSlices[i].Name)
+	}
+	return names, nil
+}
+
+// listEndpointSlicesBySvc lists the endpointslices of the service from the cache, and falls back to
+// list them through kubeClient if nothing is found before the cache is synced.
+func (s *endpointslicev1beta1) listEndpointSlicesBySvc(ctx context.Context, namespace, svcName string) ([]discoveryv1beta1.EndpointSlice, error) {
+	selector := getSvcSelector(discoveryv1beta1.LabelServiceName, svcName)
+	epSliceList := &discoveryv1beta1.EndpointSliceList{}
+	if err := s.client.List(ctx, epSliceList, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+	if len(epSliceList.Items) != 0 || s.cacheSynced.synced() {
+		return epSliceList.Items, nil
+	}
+
+	klog.V(4).Infof("cache is not synced, list endpointslices of service %s/%s from apiserver", namespace, svcName)
+	callCtx, cancel := withCallTimeout(ctx)
+	defer cancel()
+	epSliceList, err := s.kubeClient.DiscoveryV1beta1().EndpointSlices(namespace).List(callCtx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, contextError(callCtx, err)
+	}
+	return epSliceList.Items, nil
+}
+
+func (s *endpointslicev1beta1) UpdateTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerPatch(), opts)
+}
+
+func (s *endpointslicev1beta1) UpdateTriggerAnnotationsWithHash(ctx context.Context, namespace, name, hash string, opts ...PatchOption) error {
+	if triggerHashMatched(ctx, s.client, types.NamespacedName{Namespace: namespace, Name: name}, &discoveryv1beta1.EndpointSlice{}, hash) {
+		return nil
+	}
+	return s.patchTrigger(ctx, namespace, name, UpdateTriggerHashPatch(hash), opts)
+}
+
+// patchTrigger does not patch the endpointslices of the service annotated with SkipUpdateTriggerAnnotation.
+func (s *endpointslicev1beta1) patchTrigger(ctx context.Context, namespace, name string, patch []byte, opts []PatchOption) error {
+	epSlice := &discoveryv1beta1.EndpointSlice{}
+	if err := s.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, epSlice); err == nil &&
+		skipsUpdateTrigger(ctx, s.client, "endpointslice", namespace, name, epSlice.Labels[discoveryv1beta1.LabelServiceName]) {
+		return nil
+	}
+	return patchWithRetry(ctx, "endpointslice", namespace, name, func(ctx context.Context) error {
+		_, err := s.kubeClient.DiscoveryV1beta1().EndpointSlices(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, newPatchOptions(opts))
+		return err
+	})
+}
+
+func (s *endpointslicev1beta1) UpdateTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	names, err := s.ResolveSlices(ctx, namespace, svcName)
+	if err != nil {
+		return err
+	}
+	return patchConcurrently(ctx, names, func(name string) error {
+		return s.UpdateTriggerAnnotations(ctx, namespace, name, opts...)
+	})
+}
+
+func (s *endpointslicev1beta1) CleanupTriggerAnnotations(ctx context.Context, namespace, name string, opts ...PatchOption) error {
+	return cleanupTrigger(ctx, s.client, "endpointslice", namespace, name, &discoveryv1beta1.EndpointSlice{}, func(ctx context.Context) error {
+		_, err := s.kubeClient.DiscoveryV1beta1().EndpointSlices(namespace).Patch(ctx, name, types.JSONPatchType, CleanupTriggerPatch(), newPatchOptions(opts))
+		return err
+	})
+}
+
+// CleanupTriggerAnnotationsBySvc iterates the endpointslices labeled with the service name.
+func (s *endpointslicev1beta1) CleanupTriggerAnnotationsBySvc(ctx context.Context, namespace, svcName string, opts ...PatchOption) error {
+	names, err := s.ResolveSlices(ctx, namespace, svcName)
+	if err != nil {
+		return err
+	}
+	return patchConcurrently(ctx, names, func(name string) error {
+		return s.CleanupTriggerAnnotations(ctx, namespace, name, opts...)
+	})
+}
+
+func (s *endpointslicev1beta1) GetEnqueueKeysByNodePool(ctx context.Context, svcTopologyTypes map[string]string, allNpNodes sets.String) []string {
+	// The endpointslices spanning several nodes of the nodepool are listed once per node
+	keys := sets.NewString()
+	for _, nodeName := range allNpNodes.List() {
+		epSliceList := &discoveryv1beta1.EndpointSliceList{}
+		if err := s.client.List(ctx, epSliceList, client.MatchingFields{IndexerPathForNodeName: nodeName}); err != nil {
+			if isIndexMissing(err, IndexerPathForNodeName) {
+				klog.Errorf("Error listing endpointslices sets, index %s is not registered: %v", IndexerPathForNodeName, err)
+			} else {
+				klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+			}
+			return nil
+		}
+
+		for i := range epSliceList.Items {
+			epSlice := &epSliceList.Items[i]
+			if !isNodePoolTypeSvc(epSlice.Namespace, epSlice.Labels[discoveryv1beta1.LabelServiceName], svcTopologyTypes) {
+				continue
+			}
+			keys.Insert(AppendKeys(nil, epSlice)...)
+		}
+	}
+	return keys.List()
+}
+
+func (s *endpointslicev1beta1) GetEnqueueKeysByNode(ctx context.Context, svcTopologyTypes map[string]string, nodeName string) []string {
 	var keys []string
-	selector := getSvcSelector(discoveryv1beta1.LabelServiceName, svc.Name)
 	epSliceList := &discoveryv1beta1.EndpointSliceList{}
-	if err := s.client.List(context.TODO(), epSliceList, &client.ListOptions{Namespace: svc.Namespace, LabelSelector: selector}); err != nil {
-		klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+	if err := s.client.List(ctx, epSliceList, client.MatchingFields{IndexerPathForNodeName: nodeName}); err != nil {
+		if isIndexMissing(err, IndexerPathForNodeName) {
+			klog.Errorf("Error listing endpointslices sets, index %s is not registered: %v", IndexerPathForNodeName, err)
+		} else {
+			klog.V(4).Infof("Error listing endpointslices sets: %v", err)
+		}
 		return keys
 	}
 
-	for _, epSlice := range epSliceList.Items {
-		keys = appendKeys(keys, &epSlice)
+	for i := range epSliceList.Items {
+		epSlice := &epSliceList.Items[i]
+		// The nodes are checked again, the list is only narrowed by the index
+		if !getNodesInEpSliceV1Beta1(epSlice).Has(nodeName) {
+			continue
+		}
+		if !isNodePoolTypeSvc(epSlice.Namespace, epSlice.Labels[discoveryv1beta1.LabelServiceName], svcTopologyTypes) {
+			continue
+		}
+		keys = AppendKeys(keys, epSlice)
 	}
 	return keys
 }
 
-func (s *endpointslicev1beta1) UpdateTriggerAnnotations(namespace, name string) error {
-	patch := getUpdateTriggerPatch()
-	_, err := s.kubeClient.DiscoveryV1beta1().EndpointSlices(namespace).Patch(context.Background(), name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
-	return err
+func getNodesInEpSliceV1Beta1(epSlice *discoveryv1beta1.EndpointSlice) sets.String {
+	nodes := sets.NewString()
+	for _, ep := range epSlice.Endpoints {
+		if nodeName, ok := ep.Topology[corev1.LabelHostname]; ok {
+			nodes.Insert(nodeName)
+		}
+	}
+	return nodes
 }
@@ -21,11 +21,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/fake"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -39,8 +42,8 @@ func TestEndpointSliceV1Beta1AdapterUpdateTriggerAnnotations(t *testing.T) {
 	c := fakeclient.NewClientBuilder().WithObjects(epSlice).Build()
 	stopper := make(chan struct{})
 	defer close(stopper)
-	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c)
-	err := adapter.UpdateTriggerAnnotations(epSlice.Namespace, epSlice.Name)
+	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c, nil)
+	err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name)
 	if err != nil {
 		t.Errorf("update endpointsSlice trigger annotations failed")
 	}
@@ -51,6 +54,34 @@ func TestEndpointSliceV1Beta1AdapterUpdateTriggerAnnotations(t *testing.T) {
 	}
 }
 
+func TestEndpointSliceV1Beta1AdapterCleanupTriggerAnnotations(t *testing.T) {
+	epSlice := getV1Beta1EndpointSlice("default", "svc1", "node1")
+	epSlice.Annotations = map[string]string{UpdateTriggerAnnotation: "1690000000"}
+	pristine := getV1Beta1EndpointSlice("default", "svc2", "node1")
+
+	kubeClient := fake.NewSimpleClientset(epSlice, pristine)
+	c := fakeclient.NewClientBuilder().WithObjects(epSlice, pristine).Build()
+	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c, nil)
+
+	if err := adapter.CleanupTriggerAnnotationsBySvc(context.TODO(), "default", "svc2"); err != nil {
+		t.Fatalf("cleanup endpointslice trigger annotations failed, %v", err)
+	}
+	if patches := countPatchActions(kubeClient); patches != 0 {
+		t.Errorf("expect no patches, but got %d", patches)
+	}
+
+	if err := adapter.CleanupTriggerAnnotationsBySvc(context.TODO(), "default", "svc1"); err != nil {
+		t.Fatalf("cleanup endpointslice trigger annotations failed, %v", err)
+	}
+	newEpSlice, err := kubeClient.DiscoveryV1beta1().EndpointSlices(epSlice.Namespace).Get(context.TODO(), epSlice.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get endpointslice, %v", err)
+	}
+	if _, ok := newEpSlice.Annotations[UpdateTriggerAnnotation]; ok {
+		t.Errorf("expect trigger annotation is removed, but got %v", newEpSlice.Annotations)
+	}
+}
+
 func TestEndpointSliceV1Beta1AdapterGetEnqueueKeysBySvc(t *testing.T) {
 	svcName := "svc1"
 	svcNamespace := "default"
@@ -61,18 +92,89 @@ func TestEndpointSliceV1Beta1AdapterGetEnqueueKeysBySvc(t *testing.T) {
 		},
 	}
 	epSlice := getV1Beta1EndpointSlice(svcNamespace, svcName, "node1")
-	expectResult := []string{getCacheKey(epSlice)}
+	expectResult := []string{CacheKey(svc)}
 
 	stopper := make(chan struct{})
 	defer close(stopper)
 	kubeClient := fake.NewSimpleClientset(epSlice)
 	c := fakeclient.NewClientBuilder().WithObjects(epSlice).Build()
-	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c)
+	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c, nil)
 
 	keys := adapter.GetEnqueueKeysBySvc(svc)
 	if !reflect.DeepEqual(keys, expectResult) {
 		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
 	}
+	names, err := adapter.ResolveSlices(context.TODO(), svcNamespace, svcName)
+	if err != nil || !reflect.DeepEqual(names, []string{epSlice.Name}) {
+		t.Errorf("expect endpointslices %v, but got %v and %v", []string{epSlice.Name}, names, err)
+	}
+}
+
+func TestEndpointSliceV1Beta1AdapterGetEnqueueKeysByNodePool(t *testing.T) {
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+	}
+	epSlice1 := getV1Beta1EndpointSlice("default", "svc1", "node1", "node2")
+	epSlice2 := getV1Beta1EndpointSlice("default", "svc2", "node3")
+	nodepoolNodes := sets.NewString("node1", "node2")
+	expectResult := []string{CacheKey(epSlice1)}
+
+	kubeClient := fake.NewSimpleClientset(epSlice1, epSlice2)
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(epSlice1, epSlice2).Build())
+	adapter := NewEndpointsV1Beta1Adapter(kubeClient, c, nil)
+
+	// without the index, the endpointslices of nodepool are not listed
+	if keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), svcTopologyTypes, nodepoolNodes); len(keys) != 0 {
+		t.Errorf("expect no keys without the index, but got %v", keys)
+	}
+
+	if err := RegisterFieldIndexers(c, newV1Beta1EndpointSliceRESTMapper()); err != nil {
+		t.Fatalf("failed to register field indexers, %v", err)
+	}
+	if _, ok := c.indexers[IndexerPathForServiceName]; ok {
+		t.Errorf("expect the index of service name is not registered for v1beta1 endpointslices")
+	}
+	keys := adapter.GetEnqueueKeysByNodePool(context.TODO(), svcTopologyTypes, nodepoolNodes)
+	if !reflect.DeepEqual(keys, expectResult) {
+		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
+	}
+}
+
+func newV1Beta1EndpointSliceRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(discoveryv1beta1.SchemeGroupVersion.WithKind("EndpointSlice"), meta.RESTScopeNamespace)
+	return mapper
+}
+
+func TestEndpointSliceV1Beta1AdapterGetEnqueueKeysByNode(t *testing.T) {
+	svcTopologyTypes := map[string]string{
+		"default/svc1": "openyurt.io/nodepool",
+		"default/svc2": "openyurt.io/nodepool",
+		"default/svc3": "openyurt.io/nodepool",
+	}
+	epSlice1 := getV1Beta1EndpointSlice("default", "svc1", "node1", "node2")
+	epSlice2 := getV1Beta1EndpointSlice("default", "svc2", "node2", "node3")
+	epSlice3 := getV1Beta1EndpointSlice("default", "svc3", "node3")
+	// the service without topology is not enqueued
+	epSlice4 := getV1Beta1EndpointSlice("default", "svc4", "node2")
+	c := newIndexedClient(fakeclient.NewClientBuilder().WithObjects(epSlice1, epSlice2, epSlice3, epSlice4).Build())
+	adapter := NewEndpointsV1Beta1Adapter(fake.NewSimpleClientset(), c, nil)
+
+	// without the index, the endpointslices of node are not listed
+	if keys := adapter.GetEnqueueKeysByNode(context.TODO(), svcTopologyTypes, "node2"); len(keys) != 0 {
+		t.Errorf("expect no keys without the index, but got %v", keys)
+	}
+
+	if err := RegisterFieldIndexers(c, newV1Beta1EndpointSliceRESTMapper()); err != nil {
+		t.Fatalf("failed to register field indexers, %v", err)
+	}
+	expectResult := []string{CacheKey(epSlice1), CacheKey(epSlice2)}
+	keys := adapter.GetEnqueueKeysByNode(context.TODO(), svcTopologyTypes, "node2")
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, expectResult) {
+		t.Errorf("expect enqueue keys %v, but got %v", expectResult, keys)
+	}
 }
 
 func getV1Beta1EndpointSlice(svcNamespace, svcName string, nodes ...string) *discoveryv1beta1.EndpointSlice {
@@ -97,3 +199,37 @@ func getV1Beta1EndpointSlice(svcNamespace, svcName string, nodes ...string) *dis
 		Endpoints: endpoints,
 	}
 }
+
+func TestEndpointSliceV1Beta1AdapterSkipUpdateTrigger(t *testing.T) {
+	tests := []struct {
+		name         string
+		skipped      bool
+		expectPatch  int
+		expectResult []string
+	}{
+		{name: "annotated service", skipped: true},
+		{name: "unannotated service", expectPatch: 2, expectResult: []string{"default/svc1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epSlice := getV1Beta1EndpointSlice("default", "svc1", "node1")
+			svc := getService("default", "svc1", tt.skipped)
+			kubeClient := fake.NewSimpleClientset(epSlice)
+			c := fakeclient.NewClientBuilder().WithObjects(epSlice, svc).Build()
+			adapter := NewEndpointsV1Beta1Adapter(kubeClient, c, nil)
+
+			if keys := adapter.GetEnqueueKeysBySvc(svc); !reflect.DeepEqual(keys, tt.expectResult) {
+				t.Errorf("expect enqueue keys %v, but got %v", tt.expectResult, keys)
+			}
+			if err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if err := adapter.UpdateTriggerAnnotationsWithHash(context.TODO(), epSlice.Namespace, epSlice.Name, "hash"); err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+			if count := countPatchActions(kubeClient); count != tt.expectPatch {
+				t.Errorf("expect %d patches, but got %d", tt.expectPatch, count)
+			}
+		})
+	}
+}
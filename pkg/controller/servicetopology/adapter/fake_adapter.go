@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var _ Adapter = &FakeAdapter{}
+
+// FakeAdapterCall is a call recorded by FakeAdapter.
+type FakeAdapterCall struct {
+	// Method is the name of the called method of Adapter, e.g. UpdateTriggerAnnotations
+	Method string
+	// Key is the namespace/name of the object or service, or the name of node. It is empty for GetEnqueueKeysByNodePool.
+	Key string
+	// Hash is the hash passed to UpdateTriggerAnnotationsWithHash
+	Hash string
+	// Nodes are the nodes passed to GetEnqueueKeysByNodePool
+	Nodes []string
+	// DryRun is whether the DryRun option is passed to the UpdateTriggerAnnotations and CleanupTriggerAnnotations methods
+	DryRun bool
+}
+
+// FakeAdapter is an Adapter for the tests of its consumers. It records the calls and returns the scripted keys
+// and errors, so the tests do not need to set up real adapters with fake clients.
+type FakeAdapter struct {
+	// KeysBySvc are returned by GetEnqueueKeysBySvc, keyed by the namespace/name of service
+	KeysBySvc map[string][]string
+	// KeysByNodePool are returned by GetEnqueueKeysByNodePool
+	KeysByNodePool []string
+	// KeysByNode are returned by GetEnqueueKeysByNode, keyed by the name of node
+	KeysByNode map[string][]string
+	// SlicesBySvc are returned by ResolveSlices, keyed by the namespace/name of service
+	SlicesBySvc map[string][]string
+	// Errors are returned by the UpdateTriggerAnnotations and CleanupTriggerAnnotations methods, keyed by the
+	// namespace/name of the object, or of the service for the BySvc methods
+	Errors map[string]error
+
+	mu    sync.Mutex
+	calls []FakeAdapterCall
+}
+
+// NewFakeAdapter returns a FakeAdapter which returns no keys and no errors.
+func NewFakeAdapter() *FakeAdapter {
+	return &FakeAdapter{
+		KeysBySvc:   make(map[string][]string),
+		KeysByNode:  make(map[string][]string),
+		SlicesBySvc: make(map[string][]string),
+		Errors:      make(map[string]error),
+	}
+}
+
+func (f *FakeAdapter) record(call FakeAdapterCall) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+// Calls returns the recorded calls in order.
+func (f *FakeAdapter) Calls() []FakeAdapterCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeAdapterCall(nil), f.calls...)
+}
+
+// CallsOf returns the recorded calls of the method in order.
+func (f *FakeAdapter) CallsOf(method string) []FakeAdapterCall {
+	var calls []FakeAdapterCall
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls, the scripted keys and errors are kept.
+func (f *FakeAdapter) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func isDryRun(opts []PatchOption) bool {
+	return len(newPatchOptions(opts).DryRun) != 0
+}
+
+func namespacedKey(namespace, name string) string {
+	return types.NamespacedName{Namespace: namespace, Name: name}.String()
+}
+
+func (f *FakeAdapter) GetEnqueueKeysBySvc(svc *corev1.Service) []string {
+	key := namespacedKey(svc.Namespace, svc.Name)
+	f.record(FakeAdapterCall{Method: "GetEnqueueKeysBySvc", Key: key})
+	return f.KeysBySvc[key]
+}
+
+func (f *FakeAdapter) ResolveSlices(_ context.Context, namespace, svcName string) ([]string, error) {
+	key := namespacedKey(namespace, svcName)
+	f.record(FakeAdapterCall{Method: "ResolveSlices", Key: key})
+	return f.SlicesBySvc[key], nil
+}
+
+func (f *FakeAdapter) UpdateTriggerAnnotations(_ context.Context, namespace, name string, opts ...PatchOption) error {
+	key := namespacedKey(namespace, name)
+	f.record(FakeAdapterCall{Method: "UpdateTriggerAnnotations", Key: key, DryRun: isDryRun(opts)})
+	return f.Errors[key]
+}
+
+func (f *FakeAdapter) UpdateTriggerAnnotationsWithHash(_ context.Context, namespace, name, hash string, opts ...PatchOption) error {
+	key := namespacedKey(namespace, name)
+	f.record(FakeAdapterCall{Method: "UpdateTriggerAnnotationsWithHash", Key: key, Hash: hash, DryRun: isDryRun(opts)})
+	return f.Errors[key]
+}
+
+func (f *FakeAdapter) UpdateTriggerAnnotationsBySvc(_ context.Context, namespace, svcName string, opts ...PatchOption) error {
+	key := namespacedKey(namespace, svcName)
+	f.record(FakeAdapterCall{Method: "UpdateTriggerAnnotationsBySvc", Key: key, DryRun: isDryRun(opts)})
+	return f.Errors[key]
+}
+
+func (f *FakeAdapter) CleanupTriggerAnnotations(_ context.Context, namespace, name string, opts ...PatchOption) error {
+	key := namespacedKey(namespace, name)
+	f.record(FakeAdapterCall{Method: "CleanupTriggerAnnotations", Key: key, DryRun: isDryRun(opts)})
+	return f.Errors[key]
+}
+
+func (f *FakeAdapter) CleanupTriggerAnnotationsBySvc(_ context.Context, namespace, svcName string, opts ...PatchOption) error {
+	key := namespacedKey(namespace, svcName)
+	f.record(FakeAdapterCall{Method: "CleanupTriggerAnnotationsBySvc", Key: key, DryRun: isDryRun(opts)})
+	return f.Errors[key]
+}
+
+func (f *FakeAdapter) GetEnqueueKeysByNodePool(_ context.Context, _ map[string]string, allNpNodes sets.String) []string {
+	f.record(FakeAdapterCall{Method: "GetEnqueueKeysByNodePool", Nodes: allNpNodes.List()})
+	return f.KeysByNodePool
+}
+
+func (f *FakeAdapter) GetEnqueueKeysByNode(_ context.Context, _ map[string]string, nodeName string) []string {
+	f.record(FakeAdapterCall{Method: "GetEnqueueKeysByNode", Key: nodeName})
+	return f.KeysByNode[nodeName]
+}
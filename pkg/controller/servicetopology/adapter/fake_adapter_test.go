@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestFakeAdapter(t *testing.T) {
+	errPatch := errors.New("patch failed")
+	f := NewFakeAdapter()
+	f.KeysBySvc["default/svc1"] = []string{"default/svc1-abcde"}
+	f.KeysByNodePool = []string{"default/svc2-abcde"}
+	f.KeysByNode["node1"] = []string{"default/svc3-abcde"}
+	f.SlicesBySvc["default/svc1"] = []string{"svc1-abcde"}
+	f.Errors["default/svc1-abcde"] = errPatch
+
+	if keys := f.GetEnqueueKeysBySvc(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"}}); !reflect.DeepEqual(keys, []string{"default/svc1-abcde"}) {
+		t.Errorf("expect scripted keys of service, but got %v", keys)
+	}
+	if keys := f.GetEnqueueKeysBySvc(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc0"}}); len(keys) != 0 {
+		t.Errorf("expect no keys of unknown service, but got %v", keys)
+	}
+	if keys := f.GetEnqueueKeysByNodePool(context.TODO(), nil, sets.NewString("node2", "node1")); !reflect.DeepEqual(keys, []string{"default/svc2-abcde"}) {
+		t.Errorf("expect scripted keys of nodepool, but got %v", keys)
+	}
+	if keys := f.GetEnqueueKeysByNode(context.TODO(), nil, "node1"); !reflect.DeepEqual(keys, []string{"default/svc3-abcde"}) {
+		t.Errorf("expect scripted keys of node, but got %v", keys)
+	}
+	if names, err := f.ResolveSlices(context.TODO(), "default", "svc1"); err != nil || !reflect.DeepEqual(names, []string{"svc1-abcde"}) {
+		t.Errorf("expect scripted endpointslices of service, but got %v and %v", names, err)
+	}
+	if err := f.UpdateTriggerAnnotations(context.TODO(), "default", "svc1-abcde"); err != errPatch {
+		t.Errorf("expect scripted error, but got %v", err)
+	}
+	if err := f.UpdateTriggerAnnotationsWithHash(context.TODO(), "default", "svc2-abcde", "hash1"); err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+	if err := f.UpdateTriggerAnnotationsBySvc(context.TODO(), "default", "svc1"); err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+	if err := f.CleanupTriggerAnnotations(context.TODO(), "default", "svc1-abcde"); err != errPatch {
+		t.Errorf("expect scripted error, but got %v", err)
+	}
+	if err := f.CleanupTriggerAnnotationsBySvc(context.TODO(), "default", "svc1", DryRun()); err != nil {
+		t.Errorf("expect no error, but got %v", err)
+	}
+
+	expect := []FakeAdapterCall{
+		{Method: "GetEnqueueKeysBySvc", Key: "default/svc1"},
+		{Method: "GetEnqueueKeysBySvc", Key: "default/svc0"},
+		{Method: "GetEnqueueKeysByNodePool", Nodes: []string{"node1", "node2"}},
+		{Method: "GetEnqueueKeysByNode", Key: "node1"},
+		{Method: "ResolveSlices", Key: "default/svc1"},
+		{Method: "UpdateTriggerAnnotations", Key: "default/svc1-abcde"},
+		{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc2-abcde", Hash: "hash1"},
+		{Method: "UpdateTriggerAnnotationsBySvc", Key: "default/svc1"},
+		{Method: "CleanupTriggerAnnotations", Key: "default/svc1-abcde"},
+		{Method: "CleanupTriggerAnnotationsBySvc", Key: "default/svc1", DryRun: true},
+	}
+	if calls := f.Calls(); !reflect.DeepEqual(calls, expect) {
+		t.Errorf("expect calls %v, but got %v", expect, calls)
+	}
+	if calls := f.CallsOf("GetEnqueueKeysBySvc"); len(calls) != 2 {
+		t.Errorf("expect 2 calls of GetEnqueueKeysBySvc, but got %v", calls)
+	}
+
+	f.Reset()
+	if calls := f.Calls(); len(calls) != 0 {
+		t.Errorf("expect no calls after reset, but got %v", calls)
+	}
+	if keys := f.GetEnqueueKeysByNode(context.TODO(), nil, "node1"); len(keys) != 1 {
+		t.Errorf("expect scripted keys are kept after reset, but got %v", keys)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// IndexerPathForServiceName indexes the endpointslices by the value of kubernetes.io/service-name label.
+	IndexerPathForServiceName = "metadata.labels.serviceName"
+	// IndexerPathForNodeName indexes the endpointslices by the nodes of their endpoints.
+	IndexerPathForNodeName = "endpoints.nodeName"
+)
+
+// RegisterFieldIndexers registers the field indexers used by the adapters. It must be called before the cache of
+// manager is started, and only the index of the endpointslice version served by the cluster is registered, the
+// same as NewAdapter picks the adapter. The index which has been registered by others is treated as success.
+func RegisterFieldIndexers(fi client.FieldIndexer, mapper meta.RESTMapper) error {
+	if _, err := mapper.KindFor(v1EndpointSliceGVR); err != nil {
+		if _, err := mapper.KindFor(v1beta1EndpointSliceGVR); err != nil {
+			return nil
+		}
+		return registerV1Beta1FieldIndexers(fi)
+	}
+
+	err := fi.IndexField(context.TODO(), &discoveryv1.EndpointSlice{}, IndexerPathForServiceName, func(rawObj client.Object) []string {
+		epSlice, ok := rawObj.(*discoveryv1.EndpointSlice)
+		if !ok {
+			return []string{}
+		}
+		if svcName, ok := epSlice.Labels[discoveryv1.LabelServiceName]; ok {
+			return []string{svcName}
+		}
+		return []string{}
+	})
+	if err != nil && !isIndexerConflict(err) {
+		return err
+	}
+
+	err = fi.IndexField(context.TODO(), &discoveryv1.EndpointSlice{}, IndexerPathForNodeName, func(rawObj client.Object) []string {
+		epSlice, ok := rawObj.(*discoveryv1.EndpointSlice)
+		if !ok {
+			return []string{}
+		}
+		return getNodesInEpSlice(epSlice).List()
+	})
+	if err != nil && !isIndexerConflict(err) {
+		return err
+	}
+	return nil
+}
+
+// registerV1Beta1FieldIndexers registers the index of node name for the v1beta1 endpointslices of old clusters.
+func registerV1Beta1FieldIndexers(fi client.FieldIndexer) error {
+	err := fi.IndexField(context.TODO(), &discoveryv1beta1.EndpointSlice{}, IndexerPathForNodeName, func(rawObj client.Object) []string {
+		epSlice, ok := rawObj.(*discoveryv1beta1.EndpointSlice)
+		if !ok {
+			return []string{}
+		}
+		return getNodesInEpSliceV1Beta1(epSlice).List()
+	})
+	if err != nil && !isIndexerConflict(err) {
+		return err
+	}
+	return nil
+}
+
+// isIndexerConflict checks whether the error is returned because the index has already been registered.
+func isIndexerConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "indexer conflict")
+}
+
+// isIndexMissing checks whether the error is returned by the cache because the index is not registered.
+func isIndexMissing(err error, indexerPath string) bool {
+	return err != nil && strings.Contains(err.Error(), fmt.Sprintf("Index with name field:%s does not exist", indexerPath))
+}
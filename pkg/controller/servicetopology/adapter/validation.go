@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// ErrMalformedObject is wrapped by the error of a trigger patch which is skipped because the object is malformed,
+// e.g. its ports are not resolved to numbers. Patching it again does not help, so callers should not retry.
+var ErrMalformedObject = errors.New("malformed object")
+
+var malformedObjects = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: "servicetopology",
+		Name:      "malformed_objects_total",
+		Help:      "counter of endpoints and endpointslices skipped by the trigger patches because they are malformed",
+	},
+	[]string{"kind"})
+
+func init() {
+	metrics.Registry.MustRegister(malformedObjects)
+}
+
+// validateEndpoints checks that every port of the subsets is resolved to a number, the named target ports of
+// service are resolved by the endpoints controller.
+func validateEndpoints(ep *corev1.Endpoints) error {
+	for i := range ep.Subsets {
+		for _, port := range ep.Subsets[i].Ports {
+			if port.Port == 0 {
+				return fmt.Errorf("port %q of subset %d is not resolved", port.Name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEndpointSliceV1 is the validateEndpoints of endpointslice v1.
+func validateEndpointSliceV1(epSlice *discoveryv1.EndpointSlice) error {
+	for _, port := range epSlice.Ports {
+		if port.Port == nil || *port.Port == 0 {
+			name := ""
+			if port.Name != nil {
+				name = *port.Name
+			}
+			return fmt.Errorf("port %q is not resolved", name)
+		}
+	}
+	return nil
+}
+
+// checkMalformed fetches the object and validates it before the trigger patch. The object missed by the cache is
+// fetched by get from the apiserver, since the objects listed from the apiserver before the cache is synced are
+// patched as well. The malformed object is logged and counted, and the returned error wraps ErrMalformedObject.
+// The NotFound error is wrapped as the one of patchWithRetry.
+func checkMalformed(ctx context.Context, c client.Client, kind, namespace, name string, obj client.Object, get func(context.Context) error, validate func() error) error {
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		callCtx, cancel := withCallTimeout(ctx)
+		err = contextError(callCtx, get(callCtx))
+		cancel()
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s %s/%s is not found, %w", kind, namespace, name, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := validate(); err != nil {
+		klog.Warningf("skip the trigger patch of malformed %s %s/%s: %v", kind, namespace, name, err)
+		malformedObjects.WithLabelValues(kind).Inc()
+		return fmt.Errorf("%s %s/%s is skipped, %w: %v", kind, namespace, name, ErrMalformedObject, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEndpointAdapterSkipMalformed(t *testing.T) {
+	tests := []struct {
+		name            string
+		ports           []corev1.EndpointPort
+		expectMalformed bool
+	}{
+		{name: "resolved ports", ports: []corev1.EndpointPort{{Name: "http", Port: 8080}}},
+		{name: "zero port", ports: []corev1.EndpointPort{{Name: "http", Port: 8080}, {Name: "metrics"}}, expectMalformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := getEndpoints("default", "svc1", "node1")
+			ep.Subsets[0].Ports = tt.ports
+			kubeClient := fake.NewSimpleClientset(ep)
+			adapter := NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(ep).Build())
+			before := testutil.ToFloat64(malformedObjects.WithLabelValues("endpoints"))
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), ep.Namespace, ep.Name)
+			if errors.Is(err, ErrMalformedObject) != tt.expectMalformed {
+				t.Errorf("expect malformed %v, but got %v", tt.expectMalformed, err)
+			}
+			expectPatches, expectCount := 1, before
+			if tt.expectMalformed {
+				expectPatches, expectCount = 0, before+1
+			}
+			if patches := countPatchActions(kubeClient); patches != expectPatches {
+				t.Errorf("expect %d patches, but got %d", expectPatches, patches)
+			}
+			if count := testutil.ToFloat64(malformedObjects.WithLabelValues("endpoints")); count != expectCount {
+				t.Errorf("expect malformed count %v, but got %v", expectCount, count)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceV1AdapterSkipMalformed(t *testing.T) {
+	tests := []struct {
+		name            string
+		ports           []discoveryv1.EndpointPort
+		expectMalformed bool
+	}{
+		{name: "resolved ports", ports: []discoveryv1.EndpointPort{{Name: pointer.StringPtr("http"), Port: pointer.Int32Ptr(8080)}}},
+		{name: "nil port", ports: []discoveryv1.EndpointPort{{Name: pointer.StringPtr("http")}}, expectMalformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epSlice := getEndpointSlice("default", "svc1", "node1")
+			epSlice.Ports = tt.ports
+			kubeClient := fake.NewSimpleClientset(epSlice)
+			adapter := NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().WithObjects(epSlice).Build(), nil)
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name)
+			if errors.Is(err, ErrMalformedObject) != tt.expectMalformed {
+				t.Errorf("expect malformed %v, but got %v", tt.expectMalformed, err)
+			}
+			expectPatches := 1
+			if tt.expectMalformed {
+				expectPatches = 0
+			}
+			if patches := countPatchActions(kubeClient); patches != expectPatches {
+				t.Errorf("expect %d patches, but got %d", expectPatches, patches)
+			}
+		})
+	}
+}
+
+func TestPatchConcurrentlySkipMalformed(t *testing.T) {
+	err := patchConcurrently(context.TODO(), []string{"a", "b"}, func(name string) error {
+		if name == "a" {
+			return ErrMalformedObject
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expect malformed objects are skipped, but got %v", err)
+	}
+}
+
+func TestEndpointAdapterPatchCacheMissed(t *testing.T) {
+	tests := []struct {
+		name            string
+		ports           []corev1.EndpointPort
+		expectMalformed bool
+	}{
+		{name: "resolved ports", ports: []corev1.EndpointPort{{Name: "http", Port: 8080}}},
+		{name: "zero port", ports: []corev1.EndpointPort{{Name: "http"}}, expectMalformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := getEndpoints("default", "svc1", "node1")
+			ep.Subsets[0].Ports = tt.ports
+			// the endpoints is listed from the apiserver before the cache is synced
+			kubeClient := fake.NewSimpleClientset(ep)
+			adapter := NewEndpointsAdapter(kubeClient, fakeclient.NewClientBuilder().Build())
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), ep.Namespace, ep.Name)
+			if tt.expectMalformed != errors.Is(err, ErrMalformedObject) || (!tt.expectMalformed && err != nil) {
+				t.Errorf("expect malformed %v, but got %v", tt.expectMalformed, err)
+			}
+			expectPatches := 1
+			if tt.expectMalformed {
+				expectPatches = 0
+			}
+			if patches := countPatchActions(kubeClient); patches != expectPatches {
+				t.Errorf("expect %d patches, but got %d", expectPatches, patches)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceV1AdapterPatchCacheMissed(t *testing.T) {
+	tests := []struct {
+		name            string
+		ports           []discoveryv1.EndpointPort
+		expectMalformed bool
+	}{
+		{name: "resolved ports", ports: []discoveryv1.EndpointPort{{Name: pointer.StringPtr("http"), Port: pointer.Int32Ptr(8080)}}},
+		{name: "nil port", ports: []discoveryv1.EndpointPort{{Name: pointer.StringPtr("http")}}, expectMalformed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epSlice := getEndpointSlice("default", "svc1", "node1")
+			epSlice.Ports = tt.ports
+			// the endpointslice is listed from the apiserver before the cache is synced
+			kubeClient := fake.NewSimpleClientset(epSlice)
+			adapter := NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().Build(), nil)
+
+			err := adapter.UpdateTriggerAnnotations(context.TODO(), epSlice.Namespace, epSlice.Name)
+			if tt.expectMalformed != errors.Is(err, ErrMalformedObject) || (!tt.expectMalformed && err != nil) {
+				t.Errorf("expect malformed %v, but got %v", tt.expectMalformed, err)
+			}
+			expectPatches := 1
+			if tt.expectMalformed {
+				expectPatches = 0
+			}
+			if patches := countPatchActions(kubeClient); patches != expectPatches {
+				t.Errorf("expect %d patches, but got %d", expectPatches, patches)
+			}
+		})
+	}
+}
+
+func TestPatchNotFound(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	adapter := NewEndpointsV1Adapter(kubeClient, fakeclient.NewClientBuilder().Build(), nil)
+	if err := adapter.UpdateTriggerAnnotations(context.TODO(), "default", "svc1-xad21"); !apierrors.IsNotFound(err) {
+		t.Errorf("expect not found, but got %v", err)
+	}
+}
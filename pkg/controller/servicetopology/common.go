@@ -16,6 +16,28 @@ limitations under the License.
 
 package servicetopology
 
+import (
+	"flag"
+
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+)
+
+func init() {
+	flag.BoolVar(&AuditOnly, "servicetopology-audit-only", AuditOnly, "Send the trigger patches of Servicetopology controllers in dry-run mode, so they are verified by the apiserver but never persisted.")
+	flag.DurationVar(&adapter.CallTimeout, "servicetopology-call-timeout", adapter.CallTimeout, "The timeout of every call of Servicetopology controllers to the apiserver, e.g. a trigger patch. No timeout is applied if it is not positive.")
+}
+
 const (
 	ControllerName = "servicetopology"
 )
+
+// AuditOnly makes the servicetopology controllers verify the trigger patches without mutating the endpoints and endpointslices.
+var AuditOnly = false
+
+// PatchOptions returns the options of trigger patches, the patches are sent in dry-run mode if auditOnly is true.
+func PatchOptions(auditOnly bool) []adapter.PatchOption {
+	if auditOnly {
+		return []adapter.PatchOption{adapter.DryRun()}
+	}
+	return nil
+}
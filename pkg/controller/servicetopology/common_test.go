@@ -0,0 +1,30 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicetopology
+
+import (
+	"testing"
+)
+
+func TestPatchOptions(t *testing.T) {
+	if opts := PatchOptions(false); len(opts) != 0 {
+		t.Errorf("expect no patch options, but got %d", len(opts))
+	}
+	if opts := PatchOptions(true); len(opts) != 1 {
+		t.Errorf("expect dry run option, but got %d options", len(opts))
+	}
+}
@@ -18,10 +18,12 @@ package endpoints
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -33,8 +35,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
 	common "github.com/openyurtio/openyurt/pkg/controller/servicetopology"
 	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/util"
 	utildiscovery "github.com/openyurtio/openyurt/pkg/util/discovery"
 )
 
@@ -68,12 +72,15 @@ var _ reconcile.Reconciler = &ReconcileServicetopologyEndpoints{}
 // ReconcileServicetopologyEndpoints reconciles a endpoints object
 type ReconcileServicetopologyEndpoints struct {
 	client.Client
+	kubeClient       kubernetes.Interface
 	endpointsAdapter adapter.Adapter
+	// auditOnly sends the trigger patches in dry-run mode
+	auditOnly bool
 }
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(_ *appconfig.CompletedConfig, mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileServicetopologyEndpoints{}
+	return &ReconcileServicetopologyEndpoints{auditOnly: common.AuditOnly}
 }
 
 func (r *ReconcileServicetopologyEndpoints) InjectClient(c client.Client) error {
@@ -87,7 +94,7 @@ func (r *ReconcileServicetopologyEndpoints) InjectConfig(cfg *rest.Config) error
 		klog.Errorf(Format("failed to create kube client, %v", err))
 		return err
 	}
-	r.endpointsAdapter = adapter.NewEndpointsAdapter(c, r.Client)
+	r.kubeClient = c
 	return nil
 }
 
@@ -99,9 +106,29 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// The adapter is created after the kube client and client are injected by controller.New
+	reconciler := r.(*ReconcileServicetopologyEndpoints)
+	reconciler.endpointsAdapter = adapter.NewEndpointsAdapter(reconciler.kubeClient, reconciler.Client)
+
 	// Watch for changes to Service
 	if err := c.Watch(&source.Kind{Type: &corev1.Service{}}, &EnqueueEndpointsForService{
-		endpointsAdapter: r.(*ReconcileServicetopologyEndpoints).endpointsAdapter,
+		endpointsAdapter: reconciler.endpointsAdapter,
+	}); err != nil {
+		return err
+	}
+
+	// Watch for changes to NodePool
+	if err := c.Watch(&source.Kind{Type: &appsv1beta1.NodePool{}}, &EnqueueEndpointsForNodePool{
+		endpointsAdapter: reconciler.endpointsAdapter,
+		client:           reconciler.Client,
+	}); err != nil {
+		return err
+	}
+
+	// Watch for changes to the nodepool label of Node
+	if err := c.Watch(&source.Kind{Type: &corev1.Node{}}, &EnqueueEndpointsForNode{
+		endpointsAdapter: reconciler.endpointsAdapter,
+		client:           reconciler.Client,
 	}); err != nil {
 		return err
 	}
@@ -111,10 +138,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch;patch
 
 // Reconcile reads that state of the cluster for endpoints object and makes changes based on the state read
-func (r *ReconcileServicetopologyEndpoints) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileServicetopologyEndpoints) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 
 	// Note !!!!!!!!!!
 	// We strongly recommend use Format() to  encapsulation because Format() can print logs by module
@@ -123,7 +151,7 @@ func (r *ReconcileServicetopologyEndpoints) Reconcile(_ context.Context, request
 
 	// Fetch the Endpoints instance
 	instance := &corev1.Endpoints{}
-	if err := r.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -131,7 +159,7 @@ func (r *ReconcileServicetopologyEndpoints) Reconcile(_ context.Context, request
 		return reconcile.Result{}, nil
 	}
 
-	if err := r.syncEndpoints(request.Namespace, request.Name); err != nil {
+	if err := r.syncEndpoints(ctx, instance); err != nil {
 		klog.Errorf(Format("sync endpoints %v failed with : %v", request.NamespacedName, err))
 		return reconcile.Result{Requeue: true}, err
 	}
@@ -139,6 +167,31 @@ func (r *ReconcileServicetopologyEndpoints) Reconcile(_ context.Context, request
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileServicetopologyEndpoints) syncEndpoints(namespace, name string) error {
-	return r.endpointsAdapter.UpdateTriggerAnnotations(namespace, name)
+func (r *ReconcileServicetopologyEndpoints) syncEndpoints(ctx context.Context, ep *corev1.Endpoints) error {
+	namespace, name := ep.Namespace, ep.Name
+	// The endpoints has the same name as the service, its trigger annotation is removed once the service does not
+	// use service topology anymore, which notifies yurthub as well.
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, svc); err == nil && !util.HasServiceTopology(svc) {
+		return client.IgnoreNotFound(r.endpointsAdapter.CleanupTriggerAnnotations(ctx, namespace, name, common.PatchOptions(r.auditOnly)...))
+	}
+
+	// The endpoints is only patched when the endpoints filtered by yurthub are changed
+	hash, err := util.TriggerHash(ctx, r.Client, util.ServiceTopologyType(svc), adapter.GetNodes(ep))
+	if err != nil {
+		return err
+	}
+	// The object is deleted after it is fetched, so there is nothing to update
+	if err := r.endpointsAdapter.UpdateTriggerAnnotationsWithHash(ctx, namespace, name, hash, common.PatchOptions(r.auditOnly)...); err != nil {
+		// The malformed endpoints is counted by the adapter, retrying does not fix it
+		if errors.Is(err, adapter.ErrMalformedObject) {
+			klog.Warningf(Format("skip malformed endpoints %s/%s: %v", namespace, name, err))
+			return nil
+		}
+		return client.IgnoreNotFound(err)
+	}
+	if r.auditOnly {
+		klog.Infof(Format("dry-run trigger patch of endpoints %s/%s is accepted", namespace, name))
+	}
+	return nil
 }
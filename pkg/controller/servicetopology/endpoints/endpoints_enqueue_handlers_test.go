@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
+)
+
+// fakeQueue records the requests added by the event handlers.
+type fakeQueue struct {
+	workqueue.RateLimitingInterface
+	requests []reconcile.Request
+}
+
+func (q *fakeQueue) AddRateLimited(item interface{}) {
+	q.requests = append(q.requests, item.(reconcile.Request))
+}
+
+func newService(topology string) *corev1.Service {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1", Annotations: map[string]string{}}}
+	if topology != "" {
+		svc.Annotations[servicetopology.AnnotationServiceTopologyKey] = topology
+	}
+	return svc
+}
+
+func TestEnqueueEndpointsForService(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldTopology string
+		newTopology string
+		expect      []reconcile.Request
+	}{
+		{
+			name:        "topology is changed",
+			newTopology: servicetopology.AnnotationServiceTopologyValueNodePool,
+			expect:      []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1"}}},
+		},
+		{
+			name:        "topology is not changed",
+			oldTopology: servicetopology.AnnotationServiceTopologyValueNodePool,
+			newTopology: servicetopology.AnnotationServiceTopologyValueNodePool,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAdapter := adapter.NewFakeAdapter()
+			fakeAdapter.KeysBySvc["default/svc1"] = []string{"default/svc1", "invalid/key/format"}
+			handler := &EnqueueEndpointsForService{endpointsAdapter: fakeAdapter}
+			q := &fakeQueue{}
+
+			handler.Update(event.UpdateEvent{ObjectOld: newService(tt.oldTopology), ObjectNew: newService(tt.newTopology)}, q)
+			if !reflect.DeepEqual(q.requests, tt.expect) {
+				t.Errorf("expect requests %v, but got %v", tt.expect, q.requests)
+			}
+			if calls := fakeAdapter.CallsOf("GetEnqueueKeysBySvc"); len(calls) != len(tt.expect) {
+				t.Errorf("expect %d calls of GetEnqueueKeysBySvc, but got %v", len(tt.expect), calls)
+			}
+		})
+	}
+}
+
+func TestEnqueueEndpointsForNodePool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeAdapter := adapter.NewFakeAdapter()
+	fakeAdapter.KeysByNodePool = []string{"default/svc1"}
+	handler := &EnqueueEndpointsForNodePool{
+		endpointsAdapter: fakeAdapter,
+		client:           fake.NewClientBuilder().WithScheme(scheme).WithObjects(newService(servicetopology.AnnotationServiceTopologyValueNodePool)).Build(),
+	}
+	q := &fakeQueue{}
+
+	oldNp := &appsv1beta1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}, Status: appsv1beta1.NodePoolStatus{Nodes: []string{"node1"}}}
+	newNp := oldNp.DeepCopy()
+	newNp.Status.Nodes = []string{"node2"}
+	handler.Update(event.UpdateEvent{ObjectOld: oldNp, ObjectNew: newNp}, q)
+
+	expect := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1"}}}
+	if !reflect.DeepEqual(q.requests, expect) {
+		t.Errorf("expect requests %v, but got %v", expect, q.requests)
+	}
+	calls := fakeAdapter.CallsOf("GetEnqueueKeysByNodePool")
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0].Nodes, []string{"node1", "node2"}) {
+		t.Errorf("expect the nodes of old and new nodepool, but got %v", calls)
+	}
+}
+
+func TestEnqueueEndpointsForNode(t *testing.T) {
+	newNode := func(pool string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{}}}
+		if pool != "" {
+			node.Labels[appsv1alpha1.LabelCurrentNodePool] = pool
+		}
+		return node
+	}
+	tests := []struct {
+		name    string
+		oldPool string
+		newPool string
+		expect  []reconcile.Request
+	}{
+		{
+			name:    "node is moved into another nodepool",
+			oldPool: "hangzhou",
+			newPool: "beijing",
+			expect:  []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1"}}},
+		},
+		{
+			name:    "node joins a nodepool",
+			newPool: "beijing",
+			expect:  []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1"}}},
+		},
+		{
+			name:    "nodepool is not changed",
+			oldPool: "hangzhou",
+			newPool: "hangzhou",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAdapter := adapter.NewFakeAdapter()
+			fakeAdapter.KeysByNode["node1"] = []string{"default/svc1", "invalid/key/format"}
+			handler := &EnqueueEndpointsForNode{endpointsAdapter: fakeAdapter, client: fake.NewClientBuilder().Build()}
+			q := &fakeQueue{}
+
+			handler.Update(event.UpdateEvent{ObjectOld: newNode(tt.oldPool), ObjectNew: newNode(tt.newPool)}, q)
+			if !reflect.DeepEqual(q.requests, tt.expect) {
+				t.Errorf("expect requests %v, but got %v", tt.expect, q.requests)
+			}
+			if calls := fakeAdapter.CallsOf("GetEnqueueKeysByNode"); len(calls) != len(tt.expect) {
+				t.Errorf("expect %d calls of GetEnqueueKeysByNode, but got %v", len(tt.expect), calls)
+			}
+		})
+	}
+}
@@ -18,13 +18,18 @@ package endpointslice
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"strings"
+	"sync/atomic"
 
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -36,14 +41,19 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appconfig "github.com/openyurtio/openyurt/cmd/yurt-manager/app/config"
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
 	common "github.com/openyurtio/openyurt/pkg/controller/servicetopology"
 	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/util"
 )
 
 func init() {
 	flag.IntVar(&concurrentReconciles, "servicetopology-endpointslice-workers", concurrentReconciles, "Max concurrent workers for Servicetopology-endpointslice controller.")
 }
 
+// serviceRequestPrefix prefixes the names of service requests, see newServiceRequest.
+const serviceRequestPrefix = "svc/"
+
 var (
 	concurrentReconciles = 3
 	v1EndpointSliceGVR   = discoveryv1.SchemeGroupVersion.WithResource("endpointslices")
@@ -57,17 +67,29 @@ func Format(format string, args ...interface{}) string {
 // Add creates a new Servicetopology endpointslice Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(_ *appconfig.CompletedConfig, mgr manager.Manager) error {
-	r := &ReconcileServiceTopologyEndpointSlice{}
+	r := &ReconcileServiceTopologyEndpointSlice{auditOnly: common.AuditOnly}
 	c, err := controller.New(fmt.Sprintf("%s-endpointslice", common.ControllerName), mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentReconciles})
 	if err != nil {
 		return err
 	}
 
-	if r.isSupportEndpointslicev1 {
-		r.endpointsliceAdapter = adapter.NewEndpointsV1Adapter(r.kubeClient, r.Client)
-	} else {
-		r.endpointsliceAdapter = adapter.NewEndpointsV1Beta1Adapter(r.kubeClient, r.Client)
+	// The adapter lists endpointslices from the cache, so it is told whether the cache has been synced
+	var cacheSynced int32
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if mgr.GetCache().WaitForCacheSync(ctx) {
+			atomic.StoreInt32(&cacheSynced, 1)
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+	// The endpointslices of a service are listed by the index of service name
+	if err := adapter.RegisterFieldIndexers(mgr.GetFieldIndexer(), mgr.GetRESTMapper()); err != nil {
+		return err
 	}
+	r.endpointsliceAdapter = adapter.NewAdapter(r.kubeClient, r.Client, mgr.GetRESTMapper(), func() bool {
+		return atomic.LoadInt32(&cacheSynced) == 1
+	})
 
 	// Watch for changes to Service
 	if err := c.Watch(&source.Kind{Type: &corev1.Service{}}, &EnqueueEndpointsliceForService{
@@ -76,6 +98,22 @@ func Add(_ *appconfig.CompletedConfig, mgr manager.Manager) error {
 		return err
 	}
 
+	// Watch for changes to NodePool
+	if err := c.Watch(&source.Kind{Type: &appsv1beta1.NodePool{}}, &EnqueueEndpointsliceForNodePool{
+		endpointsliceAdapter: r.endpointsliceAdapter,
+		client:               r.Client,
+	}); err != nil {
+		return err
+	}
+
+	// Watch for changes to the nodepool label of Node
+	if err := c.Watch(&source.Kind{Type: &corev1.Node{}}, &EnqueueEndpointsliceForNode{
+		endpointsliceAdapter: r.endpointsliceAdapter,
+		client:               r.Client,
+	}); err != nil {
+		return err
+	}
+
 	klog.Infof("%s-endpointslice controller is added", common.ControllerName)
 	return nil
 }
@@ -88,6 +126,8 @@ type ReconcileServiceTopologyEndpointSlice struct {
 	kubeClient               kubernetes.Interface
 	endpointsliceAdapter     adapter.Adapter
 	isSupportEndpointslicev1 bool
+	// auditOnly sends the trigger patches in dry-run mode
+	auditOnly bool
 }
 
 func (r *ReconcileServiceTopologyEndpointSlice) InjectConfig(cfg *rest.Config) error {
@@ -119,36 +159,31 @@ func (r *ReconcileServiceTopologyEndpointSlice) InjectClient(c client.Client) er
 
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;patch
 
 // Reconcile reads that state of the cluster for endpointslice object and makes changes based on the state read
-func (r *ReconcileServiceTopologyEndpointSlice) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileServiceTopologyEndpointSlice) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 
 	// Note !!!!!!!!!!
 	// We strongly recommend use Format() to  encapsulation because Format() can print logs by module
 	// @kadisi
 	klog.Infof(Format("Reconcile Endpointslice %s/%s", request.Namespace, request.Name))
 
+	if svcKey, ok := parseServiceRequest(request); ok {
+		return r.reconcileService(ctx, svcKey)
+	}
+
 	// Fetch the Endpointslice instance
-	if r.isSupportEndpointslicev1 {
-		instance := &discoveryv1.EndpointSlice{}
-		if err := r.Get(context.TODO(), request.NamespacedName, instance); err != nil {
-			return ctrl.Result{}, client.IgnoreNotFound(err)
-		}
-		if instance.DeletionTimestamp != nil {
-			return reconcile.Result{}, nil
-		}
-	} else {
-		instance := &discoveryv1beta1.EndpointSlice{}
-		if err := r.Get(context.TODO(), request.NamespacedName, instance); err != nil {
-			return ctrl.Result{}, client.IgnoreNotFound(err)
-		}
-		if instance.DeletionTimestamp != nil {
-			return reconcile.Result{}, nil
-		}
+	instance, err := r.getEndpointslice(ctx, request)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if instance == nil {
+		return reconcile.Result{}, nil
 	}
 
-	if err := r.syncEndpointslice(request.Namespace, request.Name); err != nil {
+	if err := r.syncEndpointslice(ctx, instance); err != nil {
 		klog.Errorf(Format("sync endpointslice %v failed with : %v", request.NamespacedName, err))
 		return reconcile.Result{Requeue: true}, err
 	}
@@ -156,6 +191,93 @@ func (r *ReconcileServiceTopologyEndpointSlice) Reconcile(_ context.Context, req
 	return reconcile.Result{}, nil
 }
 
-func (r *ReconcileServiceTopologyEndpointSlice) syncEndpointslice(namespace, name string) error {
-	return r.endpointsliceAdapter.UpdateTriggerAnnotations(namespace, name)
+// newServiceRequest returns the request of a service whose topology configuration is changed. The services and the
+// endpointslices share the queue, so the name of service is prefixed with serviceRequestPrefix. The names of objects
+// never contain "/", so the request is not mistaken for an endpointslice named like the service.
+func newServiceRequest(namespace, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: serviceRequestPrefix + name}}
+}
+
+// parseServiceRequest returns the service of the request created by newServiceRequest.
+func parseServiceRequest(request reconcile.Request) (types.NamespacedName, bool) {
+	if !strings.HasPrefix(request.Name, serviceRequestPrefix) {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: request.Namespace, Name: strings.TrimPrefix(request.Name, serviceRequestPrefix)}, true
+}
+
+// getEndpointslice returns the endpointslice of the version served by the cluster, and nil if it does not exist or is
+// being deleted.
+func (r *ReconcileServiceTopologyEndpointSlice) getEndpointslice(ctx context.Context, request reconcile.Request) (client.Object, error) {
+	var instance client.Object = &discoveryv1beta1.EndpointSlice{}
+	if r.isSupportEndpointslicev1 {
+		instance = &discoveryv1.EndpointSlice{}
+	}
+	if err := r.Get(ctx, request.NamespacedName, instance); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if instance.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+	return instance, nil
+}
+
+// reconcileService updates all endpointslices of the service in one batch, the endpointslices are resolved by the
+// adapter now rather than when the service is enqueued, so the request of a service is not outdated by the churn.
+func (r *ReconcileServiceTopologyEndpointSlice) reconcileService(ctx context.Context, svcKey types.NamespacedName) (reconcile.Result, error) {
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, svcKey, svc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if svc.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	// Removing the trigger annotations also notifies yurthub, so the endpointslices of the service which does not
+	// use service topology anymore are restored instead of being triggered again.
+	if !util.HasServiceTopology(svc) {
+		if err := r.endpointsliceAdapter.CleanupTriggerAnnotationsBySvc(ctx, svc.Namespace, svc.Name, common.PatchOptions(r.auditOnly)...); err != nil {
+			klog.Errorf(Format("cleanup trigger annotations of endpointslices of service %v failed with : %v", svcKey, err))
+			return reconcile.Result{Requeue: true}, err
+		}
+		return reconcile.Result{}, nil
+	}
+	if err := r.endpointsliceAdapter.UpdateTriggerAnnotationsBySvc(ctx, svc.Namespace, svc.Name, common.PatchOptions(r.auditOnly)...); err != nil {
+		klog.Errorf(Format("sync endpointslices of service %v failed with : %v", svcKey, err))
+		return reconcile.Result{Requeue: true}, err
+	}
+	if r.auditOnly {
+		klog.Infof(Format("dry-run trigger patches of endpointslices of service %v are accepted", svcKey))
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileServiceTopologyEndpointSlice) syncEndpointslice(ctx context.Context, epSlice client.Object) error {
+	namespace, name := epSlice.GetNamespace(), epSlice.GetName()
+	// The endpointslice is only patched when the endpoints filtered by yurthub are changed. The service label has the
+	// same key in both versions of endpointslice.
+	svc := &corev1.Service{}
+	if svcName := epSlice.GetLabels()[discoveryv1.LabelServiceName]; svcName != "" {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: svcName}, svc); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	hash, err := util.TriggerHash(ctx, r.Client, util.ServiceTopologyType(svc), adapter.GetNodes(epSlice))
+	if err != nil {
+		return err
+	}
+	// The object is deleted after it is fetched, so there is nothing to update
+	if err := r.endpointsliceAdapter.UpdateTriggerAnnotationsWithHash(ctx, namespace, name, hash, common.PatchOptions(r.auditOnly)...); err != nil {
+		// The malformed endpointslice is counted by the adapter, retrying does not fix it
+		if errors.Is(err, adapter.ErrMalformedObject) {
+			klog.Warningf(Format("skip malformed endpointslice %s/%s: %v", namespace, name, err))
+			return nil
+		}
+		return client.IgnoreNotFound(err)
+	}
+	if r.auditOnly {
+		klog.Infof(Format("dry-run trigger patch of endpointslice %s/%s is accepted", namespace, name))
+	}
+	return nil
 }
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/util"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
+)
+
+func TestReconcile(t *testing.T) {
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "svc1",
+		Annotations: map[string]string{servicetopology.AnnotationServiceTopologyKey: servicetopology.AnnotationServiceTopologyValueNodePool},
+	}}
+	// the topology annotation of svc3 has been removed
+	plainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc3"}}
+	nodeName := "node1"
+	epSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1-abcde", Labels: map[string]string{discoveryv1.LabelServiceName: "svc1"}},
+		Endpoints:  []discoveryv1.Endpoint{{NodeName: &nodeName}},
+	}
+	nodePool := &appsv1beta1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}, Status: appsv1beta1.NodePoolStatus{Nodes: []string{nodeName}}}
+	// a user managed endpointslice named exactly like the service
+	namesakeSlice := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"}}
+	notFound := apierrors.NewNotFound(discoveryv1.Resource("endpointslices"), "svc1-abcde")
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = appsv1beta1.AddToScheme(scheme)
+	newClient := func() client.Client {
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(svc, plainSvc, epSlice, namesakeSlice, nodePool).Build()
+	}
+	// the trigger annotation is set to the hash of the nodepools of endpoints and the topology of service
+	getHash := func(topologyType string, nodes ...string) string {
+		hash, err := util.TriggerHash(context.TODO(), newClient(), topologyType, sets.NewString(nodes...))
+		if err != nil {
+			t.Fatalf("failed to get trigger hash, %v", err)
+		}
+		return hash
+	}
+	epSliceHash := getHash(servicetopology.AnnotationServiceTopologyValueNodePool, nodeName)
+	namesakeHash := getHash("")
+
+	tests := []struct {
+		name        string
+		request     string
+		errors      map[string]error
+		auditOnly   bool
+		expectCalls []adapter.FakeAdapterCall
+		expectError bool
+	}{
+		{
+			name:        "endpointslice is updated",
+			request:     "svc1-abcde",
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc1-abcde", Hash: epSliceHash}},
+		},
+		{
+			name:        "endpointslice is deleted in the meantime",
+			request:     "svc1-abcde",
+			errors:      map[string]error{"default/svc1-abcde": fmt.Errorf("endpointslice default/svc1-abcde is not found, %w", notFound)},
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc1-abcde", Hash: epSliceHash}},
+		},
+		{
+			name:        "endpointslice fails to be updated",
+			request:     "svc1-abcde",
+			errors:      map[string]error{"default/svc1-abcde": errors.New("patch failed")},
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc1-abcde", Hash: epSliceHash}},
+			expectError: true,
+		},
+		{
+			name:        "endpointslices of service are updated",
+			request:     "svc/svc1",
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsBySvc", Key: "default/svc1"}},
+		},
+		{
+			name:        "endpointslices of service fail to be updated",
+			request:     "svc/svc1",
+			errors:      map[string]error{"default/svc1": errors.New("patch failed")},
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsBySvc", Key: "default/svc1"}},
+			expectError: true,
+		},
+		{
+			name:        "endpointslice is verified in audit only mode",
+			request:     "svc1-abcde",
+			auditOnly:   true,
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc1-abcde", Hash: epSliceHash, DryRun: true}},
+		},
+		{
+			name:        "endpointslices of service are verified in audit only mode",
+			request:     "svc/svc1",
+			auditOnly:   true,
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsBySvc", Key: "default/svc1", DryRun: true}},
+		},
+		{
+			name:        "trigger annotations of service without topology are cleaned up",
+			request:     "svc/svc3",
+			expectCalls: []adapter.FakeAdapterCall{{Method: "CleanupTriggerAnnotationsBySvc", Key: "default/svc3"}},
+		},
+		{
+			name:        "trigger annotations of service without topology fail to be cleaned up",
+			request:     "svc/svc3",
+			errors:      map[string]error{"default/svc3": errors.New("patch failed")},
+			expectCalls: []adapter.FakeAdapterCall{{Method: "CleanupTriggerAnnotationsBySvc", Key: "default/svc3"}},
+			expectError: true,
+		},
+		{
+			name:        "endpointslice named like its service is updated alone",
+			request:     "svc1",
+			expectCalls: []adapter.FakeAdapterCall{{Method: "UpdateTriggerAnnotationsWithHash", Key: "default/svc1", Hash: namesakeHash}},
+		},
+		{
+			name:    "endpointslice does not exist",
+			request: "svc2",
+		},
+		{
+			name:    "service does not exist",
+			request: "svc/svc2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAdapter := adapter.NewFakeAdapter()
+			for key, err := range tt.errors {
+				fakeAdapter.Errors[key] = err
+			}
+			r := &ReconcileServiceTopologyEndpointSlice{
+				Client:                   newClient(),
+				endpointsliceAdapter:     fakeAdapter,
+				isSupportEndpointslicev1: true,
+				auditOnly:                tt.auditOnly,
+			}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: tt.request}})
+			if tt.expectError != (err != nil) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, err)
+			}
+			if calls := fakeAdapter.Calls(); !reflect.DeepEqual(calls, tt.expectCalls) {
+				t.Errorf("expect calls %v, but got %v", tt.expectCalls, calls)
+			}
+		})
+	}
+}
@@ -17,14 +17,19 @@ limitations under the License.
 package endpointslice
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
 	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
 	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/util"
 )
@@ -68,9 +73,79 @@ func (e *EnqueueEndpointsliceForService) Generic(evt event.GenericEvent,
 	q workqueue.RateLimitingInterface) {
 }
 
+// enqueueEndpointsliceForSvc enqueues the service-scoped keys of the service, so that all endpointslices of the
+// service are resolved and updated in one batch when the key is processed, instead of one request per endpointslice.
 func (e *EnqueueEndpointsliceForService) enqueueEndpointsliceForSvc(newSvc *corev1.Service, q workqueue.RateLimitingInterface) {
 	keys := e.endpointsliceAdapter.GetEnqueueKeysBySvc(newSvc)
-	klog.Infof(Format("the topology configuration of svc %s/%s is changed, enqueue endpointslices: %v", newSvc.Namespace, newSvc.Name, keys))
+	klog.Infof(Format("the topology configuration of svc %s/%s is changed, enqueue the service to update its endpointslices: %v", newSvc.Namespace, newSvc.Name, keys))
+	for _, key := range keys {
+		ns, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			klog.Errorf("failed to split key %s, %v", key, err)
+			continue
+		}
+		q.AddRateLimited(newServiceRequest(ns, name))
+	}
+}
+
+type EnqueueEndpointsliceForNodePool struct {
+	endpointsliceAdapter adapter.Adapter
+	client               client.Client
+}
+
+// Create implements EventHandler
+func (e *EnqueueEndpointsliceForNodePool) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// Update implements EventHandler
+func (e *EnqueueEndpointsliceForNodePool) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	oldNp, ok := evt.ObjectOld.(*appsv1beta1.NodePool)
+	if !ok {
+		klog.Errorf(Format("Fail to assert runtime Object(%s) to v1beta1.NodePool",
+			evt.ObjectOld.GetName()))
+		return
+	}
+	newNp, ok := evt.ObjectNew.(*appsv1beta1.NodePool)
+	if !ok {
+		klog.Errorf(Format("Fail to assert runtime Object(%s) to v1beta1.NodePool",
+			evt.ObjectNew.GetName()))
+		return
+	}
+	if allNpNodes, changed := util.NodePoolNodesChanged(oldNp, newNp); changed {
+		e.enqueueEndpointsliceForNodePool(newNp.Name, allNpNodes, q)
+	}
+}
+
+// Delete implements EventHandler
+func (e *EnqueueEndpointsliceForNodePool) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+	np, ok := evt.Object.(*appsv1beta1.NodePool)
+	if !ok {
+		klog.Errorf(Format("Fail to assert runtime Object(%s) to v1beta1.NodePool",
+			evt.Object.GetName()))
+		return
+	}
+	e.enqueueEndpointsliceForNodePool(np.Name, sets.NewString(np.Status.Nodes...), q)
+}
+
+// Generic implements EventHandler
+func (e *EnqueueEndpointsliceForNodePool) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+func (e *EnqueueEndpointsliceForNodePool) enqueueEndpointsliceForNodePool(npName string, allNpNodes sets.String, q workqueue.RateLimitingInterface) {
+	// The event handlers are not given a context, so the lists are not canceled
+	ctx := context.TODO()
+	svcTopologyTypes, err := util.GetSvcTopologyTypes(ctx, e.client)
+	if err != nil {
+		klog.Errorf(Format("failed to get topology types of services, %v", err))
+		return
+	}
+
+	keys := e.endpointsliceAdapter.GetEnqueueKeysByNodePool(ctx, svcTopologyTypes, allNpNodes)
+	klog.Infof(Format("the nodes of nodepool %s are changed, enqueue endpointslice: %v", npName, keys))
 	for _, key := range keys {
 		ns, name, err := cache.SplitMetaNamespaceKey(key)
 		if err != nil {
@@ -82,3 +157,66 @@ func (e *EnqueueEndpointsliceForService) enqueueEndpointsliceForSvc(newSvc *core
 		})
 	}
 }
+
+// EnqueueEndpointsliceForNode enqueues the endpointslice referencing the node when the node is moved into another nodepool,
+// since the nodepool of the endpoints on the node is changed while the nodepools are not updated yet.
+type EnqueueEndpointsliceForNode struct {
+	endpointsliceAdapter adapter.Adapter
+	client               client.Client
+}
+
+// Create implements EventHandler
+func (e *EnqueueEndpointsliceForNode) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// Update implements EventHandler
+func (e *EnqueueEndpointsliceForNode) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	oldNode, ok := evt.ObjectOld.(*corev1.Node)
+	if !ok {
+		klog.Errorf(Format("Fail to assert runtime Object(%s) to v1.Node",
+			evt.ObjectOld.GetName()))
+		return
+	}
+	newNode, ok := evt.ObjectNew.(*corev1.Node)
+	if !ok {
+		klog.Errorf(Format("Fail to assert runtime Object(%s) to v1.Node",
+			evt.ObjectNew.GetName()))
+		return
+	}
+	if !util.NodePoolLabelChanged(oldNode, newNode) {
+		return
+	}
+
+	// The event handlers are not given a context, so the lists are not canceled
+	ctx := context.TODO()
+	svcTopologyTypes, err := util.GetSvcTopologyTypes(ctx, e.client)
+	if err != nil {
+		klog.Errorf(Format("failed to get topology types of services, %v", err))
+		return
+	}
+
+	keys := e.endpointsliceAdapter.GetEnqueueKeysByNode(ctx, svcTopologyTypes, newNode.Name)
+	klog.Infof(Format("the nodepool of node %s is changed, enqueue endpointslice: %v", newNode.Name, keys))
+	for _, key := range keys {
+		ns, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			klog.Errorf("failed to split key %s, %v", key, err)
+			continue
+		}
+		q.AddRateLimited(reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: ns, Name: name},
+		})
+	}
+}
+
+// Delete implements EventHandler
+func (e *EnqueueEndpointsliceForNode) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+}
+
+// Generic implements EventHandler
+func (e *EnqueueEndpointsliceForNode) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+}
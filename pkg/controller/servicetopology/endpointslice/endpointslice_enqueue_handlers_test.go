@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/controller/servicetopology/adapter"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
+)
+
+// fakeQueue records the requests added by the event handlers.
+type fakeQueue struct {
+	workqueue.RateLimitingInterface
+	requests []reconcile.Request
+}
+
+func (q *fakeQueue) AddRateLimited(item interface{}) {
+	q.requests = append(q.requests, item.(reconcile.Request))
+}
+
+func TestEnqueueEndpointsliceForServiceWithManySlices(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	oldSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc1"}}
+	newSvc := oldSvc.DeepCopy()
+	newSvc.Annotations = map[string]string{servicetopology.AnnotationServiceTopologyKey: servicetopology.AnnotationServiceTopologyValueNodePool}
+
+	objs := []client.Object{newSvc}
+	var kubeObjs []runtime.Object
+	for i := 0; i < 200; i++ {
+		epSlice := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      fmt.Sprintf("svc1-%d", i),
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "svc1"},
+		}}
+		objs = append(objs, epSlice)
+		kubeObjs = append(kubeObjs, epSlice)
+	}
+	kubeClient := kubefake.NewSimpleClientset(kubeObjs...)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	sliceAdapter := adapter.NewEndpointsV1Adapter(kubeClient, c, nil)
+
+	// the service with 200 endpointslices is enqueued by one key
+	q := &fakeQueue{}
+	handler := &EnqueueEndpointsliceForService{endpointsliceAdapter: sliceAdapter}
+	handler.Update(event.UpdateEvent{ObjectOld: oldSvc, ObjectNew: newSvc}, q)
+	expect := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc/svc1"}}}
+	if !reflect.DeepEqual(q.requests, expect) {
+		t.Fatalf("expect requests %v, but got %v", expect, q.requests)
+	}
+
+	// processing the key still patches every endpointslice
+	r := &ReconcileServiceTopologyEndpointSlice{
+		Client:                   c,
+		endpointsliceAdapter:     sliceAdapter,
+		isSupportEndpointslicev1: true,
+	}
+	if _, err := r.Reconcile(context.TODO(), q.requests[0]); err != nil {
+		t.Fatalf("failed to reconcile, %v", err)
+	}
+	patched := 0
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "patch" {
+			patched++
+		}
+	}
+	if patched != 200 {
+		t.Errorf("expect 200 endpointslices are patched, but got %d", patched)
+	}
+}
+
+func TestEnqueueEndpointsliceForNode(t *testing.T) {
+	newNode := func(pool string) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{}}}
+		if pool != "" {
+			node.Labels[appsv1alpha1.LabelCurrentNodePool] = pool
+		}
+		return node
+	}
+	tests := []struct {
+		name    string
+		oldPool string
+		newPool string
+		expect  []reconcile.Request
+	}{
+		{
+			name:    "node is moved into another nodepool",
+			oldPool: "hangzhou",
+			newPool: "beijing",
+			expect:  []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1-abcde"}}},
+		},
+		{
+			name:    "node joins a nodepool",
+			newPool: "beijing",
+			expect:  []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "svc1-abcde"}}},
+		},
+		{
+			name:    "nodepool is not changed",
+			oldPool: "hangzhou",
+			newPool: "hangzhou",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAdapter := adapter.NewFakeAdapter()
+			fakeAdapter.KeysByNode["node1"] = []string{"default/svc1-abcde", "invalid/key/format"}
+			handler := &EnqueueEndpointsliceForNode{endpointsliceAdapter: fakeAdapter, client: fake.NewClientBuilder().Build()}
+			q := &fakeQueue{}
+
+			handler.Update(event.UpdateEvent{ObjectOld: newNode(tt.oldPool), ObjectNew: newNode(tt.newPool)}, q)
+			if !reflect.DeepEqual(q.requests, tt.expect) {
+				t.Errorf("expect requests %v, but got %v", tt.expect, q.requests)
+			}
+			if calls := fakeAdapter.CallsOf("GetEnqueueKeysByNode"); len(calls) != len(tt.expect) {
+				t.Errorf("expect %d calls of GetEnqueueKeysByNode, but got %v", len(tt.expect), calls)
+			}
+		})
+	}
+}
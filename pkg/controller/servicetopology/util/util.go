@@ -17,8 +17,19 @@ limitations under the License.
 package util
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	appsv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
 	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
 )
 
@@ -30,3 +41,77 @@ func ServiceTopologyTypeChanged(oldSvc, newSvc *corev1.Service) bool {
 	}
 	return true
 }
+
+// HasServiceTopology checks whether the service is configured with service topology annotation.
+func HasServiceTopology(svc *corev1.Service) bool {
+	_, ok := svc.Annotations[servicetopology.AnnotationServiceTopologyKey]
+	return ok
+}
+
+// ServiceTopologyType returns the topology type of service, which is empty if it is not configured.
+func ServiceTopologyType(svc *corev1.Service) string {
+	return svc.Annotations[servicetopology.AnnotationServiceTopologyKey]
+}
+
+// GetSvcTopologyTypes returns the topology types of all services which are configured with
+// service topology annotation, and the map is keyed by service namespace/name.
+func GetSvcTopologyTypes(ctx context.Context, c client.Client) (map[string]string, error) {
+	svcTopologyTypes := make(map[string]string)
+	svcList := &corev1.ServiceList{}
+	if err := c.List(ctx, svcList); err != nil {
+		return nil, err
+	}
+
+	for _, svc := range svcList.Items {
+		topologyType, ok := svc.Annotations[servicetopology.AnnotationServiceTopologyKey]
+		if !ok {
+			continue
+		}
+		key := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}.String()
+		svcTopologyTypes[key] = topologyType
+	}
+	return svcTopologyTypes, nil
+}
+
+// NodePoolNodesChanged returns the union of nodes in the old and new nodepool if the nodes of nodepool are changed.
+func NodePoolNodesChanged(oldNp, newNp *appsv1beta1.NodePool) (sets.String, bool) {
+	oldNpNodes := sets.NewString(oldNp.Status.Nodes...)
+	newNpNodes := sets.NewString(newNp.Status.Nodes...)
+	if oldNpNodes.Equal(newNpNodes) {
+		return nil, false
+	}
+	return oldNpNodes.Union(newNpNodes), true
+}
+
+// NodePoolLabelChanged checks whether the node is moved into another nodepool, i.e. its nodepool label is changed.
+func NodePoolLabelChanged(oldNode, newNode *corev1.Node) bool {
+	return oldNode.Labels[appsv1alpha1.LabelCurrentNodePool] != newNode.Labels[appsv1alpha1.LabelCurrentNodePool]
+}
+
+// TriggerHash returns the hash of the state which yurthub filters the endpoints of a service by, i.e. the topology type
+// of the service and the nodes of every nodepool among the nodes referenced by the endpoints. The trigger annotation is
+// set to the hash, so the object is not patched again until the endpoints filtered on the edge are changed.
+func TriggerHash(ctx context.Context, c client.Client, topologyType string, nodes sets.String) (string, error) {
+	npList := &appsv1beta1.NodePoolList{}
+	if err := c.List(ctx, npList); err != nil {
+		return "", err
+	}
+
+	nodePoolNodes := make(map[string][]string)
+	for _, np := range npList.Items {
+		if poolNodes := nodes.Intersection(sets.NewString(np.Status.Nodes...)); poolNodes.Len() != 0 {
+			nodePoolNodes[np.Name] = poolNodes.List()
+		}
+	}
+	// The keys of map are sorted by json, so the same state is always encoded to the same bytes
+	data, err := json.Marshal(struct {
+		TopologyType  string              `json:"topologyType"`
+		NodePoolNodes map[string][]string `json:"nodePoolNodes"`
+	}{TopologyType: topologyType, NodePoolNodes: nodePoolNodes})
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
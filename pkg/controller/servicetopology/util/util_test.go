@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1beta1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1beta1"
+	"github.com/openyurtio/openyurt/pkg/yurthub/filter/servicetopology"
+)
+
+func TestTriggerHash(t *testing.T) {
+	newNodePool := func(name string, nodes ...string) *appsv1beta1.NodePool {
+		return &appsv1beta1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     appsv1beta1.NodePoolStatus{Nodes: nodes},
+		}
+	}
+	getHash := func(topologyType string, nodes sets.String, nodePools ...*appsv1beta1.NodePool) string {
+		t.Helper()
+		scheme := runtime.NewScheme()
+		_ = clientgoscheme.AddToScheme(scheme)
+		_ = appsv1beta1.AddToScheme(scheme)
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for _, np := range nodePools {
+			builder = builder.WithObjects(np)
+		}
+		hash, err := TriggerHash(context.TODO(), builder.Build(), topologyType, nodes)
+		if err != nil {
+			t.Fatalf("failed to get trigger hash, %v", err)
+		}
+		return hash
+	}
+
+	nodePool := servicetopology.AnnotationServiceTopologyValueNodePool
+	base := getHash(nodePool, sets.NewString("node1", "node2"), newNodePool("hangzhou", "node1", "node3"), newNodePool("beijing", "node2"))
+	tests := []struct {
+		name         string
+		topologyType string
+		nodes        sets.String
+		nodePools    []*appsv1beta1.NodePool
+		expectSame   bool
+	}{
+		{
+			name:         "unreferenced node is changed",
+			topologyType: nodePool,
+			nodes:        sets.NewString("node1", "node2"),
+			nodePools:    []*appsv1beta1.NodePool{newNodePool("hangzhou", "node1"), newNodePool("beijing", "node2", "node4")},
+			expectSame:   true,
+		},
+		{
+			name:         "referenced node is moved into another nodepool",
+			topologyType: nodePool,
+			nodes:        sets.NewString("node1", "node2"),
+			nodePools:    []*appsv1beta1.NodePool{newNodePool("hangzhou", "node1", "node2"), newNodePool("beijing")},
+		},
+		{
+			name:         "endpoints are moved to another node",
+			topologyType: nodePool,
+			nodes:        sets.NewString("node1", "node3"),
+			nodePools:    []*appsv1beta1.NodePool{newNodePool("hangzhou", "node1", "node3"), newNodePool("beijing", "node2")},
+		},
+		{
+			name:         "topology type is changed",
+			topologyType: servicetopology.AnnotationServiceTopologyValueZone,
+			nodes:        sets.NewString("node1", "node2"),
+			nodePools:    []*appsv1beta1.NodePool{newNodePool("hangzhou", "node1", "node3"), newNodePool("beijing", "node2")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if hash := getHash(tt.topologyType, tt.nodes, tt.nodePools...); (hash == base) != tt.expectSame {
+				t.Errorf("expect same hash %v, but got %s and %s", tt.expectSame, base, hash)
+			}
+		})
+	}
+}
@@ -305,10 +305,12 @@ func (r *ReconcileYurtAppSet) calculateStatus(instance *unitv1alpha1.YurtAppSet,
 
 	// sync from status
 	newStatus.PoolReplicas = make(map[string]int32)
+	newStatus.PoolReadyReplicas = make(map[string]int32)
 	newStatus.ReadyReplicas = 0
 	newStatus.Replicas = 0
 	for _, pool := range nameToPool {
 		newStatus.PoolReplicas[pool.Name] = pool.Status.Replicas
+		newStatus.PoolReadyReplicas[pool.Name] = pool.Status.ReadyReplicas
 		newStatus.Replicas += pool.Status.Replicas
 		newStatus.ReadyReplicas += pool.Status.ReadyReplicas
 	}
@@ -353,6 +355,7 @@ func (r *ReconcileYurtAppSet) updateYurtAppSet(yas *unitv1alpha1.YurtAppSet, old
 		oldStatus.ReadyReplicas == newStatus.ReadyReplicas &&
 		yas.Generation == newStatus.ObservedGeneration &&
 		reflect.DeepEqual(oldStatus.PoolReplicas, newStatus.PoolReplicas) &&
+		reflect.DeepEqual(oldStatus.PoolReadyReplicas, newStatus.PoolReadyReplicas) &&
 		reflect.DeepEqual(oldStatus.Conditions, newStatus.Conditions) {
 		return yas, nil
 	}
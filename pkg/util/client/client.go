@@ -20,15 +20,79 @@ import (
 	"fmt"
 
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 func NewClientFromManager(mgr manager.Manager, name string) client.Client {
+	return NewClientFromManagerWithOptions(mgr, name)
+}
+
+// NewClientFromManagerWithRateLimit creates a client whose requests to the apiserver are limited by qps and burst,
+// the limits of manager are inherited if they are not positive.
+func NewClientFromManagerWithRateLimit(mgr manager.Manager, name string, qps float32, burst int) client.Client {
+	return NewClientFromManagerWithOptions(mgr, name, WithRateLimit(qps, burst))
+}
+
+// Option customizes the client created by NewClientFromManagerWithOptions.
+type Option func(*options)
+
+type options struct {
+	qps          float32
+	burst        int
+	rateLimiter  flowcontrol.RateLimiter
+	instrumented bool
+}
+
+// WithRateLimit limits the requests of client to the apiserver by qps and burst,
+// the limits of manager are inherited if they are not positive.
+func WithRateLimit(qps float32, burst int) Option {
+	return func(o *options) {
+		o.qps = qps
+		o.burst = burst
+	}
+}
+
+// WithRateLimiter injects a rate limiter into the client, so the controller does not share the limits of
+// other controllers. It takes precedence over WithRateLimit.
+func WithRateLimiter(rateLimiter flowcontrol.RateLimiter) Option {
+	return func(o *options) {
+		o.rateLimiter = rateLimiter
+	}
+}
+
+// WithInstrumentation records the counters and latencies of the requests of client, labeled by the name of client.
+func WithInstrumentation() Option {
+	return func(o *options) {
+		o.instrumented = true
+	}
+}
+
+// NewClientFromManagerWithOptions creates a client for the controller with the name, which is also the user agent
+// of the requests to the apiserver.
+func NewClientFromManagerWithOptions(mgr manager.Manager, name string, opts ...Option) client.Client {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	cfg := rest.CopyConfig(mgr.GetConfig())
 	cfg.UserAgent = fmt.Sprintf("yurt-manager/%s", name)
+	if o.qps > 0 {
+		cfg.QPS = o.qps
+	}
+	if o.burst > 0 {
+		cfg.Burst = o.burst
+	}
+	if o.rateLimiter != nil {
+		cfg.RateLimiter = o.rateLimiter
+	}
 
 	delegatingClient, _ := cluster.DefaultNewClient(mgr.GetCache(), cfg, client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if o.instrumented && delegatingClient != nil {
+		return NewInstrumentedClient(delegatingClient, name)
+	}
 	return delegatingClient
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsSubsystem = "controller_client"
+
+	verbGet          = "get"
+	verbList         = "list"
+	verbCreate       = "create"
+	verbUpdate       = "update"
+	verbPatch        = "patch"
+	verbDelete       = "delete"
+	verbDeleteAllOf  = "deleteallof"
+	verbUpdateStatus = "update_status"
+	verbPatchStatus  = "patch_status"
+
+	unknownKind = "unknown"
+)
+
+var (
+	clientRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "requests_total",
+			Help:      "counter of requests sent by the client of controllers, labeled by controller, verb and kind",
+		},
+		[]string{"controller", "verb", "kind"})
+	clientRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: metricsSubsystem,
+			Name:      "request_duration_seconds",
+			Help:      "duration of requests sent by the client of controllers, labeled by controller, verb and kind",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"controller", "verb", "kind"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(clientRequests, clientRequestDuration)
+}
+
+// instrumentedClient records the requests of a controller, so the controller saturating the apiserver can be told.
+// The reads served by the cache are recorded too, their latencies tell them apart.
+type instrumentedClient struct {
+	client.Client
+	controller string
+}
+
+// NewInstrumentedClient wraps the client, and records the counters and latencies of its requests labeled by controller.
+func NewInstrumentedClient(delegate client.Client, controller string) client.Client {
+	return &instrumentedClient{Client: delegate, controller: controller}
+}
+
+func (c *instrumentedClient) observe(verb string, obj runtime.Object, start time.Time) {
+	kind := objectKind(obj, c.Scheme())
+	clientRequests.WithLabelValues(c.controller, verb, kind).Inc()
+	clientRequestDuration.WithLabelValues(c.controller, verb, kind).Observe(time.Since(start).Seconds())
+}
+
+// objectKind returns the kind of object, the kind of list is the kind of its items.
+func objectKind(obj runtime.Object, scheme *runtime.Scheme) string {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return unknownKind
+	}
+	return strings.TrimSuffix(gvk.Kind, "List")
+}
+
+func (c *instrumentedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	defer c.observe(verbGet, obj, time.Now())
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *instrumentedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	defer c.observe(verbList, list, time.Now())
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *instrumentedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	defer c.observe(verbCreate, obj, time.Now())
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *instrumentedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	defer c.observe(verbUpdate, obj, time.Now())
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *instrumentedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer c.observe(verbPatch, obj, time.Now())
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *instrumentedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	defer c.observe(verbDelete, obj, time.Now())
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *instrumentedClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	defer c.observe(verbDeleteAllOf, obj, time.Now())
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *instrumentedClient) Status() client.StatusWriter {
+	return &instrumentedStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type instrumentedStatusWriter struct {
+	client.StatusWriter
+	client *instrumentedClient
+}
+
+func (w *instrumentedStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	defer w.client.observe(verbUpdateStatus, obj, time.Now())
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *instrumentedStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	defer w.client.observe(verbPatchStatus, obj, time.Now())
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
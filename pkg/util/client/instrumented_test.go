@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func requestCount(controller, verb, kind string) float64 {
+	return testutil.ToFloat64(clientRequests.WithLabelValues(controller, verb, kind))
+}
+
+func TestInstrumentedClient(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	delegate := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(cm).Build()
+	c := NewInstrumentedClient(delegate, "test-controller")
+	ctx := context.TODO()
+
+	tests := []struct {
+		verb string
+		kind string
+		call func() error
+	}{
+		{
+			verb: verbGet,
+			kind: "ConfigMap",
+			call: func() error { return c.Get(ctx, client.ObjectKeyFromObject(cm), cm) },
+		},
+		{
+			verb: verbList,
+			kind: "ConfigMap",
+			call: func() error { return c.List(ctx, &corev1.ConfigMapList{}) },
+		},
+		{
+			verb: verbPatch,
+			kind: "ConfigMap",
+			call: func() error {
+				old := cm.DeepCopy()
+				cm.Data = map[string]string{"foo": "bar"}
+				return c.Patch(ctx, cm, client.MergeFrom(old))
+			},
+		},
+		{
+			verb: verbUpdate,
+			kind: "ConfigMap",
+			call: func() error {
+				cm.Data = map[string]string{"foo": "baz"}
+				return c.Update(ctx, cm)
+			},
+		},
+		{
+			verb: verbCreate,
+			kind: "Service",
+			call: func() error {
+				return c.Create(ctx, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}})
+			},
+		},
+		{
+			verb: verbDelete,
+			kind: "ConfigMap",
+			call: func() error { return c.Delete(ctx, cm) },
+		},
+		{
+			// the failed requests are recorded too
+			verb: verbGet,
+			kind: "ConfigMap",
+			call: func() error {
+				if err := c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); err == nil {
+					t.Errorf("expect not found error, but got nil")
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		before := requestCount("test-controller", tt.verb, tt.kind)
+		if err := tt.call(); err != nil {
+			t.Fatalf("failed to %s %s, %v", tt.verb, tt.kind, err)
+		}
+		if count := requestCount("test-controller", tt.verb, tt.kind); count != before+1 {
+			t.Errorf("expect %s %s is counted once, but got %v", tt.verb, tt.kind, count-before)
+		}
+	}
+
+	if count := requestCount("other-controller", verbGet, "ConfigMap"); count != 0 {
+		t.Errorf("expect requests are labeled by controller, but got %v requests of other controller", count)
+	}
+	if count := testutil.CollectAndCount(clientRequestDuration); count == 0 {
+		t.Errorf("expect latencies are recorded, but got nothing")
+	}
+}
+
+func TestInstrumentedStatusWriter(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	c := NewInstrumentedClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod).Build(), "test-controller")
+
+	before := requestCount("test-controller", verbUpdateStatus, "Pod")
+	pod.Status.Phase = corev1.PodRunning
+	if err := c.Status().Update(context.TODO(), pod); err != nil {
+		t.Fatalf("failed to update status, %v", err)
+	}
+	if count := requestCount("test-controller", verbUpdateStatus, "Pod"); count != before+1 {
+		t.Errorf("expect status update is counted once, but got %v", count-before)
+	}
+}
+
+func TestObjectKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		obj    runtime.Object
+		expect string
+	}{
+		{name: "object", obj: &corev1.Service{}, expect: "Service"},
+		{name: "list", obj: &corev1.ServiceList{}, expect: "Service"},
+		{name: "unregistered", obj: &metav1.Status{}, expect: unknownKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind := objectKind(tt.obj, clientgoscheme.Scheme); kind != tt.expect {
+				t.Errorf("expect kind %s, but got %s", tt.expect, kind)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -57,6 +58,10 @@ func DiscoverGVK(gvk schema.GroupVersionKind) bool {
 	startTime := time.Now()
 	err := retry.OnError(backOff, func(err error) bool { return true }, func() error {
 		resourceList, err := discoveryClient.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+		if apierrors.IsNotFound(err) {
+			// The group version is not served at all, e.g. no CRD of the group is installed
+			return errKindNotFound
+		}
 		if err != nil {
 			return err
 		}
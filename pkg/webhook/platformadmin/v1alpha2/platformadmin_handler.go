@@ -26,6 +26,7 @@ import (
 
 	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
 	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+	platformadminutil "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
 	"github.com/openyurtio/openyurt/pkg/webhook/util"
 )
 
@@ -40,6 +41,10 @@ type Manifest struct {
 func (webhook *PlatformAdminHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
 	// init
 	webhook.Client = mgr.GetClient()
+	// The claims of pools are looked up by index, which may not be registered by the controller yet
+	if err := platformadminutil.RegisterFieldIndexers(mgr.GetFieldIndexer()); err != nil {
+		return "", "", err
+	}
 
 	gvk, err := apiutil.GVKForObject(&v1alpha2.PlatformAdmin{}, mgr.GetScheme())
 	if err != nil {
@@ -49,6 +54,8 @@ func (webhook *PlatformAdminHandler) SetupWebhookWithManager(mgr ctrl.Manager) (
 	if err := webhook.initManifest(); err != nil {
 		return "", "", err
 	}
+	// The embed component templates are used to find the additional components colliding with them
+	webhook.Configuration = config.NewPlatformAdminControllerConfiguration()
 
 	return util.GenerateMutatePath(gvk),
 		util.GenerateValidatePath(gvk),
@@ -83,6 +90,8 @@ func (webhook *PlatformAdminHandler) initManifest() error {
 type PlatformAdminHandler struct {
 	Client    client.Client
 	Manifests *Manifest
+	// Configuration holds the embed component templates, the validation against them is skipped if nil
+	Configuration *config.PlatformAdminControllerConfiguration
 }
 
 var _ webhook.CustomDefaulter = &PlatformAdminHandler{}
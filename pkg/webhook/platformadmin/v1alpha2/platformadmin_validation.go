@@ -21,13 +21,21 @@ import (
 	"fmt"
 	"strings"
 
+	k8scorev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kubernetes/pkg/apis/core"
+	corev1 "k8s.io/kubernetes/pkg/apis/core/v1"
+	apivalidation "k8s.io/kubernetes/pkg/apis/core/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	unitv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/apps/v1alpha1"
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
 	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
 	util "github.com/openyurtio/openyurt/pkg/controller/platformadmin/utils"
 )
 
@@ -42,6 +50,9 @@ func (webhook *PlatformAdminHandler) ValidateCreate(ctx context.Context, obj run
 	if allErrs := webhook.validate(ctx, platformAdmin); len(allErrs) > 0 {
 		return apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind("PlatformAdmin").GroupKind(), platformAdmin.Name, allErrs)
 	}
+	if allErrs := webhook.validatePoolClaim(ctx, platformAdmin); len(allErrs) > 0 {
+		return apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind("PlatformAdmin").GroupKind(), platformAdmin.Name, allErrs)
+	}
 
 	return nil
 }
@@ -57,6 +68,16 @@ func (webhook *PlatformAdminHandler) ValidateUpdate(ctx context.Context, oldObj,
 		return apierrors.NewBadRequest(fmt.Sprintf("expected a PlatformAdmin but got a %T", oldObj))
 	}
 
+	allErrs := validateWorkloadNamespaceUpdate(oldPlatformAdmin, newPlatformAdmin)
+	allErrs = append(allErrs, validateSecurityUpdate(oldPlatformAdmin, newPlatformAdmin)...)
+	// The existing conflicts are reported by the controller, the other updates of them are not blocked
+	if !sets.NewString(util.PlatformAdminPools(oldPlatformAdmin)...).Equal(sets.NewString(util.PlatformAdminPools(newPlatformAdmin)...)) {
+		allErrs = append(allErrs, webhook.validatePoolClaim(ctx, newPlatformAdmin)...)
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(v1alpha2.GroupVersion.WithKind("PlatformAdmin").GroupKind(), newPlatformAdmin.Name, allErrs)
+	}
+
 	// validate
 	newErrorList := webhook.validate(ctx, newPlatformAdmin)
 	oldErrorList := webhook.validate(ctx, oldPlatformAdmin)
@@ -92,16 +113,370 @@ func (webhook *PlatformAdminHandler) validatePlatformAdminSpec(platformAdmin *v1
 		return field.ErrorList{field.Invalid(field.NewPath("spec", "platform"), platformAdmin.Spec.Platform, "must be "+v1alpha2.PlatformAdminPlatformEdgeX)}
 	}
 
+	// Verify that the message bus is supported
+	switch platformAdmin.Spec.MessageBus {
+	case "", v1alpha2.PlatformAdminMessageBusRedis, v1alpha2.PlatformAdminMessageBusMQTT:
+	default:
+		return field.ErrorList{
+			field.NotSupported(field.NewPath("spec", "messageBus"), platformAdmin.Spec.MessageBus,
+				[]string{v1alpha2.PlatformAdminMessageBusRedis, v1alpha2.PlatformAdminMessageBusMQTT}),
+		}
+	}
+
+	// Verify that the scheme is supported
+	switch platformAdmin.Spec.Scheme {
+	case "", v1alpha2.PlatformAdminSchemeHTTP, v1alpha2.PlatformAdminSchemeHTTPS:
+	default:
+		return field.ErrorList{
+			field.NotSupported(field.NewPath("spec", "scheme"), platformAdmin.Spec.Scheme,
+				[]string{v1alpha2.PlatformAdminSchemeHTTP, v1alpha2.PlatformAdminSchemeHTTPS}),
+		}
+	}
+
+	// Verify that the deletion policy is supported
+	switch platformAdmin.Spec.DeletionPolicy {
+	case "", v1alpha2.DeletionPolicyDelete, v1alpha2.DeletionPolicyOrphan:
+	default:
+		return field.ErrorList{
+			field.NotSupported(field.NewPath("spec", "deletionPolicy"), platformAdmin.Spec.DeletionPolicy,
+				[]string{v1alpha2.DeletionPolicyDelete, v1alpha2.DeletionPolicyOrphan}),
+		}
+	}
+
+	// Verify the namespace which the components are deployed into
+	if namespaceErrs := validateWorkloadNamespace(platformAdmin); len(namespaceErrs) > 0 {
+		return namespaceErrs
+	}
+
+	// Verify the extra node selector requirements and tolerations of the pool
+	if schedulingErrs := validatePlatformAdminScheduling(platformAdmin); len(schedulingErrs) > 0 {
+		return schedulingErrs
+	}
+
+	// Verify the registry which the images of components are pulled from
+	if registryErrs := validateImageRegistry(platformAdmin); len(registryErrs) > 0 {
+		return registryErrs
+	}
+
+	// Verify the names of image pull secrets
+	if secretErrs := validateImagePullSecrets(platformAdmin); len(secretErrs) > 0 {
+		return secretErrs
+	}
+
+	// Verify the probe overrides of components
+	if probeErrs := validateComponentProbes(platformAdmin); len(probeErrs) > 0 {
+		return probeErrs
+	}
+
+	// Verify the update strategy overrides of components
+	if strategyErrs := validateComponentUpdateStrategies(platformAdmin); len(strategyErrs) > 0 {
+		return strategyErrs
+	}
+
+	// Verify the autoscaling of components
+	if autoscalingErrs := validateComponentAutoscaling(platformAdmin); len(autoscalingErrs) > 0 {
+		return autoscalingErrs
+	}
+
+	// Verify the additional components carried by annotations
+	if additionalErrs := validateAdditionalComponents(platformAdmin); len(additionalErrs) > 0 {
+		return additionalErrs
+	}
+
+	// Verify that the additional components do not collide with each other or the embed components
+	if setErrs := validateComponentSet(platformAdmin, webhook.Configuration); len(setErrs) > 0 {
+		return setErrs
+	}
+
 	// Verify that it is a supported platformadmin version
-	for _, version := range webhook.Manifests.Versions {
-		if platformAdmin.Spec.Version == version {
-			return nil
+	return webhook.validateVersion(platformAdmin)
+}
+
+// validateVersion checks the version and security mode against the supported versions of component templates, which
+// are the same as the ones published by the controller. The manifest is used if the templates are not loaded.
+func (webhook *PlatformAdminHandler) validateVersion(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	fldPath := field.NewPath("spec", "version")
+	if webhook.Configuration == nil {
+		for _, version := range webhook.Manifests.Versions {
+			if platformAdmin.Spec.Version == version {
+				return nil
+			}
 		}
+		return field.ErrorList{
+			field.Invalid(fldPath, platformAdmin.Spec.Version, "must be one of"+strings.Join(webhook.Manifests.Versions, ",")),
+		}
+	}
+
+	versions := config.SupportedVersions(webhook.Configuration)
+	version, ok := config.FindSupportedVersion(versions, platformAdmin.Spec.Version)
+	if !ok {
+		names := make([]string, 0, len(versions))
+		for i := range versions {
+			names = append(names, versions[i].Name)
+		}
+		return field.ErrorList{
+			field.Invalid(fldPath, platformAdmin.Spec.Version, "must be one of "+strings.Join(names, ",")),
+		}
+	}
+	if platformAdmin.Spec.Security && !version.Security {
+		return field.ErrorList{
+			field.Invalid(field.NewPath("spec", "security"), platformAdmin.Spec.Security, fmt.Sprintf("security mode is not supported by version %s", version.Name)),
+		}
+	}
+	if !platformAdmin.Spec.Security && !version.NoSecty {
+		return field.ErrorList{
+			field.Invalid(field.NewPath("spec", "security"), platformAdmin.Spec.Security, fmt.Sprintf("nosecty mode is not supported by version %s", version.Name)),
+		}
+	}
+	return nil
+}
+
+// validateWorkloadNamespace checks the workload namespace, and that the label tracking the objects generated into it
+// can hold the namespace and name of PlatformAdmin.
+func validateWorkloadNamespace(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	fldPath := field.NewPath("spec", "workloadNamespace")
+	namespace := platformAdmin.Spec.WorkloadNamespace
+	if namespace == "" || namespace == platformAdmin.Namespace {
+		return nil
+	}
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Label(namespace) {
+		allErrs = append(allErrs, field.Invalid(fldPath, namespace, msg))
 	}
+	labelValue := platformAdmin.Namespace + "." + platformAdmin.Name
+	for _, msg := range validation.IsValidLabelValue(labelValue) {
+		allErrs = append(allErrs, field.Invalid(fldPath, namespace,
+			fmt.Sprintf("the label %s=%s tracking the objects in workload namespace is invalid: %s", v1alpha2.LabelPlatformAdmin, labelValue, msg)))
+	}
+	return allErrs
+}
+
+// workloadNamespace returns the namespace which the objects of PlatformAdmin are generated in.
+func workloadNamespace(platformAdmin *v1alpha2.PlatformAdmin) string {
+	if platformAdmin.Spec.WorkloadNamespace != "" {
+		return platformAdmin.Spec.WorkloadNamespace
+	}
+	return platformAdmin.Namespace
+}
 
-	return field.ErrorList{
-		field.Invalid(field.NewPath("spec", "version"), platformAdmin.Spec.Version, "must be one of"+strings.Join(webhook.Manifests.Versions, ",")),
+// validatePoolClaim rejects the PlatformAdmin whose pools are already claimed by another PlatformAdmin generating
+// components into the same namespace, they would patch the same yurtappsets in turn.
+func (webhook *PlatformAdminHandler) validatePoolClaim(ctx context.Context, platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, pool := range util.PlatformAdminPools(platformAdmin) {
+		fldPath := poolPath(platformAdmin, pool)
+		platformAdmins := &v1alpha2.PlatformAdminList{}
+		if err := webhook.Client.List(ctx, platformAdmins, client.MatchingFields{util.IndexerPathForNodepool: pool}); err != nil {
+			return append(allErrs, field.InternalError(fldPath, err))
+		}
+		for _, other := range platformAdmins.Items {
+			// The index is not honored by every client(e.g. the fake client of tests), so the pool is checked again
+			if !containsPool(&other, pool) || other.DeletionTimestamp != nil ||
+				(other.Namespace == platformAdmin.Namespace && other.Name == platformAdmin.Name) ||
+				workloadNamespace(&other) != workloadNamespace(platformAdmin) {
+				continue
+			}
+			allErrs = append(allErrs, field.Invalid(fldPath, pool,
+				fmt.Sprintf("the nodepool is already claimed by PlatformAdmin %s/%s in namespace %s", other.Namespace, other.Name, workloadNamespace(platformAdmin))))
+			break
+		}
 	}
+	return allErrs
+}
+
+// poolPath returns the field path the pool is declared at, the poolName takes precedence over the pools.
+func poolPath(platformAdmin *v1alpha2.PlatformAdmin, pool string) *field.Path {
+	if pool != platformAdmin.Spec.PoolName {
+		for i := range platformAdmin.Spec.Pools {
+			if platformAdmin.Spec.Pools[i] == pool {
+				return field.NewPath("spec", "pools").Index(i)
+			}
+		}
+	}
+	return field.NewPath("spec", "poolName")
+}
+
+func containsPool(platformAdmin *v1alpha2.PlatformAdmin, pool string) bool {
+	for _, p := range util.PlatformAdminPools(platformAdmin) {
+		if p == pool {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWorkloadNamespaceUpdate forbids moving the components into another namespace, the objects generated
+// in the old namespace would be left behind.
+func validateWorkloadNamespaceUpdate(oldPlatformAdmin, newPlatformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	if workloadNamespace(oldPlatformAdmin) != workloadNamespace(newPlatformAdmin) {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec", "workloadNamespace"), "workloadNamespace can not be changed after creation")}
+	}
+	return nil
+}
+
+// validateSecurityUpdate forbids toggling the security mode while the components are still migrated from the
+// previous toggle, the components of the previous mode may not be released yet.
+func validateSecurityUpdate(oldPlatformAdmin, newPlatformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	if oldPlatformAdmin.Spec.Security == newPlatformAdmin.Spec.Security {
+		return nil
+	}
+	if util.IsSecurityMigrating(oldPlatformAdmin.Spec.Security, &oldPlatformAdmin.Status) {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec", "security"),
+			fmt.Sprintf("security can not be changed until the migration to security %t is completed", oldPlatformAdmin.Spec.Security))}
+	}
+	return nil
+}
+
+// validateAdditionalComponents validates the additional deployments and services converted from v1alpha1,
+// so the malformed payloads are rejected here instead of failing the reconcile.
+func validateAdditionalComponents(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("metadata", "annotations")
+	// The deployment and service with the same name make up one component
+	names := sets.NewString()
+	if data, ok := platformAdmin.Annotations[iotv1alpha1.AnnotationAdditionalDeployments]; ok {
+		deployments, err := iotv1alpha1.DecodeAdditionalDeployments(data)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(iotv1alpha1.AnnotationAdditionalDeployments), data, err.Error()))
+		}
+		for i := range deployments {
+			names.Insert(deployments[i].Name)
+		}
+	}
+	if data, ok := platformAdmin.Annotations[iotv1alpha1.AnnotationAdditionalServices]; ok {
+		services, err := iotv1alpha1.DecodeAdditionalServices(data)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(iotv1alpha1.AnnotationAdditionalServices), data, err.Error()))
+		}
+		for i := range services {
+			names.Insert(services[i].Name)
+		}
+	}
+	if err := config.ValidateAdditionalComponentsCount(names.Len()); err != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, err.Error()))
+	}
+	return allErrs
+}
+
+// validateComponentSet validates the additional components together with the embed components of the version by
+// the same routine as the controller. The templates overridden by the framework configmap are checked by the controller.
+func validateComponentSet(platformAdmin *v1alpha2.PlatformAdmin, conf *config.PlatformAdminControllerConfiguration) field.ErrorList {
+	var components []*config.Component
+	if conf != nil {
+		if platformAdmin.Spec.Security {
+			components = append(components, conf.SecurityComponents[platformAdmin.Spec.Version]...)
+		} else {
+			components = append(components, conf.NoSectyComponents[platformAdmin.Spec.Version]...)
+		}
+	}
+
+	// The deployment and service with the same name make up one component, the malformed annotations are
+	// reported by validateAdditionalComponents
+	names := sets.NewString()
+	if data, ok := platformAdmin.Annotations[iotv1alpha1.AnnotationAdditionalDeployments]; ok {
+		deployments, _ := iotv1alpha1.DecodeAdditionalDeployments(data)
+		for i := range deployments {
+			names.Insert(deployments[i].Name)
+		}
+	}
+	services := make(map[string]*k8scorev1.ServiceSpec)
+	if data, ok := platformAdmin.Annotations[iotv1alpha1.AnnotationAdditionalServices]; ok {
+		decoded, _ := iotv1alpha1.DecodeAdditionalServices(data)
+		for i := range decoded {
+			names.Insert(decoded[i].Name)
+			services[decoded[i].Name] = &decoded[i].Spec
+		}
+	}
+	for _, name := range names.List() {
+		components = append(components, &config.Component{Name: name, Service: services[name]})
+	}
+
+	if err := config.ValidateComponentSet(components); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("metadata", "annotations"), platformAdmin.Name, err.Error())}
+	}
+	return nil
+}
+
+// validateComponentProbes validates the probe overrides of components, a probe specifying more than one handler
+// is rejected by the apiserver when the deployment is created, so it is rejected here instead.
+func validateComponentProbes(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "components")
+	for i := range platformAdmin.Spec.Components {
+		component := &platformAdmin.Spec.Components[i]
+		if err := config.ValidateProbe(component.LivenessProbe); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("livenessProbe"), component.LivenessProbe, err.Error()))
+		}
+		if err := config.ValidateProbe(component.ReadinessProbe); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("readinessProbe"), component.ReadinessProbe, err.Error()))
+		}
+		if err := config.ValidateProbe(component.StartupProbe); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("startupProbe"), component.StartupProbe, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateComponentUpdateStrategies validates the update strategy overrides of components, e.g. Recreate with the
+// rolling update parameters, which would be rejected by the apiserver when the deployment is created.
+func validateComponentUpdateStrategies(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "components")
+	for i := range platformAdmin.Spec.Components {
+		component := &platformAdmin.Spec.Components[i]
+		if err := config.ValidateUpdateStrategy(component.UpdateStrategy); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("updateStrategy"), component.UpdateStrategy, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateComponentAutoscaling validates the limits of replicas of the autoscaling of components, the
+// HorizontalPodAutoscaler with minReplicas greater than maxReplicas would be rejected by the apiserver.
+func validateComponentAutoscaling(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "components")
+	for i := range platformAdmin.Spec.Components {
+		component := &platformAdmin.Spec.Components[i]
+		if err := config.ValidateAutoscaling(component.Autoscaling); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("autoscaling"), component.Autoscaling, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validatePlatformAdminScheduling validates the node selector requirements and tolerations in the same way as
+// the pools of yurtappset, since they are appended to the pool of components.
+func validatePlatformAdminScheduling(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	var allErrs field.ErrorList
+
+	fldPath := field.NewPath("spec", "nodeSelectorRequirements")
+	for i := range platformAdmin.Spec.NodeSelectorRequirements {
+		requirement := &platformAdmin.Spec.NodeSelectorRequirements[i]
+		coreRequirement := &core.NodeSelectorRequirement{}
+		if err := corev1.Convert_v1_NodeSelectorRequirement_To_core_NodeSelectorRequirement(requirement, coreRequirement, nil); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), requirement,
+				fmt.Sprintf("Convert_v1_NodeSelectorRequirement_To_core_NodeSelectorRequirement failed: %v", err)))
+			continue
+		}
+		allErrs = append(allErrs, apivalidation.ValidateNodeSelectorRequirement(*coreRequirement, fldPath.Index(i))...)
+	}
+
+	fldPath = field.NewPath("spec", "tolerations")
+	var coreTolerations []core.Toleration
+	for i := range platformAdmin.Spec.Tolerations {
+		toleration := &platformAdmin.Spec.Tolerations[i]
+		coreToleration := &core.Toleration{}
+		if err := corev1.Convert_v1_Toleration_To_core_Toleration(toleration, coreToleration, nil); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), toleration,
+				fmt.Sprintf("Convert_v1_Toleration_To_core_Toleration failed: %v", err)))
+			continue
+		}
+		coreTolerations = append(coreTolerations, *coreToleration)
+	}
+	allErrs = append(allErrs, apivalidation.ValidateTolerations(coreTolerations, fldPath)...)
+
+	return allErrs
 }
 
 func (webhook *PlatformAdminHandler) validatePlatformAdminWithNodePools(ctx context.Context, platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
@@ -112,33 +487,72 @@ func (webhook *PlatformAdminHandler) validatePlatformAdminWithNodePools(ctx cont
 			field.Invalid(field.NewPath("spec", "poolName"), platformAdmin.Spec.PoolName, "can not list nodepools, cause"+err.Error()),
 		}
 	}
-	ok := false
+	existing := make(map[string]struct{}, len(nodePools.Items))
 	for _, nodePool := range nodePools.Items {
-		if nodePool.ObjectMeta.Name == platformAdmin.Spec.PoolName {
-			ok = true
-			break
-		}
-	}
-	if !ok {
-		return field.ErrorList{
-			field.Invalid(field.NewPath("spec", "poolName"), platformAdmin.Spec.PoolName, "can not find the nodepool"),
-		}
+		existing[nodePool.ObjectMeta.Name] = struct{}{}
 	}
-	// verify that no other platformadmin in the nodepool
-	var platformadmins v1alpha2.PlatformAdminList
-	listOptions := client.MatchingFields{util.IndexerPathForNodepool: platformAdmin.Spec.PoolName}
-	if err := webhook.Client.List(ctx, &platformadmins, listOptions); err != nil {
-		return field.ErrorList{
-			field.Invalid(field.NewPath("spec", "poolName"), platformAdmin.Spec.PoolName, "can not list platformadmins, cause "+err.Error()),
+	for _, pool := range util.PlatformAdminPools(platformAdmin) {
+		if _, ok := existing[pool]; !ok {
+			return field.ErrorList{
+				field.Invalid(poolPath(platformAdmin, pool), pool, "can not find the nodepool"),
+			}
 		}
 	}
-	for _, other := range platformadmins.Items {
-		if platformAdmin.Name != other.Name {
+	// verify that no other platformadmin in the nodepools
+	for _, pool := range util.PlatformAdminPools(platformAdmin) {
+		var platformadmins v1alpha2.PlatformAdminList
+		listOptions := client.MatchingFields{util.IndexerPathForNodepool: pool}
+		if err := webhook.Client.List(ctx, &platformadmins, listOptions); err != nil {
 			return field.ErrorList{
-				field.Invalid(field.NewPath("spec", "poolName"), platformAdmin.Spec.PoolName, "already used by other platformadmin instance,"),
+				field.Invalid(poolPath(platformAdmin, pool), pool, "can not list platformadmins, cause "+err.Error()),
+			}
+		}
+		for _, other := range platformadmins.Items {
+			if platformAdmin.Name != other.Name {
+				return field.ErrorList{
+					field.Invalid(poolPath(platformAdmin, pool), pool, "already used by other platformadmin instance,"),
+				}
 			}
 		}
 	}
 
 	return nil
 }
+
+// validateImageRegistry checks that the image registry is a host with an optional port and path, e.g.
+// "harbor.local:5000/edgex". The scheme, tag and digest are rejected since the repositories are appended to it.
+func validateImageRegistry(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	registry := strings.TrimSuffix(platformAdmin.Spec.ImageRegistry, "/")
+	if registry == "" {
+		return nil
+	}
+
+	fldPath := field.NewPath("spec", "imageRegistry")
+	switch {
+	case strings.Contains(registry, "://"):
+		return field.ErrorList{field.Invalid(fldPath, platformAdmin.Spec.ImageRegistry, "must not contain a scheme")}
+	case strings.Contains(registry, "@"):
+		return field.ErrorList{field.Invalid(fldPath, platformAdmin.Spec.ImageRegistry, "must not contain a digest")}
+	}
+	// The colon of the first segment is the port of host, the one after the path is a tag
+	if i := strings.LastIndex(registry, "/"); i >= 0 && strings.Contains(registry[i+1:], ":") {
+		return field.ErrorList{field.Invalid(fldPath, platformAdmin.Spec.ImageRegistry, "must not contain a tag")}
+	}
+	return nil
+}
+
+// validateImagePullSecrets checks that the image pull secrets refer to valid secret names.
+func validateImagePullSecrets(platformAdmin *v1alpha2.PlatformAdmin) field.ErrorList {
+	fldPath := field.NewPath("spec", "imagePullSecrets")
+	var allErrs field.ErrorList
+	for i, secret := range platformAdmin.Spec.ImagePullSecrets {
+		if secret.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("name"), ""))
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("name"), secret.Name, msg))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	iotv1alpha1 "github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha1"
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/controller/platformadmin/config"
+)
+
+func TestValidatePlatformAdminSpec(t *testing.T) {
+	webhook := &PlatformAdminHandler{
+		Manifests: &Manifest{LatestVersion: "levski", Versions: []string{"levski", "jakarta"}},
+	}
+
+	tests := []struct {
+		name         string
+		version      string
+		messageBus   string
+		scheme       string
+		namespace    string
+		policy       string
+		requirements []corev1.NodeSelectorRequirement
+		tolerations  []corev1.Toleration
+		registry     string
+		secrets      []corev1.LocalObjectReference
+		components   []v1alpha2.Component
+		expectError  bool
+	}{
+		{name: "default message bus", version: "levski"},
+		{name: "redis message bus", version: "levski", messageBus: v1alpha2.PlatformAdminMessageBusRedis},
+		{name: "mqtt message bus", version: "levski", messageBus: v1alpha2.PlatformAdminMessageBusMQTT},
+		{name: "unknown message bus", version: "levski", messageBus: "kafka", expectError: true},
+		{name: "http scheme", version: "levski", scheme: v1alpha2.PlatformAdminSchemeHTTP},
+		{name: "https scheme", version: "levski", scheme: v1alpha2.PlatformAdminSchemeHTTPS},
+		{name: "unknown scheme", version: "levski", scheme: "grpc", expectError: true},
+		{name: "same workload namespace", version: "levski", namespace: "default"},
+		{name: "another workload namespace", version: "levski", namespace: "site-1"},
+		{name: "invalid workload namespace", version: "levski", namespace: "Site_1", expectError: true},
+		{name: "delete policy", version: "levski", policy: v1alpha2.DeletionPolicyDelete},
+		{name: "orphan policy", version: "levski", policy: v1alpha2.DeletionPolicyOrphan},
+		{name: "unknown deletion policy", version: "levski", policy: "Retain", expectError: true},
+		{name: "unsupported version", version: "hanoi", expectError: true},
+		{
+			name:    "valid node selector requirements and tolerations",
+			version: "levski",
+			requirements: []corev1.NodeSelectorRequirement{
+				{Key: "gpu", Operator: corev1.NodeSelectorOpExists},
+				{Key: "arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}},
+			},
+			tolerations: []corev1.Toleration{
+				{Key: "edge", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		{
+			name:         "invalid node selector operator",
+			version:      "levski",
+			requirements: []corev1.NodeSelectorRequirement{{Key: "gpu", Operator: "Has"}},
+			expectError:  true,
+		},
+		{
+			name:         "values with exists operator",
+			version:      "levski",
+			requirements: []corev1.NodeSelectorRequirement{{Key: "gpu", Operator: corev1.NodeSelectorOpExists, Values: []string{"true"}}},
+			expectError:  true,
+		},
+		{
+			name:        "invalid toleration operator",
+			version:     "levski",
+			tolerations: []corev1.Toleration{{Key: "edge", Operator: "Has"}},
+			expectError: true,
+		},
+		{name: "image registry", version: "levski", registry: "harbor.local"},
+		{name: "image registry with port and path", version: "levski", registry: "harbor.local:5000/edgex/"},
+		{name: "image registry with scheme", version: "levski", registry: "https://harbor.local", expectError: true},
+		{name: "image registry with digest", version: "levski", registry: "harbor.local/edgex@sha256:0123456789abcdef", expectError: true},
+		{name: "image registry with tag", version: "levski", registry: "harbor.local:5000/edgex:2.3.0", expectError: true},
+		{name: "image pull secrets", version: "levski", secrets: []corev1.LocalObjectReference{{Name: "registry.example.com"}, {Name: "regcred"}}},
+		{name: "invalid image pull secret", version: "levski", secrets: []corev1.LocalObjectReference{{Name: "Reg_Cred"}}, expectError: true},
+		{name: "empty image pull secret", version: "levski", secrets: []corev1.LocalObjectReference{{}}, expectError: true},
+		{
+			name:    "probe with one handler",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name:           "edgex-core-command",
+				ReadinessProbe: &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/api/v2/ping"}}},
+				LivenessProbe:  &corev1.Probe{},
+			}},
+		},
+		{
+			name:    "probe with both httpGet and exec",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name: "edgex-core-command",
+				ReadinessProbe: &corev1.Probe{Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/api/v2/ping"},
+					Exec:    &corev1.ExecAction{Command: []string{"true"}},
+				}},
+			}},
+			expectError: true,
+		},
+		{
+			name:    "recreate update strategy",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name:           "edgex-core-command",
+				UpdateStrategy: &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			}},
+		},
+		{
+			name:    "recreate update strategy with rolling update",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name: "edgex-core-command",
+				UpdateStrategy: &appsv1.DeploymentStrategy{
+					Type:          appsv1.RecreateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{},
+				},
+			}},
+			expectError: true,
+		},
+		{
+			name:    "autoscaling",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name:        "edgex-core-data",
+				Autoscaling: &v1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(1), MaxReplicas: 3},
+			}},
+		},
+		{
+			name:    "autoscaling with min replicas greater than max replicas",
+			version: "levski",
+			components: []v1alpha2.Component{{
+				Name:        "edgex-core-data",
+				Autoscaling: &v1alpha2.ComponentAutoscaling{MinReplicas: pointer.Int32Ptr(4), MaxReplicas: 3},
+			}},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformAdmin := &v1alpha2.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "edgex"},
+				Spec: v1alpha2.PlatformAdminSpec{
+					Platform:                 v1alpha2.PlatformAdminPlatformEdgeX,
+					Version:                  tt.version,
+					MessageBus:               tt.messageBus,
+					WorkloadNamespace:        tt.namespace,
+					Scheme:                   tt.scheme,
+					DeletionPolicy:           tt.policy,
+					NodeSelectorRequirements: tt.requirements,
+					Tolerations:              tt.tolerations,
+					ImageRegistry:            tt.registry,
+					ImagePullSecrets:         tt.secrets,
+					Components:               tt.components,
+				},
+			}
+			errs := webhook.validatePlatformAdminSpec(platformAdmin)
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestValidateAdditionalComponentsCap(t *testing.T) {
+	newPlatformAdmin := func(count int) *v1alpha2.PlatformAdmin {
+		deployments := make([]iotv1alpha1.DeploymentTemplateSpec, 0, count)
+		services := make([]iotv1alpha1.ServiceTemplateSpec, 0, count)
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("device-%d", i)
+			deployments = append(deployments, iotv1alpha1.DeploymentTemplateSpec{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			services = append(services, iotv1alpha1.ServiceTemplateSpec{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+		deploymentData, _ := json.Marshal(deployments)
+		serviceData, _ := json.Marshal(services)
+		return &v1alpha2.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "edgex",
+				Annotations: map[string]string{
+					iotv1alpha1.AnnotationAdditionalDeployments: string(deploymentData),
+					iotv1alpha1.AnnotationAdditionalServices:    string(serviceData),
+				},
+			},
+		}
+	}
+
+	// the deployment and service with the same name are counted as one component
+	if errs := validateAdditionalComponents(newPlatformAdmin(config.MaxAdditionalComponents)); len(errs) != 0 {
+		t.Errorf("expect no error at the cap, but got %v", errs)
+	}
+	errs := validateAdditionalComponents(newPlatformAdmin(config.MaxAdditionalComponents + 1))
+	expectMessage := fmt.Sprintf("too many additional components: %d exceeds the maximum %d", config.MaxAdditionalComponents+1, config.MaxAdditionalComponents)
+	if len(errs) != 1 || errs[0].Type != field.ErrorTypeForbidden || errs[0].Detail != expectMessage {
+		t.Errorf("expect error %s, but got %v", expectMessage, errs)
+	}
+}
+
+func TestValidateWorkloadNamespaceLabel(t *testing.T) {
+	platformAdmin := &v1alpha2.PlatformAdmin{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: strings.Repeat("a", 60)},
+		Spec:       v1alpha2.PlatformAdminSpec{WorkloadNamespace: "site-1"},
+	}
+	if errs := validateWorkloadNamespace(platformAdmin); len(errs) == 0 {
+		t.Errorf("expect error of the too long label value, but got nil")
+	}
+	// the label is not needed in the same namespace
+	platformAdmin.Spec.WorkloadNamespace = ""
+	if errs := validateWorkloadNamespace(platformAdmin); len(errs) != 0 {
+		t.Errorf("expect no error, but got %v", errs)
+	}
+}
+
+func TestValidateWorkloadNamespaceUpdate(t *testing.T) {
+	newPlatformAdmin := func(workloadNamespace string) *v1alpha2.PlatformAdmin {
+		return &v1alpha2.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "edgex"},
+			Spec:       v1alpha2.PlatformAdminSpec{WorkloadNamespace: workloadNamespace},
+		}
+	}
+	tests := []struct {
+		name        string
+		old         string
+		new         string
+		expectError bool
+	}{
+		{name: "not changed", old: "site-1", new: "site-1"},
+		{name: "default is set explicitly", old: "", new: "default"},
+		{name: "changed", old: "site-1", new: "site-2", expectError: true},
+		{name: "set after creation", old: "", new: "site-1", expectError: true},
+		{name: "unset after creation", old: "site-1", new: "", expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateWorkloadNamespaceUpdate(newPlatformAdmin(tt.old), newPlatformAdmin(tt.new))
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestValidateSecurityUpdate(t *testing.T) {
+	newPlatformAdmin := func(security bool, currentSecurity *bool) *v1alpha2.PlatformAdmin {
+		return &v1alpha2.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "edgex"},
+			Spec:       v1alpha2.PlatformAdminSpec{Security: security},
+			Status:     v1alpha2.PlatformAdminStatus{CurrentSecurity: currentSecurity},
+		}
+	}
+	secure, insecure := true, false
+	tests := []struct {
+		name        string
+		old         *v1alpha2.PlatformAdmin
+		new         *v1alpha2.PlatformAdmin
+		expectError bool
+	}{
+		{name: "not changed while migrating", old: newPlatformAdmin(true, &insecure), new: newPlatformAdmin(true, &insecure)},
+		{name: "toggled after migration", old: newPlatformAdmin(false, &insecure), new: newPlatformAdmin(true, &insecure)},
+		{name: "toggled before reconciled", old: newPlatformAdmin(false, nil), new: newPlatformAdmin(true, nil)},
+		{name: "toggled while migrating", old: newPlatformAdmin(true, &insecure), new: newPlatformAdmin(false, &insecure), expectError: true},
+		{name: "toggled while migrating to insecure", old: newPlatformAdmin(false, &secure), new: newPlatformAdmin(true, &secure), expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateSecurityUpdate(tt.old, tt.new)
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestValidatePoolClaim(t *testing.T) {
+	newPlatformAdmin := func(namespace, name, poolName string) *v1alpha2.PlatformAdmin {
+		return &v1alpha2.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       v1alpha2.PlatformAdminSpec{PoolName: poolName},
+		}
+	}
+	withPools := func(platformAdmin *v1alpha2.PlatformAdmin, pools ...string) *v1alpha2.PlatformAdmin {
+		platformAdmin.Spec.Pools = pools
+		return platformAdmin
+	}
+	crossNamespace := newPlatformAdmin("edge", "cross", "shenzhen")
+	crossNamespace.Spec.WorkloadNamespace = "default"
+	scheme := runtime.NewScheme()
+	_ = v1alpha2.AddToScheme(scheme)
+	webhook := &PlatformAdminHandler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(newPlatformAdmin("default", "edgex", "hangzhou"), crossNamespace,
+			withPools(newPlatformAdmin("default", "multi", "guangzhou"), "wuhan")).Build(),
+	}
+
+	tests := []struct {
+		name          string
+		platformAdmin *v1alpha2.PlatformAdmin
+		expectError   bool
+	}{
+		{name: "pool is not claimed", platformAdmin: newPlatformAdmin("default", "edgex-b", "beijing")},
+		{name: "pool is claimed in the same namespace", platformAdmin: newPlatformAdmin("default", "edgex-b", "hangzhou"), expectError: true},
+		{name: "pool is claimed in another namespace", platformAdmin: newPlatformAdmin("edge", "edgex", "hangzhou")},
+		{name: "pool is claimed by one generating into the same namespace", platformAdmin: newPlatformAdmin("default", "edgex-b", "shenzhen"), expectError: true},
+		{name: "pool is claimed by itself", platformAdmin: newPlatformAdmin("default", "edgex", "hangzhou")},
+		{name: "one of the pools is claimed", platformAdmin: withPools(newPlatformAdmin("default", "edgex-b", "beijing"), "hangzhou"), expectError: true},
+		{name: "pools are not claimed", platformAdmin: withPools(newPlatformAdmin("default", "edgex-b", "beijing"), "shanghai")},
+		{name: "pool is claimed by the pools of another", platformAdmin: newPlatformAdmin("default", "edgex-c", "wuhan"), expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := webhook.validatePoolClaim(context.TODO(), tt.platformAdmin)
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestValidateComponentSet(t *testing.T) {
+	conf := &config.PlatformAdminControllerConfiguration{
+		NoSectyComponents: map[string][]*config.Component{
+			"levski": {{
+				Name:    "edgex-core-command",
+				Service: &corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 59882, NodePort: 30082}}},
+			}},
+		},
+	}
+	newPlatformAdmin := func(services ...iotv1alpha1.ServiceTemplateSpec) *v1alpha2.PlatformAdmin {
+		data, _ := json.Marshal(services)
+		return &v1alpha2.PlatformAdmin{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "edgex",
+				Annotations: map[string]string{iotv1alpha1.AnnotationAdditionalServices: string(data)},
+			},
+			Spec: v1alpha2.PlatformAdminSpec{Version: "levski"},
+		}
+	}
+	newService := func(name string, nodePort int32) iotv1alpha1.ServiceTemplateSpec {
+		return iotv1alpha1.ServiceTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 59900, NodePort: nodePort}}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		platformAdmin *v1alpha2.PlatformAdmin
+		conf          *config.PlatformAdminControllerConfiguration
+		expectError   bool
+	}{
+		{name: "no collision", platformAdmin: newPlatformAdmin(newService("device-virtual", 30090)), conf: conf},
+		{name: "same name as embed component", platformAdmin: newPlatformAdmin(newService("edgex-core-command", 0)), conf: conf, expectError: true},
+		{name: "same node port as embed component", platformAdmin: newPlatformAdmin(newService("device-virtual", 30082)), conf: conf, expectError: true},
+		{name: "same node port across additional components", platformAdmin: newPlatformAdmin(newService("device-virtual", 30090), newService("device-rest", 30090)), expectError: true},
+		{name: "embed components are unknown", platformAdmin: newPlatformAdmin(newService("edgex-core-command", 30082))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateComponentSet(tt.platformAdmin, tt.conf)
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	conf := &config.PlatformAdminControllerConfiguration{
+		SecurityComponents: map[string][]*config.Component{"levski": {{Name: "edgex-core-command"}}},
+		NoSectyComponents: map[string][]*config.Component{
+			"levski":    {{Name: "edgex-core-command"}},
+			"minnesota": {{Name: "edgex-core-command"}},
+		},
+	}
+	manifests := &Manifest{LatestVersion: "levski", Versions: []string{"levski"}}
+
+	tests := []struct {
+		name        string
+		conf        *config.PlatformAdminControllerConfiguration
+		version     string
+		security    bool
+		expectError bool
+	}{
+		{name: "security mode", conf: conf, version: "levski", security: true},
+		{name: "nosecty mode", conf: conf, version: "minnesota"},
+		{name: "security mode is not supported", conf: conf, version: "minnesota", security: true, expectError: true},
+		{name: "unknown version", conf: conf, version: "hanoi", expectError: true},
+		{name: "manifest without templates", version: "levski", security: true},
+		{name: "unknown version of manifest", version: "minnesota", expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := &PlatformAdminHandler{Manifests: manifests, Configuration: tt.conf}
+			platformAdmin := &v1alpha2.PlatformAdmin{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgex"},
+				Spec:       v1alpha2.PlatformAdminSpec{Version: tt.version, Security: tt.security},
+			}
+			errs := webhook.validateVersion(platformAdmin)
+			if tt.expectError != (len(errs) != 0) {
+				t.Errorf("expect error %v, but got %v", tt.expectError, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// The protection webhook intercepts objects of several kinds, so its paths are not generated from a GVK
+	ProtectionMutatePath   = "/mutate-iot-openyurt-io-v1alpha2-protectedresource"
+	ProtectionValidatePath = "/validate-iot-openyurt-io-v1alpha2-protectedresource"
+)
+
+// SetupWebhookWithManager sets up the protection webhook. mutate path, validatepath, error
+func (webhook *ProtectionHandler) SetupWebhookWithManager(mgr ctrl.Manager) (string, string, error) {
+	mgr.GetWebhookServer().Register(ProtectionValidatePath, &admission.Webhook{Handler: webhook})
+	return ProtectionMutatePath, ProtectionValidatePath, nil
+}
+
+// The webhook fails open, so the configmaps and services of the whole cluster are not blocked while yurt-manager is down.
+// +kubebuilder:webhook:path=/validate-iot-openyurt-io-v1alpha2-protectedresource,mutating=false,failurePolicy=ignore,sideEffects=None,admissionReviewVersions=v1;v1beta1,groups="";apps.openyurt.io,resources=configmaps;services;yurtappsets,verbs=update;delete,versions=v1;v1alpha1,name=validate.iot.v1alpha2.protectedresource.openyurt.io
+
+// ProtectionHandler rejects the changes of the objects generated for the PlatformAdmins with protection enabled,
+// unless they come from yurt-manager.
+type ProtectionHandler struct{}
+
+var _ admission.Handler = &ProtectionHandler{}
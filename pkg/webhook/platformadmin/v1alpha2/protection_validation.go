@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+	"github.com/openyurtio/openyurt/pkg/webhook/util"
+)
+
+// protectionExemptUsers are the controllers of kube-controller-manager which clean up the protected objects,
+// after their PlatformAdmin or namespace is deleted.
+var protectionExemptUsers = []string{
+	serviceaccount.MakeUsername("kube-system", "generic-garbage-collector"),
+	serviceaccount.MakeUsername("kube-system", "namespace-controller"),
+}
+
+// Handle implements admission.Handler, only the metadata of objects is decoded since the webhook serves several kinds.
+func (webhook *ProtectionHandler) Handle(_ context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Update && req.Operation != admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	// The old object decides the protection, so the protection label can not be removed by others either
+	obj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(req.OldObject.Raw, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := validateProtectedObject(obj, req); err != nil {
+		// The request is forbidden like the one denied by RBAC, the message is shown to users by kubectl
+		status := apierrors.NewForbidden(schema.GroupResource{Group: req.Resource.Group, Resource: req.Resource.Resource}, obj.GetName(), err).ErrStatus
+		return admission.Response{AdmissionResponse: admissionv1.AdmissionResponse{Allowed: false, Result: &status}}
+	}
+	return admission.Allowed("")
+}
+
+// validateProtectedObject rejects the request if the generated object is protected and the user is not yurt-manager.
+func validateProtectedObject(obj *metav1.PartialObjectMetadata, req admission.Request) error {
+	labels := obj.GetLabels()
+	if _, ok := labels[v1alpha2.LabelPlatformAdminGenerate]; !ok || labels[v1alpha2.LabelProtected] != "true" {
+		return nil
+	}
+	if isProtectionExempt(req.UserInfo.Username) {
+		return nil
+	}
+	return fmt.Errorf("%s %s/%s is generated and protected by PlatformAdmin, modify the PlatformAdmin instead of %s it directly, "+
+		"or remove annotation %s from the PlatformAdmin", strings.ToLower(req.Kind.Kind), obj.GetNamespace(), obj.GetName(),
+		operationVerb(req.Operation), v1alpha2.AnnotationProtectResources)
+}
+
+// isProtectionExempt checks whether the user is allowed to change the protected objects.
+func isProtectionExempt(username string) bool {
+	if username == serviceaccount.MakeUsername(util.GetNamespace(), util.GetServiceAccountName()) {
+		return true
+	}
+	for _, user := range protectionExemptUsers {
+		if username == user {
+			return true
+		}
+	}
+	return false
+}
+
+func operationVerb(operation admissionv1.Operation) string {
+	if operation == admissionv1.Delete {
+		return "deleting"
+	}
+	return "updating"
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	k8scorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openyurtio/openyurt/pkg/apis/iot/v1alpha2"
+)
+
+func TestProtectionHandler(t *testing.T) {
+	newConfigMap := func(labels map[string]string) runtime.RawExtension {
+		configmap := &k8scorev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "common-variables", Labels: labels},
+		}
+		data, _ := json.Marshal(configmap)
+		return runtime.RawExtension{Raw: data}
+	}
+	protected := map[string]string{v1alpha2.LabelPlatformAdminGenerate: "configmap", v1alpha2.LabelProtected: "true"}
+	const (
+		controller = "system:serviceaccount:kube-system:yurt-manager"
+		user       = "kubernetes-admin"
+	)
+
+	tests := []struct {
+		name        string
+		operation   admissionv1.Operation
+		username    string
+		labels      map[string]string
+		expectAllow bool
+	}{
+		{name: "controller updates protected object", operation: admissionv1.Update, username: controller, labels: protected, expectAllow: true},
+		{name: "controller deletes protected object", operation: admissionv1.Delete, username: controller, labels: protected, expectAllow: true},
+		{name: "garbage collector deletes protected object", operation: admissionv1.Delete, username: "system:serviceaccount:kube-system:generic-garbage-collector", labels: protected, expectAllow: true},
+		{name: "user updates protected object", operation: admissionv1.Update, username: user, labels: protected},
+		{name: "user deletes protected object", operation: admissionv1.Delete, username: user, labels: protected},
+		{name: "service account of another namespace", operation: admissionv1.Update, username: "system:serviceaccount:default:yurt-manager", labels: protected},
+		{name: "user updates unprotected object", operation: admissionv1.Update, username: user, labels: map[string]string{v1alpha2.LabelPlatformAdminGenerate: "configmap"}, expectAllow: true},
+		{name: "user updates object not generated", operation: admissionv1.Update, username: user, labels: map[string]string{v1alpha2.LabelProtected: "true"}, expectAllow: true},
+		{name: "user creates object", operation: admissionv1.Create, username: user, labels: protected, expectAllow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: tt.operation,
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+				UserInfo:  authenticationv1.UserInfo{Username: tt.username},
+			}}
+			if tt.operation != admissionv1.Create {
+				req.OldObject = newConfigMap(tt.labels)
+			}
+			resp := (&ProtectionHandler{}).Handle(context.TODO(), req)
+			if resp.Allowed != tt.expectAllow {
+				t.Fatalf("expect allowed %v, but got %v", tt.expectAllow, resp.Result)
+			}
+			if !tt.expectAllow && !strings.Contains(resp.Result.Message, "modify the PlatformAdmin") {
+				t.Errorf("expect message telling to modify the PlatformAdmin, but got %s", resp.Result.Message)
+			}
+		})
+	}
+}
@@ -59,6 +59,14 @@ func GetServiceName() string {
 	return "yurt-manager-webhook-service"
 }
 
+// GetServiceAccountName returns the name of the service account which yurt-manager runs as.
+func GetServiceAccountName() string {
+	if name := os.Getenv("SERVICE_ACCOUNT_NAME"); len(name) > 0 {
+		return name
+	}
+	return "yurt-manager"
+}
+
 func GetWebHookPort() int {
 	port := 10273
 	if p := os.Getenv("WEBHOOK_PORT"); len(p) > 0 {